@@ -20,7 +20,7 @@ func authenticateEndpoint(svc auth.Service) endpoint.Endpoint {
 			return authenticateRes{}, err
 		}
 
-		return authenticateRes{id: key.Subject, userID: key.User, domainID: key.Domain}, nil
+		return authenticateRes{id: key.Subject, userID: key.User, domainID: key.Domain, serviceAccount: key.ServiceAccount, impersonatorID: key.Impersonator, readOnly: key.ReadOnly}, nil
 	}
 }
 
@@ -1,9 +1,12 @@
 package auth
 
 type authenticateRes struct {
-	id       string
-	userID   string
-	domainID string
+	id             string
+	userID         string
+	domainID       string
+	serviceAccount bool
+	impersonatorID string
+	readOnly       bool
 }
 
 type authorizeRes struct {
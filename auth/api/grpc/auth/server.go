@@ -57,7 +57,7 @@ func decodeAuthenticateRequest(_ context.Context, grpcReq interface{}) (interfac
 
 func encodeAuthenticateResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
 	res := grpcRes.(authenticateRes)
-	return &grpcAuthV1.AuthNRes{Id: res.id, UserId: res.userID, DomainId: res.domainID}, nil
+	return &grpcAuthV1.AuthNRes{Id: res.id, UserId: res.userID, DomainId: res.domainID, ServiceAccount: res.serviceAccount, ImpersonatorId: res.impersonatorID, ReadOnly: res.readOnly}, nil
 }
 
 func decodeAuthorizeRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
@@ -15,9 +15,10 @@ import (
 const tokenSvcName = "token.v1.TokenService"
 
 type tokenGrpcClient struct {
-	issue   endpoint.Endpoint
-	refresh endpoint.Endpoint
-	timeout time.Duration
+	issue          endpoint.Endpoint
+	refresh        endpoint.Endpoint
+	revokeSessions endpoint.Endpoint
+	timeout        time.Duration
 }
 
 var _ grpcTokenV1.TokenServiceClient = (*tokenGrpcClient)(nil)
@@ -41,6 +42,14 @@ func NewTokenClient(conn *grpc.ClientConn, timeout time.Duration) grpcTokenV1.To
 			decodeRefreshResponse,
 			grpcTokenV1.Token{},
 		).Endpoint(),
+		revokeSessions: kitgrpc.NewClient(
+			conn,
+			tokenSvcName,
+			"RevokeSessions",
+			encodeRevokeSessionsRequest,
+			decodeRevokeSessionsResponse,
+			grpcTokenV1.RevokeSessionsRes{},
+		).Endpoint(),
 		timeout: timeout,
 	}
 }
@@ -50,8 +59,14 @@ func (client tokenGrpcClient) Issue(ctx context.Context, req *grpcTokenV1.IssueR
 	defer cancel()
 
 	res, err := client.issue(ctx, issueReq{
-		userID:  req.GetUserId(),
-		keyType: auth.KeyType(req.GetType()),
+		userID:         req.GetUserId(),
+		keyType:        auth.KeyType(req.GetType()),
+		device:         req.GetDevice(),
+		ip:             req.GetIp(),
+		serviceAccount: req.GetServiceAccount(),
+		domainID:       req.GetDomainId(),
+		impersonatorID: req.GetImpersonatorId(),
+		readOnly:       req.GetReadOnly(),
 	})
 	if err != nil {
 		return &grpcTokenV1.Token{}, grpcapi.DecodeError(err)
@@ -62,8 +77,14 @@ func (client tokenGrpcClient) Issue(ctx context.Context, req *grpcTokenV1.IssueR
 func encodeIssueRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(issueReq)
 	return &grpcTokenV1.IssueReq{
-		UserId: req.userID,
-		Type:   uint32(req.keyType),
+		UserId:         req.userID,
+		Type:           uint32(req.keyType),
+		Device:         req.device,
+		Ip:             req.ip,
+		ServiceAccount: req.serviceAccount,
+		DomainId:       req.domainID,
+		ImpersonatorId: req.impersonatorID,
+		ReadOnly:       req.readOnly,
 	}, nil
 }
 
@@ -75,7 +96,7 @@ func (client tokenGrpcClient) Refresh(ctx context.Context, req *grpcTokenV1.Refr
 	ctx, cancel := context.WithTimeout(ctx, client.timeout)
 	defer cancel()
 
-	res, err := client.refresh(ctx, refreshReq{refreshToken: req.GetRefreshToken()})
+	res, err := client.refresh(ctx, refreshReq{refreshToken: req.GetRefreshToken(), ip: req.GetIp()})
 	if err != nil {
 		return &grpcTokenV1.Token{}, grpcapi.DecodeError(err)
 	}
@@ -84,9 +105,29 @@ func (client tokenGrpcClient) Refresh(ctx context.Context, req *grpcTokenV1.Refr
 
 func encodeRefreshRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
 	req := grpcReq.(refreshReq)
-	return &grpcTokenV1.RefreshReq{RefreshToken: req.refreshToken}, nil
+	return &grpcTokenV1.RefreshReq{RefreshToken: req.refreshToken, Ip: req.ip}, nil
 }
 
 func decodeRefreshResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
 	return grpcRes, nil
 }
+
+func (client tokenGrpcClient) RevokeSessions(ctx context.Context, req *grpcTokenV1.RevokeSessionsReq, _ ...grpc.CallOption) (*grpcTokenV1.RevokeSessionsRes, error) {
+	ctx, cancel := context.WithTimeout(ctx, client.timeout)
+	defer cancel()
+
+	res, err := client.revokeSessions(ctx, revokeSessionsReq{userID: req.GetUserId()})
+	if err != nil {
+		return &grpcTokenV1.RevokeSessionsRes{}, grpcapi.DecodeError(err)
+	}
+	return res.(*grpcTokenV1.RevokeSessionsRes), nil
+}
+
+func encodeRevokeSessionsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(revokeSessionsReq)
+	return &grpcTokenV1.RevokeSessionsReq{UserId: req.userID}, nil
+}
+
+func decodeRevokeSessionsResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	return grpcRes, nil
+}
@@ -15,8 +15,14 @@ func issueEndpoint(svc auth.Service) endpoint.Endpoint {
 		}
 
 		key := auth.Key{
-			Type: req.keyType,
-			User: req.userID,
+			Type:           req.keyType,
+			User:           req.userID,
+			Domain:         req.domainID,
+			ServiceAccount: req.serviceAccount,
+			Impersonator:   req.impersonatorID,
+			ReadOnly:       req.readOnly,
+			Device:         req.device,
+			IP:             req.ip,
 		}
 		tkn, err := svc.Issue(ctx, "", key)
 		if err != nil {
@@ -38,7 +44,7 @@ func refreshEndpoint(svc auth.Service) endpoint.Endpoint {
 			return issueRes{}, err
 		}
 
-		key := auth.Key{Type: auth.RefreshKey}
+		key := auth.Key{Type: auth.RefreshKey, IP: req.ip}
 		tkn, err := svc.Issue(ctx, req.refreshToken, key)
 		if err != nil {
 			return issueRes{}, err
@@ -51,3 +57,18 @@ func refreshEndpoint(svc auth.Service) endpoint.Endpoint {
 		return ret, nil
 	}
 }
+
+func revokeSessionsEndpoint(svc auth.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(revokeSessionsReq)
+		if err := req.validate(); err != nil {
+			return revokeSessionsRes{}, err
+		}
+
+		if err := svc.RevokeUserSessions(ctx, req.userID); err != nil {
+			return revokeSessionsRes{}, err
+		}
+
+		return revokeSessionsRes{}, nil
+	}
+}
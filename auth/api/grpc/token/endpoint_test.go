@@ -65,11 +65,15 @@ func TestIssue(t *testing.T) {
 	grpcClient := grpcapi.NewTokenClient(conn, time.Second)
 
 	cases := []struct {
-		desc          string
-		userId        string
-		kind          auth.KeyType
-		issueResponse auth.Token
-		err           error
+		desc           string
+		userId         string
+		kind           auth.KeyType
+		serviceAccount bool
+		domainID       string
+		impersonatorID string
+		readOnly       bool
+		issueResponse  auth.Token
+		err            error
 	}{
 		{
 			desc:   "issue for user with valid token",
@@ -81,6 +85,53 @@ func TestIssue(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			desc:           "issue for service account",
+			userId:         validID,
+			kind:           auth.AccessKey,
+			serviceAccount: true,
+			domainID:       validID,
+			issueResponse: auth.Token{
+				AccessToken:  validToken,
+				RefreshToken: validToken,
+			},
+			err: nil,
+		},
+		{
+			desc:           "issue for service account without domain",
+			userId:         validID,
+			kind:           auth.AccessKey,
+			serviceAccount: true,
+			issueResponse:  auth.Token{},
+			err:            errors.ErrMalformedEntity,
+		},
+		{
+			desc:           "issue read-only impersonation",
+			userId:         validID,
+			kind:           auth.AccessKey,
+			impersonatorID: validID,
+			readOnly:       true,
+			issueResponse: auth.Token{
+				AccessToken: validToken,
+			},
+			err: nil,
+		},
+		{
+			desc:          "issue read-only key without impersonator",
+			userId:        validID,
+			kind:          auth.AccessKey,
+			readOnly:      true,
+			issueResponse: auth.Token{},
+			err:           errors.ErrMalformedEntity,
+		},
+		{
+			desc:           "issue impersonation recovery key",
+			userId:         validID,
+			kind:           auth.RecoveryKey,
+			impersonatorID: validID,
+			issueResponse:  auth.Token{},
+			err:            errors.ErrMalformedEntity,
+		},
 		{
 			desc:   "issue recovery key",
 			userId: validID,
@@ -116,7 +167,7 @@ func TestIssue(t *testing.T) {
 
 	for _, tc := range cases {
 		svcCall := svc.On("Issue", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tc.issueResponse, tc.err)
-		_, err := grpcClient.Issue(context.Background(), &grpcTokenV1.IssueReq{UserId: tc.userId, Type: uint32(tc.kind)})
+		_, err := grpcClient.Issue(context.Background(), &grpcTokenV1.IssueReq{UserId: tc.userId, Type: uint32(tc.kind), ServiceAccount: tc.serviceAccount, DomainId: tc.domainID, ImpersonatorId: tc.impersonatorID, ReadOnly: tc.readOnly})
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 		svcCall.Unset()
 	}
@@ -164,3 +215,41 @@ func TestRefresh(t *testing.T) {
 		svcCall.Unset()
 	}
 }
+
+func TestRevokeSessions(t *testing.T) {
+	conn, err := grpc.NewClient(authAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer conn.Close()
+	assert.Nil(t, err, fmt.Sprintf("Unexpected error creating client connection %s", err))
+	grpcClient := grpcapi.NewTokenClient(conn, time.Second)
+
+	cases := []struct {
+		desc   string
+		userID string
+		svcErr error
+		err    error
+	}{
+		{
+			desc:   "revoke sessions with valid user ID",
+			userID: id,
+			err:    nil,
+		},
+		{
+			desc:   "revoke sessions with empty user ID",
+			userID: "",
+			err:    apiutil.ErrMissingID,
+		},
+		{
+			desc:   "revoke sessions with failed to revoke",
+			userID: id,
+			svcErr: svcerr.ErrNotFound,
+			err:    svcerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		svcCall := svc.On("RevokeUserSessions", mock.Anything, tc.userID).Return(tc.svcErr)
+		_, err := grpcClient.RevokeSessions(context.Background(), &grpcTokenV1.RevokeSessionsReq{UserId: tc.userID})
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		svcCall.Unset()
+	}
+}
@@ -6,8 +6,14 @@ import (
 )
 
 type issueReq struct {
-	userID  string
-	keyType auth.KeyType
+	userID         string
+	keyType        auth.KeyType
+	device         string
+	ip             string
+	serviceAccount bool
+	domainID       string
+	impersonatorID string
+	readOnly       bool
 }
 
 func (req issueReq) validate() error {
@@ -17,12 +23,20 @@ func (req issueReq) validate() error {
 		req.keyType != auth.InvitationKey {
 		return apiutil.ErrInvalidAuthKey
 	}
+	if req.serviceAccount && req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+	// Only the access key impersonates the user.
+	if (req.impersonatorID != "" || req.readOnly) && (req.keyType != auth.AccessKey || req.impersonatorID == "" || req.serviceAccount) {
+		return apiutil.ErrInvalidAuthKey
+	}
 
 	return nil
 }
 
 type refreshReq struct {
 	refreshToken string
+	ip           string
 }
 
 func (req refreshReq) validate() error {
@@ -32,3 +46,15 @@ func (req refreshReq) validate() error {
 
 	return nil
 }
+
+type revokeSessionsReq struct {
+	userID string
+}
+
+func (req revokeSessionsReq) validate() error {
+	if req.userID == "" {
+		return apiutil.ErrMissingID
+	}
+
+	return nil
+}
@@ -5,3 +5,5 @@ type issueRes struct {
 	refreshToken string
 	accessType   string
 }
+
+type revokeSessionsRes struct{}
@@ -19,7 +19,9 @@ func EncodeError(err error) error {
 		errors.Contains(err, svcerr.ErrInvalidPolicy),
 		err == apiutil.ErrInvalidAuthKey,
 		err == apiutil.ErrMissingID,
+		err == apiutil.ErrMissingDomainID,
 		err == apiutil.ErrMissingMemberType,
+		err == apiutil.ErrMissingRoleName,
 		err == apiutil.ErrMissingPolicySub,
 		err == apiutil.ErrMissingPolicyObj,
 		err == apiutil.ErrMalformedPolicyAct:
@@ -82,3 +82,33 @@ func revokeEndpoint(svc auth.Service) endpoint.Endpoint {
 		return revokeKeyRes{}, nil
 	}
 }
+
+func jwksEndpoint(svc auth.Service) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		keys, err := svc.RetrieveJWKS(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if keys == nil {
+			keys = []auth.PublicKeyInfo{}
+		}
+
+		return jwksRes{Keys: keys}, nil
+	}
+}
+
+func revokedSessionsEndpoint(svc auth.Service) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		sessions, err := svc.RetrieveRevokedSessions(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		res := revokedSessionsRes{Sessions: []revokedSession{}}
+		for _, s := range sessions {
+			res.Sessions = append(res.Sessions, revokedSession{ID: s.ID, ExpiresAt: s.ExpiresAt})
+		}
+
+		return res, nil
+	}
+}
@@ -69,9 +69,11 @@ func newService() (auth.Service, *mocks.KeyRepository) {
 	idProvider := uuid.NewMock()
 	pService := new(policymocks.Service)
 	pEvaluator := new(policymocks.Evaluator)
+	sessions := new(mocks.SessionRepository)
+	sessionCache := new(mocks.SessionCache)
 	t := jwt.New([]byte(secret))
 
-	return auth.New(krepo, idProvider, t, pEvaluator, pService, loginDuration, refreshDuration, invalidDuration), krepo
+	return auth.New(krepo, sessions, sessionCache, idProvider, t, pEvaluator, pService, loginDuration, refreshDuration, invalidDuration), krepo
 }
 
 func newServer(svc auth.Service) *httptest.Server {
@@ -331,3 +333,122 @@ func TestRevoke(t *testing.T) {
 		repocall.Unset()
 	}
 }
+
+func TestRetrieveJWKS(t *testing.T) {
+	key := auth.PublicKeyInfo{
+		KeyID:     "kid",
+		KeyType:   "EC",
+		Algorithm: "ES256",
+		Use:       "sig",
+		Curve:     "P-256",
+		X:         "x",
+		Y:         "y",
+	}
+
+	cases := []struct {
+		desc     string
+		keys     []auth.PublicKeyInfo
+		svcErr   error
+		status   int
+		expected string
+	}{
+		{
+			desc:     "retrieve JWKS",
+			keys:     []auth.PublicKeyInfo{key},
+			status:   http.StatusOK,
+			expected: `{"keys":[{"kid":"kid","kty":"EC","alg":"ES256","use":"sig","crv":"P-256","x":"x","y":"y"}]}`,
+		},
+		{
+			desc:     "retrieve JWKS with shared secret tokenizer",
+			status:   http.StatusOK,
+			expected: `{"keys":[]}`,
+		},
+		{
+			desc:   "retrieve JWKS with service error",
+			svcErr: svcerr.ErrViewEntity,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc := new(mocks.Service)
+			ts := newServer(svc)
+			defer ts.Close()
+			svc.On("RetrieveJWKS", mock.Anything).Return(tc.keys, tc.svcErr)
+			req := testRequest{
+				client: ts.Client(),
+				method: http.MethodGet,
+				url:    fmt.Sprintf("%s/.well-known/jwks.json", ts.URL),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			defer res.Body.Close()
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			if tc.expected != "" {
+				body, err := io.ReadAll(res.Body)
+				assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+				assert.JSONEq(t, tc.expected, string(body))
+			}
+		})
+	}
+}
+
+func TestRetrieveRevokedSessions(t *testing.T) {
+	expiresAt := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	session := auth.Session{
+		ID:        "session",
+		UserID:    "user",
+		Device:    "device",
+		IP:        "127.0.0.1",
+		ExpiresAt: expiresAt,
+	}
+
+	cases := []struct {
+		desc     string
+		sessions []auth.Session
+		svcErr   error
+		status   int
+		expected string
+	}{
+		{
+			desc:     "retrieve revoked sessions",
+			sessions: []auth.Session{session},
+			status:   http.StatusOK,
+			expected: `{"sessions":[{"id":"session","expires_at":"2030-01-01T00:00:00Z"}]}`,
+		},
+		{
+			desc:     "retrieve revoked sessions without revoked sessions",
+			status:   http.StatusOK,
+			expected: `{"sessions":[]}`,
+		},
+		{
+			desc:   "retrieve revoked sessions with service error",
+			svcErr: svcerr.ErrViewEntity,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc := new(mocks.Service)
+			ts := newServer(svc)
+			defer ts.Close()
+			svc.On("RetrieveRevokedSessions", mock.Anything).Return(tc.sessions, tc.svcErr)
+			req := testRequest{
+				client: ts.Client(),
+				method: http.MethodGet,
+				url:    fmt.Sprintf("%s/.well-known/revoked-sessions.json", ts.URL),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			defer res.Body.Close()
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			if tc.expected != "" {
+				body, err := io.ReadAll(res.Body)
+				assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+				assert.JSONEq(t, tc.expected, string(body))
+			}
+		})
+	}
+}
@@ -11,6 +11,8 @@ import (
 var (
 	_ mitras.Response = (*issueKeyRes)(nil)
 	_ mitras.Response = (*revokeKeyRes)(nil)
+	_ mitras.Response = (*jwksRes)(nil)
+	_ mitras.Response = (*revokedSessionsRes)(nil)
 )
 
 type issueKeyRes struct {
@@ -66,3 +68,46 @@ func (res revokeKeyRes) Headers() map[string]string {
 func (res revokeKeyRes) Empty() bool {
 	return true
 }
+
+type jwksRes struct {
+	Keys []auth.PublicKeyInfo `json:"keys"`
+}
+
+func (res jwksRes) Code() int {
+	return http.StatusOK
+}
+
+func (res jwksRes) Headers() map[string]string {
+	return map[string]string{
+		"Cache-Control": "public, max-age=300",
+	}
+}
+
+func (res jwksRes) Empty() bool {
+	return false
+}
+
+// revokedSession is published without authentication, like the JWKS, so
+// only the ID and the expiration of the revoked session are exposed.
+type revokedSession struct {
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type revokedSessionsRes struct {
+	Sessions []revokedSession `json:"sessions"`
+}
+
+func (res revokedSessionsRes) Code() int {
+	return http.StatusOK
+}
+
+func (res revokedSessionsRes) Headers() map[string]string {
+	return map[string]string{
+		"Cache-Control": "no-store",
+	}
+}
+
+func (res revokedSessionsRes) Empty() bool {
+	return false
+}
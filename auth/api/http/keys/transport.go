@@ -44,6 +44,21 @@ func MakeHandler(svc auth.Service, mux *chi.Mux, logger *slog.Logger) *chi.Mux {
 			opts...,
 		).ServeHTTP)
 	})
+
+	mux.Get("/.well-known/jwks.json", kithttp.NewServer(
+		jwksEndpoint(svc),
+		kithttp.NopRequestDecoder,
+		api.EncodeResponse,
+		opts...,
+	).ServeHTTP)
+
+	mux.Get("/.well-known/revoked-sessions.json", kithttp.NewServer(
+		revokedSessionsEndpoint(svc),
+		kithttp.NopRequestDecoder,
+		api.EncodeResponse,
+		opts...,
+	).ServeHTTP)
+
 	return mux
 }
 
@@ -0,0 +1,62 @@
+package sessions
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/hantdev/mitras/auth"
+)
+
+func listSessionsEndpoint(svc auth.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listSessionsReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		page, err := svc.ListSessions(ctx, req.token, req.offset, req.limit)
+		if err != nil {
+			return nil, err
+		}
+		if page.Sessions == nil {
+			page.Sessions = []auth.Session{}
+		}
+
+		return listSessionsRes{
+			Total:    page.Total,
+			Offset:   page.Offset,
+			Limit:    page.Limit,
+			Sessions: page.Sessions,
+		}, nil
+	}
+}
+
+func revokeSessionEndpoint(svc auth.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(sessionReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		if err := svc.RevokeSession(ctx, req.token, req.id); err != nil {
+			return nil, err
+		}
+
+		return revokeSessionRes{}, nil
+	}
+}
+
+func revokeAllSessionsEndpoint(svc auth.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(sessionReq)
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+
+		if err := svc.RevokeAllSessions(ctx, req.token); err != nil {
+			return nil, err
+		}
+
+		return revokeSessionRes{}, nil
+	}
+}
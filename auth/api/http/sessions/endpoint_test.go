@@ -0,0 +1,241 @@
+package sessions_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/auth"
+	httpapi "github.com/hantdev/mitras/auth/api/http"
+	"github.com/hantdev/mitras/auth/mocks"
+	smqlog "github.com/hantdev/mitras/logger"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	token     = "token"
+	sessionID = "123e4567-e89b-12d3-a456-000000000001"
+)
+
+type testRequest struct {
+	client *http.Client
+	method string
+	url    string
+	token  string
+}
+
+func (tr testRequest) make() (*http.Response, error) {
+	req, err := http.NewRequest(tr.method, tr.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if tr.token != "" {
+		req.Header.Set("Authorization", apiutil.BearerPrefix+tr.token)
+	}
+
+	return tr.client.Do(req)
+}
+
+func newServer(svc auth.Service) *httptest.Server {
+	mux := httpapi.MakeHandler(svc, smqlog.NewMock(), "")
+	return httptest.NewServer(mux)
+}
+
+func TestListSessions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	session := auth.Session{
+		ID:         sessionID,
+		UserID:     "user",
+		Device:     "Mozilla/5.0",
+		IP:         "192.168.0.1",
+		IssuedAt:   now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+
+	cases := []struct {
+		desc     string
+		token    string
+		query    string
+		offset   uint64
+		limit    uint64
+		page     auth.SessionPage
+		svcErr   error
+		status   int
+		expected string
+	}{
+		{
+			desc:   "list sessions",
+			token:  token,
+			limit:  10,
+			page:   auth.SessionPage{Total: 1, Limit: 10, Sessions: []auth.Session{session}},
+			status: http.StatusOK,
+			expected: `{"total":1,"offset":0,"limit":10,"sessions":[{"id":"123e4567-e89b-12d3-a456-000000000001",` +
+				`"user_id":"user","device":"Mozilla/5.0","ip":"192.168.0.1","issued_at":"2024-01-01T00:00:00Z",` +
+				`"last_used_at":"2024-01-01T00:00:00Z","expires_at":"2024-01-01T01:00:00Z"}]}`,
+		},
+		{
+			desc:     "list sessions with offset and limit",
+			token:    token,
+			query:    "?offset=1&limit=5",
+			offset:   1,
+			limit:    5,
+			page:     auth.SessionPage{Total: 1, Offset: 1, Limit: 5},
+			status:   http.StatusOK,
+			expected: `{"total":1,"offset":1,"limit":5,"sessions":[]}`,
+		},
+		{
+			desc:   "list sessions with invalid limit",
+			token:  token,
+			query:  "?limit=1000",
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "list sessions with invalid offset",
+			token:  token,
+			query:  "?offset=invalid",
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "list sessions with empty token",
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "list sessions with invalid token",
+			token:  "invalid",
+			limit:  10,
+			svcErr: svcerr.ErrAuthentication,
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc := new(mocks.Service)
+			ts := newServer(svc)
+			defer ts.Close()
+			svcCall := svc.On("ListSessions", mock.Anything, tc.token, tc.offset, tc.limit).Return(tc.page, tc.svcErr)
+			req := testRequest{
+				client: ts.Client(),
+				method: http.MethodGet,
+				url:    fmt.Sprintf("%s/sessions%s", ts.URL, tc.query),
+				token:  tc.token,
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			defer res.Body.Close()
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			if tc.expected != "" {
+				body, err := io.ReadAll(res.Body)
+				assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+				assert.JSONEq(t, tc.expected, string(body))
+			}
+			svcCall.Unset()
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	cases := []struct {
+		desc   string
+		token  string
+		id     string
+		svcErr error
+		status int
+	}{
+		{
+			desc:   "revoke session",
+			token:  token,
+			id:     sessionID,
+			status: http.StatusNoContent,
+		},
+		{
+			desc:   "revoke non-existing session",
+			token:  token,
+			id:     "invalid",
+			svcErr: svcerr.ErrNotFound,
+			status: http.StatusNotFound,
+		},
+		{
+			desc:   "revoke session with empty token",
+			id:     sessionID,
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "revoke session with invalid token",
+			token:  "invalid",
+			id:     sessionID,
+			svcErr: svcerr.ErrAuthentication,
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc := new(mocks.Service)
+			ts := newServer(svc)
+			defer ts.Close()
+			svc.On("RevokeSession", mock.Anything, tc.token, tc.id).Return(tc.svcErr)
+			req := testRequest{
+				client: ts.Client(),
+				method: http.MethodDelete,
+				url:    fmt.Sprintf("%s/sessions/%s", ts.URL, tc.id),
+				token:  tc.token,
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			defer res.Body.Close()
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		})
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	cases := []struct {
+		desc   string
+		token  string
+		svcErr error
+		status int
+	}{
+		{
+			desc:   "revoke all sessions",
+			token:  token,
+			status: http.StatusNoContent,
+		},
+		{
+			desc:   "revoke all sessions with empty token",
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "revoke all sessions with invalid token",
+			token:  "invalid",
+			svcErr: svcerr.ErrAuthentication,
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc := new(mocks.Service)
+			ts := newServer(svc)
+			defer ts.Close()
+			svc.On("RevokeAllSessions", mock.Anything, tc.token).Return(tc.svcErr)
+			req := testRequest{
+				client: ts.Client(),
+				method: http.MethodDelete,
+				url:    fmt.Sprintf("%s/sessions", ts.URL),
+				token:  tc.token,
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			defer res.Body.Close()
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		})
+	}
+}
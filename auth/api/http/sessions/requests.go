@@ -0,0 +1,37 @@
+package sessions
+
+import (
+	"github.com/hantdev/mitras/internal/api"
+	"github.com/hantdev/mitras/pkg/apiutil"
+)
+
+type listSessionsReq struct {
+	token  string
+	offset uint64
+	limit  uint64
+}
+
+func (req listSessionsReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.limit > api.MaxLimitSize || req.limit < 1 {
+		return apiutil.ErrLimitSize
+	}
+
+	return nil
+}
+
+type sessionReq struct {
+	token string
+	id    string
+}
+
+func (req sessionReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	return nil
+}
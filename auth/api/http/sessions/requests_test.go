@@ -0,0 +1,84 @@
+package sessions
+
+import (
+	"testing"
+
+	"github.com/hantdev/mitras/pkg/apiutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var valid = "valid"
+
+func TestListSessionsReqValidate(t *testing.T) {
+	cases := []struct {
+		desc string
+		req  listSessionsReq
+		err  error
+	}{
+		{
+			desc: "valid request",
+			req: listSessionsReq{
+				token: valid,
+				limit: 10,
+			},
+			err: nil,
+		},
+		{
+			desc: "empty token",
+			req: listSessionsReq{
+				token: "",
+				limit: 10,
+			},
+			err: apiutil.ErrBearerToken,
+		},
+		{
+			desc: "zero limit",
+			req: listSessionsReq{
+				token: valid,
+				limit: 0,
+			},
+			err: apiutil.ErrLimitSize,
+		},
+		{
+			desc: "limit too big",
+			req: listSessionsReq{
+				token: valid,
+				limit: 101,
+			},
+			err: apiutil.ErrLimitSize,
+		},
+	}
+	for _, tc := range cases {
+		err := tc.req.validate()
+		assert.Equal(t, tc.err, err)
+	}
+}
+
+func TestSessionReqValidate(t *testing.T) {
+	cases := []struct {
+		desc string
+		req  sessionReq
+		err  error
+	}{
+		{
+			desc: "valid request",
+			req: sessionReq{
+				token: valid,
+				id:    valid,
+			},
+			err: nil,
+		},
+		{
+			desc: "empty token",
+			req: sessionReq{
+				token: "",
+				id:    valid,
+			},
+			err: apiutil.ErrBearerToken,
+		},
+	}
+	for _, tc := range cases {
+		err := tc.req.validate()
+		assert.Equal(t, tc.err, err)
+	}
+}
@@ -0,0 +1,46 @@
+package sessions
+
+import (
+	"net/http"
+
+	"github.com/hantdev/mitras"
+	"github.com/hantdev/mitras/auth"
+)
+
+var (
+	_ mitras.Response = (*listSessionsRes)(nil)
+	_ mitras.Response = (*revokeSessionRes)(nil)
+)
+
+type listSessionsRes struct {
+	Total    uint64         `json:"total"`
+	Offset   uint64         `json:"offset"`
+	Limit    uint64         `json:"limit"`
+	Sessions []auth.Session `json:"sessions"`
+}
+
+func (res listSessionsRes) Code() int {
+	return http.StatusOK
+}
+
+func (res listSessionsRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res listSessionsRes) Empty() bool {
+	return false
+}
+
+type revokeSessionRes struct{}
+
+func (res revokeSessionRes) Code() int {
+	return http.StatusNoContent
+}
+
+func (res revokeSessionRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res revokeSessionRes) Empty() bool {
+	return true
+}
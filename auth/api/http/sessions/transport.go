@@ -0,0 +1,73 @@
+package sessions
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/hantdev/mitras/auth"
+	"github.com/hantdev/mitras/internal/api"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	"github.com/hantdev/mitras/pkg/errors"
+)
+
+// MakeHandler returns a HTTP handler for API endpoints.
+func MakeHandler(svc auth.Service, mux *chi.Mux, logger *slog.Logger) *chi.Mux {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(apiutil.LoggingErrorEncoder(logger, api.EncodeError)),
+	}
+	mux.Route("/sessions", func(r chi.Router) {
+		r.Get("/", kithttp.NewServer(
+			listSessionsEndpoint(svc),
+			decodeListSessions,
+			api.EncodeResponse,
+			opts...,
+		).ServeHTTP)
+
+		r.Delete("/", kithttp.NewServer(
+			revokeAllSessionsEndpoint(svc),
+			decodeSessionReq,
+			api.EncodeResponse,
+			opts...,
+		).ServeHTTP)
+
+		r.Delete("/{id}", kithttp.NewServer(
+			revokeSessionEndpoint(svc),
+			decodeSessionReq,
+			api.EncodeResponse,
+			opts...,
+		).ServeHTTP)
+	})
+
+	return mux
+}
+
+func decodeListSessions(_ context.Context, r *http.Request) (interface{}, error) {
+	offset, err := apiutil.ReadNumQuery[uint64](r, api.OffsetKey, api.DefOffset)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+	limit, err := apiutil.ReadNumQuery[uint64](r, api.LimitKey, api.DefLimit)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	req := listSessionsReq{
+		token:  apiutil.ExtractBearerToken(r),
+		offset: offset,
+		limit:  limit,
+	}
+
+	return req, nil
+}
+
+func decodeSessionReq(_ context.Context, r *http.Request) (interface{}, error) {
+	req := sessionReq{
+		token: apiutil.ExtractBearerToken(r),
+		id:    chi.URLParam(r, "id"),
+	}
+
+	return req, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/hantdev/mitras/auth"
 	"github.com/hantdev/mitras/auth/api/http/keys"
+	"github.com/hantdev/mitras/auth/api/http/sessions"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -16,6 +17,7 @@ func MakeHandler(svc auth.Service, logger *slog.Logger, instanceID string) http.
 	mux := chi.NewRouter()
 
 	mux = keys.MakeHandler(svc, mux, logger)
+	mux = sessions.MakeHandler(svc, mux, logger)
 
 	mux.Get("/health", mitras.Health("auth", instanceID))
 	mux.Handle("/metrics", promhttp.Handler())
@@ -97,6 +97,111 @@ func (lm *loggingMiddleware) Identify(ctx context.Context, token string) (id aut
 	return lm.svc.Identify(ctx, token)
 }
 
+func (lm *loggingMiddleware) RetrieveJWKS(ctx context.Context) (keys []auth.PublicKeyInfo, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Int("keys", len(keys)),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Retrieve JWKS failed", args...)
+			return
+		}
+		lm.logger.Info("Retrieve JWKS completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.RetrieveJWKS(ctx)
+}
+
+func (lm *loggingMiddleware) RetrieveRevokedSessions(ctx context.Context) (sessions []auth.Session, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Int("sessions", len(sessions)),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Retrieve revoked sessions failed", args...)
+			return
+		}
+		lm.logger.Info("Retrieve revoked sessions completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.RetrieveRevokedSessions(ctx)
+}
+
+func (lm *loggingMiddleware) ListSessions(ctx context.Context, token string, offset, limit uint64) (page auth.SessionPage, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("page",
+				slog.Uint64("offset", offset),
+				slog.Uint64("limit", limit),
+				slog.Uint64("total", page.Total),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("List sessions failed", args...)
+			return
+		}
+		lm.logger.Info("List sessions completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ListSessions(ctx, token, offset, limit)
+}
+
+func (lm *loggingMiddleware) RevokeSession(ctx context.Context, token, id string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("session_id", id),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Revoke session failed", args...)
+			return
+		}
+		lm.logger.Info("Revoke session completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.RevokeSession(ctx, token, id)
+}
+
+func (lm *loggingMiddleware) RevokeAllSessions(ctx context.Context, token string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Revoke all sessions failed", args...)
+			return
+		}
+		lm.logger.Info("Revoke all sessions completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.RevokeAllSessions(ctx, token)
+}
+
+func (lm *loggingMiddleware) RevokeUserSessions(ctx context.Context, userID string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("user_id", userID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Revoke user sessions failed", args...)
+			return
+		}
+		lm.logger.Info("Revoke user sessions completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.RevokeUserSessions(ctx, userID)
+}
+
 func (lm *loggingMiddleware) Authorize(ctx context.Context, pr policies.Policy) (err error) {
 	defer func(begin time.Time) {
 		args := []any{
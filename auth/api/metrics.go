@@ -64,6 +64,60 @@ func (ms *metricsMiddleware) Identify(ctx context.Context, token string) (auth.K
 	return ms.svc.Identify(ctx, token)
 }
 
+func (ms *metricsMiddleware) RetrieveJWKS(ctx context.Context) ([]auth.PublicKeyInfo, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "retrieve_jwks").Add(1)
+		ms.latency.With("method", "retrieve_jwks").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.RetrieveJWKS(ctx)
+}
+
+func (ms *metricsMiddleware) RetrieveRevokedSessions(ctx context.Context) ([]auth.Session, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "retrieve_revoked_sessions").Add(1)
+		ms.latency.With("method", "retrieve_revoked_sessions").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.RetrieveRevokedSessions(ctx)
+}
+
+func (ms *metricsMiddleware) ListSessions(ctx context.Context, token string, offset, limit uint64) (auth.SessionPage, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "list_sessions").Add(1)
+		ms.latency.With("method", "list_sessions").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ListSessions(ctx, token, offset, limit)
+}
+
+func (ms *metricsMiddleware) RevokeSession(ctx context.Context, token, id string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "revoke_session").Add(1)
+		ms.latency.With("method", "revoke_session").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.RevokeSession(ctx, token, id)
+}
+
+func (ms *metricsMiddleware) RevokeAllSessions(ctx context.Context, token string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "revoke_all_sessions").Add(1)
+		ms.latency.With("method", "revoke_all_sessions").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.RevokeAllSessions(ctx, token)
+}
+
+func (ms *metricsMiddleware) RevokeUserSessions(ctx context.Context, userID string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "revoke_user_sessions").Add(1)
+		ms.latency.With("method", "revoke_user_sessions").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.RevokeUserSessions(ctx, userID)
+}
+
 func (ms *metricsMiddleware) Authorize(ctx context.Context, pr policies.Policy) error {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "authorize").Add(1)
@@ -0,0 +1,2 @@
+// Package cache contains the in-memory denylist of the revoked sessions.
+package cache
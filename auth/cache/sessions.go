@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/hantdev/mitras/auth"
+)
+
+var _ auth.SessionCache = (*sessionCache)(nil)
+
+type sessionCache struct {
+	repo   auth.SessionRepository
+	logger *slog.Logger
+
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+// NewSessionCache returns the in-memory denylist of the revoked sessions.
+// The denylist is loaded from the repository and reloaded every interval
+// until the context is canceled, so the sessions revoked by the other Auth
+// service instances are denied at most an interval later. The expired
+// sessions are removed from the repository on reload.
+func NewSessionCache(ctx context.Context, repo auth.SessionRepository, interval time.Duration, logger *slog.Logger) (auth.SessionCache, error) {
+	c := &sessionCache{
+		repo:    repo,
+		logger:  logger,
+		revoked: make(map[string]time.Time),
+	}
+	if err := c.load(ctx); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.repo.RemoveExpired(ctx); err != nil {
+					c.logger.Warn(fmt.Sprintf("failed to remove expired sessions: %s", err))
+				}
+				if err := c.load(ctx); err != nil {
+					c.logger.Warn(fmt.Sprintf("failed to load revoked sessions: %s", err))
+				}
+			}
+		}
+	}()
+
+	return c, nil
+}
+
+func (c *sessionCache) Revoke(sessions ...auth.Session) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range sessions {
+		c.revoked[s.ID] = s.ExpiresAt
+	}
+}
+
+func (c *sessionCache) Revoked(id string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.revoked[id]
+
+	return ok
+}
+
+func (c *sessionCache) load(ctx context.Context) error {
+	sessions, err := c.repo.RetrieveRevoked(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Keep the sessions revoked locally meanwhile, until they expire.
+	for id, exp := range c.revoked {
+		if !exp.After(now) {
+			delete(c.revoked, id)
+		}
+	}
+	for _, s := range sessions {
+		c.revoked[s.ID] = s.ExpiresAt
+	}
+
+	return nil
+}
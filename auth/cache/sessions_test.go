@@ -0,0 +1,54 @@
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/auth"
+	"github.com/hantdev/mitras/auth/cache"
+	"github.com/hantdev/mitras/auth/mocks"
+	smqlog "github.com/hantdev/mitras/logger"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	exp := time.Now().UTC().Add(time.Hour)
+	stored := auth.Session{ID: "stored", ExpiresAt: exp}
+	remote := auth.Session{ID: "remote", ExpiresAt: exp}
+
+	repo := new(mocks.SessionRepository)
+	loadCall := repo.On("RetrieveRevoked", mock.Anything).Return([]auth.Session{stored}, nil).Once()
+	repo.On("RemoveExpired", mock.Anything).Return(nil)
+
+	c, err := cache.NewSessionCache(ctx, repo, 10*time.Millisecond, smqlog.NewMock())
+	require.Nil(t, err, fmt.Sprintf("new session cache unexpected error: %s", err))
+	assert.True(t, c.Revoked(stored.ID), "expected stored session to be revoked")
+	assert.False(t, c.Revoked(remote.ID), "expected remote session not to be revoked")
+
+	c.Revoke(auth.Session{ID: "local", ExpiresAt: exp}, auth.Session{ID: "expired", ExpiresAt: time.Now().UTC()})
+	assert.True(t, c.Revoked("local"), "expected local session to be revoked")
+
+	// The sessions revoked by the other instances are loaded on reload,
+	// while the expired sessions are dropped.
+	loadCall.Unset()
+	repo.On("RetrieveRevoked", mock.Anything).Return([]auth.Session{stored, remote}, nil)
+	assert.Eventually(t, func() bool { return c.Revoked(remote.ID) }, time.Second, 10*time.Millisecond)
+	assert.True(t, c.Revoked("local"), "expected local session to be revoked")
+	assert.False(t, c.Revoked("expired"), "expected expired session to be removed")
+}
+
+func TestNewSessionCache(t *testing.T) {
+	repo := new(mocks.SessionRepository)
+	repo.On("RetrieveRevoked", mock.Anything).Return(nil, repoerr.ErrViewEntity)
+
+	_, err := cache.NewSessionCache(context.Background(), repo, time.Minute, smqlog.NewMock())
+	assert.Equal(t, repoerr.ErrViewEntity, err)
+}
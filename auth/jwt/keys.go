@@ -0,0 +1,446 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/hantdev/mitras/auth"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const (
+	rsaKeySize = 2048
+	// refreshInterval is the interval of reloading the signing keys, so the
+	// keys rotated by the other instances are picked up.
+	refreshInterval = time.Minute
+	// minRefreshInterval limits reloading the signing keys on the tokens
+	// signed with an unknown key.
+	minRefreshInterval = 10 * time.Second
+)
+
+var (
+	// ErrUnsupportedAlgorithm indicates the signature algorithm isn't supported.
+	ErrUnsupportedAlgorithm = errors.New("unsupported signature algorithm")
+	// ErrInvalidRotation indicates the invalid key rotation period.
+	ErrInvalidRotation = errors.New("invalid key rotation period")
+	// ErrMissingKEK indicates the missing key encryption key.
+	ErrMissingKEK = errors.New("missing signing key encryption key")
+
+	errLoadKeys    = errors.New("failed to load signing keys")
+	errGenerateKey = errors.New("failed to generate signing key")
+	errUnknownKey  = errors.New("unknown signing key")
+	errDecryptKey  = errors.New("failed to decrypt signing key")
+)
+
+// Config represents the asymmetric tokenizer configuration.
+type Config struct {
+	// Algorithm is the signature algorithm, one of RS256, ES256 or EdDSA.
+	Algorithm string `env:"ALGORITHM"    envDefault:"HS512"`
+
+	// Rotation is the period after which the new signing key is generated.
+	Rotation time.Duration `env:"ROTATION"     envDefault:"720h"`
+
+	// GracePeriod is the period the rotated key is still used to verify
+	// the tokens. The key is kept longer if it signed the tokens which
+	// expire later.
+	GracePeriod time.Duration `env:"GRACE_PERIOD" envDefault:"24h"`
+
+	// KEK is the key encryption key of the private signing keys stored in
+	// the repository.
+	KEK string `env:"KEK"          envDefault:""`
+}
+
+// Asymmetric reports whether the configuration uses the asymmetric keys
+// instead of the shared secret.
+func (cfg Config) Asymmetric() bool {
+	return cfg.Algorithm != jwa.HS512.String()
+}
+
+type signingKey struct {
+	auth.SigningKey
+	private jwk.Key
+}
+
+type keyTokenizer struct {
+	alg         jwa.SignatureAlgorithm
+	rotation    time.Duration
+	gracePeriod time.Duration
+	repo        auth.SigningKeyRepository
+	secret      []byte
+	kek         cipher.AEAD
+
+	mu         sync.RWMutex
+	current    *signingKey
+	public     jwk.Set
+	loadedAt   time.Time
+	reloadedAt time.Time
+}
+
+var _ auth.Tokenizer = (*keyTokenizer)(nil)
+
+// NewKeyTokenizer instantiates the tokenizer which signs the tokens with the
+// asymmetric keys stored in the repository. The signing key is rotated every
+// rotation period, and the rotated keys are used to verify the tokens until
+// they expire. The tokens without the key ID are verified using the shared
+// secret, if set, so the tokens issued before switching to the asymmetric
+// keys remain valid. The private keys are stored encrypted with the key
+// encryption key.
+func NewKeyTokenizer(cfg Config, repo auth.SigningKeyRepository, secret []byte) (auth.Tokenizer, error) {
+	alg := jwa.SignatureAlgorithm(cfg.Algorithm)
+	switch alg {
+	case jwa.RS256, jwa.ES256, jwa.EdDSA:
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if cfg.Rotation <= 0 || cfg.GracePeriod < 0 {
+		return nil, ErrInvalidRotation
+	}
+	if cfg.KEK == "" {
+		return nil, ErrMissingKEK
+	}
+	// The AES-256 key is derived from the configured key, so the key of any
+	// length can be used.
+	kek := sha256.Sum256([]byte(cfg.KEK))
+	block, err := aes.NewCipher(kek[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyTokenizer{
+		alg:         alg,
+		rotation:    cfg.Rotation,
+		gracePeriod: cfg.GracePeriod,
+		repo:        repo,
+		secret:      secret,
+		kek:         aead,
+	}, nil
+}
+
+func (tok *keyTokenizer) Issue(key auth.Key) (string, error) {
+	sk, err := tok.signingKey()
+	if err != nil {
+		return "", errors.Wrap(ErrSignJWT, err)
+	}
+
+	tkn, err := build(key)
+	if err != nil {
+		return "", errors.Wrap(svcerr.ErrAuthentication, err)
+	}
+	if err := tok.extend(sk, key.ExpiresAt); err != nil {
+		return "", errors.Wrap(ErrSignJWT, err)
+	}
+	signedTkn, err := jwt.Sign(tkn, jwt.WithKey(tok.alg, sk.private))
+	if err != nil {
+		return "", errors.Wrap(ErrSignJWT, err)
+	}
+
+	return string(signedTkn), nil
+}
+
+func (tok *keyTokenizer) Parse(token string) (auth.Key, error) {
+	msg, err := jws.Parse([]byte(token))
+	if err != nil || len(msg.Signatures()) != 1 {
+		return auth.Key{}, errors.Wrap(svcerr.ErrAuthentication, ErrValidateJWTToken)
+	}
+
+	var opt jwt.ParseOption
+	switch kid := msg.Signatures()[0].ProtectedHeaders().KeyID(); {
+	case kid == "" && len(tok.secret) > 0:
+		opt = jwt.WithKey(jwa.HS512, tok.secret)
+	default:
+		set, err := tok.keySet(kid)
+		if err != nil {
+			return auth.Key{}, errors.Wrap(svcerr.ErrAuthentication, err)
+		}
+		opt = jwt.WithKeySet(set)
+	}
+
+	tkn, err := validate(token, opt)
+	if err != nil {
+		return auth.Key{}, errors.Wrap(svcerr.ErrAuthentication, err)
+	}
+
+	key, err := toKey(tkn)
+	if err != nil {
+		return auth.Key{}, errors.Wrap(svcerr.ErrAuthentication, err)
+	}
+
+	return key, nil
+}
+
+func (tok *keyTokenizer) RetrieveJWKS() ([]auth.PublicKeyInfo, error) {
+	tok.mu.Lock()
+	defer tok.mu.Unlock()
+
+	if time.Since(tok.loadedAt) >= refreshInterval {
+		if err := tok.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	var keys []auth.PublicKeyInfo
+	for i := 0; i < tok.public.Len(); i++ {
+		k, _ := tok.public.Key(i)
+		info, err := toPublicKeyInfo(k)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, info)
+	}
+
+	return keys, nil
+}
+
+// signingKey returns the current signing key, and rotates it if it's not
+// active anymore.
+func (tok *keyTokenizer) signingKey() (*signingKey, error) {
+	tok.mu.RLock()
+	sk := tok.current
+	stale := time.Since(tok.loadedAt) >= refreshInterval
+	tok.mu.RUnlock()
+	if sk != nil && !stale && time.Now().Before(sk.ActiveUntil) {
+		return sk, nil
+	}
+
+	tok.mu.Lock()
+	defer tok.mu.Unlock()
+	if err := tok.load(); err != nil {
+		return nil, err
+	}
+
+	return tok.current, nil
+}
+
+// keySet returns the public keys, and reloads them if the key ID is unknown.
+func (tok *keyTokenizer) keySet(kid string) (jwk.Set, error) {
+	tok.mu.RLock()
+	set := tok.public
+	tok.mu.RUnlock()
+	if set != nil {
+		if _, ok := set.LookupKeyID(kid); ok {
+			return set, nil
+		}
+	}
+
+	tok.mu.Lock()
+	defer tok.mu.Unlock()
+	if tok.public == nil || time.Since(tok.reloadedAt) >= minRefreshInterval {
+		tok.reloadedAt = time.Now()
+		if err := tok.load(); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := tok.public.LookupKeyID(kid); !ok {
+		return nil, errUnknownKey
+	}
+
+	return tok.public, nil
+}
+
+// load reloads the signing keys from the repository, and generates the new
+// signing key if none of the keys is active. It must be called holding the
+// write lock.
+func (tok *keyTokenizer) load() error {
+	ctx := context.Background()
+	if err := tok.repo.RemoveExpired(ctx); err != nil {
+		return errors.Wrap(errLoadKeys, err)
+	}
+	keys, err := tok.repo.RetrieveAll(ctx)
+	if err != nil {
+		return errors.Wrap(errLoadKeys, err)
+	}
+
+	var current *signingKey
+	public := jwk.NewSet()
+	now := time.Now()
+	for _, k := range keys {
+		if k.PrivateKey, err = tok.decrypt(k); err != nil {
+			return errors.Wrap(errLoadKeys, err)
+		}
+		sk, err := toSigningKey(k)
+		if err != nil {
+			return errors.Wrap(errLoadKeys, err)
+		}
+		pk, err := sk.private.PublicKey()
+		if err != nil {
+			return errors.Wrap(errLoadKeys, err)
+		}
+		if err := public.AddKey(pk); err != nil {
+			return errors.Wrap(errLoadKeys, err)
+		}
+		if k.Algorithm == tok.alg.String() && now.Before(k.ActiveUntil) && (current == nil || k.CreatedAt.After(current.CreatedAt)) {
+			current = sk
+		}
+	}
+
+	if current == nil {
+		sk, err := tok.generate(now)
+		if err != nil {
+			return err
+		}
+		pk, err := sk.private.PublicKey()
+		if err != nil {
+			return errors.Wrap(errGenerateKey, err)
+		}
+		if err := public.AddKey(pk); err != nil {
+			return errors.Wrap(errGenerateKey, err)
+		}
+		current = sk
+	}
+
+	tok.current = current
+	tok.public = public
+	tok.loadedAt = now
+
+	return nil
+}
+
+func (tok *keyTokenizer) generate(now time.Time) (*signingKey, error) {
+	var raw crypto.Signer
+	var err error
+	switch tok.alg {
+	case jwa.RS256:
+		raw, err = rsa.GenerateKey(rand.Reader, rsaKeySize)
+	case jwa.ES256:
+		raw, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jwa.EdDSA:
+		_, raw, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, errors.Wrap(errGenerateKey, err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(raw)
+	if err != nil {
+		return nil, errors.Wrap(errGenerateKey, err)
+	}
+
+	key := auth.SigningKey{
+		Algorithm:   tok.alg.String(),
+		PrivateKey:  der,
+		CreatedAt:   now.UTC(),
+		ActiveUntil: now.Add(tok.rotation).UTC(),
+		ExpiresAt:   now.Add(tok.rotation + tok.gracePeriod).UTC(),
+	}
+	sk, err := toSigningKey(key)
+	if err != nil {
+		return nil, errors.Wrap(errGenerateKey, err)
+	}
+	encrypted := sk.SigningKey
+	if encrypted.PrivateKey, err = tok.encrypt(encrypted); err != nil {
+		return nil, errors.Wrap(errGenerateKey, err)
+	}
+	if err := tok.repo.Save(context.Background(), encrypted); err != nil {
+		return nil, errors.Wrap(errGenerateKey, err)
+	}
+
+	return sk, nil
+}
+
+// encrypt returns the private key encrypted with the key encryption key, and
+// prefixed with the nonce. The key ID is authenticated with the private key,
+// so the encrypted private keys can't be swapped between the keys.
+func (tok *keyTokenizer) encrypt(key auth.SigningKey) ([]byte, error) {
+	nonce := make([]byte, tok.kek.NonceSize(), tok.kek.NonceSize()+len(key.PrivateKey)+tok.kek.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return tok.kek.Seal(nonce, nonce, key.PrivateKey, []byte(key.ID)), nil
+}
+
+// decrypt returns the private key decrypted with the key encryption key.
+func (tok *keyTokenizer) decrypt(key auth.SigningKey) ([]byte, error) {
+	if len(key.PrivateKey) < tok.kek.NonceSize() {
+		return nil, errDecryptKey
+	}
+	nonce, ciphertext := key.PrivateKey[:tok.kek.NonceSize()], key.PrivateKey[tok.kek.NonceSize():]
+	der, err := tok.kek.Open(nil, nonce, ciphertext, []byte(key.ID))
+	if err != nil {
+		return nil, errors.Wrap(errDecryptKey, err)
+	}
+
+	return der, nil
+}
+
+// extend makes sure the signing key isn't removed before the token expires.
+func (tok *keyTokenizer) extend(sk *signingKey, expiresAt time.Time) error {
+	tok.mu.RLock()
+	covered := !sk.ExpiresAt.IsZero() && !expiresAt.IsZero() && !expiresAt.After(sk.ExpiresAt)
+	tok.mu.RUnlock()
+	if covered || sk.ExpiresAt.IsZero() {
+		return nil
+	}
+
+	if err := tok.repo.Extend(context.Background(), sk.ID, expiresAt.UTC()); err != nil {
+		return err
+	}
+	tok.mu.Lock()
+	sk.ExpiresAt = expiresAt.UTC()
+	tok.mu.Unlock()
+
+	return nil
+}
+
+// toSigningKey parses the private key, and sets the key ID to the key
+// thumbprint if it's not set.
+func toSigningKey(key auth.SigningKey) (*signingKey, error) {
+	raw, err := x509.ParsePKCS8PrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	private, err := jwk.FromRaw(raw)
+	if err != nil {
+		return nil, err
+	}
+	if key.ID == "" {
+		if err := jwk.AssignKeyID(private); err != nil {
+			return nil, err
+		}
+		key.ID = private.KeyID()
+	}
+	if err := private.Set(jwk.KeyIDKey, key.ID); err != nil {
+		return nil, err
+	}
+	if err := private.Set(jwk.AlgorithmKey, jwa.SignatureAlgorithm(key.Algorithm)); err != nil {
+		return nil, err
+	}
+	if err := private.Set(jwk.KeyUsageKey, jwk.ForSignature); err != nil {
+		return nil, err
+	}
+
+	return &signingKey{SigningKey: key, private: private}, nil
+}
+
+func toPublicKeyInfo(key jwk.Key) (auth.PublicKeyInfo, error) {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return auth.PublicKeyInfo{}, errors.Wrap(ErrJSONHandle, err)
+	}
+	var info auth.PublicKeyInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return auth.PublicKeyInfo{}, errors.Wrap(ErrJSONHandle, err)
+	}
+
+	return info, nil
+}
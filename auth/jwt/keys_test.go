@@ -0,0 +1,243 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/auth"
+	authjwt "github.com/hantdev/mitras/auth/jwt"
+	"github.com/hantdev/mitras/auth/mocks"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newSigningKeyRepo returns the signing key repository mock which keeps the
+// saved keys in memory.
+func newSigningKeyRepo() *mocks.SigningKeyRepository {
+	var mu sync.Mutex
+	keys := make(map[string]auth.SigningKey)
+
+	repo := new(mocks.SigningKeyRepository)
+	repo.On("Save", mock.Anything, mock.Anything).Return(func(_ context.Context, key auth.SigningKey) error {
+		mu.Lock()
+		defer mu.Unlock()
+		keys[key.ID] = key
+		return nil
+	})
+	repo.On("RetrieveAll", mock.Anything).Return(func(context.Context) ([]auth.SigningKey, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		var ret []auth.SigningKey
+		for _, k := range keys {
+			ret = append(ret, k)
+		}
+		return ret, nil
+	})
+	repo.On("Extend", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, id string, expiresAt time.Time) error {
+		mu.Lock()
+		defer mu.Unlock()
+		k := keys[id]
+		k.ExpiresAt = expiresAt
+		keys[id] = k
+		return nil
+	})
+	repo.On("RemoveExpired", mock.Anything).Return(nil)
+
+	return repo
+}
+
+const kek = "key-encryption-key"
+
+func keyID(t *testing.T, token string) string {
+	msg, err := jws.Parse([]byte(token))
+	require.Nil(t, err, fmt.Sprintf("parse token unexpected error: %s", err))
+	return msg.Signatures()[0].ProtectedHeaders().KeyID()
+}
+
+func TestKeyTokenizer(t *testing.T) {
+	for _, alg := range []string{"RS256", "ES256", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			tokenizer, err := authjwt.NewKeyTokenizer(authjwt.Config{Algorithm: alg, Rotation: time.Hour, GracePeriod: time.Hour, KEK: kek}, newSigningKeyRepo(), nil)
+			require.Nil(t, err, fmt.Sprintf("new tokenizer unexpected error: %s", err))
+
+			k := key()
+			tkn, err := tokenizer.Issue(k)
+			require.Nil(t, err, fmt.Sprintf("issue token unexpected error: %s", err))
+
+			parsed, err := tokenizer.Parse(tkn)
+			assert.Nil(t, err, fmt.Sprintf("parse token unexpected error: %s", err))
+			assert.Equal(t, k, parsed)
+
+			jwks, err := tokenizer.RetrieveJWKS()
+			assert.Nil(t, err, fmt.Sprintf("retrieve JWKS unexpected error: %s", err))
+			require.Len(t, jwks, 1)
+			assert.Equal(t, keyID(t, tkn), jwks[0].KeyID)
+			assert.Equal(t, alg, jwks[0].Algorithm)
+			assert.Equal(t, "sig", jwks[0].Use)
+		})
+	}
+}
+
+func TestKeyTokenizerConfig(t *testing.T) {
+	cases := []struct {
+		desc string
+		cfg  authjwt.Config
+		err  error
+	}{
+		{
+			desc: "create tokenizer with shared secret algorithm",
+			cfg:  authjwt.Config{Algorithm: "HS512", Rotation: time.Hour},
+			err:  authjwt.ErrUnsupportedAlgorithm,
+		},
+		{
+			desc: "create tokenizer without rotation period",
+			cfg:  authjwt.Config{Algorithm: "ES256", KEK: kek},
+			err:  authjwt.ErrInvalidRotation,
+		},
+		{
+			desc: "create tokenizer without key encryption key",
+			cfg:  authjwt.Config{Algorithm: "ES256", Rotation: time.Hour},
+			err:  authjwt.ErrMissingKEK,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := authjwt.NewKeyTokenizer(tc.cfg, newSigningKeyRepo(), nil)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("expected error %s, got %s", tc.err, err))
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	repo := newSigningKeyRepo()
+	tokenizer, err := authjwt.NewKeyTokenizer(authjwt.Config{Algorithm: "ES256", Rotation: 50 * time.Millisecond, GracePeriod: time.Hour, KEK: kek}, repo, nil)
+	require.Nil(t, err, fmt.Sprintf("new tokenizer unexpected error: %s", err))
+
+	k := key()
+	first, err := tokenizer.Issue(k)
+	require.Nil(t, err, fmt.Sprintf("issue token unexpected error: %s", err))
+	time.Sleep(100 * time.Millisecond)
+	second, err := tokenizer.Issue(k)
+	require.Nil(t, err, fmt.Sprintf("issue token unexpected error: %s", err))
+	assert.NotEqual(t, keyID(t, first), keyID(t, second), "expected the signing key to be rotated")
+
+	// Tokens signed with the rotated key are verified during the grace period.
+	for _, tkn := range []string{first, second} {
+		parsed, err := tokenizer.Parse(tkn)
+		assert.Nil(t, err, fmt.Sprintf("parse token unexpected error: %s", err))
+		assert.Equal(t, k, parsed)
+	}
+
+	jwks, err := tokenizer.RetrieveJWKS()
+	assert.Nil(t, err, fmt.Sprintf("retrieve JWKS unexpected error: %s", err))
+	assert.Len(t, jwks, 2)
+
+	// The other instance sharing the repository verifies the tokens.
+	other, err := authjwt.NewKeyTokenizer(authjwt.Config{Algorithm: "ES256", Rotation: time.Hour, GracePeriod: time.Hour, KEK: kek}, repo, nil)
+	require.Nil(t, err, fmt.Sprintf("new tokenizer unexpected error: %s", err))
+	_, err = other.Parse(second)
+	assert.Nil(t, err, fmt.Sprintf("parse token unexpected error: %s", err))
+
+	// The instance with the different keys rejects the tokens.
+	unknown, err := authjwt.NewKeyTokenizer(authjwt.Config{Algorithm: "ES256", Rotation: time.Hour, GracePeriod: time.Hour, KEK: kek}, newSigningKeyRepo(), nil)
+	require.Nil(t, err, fmt.Sprintf("new tokenizer unexpected error: %s", err))
+	_, err = unknown.Parse(second)
+	assert.True(t, errors.Contains(err, svcerr.ErrAuthentication), fmt.Sprintf("expected error %s, got %s", svcerr.ErrAuthentication, err))
+}
+
+func TestKeyExtension(t *testing.T) {
+	repo := newSigningKeyRepo()
+	tokenizer, err := authjwt.NewKeyTokenizer(authjwt.Config{Algorithm: "ES256", Rotation: time.Hour, GracePeriod: time.Hour, KEK: kek}, repo, nil)
+	require.Nil(t, err, fmt.Sprintf("new tokenizer unexpected error: %s", err))
+
+	k := key()
+	_, err = tokenizer.Issue(k)
+	require.Nil(t, err, fmt.Sprintf("issue token unexpected error: %s", err))
+	repo.AssertNotCalled(t, "Extend", mock.Anything, mock.Anything, mock.Anything)
+
+	// The key which signed the token without expiration never expires.
+	k.Type = auth.APIKey
+	k.ExpiresAt = time.Time{}
+	tkn, err := tokenizer.Issue(k)
+	require.Nil(t, err, fmt.Sprintf("issue token unexpected error: %s", err))
+	repo.AssertCalled(t, "Extend", mock.Anything, keyID(t, tkn), time.Time{})
+}
+
+func TestKeyTokenizerLegacy(t *testing.T) {
+	k := key()
+	legacy, err := authjwt.New([]byte(secret)).Issue(k)
+	require.Nil(t, err, fmt.Sprintf("issue token unexpected error: %s", err))
+
+	cases := []struct {
+		desc   string
+		secret []byte
+		err    error
+	}{
+		{
+			desc:   "parse token signed with shared secret",
+			secret: []byte(secret),
+			err:    nil,
+		},
+		{
+			desc:   "parse token signed with shared secret without secret",
+			secret: nil,
+			err:    svcerr.ErrAuthentication,
+		},
+		{
+			desc:   "parse token signed with different shared secret",
+			secret: []byte("other"),
+			err:    svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tokenizer, err := authjwt.NewKeyTokenizer(authjwt.Config{Algorithm: "ES256", Rotation: time.Hour, KEK: kek}, newSigningKeyRepo(), tc.secret)
+			require.Nil(t, err, fmt.Sprintf("new tokenizer unexpected error: %s", err))
+			_, err = tokenizer.Parse(legacy)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("expected error %s, got %s", tc.err, err))
+		})
+	}
+}
+
+func TestKeyEncryption(t *testing.T) {
+	repo := newSigningKeyRepo()
+	tokenizer, err := authjwt.NewKeyTokenizer(authjwt.Config{Algorithm: "ES256", Rotation: time.Hour, GracePeriod: time.Hour, KEK: kek}, repo, nil)
+	require.Nil(t, err, fmt.Sprintf("new tokenizer unexpected error: %s", err))
+
+	tkn, err := tokenizer.Issue(key())
+	require.Nil(t, err, fmt.Sprintf("issue token unexpected error: %s", err))
+
+	keys, err := repo.RetrieveAll(context.Background())
+	require.Nil(t, err, fmt.Sprintf("retrieve keys unexpected error: %s", err))
+	require.Len(t, keys, 1)
+	_, err = x509.ParsePKCS8PrivateKey(keys[0].PrivateKey)
+	assert.NotNil(t, err, "expected the stored private key to be encrypted")
+
+	// The instance with the different key encryption key can't load the keys.
+	other, err := authjwt.NewKeyTokenizer(authjwt.Config{Algorithm: "ES256", Rotation: time.Hour, GracePeriod: time.Hour, KEK: "other"}, repo, nil)
+	require.Nil(t, err, fmt.Sprintf("new tokenizer unexpected error: %s", err))
+	_, err = other.Parse(tkn)
+	assert.True(t, errors.Contains(err, svcerr.ErrAuthentication), fmt.Sprintf("expected error %s, got %s", svcerr.ErrAuthentication, err))
+	_, err = other.Issue(key())
+	assert.True(t, errors.Contains(err, authjwt.ErrSignJWT), fmt.Sprintf("expected error %s, got %s", authjwt.ErrSignJWT, err))
+
+	// The encrypted private key is bound to the key ID.
+	swapped := keys[0]
+	swapped.ID = "other"
+	repo = newSigningKeyRepo()
+	require.Nil(t, repo.Save(context.Background(), swapped))
+	tokenizer, err = authjwt.NewKeyTokenizer(authjwt.Config{Algorithm: "ES256", Rotation: time.Hour, GracePeriod: time.Hour, KEK: kek}, repo, nil)
+	require.Nil(t, err, fmt.Sprintf("new tokenizer unexpected error: %s", err))
+	_, err = tokenizer.RetrieveJWKS()
+	assert.NotNil(t, err, "expected the swapped private key to be rejected")
+}
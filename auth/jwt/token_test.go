@@ -159,6 +159,22 @@ func TestParse(t *testing.T) {
 	emptyToken, err := tokenizer.Issue(emptyKey)
 	require.Nil(t, err, fmt.Sprintf("issuing user key expected to succeed: %s", err))
 
+	sessionKey := key()
+	sessionKey.Session = "66af4a67-3823-438a-abd7-efdb613eaef7"
+	sessionToken, err := tokenizer.Issue(sessionKey)
+	require.Nil(t, err, fmt.Sprintf("issuing user key expected to succeed: %s", err))
+
+	serviceAccountKey := key()
+	serviceAccountKey.ServiceAccount = true
+	serviceAccountToken, err := tokenizer.Issue(serviceAccountKey)
+	require.Nil(t, err, fmt.Sprintf("issuing service account key expected to succeed: %s", err))
+
+	impersonationKey := key()
+	impersonationKey.Impersonator = "admin"
+	impersonationKey.ReadOnly = true
+	impersonationToken, err := tokenizer.Issue(impersonationKey)
+	require.Nil(t, err, fmt.Sprintf("issuing impersonation key expected to succeed: %s", err))
+
 	inValidToken := newToken("invalid", key())
 
 	cases := []struct {
@@ -173,6 +189,24 @@ func TestParse(t *testing.T) {
 			token: token,
 			err:   nil,
 		},
+		{
+			desc:  "parse key with session",
+			key:   sessionKey,
+			token: sessionToken,
+			err:   nil,
+		},
+		{
+			desc:  "parse service account key",
+			key:   serviceAccountKey,
+			token: serviceAccountToken,
+			err:   nil,
+		},
+		{
+			desc:  "parse impersonation key",
+			key:   impersonationKey,
+			token: impersonationToken,
+			err:   nil,
+		},
 		{
 			desc:  "parse invalid key",
 			key:   auth.Key{},
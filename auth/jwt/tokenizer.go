@@ -29,6 +29,11 @@ const (
 	issuerName             = "mitras.auth"
 	tokenType              = "type"
 	userField              = "user"
+	sessionField           = "session"
+	domainField            = "domain"
+	serviceAccountField    = "service_account"
+	impersonatorField      = "impersonator"
+	readOnlyField          = "read_only"
 	oauthProviderField     = "oauth_provider"
 	oauthAccessTokenField  = "access_token"
 	oauthRefreshTokenField = "refresh_token"
@@ -48,20 +53,7 @@ func New(secret []byte) auth.Tokenizer {
 }
 
 func (tok *tokenizer) Issue(key auth.Key) (string, error) {
-	builder := jwt.NewBuilder()
-	builder.
-		Issuer(issuerName).
-		IssuedAt(key.IssuedAt).
-		Claim(tokenType, key.Type).
-		Expiration(key.ExpiresAt)
-	builder.Claim(userField, key.User)
-	if key.Subject != "" {
-		builder.Subject(key.Subject)
-	}
-	if key.ID != "" {
-		builder.JwtID(key.ID)
-	}
-	tkn, err := builder.Build()
+	tkn, err := build(key)
 	if err != nil {
 		return "", errors.Wrap(svcerr.ErrAuthentication, err)
 	}
@@ -86,11 +78,54 @@ func (tok *tokenizer) Parse(token string) (auth.Key, error) {
 	return key, nil
 }
 
+// RetrieveJWKS returns no keys, since the tokens are signed with the shared secret.
+func (tok *tokenizer) RetrieveJWKS() ([]auth.PublicKeyInfo, error) {
+	return nil, nil
+}
+
 func (tok *tokenizer) validateToken(token string) (jwt.Token, error) {
+	return validate(token, jwt.WithKey(jwa.HS512, tok.secret))
+}
+
+func build(key auth.Key) (jwt.Token, error) {
+	builder := jwt.NewBuilder()
+	builder.
+		Issuer(issuerName).
+		IssuedAt(key.IssuedAt).
+		Claim(tokenType, key.Type).
+		Expiration(key.ExpiresAt)
+	builder.Claim(userField, key.User)
+	if key.Subject != "" {
+		builder.Subject(key.Subject)
+	}
+	if key.ID != "" {
+		builder.JwtID(key.ID)
+	}
+	if key.Session != "" {
+		builder.Claim(sessionField, key.Session)
+	}
+	// The service account token is bound to the domain owning the
+	// service account.
+	if key.ServiceAccount {
+		builder.Claim(serviceAccountField, true)
+		builder.Claim(domainField, key.Domain)
+	}
+	// The impersonation token carries both the impersonated user and the
+	// super admin impersonating the user.
+	if key.Impersonator != "" {
+		builder.Claim(impersonatorField, key.Impersonator)
+		if key.ReadOnly {
+			builder.Claim(readOnlyField, true)
+		}
+	}
+	return builder.Build()
+}
+
+func validate(token string, opt jwt.ParseOption) (jwt.Token, error) {
 	tkn, err := jwt.Parse(
 		[]byte(token),
 		jwt.WithValidate(true),
-		jwt.WithKey(jwa.HS512, tok.secret),
+		opt,
 	)
 	if err != nil {
 		if errors.Contains(err, errJWTExpiryKey) {
@@ -53,9 +53,23 @@ type Key struct {
 	Issuer    string    `json:"issuer,omitempty"`
 	Subject   string    `json:"subject,omitempty"` // user ID
 	User      string    `json:"user,omitempty"`
-	Domain    string    `json:"domain,omitempty"` // domain user ID
+	Domain    string    `json:"domain,omitempty"`  // domain user ID
+	Session   string    `json:"session,omitempty"` // session ID of the access and refresh keys
 	IssuedAt  time.Time `json:"issued_at,omitempty"`
 	ExpiresAt time.Time `json:"expires_at,omitempty"`
+
+	// ServiceAccount is true if the user is a service account.
+	ServiceAccount bool `json:"service_account,omitempty"`
+
+	// Impersonator is the ID of the super admin impersonating the user, and
+	// ReadOnly limits the impersonation to the reads.
+	Impersonator string `json:"impersonator,omitempty"`
+	ReadOnly     bool   `json:"read_only,omitempty"`
+
+	// Device and IP describe the client which logged in. They are stored
+	// with the session created on login and aren't a part of the token.
+	Device string `json:"-"`
+	IP     string `json:"-"`
 }
 
 func (key Key) String() string {
@@ -66,9 +80,10 @@ func (key Key) String() string {
 	subject: %s,
 	user: %s,
 	domain: %s,
+	session: %s,
 	iat: %v,
 	eat: %v
-}`, key.ID, key.Type, key.Issuer, key.Subject, key.User, key.Domain, key.IssuedAt, key.ExpiresAt)
+}`, key.ID, key.Type, key.Issuer, key.Subject, key.User, key.Domain, key.Session, key.IssuedAt, key.ExpiresAt)
 }
 
 // Expired verifies if the key is expired.
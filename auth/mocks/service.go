@@ -91,6 +91,64 @@ func (_m *Service) Issue(ctx context.Context, token string, key auth.Key) (auth.
 	return r0, r1
 }
 
+// ListSessions provides a mock function with given fields: ctx, token, offset, limit
+func (_m *Service) ListSessions(ctx context.Context, token string, offset uint64, limit uint64) (auth.SessionPage, error) {
+	ret := _m.Called(ctx, token, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSessions")
+	}
+
+	var r0 auth.SessionPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint64, uint64) (auth.SessionPage, error)); ok {
+		return rf(ctx, token, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint64, uint64) auth.SessionPage); ok {
+		r0 = rf(ctx, token, offset, limit)
+	} else {
+		r0 = ret.Get(0).(auth.SessionPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint64, uint64) error); ok {
+		r1 = rf(ctx, token, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RetrieveJWKS provides a mock function with given fields: ctx
+func (_m *Service) RetrieveJWKS(ctx context.Context) ([]auth.PublicKeyInfo, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveJWKS")
+	}
+
+	var r0 []auth.PublicKeyInfo
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]auth.PublicKeyInfo, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []auth.PublicKeyInfo); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]auth.PublicKeyInfo)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RetrieveKey provides a mock function with given fields: ctx, token, id
 func (_m *Service) RetrieveKey(ctx context.Context, token string, id string) (auth.Key, error) {
 	ret := _m.Called(ctx, token, id)
@@ -119,6 +177,36 @@ func (_m *Service) RetrieveKey(ctx context.Context, token string, id string) (au
 	return r0, r1
 }
 
+// RetrieveRevokedSessions provides a mock function with given fields: ctx
+func (_m *Service) RetrieveRevokedSessions(ctx context.Context) ([]auth.Session, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveRevokedSessions")
+	}
+
+	var r0 []auth.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]auth.Session, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []auth.Session); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]auth.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Revoke provides a mock function with given fields: ctx, token, id
 func (_m *Service) Revoke(ctx context.Context, token string, id string) error {
 	ret := _m.Called(ctx, token, id)
@@ -137,6 +225,60 @@ func (_m *Service) Revoke(ctx context.Context, token string, id string) error {
 	return r0
 }
 
+// RevokeAllSessions provides a mock function with given fields: ctx, token
+func (_m *Service) RevokeAllSessions(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAllSessions")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeSession provides a mock function with given fields: ctx, token, id
+func (_m *Service) RevokeSession(ctx context.Context, token string, id string) error {
+	ret := _m.Called(ctx, token, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, token, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeUserSessions provides a mock function with given fields: ctx, userID
+func (_m *Service) RevokeUserSessions(ctx context.Context, userID string) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeUserSessions")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewService creates a new instance of Service. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewService(t interface {
@@ -0,0 +1,56 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	auth "github.com/hantdev/mitras/auth"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SessionCache is an autogenerated mock type for the SessionCache type
+type SessionCache struct {
+	mock.Mock
+}
+
+// Revoke provides a mock function with given fields: sessions
+func (_m *SessionCache) Revoke(sessions ...auth.Session) {
+	_va := make([]interface{}, len(sessions))
+	for _i := range sessions {
+		_va[_i] = sessions[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
+// Revoked provides a mock function with given fields: id
+func (_m *SessionCache) Revoked(id string) bool {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Revoked")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// NewSessionCache creates a new instance of SessionCache. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSessionCache(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SessionCache {
+	mock := &SessionCache{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,179 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	auth "github.com/hantdev/mitras/auth"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SessionRepository is an autogenerated mock type for the SessionRepository type
+type SessionRepository struct {
+	mock.Mock
+}
+
+// Refresh provides a mock function with given fields: ctx, session
+func (_m *SessionRepository) Refresh(ctx context.Context, session auth.Session) error {
+	ret := _m.Called(ctx, session)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Refresh")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, auth.Session) error); ok {
+		r0 = rf(ctx, session)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveExpired provides a mock function with given fields: ctx
+func (_m *SessionRepository) RemoveExpired(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveExpired")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RetrieveAll provides a mock function with given fields: ctx, userID, offset, limit
+func (_m *SessionRepository) RetrieveAll(ctx context.Context, userID string, offset uint64, limit uint64) (auth.SessionPage, error) {
+	ret := _m.Called(ctx, userID, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveAll")
+	}
+
+	var r0 auth.SessionPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint64, uint64) (auth.SessionPage, error)); ok {
+		return rf(ctx, userID, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint64, uint64) auth.SessionPage); ok {
+		r0 = rf(ctx, userID, offset, limit)
+	} else {
+		r0 = ret.Get(0).(auth.SessionPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint64, uint64) error); ok {
+		r1 = rf(ctx, userID, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RetrieveRevoked provides a mock function with given fields: ctx
+func (_m *SessionRepository) RetrieveRevoked(ctx context.Context) ([]auth.Session, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveRevoked")
+	}
+
+	var r0 []auth.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]auth.Session, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []auth.Session); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]auth.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Revoke provides a mock function with given fields: ctx, userID, ids
+func (_m *SessionRepository) Revoke(ctx context.Context, userID string, ids ...string) ([]auth.Session, error) {
+	_va := make([]interface{}, len(ids))
+	for _i := range ids {
+		_va[_i] = ids[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, userID)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Revoke")
+	}
+
+	var r0 []auth.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...string) ([]auth.Session, error)); ok {
+		return rf(ctx, userID, ids...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...string) []auth.Session); ok {
+		r0 = rf(ctx, userID, ids...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]auth.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, ...string) error); ok {
+		r1 = rf(ctx, userID, ids...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, session
+func (_m *SessionRepository) Save(ctx context.Context, session auth.Session) error {
+	ret := _m.Called(ctx, session)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, auth.Session) error); ok {
+		r0 = rf(ctx, session)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewSessionRepository creates a new instance of SessionRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSessionRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SessionRepository {
+	mock := &SessionRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -62,6 +62,41 @@ func Migration() *migrate.MemoryMigrationSource {
                     `,
 				},
 			},
+			{
+				Id: "auth_4",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS signing_keys (
+                        id           VARCHAR(254) PRIMARY KEY,
+                        algorithm    VARCHAR(16) NOT NULL,
+                        private_key  BYTEA NOT NULL,
+                        created_at   TIMESTAMP NOT NULL,
+                        active_until TIMESTAMP NOT NULL,
+                        expires_at   TIMESTAMP
+                    )`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS signing_keys`,
+				},
+			},
+			{
+				Id: "auth_5",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS sessions (
+                        id           VARCHAR(36) PRIMARY KEY,
+                        user_id      VARCHAR(36) NOT NULL,
+                        device       TEXT NOT NULL DEFAULT '',
+                        ip           VARCHAR(45) NOT NULL DEFAULT '',
+                        issued_at    TIMESTAMP NOT NULL,
+                        last_used_at TIMESTAMP NOT NULL,
+                        expires_at   TIMESTAMP NOT NULL,
+                        revoked_at   TIMESTAMP
+                    )`,
+					`CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS sessions`,
+				},
+			},
 		},
 	}
 }
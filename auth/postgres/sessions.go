@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hantdev/mitras/auth"
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/hantdev/mitras/pkg/postgres"
+)
+
+var _ auth.SessionRepository = (*sessionRepo)(nil)
+
+type sessionRepo struct {
+	db postgres.Database
+}
+
+// NewSessionRepository instantiates a PostgreSQL implementation of session
+// repository.
+func NewSessionRepository(db postgres.Database) auth.SessionRepository {
+	return &sessionRepo{
+		db: db,
+	}
+}
+
+func (sr *sessionRepo) Save(ctx context.Context, session auth.Session) error {
+	q := `INSERT INTO sessions (id, user_id, device, ip, issued_at, last_used_at, expires_at)
+	      VALUES (:id, :user_id, :device, :ip, :issued_at, :last_used_at, :expires_at)`
+
+	if _, err := sr.db.NamedExecContext(ctx, q, toDBSession(session)); err != nil {
+		return postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+func (sr *sessionRepo) RetrieveAll(ctx context.Context, userID string, offset, limit uint64) (auth.SessionPage, error) {
+	q := `SELECT id, user_id, device, ip, issued_at, last_used_at, expires_at, revoked_at FROM sessions
+	      WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+	      ORDER BY last_used_at DESC LIMIT $3 OFFSET $4`
+
+	now := time.Now().UTC()
+	rows, err := sr.db.QueryxContext(ctx, q, userID, now, limit, offset)
+	if err != nil {
+		return auth.SessionPage{}, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	sessions := []auth.Session{}
+	for rows.Next() {
+		var dbs dbSession
+		if err := rows.StructScan(&dbs); err != nil {
+			return auth.SessionPage{}, postgres.HandleError(repoerr.ErrViewEntity, err)
+		}
+		sessions = append(sessions, toSession(dbs))
+	}
+
+	cq := `SELECT COUNT(*) FROM sessions WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2`
+	var total uint64
+	if err := sr.db.QueryRowxContext(ctx, cq, userID, now).Scan(&total); err != nil {
+		return auth.SessionPage{}, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+
+	return auth.SessionPage{
+		Total:    total,
+		Offset:   offset,
+		Limit:    limit,
+		Sessions: sessions,
+	}, nil
+}
+
+func (sr *sessionRepo) Refresh(ctx context.Context, session auth.Session) error {
+	q := `UPDATE sessions SET last_used_at = :last_used_at, expires_at = :expires_at,
+	      ip = COALESCE(NULLIF(:ip, ''), ip)
+	      WHERE id = :id AND revoked_at IS NULL AND expires_at > :now`
+
+	params := map[string]interface{}{
+		"id":           session.ID,
+		"ip":           session.IP,
+		"last_used_at": session.LastUsedAt,
+		"expires_at":   session.ExpiresAt,
+		"now":          time.Now().UTC(),
+	}
+	result, err := sr.db.NamedExecContext(ctx, q, params)
+	if err != nil {
+		return postgres.HandleError(repoerr.ErrUpdateEntity, err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
+func (sr *sessionRepo) Revoke(ctx context.Context, userID string, ids ...string) ([]auth.Session, error) {
+	q := `UPDATE sessions SET revoked_at = :now
+	      WHERE user_id = :user_id AND revoked_at IS NULL AND expires_at > :now`
+	if len(ids) > 0 {
+		q += ` AND id = ANY(:ids)`
+	}
+	q += ` RETURNING id, user_id, device, ip, issued_at, last_used_at, expires_at, revoked_at`
+
+	params := map[string]interface{}{
+		"user_id": userID,
+		"ids":     ids,
+		"now":     time.Now().UTC(),
+	}
+	rows, err := sr.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return nil, postgres.HandleError(repoerr.ErrRemoveEntity, err)
+	}
+	defer rows.Close()
+
+	var sessions []auth.Session
+	for rows.Next() {
+		var dbs dbSession
+		if err := rows.StructScan(&dbs); err != nil {
+			return nil, postgres.HandleError(repoerr.ErrRemoveEntity, err)
+		}
+		sessions = append(sessions, toSession(dbs))
+	}
+	if len(ids) > 0 && len(sessions) == 0 {
+		return nil, repoerr.ErrNotFound
+	}
+
+	return sessions, nil
+}
+
+func (sr *sessionRepo) RetrieveRevoked(ctx context.Context) ([]auth.Session, error) {
+	q := `SELECT id, user_id, device, ip, issued_at, last_used_at, expires_at, revoked_at FROM sessions
+	      WHERE revoked_at IS NOT NULL AND expires_at > $1`
+
+	rows, err := sr.db.QueryxContext(ctx, q, time.Now().UTC())
+	if err != nil {
+		return nil, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	var sessions []auth.Session
+	for rows.Next() {
+		var dbs dbSession
+		if err := rows.StructScan(&dbs); err != nil {
+			return nil, postgres.HandleError(repoerr.ErrViewEntity, err)
+		}
+		sessions = append(sessions, toSession(dbs))
+	}
+
+	return sessions, nil
+}
+
+func (sr *sessionRepo) RemoveExpired(ctx context.Context) error {
+	q := `DELETE FROM sessions WHERE expires_at <= $1`
+	if _, err := sr.db.ExecContext(ctx, q, time.Now().UTC()); err != nil {
+		return errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+
+	return nil
+}
+
+type dbSession struct {
+	ID         string       `db:"id"`
+	UserID     string       `db:"user_id"`
+	Device     string       `db:"device"`
+	IP         string       `db:"ip"`
+	IssuedAt   time.Time    `db:"issued_at"`
+	LastUsedAt time.Time    `db:"last_used_at"`
+	ExpiresAt  time.Time    `db:"expires_at"`
+	RevokedAt  sql.NullTime `db:"revoked_at"`
+}
+
+func toDBSession(session auth.Session) dbSession {
+	return dbSession{
+		ID:         session.ID,
+		UserID:     session.UserID,
+		Device:     session.Device,
+		IP:         session.IP,
+		IssuedAt:   session.IssuedAt,
+		LastUsedAt: session.LastUsedAt,
+		ExpiresAt:  session.ExpiresAt,
+	}
+}
+
+func toSession(dbs dbSession) auth.Session {
+	return auth.Session{
+		ID:         dbs.ID,
+		UserID:     dbs.UserID,
+		Device:     dbs.Device,
+		IP:         dbs.IP,
+		IssuedAt:   dbs.IssuedAt,
+		LastUsedAt: dbs.LastUsedAt,
+		ExpiresAt:  dbs.ExpiresAt,
+	}
+}
@@ -0,0 +1,102 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/auth"
+	"github.com/hantdev/mitras/auth/postgres"
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func session(t *testing.T, userID string, lastUsedAt time.Time) auth.Session {
+	return auth.Session{
+		ID:         generateID(t),
+		UserID:     userID,
+		Device:     "Mozilla/5.0",
+		IP:         "192.168.0.1",
+		IssuedAt:   lastUsedAt,
+		LastUsedAt: lastUsedAt,
+		ExpiresAt:  lastUsedAt.Add(24 * time.Hour),
+	}
+}
+
+func TestSessions(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := db.Exec("DELETE FROM sessions")
+		require.Nil(t, err, fmt.Sprintf("clean sessions unexpected error: %s", err))
+	})
+	repo := postgres.NewSessionRepository(database)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	userID := generateID(t)
+	first := session(t, userID, now.Add(-time.Hour))
+	second := session(t, userID, now)
+	other := session(t, generateID(t), now)
+	expired := session(t, userID, now.Add(-48*time.Hour))
+
+	for _, s := range []auth.Session{first, second, other, expired} {
+		err := repo.Save(ctx, s)
+		assert.Nil(t, err, fmt.Sprintf("save session unexpected error: %s", err))
+	}
+	err := repo.Save(ctx, first)
+	assert.True(t, errors.Contains(err, repoerr.ErrConflict), fmt.Sprintf("expected error %s, got %s", repoerr.ErrConflict, err))
+
+	page, err := repo.RetrieveAll(ctx, userID, 0, 10)
+	assert.Nil(t, err, fmt.Sprintf("retrieve sessions unexpected error: %s", err))
+	assert.Equal(t, uint64(2), page.Total)
+	assert.Equal(t, []auth.Session{second, first}, page.Sessions)
+
+	page, err = repo.RetrieveAll(ctx, userID, 1, 10)
+	assert.Nil(t, err, fmt.Sprintf("retrieve sessions unexpected error: %s", err))
+	assert.Equal(t, []auth.Session{first}, page.Sessions)
+
+	// Refresh keeps the IP if the new one isn't provided.
+	first.LastUsedAt = now.Add(time.Minute)
+	first.ExpiresAt = first.LastUsedAt.Add(24 * time.Hour)
+	err = repo.Refresh(ctx, auth.Session{ID: first.ID, LastUsedAt: first.LastUsedAt, ExpiresAt: first.ExpiresAt})
+	assert.Nil(t, err, fmt.Sprintf("refresh session unexpected error: %s", err))
+	page, err = repo.RetrieveAll(ctx, userID, 0, 10)
+	assert.Nil(t, err, fmt.Sprintf("retrieve sessions unexpected error: %s", err))
+	assert.Equal(t, []auth.Session{first, second}, page.Sessions)
+
+	err = repo.Refresh(ctx, auth.Session{ID: expired.ID, LastUsedAt: now, ExpiresAt: now.Add(time.Hour)})
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+
+	revoked, err := repo.Revoke(ctx, userID, first.ID)
+	assert.Nil(t, err, fmt.Sprintf("revoke session unexpected error: %s", err))
+	assert.Equal(t, []auth.Session{first}, revoked)
+
+	_, err = repo.Revoke(ctx, userID, first.ID)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+	_, err = repo.Revoke(ctx, userID, other.ID)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+
+	err = repo.Refresh(ctx, auth.Session{ID: first.ID, LastUsedAt: now, ExpiresAt: now.Add(time.Hour)})
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+
+	revoked, err = repo.Revoke(ctx, userID)
+	assert.Nil(t, err, fmt.Sprintf("revoke sessions unexpected error: %s", err))
+	assert.Equal(t, []auth.Session{second}, revoked)
+
+	revoked, err = repo.RetrieveRevoked(ctx)
+	assert.Nil(t, err, fmt.Sprintf("retrieve revoked sessions unexpected error: %s", err))
+	assert.ElementsMatch(t, []auth.Session{first, second}, revoked)
+
+	page, err = repo.RetrieveAll(ctx, userID, 0, 10)
+	assert.Nil(t, err, fmt.Sprintf("retrieve sessions unexpected error: %s", err))
+	assert.Equal(t, uint64(0), page.Total)
+
+	err = repo.RemoveExpired(ctx)
+	assert.Nil(t, err, fmt.Sprintf("remove expired sessions unexpected error: %s", err))
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&count)
+	assert.Nil(t, err, fmt.Sprintf("count sessions unexpected error: %s", err))
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hantdev/mitras/auth"
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/mitras/pkg/postgres"
+)
+
+var (
+	errSaveSigningKey     = errors.New("failed to save signing key in database")
+	errRetrieveSigningKey = errors.New("failed to retrieve signing keys from database")
+	errUpdateSigningKey   = errors.New("failed to update signing key in database")
+	errDeleteSigningKey   = errors.New("failed to delete signing keys from database")
+)
+
+var _ auth.SigningKeyRepository = (*signingKeyRepo)(nil)
+
+type signingKeyRepo struct {
+	db postgres.Database
+}
+
+// NewSigningKeyRepository instantiates a PostgreSQL implementation of signing
+// key repository.
+func NewSigningKeyRepository(db postgres.Database) auth.SigningKeyRepository {
+	return &signingKeyRepo{
+		db: db,
+	}
+}
+
+func (sr *signingKeyRepo) Save(ctx context.Context, key auth.SigningKey) error {
+	q := `INSERT INTO signing_keys (id, algorithm, private_key, created_at, active_until, expires_at)
+	      VALUES (:id, :algorithm, :private_key, :created_at, :active_until, :expires_at)`
+
+	if _, err := sr.db.NamedExecContext(ctx, q, toDBSigningKey(key)); err != nil {
+		return postgres.HandleError(errSaveSigningKey, err)
+	}
+
+	return nil
+}
+
+func (sr *signingKeyRepo) RetrieveAll(ctx context.Context) ([]auth.SigningKey, error) {
+	q := `SELECT id, algorithm, private_key, created_at, active_until, expires_at FROM signing_keys
+	      WHERE expires_at IS NULL OR expires_at > $1 ORDER BY created_at`
+
+	rows, err := sr.db.QueryxContext(ctx, q, time.Now().UTC())
+	if err != nil {
+		return nil, postgres.HandleError(errRetrieveSigningKey, err)
+	}
+	defer rows.Close()
+
+	var keys []auth.SigningKey
+	for rows.Next() {
+		var dbk dbSigningKey
+		if err := rows.StructScan(&dbk); err != nil {
+			return nil, postgres.HandleError(errRetrieveSigningKey, err)
+		}
+		keys = append(keys, toSigningKey(dbk))
+	}
+
+	return keys, nil
+}
+
+func (sr *signingKeyRepo) Extend(ctx context.Context, id string, expiresAt time.Time) error {
+	q := `UPDATE signing_keys SET expires_at = $2 WHERE id = $1 AND expires_at IS NOT NULL AND expires_at < $2`
+	args := []any{id, expiresAt}
+	if expiresAt.IsZero() {
+		q = `UPDATE signing_keys SET expires_at = NULL WHERE id = $1`
+		args = args[:1]
+	}
+
+	if _, err := sr.db.ExecContext(ctx, q, args...); err != nil {
+		return postgres.HandleError(errUpdateSigningKey, err)
+	}
+
+	return nil
+}
+
+func (sr *signingKeyRepo) RemoveExpired(ctx context.Context) error {
+	q := `DELETE FROM signing_keys WHERE expires_at <= $1`
+	if _, err := sr.db.ExecContext(ctx, q, time.Now().UTC()); err != nil {
+		return errors.Wrap(errDeleteSigningKey, err)
+	}
+
+	return nil
+}
+
+type dbSigningKey struct {
+	ID          string       `db:"id"`
+	Algorithm   string       `db:"algorithm"`
+	PrivateKey  []byte       `db:"private_key"`
+	CreatedAt   time.Time    `db:"created_at"`
+	ActiveUntil time.Time    `db:"active_until"`
+	ExpiresAt   sql.NullTime `db:"expires_at"`
+}
+
+func toDBSigningKey(key auth.SigningKey) dbSigningKey {
+	return dbSigningKey{
+		ID:          key.ID,
+		Algorithm:   key.Algorithm,
+		PrivateKey:  key.PrivateKey,
+		CreatedAt:   key.CreatedAt,
+		ActiveUntil: key.ActiveUntil,
+		ExpiresAt:   sql.NullTime{Time: key.ExpiresAt, Valid: !key.ExpiresAt.IsZero()},
+	}
+}
+
+func toSigningKey(dbk dbSigningKey) auth.SigningKey {
+	key := auth.SigningKey{
+		ID:          dbk.ID,
+		Algorithm:   dbk.Algorithm,
+		PrivateKey:  dbk.PrivateKey,
+		CreatedAt:   dbk.CreatedAt,
+		ActiveUntil: dbk.ActiveUntil,
+	}
+	if dbk.ExpiresAt.Valid {
+		key.ExpiresAt = dbk.ExpiresAt.Time
+	}
+
+	return key
+}
@@ -0,0 +1,72 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/auth"
+	"github.com/hantdev/mitras/auth/postgres"
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signingKey(t *testing.T, expiresAt time.Time) auth.SigningKey {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	return auth.SigningKey{
+		ID:          generateID(t),
+		Algorithm:   "ES256",
+		PrivateKey:  []byte(generateID(t)),
+		CreatedAt:   now,
+		ActiveUntil: now.Add(time.Hour),
+		ExpiresAt:   expiresAt,
+	}
+}
+
+func TestSigningKeys(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := db.Exec("DELETE FROM signing_keys")
+		require.Nil(t, err, fmt.Sprintf("clean signing keys unexpected error: %s", err))
+	})
+	repo := postgres.NewSigningKeyRepository(database)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	active := signingKey(t, now.Add(2*time.Hour))
+	permanent := signingKey(t, time.Time{})
+	expired := signingKey(t, now.Add(-time.Minute))
+
+	for _, k := range []auth.SigningKey{active, permanent, expired} {
+		err := repo.Save(ctx, k)
+		assert.Nil(t, err, fmt.Sprintf("save signing key unexpected error: %s", err))
+	}
+	err := repo.Save(ctx, active)
+	assert.True(t, errors.Contains(err, repoerr.ErrConflict), fmt.Sprintf("expected error %s, got %s", repoerr.ErrConflict, err))
+
+	keys, err := repo.RetrieveAll(ctx)
+	assert.Nil(t, err, fmt.Sprintf("retrieve signing keys unexpected error: %s", err))
+	assert.ElementsMatch(t, []auth.SigningKey{active, permanent}, keys)
+
+	// Extending to the earlier time doesn't change the expiration.
+	err = repo.Extend(ctx, active.ID, now.Add(time.Hour))
+	assert.Nil(t, err, fmt.Sprintf("extend signing key unexpected error: %s", err))
+	active.ExpiresAt = now.Add(3 * time.Hour)
+	err = repo.Extend(ctx, active.ID, active.ExpiresAt)
+	assert.Nil(t, err, fmt.Sprintf("extend signing key unexpected error: %s", err))
+	err = repo.Extend(ctx, permanent.ID, now.Add(time.Hour))
+	assert.Nil(t, err, fmt.Sprintf("extend signing key unexpected error: %s", err))
+
+	keys, err = repo.RetrieveAll(ctx)
+	assert.Nil(t, err, fmt.Sprintf("retrieve signing keys unexpected error: %s", err))
+	assert.ElementsMatch(t, []auth.SigningKey{active, permanent}, keys)
+
+	err = repo.RemoveExpired(ctx)
+	assert.Nil(t, err, fmt.Sprintf("remove expired signing keys unexpected error: %s", err))
+	var count int
+	err = db.Get(&count, "SELECT COUNT(*) FROM signing_keys")
+	assert.Nil(t, err, fmt.Sprintf("count signing keys unexpected error: %s", err))
+	assert.Equal(t, 2, count)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/hantdev/mitras"
 	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
 	svcerr "github.com/hantdev/mitras/pkg/errors/service"
 	"github.com/hantdev/mitras/pkg/policies"
 )
@@ -14,6 +15,9 @@ import (
 const (
 	recoveryDuration = 5 * time.Minute
 	defLimit         = 100
+
+	// ImpersonationDuration is the max lifetime of the impersonation token.
+	ImpersonationDuration = 15 * time.Minute
 )
 
 var (
@@ -26,6 +30,9 @@ var (
 	errRetrieve  = errors.New("failed to retrieve key data")
 	errIdentify  = errors.New("failed to validate token")
 	errPlatform  = errors.New("invalid platform id")
+
+	// ErrSessionRevoked indicates that the session of the key is revoked.
+	ErrSessionRevoked = errors.New("session is revoked")
 )
 
 // Authz represents a authorization service. It exposes
@@ -61,6 +68,30 @@ type Authn interface {
 	// is returned. If token is invalid, or invocation failed for some
 	// other reason, non-nil error value is returned in response.
 	Identify(ctx context.Context, token string) (Key, error)
+
+	// RetrieveJWKS retrieves the public keys used to verify the tokens, in
+	// the JSON Web Key Set format.
+	RetrieveJWKS(ctx context.Context) ([]PublicKeyInfo, error)
+
+	// RetrieveRevokedSessions retrieves the revoked sessions which aren't
+	// expired, used by the services which verify the tokens locally.
+	RetrieveRevokedSessions(ctx context.Context) ([]Session, error)
+
+	// ListSessions retrieves the active sessions of the user identified
+	// by the provided key.
+	ListSessions(ctx context.Context, token string, offset, limit uint64) (SessionPage, error)
+
+	// RevokeSession revokes the session with the provided ID of the user
+	// identified by the provided key.
+	RevokeSession(ctx context.Context, token, id string) error
+
+	// RevokeAllSessions revokes all the sessions of the user identified
+	// by the provided key, including the session of the key itself.
+	RevokeAllSessions(ctx context.Context, token string) error
+
+	// RevokeUserSessions revokes all the sessions of the user with the
+	// provided ID.
+	RevokeUserSessions(ctx context.Context, userID string) error
 }
 
 // Service specifies an API that must be fulfilled by the domain service
@@ -78,6 +109,8 @@ var _ Service = (*service)(nil)
 
 type service struct {
 	keys               KeyRepository
+	sessions           SessionRepository
+	sessionCache       SessionCache
 	idProvider         mitras.IDProvider
 	evaluator          policies.Evaluator
 	policysvc          policies.Service
@@ -88,10 +121,12 @@ type service struct {
 }
 
 // New instantiates the auth service implementation.
-func New(keys KeyRepository, idp mitras.IDProvider, tokenizer Tokenizer, policyEvaluator policies.Evaluator, policyService policies.Service, loginDuration, refreshDuration, invitationDuration time.Duration) Service {
+func New(keys KeyRepository, sessions SessionRepository, sessionCache SessionCache, idp mitras.IDProvider, tokenizer Tokenizer, policyEvaluator policies.Evaluator, policyService policies.Service, loginDuration, refreshDuration, invitationDuration time.Duration) Service {
 	return &service{
 		tokenizer:          tokenizer,
 		keys:               keys,
+		sessions:           sessions,
+		sessionCache:       sessionCache,
 		idProvider:         idp,
 		evaluator:          policyEvaluator,
 		policysvc:          policyService,
@@ -150,6 +185,9 @@ func (svc service) Identify(ctx context.Context, token string) (Key, error) {
 	if err != nil {
 		return Key{}, errors.Wrap(svcerr.ErrAuthentication, errors.Wrap(errIdentify, err))
 	}
+	if svc.revoked(key) {
+		return Key{}, errors.Wrap(svcerr.ErrAuthentication, ErrSessionRevoked)
+	}
 
 	switch key.Type {
 	case RecoveryKey, AccessKey, InvitationKey, RefreshKey:
@@ -165,6 +203,63 @@ func (svc service) Identify(ctx context.Context, token string) (Key, error) {
 	}
 }
 
+func (svc service) RetrieveJWKS(ctx context.Context) ([]PublicKeyInfo, error) {
+	keys, err := svc.tokenizer.RetrieveJWKS()
+	if err != nil {
+		return nil, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	return keys, nil
+}
+
+func (svc service) RetrieveRevokedSessions(ctx context.Context) ([]Session, error) {
+	sessions, err := svc.sessions.RetrieveRevoked(ctx)
+	if err != nil {
+		return nil, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	return sessions, nil
+}
+
+func (svc service) ListSessions(ctx context.Context, token string, offset, limit uint64) (SessionPage, error) {
+	userID, err := svc.sessionUser(ctx, token)
+	if err != nil {
+		return SessionPage{}, err
+	}
+	if limit == 0 {
+		limit = defLimit
+	}
+
+	page, err := svc.sessions.RetrieveAll(ctx, userID, offset, limit)
+	if err != nil {
+		return SessionPage{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	return page, nil
+}
+
+func (svc service) RevokeSession(ctx context.Context, token, id string) error {
+	userID, err := svc.sessionUser(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	return svc.revokeSessions(ctx, userID, id)
+}
+
+func (svc service) RevokeAllSessions(ctx context.Context, token string) error {
+	userID, err := svc.sessionUser(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	return svc.revokeSessions(ctx, userID)
+}
+
+func (svc service) RevokeUserSessions(ctx context.Context, userID string) error {
+	return svc.revokeSessions(ctx, userID)
+}
+
 func (svc service) Authorize(ctx context.Context, pr policies.Policy) error {
 	if err := svc.PolicyValidation(pr); err != nil {
 		return errors.Wrap(svcerr.ErrMalformedEntity, err)
@@ -244,16 +339,29 @@ func (svc service) accessKey(ctx context.Context, key Key) (Token, error) {
 	var err error
 	key.Type = AccessKey
 	key.ExpiresAt = time.Now().Add(svc.loginDuration)
+	if key.Impersonator != "" && svc.loginDuration > ImpersonationDuration {
+		key.ExpiresAt = time.Now().Add(ImpersonationDuration)
+	}
 
 	key.Subject, err = svc.checkUserDomain(ctx, key)
 	if err != nil {
 		return Token{}, errors.Wrap(svcerr.ErrAuthorization, err)
 	}
 
+	if key.User != "" {
+		if key.Session, err = svc.createSession(ctx, key); err != nil {
+			return Token{}, errors.Wrap(errIssueTmp, err)
+		}
+	}
+
 	access, err := svc.tokenizer.Issue(key)
 	if err != nil {
 		return Token{}, errors.Wrap(errIssueTmp, err)
 	}
+	// The impersonation can't be extended, so the refresh key isn't issued.
+	if key.Impersonator != "" {
+		return Token{AccessToken: access}, nil
+	}
 
 	key.ExpiresAt = time.Now().Add(svc.refreshDuration)
 	key.Type = RefreshKey
@@ -291,11 +399,15 @@ func (svc service) refreshKey(ctx context.Context, token string, key Key) (Token
 	if k.Type != RefreshKey {
 		return Token{}, errIssueUser
 	}
+	if svc.revoked(k) {
+		return Token{}, errors.Wrap(svcerr.ErrAuthentication, ErrSessionRevoked)
+	}
 	key.ID = k.ID
 	if key.Domain == "" {
 		key.Domain = k.Domain
 	}
 	key.User = k.User
+	key.ServiceAccount = k.ServiceAccount
 	key.Type = AccessKey
 
 	key.Subject, err = svc.checkUserDomain(ctx, key)
@@ -303,6 +415,29 @@ func (svc service) refreshKey(ctx context.Context, token string, key Key) (Token
 		return Token{}, errors.Wrap(svcerr.ErrAuthorization, err)
 	}
 
+	switch {
+	case k.Session != "":
+		key.Session = k.Session
+		now := time.Now().UTC()
+		session := Session{
+			ID:         k.Session,
+			UserID:     k.User,
+			IP:         key.IP,
+			LastUsedAt: now,
+			ExpiresAt:  now.Add(svc.refreshDuration),
+		}
+		if err := svc.sessions.Refresh(ctx, session); err != nil {
+			// The session is revoked by another instance or expired.
+			return Token{}, errors.Wrap(svcerr.ErrAuthentication, errors.Wrap(ErrSessionRevoked, err))
+		}
+	case key.User != "":
+		// The refresh keys issued before the sessions were introduced
+		// don't carry the session, so the new session is created.
+		if key.Session, err = svc.createSession(ctx, key); err != nil {
+			return Token{}, errors.Wrap(errIssueTmp, err)
+		}
+	}
+
 	key.ExpiresAt = time.Now().Add(svc.loginDuration)
 	access, err := svc.tokenizer.Issue(key)
 	if err != nil {
@@ -320,6 +455,11 @@ func (svc service) refreshKey(ctx context.Context, token string, key Key) (Token
 }
 
 func (svc service) checkUserDomain(ctx context.Context, key Key) (subject string, err error) {
+	// The service account is owned by the domain, which is checked by the
+	// Users service.
+	if key.ServiceAccount {
+		return EncodeDomainUserID(key.Domain, key.User), nil
+	}
 	if key.Domain != "" {
 		// Check user is platform admin.
 		if err = svc.Authorize(ctx, policies.Policy{
@@ -381,6 +521,9 @@ func (svc service) authenticate(token string) (string, string, error) {
 	if err != nil {
 		return "", "", errors.Wrap(svcerr.ErrAuthentication, err)
 	}
+	if svc.revoked(key) {
+		return "", "", errors.Wrap(svcerr.ErrAuthentication, ErrSessionRevoked)
+	}
 	// Only login key token is valid for login.
 	if key.Type != AccessKey || key.Issuer == "" {
 		return "", "", svcerr.ErrAuthentication
@@ -389,6 +532,64 @@ func (svc service) authenticate(token string) (string, string, error) {
 	return key.Issuer, key.Subject, nil
 }
 
+func (svc service) createSession(ctx context.Context, key Key) (string, error) {
+	id, err := svc.idProvider.ID()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	session := Session{
+		ID:         id,
+		UserID:     key.User,
+		Device:     key.Device,
+		IP:         key.IP,
+		IssuedAt:   now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(svc.refreshDuration),
+	}
+	// The impersonation session isn't refreshed.
+	if key.Impersonator != "" {
+		session.ExpiresAt = key.ExpiresAt.UTC()
+	}
+	if err := svc.sessions.Save(ctx, session); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// sessionUser returns the ID of the user who owns the sessions, identified
+// by the access key.
+func (svc service) sessionUser(ctx context.Context, token string) (string, error) {
+	key, err := svc.Identify(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	if key.Type != AccessKey || key.User == "" {
+		return "", svcerr.ErrAuthentication
+	}
+
+	return key.User, nil
+}
+
+func (svc service) revokeSessions(ctx context.Context, userID string, ids ...string) error {
+	sessions, err := svc.sessions.Revoke(ctx, userID, ids...)
+	if err != nil {
+		if errors.Contains(err, repoerr.ErrNotFound) {
+			return errors.Wrap(svcerr.ErrNotFound, err)
+		}
+		return errors.Wrap(svcerr.ErrRemoveEntity, err)
+	}
+	svc.sessionCache.Revoke(sessions...)
+
+	return nil
+}
+
+// revoked reports whether the session of the key is revoked.
+func (svc service) revoked(key Key) bool {
+	return key.Session != "" && svc.sessionCache.Revoked(key.Session)
+}
+
 // Switch the relative permission for the relation.
 func SwitchToPermission(relation string) string {
 	switch relation {
@@ -43,13 +43,17 @@ var (
 )
 
 var (
-	krepo      *mocks.KeyRepository
-	pService   *policymocks.Service
-	pEvaluator *policymocks.Evaluator
+	krepo        *mocks.KeyRepository
+	sessionRepo  *mocks.SessionRepository
+	sessionCache *mocks.SessionCache
+	pService     *policymocks.Service
+	pEvaluator   *policymocks.Evaluator
 )
 
 func newService() (auth.Service, string) {
 	krepo = new(mocks.KeyRepository)
+	sessionRepo = new(mocks.SessionRepository)
+	sessionCache = new(mocks.SessionCache)
 	pService = new(policymocks.Service)
 	pEvaluator = new(policymocks.Evaluator)
 	idProvider := uuid.NewMock()
@@ -65,7 +69,10 @@ func newService() (auth.Service, string) {
 	}
 	token, _ := t.Issue(key)
 
-	return auth.New(krepo, idProvider, t, pEvaluator, pService, loginDuration, refreshDuration, invalidDuration), token
+	sessionRepo.On("Save", mock.Anything, mock.Anything).Return(nil).Maybe()
+	sessionCache.On("Revoked", mock.Anything).Return(false).Maybe()
+
+	return auth.New(krepo, sessionRepo, sessionCache, idProvider, t, pEvaluator, pService, loginDuration, refreshDuration, invalidDuration), token
 }
 
 func TestIssue(t *testing.T) {
@@ -1235,3 +1242,360 @@ func TestDecodeDomainUserID(t *testing.T) {
 		assert.Equal(t, tc.respDomainID, ar, fmt.Sprintf("%s expected %s got %s\n", tc.desc, tc.respDomainID, ar))
 	}
 }
+
+func newSessionService() (auth.Service, *mocks.SessionRepository, *mocks.SessionCache) {
+	sessions := new(mocks.SessionRepository)
+	cache := new(mocks.SessionCache)
+	svc := auth.New(new(mocks.KeyRepository), sessions, cache, uuid.NewMock(), jwt.New([]byte(secret)), new(policymocks.Evaluator), new(policymocks.Service), loginDuration, refreshDuration, invalidDuration)
+
+	return svc, sessions, cache
+}
+
+func TestIssueSession(t *testing.T) {
+	svc, sessions, cache := newSessionService()
+	tokenizer := jwt.New([]byte(secret))
+
+	var session auth.Session
+	saveCall := sessions.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		session = args.Get(1).(auth.Session)
+	}).Return(nil).Once()
+	token, err := svc.Issue(context.Background(), "", auth.Key{Type: auth.AccessKey, User: userID, Device: "Mozilla/5.0", IP: "192.168.0.1"})
+	assert.Nil(t, err, fmt.Sprintf("Issuing access key expected to succeed: %s", err))
+	saveCall.Unset()
+	assert.Equal(t, userID, session.UserID)
+	assert.Equal(t, "Mozilla/5.0", session.Device)
+	assert.Equal(t, "192.168.0.1", session.IP)
+
+	access, err := tokenizer.Parse(token.AccessToken)
+	assert.Nil(t, err, fmt.Sprintf("Parsing access key expected to succeed: %s", err))
+	assert.Equal(t, session.ID, access.Session)
+	refresh, err := tokenizer.Parse(token.RefreshToken)
+	assert.Nil(t, err, fmt.Sprintf("Parsing refresh key expected to succeed: %s", err))
+	assert.Equal(t, session.ID, refresh.Session)
+
+	sessions.On("Save", mock.Anything, mock.Anything).Return(repoerr.ErrCreateEntity).Once()
+	_, err = svc.Issue(context.Background(), "", auth.Key{Type: auth.AccessKey, User: userID})
+	assert.True(t, errors.Contains(err, repoerr.ErrCreateEntity), fmt.Sprintf("expected %s got %s\n", repoerr.ErrCreateEntity, err))
+
+	legacy, err := tokenizer.Issue(auth.Key{Type: auth.RefreshKey, User: userID, IssuedAt: time.Now(), ExpiresAt: time.Now().Add(refreshDuration)})
+	assert.Nil(t, err, fmt.Sprintf("Issuing refresh key expected to succeed: %s", err))
+
+	cases := []struct {
+		desc       string
+		token      string
+		revoked    bool
+		refreshErr error
+		saveErr    error
+		err        error
+	}{
+		{
+			desc:  "refresh session",
+			token: token.RefreshToken,
+		},
+		{
+			desc:    "refresh revoked session",
+			token:   token.RefreshToken,
+			revoked: true,
+			err:     auth.ErrSessionRevoked,
+		},
+		{
+			desc:       "refresh session revoked by another instance",
+			token:      token.RefreshToken,
+			refreshErr: repoerr.ErrNotFound,
+			err:        auth.ErrSessionRevoked,
+		},
+		{
+			desc:  "refresh key without session",
+			token: legacy,
+		},
+		{
+			desc:    "refresh key without session with failed to save",
+			token:   legacy,
+			saveErr: repoerr.ErrCreateEntity,
+			err:     repoerr.ErrCreateEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		cacheCall := cache.On("Revoked", session.ID).Return(tc.revoked)
+		refreshCall := sessions.On("Refresh", mock.Anything, mock.Anything).Return(tc.refreshErr)
+		saveCall := sessions.On("Save", mock.Anything, mock.Anything).Return(tc.saveErr)
+		_, err := svc.Issue(context.Background(), tc.token, auth.Key{Type: auth.RefreshKey, IP: "192.168.0.2"})
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s expected %s got %s\n", tc.desc, tc.err, err))
+		cacheCall.Unset()
+		refreshCall.Unset()
+		saveCall.Unset()
+	}
+}
+
+func TestIssueServiceAccount(t *testing.T) {
+	svc, sessions, _ := newSessionService()
+	tokenizer := jwt.New([]byte(secret))
+
+	saveCall := sessions.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
+	token, err := svc.Issue(context.Background(), "", auth.Key{Type: auth.AccessKey, User: userID, Domain: domainID, ServiceAccount: true})
+	assert.Nil(t, err, fmt.Sprintf("Issuing service account key expected to succeed: %s", err))
+	saveCall.Unset()
+
+	access, err := tokenizer.Parse(token.AccessToken)
+	assert.Nil(t, err, fmt.Sprintf("Parsing access key expected to succeed: %s", err))
+	assert.True(t, access.ServiceAccount, "access key expected to be issued for service account")
+	assert.Equal(t, domainID, access.Domain)
+	assert.Equal(t, auth.EncodeDomainUserID(domainID, userID), access.Subject)
+}
+
+func TestListSessions(t *testing.T) {
+	svc, sessions, cache := newSessionService()
+
+	sessions.On("Save", mock.Anything, mock.Anything).Return(nil)
+	token, err := svc.Issue(context.Background(), "", auth.Key{Type: auth.AccessKey, User: userID})
+	assert.Nil(t, err, fmt.Sprintf("Issuing access key expected to succeed: %s", err))
+
+	page := auth.SessionPage{Total: 1, Limit: 10, Sessions: []auth.Session{{ID: validID, UserID: userID}}}
+
+	cases := []struct {
+		desc    string
+		token   string
+		limit   uint64
+		revoked bool
+		page    auth.SessionPage
+		repoErr error
+		err     error
+	}{
+		{
+			desc:  "list sessions",
+			token: token.AccessToken,
+			limit: 10,
+			page:  page,
+		},
+		{
+			desc:  "list sessions with default limit",
+			token: token.AccessToken,
+			page:  page,
+		},
+		{
+			desc:  "list sessions with refresh key",
+			token: token.RefreshToken,
+			limit: 10,
+			err:   svcerr.ErrAuthentication,
+		},
+		{
+			desc:    "list sessions with revoked session",
+			token:   token.AccessToken,
+			limit:   10,
+			revoked: true,
+			err:     auth.ErrSessionRevoked,
+		},
+		{
+			desc:  "list sessions with invalid token",
+			token: inValidToken,
+			limit: 10,
+			err:   svcerr.ErrAuthentication,
+		},
+		{
+			desc:    "list sessions with failed to retrieve",
+			token:   token.AccessToken,
+			limit:   10,
+			repoErr: repoerr.ErrViewEntity,
+			err:     svcerr.ErrViewEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		limit := tc.limit
+		if limit == 0 {
+			limit = 100
+		}
+		cacheCall := cache.On("Revoked", mock.Anything).Return(tc.revoked)
+		repoCall := sessions.On("RetrieveAll", mock.Anything, userID, uint64(0), limit).Return(tc.page, tc.repoErr)
+		page, err := svc.ListSessions(context.Background(), tc.token, 0, tc.limit)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.page, page, fmt.Sprintf("%s expected %v got %v\n", tc.desc, tc.page, page))
+		cacheCall.Unset()
+		repoCall.Unset()
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	svc, sessions, cache := newSessionService()
+
+	sessions.On("Save", mock.Anything, mock.Anything).Return(nil)
+	token, err := svc.Issue(context.Background(), "", auth.Key{Type: auth.AccessKey, User: userID})
+	assert.Nil(t, err, fmt.Sprintf("Issuing access key expected to succeed: %s", err))
+	cache.On("Revoked", mock.Anything).Return(false)
+
+	revoked := []auth.Session{{ID: validID, UserID: userID}}
+
+	cases := []struct {
+		desc     string
+		token    string
+		id       string
+		sessions []auth.Session
+		repoErr  error
+		err      error
+	}{
+		{
+			desc:     "revoke session",
+			token:    token.AccessToken,
+			id:       validID,
+			sessions: revoked,
+		},
+		{
+			desc:    "revoke non-existing session",
+			token:   token.AccessToken,
+			id:      id,
+			repoErr: repoerr.ErrNotFound,
+			err:     svcerr.ErrNotFound,
+		},
+		{
+			desc:    "revoke session with failed to revoke",
+			token:   token.AccessToken,
+			id:      validID,
+			repoErr: repoerr.ErrRemoveEntity,
+			err:     svcerr.ErrRemoveEntity,
+		},
+		{
+			desc:  "revoke session with invalid token",
+			token: inValidToken,
+			id:    validID,
+			err:   svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		repoCall := sessions.On("Revoke", mock.Anything, userID, tc.id).Return(tc.sessions, tc.repoErr)
+		cacheCall := cache.On("Revoke", mock.Anything).Return()
+		err := svc.RevokeSession(context.Background(), tc.token, tc.id)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			cache.AssertCalled(t, "Revoke", revoked[0])
+		}
+		repoCall.Unset()
+		cacheCall.Unset()
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	svc, sessions, cache := newSessionService()
+
+	sessions.On("Save", mock.Anything, mock.Anything).Return(nil)
+	token, err := svc.Issue(context.Background(), "", auth.Key{Type: auth.AccessKey, User: userID})
+	assert.Nil(t, err, fmt.Sprintf("Issuing access key expected to succeed: %s", err))
+	cache.On("Revoked", mock.Anything).Return(false)
+
+	cases := []struct {
+		desc    string
+		token   string
+		repoErr error
+		err     error
+	}{
+		{
+			desc:  "revoke all sessions",
+			token: token.AccessToken,
+		},
+		{
+			desc:    "revoke all sessions with failed to revoke",
+			token:   token.AccessToken,
+			repoErr: repoerr.ErrRemoveEntity,
+			err:     svcerr.ErrRemoveEntity,
+		},
+		{
+			desc:  "revoke all sessions with invalid token",
+			token: inValidToken,
+			err:   svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		repoCall := sessions.On("Revoke", mock.Anything, userID).Return([]auth.Session{{ID: validID}}, tc.repoErr)
+		cacheCall := cache.On("Revoke", mock.Anything).Return()
+		err := svc.RevokeAllSessions(context.Background(), tc.token)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s expected %s got %s\n", tc.desc, tc.err, err))
+		repoCall.Unset()
+		cacheCall.Unset()
+	}
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	svc, sessions, cache := newSessionService()
+
+	cases := []struct {
+		desc    string
+		userID  string
+		repoErr error
+		err     error
+	}{
+		{
+			desc:   "revoke user sessions",
+			userID: userID,
+		},
+		{
+			desc:    "revoke user sessions with failed to revoke",
+			userID:  userID,
+			repoErr: repoerr.ErrRemoveEntity,
+			err:     svcerr.ErrRemoveEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		repoCall := sessions.On("Revoke", mock.Anything, tc.userID).Return([]auth.Session{{ID: validID}}, tc.repoErr)
+		cacheCall := cache.On("Revoke", mock.Anything).Return()
+		err := svc.RevokeUserSessions(context.Background(), tc.userID)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s expected %s got %s\n", tc.desc, tc.err, err))
+		repoCall.Unset()
+		cacheCall.Unset()
+	}
+}
+
+func TestRetrieveRevokedSessions(t *testing.T) {
+	svc, sessions, _ := newSessionService()
+
+	revoked := []auth.Session{{ID: validID, UserID: userID, ExpiresAt: time.Now().Add(time.Hour)}}
+
+	cases := []struct {
+		desc     string
+		sessions []auth.Session
+		repoErr  error
+		err      error
+	}{
+		{
+			desc:     "retrieve revoked sessions",
+			sessions: revoked,
+		},
+		{
+			desc:    "retrieve revoked sessions with failed to retrieve",
+			repoErr: repoerr.ErrViewEntity,
+			err:     svcerr.ErrViewEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		repoCall := sessions.On("RetrieveRevoked", mock.Anything).Return(tc.sessions, tc.repoErr)
+		res, err := svc.RetrieveRevokedSessions(context.Background())
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.sessions, res, fmt.Sprintf("%s expected %v got %v\n", tc.desc, tc.sessions, res))
+		repoCall.Unset()
+	}
+}
+
+func TestIssueImpersonation(t *testing.T) {
+	svc, sessions, _ := newSessionService()
+	tokenizer := jwt.New([]byte(secret))
+
+	var session auth.Session
+	saveCall := sessions.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		session = args.Get(1).(auth.Session)
+	}).Return(nil).Once()
+	token, err := svc.Issue(context.Background(), "", auth.Key{Type: auth.AccessKey, User: userID, Impersonator: "admin", ReadOnly: true})
+	assert.Nil(t, err, fmt.Sprintf("Issuing impersonation key expected to succeed: %s", err))
+	saveCall.Unset()
+	assert.Empty(t, token.RefreshToken, "impersonation key expected to be issued without refresh key")
+
+	access, err := tokenizer.Parse(token.AccessToken)
+	assert.Nil(t, err, fmt.Sprintf("Parsing access key expected to succeed: %s", err))
+	assert.Equal(t, userID, access.User)
+	assert.Equal(t, "admin", access.Impersonator)
+	assert.True(t, access.ReadOnly, "impersonation key expected to be read-only")
+	assert.False(t, access.ExpiresAt.After(time.Now().Add(auth.ImpersonationDuration)), "impersonation key expected to expire within the impersonation duration")
+	assert.Equal(t, access.ExpiresAt.UTC().Truncate(time.Second), session.ExpiresAt.Truncate(time.Second))
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// Session represents the login session of the user. The session is created
+// on login, and the access and refresh keys issued on login and on refresh
+// carry its ID. Revoking the session invalidates all of its keys.
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Device     string    `json:"device,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	IssuedAt   time.Time `json:"issued_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// SessionPage contains page related metadata as well as list of sessions.
+type SessionPage struct {
+	Total    uint64    `json:"total"`
+	Offset   uint64    `json:"offset"`
+	Limit    uint64    `json:"limit"`
+	Sessions []Session `json:"sessions"`
+}
+
+// SessionRepository specifies session persistence API.
+//
+//go:generate mockery --name SessionRepository --output=./mocks --filename sessions.go --quiet
+type SessionRepository interface {
+	// Save persists the session.
+	Save(ctx context.Context, session Session) error
+
+	// RetrieveAll retrieves the active sessions of the user, ordered by
+	// the last use, the most recent first.
+	RetrieveAll(ctx context.Context, userID string, offset, limit uint64) (SessionPage, error)
+
+	// Refresh updates the last use of the active session and postpones
+	// its expiration.
+	Refresh(ctx context.Context, session Session) error
+
+	// Revoke revokes the active sessions of the user with the given IDs,
+	// or all the active sessions of the user if no ID is given, and
+	// returns the revoked sessions.
+	Revoke(ctx context.Context, userID string, ids ...string) ([]Session, error)
+
+	// RetrieveRevoked retrieves the revoked sessions which aren't expired.
+	RetrieveRevoked(ctx context.Context) ([]Session, error)
+
+	// RemoveExpired removes the expired sessions.
+	RemoveExpired(ctx context.Context) error
+}
+
+// SessionCache is the denylist of the revoked sessions, used to check the
+// session revocation without querying the repository.
+//
+//go:generate mockery --name SessionCache --output=./mocks --filename session_cache.go --quiet
+type SessionCache interface {
+	// Revoke adds the sessions to the denylist until they expire.
+	Revoke(sessions ...Session)
+
+	// Revoked reports whether the session with the given ID is revoked.
+	Revoked(id string) bool
+}
@@ -1,5 +1,10 @@
 package auth
 
+import (
+	"context"
+	"time"
+)
+
 // Tokenizer specifies API for encoding and decoding between string and Key.
 type Tokenizer interface {
 	// Issue converts API Key to its string representation.
@@ -7,4 +12,64 @@ type Tokenizer interface {
 
 	// Parse extracts API Key data from string token.
 	Parse(token string) (key Key, err error)
+
+	// RetrieveJWKS returns the public keys used to verify the tokens.
+	RetrieveJWKS() ([]PublicKeyInfo, error)
+}
+
+// PublicKeyInfo represents the public key in the JSON Web Key format.
+type PublicKeyInfo struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use,omitempty"`
+
+	// RSA public key fields.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC and OKP public key fields.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// SigningKey represents the asymmetric key used to sign and verify the tokens.
+type SigningKey struct {
+	// ID is the key ID, which is set as the "kid" header of the tokens.
+	ID string
+
+	// Algorithm is the signature algorithm of the key.
+	Algorithm string
+
+	// PrivateKey is the PKCS #8 DER encoded private key. The stored private
+	// key is encrypted with the key encryption key of the tokenizer.
+	PrivateKey []byte
+
+	CreatedAt time.Time
+
+	// ActiveUntil is the time until the key is used to sign new tokens.
+	ActiveUntil time.Time
+
+	// ExpiresAt is the time until the tokens signed with the key are
+	// verified. The zero time means the key never expires.
+	ExpiresAt time.Time
+}
+
+// SigningKeyRepository specifies signing key persistence API.
+//
+//go:generate mockery --name SigningKeyRepository --output=./mocks --filename signing_keys.go --quiet
+type SigningKeyRepository interface {
+	// Save persists the signing key.
+	Save(ctx context.Context, key SigningKey) error
+
+	// RetrieveAll retrieves the signing keys which aren't expired.
+	RetrieveAll(ctx context.Context) ([]SigningKey, error)
+
+	// Extend postpones the expiration of the signing key to the given time,
+	// if the key expires before it. The zero time means the key never expires.
+	Extend(ctx context.Context, id string, expiresAt time.Time) error
+
+	// RemoveExpired removes the expired signing keys.
+	RemoveExpired(ctx context.Context) error
 }
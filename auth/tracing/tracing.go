@@ -57,6 +57,55 @@ func (tm *tracingMiddleware) Identify(ctx context.Context, token string) (auth.K
 	return tm.svc.Identify(ctx, token)
 }
 
+func (tm *tracingMiddleware) RetrieveJWKS(ctx context.Context) ([]auth.PublicKeyInfo, error) {
+	ctx, span := tm.tracer.Start(ctx, "retrieve_jwks")
+	defer span.End()
+
+	return tm.svc.RetrieveJWKS(ctx)
+}
+
+func (tm *tracingMiddleware) RetrieveRevokedSessions(ctx context.Context) ([]auth.Session, error) {
+	ctx, span := tm.tracer.Start(ctx, "retrieve_revoked_sessions")
+	defer span.End()
+
+	return tm.svc.RetrieveRevokedSessions(ctx)
+}
+
+func (tm *tracingMiddleware) ListSessions(ctx context.Context, token string, offset, limit uint64) (auth.SessionPage, error) {
+	ctx, span := tm.tracer.Start(ctx, "list_sessions", trace.WithAttributes(
+		attribute.Int64("offset", int64(offset)),
+		attribute.Int64("limit", int64(limit)),
+	))
+	defer span.End()
+
+	return tm.svc.ListSessions(ctx, token, offset, limit)
+}
+
+func (tm *tracingMiddleware) RevokeSession(ctx context.Context, token, id string) error {
+	ctx, span := tm.tracer.Start(ctx, "revoke_session", trace.WithAttributes(
+		attribute.String("id", id),
+	))
+	defer span.End()
+
+	return tm.svc.RevokeSession(ctx, token, id)
+}
+
+func (tm *tracingMiddleware) RevokeAllSessions(ctx context.Context, token string) error {
+	ctx, span := tm.tracer.Start(ctx, "revoke_all_sessions")
+	defer span.End()
+
+	return tm.svc.RevokeAllSessions(ctx, token)
+}
+
+func (tm *tracingMiddleware) RevokeUserSessions(ctx context.Context, userID string) error {
+	ctx, span := tm.tracer.Start(ctx, "revoke_user_sessions", trace.WithAttributes(
+		attribute.String("user_id", userID),
+	))
+	defer span.End()
+
+	return tm.svc.RevokeUserSessions(ctx, userID)
+}
+
 func (tm *tracingMiddleware) Authorize(ctx context.Context, pr policies.Policy) error {
 	ctx, span := tm.tracer.Start(ctx, "authorize", trace.WithAttributes(
 		attribute.String("subject", pr.Subject),
@@ -31,6 +31,7 @@ type grpcClient struct {
 	removeConnections          endpoint.Endpoint
 	removeChannelConnections   endpoint.Endpoint
 	unsetParentGroupFromClient endpoint.Endpoint
+	retrieveSecret             endpoint.Endpoint
 }
 
 // NewClient returns new gRPC client instance.
@@ -99,6 +100,15 @@ func NewClient(conn *grpc.ClientConn, timeout time.Duration) grpcClientsV1.Clien
 			grpcClientsV1.UnsetParentGroupFromClientRes{},
 		).Endpoint(),
 
+		retrieveSecret: kitgrpc.NewClient(
+			conn,
+			svcName,
+			"RetrieveSecret",
+			encodeRetrieveSecretRequest,
+			decodeRetrieveSecretResponse,
+			grpcClientsV1.RetrieveSecretRes{},
+		).Endpoint(),
+
 		timeout: timeout,
 	}
 }
@@ -343,6 +353,31 @@ func decodeUnsetParentGroupFromClientResponse(_ context.Context, grpcRes interfa
 	return grpcRes.(*grpcClientsV1.UnsetParentGroupFromClientRes), nil
 }
 
+func (client grpcClient) RetrieveSecret(ctx context.Context, req *grpcClientsV1.RetrieveSecretReq, _ ...grpc.CallOption) (r *grpcClientsV1.RetrieveSecretRes, err error) {
+	ctx, cancel := context.WithTimeout(ctx, client.timeout)
+	defer cancel()
+
+	res, err := client.retrieveSecret(ctx, retrieveSecretReq{id: req.GetId()})
+	if err != nil {
+		return &grpcClientsV1.RetrieveSecretRes{}, decodeError(err)
+	}
+
+	rs := res.(retrieveSecretRes)
+	return &grpcClientsV1.RetrieveSecretRes{Secret: rs.secret}, nil
+}
+
+func encodeRetrieveSecretRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(retrieveSecretReq)
+	return &grpcClientsV1.RetrieveSecretReq{
+		Id: req.id,
+	}, nil
+}
+
+func decodeRetrieveSecretResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(*grpcClientsV1.RetrieveSecretRes)
+	return retrieveSecretRes{secret: res.GetSecret()}, nil
+}
+
 func decodeError(err error) error {
 	if st, ok := status.FromError(err); ok {
 		switch st.Code() {
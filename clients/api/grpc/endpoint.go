@@ -5,6 +5,7 @@ import (
 
 	"github.com/hantdev/mitras/clients"
 	pClients "github.com/hantdev/mitras/clients/private"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
 	"github.com/go-kit/kit/endpoint"
 )
 
@@ -34,6 +35,21 @@ func retrieveEntityEndpoint(svc pClients.Service) endpoint.Endpoint {
 	}
 }
 
+func retrieveSecretEndpoint(svc pClients.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(retrieveSecretReq)
+		client, err := svc.RetrieveById(ctx, req.id)
+		if err != nil {
+			return retrieveSecretRes{}, err
+		}
+		if client.Status != clients.EnabledStatus {
+			return retrieveSecretRes{}, svcerr.ErrAuthentication
+		}
+
+		return retrieveSecretRes{secret: client.Credentials.Secret}, nil
+	}
+}
+
 func retrieveEntitiesEndpoint(svc pClients.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(retrieveEntitiesReq)
@@ -158,6 +158,61 @@ func TestRetrieveEntity(t *testing.T) {
 	}
 }
 
+func TestRetrieveSecret(t *testing.T) {
+	svc := new(mocks.Service)
+	server := startGRPCServer(svc, port)
+	defer server.GracefulStop()
+	authAddr := fmt.Sprintf("localhost:%d", port)
+	conn, _ := grpc.NewClient(authAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	client := grpcapi.NewClient(conn, time.Second)
+
+	enabledClient := validClient
+	enabledClient.Credentials.Secret = validSecret
+	disabledClient := enabledClient
+	disabledClient.Status = clients.DisabledStatus
+
+	cases := []struct {
+		desc   string
+		id     string
+		svcRes clients.Client
+		resp   *grpcClientsV1.RetrieveSecretRes
+		svcErr error
+		err    error
+	}{
+		{
+			desc:   "retrieve secret successfully",
+			id:     validID,
+			svcRes: enabledClient,
+			resp:   &grpcClientsV1.RetrieveSecretRes{Secret: validSecret},
+			err:    nil,
+		},
+		{
+			desc:   "retrieve secret of disabled client",
+			id:     validID,
+			svcRes: disabledClient,
+			resp:   &grpcClientsV1.RetrieveSecretRes{},
+			err:    svcerr.ErrAuthentication,
+		},
+		{
+			desc:   "retrieve secret with invalid ID",
+			id:     "invalidID",
+			resp:   &grpcClientsV1.RetrieveSecretRes{},
+			svcErr: svcerr.ErrNotFound,
+			err:    svcerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svcCall := svc.On("RetrieveById", mock.Anything, tc.id).Return(tc.svcRes, tc.svcErr)
+			res, err := client.RetrieveSecret(context.Background(), &grpcClientsV1.RetrieveSecretReq{Id: tc.id})
+			assert.True(t, errors.Contains(err, tc.err))
+			assert.Equal(t, tc.resp, res)
+			svcCall.Unset()
+		})
+	}
+}
+
 func TestRetrieveEntities(t *testing.T) {
 	svc := new(mocks.Service)
 	server := startGRPCServer(svc, port)
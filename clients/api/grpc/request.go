@@ -20,3 +20,7 @@ type removeChannelConnectionsReq struct {
 type UnsetParentGroupFromClientReq struct {
 	parentGroupID string
 }
+
+type retrieveSecretReq struct {
+	id string
+}
@@ -40,3 +40,7 @@ type connectionsRes struct {
 type removeChannelConnectionsRes struct{}
 
 type UnsetParentGroupFromClientRes struct{}
+
+type retrieveSecretRes struct {
+	secret string
+}
@@ -27,6 +27,7 @@ type grpcServer struct {
 	removeConnections          kitgrpc.Handler
 	removeChannelConnections   kitgrpc.Handler
 	unsetParentGroupFromClient kitgrpc.Handler
+	retrieveSecret             kitgrpc.Handler
 }
 
 // NewServer returns new AuthServiceServer instance.
@@ -67,6 +68,11 @@ func NewServer(svc clients.Service) grpcClientsV1.ClientsServiceServer {
 			decodeUnsetParentGroupFromClientRequest,
 			encodeUnsetParentGroupFromClientResponse,
 		),
+		retrieveSecret: kitgrpc.NewServer(
+			retrieveSecretEndpoint(svc),
+			decodeRetrieveSecretRequest,
+			encodeRetrieveSecretResponse,
+		),
 	}
 }
 
@@ -261,6 +267,28 @@ func encodeUnsetParentGroupFromClientResponse(_ context.Context, grpcRes interfa
 	return &grpcClientsV1.UnsetParentGroupFromClientRes{}, nil
 }
 
+func (s *grpcServer) RetrieveSecret(ctx context.Context, req *grpcClientsV1.RetrieveSecretReq) (*grpcClientsV1.RetrieveSecretRes, error) {
+	_, res, err := s.retrieveSecret.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcClientsV1.RetrieveSecretRes), nil
+}
+
+func decodeRetrieveSecretRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcClientsV1.RetrieveSecretReq)
+
+	return retrieveSecretReq{
+		id: req.GetId(),
+	}, nil
+}
+
+func encodeRetrieveSecretResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(retrieveSecretRes)
+
+	return &grpcClientsV1.RetrieveSecretRes{Secret: res.secret}, nil
+}
+
 func encodeError(err error) error {
 	switch {
 	case errors.Contains(err, nil):
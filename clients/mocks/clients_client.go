@@ -471,6 +471,80 @@ func (_c *ClientsServiceClient_RetrieveEntity_Call) RunAndReturn(run func(contex
 	return _c
 }
 
+// RetrieveSecret provides a mock function with given fields: ctx, in, opts
+func (_m *ClientsServiceClient) RetrieveSecret(ctx context.Context, in *clientsv1.RetrieveSecretReq, opts ...grpc.CallOption) (*clientsv1.RetrieveSecretRes, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveSecret")
+	}
+
+	var r0 *clientsv1.RetrieveSecretRes
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *clientsv1.RetrieveSecretReq, ...grpc.CallOption) (*clientsv1.RetrieveSecretRes, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *clientsv1.RetrieveSecretReq, ...grpc.CallOption) *clientsv1.RetrieveSecretRes); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*clientsv1.RetrieveSecretRes)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *clientsv1.RetrieveSecretReq, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClientsServiceClient_RetrieveSecret_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveSecret'
+type ClientsServiceClient_RetrieveSecret_Call struct {
+	*mock.Call
+}
+
+// RetrieveSecret is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *clientsv1.RetrieveSecretReq
+//   - opts ...grpc.CallOption
+func (_e *ClientsServiceClient_Expecter) RetrieveSecret(ctx interface{}, in interface{}, opts ...interface{}) *ClientsServiceClient_RetrieveSecret_Call {
+	return &ClientsServiceClient_RetrieveSecret_Call{Call: _e.mock.On("RetrieveSecret",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *ClientsServiceClient_RetrieveSecret_Call) Run(run func(ctx context.Context, in *clientsv1.RetrieveSecretReq, opts ...grpc.CallOption)) *ClientsServiceClient_RetrieveSecret_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]grpc.CallOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(grpc.CallOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*clientsv1.RetrieveSecretReq), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *ClientsServiceClient_RetrieveSecret_Call) Return(_a0 *clientsv1.RetrieveSecretRes, _a1 error) *ClientsServiceClient_RetrieveSecret_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *ClientsServiceClient_RetrieveSecret_Call) RunAndReturn(run func(context.Context, *clientsv1.RetrieveSecretReq, ...grpc.CallOption) (*clientsv1.RetrieveSecretRes, error)) *ClientsServiceClient_RetrieveSecret_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UnsetParentGroupFromClient provides a mock function with given fields: ctx, in, opts
 func (_m *ClientsServiceClient) UnsetParentGroupFromClient(ctx context.Context, in *clientsv1.UnsetParentGroupFromClientReq, opts ...grpc.CallOption) (*clientsv1.UnsetParentGroupFromClientRes, error) {
 	_va := make([]interface{}, len(opts))
@@ -0,0 +1,197 @@
+// Package main contains archive-writer main function to start the archive-writer service.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/hantdev/mitras/consumers"
+	consumertracing "github.com/hantdev/mitras/consumers/tracing"
+	"github.com/hantdev/mitras/consumers/writers/api"
+	"github.com/hantdev/mitras/consumers/writers/archive"
+	smqlog "github.com/hantdev/mitras/logger"
+	jaegerclient "github.com/hantdev/mitras/pkg/jaeger"
+	"github.com/hantdev/mitras/pkg/messaging/brokers"
+	brokerstracing "github.com/hantdev/mitras/pkg/messaging/brokers/tracing"
+	"github.com/hantdev/mitras/pkg/prometheus"
+	"github.com/hantdev/mitras/pkg/server"
+	httpserver "github.com/hantdev/mitras/pkg/server/http"
+	"github.com/hantdev/mitras/pkg/ulid"
+	"github.com/hantdev/mitras/pkg/uuid"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	svcName        = "archive-writer"
+	envPrefixS3    = "MITRAS_ARCHIVE_WRITER_S3_"
+	envPrefixHTTP  = "MITRAS_ARCHIVE_WRITER_HTTP_"
+	defSvcHTTPPort = "9014"
+	fsStorage      = "fs"
+	s3Storage      = "s3"
+)
+
+type config struct {
+	LogLevel      string        `env:"MITRAS_ARCHIVE_WRITER_LOG_LEVEL"     envDefault:"info"`
+	ConfigPath    string        `env:"MITRAS_ARCHIVE_WRITER_CONFIG_PATH"   envDefault:"/config.toml"`
+	Storage       string        `env:"MITRAS_ARCHIVE_WRITER_STORAGE"       envDefault:"fs"`
+	Dir           string        `env:"MITRAS_ARCHIVE_WRITER_DIR"           envDefault:"/archive"`
+	Prefix        string        `env:"MITRAS_ARCHIVE_WRITER_PREFIX"        envDefault:""`
+	Encoding      string        `env:"MITRAS_ARCHIVE_WRITER_ENCODING"      envDefault:"ndjson"`
+	MaxSize       int64         `env:"MITRAS_ARCHIVE_WRITER_MAX_SIZE"      envDefault:"67108864"`
+	MaxAge        time.Duration `env:"MITRAS_ARCHIVE_WRITER_MAX_AGE"       envDefault:"1h"`
+	BrokerURL     string        `env:"MITRAS_MESSAGE_BROKER_URL"           envDefault:"nats://localhost:4222"`
+	JaegerURL     url.URL       `env:"MITRAS_JAEGER_URL"                   envDefault:"http://localhost:4318/v1/traces"`
+	SendTelemetry bool          `env:"MITRAS_SEND_TELEMETRY"               envDefault:"true"`
+	InstanceID    string        `env:"MITRAS_ARCHIVE_WRITER_INSTANCE_ID"   envDefault:""`
+	TraceRatio    float64       `env:"MITRAS_JAEGER_TRACE_RATIO"           envDefault:"1.0"`
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	g, ctx := errgroup.WithContext(ctx)
+
+	cfg := config{}
+	if err := env.Parse(&cfg); err != nil {
+		log.Fatalf("failed to load %s service configuration : %s", svcName, err)
+	}
+
+	logger, err := smqlog.New(os.Stdout, cfg.LogLevel)
+	if err != nil {
+		log.Fatalf("failed to init logger: %s", err.Error())
+	}
+
+	var exitCode int
+	defer smqlog.ExitWithError(&exitCode)
+
+	if cfg.InstanceID == "" {
+		if cfg.InstanceID, err = uuid.New().ID(); err != nil {
+			logger.Error(fmt.Sprintf("failed to generate instanceID: %s", err))
+			exitCode = 1
+			return
+		}
+	}
+
+	httpServerConfig := server.Config{Port: defSvcHTTPPort}
+	if err := env.ParseWithOptions(&httpServerConfig, env.Options{Prefix: envPrefixHTTP}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load %s HTTP server configuration : %s", svcName, err))
+		exitCode = 1
+		return
+	}
+
+	var storage archive.Storage
+	switch cfg.Storage {
+	case fsStorage:
+		storage = archive.NewFSStorage(cfg.Dir)
+	case s3Storage:
+		s3Config := archive.S3Config{}
+		if err := env.ParseWithOptions(&s3Config, env.Options{Prefix: envPrefixS3}); err != nil {
+			logger.Error(fmt.Sprintf("failed to load %s S3 configuration : %s", svcName, err))
+			exitCode = 1
+			return
+		}
+		storage = archive.NewS3Storage(s3Config, nil)
+	default:
+		logger.Error(fmt.Sprintf("invalid %s storage %q", svcName, cfg.Storage))
+		exitCode = 1
+		return
+	}
+
+	arch, err := archive.New(storage, ulid.New(), archive.Config{
+		Encoding: cfg.Encoding,
+		Prefix:   cfg.Prefix,
+		MaxSize:  cfg.MaxSize,
+		MaxAge:   cfg.MaxAge,
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to create archive: %s", err))
+		exitCode = 1
+		return
+	}
+
+	tp, err := jaegerclient.NewProvider(ctx, svcName, cfg.JaegerURL, cfg.InstanceID, cfg.TraceRatio)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to init Jaeger: %s", err))
+		exitCode = 1
+		return
+	}
+	defer func() {
+		if err := tp.Shutdown(ctx); err != nil {
+			logger.Error(fmt.Sprintf("Error shutting down tracer provider: %v", err))
+		}
+	}()
+	tracer := tp.Tracer(svcName)
+
+	repo := newService(arch, logger)
+	repo = consumertracing.NewBlocking(tracer, repo, httpServerConfig)
+
+	pubSub, err := brokers.NewPubSub(ctx, cfg.BrokerURL, logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to connect to message broker: %s", err))
+		exitCode = 1
+		return
+	}
+	defer pubSub.Close()
+	pubSub = brokerstracing.NewPubSub(httpServerConfig, tracer, pubSub)
+
+	if err = consumers.Start(ctx, svcName, pubSub, repo, cfg.ConfigPath, logger); err != nil {
+		logger.Error(fmt.Sprintf("failed to create Archive writer: %s", err))
+		exitCode = 1
+		return
+	}
+
+	hs := httpserver.NewServer(ctx, cancel, svcName, httpServerConfig, api.MakeHandler(svcName, cfg.InstanceID), logger)
+
+	g.Go(func() error {
+		return hs.Start()
+	})
+
+	g.Go(func() error {
+		return flushArchive(ctx, arch, cfg.MaxAge, logger)
+	})
+
+	g.Go(func() error {
+		return server.StopSignalHandler(ctx, cancel, logger, svcName, hs)
+	})
+
+	if err := g.Wait(); err != nil {
+		logger.Error(fmt.Sprintf("Archive writer service terminated: %s", err))
+	}
+}
+
+func newService(arch archive.Archive, logger *slog.Logger) consumers.BlockingConsumer {
+	svc := api.LoggingMiddleware(arch, logger)
+	counter, latency := prometheus.MakeMetrics("archive", "message_writer")
+	svc = api.MetricsMiddleware(svc, counter, latency)
+	return svc
+}
+
+// flushArchive periodically writes partitions older than the maximum age
+// until the context is canceled, when all buffered partitions are written.
+func flushArchive(ctx context.Context, arch archive.Archive, maxAge time.Duration, logger *slog.Logger) error {
+	interval := maxAge / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := arch.Flush(context.Background(), true); err != nil {
+				logger.Error(fmt.Sprintf("failed to flush archive: %s", err))
+			}
+			return nil
+		case <-ticker.C:
+			if err := arch.Flush(ctx, false); err != nil {
+				logger.Warn(fmt.Sprintf("failed to flush archive: %s", err))
+			}
+		}
+	}
+}
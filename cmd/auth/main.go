@@ -18,6 +18,7 @@ import (
 	authgrpcapi "github.com/hantdev/mitras/auth/api/grpc/auth"
 	tokengrpcapi "github.com/hantdev/mitras/auth/api/grpc/token"
 	httpapi "github.com/hantdev/mitras/auth/api/http"
+	"github.com/hantdev/mitras/auth/cache"
 	"github.com/hantdev/mitras/auth/jwt"
 	apostgres "github.com/hantdev/mitras/auth/postgres"
 	"github.com/hantdev/mitras/auth/tracing"
@@ -46,6 +47,7 @@ const (
 	envPrefixHTTP  = "MITRAS_AUTH_HTTP_"
 	envPrefixGrpc  = "MITRAS_AUTH_GRPC_"
 	envPrefixDB    = "MITRAS_AUTH_DB_"
+	envPrefixSign  = "MITRAS_AUTH_SIGNING_"
 	defDB          = "auth"
 	defSvcHTTPPort = "8189"
 	defSvcGRPCPort = "8181"
@@ -60,6 +62,7 @@ type config struct {
 	AccessDuration      time.Duration `env:"MITRAS_AUTH_ACCESS_TOKEN_DURATION"   envDefault:"1h"`
 	RefreshDuration     time.Duration `env:"MITRAS_AUTH_REFRESH_TOKEN_DURATION"  envDefault:"24h"`
 	InvitationDuration  time.Duration `env:"MITRAS_AUTH_INVITATION_DURATION"     envDefault:"168h"`
+	SessionCacheReload  time.Duration `env:"MITRAS_AUTH_SESSION_CACHE_RELOAD"    envDefault:"10s"`
 	SpicedbHost         string        `env:"MITRAS_SPICEDB_HOST"                 envDefault:"localhost"`
 	SpicedbPort         string        `env:"MITRAS_SPICEDB_PORT"                 envDefault:"50051"`
 	SpicedbSchemaFile   string        `env:"MITRAS_SPICEDB_SCHEMA_FILE"          envDefault:"./docker/spicedb/schema.zed"`
@@ -126,7 +129,19 @@ func main() {
 		exitCode = 1
 		return
 	}
-	svc := newService(ctx, db, tracer, cfg, dbConfig, logger, spicedbclient)
+	signingConfig := jwt.Config{}
+	if err := env.ParseWithOptions(&signingConfig, env.Options{Prefix: envPrefixSign}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load %s signing configuration : %s", svcName, err))
+		exitCode = 1
+		return
+	}
+
+	svc, err := newService(ctx, db, tracer, cfg, signingConfig, dbConfig, logger, spicedbclient)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to create %s service: %s", svcName, err))
+		exitCode = 1
+		return
+	}
 
 	grpcServerConfig := server.Config{Port: defSvcGRPCPort}
 	if err := env.ParseWithOptions(&grpcServerConfig, env.Options{Prefix: envPrefixGrpc}); err != nil {
@@ -197,7 +212,7 @@ func initSchema(ctx context.Context, client *authzed.ClientWithExperimental, sch
 	return nil
 }
 
-func newService(_ context.Context, db *sqlx.DB, tracer trace.Tracer, cfg config, dbConfig pgclient.Config, logger *slog.Logger, spicedbClient *authzed.ClientWithExperimental) auth.Service {
+func newService(ctx context.Context, db *sqlx.DB, tracer trace.Tracer, cfg config, signingConfig jwt.Config, dbConfig pgclient.Config, logger *slog.Logger, spicedbClient *authzed.ClientWithExperimental) (auth.Service, error) {
 	database := postgres.NewDatabase(db, dbConfig, tracer)
 	keysRepo := apostgres.New(database)
 	idProvider := uuid.New()
@@ -206,12 +221,27 @@ func newService(_ context.Context, db *sqlx.DB, tracer trace.Tracer, cfg config,
 	pService := spicedb.NewPolicyService(spicedbClient, logger)
 
 	t := jwt.New([]byte(cfg.SecretKey))
+	if signingConfig.Asymmetric() {
+		// The shared secret is kept to verify the tokens issued before
+		// switching to the asymmetric signing.
+		kt, err := jwt.NewKeyTokenizer(signingConfig, apostgres.NewSigningKeyRepository(database), []byte(cfg.SecretKey))
+		if err != nil {
+			return nil, err
+		}
+		t = kt
+	}
+
+	sessionsRepo := apostgres.NewSessionRepository(database)
+	sessionCache, err := cache.NewSessionCache(ctx, sessionsRepo, cfg.SessionCacheReload, logger)
+	if err != nil {
+		return nil, err
+	}
 
-	svc := auth.New(keysRepo, idProvider, t, pEvaluator, pService, cfg.AccessDuration, cfg.RefreshDuration, cfg.InvitationDuration)
+	svc := auth.New(keysRepo, sessionsRepo, sessionCache, idProvider, t, pEvaluator, pService, cfg.AccessDuration, cfg.RefreshDuration, cfg.InvitationDuration)
 	svc = api.LoggingMiddleware(svc, logger)
 	counter, latency := prometheus.MakeMetrics("auth", "api")
 	svc = api.MetricsMiddleware(svc, counter, latency)
 	svc = tracing.New(svc, tracer)
 
-	return svc
+	return svc, nil
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/hantdev/mitras/certs/tracing"
 	smqlog "github.com/hantdev/mitras/logger"
 	authsvcAuthn "github.com/hantdev/mitras/pkg/authn/authsvc"
+	"github.com/hantdev/mitras/pkg/authn/jwks"
 	"github.com/hantdev/mitras/pkg/grpcclient"
 	jaegerclient "github.com/hantdev/mitras/pkg/jaeger"
 	"github.com/hantdev/mitras/pkg/prometheus"
@@ -52,6 +53,7 @@ type config struct {
 	SDKHost         string `env:"MITRAS_CERTS_SDK_HOST"             envDefault:""`
 	SDKCertsURL     string `env:"MITRAS_CERTS_SDK_CERTS_URL"        envDefault:"http://localhost:9010"`
 	TLSVerification bool   `env:"MITRAS_CERTS_SDK_TLS_VERIFICATION" envDefault:"false"`
+	AuthJWKSURL     string `env:"MITRAS_AUTH_JWKS_URL"              envDefault:""`
 }
 
 func main() {
@@ -107,6 +109,16 @@ func main() {
 	defer authnClient.Close()
 	logger.Info("AuthN successfully connected to auth gRPC server " + authnClient.Secure())
 
+	if cfg.AuthJWKSURL != "" {
+		authn, err = jwks.NewAuthentication(ctx, cfg.AuthJWKSURL, authn)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to create JWKS authn : %s", err))
+			exitCode = 1
+			return
+		}
+		logger.Info("AuthN verifies tokens locally using JWKS from " + cfg.AuthJWKSURL)
+	}
+
 	tp, err := jaegerclient.NewProvider(ctx, svcName, cfg.JaegerURL, cfg.InstanceID, cfg.TraceRatio)
 	if err != nil {
 		logger.Error(fmt.Sprintf("failed to init Jaeger: %s", err))
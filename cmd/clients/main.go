@@ -29,6 +29,7 @@ import (
 	grpcGroupsV1 "github.com/hantdev/mitras/internal/grpc/groups/v1"
 	smqlog "github.com/hantdev/mitras/logger"
 	authsvcAuthn "github.com/hantdev/mitras/pkg/authn/authsvc"
+	"github.com/hantdev/mitras/pkg/authn/jwks"
 	smqauthz "github.com/hantdev/mitras/pkg/authz"
 	authsvcAuthz "github.com/hantdev/mitras/pkg/authz/authsvc"
 	"github.com/hantdev/mitras/pkg/grpcclient"
@@ -79,6 +80,7 @@ type config struct {
 	SpicedbHost         string        `env:"MITRAS_SPICEDB_HOST"               envDefault:"localhost"`
 	SpicedbPort         string        `env:"MITRAS_SPICEDB_PORT"               envDefault:"50051"`
 	SpicedbPreSharedKey string        `env:"MITRAS_SPICEDB_PRE_SHARED_KEY"     envDefault:"12345678"`
+	AuthJWKSURL         string        `env:"MITRAS_AUTH_JWKS_URL"              envDefault:""`
 }
 
 func main() {
@@ -174,6 +176,16 @@ func main() {
 	defer authnClient.Close()
 	logger.Info("AuthN  successfully connected to auth gRPC server " + authnClient.Secure())
 
+	if cfg.AuthJWKSURL != "" {
+		authn, err = jwks.NewAuthentication(ctx, cfg.AuthJWKSURL, authn)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to create JWKS authn : %s", err))
+			exitCode = 1
+			return
+		}
+		logger.Info("AuthN verifies tokens locally using JWKS from " + cfg.AuthJWKSURL)
+	}
+
 	authz, authzClient, err := authsvcAuthz.NewAuthorization(ctx, grpcCfg)
 	if err != nil {
 		logger.Error(err.Error())
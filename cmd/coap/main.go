@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/hantdev/mitras/coap"
@@ -29,6 +30,8 @@ const (
 	svcName           = "coap_adapter"
 	envPrefix         = "MITRAS_COAP_ADAPTER_"
 	envPrefixHTTP     = "MITRAS_COAP_ADAPTER_HTTP_"
+	envPrefixTCP      = "MITRAS_COAP_ADAPTER_TCP_"
+	envPrefixWS       = "MITRAS_COAP_ADAPTER_WS_"
 	envPrefixClients  = "MITRAS_CLIENTS_AUTH_GRPC_"
 	envPrefixChannels = "MITRAS_CHANNELS_GRPC_"
 	defSvcHTTPPort    = "5683"
@@ -36,12 +39,17 @@ const (
 )
 
 type config struct {
-	LogLevel      string  `env:"MITRAS_COAP_ADAPTER_LOG_LEVEL"   envDefault:"info"`
-	BrokerURL     string  `env:"MITRAS_MESSAGE_BROKER_URL"       envDefault:"nats://localhost:4222"`
-	JaegerURL     url.URL `env:"MITRAS_JAEGER_URL"               envDefault:"http://localhost:4318/v1/traces"`
-	SendTelemetry bool    `env:"MITRAS_SEND_TELEMETRY"           envDefault:"true"`
-	InstanceID    string  `env:"MITRAS_COAP_ADAPTER_INSTANCE_ID" envDefault:""`
-	TraceRatio    float64 `env:"MITRAS_JAEGER_TRACE_RATIO"       envDefault:"1.0"`
+	LogLevel                 string        `env:"MITRAS_COAP_ADAPTER_LOG_LEVEL"                  envDefault:"info"`
+	BrokerURL                string        `env:"MITRAS_MESSAGE_BROKER_URL"                      envDefault:"nats://localhost:4222"`
+	JaegerURL                url.URL       `env:"MITRAS_JAEGER_URL"                              envDefault:"http://localhost:4318/v1/traces"`
+	SendTelemetry            bool          `env:"MITRAS_SEND_TELEMETRY"                          envDefault:"true"`
+	InstanceID               string        `env:"MITRAS_COAP_ADAPTER_INSTANCE_ID"                envDefault:""`
+	TraceRatio               float64       `env:"MITRAS_JAEGER_TRACE_RATIO"                      envDefault:"1.0"`
+	DTLSPSK                  bool          `env:"MITRAS_COAP_ADAPTER_DTLS_PSK"                   envDefault:"false"`
+	MaxPayloadSize           int64         `env:"MITRAS_COAP_ADAPTER_MAX_PAYLOAD_SIZE"           envDefault:"1048576"`
+	BlockSize                int           `env:"MITRAS_COAP_ADAPTER_BLOCK_SIZE"                 envDefault:"1024"`
+	MaxMessageSize           uint32        `env:"MITRAS_COAP_ADAPTER_MAX_MESSAGE_SIZE"           envDefault:"65536"`
+	BlockwiseTransferTimeout time.Duration `env:"MITRAS_COAP_ADAPTER_BLOCKWISE_TRANSFER_TIMEOUT" envDefault:"10s"`
 }
 
 func main() {
@@ -83,6 +91,20 @@ func main() {
 		return
 	}
 
+	tcpServerConfig := server.Config{}
+	if err := env.ParseWithOptions(&tcpServerConfig, env.Options{Prefix: envPrefixTCP}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load %s CoAP over TCP server configuration : %s", svcName, err))
+		exitCode = 1
+		return
+	}
+
+	wsServerConfig := server.Config{}
+	if err := env.ParseWithOptions(&wsServerConfig, env.Options{Prefix: envPrefixWS}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load %s CoAP over WebSockets server configuration : %s", svcName, err))
+		exitCode = 1
+		return
+	}
+
 	clientsClientCfg := grpcclient.Config{}
 	if err := env.ParseWithOptions(&clientsClientCfg, env.Options{Prefix: envPrefixClients}); err != nil {
 		logger.Error(fmt.Sprintf("failed to load %s auth configuration : %s", svcName, err))
@@ -149,16 +171,32 @@ func main() {
 
 	hs := httpserver.NewServer(ctx, cancel, svcName, httpServerConfig, api.MakeHandler(cfg.InstanceID), logger)
 
-	cs := coapserver.NewServer(ctx, cancel, svcName, coapServerConfig, api.MakeCoAPHandler(svc, logger), logger)
+	blockwise := coapserver.WithBlockwise(cfg.BlockSize, cfg.BlockwiseTransferTimeout)
+	maxMessageSize := coapserver.WithMaxMessageSize(cfg.MaxMessageSize)
+	coapOpts := []coapserver.Option{blockwise, maxMessageSize}
+	if cfg.DTLSPSK {
+		coapOpts = append(coapOpts, coapserver.WithPSK(coap.PSK(clientsClient)))
+	}
+	coapHandler := api.MakeCoAPHandler(svc, logger, cfg.MaxPayloadSize)
+	servers := []server.Server{
+		hs,
+		coapserver.NewServer(ctx, cancel, svcName, coapServerConfig, coapHandler, logger, coapOpts...),
+	}
+	// CoAP over TCP and WebSockets servers are started only if configured.
+	if tcpServerConfig.Port != "" {
+		servers = append(servers, coapserver.NewServer(ctx, cancel, svcName, tcpServerConfig, coapHandler, logger, blockwise, maxMessageSize, coapserver.WithTransport(coapserver.TCP)))
+	}
+	if wsServerConfig.Port != "" {
+		servers = append(servers, coapserver.NewServer(ctx, cancel, svcName, wsServerConfig, coapHandler, logger, blockwise, maxMessageSize, coapserver.WithTransport(coapserver.WS)))
+	}
 
+	for _, srv := range servers {
+		g.Go(func() error {
+			return srv.Start()
+		})
+	}
 	g.Go(func() error {
-		return hs.Start()
-	})
-	g.Go(func() error {
-		return cs.Start()
-	})
-	g.Go(func() error {
-		return server.StopSignalHandler(ctx, cancel, logger, svcName, hs, cs)
+		return server.StopSignalHandler(ctx, cancel, logger, svcName, servers...)
 	})
 
 	if err := g.Wait(); err != nil {
@@ -24,6 +24,7 @@ import (
 	grpcDomainsV1 "github.com/hantdev/mitras/internal/grpc/domains/v1"
 	smqlog "github.com/hantdev/mitras/logger"
 	authsvcAuthn "github.com/hantdev/mitras/pkg/authn/authsvc"
+	"github.com/hantdev/mitras/pkg/authn/jwks"
 	"github.com/hantdev/mitras/pkg/authz"
 	authsvcAuthz "github.com/hantdev/mitras/pkg/authz/authsvc"
 	"github.com/hantdev/mitras/pkg/grpcclient"
@@ -70,6 +71,7 @@ type config struct {
 	SpicedbPreSharedKey string  `env:"MITRAS_SPICEDB_PRE_SHARED_KEY"       envDefault:"12345678"`
 	TraceRatio          float64 `env:"MITRAS_JAEGER_TRACE_RATIO"           envDefault:"1.0"`
 	ESURL               string  `env:"MITRAS_ES_URL"                       envDefault:"nats://localhost:4222"`
+	AuthJWKSURL         string  `env:"MITRAS_AUTH_JWKS_URL"                envDefault:""`
 }
 
 func main() {
@@ -148,6 +150,16 @@ func main() {
 	defer authnHandler.Close()
 	logger.Info("Authn successfully connected to auth gRPC server " + authnHandler.Secure())
 
+	if cfg.AuthJWKSURL != "" {
+		authn, err = jwks.NewAuthentication(ctx, cfg.AuthJWKSURL, authn)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to create JWKS authn : %s", err))
+			exitCode = 1
+			return
+		}
+		logger.Info("AuthN verifies tokens locally using JWKS from " + cfg.AuthJWKSURL)
+	}
+
 	authz, authzHandler, err := authsvcAuthz.NewAuthorization(ctx, clientConfig)
 	if err != nil {
 		logger.Error(fmt.Sprintf("authz failed to connect to auth gRPC server : %s", err.Error()))
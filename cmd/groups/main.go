@@ -27,6 +27,7 @@ import (
 	grpcGroupsV1 "github.com/hantdev/mitras/internal/grpc/groups/v1"
 	smqlog "github.com/hantdev/mitras/logger"
 	authsvcAuthn "github.com/hantdev/mitras/pkg/authn/authsvc"
+	"github.com/hantdev/mitras/pkg/authn/jwks"
 	smqauthz "github.com/hantdev/mitras/pkg/authz"
 	authsvcAuthz "github.com/hantdev/mitras/pkg/authz/authsvc"
 	"github.com/hantdev/mitras/pkg/grpcclient"
@@ -76,6 +77,7 @@ type config struct {
 	SpicedbPort         string  `env:"MITRAS_SPICEDB_PORT"              envDefault:"50051"`
 	SpicedbSchemaFile   string  `env:"MITRAS_SPICEDB_SCHEMA_FILE"       envDefault:"schema.zed"`
 	SpicedbPreSharedKey string  `env:"MITRAS_SPICEDB_PRE_SHARED_KEY"    envDefault:"12345678"`
+	AuthJWKSURL         string  `env:"MITRAS_AUTH_JWKS_URL"             envDefault:""`
 }
 
 func main() {
@@ -152,6 +154,16 @@ func main() {
 	defer authnHandler.Close()
 	logger.Info("Authn successfully connected to auth gRPC server " + authnHandler.Secure())
 
+	if cfg.AuthJWKSURL != "" {
+		authn, err = jwks.NewAuthentication(ctx, cfg.AuthJWKSURL, authn)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to create JWKS authn : %s", err))
+			exitCode = 1
+			return
+		}
+		logger.Info("AuthN verifies tokens locally using JWKS from " + cfg.AuthJWKSURL)
+	}
+
 	authz, authzHandler, err := authsvcAuthz.NewAuthorization(ctx, authClientConfig)
 	if err != nil {
 		logger.Error("failed to create authz " + err.Error())
@@ -10,9 +10,10 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"time"
 
-	"github.com/hantdev/hermina"
 	"github.com/caarlos0/env/v11"
+	"github.com/hantdev/hermina"
 	mgatehttp "github.com/hantdev/hermina/pkg/http"
 	"github.com/hantdev/hermina/pkg/session"
 	adapter "github.com/hantdev/mitras/http"
@@ -22,6 +23,7 @@ import (
 	smqlog "github.com/hantdev/mitras/logger"
 	smqauthn "github.com/hantdev/mitras/pkg/authn"
 	"github.com/hantdev/mitras/pkg/authn/authsvc"
+	"github.com/hantdev/mitras/pkg/authn/jwks"
 	"github.com/hantdev/mitras/pkg/grpcclient"
 	jaegerclient "github.com/hantdev/mitras/pkg/jaeger"
 	"github.com/hantdev/mitras/pkg/messaging"
@@ -48,12 +50,17 @@ const (
 )
 
 type config struct {
-	LogLevel      string  `env:"SMQ_HTTP_ADAPTER_LOG_LEVEL"   envDefault:"info"`
-	BrokerURL     string  `env:"SMQ_MESSAGE_BROKER_URL"       envDefault:"nats://localhost:4222"`
-	JaegerURL     url.URL `env:"SMQ_JAEGER_URL"               envDefault:"http://localhost:4318/v1/traces"`
-	SendTelemetry bool    `env:"SMQ_SEND_TELEMETRY"           envDefault:"true"`
-	InstanceID    string  `env:"SMQ_HTTP_ADAPTER_INSTANCE_ID" envDefault:""`
-	TraceRatio    float64 `env:"SMQ_JAEGER_TRACE_RATIO"       envDefault:"1.0"`
+	LogLevel           string        `env:"SMQ_HTTP_ADAPTER_LOG_LEVEL"            envDefault:"info"`
+	BrokerURL          string        `env:"SMQ_MESSAGE_BROKER_URL"                envDefault:"nats://localhost:4222"`
+	JaegerURL          url.URL       `env:"SMQ_JAEGER_URL"                        envDefault:"http://localhost:4318/v1/traces"`
+	SendTelemetry      bool          `env:"SMQ_SEND_TELEMETRY"                    envDefault:"true"`
+	InstanceID         string        `env:"SMQ_HTTP_ADAPTER_INSTANCE_ID"          envDefault:""`
+	TraceRatio         float64       `env:"SMQ_JAEGER_TRACE_RATIO"                envDefault:"1.0"`
+	SSEBufferSize      int           `env:"SMQ_HTTP_ADAPTER_SSE_BUFFER_SIZE"      envDefault:"100"`
+	SSEBufferRetention time.Duration `env:"SMQ_HTTP_ADAPTER_SSE_BUFFER_RETENTION" envDefault:"1m"`
+	SSEHeartbeat       time.Duration `env:"SMQ_HTTP_ADAPTER_SSE_HEARTBEAT"        envDefault:"15s"`
+	MaxIngestSize      int64         `env:"SMQ_HTTP_ADAPTER_MAX_INGEST_SIZE"      envDefault:"33554432"`
+	AuthJWKSURL        string        `env:"MITRAS_AUTH_JWKS_URL"                  envDefault:""`
 }
 
 func main() {
@@ -136,6 +143,16 @@ func main() {
 	defer authnHandler.Close()
 	logger.Info("authn successfully connected to auth gRPC server " + authnHandler.Secure())
 
+	if cfg.AuthJWKSURL != "" {
+		authn, err = jwks.NewAuthentication(ctx, cfg.AuthJWKSURL, authn)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to create JWKS authn : %s", err))
+			exitCode = 1
+			return
+		}
+		logger.Info("AuthN verifies tokens locally using JWKS from " + cfg.AuthJWKSURL)
+	}
+
 	tp, err := jaegerclient.NewProvider(ctx, svcName, cfg.JaegerURL, cfg.InstanceID, cfg.TraceRatio)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to init Jaeger: %s", err))
@@ -149,26 +166,27 @@ func main() {
 	}()
 	tracer := tp.Tracer(svcName)
 
-	pub, err := brokers.NewPublisher(ctx, cfg.BrokerURL)
+	nps, err := brokers.NewPubSub(ctx, cfg.BrokerURL, logger)
 	if err != nil {
 		logger.Error(fmt.Sprintf("failed to connect to message broker: %s", err))
 		exitCode = 1
 		return
 	}
-	defer pub.Close()
-	pub = brokerstracing.NewPublisher(httpServerConfig, tracer, pub)
+	defer nps.Close()
+	nps = brokerstracing.NewPubSub(httpServerConfig, tracer, nps)
 
-	svc := newService(pub, authn, clientsClient, channelsClient, logger, tracer)
+	svc := newService(nps, authn, clientsClient, channelsClient, cfg.MaxIngestSize, logger, tracer)
+	streams := newStreamService(nps, authn, clientsClient, channelsClient, cfg, logger)
 	targetServerCfg := server.Config{Port: targetHTTPPort}
 
-	hs := httpserver.NewServer(ctx, cancel, svcName, targetServerCfg, api.MakeHandler(logger, cfg.InstanceID), logger)
+	hs := httpserver.NewServer(ctx, cancel, svcName, targetServerCfg, api.MakeHandler(streams, cfg.SSEHeartbeat, logger, cfg.InstanceID), logger)
 
 	g.Go(func() error {
 		return hs.Start()
 	})
 
 	g.Go(func() error {
-		return proxyHTTP(ctx, httpServerConfig, logger, svc)
+		return proxyHTTP(ctx, httpServerConfig, cfg.MaxIngestSize, logger, svc)
 	})
 
 	g.Go(func() error {
@@ -180,8 +198,8 @@ func main() {
 	}
 }
 
-func newService(pub messaging.Publisher, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient, logger *slog.Logger, tracer trace.Tracer) session.Handler {
-	svc := adapter.NewHandler(pub, authn, clients, channels, logger)
+func newService(pub messaging.Publisher, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient, maxIngestSize int64, logger *slog.Logger, tracer trace.Tracer) session.Handler {
+	svc := adapter.NewHandler(pub, authn, clients, channels, maxIngestSize, logger)
 	svc = handler.NewTracing(tracer, svc)
 	svc = handler.LoggingMiddleware(svc, logger)
 	counter, latency := prometheus.MakeMetrics(svcName, "api")
@@ -189,7 +207,15 @@ func newService(pub messaging.Publisher, authn smqauthn.Authentication, clients
 	return svc
 }
 
-func proxyHTTP(ctx context.Context, cfg server.Config, logger *slog.Logger, sessionHandler session.Handler) error {
+func newStreamService(nps messaging.PubSub, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient, cfg config, logger *slog.Logger) adapter.Service {
+	svc := adapter.NewService(nps, authn, clients, channels, uuid.New(), cfg.SSEBufferSize, cfg.SSEBufferRetention)
+	svc = api.LoggingMiddleware(svc, logger)
+	counter, latency := prometheus.MakeMetrics(svcName, "stream")
+	svc = api.MetricsMiddleware(svc, counter, latency)
+	return svc
+}
+
+func proxyHTTP(ctx context.Context, cfg server.Config, maxIngestSize int64, logger *slog.Logger, sessionHandler session.Handler) error {
 	config := hermina.Config{
 		Address:    fmt.Sprintf("%s:%s", "", cfg.Port),
 		Target:     fmt.Sprintf("%s:%s", targetHTTPHost, targetHTTPPort),
@@ -208,7 +234,7 @@ func proxyHTTP(ctx context.Context, cfg server.Config, logger *slog.Logger, sess
 	if err != nil {
 		return err
 	}
-	http.HandleFunc("/", mp.ServeHTTP)
+	http.Handle("/", adapter.LimitIngestBody(mp, maxIngestSize))
 
 	errCh := make(chan error)
 	switch {
@@ -17,6 +17,7 @@ import (
 	invitationspg "github.com/hantdev/mitras/invitations/postgres"
 	smqlog "github.com/hantdev/mitras/logger"
 	authsvcAuthn "github.com/hantdev/mitras/pkg/authn/authsvc"
+	"github.com/hantdev/mitras/pkg/authn/jwks"
 	smqauthz "github.com/hantdev/mitras/pkg/authz"
 	authsvcAuthz "github.com/hantdev/mitras/pkg/authz/authsvc"
 	"github.com/hantdev/mitras/pkg/grpcclient"
@@ -50,6 +51,7 @@ type config struct {
 	JaegerURL     url.URL `env:"MITRAS_JAEGER_URL"                 envDefault:"http://localhost:4318/v1/traces"`
 	TraceRatio    float64 `env:"MITRAS_JAEGER_TRACE_RATIO"         envDefault:"1.0"`
 	SendTelemetry bool    `env:"MITRAS_SEND_TELEMETRY"             envDefault:"true"`
+	AuthJWKSURL   string  `env:"MITRAS_AUTH_JWKS_URL"              envDefault:""`
 }
 
 func main() {
@@ -115,6 +117,16 @@ func main() {
 	defer authnHandler.Close()
 	logger.Info("AuthN successfully connected to auth gRPC server " + authnHandler.Secure())
 
+	if cfg.AuthJWKSURL != "" {
+		authn, err = jwks.NewAuthentication(ctx, cfg.AuthJWKSURL, authn)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to create JWKS authn : %s", err))
+			exitCode = 1
+			return
+		}
+		logger.Info("AuthN verifies tokens locally using JWKS from " + cfg.AuthJWKSURL)
+	}
+
 	authz, authzHandler, err := authsvcAuthz.NewAuthorization(ctx, authClientCfg)
 	if err != nil {
 		logger.Error(err.Error())
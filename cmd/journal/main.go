@@ -17,6 +17,7 @@ import (
 	journalpg "github.com/hantdev/mitras/journal/postgres"
 	smqlog "github.com/hantdev/mitras/logger"
 	authsvcAuthn "github.com/hantdev/mitras/pkg/authn/authsvc"
+	"github.com/hantdev/mitras/pkg/authn/jwks"
 	smqauthz "github.com/hantdev/mitras/pkg/authz"
 	authsvcAuthz "github.com/hantdev/mitras/pkg/authz/authsvc"
 	"github.com/hantdev/mitras/pkg/events/store"
@@ -49,6 +50,7 @@ type config struct {
 	SendTelemetry bool    `env:"MITRAS_SEND_TELEMETRY"      envDefault:"true"`
 	InstanceID    string  `env:"MITRAS_JOURNAL_INSTANCE_ID" envDefault:""`
 	TraceRatio    float64 `env:"MITRAS_JAEGER_TRACE_RATIO"  envDefault:"1.0"`
+	AuthJWKSURL   string  `env:"MITRAS_AUTH_JWKS_URL"       envDefault:""`
 }
 
 func main() {
@@ -106,6 +108,16 @@ func main() {
 	defer authnHandler.Close()
 	logger.Info("AuthN successfully connected to auth gRPC server " + authnHandler.Secure())
 
+	if cfg.AuthJWKSURL != "" {
+		authn, err = jwks.NewAuthentication(ctx, cfg.AuthJWKSURL, authn)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to create JWKS authn : %s", err))
+			exitCode = 1
+			return
+		}
+		logger.Info("AuthN verifies tokens locally using JWKS from " + cfg.AuthJWKSURL)
+	}
+
 	authz, authzHandler, err := authsvcAuthz.NewAuthorization(ctx, authClientCfg)
 	if err != nil {
 		logger.Error(err.Error())
@@ -0,0 +1,234 @@
+// Package main contains lwm2m-adapter main function to start the lwm2m-adapter service.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/hantdev/mitras/coap"
+	smqlog "github.com/hantdev/mitras/logger"
+	"github.com/hantdev/mitras/lwm2m"
+	"github.com/hantdev/mitras/lwm2m/api"
+	"github.com/hantdev/mitras/lwm2m/middleware"
+	lwm2mpg "github.com/hantdev/mitras/lwm2m/postgres"
+	authsvcAuthn "github.com/hantdev/mitras/pkg/authn/authsvc"
+	"github.com/hantdev/mitras/pkg/authn/jwks"
+	authsvcAuthz "github.com/hantdev/mitras/pkg/authz/authsvc"
+	"github.com/hantdev/mitras/pkg/grpcclient"
+	jaegerclient "github.com/hantdev/mitras/pkg/jaeger"
+	"github.com/hantdev/mitras/pkg/messaging/brokers"
+	brokerstracing "github.com/hantdev/mitras/pkg/messaging/brokers/tracing"
+	"github.com/hantdev/mitras/pkg/postgres"
+	pgclient "github.com/hantdev/mitras/pkg/postgres"
+	"github.com/hantdev/mitras/pkg/server"
+	coapserver "github.com/hantdev/mitras/pkg/server/coap"
+	httpserver "github.com/hantdev/mitras/pkg/server/http"
+	"github.com/hantdev/mitras/pkg/uuid"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	svcName           = "lwm2m_adapter"
+	envPrefix         = "MITRAS_LWM2M_ADAPTER_"
+	envPrefixHTTP     = "MITRAS_LWM2M_ADAPTER_HTTP_"
+	envPrefixDB       = "MITRAS_LWM2M_ADAPTER_DB_"
+	envPrefixAuth     = "MITRAS_AUTH_GRPC_"
+	envPrefixClients  = "MITRAS_CLIENTS_AUTH_GRPC_"
+	envPrefixChannels = "MITRAS_CHANNELS_GRPC_"
+	defDB             = "lwm2m"
+	defSvcHTTPPort    = "9022"
+	defSvcCoAPPort    = "5783"
+)
+
+type config struct {
+	LogLevel                 string        `env:"MITRAS_LWM2M_ADAPTER_LOG_LEVEL"                  envDefault:"info"`
+	BrokerURL                string        `env:"MITRAS_MESSAGE_BROKER_URL"                       envDefault:"nats://localhost:4222"`
+	JaegerURL                url.URL       `env:"MITRAS_JAEGER_URL"                               envDefault:"http://localhost:4318/v1/traces"`
+	SendTelemetry            bool          `env:"MITRAS_SEND_TELEMETRY"                           envDefault:"true"`
+	InstanceID               string        `env:"MITRAS_LWM2M_ADAPTER_INSTANCE_ID"                envDefault:""`
+	TraceRatio               float64       `env:"MITRAS_JAEGER_TRACE_RATIO"                       envDefault:"1.0"`
+	DTLSPSK                  bool          `env:"MITRAS_LWM2M_ADAPTER_DTLS_PSK"                   envDefault:"false"`
+	ServerURI                string        `env:"MITRAS_LWM2M_ADAPTER_SERVER_URI"                 envDefault:"coap://localhost:5783"`
+	Lifetime                 time.Duration `env:"MITRAS_LWM2M_ADAPTER_LIFETIME"                   envDefault:"24h"`
+	BlockSize                int           `env:"MITRAS_LWM2M_ADAPTER_BLOCK_SIZE"                 envDefault:"1024"`
+	BlockwiseTransferTimeout time.Duration `env:"MITRAS_LWM2M_ADAPTER_BLOCKWISE_TRANSFER_TIMEOUT" envDefault:"10s"`
+	AuthJWKSURL              string        `env:"MITRAS_AUTH_JWKS_URL"                            envDefault:""`
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	g, ctx := errgroup.WithContext(ctx)
+
+	cfg := config{}
+	if err := env.Parse(&cfg); err != nil {
+		log.Fatalf("failed to load %s configuration : %s", svcName, err)
+	}
+
+	logger, err := smqlog.New(os.Stdout, cfg.LogLevel)
+	if err != nil {
+		log.Fatalf("failed to init logger: %s", err.Error())
+	}
+
+	var exitCode int
+	defer smqlog.ExitWithError(&exitCode)
+
+	if cfg.InstanceID == "" {
+		if cfg.InstanceID, err = uuid.New().ID(); err != nil {
+			logger.Error(fmt.Sprintf("failed to generate instanceID: %s", err))
+			exitCode = 1
+			return
+		}
+	}
+
+	httpServerConfig := server.Config{Port: defSvcHTTPPort}
+	if err := env.ParseWithOptions(&httpServerConfig, env.Options{Prefix: envPrefixHTTP}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load %s HTTP server configuration : %s", svcName, err))
+		exitCode = 1
+		return
+	}
+
+	coapServerConfig := server.Config{Port: defSvcCoAPPort}
+	if err := env.ParseWithOptions(&coapServerConfig, env.Options{Prefix: envPrefix}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load %s CoAP server configuration : %s", svcName, err))
+		exitCode = 1
+		return
+	}
+
+	dbConfig := pgclient.Config{Name: defDB}
+	if err := env.ParseWithOptions(&dbConfig, env.Options{Prefix: envPrefixDB}); err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	db, err := pgclient.Setup(dbConfig, *lwm2mpg.Migration())
+	if err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	defer db.Close()
+
+	authClientCfg := grpcclient.Config{}
+	if err := env.ParseWithOptions(&authClientCfg, env.Options{Prefix: envPrefixAuth}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load auth gRPC client configuration : %s", err))
+		exitCode = 1
+		return
+	}
+
+	authn, authnHandler, err := authsvcAuthn.NewAuthentication(ctx, authClientCfg)
+	if err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	defer authnHandler.Close()
+	logger.Info("AuthN successfully connected to auth gRPC server " + authnHandler.Secure())
+
+	if cfg.AuthJWKSURL != "" {
+		authn, err = jwks.NewAuthentication(ctx, cfg.AuthJWKSURL, authn)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to create JWKS authn : %s", err))
+			exitCode = 1
+			return
+		}
+		logger.Info("AuthN verifies tokens locally using JWKS from " + cfg.AuthJWKSURL)
+	}
+
+	authz, authzHandler, err := authsvcAuthz.NewAuthorization(ctx, authClientCfg)
+	if err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	defer authzHandler.Close()
+	logger.Info("AuthZ successfully connected to auth gRPC server " + authzHandler.Secure())
+
+	clientsClientCfg := grpcclient.Config{}
+	if err := env.ParseWithOptions(&clientsClientCfg, env.Options{Prefix: envPrefixClients}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load %s auth configuration : %s", svcName, err))
+		exitCode = 1
+		return
+	}
+
+	clientsClient, clientsHandler, err := grpcclient.SetupClientsClient(ctx, clientsClientCfg)
+	if err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	defer clientsHandler.Close()
+	logger.Info("Clients service gRPC client successfully connected to clients gRPC server " + clientsHandler.Secure())
+
+	channelsClientCfg := grpcclient.Config{}
+	if err := env.ParseWithOptions(&channelsClientCfg, env.Options{Prefix: envPrefixChannels}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load channels gRPC client configuration : %s", err))
+		exitCode = 1
+		return
+	}
+
+	channelsClient, channelsHandler, err := grpcclient.SetupChannelsClient(ctx, channelsClientCfg)
+	if err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	defer channelsHandler.Close()
+	logger.Info("Channels service gRPC client successfully connected to channels gRPC server " + channelsHandler.Secure())
+
+	tp, err := jaegerclient.NewProvider(ctx, svcName, cfg.JaegerURL, cfg.InstanceID, cfg.TraceRatio)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to init Jaeger: %s", err))
+		exitCode = 1
+		return
+	}
+	defer func() {
+		if err := tp.Shutdown(ctx); err != nil {
+			logger.Error(fmt.Sprintf("Error shutting down tracer provider: %v", err))
+		}
+	}()
+	tracer := tp.Tracer(svcName)
+
+	pub, err := brokers.NewPublisher(ctx, cfg.BrokerURL)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to connect to message broker: %s", err))
+		exitCode = 1
+		return
+	}
+	defer pub.Close()
+	pub = brokerstracing.NewPublisher(coapServerConfig, tracer, pub)
+
+	database := postgres.NewDatabase(db, dbConfig, tracer)
+	repo := lwm2mpg.NewRepository(database)
+	svcCfg := lwm2m.Config{ServerURI: cfg.ServerURI, Lifetime: cfg.Lifetime}
+
+	svc := lwm2m.NewService(repo, clientsClient, channelsClient, pub, uuid.New(), svcCfg, logger)
+	svc = middleware.AuthorizationMiddleware(svc, authz)
+	svc = middleware.LoggingMiddleware(svc, logger)
+
+	hs := httpserver.NewServer(ctx, cancel, svcName, httpServerConfig, api.MakeHandler(svc, authn, logger, svcName, cfg.InstanceID), logger)
+
+	coapOpts := []coapserver.Option{coapserver.WithBlockwise(cfg.BlockSize, cfg.BlockwiseTransferTimeout)}
+	if cfg.DTLSPSK {
+		coapOpts = append(coapOpts, coapserver.WithPSK(coap.PSK(clientsClient)))
+	}
+	cs := coapserver.NewServer(ctx, cancel, svcName, coapServerConfig, api.MakeCoAPHandler(svc, logger), logger, coapOpts...)
+
+	g.Go(func() error {
+		return hs.Start()
+	})
+	g.Go(func() error {
+		return cs.Start()
+	})
+	g.Go(func() error {
+		return server.StopSignalHandler(ctx, cancel, logger, svcName, hs, cs)
+	})
+
+	if err := g.Wait(); err != nil {
+		logger.Error(fmt.Sprintf("LwM2M adapter service terminated: %s", err))
+	}
+}
@@ -0,0 +1,210 @@
+// Package main contains modbus main function to start the modbus service.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	smqlog "github.com/hantdev/mitras/logger"
+	"github.com/hantdev/mitras/modbus"
+	"github.com/hantdev/mitras/modbus/api"
+	"github.com/hantdev/mitras/modbus/middleware"
+	modbuspg "github.com/hantdev/mitras/modbus/postgres"
+	authsvcAuthn "github.com/hantdev/mitras/pkg/authn/authsvc"
+	"github.com/hantdev/mitras/pkg/authn/jwks"
+	authsvcAuthz "github.com/hantdev/mitras/pkg/authz/authsvc"
+	"github.com/hantdev/mitras/pkg/grpcclient"
+	jaegerclient "github.com/hantdev/mitras/pkg/jaeger"
+	"github.com/hantdev/mitras/pkg/messaging"
+	"github.com/hantdev/mitras/pkg/messaging/brokers"
+	brokerstracing "github.com/hantdev/mitras/pkg/messaging/brokers/tracing"
+	"github.com/hantdev/mitras/pkg/postgres"
+	pgclient "github.com/hantdev/mitras/pkg/postgres"
+	"github.com/hantdev/mitras/pkg/server"
+	httpserver "github.com/hantdev/mitras/pkg/server/http"
+	"github.com/hantdev/mitras/pkg/uuid"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	svcName           = "modbus"
+	envPrefixHTTP     = "MITRAS_MODBUS_HTTP_"
+	envPrefixDB       = "MITRAS_MODBUS_DB_"
+	envPrefixAuth     = "MITRAS_AUTH_GRPC_"
+	envPrefixChannels = "MITRAS_CHANNELS_GRPC_"
+	defDB             = "modbus"
+	defSvcHTTPPort    = "9023"
+)
+
+type config struct {
+	LogLevel      string        `env:"MITRAS_MODBUS_LOG_LEVEL"     envDefault:"info"`
+	BrokerURL     string        `env:"MITRAS_MESSAGE_BROKER_URL"   envDefault:"nats://localhost:4222"`
+	JaegerURL     url.URL       `env:"MITRAS_JAEGER_URL"           envDefault:"http://localhost:4318/v1/traces"`
+	SendTelemetry bool          `env:"MITRAS_SEND_TELEMETRY"       envDefault:"true"`
+	InstanceID    string        `env:"MITRAS_MODBUS_INSTANCE_ID"   envDefault:""`
+	TraceRatio    float64       `env:"MITRAS_JAEGER_TRACE_RATIO"   envDefault:"1.0"`
+	Timeout       time.Duration `env:"MITRAS_MODBUS_TIMEOUT"       envDefault:"5s"`
+	AuthJWKSURL   string        `env:"MITRAS_AUTH_JWKS_URL"        envDefault:""`
+}
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	g, ctx := errgroup.WithContext(ctx)
+
+	cfg := config{}
+	if err := env.Parse(&cfg); err != nil {
+		log.Fatalf("failed to load %s configuration : %s", svcName, err)
+	}
+
+	logger, err := smqlog.New(os.Stdout, cfg.LogLevel)
+	if err != nil {
+		log.Fatalf("failed to init logger: %s", err.Error())
+	}
+
+	var exitCode int
+	defer smqlog.ExitWithError(&exitCode)
+
+	if cfg.InstanceID == "" {
+		if cfg.InstanceID, err = uuid.New().ID(); err != nil {
+			logger.Error(fmt.Sprintf("failed to generate instanceID: %s", err))
+			exitCode = 1
+			return
+		}
+	}
+
+	httpServerConfig := server.Config{Port: defSvcHTTPPort}
+	if err := env.ParseWithOptions(&httpServerConfig, env.Options{Prefix: envPrefixHTTP}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load %s HTTP server configuration : %s", svcName, err))
+		exitCode = 1
+		return
+	}
+
+	dbConfig := pgclient.Config{Name: defDB}
+	if err := env.ParseWithOptions(&dbConfig, env.Options{Prefix: envPrefixDB}); err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	db, err := pgclient.Setup(dbConfig, *modbuspg.Migration())
+	if err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	defer db.Close()
+
+	authClientCfg := grpcclient.Config{}
+	if err := env.ParseWithOptions(&authClientCfg, env.Options{Prefix: envPrefixAuth}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load auth gRPC client configuration : %s", err))
+		exitCode = 1
+		return
+	}
+
+	authn, authnHandler, err := authsvcAuthn.NewAuthentication(ctx, authClientCfg)
+	if err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	defer authnHandler.Close()
+	logger.Info("AuthN successfully connected to auth gRPC server " + authnHandler.Secure())
+
+	if cfg.AuthJWKSURL != "" {
+		authn, err = jwks.NewAuthentication(ctx, cfg.AuthJWKSURL, authn)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to create JWKS authn : %s", err))
+			exitCode = 1
+			return
+		}
+		logger.Info("AuthN verifies tokens locally using JWKS from " + cfg.AuthJWKSURL)
+	}
+
+	authz, authzHandler, err := authsvcAuthz.NewAuthorization(ctx, authClientCfg)
+	if err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	defer authzHandler.Close()
+	logger.Info("AuthZ successfully connected to auth gRPC server " + authzHandler.Secure())
+
+	channelsClientCfg := grpcclient.Config{}
+	if err := env.ParseWithOptions(&channelsClientCfg, env.Options{Prefix: envPrefixChannels}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load channels gRPC client configuration : %s", err))
+		exitCode = 1
+		return
+	}
+
+	channelsClient, channelsHandler, err := grpcclient.SetupChannelsClient(ctx, channelsClientCfg)
+	if err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	defer channelsHandler.Close()
+	logger.Info("Channels service gRPC client successfully connected to channels gRPC server " + channelsHandler.Secure())
+
+	tp, err := jaegerclient.NewProvider(ctx, svcName, cfg.JaegerURL, cfg.InstanceID, cfg.TraceRatio)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to init Jaeger: %s", err))
+		exitCode = 1
+		return
+	}
+	defer func() {
+		if err := tp.Shutdown(ctx); err != nil {
+			logger.Error(fmt.Sprintf("Error shutting down tracer provider: %v", err))
+		}
+	}()
+	tracer := tp.Tracer(svcName)
+
+	ps, err := brokers.NewPubSub(ctx, cfg.BrokerURL, logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to connect to message broker: %s", err))
+		exitCode = 1
+		return
+	}
+	defer ps.Close()
+	ps = brokerstracing.NewPubSub(httpServerConfig, tracer, ps)
+
+	database := postgres.NewDatabase(db, dbConfig, tracer)
+	repo := modbuspg.NewRepository(database)
+
+	svc := modbus.NewService(repo, channelsClient, ps, modbus.NewDialer(cfg.Timeout), logger)
+	svc = middleware.AuthorizationMiddleware(svc, authz)
+	svc = middleware.LoggingMiddleware(svc, logger)
+
+	if err := svc.Start(ctx); err != nil {
+		logger.Error(fmt.Sprintf("failed to start polling devices: %s", err))
+		exitCode = 1
+		return
+	}
+
+	subCfg := messaging.SubscriberConfig{
+		ID:      svcName,
+		Topic:   api.CommandsTopic,
+		Handler: api.MakeCommandHandler(svc, logger),
+	}
+	if err := ps.Subscribe(ctx, subCfg); err != nil {
+		logger.Error(fmt.Sprintf("failed to subscribe to device commands: %s", err))
+		exitCode = 1
+		return
+	}
+
+	hs := httpserver.NewServer(ctx, cancel, svcName, httpServerConfig, api.MakeHandler(svc, authn, logger, svcName, cfg.InstanceID), logger)
+
+	g.Go(func() error {
+		return hs.Start()
+	})
+	g.Go(func() error {
+		return server.StopSignalHandler(ctx, cancel, logger, svcName, hs)
+	})
+
+	if err := g.Wait(); err != nil {
+		logger.Error(fmt.Sprintf("Modbus service terminated: %s", err))
+	}
+}
@@ -193,18 +193,19 @@ func main() {
 	defer channelsHandler.Close()
 	logger.Info("Channels service gRPC client successfully connected to channels gRPC server " + channelsHandler.Secure())
 
-	h := mqtt.NewHandler(np, es, logger, clientsClient, channelsClient)
-	h = handler.NewTracing(tracer, h)
+	// The handler is the interceptor of the proxy too, recording the session
+	// lifecycle.
+	mh := mqtt.NewHandler(np, es, logger, clientsClient, channelsClient)
+	h := handler.NewTracing(tracer, mh)
 
-	var interceptor session.Interceptor
 	logger.Info(fmt.Sprintf("Starting MQTT proxy on port %s", cfg.MQTTPort))
 	g.Go(func() error {
-		return proxyMQTT(ctx, cfg, logger, h, interceptor)
+		return proxyMQTT(ctx, cfg, logger, h, mh)
 	})
 
 	logger.Info(fmt.Sprintf("Starting MQTT over WS  proxy on port %s", cfg.HTTPPort))
 	g.Go(func() error {
-		return proxyWS(ctx, cfg, logger, h, interceptor)
+		return proxyWS(ctx, cfg, logger, h, mh)
 	})
 
 	g.Go(func() error {
@@ -11,6 +11,7 @@ import (
 	"github.com/caarlos0/env/v11"
 	smqlog "github.com/hantdev/mitras/logger"
 	"github.com/hantdev/mitras/pkg/authn/authsvc"
+	"github.com/hantdev/mitras/pkg/authn/jwks"
 	"github.com/hantdev/mitras/pkg/grpcclient"
 	pgclient "github.com/hantdev/mitras/pkg/postgres"
 	"github.com/hantdev/mitras/pkg/prometheus"
@@ -39,6 +40,8 @@ type config struct {
 	LogLevel      string `env:"MITRAS_POSTGRES_READER_LOG_LEVEL"     envDefault:"info"`
 	SendTelemetry bool   `env:"MITRAS_SEND_TELEMETRY"                envDefault:"true"`
 	InstanceID    string `env:"MITRAS_POSTGRES_READER_INSTANCE_ID"   envDefault:""`
+	AuthJWKSURL   string `env:"MITRAS_AUTH_JWKS_URL"                 envDefault:""`
+	MaxBuckets    uint64 `env:"MITRAS_POSTGRES_READER_MAX_BUCKETS"   envDefault:"10000"`
 }
 
 func main() {
@@ -128,6 +131,16 @@ func main() {
 	defer authnHandler.Close()
 	logger.Info("authn successfully connected to auth gRPC server " + authnHandler.Secure())
 
+	if cfg.AuthJWKSURL != "" {
+		authn, err = jwks.NewAuthentication(ctx, cfg.AuthJWKSURL, authn)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to create JWKS authn : %s", err))
+			exitCode = 1
+			return
+		}
+		logger.Info("AuthN verifies tokens locally using JWKS from " + cfg.AuthJWKSURL)
+	}
+
 	repo := newService(db, logger)
 
 	httpServerConfig := server.Config{Port: defSvcHTTPPort}
@@ -136,7 +149,7 @@ func main() {
 		exitCode = 1
 		return
 	}
-	hs := httpserver.NewServer(ctx, cancel, svcName, httpServerConfig, api.MakeHandler(repo, authn, clientsClient, channelsClient, svcName, cfg.InstanceID), logger)
+	hs := httpserver.NewServer(ctx, cancel, svcName, httpServerConfig, api.MakeHandler(repo, authn, clientsClient, channelsClient, cfg.MaxBuckets, svcName, cfg.InstanceID), logger)
 
 	g.Go(func() error {
 		return hs.Start()
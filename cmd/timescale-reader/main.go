@@ -7,10 +7,16 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/caarlos0/env/v11"
+	"github.com/go-chi/chi/v5"
+	grpcChannelsV1 "github.com/hantdev/mitras/internal/grpc/channels/v1"
 	smqlog "github.com/hantdev/mitras/logger"
 	"github.com/hantdev/mitras/pkg/authn/authsvc"
+	"github.com/hantdev/mitras/pkg/authn/jwks"
+	smqauthz "github.com/hantdev/mitras/pkg/authz"
+	authsvcAuthz "github.com/hantdev/mitras/pkg/authz/authsvc"
 	"github.com/hantdev/mitras/pkg/grpcclient"
 	pgclient "github.com/hantdev/mitras/pkg/postgres"
 	"github.com/hantdev/mitras/pkg/prometheus"
@@ -19,6 +25,9 @@ import (
 	"github.com/hantdev/mitras/pkg/uuid"
 	"github.com/hantdev/mitras/readers"
 	"github.com/hantdev/mitras/readers/api"
+	"github.com/hantdev/mitras/readers/retention"
+	retentionapi "github.com/hantdev/mitras/readers/retention/api"
+	retentionmw "github.com/hantdev/mitras/readers/retention/middleware"
 	"github.com/hantdev/mitras/readers/timescale"
 	"github.com/jmoiron/sqlx"
 	"golang.org/x/sync/errgroup"
@@ -36,9 +45,12 @@ const (
 )
 
 type config struct {
-	LogLevel      string `env:"MITRAS_TIMESCALE_READER_LOG_LEVEL"    envDefault:"info"`
-	SendTelemetry bool   `env:"MITRAS_SEND_TELEMETRY"                envDefault:"true"`
-	InstanceID    string `env:"MITRAS_TIMESCALE_READER_INSTANCE_ID"  envDefault:""`
+	LogLevel          string        `env:"MITRAS_TIMESCALE_READER_LOG_LEVEL"          envDefault:"info"`
+	SendTelemetry     bool          `env:"MITRAS_SEND_TELEMETRY"                      envDefault:"true"`
+	InstanceID        string        `env:"MITRAS_TIMESCALE_READER_INSTANCE_ID"        envDefault:""`
+	RetentionInterval time.Duration `env:"MITRAS_TIMESCALE_READER_RETENTION_INTERVAL" envDefault:"1h"`
+	AuthJWKSURL       string        `env:"MITRAS_AUTH_JWKS_URL"                       envDefault:""`
+	MaxBuckets        uint64        `env:"MITRAS_TIMESCALE_READER_MAX_BUCKETS"        envDefault:"10000"`
 }
 
 func main() {
@@ -129,18 +141,45 @@ func main() {
 	defer authnHandler.Close()
 	logger.Info("authn successfully connected to auth gRPC server " + authnHandler.Secure())
 
+	if cfg.AuthJWKSURL != "" {
+		authn, err = jwks.NewAuthentication(ctx, cfg.AuthJWKSURL, authn)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to create JWKS authn : %s", err))
+			exitCode = 1
+			return
+		}
+		logger.Info("AuthN verifies tokens locally using JWKS from " + cfg.AuthJWKSURL)
+	}
+
+	authz, authzHandler, err := authsvcAuthz.NewAuthorization(ctx, authnCfg)
+	if err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	defer authzHandler.Close()
+	logger.Info("authz successfully connected to auth gRPC server " + authzHandler.Secure())
+
+	retentionSvc := newRetentionService(db, channelsClient, authz, logger)
+
 	httpServerConfig := server.Config{Port: defSvcHTTPPort}
 	if err := env.ParseWithOptions(&httpServerConfig, env.Options{Prefix: envPrefixHTTP}); err != nil {
 		logger.Error(fmt.Sprintf("failed to load %s HTTP server configuration : %s", svcName, err))
 		exitCode = 1
 		return
 	}
-	hs := httpserver.NewServer(ctx, cancel, svcName, httpServerConfig, api.MakeHandler(repo, authn, clientsClient, channelsClient, svcName, cfg.InstanceID), logger)
+	mux := chi.NewRouter()
+	mux.Mount("/", api.MakeHandler(repo, authn, clientsClient, channelsClient, cfg.MaxBuckets, svcName, cfg.InstanceID))
+	hs := httpserver.NewServer(ctx, cancel, svcName, httpServerConfig, retentionapi.MakeHandler(retentionSvc, authn, mux, logger), logger)
 
 	g.Go(func() error {
 		return hs.Start()
 	})
 
+	g.Go(func() error {
+		return enforceRetention(ctx, retentionSvc, cfg.RetentionInterval)
+	})
+
 	g.Go(func() error {
 		return server.StopSignalHandler(ctx, cancel, logger, svcName, hs)
 	})
@@ -158,3 +197,27 @@ func newService(db *sqlx.DB, logger *slog.Logger) readers.MessageRepository {
 
 	return svc
 }
+
+func newRetentionService(db *sqlx.DB, channels grpcChannelsV1.ChannelsServiceClient, authz smqauthz.Authorization, logger *slog.Logger) retention.Service {
+	svc := retention.NewService(uuid.New(), timescale.NewRetentionRepository(db), channels)
+	svc = retentionmw.AuthorizationMiddleware(svc, authz)
+	svc = retentionmw.LoggingMiddleware(svc, logger)
+
+	return svc
+}
+
+// enforceRetention periodically applies retention policies until the context
+// is canceled. Enforcement failures are logged by the service middleware.
+func enforceRetention(ctx context.Context, svc retention.Service, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			_ = svc.Enforce(ctx, now)
+		}
+	}
+}
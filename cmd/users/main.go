@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/authzed/authzed-go/v1"
@@ -19,13 +20,16 @@ import (
 	grpcDomainsV1 "github.com/hantdev/mitras/internal/grpc/domains/v1"
 	grpcTokenV1 "github.com/hantdev/mitras/internal/grpc/token/v1"
 	smqlog "github.com/hantdev/mitras/logger"
+	"github.com/hantdev/mitras/pkg/apiutil"
 	authsvcAuthn "github.com/hantdev/mitras/pkg/authn/authsvc"
+	"github.com/hantdev/mitras/pkg/authn/jwks"
 	smqauthz "github.com/hantdev/mitras/pkg/authz"
 	authsvcAuthz "github.com/hantdev/mitras/pkg/authz/authsvc"
 	"github.com/hantdev/mitras/pkg/grpcclient"
 	jaegerclient "github.com/hantdev/mitras/pkg/jaeger"
 	"github.com/hantdev/mitras/pkg/oauth2"
 	googleoauth "github.com/hantdev/mitras/pkg/oauth2/google"
+	"github.com/hantdev/mitras/pkg/oauth2/oidc"
 	"github.com/hantdev/mitras/pkg/policies"
 	"github.com/hantdev/mitras/pkg/policies/spicedb"
 	pg "github.com/hantdev/mitras/pkg/postgres"
@@ -39,6 +43,7 @@ import (
 	"github.com/hantdev/mitras/users/emailer"
 	"github.com/hantdev/mitras/users/events"
 	"github.com/hantdev/mitras/users/hasher"
+	"github.com/hantdev/mitras/users/ldap"
 	"github.com/hantdev/mitras/users/middleware"
 	"github.com/hantdev/mitras/users/postgres"
 	"github.com/hantdev/mitras/users/tracing"
@@ -56,32 +61,60 @@ const (
 	envPrefixAuth    = "MITRAS_AUTH_GRPC_"
 	envPrefixDomains = "MITRAS_DOMAINS_GRPC_"
 	envPrefixGoogle  = "MITRAS_GOOGLE_"
+	envPrefixOIDC    = "MITRAS_OIDC_"
+	envPrefixLDAP    = "MITRAS_USERS_LDAP_"
 	defDB            = "users"
 	defSvcHTTPPort   = "9002"
 )
 
 type config struct {
-	LogLevel            string        `env:"MITRAS_USERS_LOG_LEVEL"           envDefault:"info"`
-	AdminEmail          string        `env:"MITRAS_USERS_ADMIN_EMAIL"         envDefault:"admin@example.com"`
-	AdminPassword       string        `env:"MITRAS_USERS_ADMIN_PASSWORD"      envDefault:"12345678"`
-	AdminUsername       string        `env:"MITRAS_USERS_ADMIN_USERNAME"      envDefault:"admin"`
-	AdminFirstName      string        `env:"MITRAS_USERS_ADMIN_FIRST_NAME"    envDefault:"super"`
-	AdminLastName       string        `env:"MITRAS_USERS_ADMIN_LAST_NAME"     envDefault:"admin"`
-	PassRegexText       string        `env:"MITRAS_USERS_PASS_REGEX"          envDefault:"^.{8,}$"`
-	ResetURL            string        `env:"MITRAS_TOKEN_RESET_ENDPOINT"      envDefault:"/reset-request"`
-	JaegerURL           url.URL       `env:"MITRAS_JAEGER_URL"                envDefault:"http://localhost:4318/v1/traces"`
-	SendTelemetry       bool          `env:"MITRAS_SEND_TELEMETRY"            envDefault:"true"`
-	InstanceID          string        `env:"MITRAS_USERS_INSTANCE_ID"         envDefault:""`
-	ESURL               string        `env:"MITRAS_ES_URL"                    envDefault:"nats://localhost:4222"`
-	TraceRatio          float64       `env:"MITRAS_JAEGER_TRACE_RATIO"        envDefault:"1.0"`
-	SelfRegister        bool          `env:"MITRAS_USERS_ALLOW_SELF_REGISTER" envDefault:"false"`
-	OAuthUIRedirectURL  string        `env:"MITRAS_OAUTH_UI_REDIRECT_URL"     envDefault:"http://localhost:9095/domains"`
-	OAuthUIErrorURL     string        `env:"MITRAS_OAUTH_UI_ERROR_URL"        envDefault:"http://localhost:9095/error"`
-	DeleteInterval      time.Duration `env:"MITRAS_USERS_DELETE_INTERVAL"     envDefault:"24h"`
-	DeleteAfter         time.Duration `env:"MITRAS_USERS_DELETE_AFTER"        envDefault:"720h"`
-	SpicedbHost         string        `env:"MITRAS_SPICEDB_HOST"              envDefault:"localhost"`
-	SpicedbPort         string        `env:"MITRAS_SPICEDB_PORT"              envDefault:"50051"`
-	SpicedbPreSharedKey string        `env:"MITRAS_SPICEDB_PRE_SHARED_KEY"    envDefault:"12345678"`
+	LogLevel            string        `env:"MITRAS_USERS_LOG_LEVEL"               envDefault:"info"`
+	AdminEmail          string        `env:"MITRAS_USERS_ADMIN_EMAIL"             envDefault:"admin@example.com"`
+	AdminPassword       string        `env:"MITRAS_USERS_ADMIN_PASSWORD"          envDefault:"12345678"`
+	AdminUsername       string        `env:"MITRAS_USERS_ADMIN_USERNAME"          envDefault:"admin"`
+	AdminFirstName      string        `env:"MITRAS_USERS_ADMIN_FIRST_NAME"        envDefault:"super"`
+	AdminLastName       string        `env:"MITRAS_USERS_ADMIN_LAST_NAME"         envDefault:"admin"`
+	PassRegexText       string        `env:"MITRAS_USERS_PASS_REGEX"              envDefault:"^.{8,}$"`
+	PassMinLength       int           `env:"MITRAS_USERS_PASSWORD_MIN_LENGTH"     envDefault:"8"`
+	PassRequireUpper    bool          `env:"MITRAS_USERS_PASSWORD_REQUIRE_UPPER"  envDefault:"false"`
+	PassRequireLower    bool          `env:"MITRAS_USERS_PASSWORD_REQUIRE_LOWER"  envDefault:"false"`
+	PassRequireDigit    bool          `env:"MITRAS_USERS_PASSWORD_REQUIRE_DIGIT"  envDefault:"false"`
+	PassRequireSymbol   bool          `env:"MITRAS_USERS_PASSWORD_REQUIRE_SYMBOL" envDefault:"false"`
+	PassDenylist        string        `env:"MITRAS_USERS_PASSWORD_DENYLIST"       envDefault:""`
+	PassHistory         uint64        `env:"MITRAS_USERS_PASSWORD_HISTORY"        envDefault:"0"`
+	PassMaxAge          time.Duration `env:"MITRAS_USERS_PASSWORD_MAX_AGE"        envDefault:"0"`
+	ResetURL            string        `env:"MITRAS_TOKEN_RESET_ENDPOINT"          envDefault:"/reset-request"`
+	JaegerURL           url.URL       `env:"MITRAS_JAEGER_URL"                    envDefault:"http://localhost:4318/v1/traces"`
+	SendTelemetry       bool          `env:"MITRAS_SEND_TELEMETRY"                envDefault:"true"`
+	InstanceID          string        `env:"MITRAS_USERS_INSTANCE_ID"             envDefault:""`
+	ESURL               string        `env:"MITRAS_ES_URL"                        envDefault:"nats://localhost:4222"`
+	TraceRatio          float64       `env:"MITRAS_JAEGER_TRACE_RATIO"            envDefault:"1.0"`
+	SelfRegister        bool          `env:"MITRAS_USERS_ALLOW_SELF_REGISTER"     envDefault:"false"`
+	SelfRegisterDomains []string      `env:"MITRAS_USERS_SELF_REGISTER_DOMAINS"   envDefault:""`
+	InviteOnly          bool          `env:"MITRAS_USERS_INVITE_ONLY"             envDefault:"false"`
+	VerifyEmail         bool          `env:"MITRAS_USERS_VERIFY_EMAIL"            envDefault:"true"`
+	SecretKey           string        `env:"MITRAS_USERS_SECRET_KEY"              envDefault:"secret"`
+	MFAKey              string        `env:"MITRAS_USERS_MFA_KEY"                 envDefault:"secret"`
+	VerificationURL     string        `env:"MITRAS_USERS_VERIFICATION_URL"        envDefault:"http://localhost:9095/verify-email"`
+	VerificationTTL     time.Duration `env:"MITRAS_USERS_VERIFICATION_DURATION"   envDefault:"24h"`
+	InvitationURL       string        `env:"MITRAS_USERS_INVITATION_URL"          envDefault:"http://localhost:9095/register"`
+	InvitationTTL       time.Duration `env:"MITRAS_USERS_INVITATION_DURATION"     envDefault:"72h"`
+	LockoutAttempts     uint64        `env:"MITRAS_USERS_LOCKOUT_MAX_ATTEMPTS"    envDefault:"5"`
+	LockoutIPAttempts   uint64        `env:"MITRAS_USERS_LOCKOUT_MAX_IP_ATTEMPTS" envDefault:"50"`
+	LockoutDuration     time.Duration `env:"MITRAS_USERS_LOCKOUT_DURATION"        envDefault:"15m"`
+	LockoutDelay        time.Duration `env:"MITRAS_USERS_LOCKOUT_DELAY"           envDefault:"1s"`
+	LockoutMaxDelay     time.Duration `env:"MITRAS_USERS_LOCKOUT_MAX_DELAY"       envDefault:"30s"`
+	OAuthUIRedirectURL  string        `env:"MITRAS_OAUTH_UI_REDIRECT_URL"         envDefault:"http://localhost:9095/domains"`
+	OAuthUIErrorURL     string        `env:"MITRAS_OAUTH_UI_ERROR_URL"            envDefault:"http://localhost:9095/error"`
+	OIDCProviders       []string      `env:"MITRAS_OIDC_PROVIDERS"                envDefault:""`
+	LDAPSyncInterval    time.Duration `env:"MITRAS_USERS_LDAP_SYNC_INTERVAL"      envDefault:"1h"`
+	DeleteInterval      time.Duration `env:"MITRAS_USERS_DELETE_INTERVAL"         envDefault:"24h"`
+	DeleteAfter         time.Duration `env:"MITRAS_USERS_DELETE_AFTER"            envDefault:"720h"`
+	SpicedbHost         string        `env:"MITRAS_SPICEDB_HOST"                  envDefault:"localhost"`
+	SpicedbPort         string        `env:"MITRAS_SPICEDB_PORT"                  envDefault:"50051"`
+	SpicedbPreSharedKey string        `env:"MITRAS_SPICEDB_PRE_SHARED_KEY"        envDefault:"12345678"`
+	AuthJWKSURL         string        `env:"MITRAS_AUTH_JWKS_URL"                 envDefault:""`
+	TrustedProxies      []string      `env:"MITRAS_USERS_TRUSTED_PROXIES"         envDefault:""`
 	PassRegex           *regexp.Regexp
 }
 
@@ -98,6 +131,10 @@ func main() {
 		log.Fatalf("invalid password validation rules %s\n", cfg.PassRegexText)
 	}
 	cfg.PassRegex = passRegex
+	trustedProxies, err := apiutil.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("invalid trusted proxies %v: %s\n", cfg.TrustedProxies, err)
+	}
 
 	logger, err := smqlog.New(os.Stdout, cfg.LogLevel)
 	if err != nil {
@@ -176,6 +213,16 @@ func main() {
 	defer authnHandler.Close()
 	logger.Info("AuthN successfully connected to auth gRPC server " + authnHandler.Secure())
 
+	if cfg.AuthJWKSURL != "" {
+		authn, err = jwks.NewAuthentication(ctx, cfg.AuthJWKSURL, authn)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to create JWKS authn : %s", err))
+			exitCode = 1
+			return
+		}
+		logger.Info("AuthN verifies tokens locally using JWKS from " + cfg.AuthJWKSURL)
+	}
+
 	authz, authzHandler, err := authsvcAuthz.NewAuthorization(ctx, authClientConfig)
 	if err != nil {
 		logger.Error("failed to create authz " + err.Error())
@@ -229,10 +276,27 @@ func main() {
 		exitCode = 1
 		return
 	}
-	oauthProvider := googleoauth.NewProvider(oauthConfig, cfg.OAuthUIRedirectURL, cfg.OAuthUIErrorURL)
+	oauthProviders := []oauth2.Provider{googleoauth.NewProvider(oauthConfig, cfg.OAuthUIRedirectURL, cfg.OAuthUIErrorURL)}
+
+	for _, name := range cfg.OIDCProviders {
+		name = strings.ToLower(strings.TrimSpace(name))
+		oidcConfig := oidc.Config{}
+		if err := env.ParseWithOptions(&oidcConfig, env.Options{Prefix: envPrefixOIDC + strings.ToUpper(name) + "_"}); err != nil {
+			logger.Error(fmt.Sprintf("failed to load %s OpenID Connect %s configuration : %s", svcName, name, err.Error()))
+			exitCode = 1
+			return
+		}
+		oidcProvider, err := oidc.NewProvider(ctx, name, oidcConfig, cfg.OAuthUIRedirectURL, cfg.OAuthUIErrorURL)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to create OpenID Connect %s provider : %s", name, err.Error()))
+			exitCode = 1
+			return
+		}
+		oauthProviders = append(oauthProviders, oidcProvider)
+	}
 
 	mux := chi.NewRouter()
-	httpSrv := httpserver.NewServer(ctx, cancel, svcName, httpServerConfig, api.MakeHandler(csvc, authn, tokenClient, cfg.SelfRegister, mux, logger, cfg.InstanceID, cfg.PassRegex, oauthProvider), logger)
+	httpSrv := httpserver.NewServer(ctx, cancel, svcName, httpServerConfig, api.MakeHandler(csvc, authn, cfg.SelfRegister, mux, logger, cfg.InstanceID, cfg.PassRegex, trustedProxies, oauthProviders...), logger)
 
 	g.Go(func() error {
 		return httpSrv.Start()
@@ -254,12 +318,55 @@ func newService(ctx context.Context, authz smqauthz.Authorization, token grpcTok
 
 	// Creating users service
 	repo := postgres.NewRepository(database)
-	emailerClient, err := emailer.New(c.ResetURL, &ec)
+	emailerClient, err := emailer.New(c.ResetURL, c.VerificationURL, c.InvitationURL, &ec)
 	if err != nil {
 		logger.Error(fmt.Sprintf("failed to configure e-mailing util: %s", err.Error()))
 	}
 
-	svc := users.NewService(token, repo, policyService, emailerClient, hsr, idp)
+	mfaRepo := postgres.NewMFARepository(database, []byte(c.MFAKey))
+	rc := users.RegistrationConfig{
+		EmailDomains:         c.SelfRegisterDomains,
+		InviteOnly:           c.InviteOnly,
+		VerifyEmail:          c.VerifyEmail,
+		Key:                  []byte(c.SecretKey),
+		VerificationDuration: c.VerificationTTL,
+		InvitationDuration:   c.InvitationTTL,
+	}
+	lockoutRepo := postgres.NewLockoutRepository(database)
+	lc := users.LockoutConfig{
+		MaxAttempts:   c.LockoutAttempts,
+		MaxIPAttempts: c.LockoutIPAttempts,
+		Duration:      c.LockoutDuration,
+		Delay:         c.LockoutDelay,
+		MaxDelay:      c.LockoutMaxDelay,
+	}
+	ldapConfig := ldap.Config{}
+	if err := env.ParseWithOptions(&ldapConfig, env.Options{Prefix: envPrefixLDAP}); err != nil {
+		return nil, fmt.Errorf("failed to load LDAP configuration: %w", err)
+	}
+	var directory users.Directory
+	if ldapConfig.URL != "" {
+		if directory, err = ldap.NewDirectory(ldapConfig); err != nil {
+			return nil, fmt.Errorf("failed to configure LDAP directory: %w", err)
+		}
+	}
+	pp := users.PasswordPolicy{
+		MinLength:     c.PassMinLength,
+		RequireUpper:  c.PassRequireUpper,
+		RequireLower:  c.PassRequireLower,
+		RequireDigit:  c.PassRequireDigit,
+		RequireSymbol: c.PassRequireSymbol,
+		History:       c.PassHistory,
+		MaxAge:        c.PassMaxAge,
+	}
+	if c.PassDenylist != "" {
+		if pp.Denylist, err = users.LoadDenylist(c.PassDenylist); err != nil {
+			return nil, fmt.Errorf("failed to load password denylist: %w", err)
+		}
+	}
+	passwordRepo := postgres.NewPasswordRepository(database)
+	serviceAccountRepo := postgres.NewServiceAccountRepository(database)
+	svc := users.NewService(token, repo, mfaRepo, lockoutRepo, passwordRepo, serviceAccountRepo, domainsClient, policyService, emailerClient, hsr, idp, directory, rc, lc, pp)
 
 	svc, err = events.NewEventStoreMiddleware(ctx, svc, c.ESURL)
 	if err != nil {
@@ -281,6 +388,9 @@ func newService(ctx context.Context, authz smqauthz.Authorization, token grpcTok
 	}
 
 	users.NewDeleteHandler(ctx, repo, policyService, domainsClient, c.DeleteInterval, c.DeleteAfter, logger)
+	if directory != nil && c.LDAPSyncInterval > 0 {
+		users.NewDirectorySyncHandler(ctx, repo, directory, domainsClient, c.LDAPSyncInterval, logger)
+	}
 
 	return svc, err
 }
@@ -307,10 +417,11 @@ func createAdmin(ctx context.Context, c config, repo users.Repository, hsr users
 		Metadata: users.Metadata{
 			"role": "admin",
 		},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Role:      users.AdminRole,
-		Status:    users.EnabledStatus,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+		VerifiedAt: time.Now(),
+		Role:       users.AdminRole,
+		Status:     users.EnabledStatus,
 	}
 
 	if u, err := repo.RetrieveByEmail(ctx, user.Email); err == nil {
@@ -321,7 +432,7 @@ func createAdmin(ctx context.Context, c config, repo users.Repository, hsr users
 	if _, err = repo.Save(ctx, user); err != nil {
 		return "", err
 	}
-	if _, err = svc.IssueToken(ctx, c.AdminUsername, c.AdminPassword); err != nil {
+	if _, err = svc.IssueToken(ctx, c.AdminUsername, c.AdminPassword, "", ""); err != nil {
 		return "", err
 	}
 	return user.ID, nil
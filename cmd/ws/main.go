@@ -16,6 +16,7 @@ import (
 	grpcClientsV1 "github.com/hantdev/mitras/internal/grpc/clients/v1"
 	smqlog "github.com/hantdev/mitras/logger"
 	"github.com/hantdev/mitras/pkg/authn/authsvc"
+	"github.com/hantdev/mitras/pkg/authn/jwks"
 	"github.com/hantdev/mitras/pkg/grpcclient"
 	jaegerclient "github.com/hantdev/mitras/pkg/jaeger"
 	"github.com/hantdev/mitras/pkg/messaging"
@@ -50,6 +51,7 @@ type config struct {
 	SendTelemetry bool    `env:"MITRAS_SEND_TELEMETRY"          envDefault:"true"`
 	InstanceID    string  `env:"MITRAS_WS_ADAPTER_INSTANCE_ID"  envDefault:""`
 	TraceRatio    float64 `env:"MITRAS_JAEGER_TRACE_RATIO"      envDefault:"1.0"`
+	AuthJWKSURL   string  `env:"MITRAS_AUTH_JWKS_URL"           envDefault:""`
 }
 
 func main() {
@@ -138,6 +140,16 @@ func main() {
 	defer authnHandler.Close()
 	logger.Info("authn successfully connected to auth gRPC server " + authnHandler.Secure())
 
+	if cfg.AuthJWKSURL != "" {
+		authn, err = jwks.NewAuthentication(ctx, cfg.AuthJWKSURL, authn)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to create JWKS authn : %s", err))
+			exitCode = 1
+			return
+		}
+		logger.Info("AuthN verifies tokens locally using JWKS from " + cfg.AuthJWKSURL)
+	}
+
 	tp, err := jaegerclient.NewProvider(ctx, svcName, cfg.JaegerURL, cfg.InstanceID, cfg.TraceRatio)
 	if err != nil {
 		logger.Error(fmt.Sprintf("failed to init Jaeger: %s", err))
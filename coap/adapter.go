@@ -58,18 +58,13 @@ func New(clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.Cha
 }
 
 func (svc *adapterService) Publish(ctx context.Context, key string, msg *messaging.Message) error {
-	authnRes, err := svc.clients.Authenticate(ctx, &grpcClientsV1.AuthnReq{
-		ClientSecret: key,
-	})
+	clientID, err := svc.authenticate(ctx, key)
 	if err != nil {
-		return errors.Wrap(svcerr.ErrAuthentication, err)
-	}
-	if !authnRes.Authenticated {
-		return svcerr.ErrAuthentication
+		return err
 	}
 
 	authzRes, err := svc.channels.Authorize(ctx, &grpcChannelsV1.AuthzReq{
-		ClientId:   authnRes.GetId(),
+		ClientId:   clientID,
 		ClientType: policies.ClientType,
 		Type:       uint32(connections.Publish),
 		ChannelId:  msg.GetChannel(),
@@ -81,23 +76,17 @@ func (svc *adapterService) Publish(ctx context.Context, key string, msg *messagi
 		return svcerr.ErrAuthorization
 	}
 
-	msg.Publisher = authnRes.GetId()
+	msg.Publisher = clientID
 
 	return svc.pubsub.Publish(ctx, msg.GetChannel(), msg)
 }
 
 func (svc *adapterService) Subscribe(ctx context.Context, key, chanID, subtopic string, c Client) error {
-	authnRes, err := svc.clients.Authenticate(ctx, &grpcClientsV1.AuthnReq{
-		ClientSecret: key,
-	})
+	clientID, err := svc.authenticate(ctx, key)
 	if err != nil {
-		return errors.Wrap(svcerr.ErrAuthentication, err)
-	}
-	if !authnRes.Authenticated {
-		return svcerr.ErrAuthentication
+		return err
 	}
 
-	clientID := authnRes.GetId()
 	authzRes, err := svc.channels.Authorize(ctx, &grpcChannelsV1.AuthzReq{
 		ClientId:   clientID,
 		ClientType: policies.ClientType,
@@ -126,19 +115,14 @@ func (svc *adapterService) Subscribe(ctx context.Context, key, chanID, subtopic
 }
 
 func (svc *adapterService) Unsubscribe(ctx context.Context, key, chanID, subtopic, token string) error {
-	authnRes, err := svc.clients.Authenticate(ctx, &grpcClientsV1.AuthnReq{
-		ClientSecret: key,
-	})
+	clientID, err := svc.authenticate(ctx, key)
 	if err != nil {
-		return errors.Wrap(svcerr.ErrAuthentication, err)
-	}
-	if !authnRes.Authenticated {
-		return svcerr.ErrAuthentication
+		return err
 	}
 
 	authzRes, err := svc.channels.Authorize(ctx, &grpcChannelsV1.AuthzReq{
 		DomainId:   "",
-		ClientId:   authnRes.GetId(),
+		ClientId:   clientID,
 		ClientType: policies.ClientType,
 		Type:       uint32(connections.Subscribe),
 		ChannelId:  chanID,
@@ -158,6 +142,27 @@ func (svc *adapterService) Unsubscribe(ctx context.Context, key, chanID, subtopi
 	return svc.pubsub.Unsubscribe(ctx, token, subject)
 }
 
+// authenticate returns the ID of the client. Clients authenticated by the
+// transport, e.g. by the DTLS handshake, are identified by the client ID
+// from the context, otherwise the key is used.
+func (svc *adapterService) authenticate(ctx context.Context, key string) (string, error) {
+	if clientID, ok := ClientID(ctx); ok {
+		return clientID, nil
+	}
+
+	authnRes, err := svc.clients.Authenticate(ctx, &grpcClientsV1.AuthnReq{
+		ClientSecret: key,
+	})
+	if err != nil {
+		return "", errors.Wrap(svcerr.ErrAuthentication, err)
+	}
+	if !authnRes.Authenticated {
+		return "", svcerr.ErrAuthentication
+	}
+
+	return authnRes.GetId(), nil
+}
+
 func (svc *adapterService) DisconnectHandler(ctx context.Context, chanID, subtopic, token string) error {
 	subject := fmt.Sprintf("%s.%s", chansPrefix, chanID)
 	if subtopic != "" {
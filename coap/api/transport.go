@@ -1,7 +1,10 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"log/slog"
@@ -9,6 +12,7 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -17,6 +21,7 @@ import (
 	"github.com/hantdev/mitras/pkg/errors"
 	svcerr "github.com/hantdev/mitras/pkg/errors/service"
 	"github.com/hantdev/mitras/pkg/messaging"
+	piondtls "github.com/pion/dtls/v3"
 	"github.com/plgd-dev/go-coap/v3/message"
 	"github.com/plgd-dev/go-coap/v3/message/codes"
 	"github.com/plgd-dev/go-coap/v3/message/pool"
@@ -41,13 +46,36 @@ var (
 	errMalformedSubtopic = errors.New("malformed subtopic")
 	errBadOptions        = errors.New("bad options")
 	errMethodNotAllowed  = errors.New("method not allowed")
+	errPayloadTooLarge   = errors.New("payload too large")
 )
 
+// dtlsConn is the DTLS connection exposing the state of the handshake.
+type dtlsConn interface {
+	ConnectionState() (piondtls.State, bool)
+}
+
+// tlsConn is the TLS connection exposing the state of the handshake.
+type tlsConn interface {
+	ConnectionState() tls.ConnectionState
+}
+
 var (
-	logger  *slog.Logger
-	service coap.Service
+	logger         *slog.Logger
+	service        coap.Service
+	maxPayloadSize int64
 )
 
+// observation identifies the resource observed over the connection.
+type observation struct {
+	conn     mux.Conn
+	channel  string
+	subtopic string
+}
+
+// observers holds the clients by the observation. It's used to serve the
+// remaining blocks of the notifications sent using block-wise transfer.
+var observers sync.Map
+
 // MakeHandler returns a HTTP handler for API endpoints.
 func MakeHandler(instanceID string) http.Handler {
 	b := chi.NewRouter()
@@ -57,10 +85,13 @@ func MakeHandler(instanceID string) http.Handler {
 	return b
 }
 
-// MakeCoAPHandler creates handler for CoAP messages.
-func MakeCoAPHandler(svc coap.Service, l *slog.Logger) mux.HandlerFunc {
+// MakeCoAPHandler creates handler for CoAP messages. Messages with the
+// payload, reassembled from blocks when block-wise transfer is used, larger
+// than maxPayload bytes are rejected.
+func MakeCoAPHandler(svc coap.Service, l *slog.Logger, maxPayload int64) mux.HandlerFunc {
 	logger = l
 	service = svc
+	maxPayloadSize = maxPayload
 
 	return handler
 }
@@ -72,6 +103,11 @@ func sendResp(w mux.ResponseWriter, resp *pool.Message) {
 }
 
 func handler(w mux.ResponseWriter, m *mux.Message) {
+	if isBlockRequest(m) {
+		handleBlock(w, m)
+		return
+	}
+
 	resp := pool.NewMessage(w.Conn().Context())
 	resp.SetToken(m.Token())
 	for _, opt := range m.Options() {
@@ -83,9 +119,13 @@ func handler(w mux.ResponseWriter, m *mux.Message) {
 	if err != nil {
 		logger.Warn(fmt.Sprintf("Error decoding message: %s", err))
 		resp.SetCode(codes.BadRequest)
+		if err == errPayloadTooLarge {
+			resp.SetCode(codes.RequestEntityTooLarge)
+			resp.SetOptionUint32(message.Size1, uint32(maxPayloadSize))
+		}
 		return
 	}
-	key, err := parseKey(m)
+	clientID, key, err := parseAuth(w.Conn(), m)
 	if err != nil {
 		logger.Warn(fmt.Sprintf("Error parsing auth: %s", err))
 		resp.SetCode(codes.Unauthorized)
@@ -95,10 +135,10 @@ func handler(w mux.ResponseWriter, m *mux.Message) {
 	switch m.Code() {
 	case codes.GET:
 		resp.SetCode(codes.Content)
-		err = handleGet(m, w, msg, key)
+		err = handleGet(m, w, msg, clientID, key)
 	case codes.POST:
 		resp.SetCode(codes.Created)
-		err = service.Publish(m.Context(), key, msg)
+		err = service.Publish(withClientID(m.Context(), clientID), key, msg)
 	default:
 		err = errMethodNotAllowed
 	}
@@ -119,21 +159,69 @@ func handler(w mux.ResponseWriter, m *mux.Message) {
 	}
 }
 
-func handleGet(m *mux.Message, w mux.ResponseWriter, msg *messaging.Message, key string) error {
+func handleGet(m *mux.Message, w mux.ResponseWriter, msg *messaging.Message, clientID, key string) error {
 	var obs uint32
 	obs, err := m.Options().Observe()
 	if err != nil {
 		logger.Warn(fmt.Sprintf("Error reading observe option: %s", err))
 		return errBadOptions
 	}
+	obsKey := observation{conn: w.Conn(), channel: msg.GetChannel(), subtopic: msg.GetSubtopic()}
 	if obs == startObserve {
 		c := coap.NewClient(w.Conn(), m.Token(), logger)
 		w.Conn().AddOnClose(func() {
+			observers.Delete(obsKey)
 			_ = service.DisconnectHandler(context.Background(), msg.GetChannel(), msg.GetSubtopic(), c.Token())
 		})
-		return service.Subscribe(w.Conn().Context(), key, msg.GetChannel(), msg.GetSubtopic(), c)
+		if err := service.Subscribe(withClientID(w.Conn().Context(), clientID), key, msg.GetChannel(), msg.GetSubtopic(), c); err != nil {
+			return err
+		}
+		observers.Store(obsKey, c)
+		return nil
+	}
+	observers.Delete(obsKey)
+	return service.Unsubscribe(withClientID(w.Conn().Context(), clientID), key, msg.GetChannel(), msg.GetSubtopic(), m.Token().String())
+}
+
+// isBlockRequest returns true if the message is the request for the block of
+// the notification. Clients retrieve the remaining blocks of the notification
+// using GET requests without the Observe option, as defined in RFC 7959.
+func isBlockRequest(m *mux.Message) bool {
+	return m.Code() == codes.GET && m.Options().HasOption(message.Block2) && !m.Options().HasOption(message.Observe)
+}
+
+// handleBlock responds with the last notification sent to the client
+// observing the resource over the connection. The response is split into
+// the requested block by the block-wise transfer of the server. The client
+// is authorized when the observation is registered, so only the resources
+// observed over the same connection are served.
+func handleBlock(w mux.ResponseWriter, m *mux.Message) {
+	code := codes.NotFound
+	var body io.ReadSeeker
+	var opts []message.Option
+
+	msg, err := decodeMessage(m)
+	switch {
+	case err != nil:
+		logger.Warn(fmt.Sprintf("Error decoding message: %s", err))
+		code = codes.BadRequest
+	default:
+		c, ok := observers.Load(observation{conn: w.Conn(), channel: msg.GetChannel(), subtopic: msg.GetSubtopic()})
+		if !ok {
+			break
+		}
+		payload, etag := c.(coap.Client).Notification()
+		if etag == nil {
+			break
+		}
+		code = codes.Content
+		body = bytes.NewReader(payload)
+		opts = append(opts, message.Option{ID: message.ETag, Value: etag})
+	}
+
+	if err := w.SetResponse(code, message.TextPlain, body, opts...); err != nil {
+		logger.Warn(fmt.Sprintf("Can't set response: %s", err))
 	}
-	return service.Unsubscribe(w.Conn().Context(), key, msg.GetChannel(), msg.GetSubtopic(), m.Token().String())
 }
 
 func decodeMessage(msg *mux.Message) (*messaging.Message, error) {
@@ -162,15 +250,57 @@ func decodeMessage(msg *mux.Message) (*messaging.Message, error) {
 	}
 
 	if msg.Body() != nil {
-		buff, err := io.ReadAll(msg.Body())
+		buff, err := io.ReadAll(io.LimitReader(msg.Body(), maxPayloadSize+1))
 		if err != nil {
 			return ret, err
 		}
+		if int64(len(buff)) > maxPayloadSize {
+			return ret, errPayloadTooLarge
+		}
 		ret.Payload = buff
 	}
 	return ret, nil
 }
 
+// parseAuth returns the client ID or the key of the client. Clients connected
+// over DTLS are identified by the PSK identity, which is the client ID.
+// Clients connected over DTLS or TLS using a verified client certificate are
+// identified by the certificate common name, which is the client key. Other
+// clients authenticate using the auth query key.
+func parseAuth(conn mux.Conn, msg *mux.Message) (string, string, error) {
+	switch c := conn.NetConn().(type) {
+	case dtlsConn:
+		state, ok := c.ConnectionState()
+		switch {
+		case ok && len(state.IdentityHint) > 0:
+			return string(state.IdentityHint), "", nil
+		case ok && len(state.PeerCertificates) > 0:
+			cert, err := x509.ParseCertificate(state.PeerCertificates[0])
+			if err != nil {
+				return "", "", errors.Wrap(svcerr.ErrAuthentication, err)
+			}
+			if cert.Subject.CommonName != "" {
+				return "", cert.Subject.CommonName, nil
+			}
+		}
+	case tlsConn:
+		state := c.ConnectionState()
+		if len(state.PeerCertificates) > 0 && state.PeerCertificates[0].Subject.CommonName != "" {
+			return "", state.PeerCertificates[0].Subject.CommonName, nil
+		}
+	}
+
+	key, err := parseKey(msg)
+	return "", key, err
+}
+
+func withClientID(ctx context.Context, clientID string) context.Context {
+	if clientID == "" {
+		return ctx
+	}
+	return coap.WithClientID(ctx, clientID)
+}
+
 func parseKey(msg *mux.Message) (string, error) {
 	authKey, err := msg.Options().GetString(message.URIQuery)
 	if err != nil {
@@ -0,0 +1,390 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	chmocks "github.com/hantdev/mitras/channels/mocks"
+	climocks "github.com/hantdev/mitras/clients/mocks"
+	"github.com/hantdev/mitras/coap"
+	"github.com/hantdev/mitras/coap/api"
+	grpcChannelsV1 "github.com/hantdev/mitras/internal/grpc/channels/v1"
+	grpcClientsV1 "github.com/hantdev/mitras/internal/grpc/clients/v1"
+	"github.com/hantdev/mitras/internal/testsutil"
+	smqlog "github.com/hantdev/mitras/logger"
+	"github.com/hantdev/mitras/pkg/messaging"
+	pubsub "github.com/hantdev/mitras/pkg/messaging/mocks"
+	"github.com/hantdev/mitras/pkg/server"
+	coapserver "github.com/hantdev/mitras/pkg/server/coap"
+	piondtls "github.com/pion/dtls/v3"
+	"github.com/plgd-dev/go-coap/v3/dtls"
+	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+	"github.com/plgd-dev/go-coap/v3/message/pool"
+	coapnet "github.com/plgd-dev/go-coap/v3/net"
+	"github.com/plgd-dev/go-coap/v3/net/blockwise"
+	"github.com/plgd-dev/go-coap/v3/options"
+	"github.com/plgd-dev/go-coap/v3/udp"
+	"github.com/plgd-dev/go-coap/v3/udp/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	clientKey      = "client-key"
+	maxPayloadSize = 4096
+	blockSZX       = blockwise.SZX64
+	timeout        = 5 * time.Second
+)
+
+var (
+	clientID  = testsutil.GenerateUUID(&testing.T{})
+	channelID = testsutil.GenerateUUID(&testing.T{})
+)
+
+var (
+	ps         = new(pubsub.PubSub)
+	serverAddr = sync.OnceValue(func() string {
+		return newServer(newService())
+	})
+)
+
+func newService() coap.Service {
+	clients := new(climocks.ClientsServiceClient)
+	channels := new(chmocks.ChannelsServiceClient)
+	clients.On("Authenticate", mock.Anything, &grpcClientsV1.AuthnReq{ClientSecret: clientKey}).Return(&grpcClientsV1.AuthnRes{Authenticated: true, Id: clientID}, nil)
+	channels.On("Authorize", mock.Anything, mock.Anything).Return(&grpcChannelsV1.AuthzRes{Authorized: true}, nil)
+	ps.On("Unsubscribe", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	return coap.New(clients, channels, ps)
+}
+
+// newServer starts the CoAP server shared by the tests, since the CoAP
+// handler is configured once per process.
+func newServer(svc coap.Service) string {
+	listener, err := coapnet.NewListenUDP("udp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("failed to start CoAP server: %s", err))
+	}
+	srv := udp.NewServer(options.WithMux(api.MakeCoAPHandler(svc, smqlog.NewMock(), maxPayloadSize)), options.WithBlockwise(true, blockSZX, timeout))
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+
+	return listener.LocalAddr().String()
+}
+
+func newClient(t *testing.T, addr string) *client.Conn {
+	cc, err := udp.Dial(addr, options.WithBlockwise(true, blockSZX, timeout))
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	t.Cleanup(func() {
+		_ = cc.Close()
+	})
+
+	return cc
+}
+
+func TestPublish(t *testing.T) {
+	cc := newClient(t, serverAddr())
+
+	cases := []struct {
+		desc    string
+		payload []byte
+		status  codes.Code
+	}{
+		{
+			desc:    "publish message in a single block",
+			payload: []byte("hello"),
+			status:  codes.Created,
+		},
+		{
+			desc:    "publish message using block-wise transfer",
+			payload: bytes.Repeat([]byte{'a'}, 3000),
+			status:  codes.Created,
+		},
+		{
+			desc:    "publish message with max payload size using block-wise transfer",
+			payload: bytes.Repeat([]byte{'b'}, maxPayloadSize),
+			status:  codes.Created,
+		},
+		{
+			desc:    "publish message with payload exceeding max payload size",
+			payload: bytes.Repeat([]byte{'c'}, maxPayloadSize+1),
+			status:  codes.RequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			payloads := make(chan []byte, 1)
+			repoCall := ps.On("Publish", mock.Anything, channelID, mock.Anything).Run(func(args mock.Arguments) {
+				payloads <- args.Get(2).(*messaging.Message).GetPayload()
+			}).Return(nil)
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			res, err := cc.Post(ctx, fmt.Sprintf("/channels/%s/messages", channelID), message.TextPlain, bytes.NewReader(tc.payload), authOption())
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.Code(), fmt.Sprintf("%s: expected status code %s got %s", tc.desc, tc.status, res.Code()))
+			if tc.status == codes.Created {
+				assert.Equal(t, tc.payload, <-payloads, fmt.Sprintf("%s: got unexpected payload", tc.desc))
+			}
+			if tc.status == codes.RequestEntityTooLarge {
+				size, err := res.Options().GetUint32(message.Size1)
+				assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+				assert.Equal(t, uint32(maxPayloadSize), size, fmt.Sprintf("%s: got unexpected max payload size", tc.desc))
+			}
+			repoCall.Unset()
+		})
+	}
+}
+
+func TestObserve(t *testing.T) {
+	handlers := make(chan messaging.MessageHandler, 1)
+	ps.On("Subscribe", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		handlers <- args.Get(1).(messaging.SubscriberConfig).Handler
+	}).Return(nil)
+	cc := newClient(t, serverAddr())
+
+	notifications := make(chan []byte, 10)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	obs, err := cc.Observe(ctx, fmt.Sprintf("/channels/%s/messages", channelID), func(req *pool.Message) {
+		if req.Body() == nil {
+			return
+		}
+		data, err := io.ReadAll(req.Body())
+		if err == nil && len(data) > 0 {
+			notifications <- data
+		}
+	}, authOption())
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	defer func() {
+		_ = obs.Cancel(context.Background())
+	}()
+	handler := <-handlers
+
+	cases := []struct {
+		desc    string
+		payload []byte
+	}{
+		{
+			desc:    "notify message in a single block",
+			payload: []byte("hello"),
+		},
+		{
+			desc:    "notify message using block-wise transfer",
+			payload: bytes.Repeat([]byte{'a'}, 1000),
+		},
+		{
+			desc:    "notify another message using block-wise transfer",
+			payload: bytes.Repeat([]byte{'b'}, 3000),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := handler.Handle(&messaging.Message{Channel: channelID, Payload: tc.payload})
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			select {
+			case payload := <-notifications:
+				assert.Equal(t, tc.payload, payload, fmt.Sprintf("%s: got unexpected notification", tc.desc))
+			case <-time.After(timeout):
+				assert.Fail(t, fmt.Sprintf("%s: notification not received", tc.desc))
+			}
+		})
+	}
+
+	// Blocks of notifications are served only to the clients observing the resource.
+	other := newClient(t, serverAddr())
+	req, err := other.NewGetRequest(ctx, fmt.Sprintf("/channels/%s/messages", channelID), authOption())
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	block, err := blockwise.EncodeBlockOption(blockSZX, 1, false)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	req.SetOptionUint32(message.Block2, block)
+	res, err := other.Do(req)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	assert.Equal(t, codes.NotFound, res.Code(), fmt.Sprintf("expected status code %s got %s", codes.NotFound, res.Code()))
+}
+
+func authOption() message.Option {
+	return message.Option{ID: message.URIQuery, Value: []byte("auth=" + clientKey)}
+}
+
+// newCert returns the certificate with the given common name signed by the
+// parent, or self-signed CA certificate if the parent is nil.
+func newCert(t *testing.T, cn string, usage x509.ExtKeyUsage, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err, fmt.Sprintf("generate key unexpected error: %s", err))
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+		tmpl.ExtKeyUsage = nil
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey.(*ecdsa.PrivateKey)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.Nil(t, err, fmt.Sprintf("create certificate unexpected error: %s", err))
+	leaf, err := x509.ParseCertificate(der)
+	require.Nil(t, err, fmt.Sprintf("parse certificate unexpected error: %s", err))
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func writePEM(t *testing.T, blockType string, data []byte) string {
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0o600)
+	require.Nil(t, err, fmt.Sprintf("write PEM unexpected error: %s", err))
+
+	return path
+}
+
+// newDTLSServer starts the CoAP over DTLS server accepting client
+// certificates signed by the CA and PSK identities of the clients service.
+func newDTLSServer(t *testing.T, ca tls.Certificate, clients *climocks.ClientsServiceClient) string {
+	serverCert := newCert(t, "coap", x509.ExtKeyUsageServerAuth, &ca)
+	serverKey, err := x509.MarshalPKCS8PrivateKey(serverCert.PrivateKey)
+	require.Nil(t, err, fmt.Sprintf("marshal key unexpected error: %s", err))
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err, fmt.Sprintf("listen unexpected error: %s", err))
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	require.Nil(t, conn.Close(), "close unexpected error")
+
+	cfg := server.Config{
+		Host:         "127.0.0.1",
+		Port:         strconv.Itoa(port),
+		CertFile:     writePEM(t, "CERTIFICATE", serverCert.Certificate[0]),
+		KeyFile:      writePEM(t, "PRIVATE KEY", serverKey),
+		ClientCAFile: writePEM(t, "CERTIFICATE", ca.Certificate[0]),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := api.MakeCoAPHandler(newService(), smqlog.NewMock(), maxPayloadSize)
+	srv := coapserver.NewServer(ctx, cancel, "coap", cfg, handler, smqlog.NewMock(), coapserver.WithPSK(coap.PSK(clients)))
+	go func() {
+		_ = srv.Start()
+	}()
+	t.Cleanup(func() {
+		_ = srv.Stop()
+	})
+
+	return fmt.Sprintf("127.0.0.1:%d", port)
+}
+
+func TestDTLS(t *testing.T) {
+	pskClientID := testsutil.GenerateUUID(t)
+	pskSecret := "psk-secret"
+	ca := newCert(t, "ca", 0, nil)
+	otherCA := newCert(t, "other-ca", 0, nil)
+
+	clients := new(climocks.ClientsServiceClient)
+	clients.On("RetrieveSecret", mock.Anything, &grpcClientsV1.RetrieveSecretReq{Id: pskClientID}).Return(&grpcClientsV1.RetrieveSecretRes{Secret: pskSecret}, nil)
+	addr := newDTLSServer(t, ca, clients)
+
+	pskConfig := func(identity, secret string) *piondtls.Config {
+		return &piondtls.Config{
+			PSK: func([]byte) ([]byte, error) {
+				return []byte(secret), nil
+			},
+			PSKIdentityHint: []byte(identity),
+			CipherSuites:    []piondtls.CipherSuiteID{piondtls.TLS_PSK_WITH_AES_128_CCM_8},
+		}
+	}
+	certConfig := func(cert tls.Certificate) *piondtls.Config {
+		return &piondtls.Config{
+			Certificates:         []tls.Certificate{cert},
+			InsecureSkipVerify:   true,
+			ExtendedMasterSecret: piondtls.RequireExtendedMasterSecret,
+		}
+	}
+
+	cases := []struct {
+		desc      string
+		config    *piondtls.Config
+		publisher string
+		status    codes.Code
+		err       bool
+	}{
+		{
+			desc:      "publish with PSK identity",
+			config:    pskConfig(pskClientID, pskSecret),
+			publisher: pskClientID,
+			status:    codes.Created,
+		},
+		{
+			desc:      "publish with client certificate",
+			config:    certConfig(newCert(t, clientKey, x509.ExtKeyUsageClientAuth, &ca)),
+			publisher: clientID,
+			status:    codes.Created,
+		},
+		{
+			desc:   "publish with invalid PSK secret",
+			config: pskConfig(pskClientID, "invalid"),
+			err:    true,
+		},
+		{
+			// Client certificates not signed by the client CA are not sent,
+			// so the client is not authenticated.
+			desc:   "publish with client certificate of unknown CA",
+			config: certConfig(newCert(t, clientKey, x509.ExtKeyUsageClientAuth, &otherCA)),
+			status: codes.Unauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			publishers := make(chan string, 1)
+			repoCall := ps.On("Publish", mock.Anything, channelID, mock.Anything).Run(func(args mock.Arguments) {
+				publishers <- args.Get(2).(*messaging.Message).GetPublisher()
+			}).Return(nil)
+			defer repoCall.Unset()
+
+			cc, err := dtls.Dial(addr, tc.config)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			defer cc.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			// Clients authenticated by the DTLS handshake do not send the
+			// auth query key.
+			res, err := cc.Post(ctx, fmt.Sprintf("/channels/%s/messages", channelID), message.TextPlain, bytes.NewReader([]byte("hello")))
+			if tc.err {
+				assert.NotNil(t, err, fmt.Sprintf("%s: expected handshake error", tc.desc))
+				return
+			}
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.Code(), fmt.Sprintf("%s: expected status code %s got %s", tc.desc, tc.status, res.Code()))
+			if tc.status == codes.Created {
+				assert.Equal(t, tc.publisher, <-publishers, fmt.Sprintf("%s: got unexpected publisher", tc.desc))
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package coap
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"log/slog"
+	"sync"
 	"sync/atomic"
 
 	"github.com/hantdev/mitras/pkg/errors"
@@ -21,6 +23,11 @@ type Client interface {
 	// Handle handles incoming messages.
 	Handle(m *messaging.Message) error
 
+	// Notification returns the payload and the ETag of the last notification
+	// sent to the client. It's used to serve the remaining blocks of the
+	// notifications sent using block-wise transfer.
+	Notification() ([]byte, []byte)
+
 	// Cancel cancels the client.
 	Cancel() error
 
@@ -36,6 +43,9 @@ type client struct {
 	token   message.Token
 	observe uint32
 	logger  *slog.Logger
+	mu      sync.Mutex
+	payload []byte
+	etag    []byte
 }
 
 // NewClient instantiates a new Observer.
@@ -67,6 +77,12 @@ func (c *client) Token() string {
 	return c.token.String()
 }
 
+func (c *client) Notification() ([]byte, []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.payload, c.etag
+}
+
 func (c *client) Handle(msg *messaging.Message) error {
 	pm := c.conn.AcquireMessage(c.conn.Context())
 	defer c.conn.ReleaseMessage(pm)
@@ -74,7 +90,13 @@ func (c *client) Handle(msg *messaging.Message) error {
 	pm.SetToken(c.token)
 	pm.SetBody(bytes.NewReader(msg.GetPayload()))
 
-	atomic.AddUint32(&c.observe, 1)
+	// The observe sequence number is used as the ETag, so the clients can
+	// detect a notification changed during the block-wise transfer.
+	etag := binary.BigEndian.AppendUint32(nil, atomic.AddUint32(&c.observe, 1))
+	c.mu.Lock()
+	c.payload, c.etag = msg.GetPayload(), etag
+	c.mu.Unlock()
+	pm.SetOptionBytes(message.ETag, etag)
 	var opts message.Options
 	var buff []byte
 	opts, n, err := opts.SetContentFormat(buff, message.TextPlain)
@@ -0,0 +1,51 @@
+package coap
+
+import (
+	"context"
+	"time"
+
+	grpcClientsV1 "github.com/hantdev/mitras/internal/grpc/clients/v1"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+)
+
+// pskTimeout bounds the retrieval of the client secret during the DTLS
+// handshake.
+const pskTimeout = 5 * time.Second
+
+type clientIDKey struct{}
+
+// WithClientID returns the context carrying the ID of the client
+// authenticated by the transport.
+func WithClientID(ctx context.Context, clientID string) context.Context {
+	return context.WithValue(ctx, clientIDKey{}, clientID)
+}
+
+// ClientID returns the ID of the client authenticated by the transport.
+func ClientID(ctx context.Context) (string, bool) {
+	clientID, ok := ctx.Value(clientIDKey{}).(string)
+	return clientID, ok && clientID != ""
+}
+
+// PSK returns the DTLS pre-shared key callback. The PSK identity is the
+// client ID and the pre-shared key is the client secret.
+func PSK(clients grpcClientsV1.ClientsServiceClient) func(identity []byte) ([]byte, error) {
+	return func(identity []byte) ([]byte, error) {
+		if len(identity) == 0 {
+			return nil, svcerr.ErrAuthentication
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), pskTimeout)
+		defer cancel()
+		res, err := clients.RetrieveSecret(ctx, &grpcClientsV1.RetrieveSecretReq{
+			Id: string(identity),
+		})
+		if err != nil {
+			return nil, errors.Wrap(svcerr.ErrAuthentication, err)
+		}
+		if res.GetSecret() == "" {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		return []byte(res.GetSecret()), nil
+	}
+}
@@ -15,6 +15,8 @@ import (
 // ErrMessage indicates an error converting a message to mitras message.
 var ErrMessage = errors.New("failed to convert to Mitras message")
 
+var errReadOnly = errors.New("read-only impersonation can't modify the subscriptions")
+
 var _ consumers.AsyncConsumer = (*notifierService)(nil)
 
 // Service reprents a notification service.
@@ -65,6 +67,9 @@ func (ns *notifierService) CreateSubscription(ctx context.Context, token string,
 	if err != nil {
 		return "", err
 	}
+	if session.ReadOnly {
+		return "", errors.Wrap(svcerr.ErrAuthorization, errReadOnly)
+	}
 	sub.ID, err = ns.idp.ID()
 	if err != nil {
 		return "", err
@@ -95,9 +100,13 @@ func (ns *notifierService) ListSubscriptions(ctx context.Context, token string,
 }
 
 func (ns *notifierService) RemoveSubscription(ctx context.Context, token, id string) error {
-	if _, err := ns.authn.Authenticate(ctx, token); err != nil {
+	session, err := ns.authn.Authenticate(ctx, token)
+	if err != nil {
 		return err
 	}
+	if session.ReadOnly {
+		return errors.Wrap(svcerr.ErrAuthorization, errReadOnly)
+	}
 
 	return ns.subs.Remove(ctx, id)
 }
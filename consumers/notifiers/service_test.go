@@ -46,6 +46,7 @@ func TestCreateSubscription(t *testing.T) {
 		err             error
 		authenticateErr error
 		userID          string
+		readOnly        bool
 	}{
 		{
 			desc:            "test success",
@@ -73,10 +74,19 @@ func TestCreateSubscription(t *testing.T) {
 			err:             svcerr.ErrAuthentication,
 			authenticateErr: svcerr.ErrAuthentication,
 		},
+		{
+			desc:     "test with read-only impersonation",
+			token:    exampleUser1,
+			sub:      notifiers.Subscription{Contact: exampleUser1, Topic: "valid.topic"},
+			id:       "",
+			err:      svcerr.ErrAuthorization,
+			userID:   validID,
+			readOnly: true,
+		},
 	}
 
 	for _, tc := range cases {
-		repoCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.authenticateErr)
+		repoCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID, ReadOnly: tc.readOnly}, tc.authenticateErr)
 		repoCall1 := repo.On("Save", context.Background(), mock.Anything).Return(tc.id, tc.err)
 		id, err := svc.CreateSubscription(context.Background(), tc.token, tc.sub)
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
@@ -283,6 +293,7 @@ func TestRemoveSubscription(t *testing.T) {
 		err             error
 		authenticateErr error
 		userID          string
+		readOnly        bool
 	}{
 		{
 			desc:            "test success",
@@ -307,10 +318,18 @@ func TestRemoveSubscription(t *testing.T) {
 			err:             svcerr.ErrAuthentication,
 			authenticateErr: svcerr.ErrAuthentication,
 		},
+		{
+			desc:     "test with read-only impersonation",
+			token:    exampleUser1,
+			id:       sub.ID,
+			err:      svcerr.ErrAuthorization,
+			userID:   validID,
+			readOnly: true,
+		},
 	}
 
 	for _, tc := range cases {
-		repoCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.authenticateErr)
+		repoCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID, ReadOnly: tc.readOnly}, tc.authenticateErr)
 		repoCall1 := repo.On("Remove", context.Background(), tc.id).Return(tc.err)
 		err := svc.RemoveSubscription(context.Background(), tc.token, tc.id)
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
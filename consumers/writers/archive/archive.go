@@ -0,0 +1,145 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hantdev/mitras/consumers"
+	"github.com/hantdev/mitras/pkg/errors"
+)
+
+const (
+	// NDJSON encodes partitions as gzip compressed newline delimited JSON.
+	NDJSON = "ndjson"
+	// Parquet encodes partitions as gzip compressed Parquet files.
+	Parquet = "parquet"
+
+	// SenMLFormat is the partition format of SenML messages.
+	SenMLFormat = "senml"
+
+	// manifestDir holds the manifest object of every written partition.
+	manifestDir = "manifest"
+	// legacyManifestKey is the single manifest object written by previous
+	// versions of the archive writer.
+	legacyManifestKey = "manifest.json"
+)
+
+var (
+	// ErrNotFound indicates a non-existent archive object.
+	ErrNotFound = errors.New("archive object not found")
+
+	// ErrInvalidEncoding indicates an unsupported partition encoding.
+	ErrInvalidEncoding = errors.New("invalid archive encoding")
+
+	errArchive = errors.New("failed to archive messages")
+	errInvalid = errors.New("invalid message representation")
+)
+
+// Config defines the options of the archive writer.
+type Config struct {
+	// Encoding of the partition files, NDJSON or Parquet.
+	Encoding string
+	// Prefix of the keys of the archived objects.
+	Prefix string
+	// MaxSize is the approximate size in bytes of the messages of a
+	// partition after which the partition file is written.
+	MaxSize int64
+	// MaxAge is the maximum time messages are buffered before the partition
+	// file is written.
+	MaxAge time.Duration
+}
+
+// Storage stores the archived objects.
+type Storage interface {
+	// Put stores the object under the given key, replacing existing one.
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Get retrieves the object stored under the given key. ErrNotFound is
+	// returned if there is no such object.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// List retrieves the keys of the objects with the given key prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Archive is a message writer archiving messages into partition files.
+// Messages are buffered in memory per channel, message format and hour of
+// the message time until the partition reaches the maximum size or age.
+type Archive interface {
+	consumers.BlockingConsumer
+
+	// Flush writes the partitions buffered longer than the maximum age, or
+	// all buffered partitions if force is true.
+	Flush(ctx context.Context, force bool) error
+}
+
+// Manifest lists the archived partition files so they can be queried or
+// replayed later. Every partition file is recorded in its own manifest
+// object, so multiple writer instances can share the archive prefix.
+type Manifest struct {
+	Partitions []PartitionInfo `json:"partitions"`
+}
+
+// PartitionInfo describes an archived partition file.
+type PartitionInfo struct {
+	Key      string    `json:"key"`
+	Format   string    `json:"format"`
+	Encoding string    `json:"encoding"`
+	Channel  string    `json:"channel"`
+	From     int64     `json:"from"`
+	To       int64     `json:"to"`
+	Count    int       `json:"count"`
+	Size     int       `json:"size"`
+	Created  time.Time `json:"created"`
+}
+
+// ReadManifest retrieves the manifest of the archive with the given prefix
+// by merging the manifest objects of the archived partitions. Partitions
+// are sorted by creation time.
+func ReadManifest(ctx context.Context, storage Storage, prefix string) (Manifest, error) {
+	var m Manifest
+
+	data, err := storage.Get(ctx, path.Join(prefix, legacyManifestKey))
+	switch {
+	case errors.Contains(err, ErrNotFound):
+	case err != nil:
+		return Manifest{}, err
+	default:
+		if err := json.Unmarshal(data, &m); err != nil {
+			return Manifest{}, err
+		}
+	}
+
+	keys, err := storage.List(ctx, path.Join(prefix, manifestDir)+"/")
+	if err != nil {
+		return Manifest{}, err
+	}
+	for _, key := range keys {
+		if !strings.HasSuffix(key, ".json") {
+			continue
+		}
+		data, err := storage.Get(ctx, key)
+		if err != nil {
+			return Manifest{}, err
+		}
+		var p PartitionInfo
+		if err := json.Unmarshal(data, &p); err != nil {
+			return Manifest{}, err
+		}
+		m.Partitions = append(m.Partitions, p)
+	}
+
+	sort.SliceStable(m.Partitions, func(i, j int) bool {
+		return m.Partitions[i].Created.Before(m.Partitions[j].Created)
+	})
+
+	return m, nil
+}
+
+func manifestKey(prefix, id string) string {
+	return path.Join(prefix, manifestDir, id+".json")
+}
@@ -0,0 +1,3 @@
+// Package archive contains the message writer archiving messages into
+// time-partitioned files on the local filesystem or an S3-compatible bucket.
+package archive
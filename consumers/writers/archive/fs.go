@@ -0,0 +1,85 @@
+package archive
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var _ Storage = (*fsStorage)(nil)
+
+type fsStorage struct {
+	dir string
+}
+
+// NewFSStorage returns storage keeping archived objects as files in the
+// given directory.
+func NewFSStorage(dir string) Storage {
+	return &fsStorage{dir: dir}
+}
+
+func (s *fsStorage) Put(_ context.Context, key string, data []byte) error {
+	name := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	// Objects are written to temporary files and renamed so readers never
+	// observe partially written files.
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".archive-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *fsStorage) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	return data, err
+}
+
+func (s *fsStorage) List(_ context.Context, prefix string) ([]string, error) {
+	// Only the directory of the prefix needs to be walked.
+	root := filepath.Join(s.dir, filepath.FromSlash(prefix[:strings.LastIndex(prefix, "/")+1]))
+
+	var keys []string
+	err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		switch {
+		case os.IsNotExist(err):
+			return filepath.SkipDir
+		case err != nil:
+			return err
+		case d.IsDir(), strings.HasPrefix(d.Name(), ".archive-"):
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, name)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
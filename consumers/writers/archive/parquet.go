@@ -0,0 +1,117 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+
+	smqjson "github.com/hantdev/mitras/pkg/transformers/json"
+	"github.com/hantdev/mitras/pkg/transformers/senml"
+	"github.com/parquet-go/parquet-go"
+)
+
+// senmlRow is the Parquet row of a SenML message.
+type senmlRow struct {
+	Channel     string   `parquet:"channel"`
+	Subtopic    string   `parquet:"subtopic"`
+	Publisher   string   `parquet:"publisher"`
+	Protocol    string   `parquet:"protocol"`
+	Name        string   `parquet:"name"`
+	Unit        string   `parquet:"unit"`
+	Time        float64  `parquet:"time"`
+	UpdateTime  float64  `parquet:"update_time"`
+	Value       *float64 `parquet:"value,optional"`
+	StringValue *string  `parquet:"string_value,optional"`
+	DataValue   *string  `parquet:"data_value,optional"`
+	BoolValue   *bool    `parquet:"bool_value,optional"`
+	Sum         *float64 `parquet:"sum,optional"`
+}
+
+// jsonRow is the Parquet row of a JSON message. Payload is stored as JSON
+// string.
+type jsonRow struct {
+	Channel   string  `parquet:"channel"`
+	Created   int64   `parquet:"created"`
+	Subtopic  string  `parquet:"subtopic"`
+	Publisher string  `parquet:"publisher"`
+	Protocol  string  `parquet:"protocol"`
+	Payload   *string `parquet:"payload,optional"`
+}
+
+func encodeSenMLParquet(msgs []senml.Message) ([]byte, error) {
+	rows := make([]senmlRow, len(msgs))
+	for i, msg := range msgs {
+		rows[i] = senmlRow(msg)
+	}
+
+	return encodeParquet(rows)
+}
+
+func encodeJSONParquet(msgs []smqjson.Message) ([]byte, error) {
+	rows := make([]jsonRow, len(msgs))
+	for i, msg := range msgs {
+		rows[i] = jsonRow{
+			Channel:   msg.Channel,
+			Created:   msg.Created,
+			Subtopic:  msg.Subtopic,
+			Publisher: msg.Publisher,
+			Protocol:  msg.Protocol,
+		}
+		if msg.Payload != nil {
+			data, err := json.Marshal(msg.Payload)
+			if err != nil {
+				return nil, err
+			}
+			payload := string(data)
+			rows[i].Payload = &payload
+		}
+	}
+
+	return encodeParquet(rows)
+}
+
+func encodeParquet[T any](rows []T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, rows, parquet.Compression(&parquet.Gzip)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeParquet decodes the partition file into the messages in the same
+// representation as consumed by the writers.
+func decodeParquet(format string, data []byte) (interface{}, error) {
+	if format == SenMLFormat {
+		rows, err := parquet.Read[senmlRow](bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		msgs := make([]senml.Message, len(rows))
+		for i, row := range rows {
+			msgs[i] = senml.Message(row)
+		}
+		return msgs, nil
+	}
+
+	rows, err := parquet.Read[jsonRow](bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	msgs := smqjson.Messages{Format: format}
+	for _, row := range rows {
+		msg := smqjson.Message{
+			Channel:   row.Channel,
+			Created:   row.Created,
+			Subtopic:  row.Subtopic,
+			Publisher: row.Publisher,
+			Protocol:  row.Protocol,
+		}
+		if row.Payload != nil {
+			if err := json.Unmarshal([]byte(*row.Payload), &msg.Payload); err != nil {
+				return nil, err
+			}
+		}
+		msgs.Data = append(msgs.Data, msg)
+	}
+	return msgs, nil
+}
@@ -0,0 +1,85 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+
+	"github.com/hantdev/mitras/consumers"
+	"github.com/hantdev/mitras/pkg/errors"
+	smqjson "github.com/hantdev/mitras/pkg/transformers/json"
+	"github.com/hantdev/mitras/pkg/transformers/senml"
+)
+
+var errReplay = errors.New("failed to replay archived messages")
+
+// Replay decodes the archived partitions accepted by the filter and consumes
+// their messages with the consumer. A nil filter accepts all partitions.
+func Replay(ctx context.Context, storage Storage, prefix string, filter func(PartitionInfo) bool, consumer consumers.BlockingConsumer) error {
+	manifest, err := ReadManifest(ctx, storage, prefix)
+	if err != nil {
+		return errors.Wrap(errReplay, err)
+	}
+
+	for _, p := range manifest.Partitions {
+		if filter != nil && !filter(p) {
+			continue
+		}
+		data, err := storage.Get(ctx, p.Key)
+		if err != nil {
+			return errors.Wrap(errReplay, err)
+		}
+		var msgs interface{}
+		switch p.Encoding {
+		case NDJSON:
+			msgs, err = decodeNDJSON(p.Format, data)
+		case Parquet:
+			msgs, err = decodeParquet(p.Format, data)
+		default:
+			err = ErrInvalidEncoding
+		}
+		if err != nil {
+			return errors.Wrap(errReplay, err)
+		}
+		if err := consumer.ConsumeBlocking(ctx, msgs); err != nil {
+			return errors.Wrap(errReplay, err)
+		}
+	}
+
+	return nil
+}
+
+// decodeNDJSON decodes the partition file into the messages in the same
+// representation as consumed by the writers.
+func decodeNDJSON(format string, data []byte) (interface{}, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(zr))
+	if format == SenMLFormat {
+		msgs := []senml.Message{}
+		for dec.More() {
+			var msg senml.Message
+			if err := dec.Decode(&msg); err != nil {
+				return nil, err
+			}
+			msgs = append(msgs, msg)
+		}
+		return msgs, nil
+	}
+
+	msgs := smqjson.Messages{Format: format}
+	for dec.More() {
+		var msg smqjson.Message
+		if err := dec.Decode(&msg); err != nil {
+			return nil, err
+		}
+		msgs.Data = append(msgs.Data, msg)
+	}
+	return msgs, nil
+}
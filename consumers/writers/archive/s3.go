@@ -0,0 +1,231 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hantdev/mitras/pkg/errors"
+)
+
+const (
+	s3Algorithm = "AWS4-HMAC-SHA256"
+	s3Service   = "s3"
+	s3Request   = "aws4_request"
+	s3TimeFmt   = "20060102T150405Z"
+	s3DateFmt   = "20060102"
+	signed      = "host;x-amz-content-sha256;x-amz-date"
+)
+
+var errS3Request = errors.New("S3 request failed")
+
+// S3Config defines the options of the S3-compatible bucket.
+type S3Config struct {
+	Endpoint  string `env:"ENDPOINT"   envDefault:"localhost:9000"`
+	Bucket    string `env:"BUCKET"     envDefault:"mitras-archive"`
+	Region    string `env:"REGION"     envDefault:"us-east-1"`
+	AccessKey string `env:"ACCESS_KEY" envDefault:""`
+	SecretKey string `env:"SECRET_KEY" envDefault:""`
+	UseSSL    bool   `env:"USE_SSL"    envDefault:"false"`
+}
+
+var _ Storage = (*s3Storage)(nil)
+
+type s3Storage struct {
+	cfg    S3Config
+	client *http.Client
+}
+
+// NewS3Storage returns storage keeping archived objects in the bucket of
+// an S3-compatible object storage. Requests use path-style addressing and
+// AWS Signature Version 4.
+func NewS3Storage(cfg S3Config, client *http.Client) Storage {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &s3Storage{cfg: cfg, client: client}
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, data []byte) error {
+	res, err := s.do(ctx, http.MethodPut, key, nil, data)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return s3Error(res)
+	}
+
+	return nil
+}
+
+func (s *s3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	res, err := s.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(res.Body)
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, s3Error(res)
+	}
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *s3Storage) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	var token string
+	for {
+		query := map[string]string{"list-type": "2", "prefix": prefix}
+		if token != "" {
+			query["continuation-token"] = token
+		}
+		res, err := s.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusOK {
+			err := s3Error(res)
+			res.Body.Close()
+			return nil, err
+		}
+
+		var page listBucketResult
+		err = xml.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range page.Contents {
+			keys = append(keys, c.Key)
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+func (s *s3Storage) do(ctx context.Context, method, key string, query map[string]string, body []byte) (*http.Response, error) {
+	scheme := "http"
+	if s.cfg.UseSSL {
+		scheme = "https"
+	}
+	uri := "/" + s3Escape(s.cfg.Bucket, false)
+	if key != "" {
+		uri += "/" + s3Escape(key, false)
+	}
+	rawQuery := s3Query(query)
+
+	target := fmt.Sprintf("%s://%s%s", scheme, s.cfg.Endpoint, uri)
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, uri, rawQuery, body, time.Now().UTC())
+
+	return s.client.Do(req)
+}
+
+// sign signs the request using AWS Signature Version 4.
+func (s *s3Storage) sign(req *http.Request, uri, rawQuery string, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format(s3TimeFmt)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	req.Header.Set("x-amz-date", amzDate)
+
+	canonical := strings.Join([]string{
+		req.Method,
+		uri,
+		rawQuery,
+		fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", req.URL.Host, payloadHash, amzDate),
+		signed,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format(s3DateFmt), s.cfg.Region, s3Service, s3Request}, "/")
+	toSign := strings.Join([]string{s3Algorithm, amzDate, scope, sha256Hex([]byte(canonical))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), now.Format(s3DateFmt))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, s3Request)
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", s3Algorithm, s.cfg.AccessKey, scope, signed, signature))
+}
+
+func s3Error(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return errors.Wrap(errS3Request, fmt.Errorf("status %d: %s", res.StatusCode, body))
+}
+
+// s3Query returns the canonical query string of the query parameters, sorted
+// by name.
+func s3Query(query map[string]string) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]string, len(names))
+	for i, name := range names {
+		params[i] = s3Escape(name, true) + "=" + s3Escape(query[name], true)
+	}
+	return strings.Join(params, "&")
+}
+
+// s3Escape URI-encodes every byte of the value except unreserved characters,
+// and path separators unless escapeSlash is set.
+func s3Escape(value string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
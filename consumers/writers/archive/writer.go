@@ -0,0 +1,267 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/hantdev/mitras"
+	"github.com/hantdev/mitras/pkg/errors"
+	smqjson "github.com/hantdev/mitras/pkg/transformers/json"
+	"github.com/hantdev/mitras/pkg/transformers/senml"
+)
+
+var invalidSegment = regexp.MustCompile(`[^a-zA-Z0-9_.=-]`)
+
+var _ Archive = (*archiveRepo)(nil)
+
+type archiveRepo struct {
+	mu         sync.Mutex
+	storage    Storage
+	idp        mitras.IDProvider
+	cfg        Config
+	partitions map[string]*partition
+}
+
+// partition buffers the messages of a channel, message format and hour.
+type partition struct {
+	dir     string
+	format  string
+	channel string
+	senml   []senml.Message
+	json    []smqjson.Message
+	size    int64
+	from    int64
+	to      int64
+	created time.Time
+}
+
+// New returns new archive writer.
+func New(storage Storage, idp mitras.IDProvider, cfg Config) (Archive, error) {
+	if cfg.Encoding != NDJSON && cfg.Encoding != Parquet {
+		return nil, ErrInvalidEncoding
+	}
+
+	return &archiveRepo{
+		storage:    storage,
+		idp:        idp,
+		cfg:        cfg,
+		partitions: make(map[string]*partition),
+	}, nil
+}
+
+func (ar *archiveRepo) ConsumeBlocking(ctx context.Context, messages interface{}) error {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	full := make(map[string]*partition)
+	switch m := messages.(type) {
+	case smqjson.Messages:
+		for _, msg := range m.Data {
+			size, err := jsonSize(msg)
+			if err != nil {
+				return errors.Wrap(errArchive, err)
+			}
+			p := ar.partition(m.Format, msg.Channel, msg.Created)
+			p.json = append(p.json, msg)
+			if p.add(msg.Created, size, ar.cfg.MaxSize) {
+				full[p.dir] = p
+			}
+		}
+	case []senml.Message:
+		for _, msg := range m {
+			size, err := jsonSize(msg)
+			if err != nil {
+				return errors.Wrap(errArchive, err)
+			}
+			t := int64(msg.Time)
+			p := ar.partition(SenMLFormat, msg.Channel, t)
+			p.senml = append(p.senml, msg)
+			if p.add(t, size, ar.cfg.MaxSize) {
+				full[p.dir] = p
+			}
+		}
+	default:
+		return errors.Wrap(errArchive, errInvalid)
+	}
+
+	for _, p := range full {
+		if err := ar.write(ctx, p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ar *archiveRepo) Flush(ctx context.Context, force bool) error {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	var err error
+	for _, p := range ar.partitions {
+		if !force && time.Since(p.created) < ar.cfg.MaxAge {
+			continue
+		}
+		// Partitions failed to be written remain buffered and are retried
+		// on the next flush.
+		if e := ar.write(ctx, p); e != nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// partition returns the buffered partition of the message, creating one if
+// needed. Message time is in nanoseconds.
+func (ar *archiveRepo) partition(format, channel string, t int64) *partition {
+	ts := time.Now().UTC()
+	if t > 0 {
+		ts = time.Unix(0, t).UTC()
+	}
+	dir := path.Join(ar.cfg.Prefix, segment(format), "channel="+segment(channel), "date="+ts.Format(time.DateOnly), fmt.Sprintf("hour=%02d", ts.Hour()))
+
+	p, ok := ar.partitions[dir]
+	if !ok {
+		p = &partition{
+			dir:     dir,
+			format:  format,
+			channel: channel,
+			created: time.Now(),
+		}
+		ar.partitions[dir] = p
+	}
+
+	return p
+}
+
+// add records the time and size of the appended message. It reports whether
+// the partition reached the maximum size.
+func (p *partition) add(t, size, maxSize int64) bool {
+	if p.from == 0 || t < p.from {
+		p.from = t
+	}
+	if t > p.to {
+		p.to = t
+	}
+	p.size += size
+
+	return maxSize > 0 && p.size >= maxSize
+}
+
+func (p *partition) count() int {
+	return len(p.senml) + len(p.json)
+}
+
+// write encodes and stores the partition file and its manifest object, and
+// removes the partition from the buffer.
+func (ar *archiveRepo) write(ctx context.Context, p *partition) error {
+	if p.count() == 0 {
+		delete(ar.partitions, p.dir)
+		return nil
+	}
+
+	var data []byte
+	var ext string
+	var err error
+	switch ar.cfg.Encoding {
+	case Parquet:
+		data, err = p.parquet()
+		ext = ".parquet"
+	default:
+		data, err = p.ndjson()
+		ext = ".ndjson.gz"
+	}
+	if err != nil {
+		return errors.Wrap(errArchive, err)
+	}
+
+	id, err := ar.idp.ID()
+	if err != nil {
+		return errors.Wrap(errArchive, err)
+	}
+	key := path.Join(p.dir, id+ext)
+	if err := ar.storage.Put(ctx, key, data); err != nil {
+		return errors.Wrap(errArchive, err)
+	}
+
+	info, err := json.Marshal(PartitionInfo{
+		Key:      key,
+		Format:   p.format,
+		Encoding: ar.cfg.Encoding,
+		Channel:  p.channel,
+		From:     p.from,
+		To:       p.to,
+		Count:    p.count(),
+		Size:     len(data),
+		Created:  time.Now().UTC(),
+	})
+	if err != nil {
+		return errors.Wrap(errArchive, err)
+	}
+	if err := ar.storage.Put(ctx, manifestKey(ar.cfg.Prefix, id), info); err != nil {
+		return errors.Wrap(errArchive, err)
+	}
+	delete(ar.partitions, p.dir)
+
+	return nil
+}
+
+func (p *partition) ndjson() ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	w := bufio.NewWriter(zw)
+	enc := json.NewEncoder(w)
+	for _, msg := range p.senml {
+		if err := enc.Encode(msg); err != nil {
+			return nil, err
+		}
+	}
+	for _, msg := range p.json {
+		if err := enc.Encode(msg); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (p *partition) parquet() ([]byte, error) {
+	if p.format == SenMLFormat {
+		return encodeSenMLParquet(p.senml)
+	}
+
+	return encodeJSONParquet(p.json)
+}
+
+func jsonSize(msg interface{}) (int64, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(data)) + 1, nil
+}
+
+// segment sanitizes the key segment derived from the message.
+func segment(s string) string {
+	s = invalidSegment.ReplaceAllString(s, "_")
+	if s == "" || s == "." || s == ".." {
+		return "_"
+	}
+	return s
+}
@@ -0,0 +1,227 @@
+package archive_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/consumers/writers/archive"
+	"github.com/hantdev/mitras/internal/testsutil"
+	"github.com/hantdev/mitras/pkg/transformers/json"
+	"github.com/hantdev/mitras/pkg/transformers/senml"
+	"github.com/hantdev/mitras/pkg/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	msgsNum = 42
+	format  = "some_json"
+)
+
+var v float64 = 5
+
+type consumer struct {
+	msgs []interface{}
+}
+
+func (c *consumer) ConsumeBlocking(_ context.Context, msgs interface{}) error {
+	c.msgs = append(c.msgs, msgs)
+	return nil
+}
+
+func senmlMessages(t *testing.T, start time.Time) []senml.Message {
+	chanID := testsutil.GenerateUUID(t)
+	pubID := testsutil.GenerateUUID(t)
+
+	var msgs []senml.Message
+	for i := 0; i < msgsNum; i++ {
+		msgs = append(msgs, senml.Message{
+			Channel:   chanID,
+			Publisher: pubID,
+			Name:      "temperature",
+			Time:      float64(start.Add(time.Duration(i) * time.Second).UnixNano()),
+			Value:     &v,
+		})
+	}
+
+	return msgs
+}
+
+func TestConsumeSenml(t *testing.T) {
+	now := time.Date(2025, 1, 1, 10, 59, 40, 0, time.UTC)
+	msgs := senmlMessages(t, now)
+
+	cases := []struct {
+		desc       string
+		cfg        archive.Config
+		partitions int
+	}{
+		{
+			desc:       "archive messages buffered until flush",
+			cfg:        archive.Config{Encoding: archive.NDJSON, MaxAge: time.Hour},
+			partitions: 0,
+		},
+		{
+			desc:       "archive messages exceeding maximum size",
+			cfg:        archive.Config{Encoding: archive.NDJSON, Prefix: "archive", MaxSize: 1, MaxAge: time.Hour},
+			partitions: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		storage := archive.NewFSStorage(t.TempDir())
+		repo, err := archive.New(storage, uuid.NewMock(), tc.cfg)
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		err = repo.ConsumeBlocking(context.Background(), msgs)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", tc.desc, err))
+
+		manifest, err := archive.ReadManifest(context.Background(), storage, tc.cfg.Prefix)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", tc.desc, err))
+		assert.Len(t, manifest.Partitions, tc.partitions, fmt.Sprintf("%s: got unexpected number of partitions", tc.desc))
+	}
+}
+
+func TestFlush(t *testing.T) {
+	now := time.Date(2025, 1, 1, 10, 59, 40, 0, time.UTC)
+	msgs := senmlMessages(t, now)
+
+	for _, encoding := range []string{archive.NDJSON, archive.Parquet} {
+		storage := archive.NewFSStorage(t.TempDir())
+		repo, err := archive.New(storage, uuid.NewMock(), archive.Config{Encoding: encoding, MaxAge: time.Hour})
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", encoding, err))
+
+		err = repo.ConsumeBlocking(context.Background(), msgs)
+		require.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", encoding, err))
+
+		err = repo.Flush(context.Background(), false)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", encoding, err))
+		manifest, err := archive.ReadManifest(context.Background(), storage, "")
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", encoding, err))
+		assert.Empty(t, manifest.Partitions, fmt.Sprintf("%s: expected partitions younger than maximum age to remain buffered", encoding))
+
+		err = repo.Flush(context.Background(), true)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", encoding, err))
+		manifest, err = archive.ReadManifest(context.Background(), storage, "")
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", encoding, err))
+		require.Len(t, manifest.Partitions, 2, fmt.Sprintf("%s: expected messages to be partitioned by hour", encoding))
+
+		var count int
+		for _, p := range manifest.Partitions {
+			assert.Equal(t, archive.SenMLFormat, p.Format, fmt.Sprintf("%s: got unexpected partition format", encoding))
+			assert.Equal(t, encoding, p.Encoding, fmt.Sprintf("%s: got unexpected partition encoding", encoding))
+			assert.Equal(t, msgs[0].Channel, p.Channel, fmt.Sprintf("%s: got unexpected partition channel", encoding))
+			count += p.Count
+		}
+		assert.Equal(t, msgsNum, count, fmt.Sprintf("%s: got unexpected number of archived messages", encoding))
+
+		c := &consumer{}
+		err = archive.Replay(context.Background(), storage, "", nil, c)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", encoding, err))
+
+		var replayed []senml.Message
+		for _, m := range c.msgs {
+			replayed = append(replayed, m.([]senml.Message)...)
+		}
+		assert.ElementsMatch(t, msgs, replayed, fmt.Sprintf("%s: got unexpected replayed messages", encoding))
+	}
+}
+
+func TestSharedPrefix(t *testing.T) {
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	msgs := senmlMessages(t, now)
+	storage := archive.NewFSStorage(t.TempDir())
+	cfg := archive.Config{Encoding: archive.NDJSON, Prefix: "archive", MaxAge: time.Hour}
+
+	// Writer instances sharing the prefix must not overwrite each other's
+	// manifest entries.
+	for i := 0; i < 2; i++ {
+		repo, err := archive.New(storage, uuid.New(), cfg)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		err = repo.ConsumeBlocking(context.Background(), msgs)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s", err))
+		err = repo.Flush(context.Background(), true)
+		require.Nil(t, err, fmt.Sprintf("expected no error got %s", err))
+	}
+
+	manifest, err := archive.ReadManifest(context.Background(), storage, cfg.Prefix)
+	assert.Nil(t, err, fmt.Sprintf("expected no error got %s", err))
+	require.Len(t, manifest.Partitions, 2, "expected partitions of both writers")
+	assert.NotEqual(t, manifest.Partitions[0].Key, manifest.Partitions[1].Key, "expected distinct partition files")
+
+	c := &consumer{}
+	err = archive.Replay(context.Background(), storage, cfg.Prefix, nil, c)
+	assert.Nil(t, err, fmt.Sprintf("expected no error got %s", err))
+	assert.Len(t, c.msgs, 2, "expected partitions of both writers to be replayed")
+}
+
+func TestReadLegacyManifest(t *testing.T) {
+	storage := archive.NewFSStorage(t.TempDir())
+	legacy := `{"partitions":[{"key":"archive/senml/old.ndjson.gz","format":"senml","encoding":"ndjson","count":1}]}`
+	err := storage.Put(context.Background(), "archive/manifest.json", []byte(legacy))
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	repo, err := archive.New(storage, uuid.NewMock(), archive.Config{Encoding: archive.NDJSON, Prefix: "archive", MaxAge: time.Hour})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	err = repo.ConsumeBlocking(context.Background(), senmlMessages(t, time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)))
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s", err))
+	err = repo.Flush(context.Background(), true)
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s", err))
+
+	manifest, err := archive.ReadManifest(context.Background(), storage, "archive")
+	assert.Nil(t, err, fmt.Sprintf("expected no error got %s", err))
+	require.Len(t, manifest.Partitions, 2, "expected legacy and new partitions")
+	assert.Equal(t, "archive/senml/old.ndjson.gz", manifest.Partitions[0].Key, "expected legacy partition first")
+}
+
+func TestConsumeJSON(t *testing.T) {
+	chanID := testsutil.GenerateUUID(t)
+	msgs := json.Messages{Format: format}
+	for i := 0; i < msgsNum; i++ {
+		msgs.Data = append(msgs.Data, json.Message{
+			Channel:   chanID,
+			Publisher: chanID,
+			Created:   time.Now().UnixNano(),
+			Protocol:  "http",
+			Payload:   json.Payload{"temperature": 23.5, "on": true},
+		})
+	}
+
+	for _, encoding := range []string{archive.NDJSON, archive.Parquet} {
+		storage := archive.NewFSStorage(t.TempDir())
+		repo, err := archive.New(storage, uuid.NewMock(), archive.Config{Encoding: encoding, MaxAge: time.Hour})
+		require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", encoding, err))
+
+		err = repo.ConsumeBlocking(context.Background(), msgs)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", encoding, err))
+		err = repo.Flush(context.Background(), true)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", encoding, err))
+
+		manifest, err := archive.ReadManifest(context.Background(), storage, "")
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", encoding, err))
+		require.NotEmpty(t, manifest.Partitions, fmt.Sprintf("%s: expected archived partitions", encoding))
+
+		p := manifest.Partitions[0]
+		assert.Equal(t, format, p.Format, fmt.Sprintf("%s: got unexpected partition format", encoding))
+
+		c := &consumer{}
+		err = archive.Replay(context.Background(), storage, "", nil, c)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", encoding, err))
+
+		var replayed []json.Message
+		for _, m := range c.msgs {
+			jm := m.(json.Messages)
+			assert.Equal(t, format, jm.Format, fmt.Sprintf("%s: got unexpected replayed message format", encoding))
+			replayed = append(replayed, jm.Data...)
+		}
+		assert.ElementsMatch(t, msgs.Data, replayed, fmt.Sprintf("%s: got unexpected replayed messages", encoding))
+	}
+}
+
+func TestNewInvalidEncoding(t *testing.T) {
+	_, err := archive.New(archive.NewFSStorage(t.TempDir()), uuid.NewMock(), archive.Config{Encoding: "csv"})
+	assert.Equal(t, archive.ErrInvalidEncoding, err, fmt.Sprintf("expected %s got %s", archive.ErrInvalidEncoding, err))
+}
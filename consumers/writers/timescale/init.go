@@ -31,6 +31,42 @@ func Migration() *migrate.MemoryMigrationSource {
 					"DROP TABLE messages",
 				},
 			},
+			{
+				Id: "messages_2",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS retention_policies (
+                        id                  VARCHAR(36) PRIMARY KEY,
+                        domain_id           VARCHAR(36) NOT NULL,
+                        channel_id          VARCHAR(36) NOT NULL DEFAULT '',
+                        raw_days            BIGINT NOT NULL,
+                        downsample_interval TEXT NOT NULL DEFAULT '',
+                        downsample_days     BIGINT NOT NULL DEFAULT 0,
+                        created_by          VARCHAR(254),
+                        created_at          TIMESTAMP NOT NULL,
+                        updated_at          TIMESTAMP,
+                        UNIQUE (domain_id, channel_id)
+                    )`,
+					`CREATE TABLE IF NOT EXISTS messages_rollup (
+                        time          BIGINT NOT NULL,
+                        channel       UUID,
+                        subtopic      VARCHAR(254),
+                        publisher     UUID,
+                        protocol      TEXT,
+                        name          VARCHAR(254),
+                        unit          TEXT,
+                        min_value     FLOAT,
+                        max_value     FLOAT,
+                        sum_value     FLOAT,
+                        count_value   BIGINT,
+                        PRIMARY KEY (time, channel, publisher, subtopic, name)
+                    );
+                    SELECT create_hypertable('messages_rollup', 'time', create_default_indexes => FALSE, chunk_time_interval => 2592000000000000, if_not_exists => TRUE);`,
+				},
+				Down: []string{
+					"DROP TABLE messages_rollup",
+					"DROP TABLE retention_policies",
+				},
+			},
 		},
 	}
 }
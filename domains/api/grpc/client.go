@@ -17,6 +17,9 @@ var _ grpcDomainsV1.DomainsServiceClient = (*domainsGrpcClient)(nil)
 
 type domainsGrpcClient struct {
 	deleteUserFromDomains endpoint.Endpoint
+	requiresMFA           endpoint.Endpoint
+	addUserRole           endpoint.Endpoint
+	removeUserRole        endpoint.Endpoint
 	timeout               time.Duration
 }
 
@@ -31,6 +34,30 @@ func NewDomainsClient(conn *grpc.ClientConn, timeout time.Duration) grpcDomainsV
 			decodeDeleteUserResponse,
 			grpcDomainsV1.DeleteUserRes{},
 		).Endpoint(),
+		requiresMFA: kitgrpc.NewClient(
+			conn,
+			domainsSvcName,
+			"RequiresMFA",
+			encodeRequiresMFARequest,
+			decodeRequiresMFAResponse,
+			grpcDomainsV1.RequiresMFARes{},
+		).Endpoint(),
+		addUserRole: kitgrpc.NewClient(
+			conn,
+			domainsSvcName,
+			"AddUserRole",
+			encodeAddUserRoleRequest,
+			decodeAddUserRoleResponse,
+			grpcDomainsV1.AddUserRoleRes{},
+		).Endpoint(),
+		removeUserRole: kitgrpc.NewClient(
+			conn,
+			domainsSvcName,
+			"RemoveUserRole",
+			encodeRemoveUserRoleRequest,
+			decodeRemoveUserRoleResponse,
+			grpcDomainsV1.RemoveUserRoleRes{},
+		).Endpoint(),
 
 		timeout: timeout,
 	}
@@ -62,3 +89,92 @@ func encodeDeleteUserRequest(_ context.Context, grpcReq interface{}) (interface{
 		Id: req.ID,
 	}, nil
 }
+
+func (client domainsGrpcClient) RequiresMFA(ctx context.Context, in *grpcDomainsV1.RequiresMFAReq, opts ...grpc.CallOption) (*grpcDomainsV1.RequiresMFARes, error) {
+	ctx, cancel := context.WithTimeout(ctx, client.timeout)
+	defer cancel()
+
+	res, err := client.requiresMFA(ctx, requiresMFAReq{
+		userID: in.GetUserId(),
+	})
+	if err != nil {
+		return &grpcDomainsV1.RequiresMFARes{}, grpcapi.DecodeError(err)
+	}
+
+	rmr := res.(requiresMFARes)
+	return &grpcDomainsV1.RequiresMFARes{Required: rmr.required}, nil
+}
+
+func decodeRequiresMFAResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(*grpcDomainsV1.RequiresMFARes)
+	return requiresMFARes{required: res.GetRequired()}, nil
+}
+
+func encodeRequiresMFARequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(requiresMFAReq)
+	return &grpcDomainsV1.RequiresMFAReq{
+		UserId: req.userID,
+	}, nil
+}
+
+func (client domainsGrpcClient) AddUserRole(ctx context.Context, in *grpcDomainsV1.AddUserRoleReq, opts ...grpc.CallOption) (*grpcDomainsV1.AddUserRoleRes, error) {
+	ctx, cancel := context.WithTimeout(ctx, client.timeout)
+	defer cancel()
+
+	res, err := client.addUserRole(ctx, userRoleReq{
+		domainID: in.GetDomainId(),
+		roleName: in.GetRoleName(),
+		userID:   in.GetUserId(),
+	})
+	if err != nil {
+		return &grpcDomainsV1.AddUserRoleRes{}, grpcapi.DecodeError(err)
+	}
+
+	aur := res.(addUserRoleRes)
+	return &grpcDomainsV1.AddUserRoleRes{Added: aur.added}, nil
+}
+
+func decodeAddUserRoleResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(*grpcDomainsV1.AddUserRoleRes)
+	return addUserRoleRes{added: res.GetAdded()}, nil
+}
+
+func encodeAddUserRoleRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(userRoleReq)
+	return &grpcDomainsV1.AddUserRoleReq{
+		DomainId: req.domainID,
+		RoleName: req.roleName,
+		UserId:   req.userID,
+	}, nil
+}
+
+func (client domainsGrpcClient) RemoveUserRole(ctx context.Context, in *grpcDomainsV1.RemoveUserRoleReq, opts ...grpc.CallOption) (*grpcDomainsV1.RemoveUserRoleRes, error) {
+	ctx, cancel := context.WithTimeout(ctx, client.timeout)
+	defer cancel()
+
+	res, err := client.removeUserRole(ctx, userRoleReq{
+		domainID: in.GetDomainId(),
+		roleName: in.GetRoleName(),
+		userID:   in.GetUserId(),
+	})
+	if err != nil {
+		return &grpcDomainsV1.RemoveUserRoleRes{}, grpcapi.DecodeError(err)
+	}
+
+	rur := res.(removeUserRoleRes)
+	return &grpcDomainsV1.RemoveUserRoleRes{Removed: rur.removed}, nil
+}
+
+func decodeRemoveUserRoleResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(*grpcDomainsV1.RemoveUserRoleRes)
+	return removeUserRoleRes{removed: res.GetRemoved()}, nil
+}
+
+func encodeRemoveUserRoleRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(userRoleReq)
+	return &grpcDomainsV1.RemoveUserRoleReq{
+		DomainId: req.domainID,
+		RoleName: req.roleName,
+		UserId:   req.userID,
+	}, nil
+}
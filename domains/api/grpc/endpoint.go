@@ -21,3 +21,49 @@ func deleteUserFromDomainsEndpoint(svc domains.Service) endpoint.Endpoint {
 		return deleteUserRes{deleted: true}, nil
 	}
 }
+
+func requiresMFAEndpoint(svc domains.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(requiresMFAReq)
+		if err := req.validate(); err != nil {
+			return requiresMFARes{}, err
+		}
+
+		required, err := svc.RequiresMFA(ctx, req.userID)
+		if err != nil {
+			return requiresMFARes{}, err
+		}
+
+		return requiresMFARes{required: required}, nil
+	}
+}
+
+func addUserRoleEndpoint(svc domains.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(userRoleReq)
+		if err := req.validate(); err != nil {
+			return addUserRoleRes{}, err
+		}
+
+		if err := svc.AddUserRole(ctx, req.domainID, req.roleName, req.userID); err != nil {
+			return addUserRoleRes{}, err
+		}
+
+		return addUserRoleRes{added: true}, nil
+	}
+}
+
+func removeUserRoleEndpoint(svc domains.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(userRoleReq)
+		if err := req.validate(); err != nil {
+			return removeUserRoleRes{}, err
+		}
+
+		if err := svc.RemoveUserRole(ctx, req.domainID, req.roleName, req.userID); err != nil {
+			return removeUserRoleRes{}, err
+		}
+
+		return removeUserRoleRes{removed: true}, nil
+	}
+}
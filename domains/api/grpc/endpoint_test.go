@@ -12,6 +12,7 @@ import (
 	grpcDomainsV1 "github.com/hantdev/mitras/internal/grpc/domains/v1"
 	"github.com/hantdev/mitras/pkg/apiutil"
 	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"google.golang.org/grpc"
@@ -99,3 +100,140 @@ func TestDeleteUserFromDomains(t *testing.T) {
 		repoCall.Unset()
 	}
 }
+
+func TestRequiresMFA(t *testing.T) {
+	conn, err := grpc.NewClient(authAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err, fmt.Sprintf("Unexpected error creating client connection %s", err))
+	grpcClient := grpcapi.NewDomainsClient(conn, time.Second)
+
+	cases := []struct {
+		desc     string
+		req      *grpcDomainsV1.RequiresMFAReq
+		required bool
+		svcErr   error
+		err      error
+	}{
+		{
+			desc:     "check MFA requirement with valid req",
+			req:      &grpcDomainsV1.RequiresMFAReq{UserId: id},
+			required: true,
+			err:      nil,
+		},
+		{
+			desc: "check MFA requirement with missing user id",
+			req:  &grpcDomainsV1.RequiresMFAReq{},
+			err:  apiutil.ErrMissingID,
+		},
+		{
+			desc:   "check MFA requirement with service error",
+			req:    &grpcDomainsV1.RequiresMFAReq{UserId: id},
+			svcErr: svcerr.ErrViewEntity,
+			err:    svcerr.ErrViewEntity,
+		},
+	}
+	for _, tc := range cases {
+		svcCall := svc.On("RequiresMFA", mock.Anything, tc.req.GetUserId()).Return(tc.required, tc.svcErr)
+		res, err := grpcClient.RequiresMFA(context.Background(), tc.req)
+		assert.Equal(t, tc.required, res.GetRequired(), fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.required, res.GetRequired()))
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		svcCall.Unset()
+	}
+}
+
+func TestAddUserRole(t *testing.T) {
+	conn, err := grpc.NewClient(authAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err, fmt.Sprintf("Unexpected error creating client connection %s", err))
+	grpcClient := grpcapi.NewDomainsClient(conn, time.Second)
+
+	cases := []struct {
+		desc   string
+		req    *grpcDomainsV1.AddUserRoleReq
+		added  bool
+		svcErr error
+		err    error
+	}{
+		{
+			desc:  "add user role with valid req",
+			req:   &grpcDomainsV1.AddUserRoleReq{DomainId: id, RoleName: adminpermission, UserId: id},
+			added: true,
+			err:   nil,
+		},
+		{
+			desc: "add user role with missing domain id",
+			req:  &grpcDomainsV1.AddUserRoleReq{RoleName: adminpermission, UserId: id},
+			err:  apiutil.ErrMissingID,
+		},
+		{
+			desc: "add user role with missing user id",
+			req:  &grpcDomainsV1.AddUserRoleReq{DomainId: id, RoleName: adminpermission},
+			err:  apiutil.ErrMissingID,
+		},
+		{
+			desc: "add user role with missing role name",
+			req:  &grpcDomainsV1.AddUserRoleReq{DomainId: id, UserId: id},
+			err:  apiutil.ErrMissingRoleName,
+		},
+		{
+			desc:   "add user role with service error",
+			req:    &grpcDomainsV1.AddUserRoleReq{DomainId: id, RoleName: adminpermission, UserId: id},
+			svcErr: svcerr.ErrUpdateEntity,
+			err:    svcerr.ErrUpdateEntity,
+		},
+	}
+	for _, tc := range cases {
+		svcCall := svc.On("AddUserRole", mock.Anything, tc.req.GetDomainId(), tc.req.GetRoleName(), tc.req.GetUserId()).Return(tc.svcErr)
+		res, err := grpcClient.AddUserRole(context.Background(), tc.req)
+		assert.Equal(t, tc.added, res.GetAdded(), fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.added, res.GetAdded()))
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		svcCall.Unset()
+	}
+}
+
+func TestRemoveUserRole(t *testing.T) {
+	conn, err := grpc.NewClient(authAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err, fmt.Sprintf("Unexpected error creating client connection %s", err))
+	grpcClient := grpcapi.NewDomainsClient(conn, time.Second)
+
+	cases := []struct {
+		desc    string
+		req     *grpcDomainsV1.RemoveUserRoleReq
+		removed bool
+		svcErr  error
+		err     error
+	}{
+		{
+			desc:    "remove user role with valid req",
+			req:     &grpcDomainsV1.RemoveUserRoleReq{DomainId: id, RoleName: adminpermission, UserId: id},
+			removed: true,
+			err:     nil,
+		},
+		{
+			desc: "remove user role with missing domain id",
+			req:  &grpcDomainsV1.RemoveUserRoleReq{RoleName: adminpermission, UserId: id},
+			err:  apiutil.ErrMissingID,
+		},
+		{
+			desc: "remove user role with missing user id",
+			req:  &grpcDomainsV1.RemoveUserRoleReq{DomainId: id, RoleName: adminpermission},
+			err:  apiutil.ErrMissingID,
+		},
+		{
+			desc: "remove user role with missing role name",
+			req:  &grpcDomainsV1.RemoveUserRoleReq{DomainId: id, UserId: id},
+			err:  apiutil.ErrMissingRoleName,
+		},
+		{
+			desc:   "remove user role with service error",
+			req:    &grpcDomainsV1.RemoveUserRoleReq{DomainId: id, RoleName: adminpermission, UserId: id},
+			svcErr: svcerr.ErrRemoveEntity,
+			err:    svcerr.ErrRemoveEntity,
+		},
+	}
+	for _, tc := range cases {
+		svcCall := svc.On("RemoveUserRole", mock.Anything, tc.req.GetDomainId(), tc.req.GetRoleName(), tc.req.GetUserId()).Return(tc.svcErr)
+		res, err := grpcClient.RemoveUserRole(context.Background(), tc.req)
+		assert.Equal(t, tc.removed, res.GetRemoved(), fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.removed, res.GetRemoved()))
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		svcCall.Unset()
+	}
+}
@@ -15,3 +15,32 @@ func (req deleteUserPoliciesReq) validate() error {
 
 	return nil
 }
+
+type requiresMFAReq struct {
+	userID string
+}
+
+func (req requiresMFAReq) validate() error {
+	if req.userID == "" {
+		return apiutil.ErrMissingID
+	}
+
+	return nil
+}
+
+type userRoleReq struct {
+	domainID string
+	roleName string
+	userID   string
+}
+
+func (req userRoleReq) validate() error {
+	if req.domainID == "" || req.userID == "" {
+		return apiutil.ErrMissingID
+	}
+	if req.roleName == "" {
+		return apiutil.ErrMissingRoleName
+	}
+
+	return nil
+}
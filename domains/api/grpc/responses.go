@@ -3,3 +3,15 @@ package grpc
 type deleteUserRes struct {
 	deleted bool
 }
+
+type requiresMFARes struct {
+	required bool
+}
+
+type addUserRoleRes struct {
+	added bool
+}
+
+type removeUserRoleRes struct {
+	removed bool
+}
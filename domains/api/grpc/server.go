@@ -14,6 +14,9 @@ var _ grpcDomainsV1.DomainsServiceServer = (*domainsGrpcServer)(nil)
 type domainsGrpcServer struct {
 	grpcDomainsV1.UnimplementedDomainsServiceServer
 	deleteUserFromDomains kitgrpc.Handler
+	requiresMFA           kitgrpc.Handler
+	addUserRole           kitgrpc.Handler
+	removeUserRole        kitgrpc.Handler
 }
 
 func NewDomainsServer(svc domains.Service) grpcDomainsV1.DomainsServiceServer {
@@ -23,6 +26,21 @@ func NewDomainsServer(svc domains.Service) grpcDomainsV1.DomainsServiceServer {
 			decodeDeleteUserRequest,
 			encodeDeleteUserResponse,
 		),
+		requiresMFA: kitgrpc.NewServer(
+			requiresMFAEndpoint(svc),
+			decodeRequiresMFARequest,
+			encodeRequiresMFAResponse,
+		),
+		addUserRole: kitgrpc.NewServer(
+			addUserRoleEndpoint(svc),
+			decodeAddUserRoleRequest,
+			encodeAddUserRoleResponse,
+		),
+		removeUserRole: kitgrpc.NewServer(
+			removeUserRoleEndpoint(svc),
+			decodeRemoveUserRoleRequest,
+			encodeRemoveUserRoleResponse,
+		),
 	}
 }
 
@@ -45,3 +63,67 @@ func (s *domainsGrpcServer) DeleteUserFromDomains(ctx context.Context, req *grpc
 	}
 	return res.(*grpcDomainsV1.DeleteUserRes), nil
 }
+
+func decodeRequiresMFARequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcDomainsV1.RequiresMFAReq)
+	return requiresMFAReq{
+		userID: req.GetUserId(),
+	}, nil
+}
+
+func encodeRequiresMFAResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(requiresMFARes)
+	return &grpcDomainsV1.RequiresMFARes{Required: res.required}, nil
+}
+
+func (s *domainsGrpcServer) RequiresMFA(ctx context.Context, req *grpcDomainsV1.RequiresMFAReq) (*grpcDomainsV1.RequiresMFARes, error) {
+	_, res, err := s.requiresMFA.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcapi.EncodeError(err)
+	}
+	return res.(*grpcDomainsV1.RequiresMFARes), nil
+}
+
+func decodeAddUserRoleRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcDomainsV1.AddUserRoleReq)
+	return userRoleReq{
+		domainID: req.GetDomainId(),
+		roleName: req.GetRoleName(),
+		userID:   req.GetUserId(),
+	}, nil
+}
+
+func encodeAddUserRoleResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(addUserRoleRes)
+	return &grpcDomainsV1.AddUserRoleRes{Added: res.added}, nil
+}
+
+func (s *domainsGrpcServer) AddUserRole(ctx context.Context, req *grpcDomainsV1.AddUserRoleReq) (*grpcDomainsV1.AddUserRoleRes, error) {
+	_, res, err := s.addUserRole.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcapi.EncodeError(err)
+	}
+	return res.(*grpcDomainsV1.AddUserRoleRes), nil
+}
+
+func decodeRemoveUserRoleRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcDomainsV1.RemoveUserRoleReq)
+	return userRoleReq{
+		domainID: req.GetDomainId(),
+		roleName: req.GetRoleName(),
+		userID:   req.GetUserId(),
+	}, nil
+}
+
+func encodeRemoveUserRoleResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(removeUserRoleRes)
+	return &grpcDomainsV1.RemoveUserRoleRes{Removed: res.removed}, nil
+}
+
+func (s *domainsGrpcServer) RemoveUserRole(ctx context.Context, req *grpcDomainsV1.RemoveUserRoleReq) (*grpcDomainsV1.RemoveUserRoleRes, error) {
+	_, res, err := s.removeUserRole.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, grpcapi.EncodeError(err)
+	}
+	return res.(*grpcDomainsV1.RemoveUserRoleRes), nil
+}
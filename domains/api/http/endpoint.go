@@ -25,10 +25,11 @@ func createDomainEndpoint(svc domains.Service) endpoint.Endpoint {
 		}
 
 		d := domains.Domain{
-			Name:     req.Name,
-			Metadata: req.Metadata,
-			Tags:     req.Tags,
-			Alias:    req.Alias,
+			Name:       req.Name,
+			Metadata:   req.Metadata,
+			Tags:       req.Tags,
+			Alias:      req.Alias,
+			RequireMFA: req.RequireMFA,
 		}
 		domain, err := svc.CreateDomain(ctx, session, d)
 		if err != nil {
@@ -76,10 +77,11 @@ func updateDomainEndpoint(svc domains.Service) endpoint.Endpoint {
 			metadata = *req.Metadata
 		}
 		d := domains.DomainReq{
-			Name:     req.Name,
-			Metadata: &metadata,
-			Tags:     req.Tags,
-			Alias:    req.Alias,
+			Name:       req.Name,
+			Metadata:   &metadata,
+			Tags:       req.Tags,
+			Alias:      req.Alias,
+			RequireMFA: req.RequireMFA,
 		}
 		domain, err := svc.UpdateDomain(ctx, session, req.domainID, d)
 		if err != nil {
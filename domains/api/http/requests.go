@@ -20,10 +20,11 @@ type page struct {
 }
 
 type createDomainReq struct {
-	Name     string                 `json:"name"`
-	Metadata map[string]interface{} `json:"metadata,omitempty"`
-	Tags     []string               `json:"tags,omitempty"`
-	Alias    string                 `json:"alias"`
+	Name       string                 `json:"name"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Tags       []string               `json:"tags,omitempty"`
+	Alias      string                 `json:"alias"`
+	RequireMFA bool                   `json:"require_mfa,omitempty"`
 }
 
 func (req createDomainReq) validate() error {
@@ -51,11 +52,12 @@ func (req retrieveDomainRequest) validate() error {
 }
 
 type updateDomainReq struct {
-	domainID string
-	Name     *string                 `json:"name,omitempty"`
-	Metadata *map[string]interface{} `json:"metadata,omitempty"`
-	Tags     *[]string               `json:"tags,omitempty"`
-	Alias    *string                 `json:"alias,omitempty"`
+	domainID   string
+	Name       *string                 `json:"name,omitempty"`
+	Metadata   *map[string]interface{} `json:"metadata,omitempty"`
+	Tags       *[]string               `json:"tags,omitempty"`
+	Alias      *string                 `json:"alias,omitempty"`
+	RequireMFA *bool                   `json:"require_mfa,omitempty"`
 }
 
 func (req updateDomainReq) validate() error {
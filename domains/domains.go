@@ -94,26 +94,30 @@ func (s *Status) UnmarshalJSON(data []byte) error {
 type Metadata map[string]interface{}
 
 type DomainReq struct {
-	Name     *string   `json:"name,omitempty"`
-	Metadata *Metadata `json:"metadata,omitempty"`
-	Tags     *[]string `json:"tags,omitempty"`
-	Alias    *string   `json:"alias,omitempty"`
-	Status   *Status   `json:"status,omitempty"`
+	Name       *string   `json:"name,omitempty"`
+	Metadata   *Metadata `json:"metadata,omitempty"`
+	Tags       *[]string `json:"tags,omitempty"`
+	Alias      *string   `json:"alias,omitempty"`
+	Status     *Status   `json:"status,omitempty"`
+	RequireMFA *bool     `json:"require_mfa,omitempty"`
 }
 type Domain struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Metadata  Metadata  `json:"metadata,omitempty"`
-	Tags      []string  `json:"tags,omitempty"`
-	Alias     string    `json:"alias,omitempty"`
-	Status    Status    `json:"status"`
-	RoleID    string    `json:"role_id,omitempty"`
-	RoleName  string    `json:"role_name,omitempty"`
-	Actions   []string  `json:"actions,omitempty"`
-	CreatedBy string    `json:"created_by,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedBy string    `json:"updated_by,omitempty"`
-	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Metadata Metadata `json:"metadata,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Alias    string   `json:"alias,omitempty"`
+	Status   Status   `json:"status"`
+	// RequireMFA requires the members of the domain to log in using
+	// the multi-factor authentication.
+	RequireMFA bool      `json:"require_mfa"`
+	RoleID     string    `json:"role_id,omitempty"`
+	RoleName   string    `json:"role_name,omitempty"`
+	Actions    []string  `json:"actions,omitempty"`
+	CreatedBy  string    `json:"created_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedBy  string    `json:"updated_by,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at,omitempty"`
 }
 
 type Page struct {
@@ -167,6 +171,16 @@ type Service interface {
 	FreezeDomain(ctx context.Context, sesssion authn.Session, id string) (Domain, error)
 	ListDomains(ctx context.Context, sesssion authn.Session, page Page) (DomainsPage, error)
 	DeleteUserFromDomains(ctx context.Context, id string) error
+	// RequiresMFA checks whether any of the enabled domains of the user
+	// requires the multi-factor authentication.
+	RequiresMFA(ctx context.Context, userID string) (bool, error)
+	// AddUserRole adds the user to the domain role, unless the user is
+	// already its member. It's used by the services to grant the roles,
+	// e.g. mapped from the identity provider groups.
+	AddUserRole(ctx context.Context, domainID, roleName, userID string) error
+	// RemoveUserRole removes the user from the domain role, if the user is
+	// its member.
+	RemoveUserRole(ctx context.Context, domainID, roleName, userID string) error
 	roles.RoleManager
 }
 
@@ -194,5 +208,9 @@ type Repository interface {
 	// ListDomains list all the domains
 	ListDomains(ctx context.Context, pm Page) (DomainsPage, error)
 
+	// RequiresMFA checks whether any of the enabled domains the user is
+	// a member of requires the multi-factor authentication.
+	RequiresMFA(ctx context.Context, userID string) (bool, error)
+
 	roles.Repository
 }
@@ -17,6 +17,8 @@ const (
 	domainFreeze     = domainPrefix + "freeze"
 	domainList       = domainPrefix + "list"
 	domainUserDelete = domainPrefix + "user_delete"
+	domainAddRole    = domainPrefix + "add_user_role"
+	domainRemoveRole = domainPrefix + "remove_user_role"
 )
 
 var (
@@ -27,6 +29,8 @@ var (
 	_ events.Event = (*disableDomainEvent)(nil)
 	_ events.Event = (*freezeDomainEvent)(nil)
 	_ events.Event = (*listDomainsEvent)(nil)
+	_ events.Event = (*addUserRoleEvent)(nil)
+	_ events.Event = (*removeUserRoleEvent)(nil)
 )
 
 type createDomainEvent struct {
@@ -35,12 +39,13 @@ type createDomainEvent struct {
 
 func (cde createDomainEvent) Encode() (map[string]interface{}, error) {
 	val := map[string]interface{}{
-		"operation":  domainCreate,
-		"id":         cde.ID,
-		"alias":      cde.Alias,
-		"status":     cde.Status.String(),
-		"created_at": cde.CreatedAt,
-		"created_by": cde.CreatedBy,
+		"operation":   domainCreate,
+		"id":          cde.ID,
+		"alias":       cde.Alias,
+		"status":      cde.Status.String(),
+		"require_mfa": cde.RequireMFA,
+		"created_at":  cde.CreatedAt,
+		"created_by":  cde.CreatedBy,
 	}
 
 	if cde.Name != "" {
@@ -94,14 +99,15 @@ type updateDomainEvent struct {
 
 func (ude updateDomainEvent) Encode() (map[string]interface{}, error) {
 	val := map[string]interface{}{
-		"operation":  domainUpdate,
-		"id":         ude.ID,
-		"alias":      ude.Alias,
-		"status":     ude.Status.String(),
-		"created_at": ude.CreatedAt,
-		"created_by": ude.CreatedBy,
-		"updated_at": ude.UpdatedAt,
-		"updated_by": ude.UpdatedBy,
+		"operation":   domainUpdate,
+		"id":          ude.ID,
+		"alias":       ude.Alias,
+		"status":      ude.Status.String(),
+		"require_mfa": ude.RequireMFA,
+		"created_at":  ude.CreatedAt,
+		"created_by":  ude.CreatedBy,
+		"updated_at":  ude.UpdatedAt,
+		"updated_by":  ude.UpdatedBy,
 	}
 
 	if ude.Name != "" {
@@ -229,3 +235,33 @@ func (dude deleteUserFromDomainsEvent) Encode() (map[string]interface{}, error)
 	}
 	return val, nil
 }
+
+type addUserRoleEvent struct {
+	domainID string
+	roleName string
+	userID   string
+}
+
+func (aure addUserRoleEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": domainAddRole,
+		"id":        aure.domainID,
+		"role_name": aure.roleName,
+		"user_id":   aure.userID,
+	}, nil
+}
+
+type removeUserRoleEvent struct {
+	domainID string
+	roleName string
+	userID   string
+}
+
+func (rure removeUserRoleEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": domainRemoveRole,
+		"id":        rure.domainID,
+		"role_name": rure.roleName,
+		"user_id":   rure.userID,
+	}, nil
+}
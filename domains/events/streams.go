@@ -162,6 +162,26 @@ func (es *eventStore) ListDomains(ctx context.Context, session authn.Session, p
 	return dp, nil
 }
 
+func (es *eventStore) RequiresMFA(ctx context.Context, userID string) (bool, error) {
+	return es.svc.RequiresMFA(ctx, userID)
+}
+
+func (es *eventStore) AddUserRole(ctx context.Context, domainID, roleName, userID string) error {
+	if err := es.svc.AddUserRole(ctx, domainID, roleName, userID); err != nil {
+		return err
+	}
+
+	return es.Publish(ctx, addUserRoleEvent{domainID: domainID, roleName: roleName, userID: userID})
+}
+
+func (es *eventStore) RemoveUserRole(ctx context.Context, domainID, roleName, userID string) error {
+	if err := es.svc.RemoveUserRole(ctx, domainID, roleName, userID); err != nil {
+		return err
+	}
+
+	return es.Publish(ctx, removeUserRoleEvent{domainID: domainID, roleName: roleName, userID: userID})
+}
+
 func (es *eventStore) DeleteUserFromDomains(ctx context.Context, userID string) error {
 	if err := es.svc.DeleteUserFromDomains(ctx, userID); err != nil {
 		return err
@@ -130,6 +130,18 @@ func (am *authorizationMiddleware) ListDomains(ctx context.Context, session auth
 	return am.svc.ListDomains(ctx, session, page)
 }
 
+func (am *authorizationMiddleware) RequiresMFA(ctx context.Context, userID string) (bool, error) {
+	return am.svc.RequiresMFA(ctx, userID)
+}
+
+func (am *authorizationMiddleware) AddUserRole(ctx context.Context, domainID, roleName, userID string) error {
+	return am.svc.AddUserRole(ctx, domainID, roleName, userID)
+}
+
+func (am *authorizationMiddleware) RemoveUserRole(ctx context.Context, domainID, roleName, userID string) error {
+	return am.svc.RemoveUserRole(ctx, domainID, roleName, userID)
+}
+
 func (am *authorizationMiddleware) DeleteUserFromDomains(ctx context.Context, id string) (err error) {
 	return am.svc.DeleteUserFromDomains(ctx, id)
 }
@@ -161,6 +161,59 @@ func (lm *loggingMiddleware) ListDomains(ctx context.Context, session authn.Sess
 	return lm.svc.ListDomains(ctx, session, page)
 }
 
+func (lm *loggingMiddleware) RequiresMFA(ctx context.Context, userID string) (required bool, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("user_id", userID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Check domains MFA requirement failed", args...)
+			return
+		}
+		args = append(args, slog.Bool("required", required))
+		lm.logger.Info("Check domains MFA requirement completed successfully", args...)
+	}(time.Now())
+	return lm.svc.RequiresMFA(ctx, userID)
+}
+
+func (lm *loggingMiddleware) AddUserRole(ctx context.Context, domainID, roleName, userID string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("domain_id", domainID),
+			slog.String("role_name", roleName),
+			slog.String("user_id", userID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Add user role failed", args...)
+			return
+		}
+		lm.logger.Info("Add user role completed successfully", args...)
+	}(time.Now())
+	return lm.svc.AddUserRole(ctx, domainID, roleName, userID)
+}
+
+func (lm *loggingMiddleware) RemoveUserRole(ctx context.Context, domainID, roleName, userID string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("domain_id", domainID),
+			slog.String("role_name", roleName),
+			slog.String("user_id", userID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Remove user role failed", args...)
+			return
+		}
+		lm.logger.Info("Remove user role completed successfully", args...)
+	}(time.Now())
+	return lm.svc.RemoveUserRole(ctx, domainID, roleName, userID)
+}
+
 func (lm *loggingMiddleware) DeleteUserFromDomains(ctx context.Context, id string) (err error) {
 	defer func(begin time.Time) {
 		args := []any{
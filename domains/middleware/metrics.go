@@ -89,6 +89,30 @@ func (ms *metricsMiddleware) ListDomains(ctx context.Context, session authn.Sess
 	return ms.svc.ListDomains(ctx, session, page)
 }
 
+func (ms *metricsMiddleware) RequiresMFA(ctx context.Context, userID string) (bool, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "requires_mfa").Add(1)
+		ms.latency.With("method", "requires_mfa").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.RequiresMFA(ctx, userID)
+}
+
+func (ms *metricsMiddleware) AddUserRole(ctx context.Context, domainID, roleName, userID string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "add_user_role").Add(1)
+		ms.latency.With("method", "add_user_role").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.AddUserRole(ctx, domainID, roleName, userID)
+}
+
+func (ms *metricsMiddleware) RemoveUserRole(ctx context.Context, domainID, roleName, userID string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "remove_user_role").Add(1)
+		ms.latency.With("method", "remove_user_role").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.RemoveUserRole(ctx, domainID, roleName, userID)
+}
+
 func (ms *metricsMiddleware) DeleteUserFromDomains(ctx context.Context, id string) error {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "delete_user_from_domains").Add(1)
@@ -25,6 +25,80 @@ func (_m *DomainsServiceClient) EXPECT() *DomainsServiceClient_Expecter {
 	return &DomainsServiceClient_Expecter{mock: &_m.Mock}
 }
 
+// AddUserRole provides a mock function with given fields: ctx, in, opts
+func (_m *DomainsServiceClient) AddUserRole(ctx context.Context, in *v1.AddUserRoleReq, opts ...grpc.CallOption) (*v1.AddUserRoleRes, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddUserRole")
+	}
+
+	var r0 *v1.AddUserRoleRes
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.AddUserRoleReq, ...grpc.CallOption) (*v1.AddUserRoleRes, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.AddUserRoleReq, ...grpc.CallOption) *v1.AddUserRoleRes); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.AddUserRoleRes)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.AddUserRoleReq, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DomainsServiceClient_AddUserRole_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddUserRole'
+type DomainsServiceClient_AddUserRole_Call struct {
+	*mock.Call
+}
+
+// AddUserRole is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v1.AddUserRoleReq
+//   - opts ...grpc.CallOption
+func (_e *DomainsServiceClient_Expecter) AddUserRole(ctx interface{}, in interface{}, opts ...interface{}) *DomainsServiceClient_AddUserRole_Call {
+	return &DomainsServiceClient_AddUserRole_Call{Call: _e.mock.On("AddUserRole",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *DomainsServiceClient_AddUserRole_Call) Run(run func(ctx context.Context, in *v1.AddUserRoleReq, opts ...grpc.CallOption)) *DomainsServiceClient_AddUserRole_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]grpc.CallOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(grpc.CallOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*v1.AddUserRoleReq), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *DomainsServiceClient_AddUserRole_Call) Return(_a0 *v1.AddUserRoleRes, _a1 error) *DomainsServiceClient_AddUserRole_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *DomainsServiceClient_AddUserRole_Call) RunAndReturn(run func(context.Context, *v1.AddUserRoleReq, ...grpc.CallOption) (*v1.AddUserRoleRes, error)) *DomainsServiceClient_AddUserRole_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // DeleteUserFromDomains provides a mock function with given fields: ctx, in, opts
 func (_m *DomainsServiceClient) DeleteUserFromDomains(ctx context.Context, in *v1.DeleteUserReq, opts ...grpc.CallOption) (*v1.DeleteUserRes, error) {
 	_va := make([]interface{}, len(opts))
@@ -99,6 +173,154 @@ func (_c *DomainsServiceClient_DeleteUserFromDomains_Call) RunAndReturn(run func
 	return _c
 }
 
+// RemoveUserRole provides a mock function with given fields: ctx, in, opts
+func (_m *DomainsServiceClient) RemoveUserRole(ctx context.Context, in *v1.RemoveUserRoleReq, opts ...grpc.CallOption) (*v1.RemoveUserRoleRes, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveUserRole")
+	}
+
+	var r0 *v1.RemoveUserRoleRes
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RemoveUserRoleReq, ...grpc.CallOption) (*v1.RemoveUserRoleRes, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RemoveUserRoleReq, ...grpc.CallOption) *v1.RemoveUserRoleRes); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.RemoveUserRoleRes)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RemoveUserRoleReq, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DomainsServiceClient_RemoveUserRole_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveUserRole'
+type DomainsServiceClient_RemoveUserRole_Call struct {
+	*mock.Call
+}
+
+// RemoveUserRole is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v1.RemoveUserRoleReq
+//   - opts ...grpc.CallOption
+func (_e *DomainsServiceClient_Expecter) RemoveUserRole(ctx interface{}, in interface{}, opts ...interface{}) *DomainsServiceClient_RemoveUserRole_Call {
+	return &DomainsServiceClient_RemoveUserRole_Call{Call: _e.mock.On("RemoveUserRole",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *DomainsServiceClient_RemoveUserRole_Call) Run(run func(ctx context.Context, in *v1.RemoveUserRoleReq, opts ...grpc.CallOption)) *DomainsServiceClient_RemoveUserRole_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]grpc.CallOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(grpc.CallOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*v1.RemoveUserRoleReq), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *DomainsServiceClient_RemoveUserRole_Call) Return(_a0 *v1.RemoveUserRoleRes, _a1 error) *DomainsServiceClient_RemoveUserRole_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *DomainsServiceClient_RemoveUserRole_Call) RunAndReturn(run func(context.Context, *v1.RemoveUserRoleReq, ...grpc.CallOption) (*v1.RemoveUserRoleRes, error)) *DomainsServiceClient_RemoveUserRole_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RequiresMFA provides a mock function with given fields: ctx, in, opts
+func (_m *DomainsServiceClient) RequiresMFA(ctx context.Context, in *v1.RequiresMFAReq, opts ...grpc.CallOption) (*v1.RequiresMFARes, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequiresMFA")
+	}
+
+	var r0 *v1.RequiresMFARes
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RequiresMFAReq, ...grpc.CallOption) (*v1.RequiresMFARes, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.RequiresMFAReq, ...grpc.CallOption) *v1.RequiresMFARes); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.RequiresMFARes)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.RequiresMFAReq, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DomainsServiceClient_RequiresMFA_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RequiresMFA'
+type DomainsServiceClient_RequiresMFA_Call struct {
+	*mock.Call
+}
+
+// RequiresMFA is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v1.RequiresMFAReq
+//   - opts ...grpc.CallOption
+func (_e *DomainsServiceClient_Expecter) RequiresMFA(ctx interface{}, in interface{}, opts ...interface{}) *DomainsServiceClient_RequiresMFA_Call {
+	return &DomainsServiceClient_RequiresMFA_Call{Call: _e.mock.On("RequiresMFA",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *DomainsServiceClient_RequiresMFA_Call) Run(run func(ctx context.Context, in *v1.RequiresMFAReq, opts ...grpc.CallOption)) *DomainsServiceClient_RequiresMFA_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]grpc.CallOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(grpc.CallOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*v1.RequiresMFAReq), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *DomainsServiceClient_RequiresMFA_Call) Return(_a0 *v1.RequiresMFARes, _a1 error) *DomainsServiceClient_RequiresMFA_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *DomainsServiceClient_RequiresMFA_Call) RunAndReturn(run func(context.Context, *v1.RequiresMFAReq, ...grpc.CallOption) (*v1.RequiresMFARes, error)) *DomainsServiceClient_RequiresMFA_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewDomainsServiceClient creates a new instance of DomainsServiceClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewDomainsServiceClient(t interface {
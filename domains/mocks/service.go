@@ -47,6 +47,24 @@ func (_m *Service) AddRole(ctx context.Context, session authn.Session, entityID
 	return r0, r1
 }
 
+// AddUserRole provides a mock function with given fields: ctx, domainID, roleName, userID
+func (_m *Service) AddUserRole(ctx context.Context, domainID string, roleName string, userID string) error {
+	ret := _m.Called(ctx, domainID, roleName, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddUserRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, domainID, roleName, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateDomain provides a mock function with given fields: ctx, sesssion, d
 func (_m *Service) CreateDomain(ctx context.Context, sesssion authn.Session, d domains.Domain) (domains.Domain, error) {
 	ret := _m.Called(ctx, sesssion, d)
@@ -271,6 +289,52 @@ func (_m *Service) RemoveRole(ctx context.Context, session authn.Session, entity
 	return r0
 }
 
+// RemoveUserRole provides a mock function with given fields: ctx, domainID, roleName, userID
+func (_m *Service) RemoveUserRole(ctx context.Context, domainID string, roleName string, userID string) error {
+	ret := _m.Called(ctx, domainID, roleName, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveUserRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, domainID, roleName, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RequiresMFA provides a mock function with given fields: ctx, userID
+func (_m *Service) RequiresMFA(ctx context.Context, userID string) (bool, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequiresMFA")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RetrieveAllRoles provides a mock function with given fields: ctx, session, entityID, limit, offset
 func (_m *Service) RetrieveAllRoles(ctx context.Context, session authn.Session, entityID string, limit uint64, offset uint64) (roles.RolePage, error) {
 	ret := _m.Called(ctx, session, entityID, limit, offset)
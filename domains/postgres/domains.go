@@ -43,9 +43,9 @@ func New(db postgres.Database) domains.Repository {
 }
 
 func (repo domainRepo) Save(ctx context.Context, d domains.Domain) (dd domains.Domain, err error) {
-	q := `INSERT INTO domains (id, name, tags, alias, metadata, created_at, updated_at, updated_by, created_by, status)
-	VALUES (:id, :name, :tags, :alias, :metadata, :created_at, :updated_at, :updated_by, :created_by, :status)
-	RETURNING id, name, tags, alias, metadata, created_at, updated_at, updated_by, created_by, status;`
+	q := `INSERT INTO domains (id, name, tags, alias, metadata, created_at, updated_at, updated_by, created_by, status, require_mfa)
+	VALUES (:id, :name, :tags, :alias, :metadata, :created_at, :updated_at, :updated_by, :created_by, :status, :require_mfa)
+	RETURNING id, name, tags, alias, metadata, created_at, updated_at, updated_by, created_by, status, require_mfa;`
 
 	dbd, err := toDBDomain(d)
 	if err != nil {
@@ -74,7 +74,7 @@ func (repo domainRepo) Save(ctx context.Context, d domains.Domain) (dd domains.D
 
 // RetrieveByID retrieves Domain by its unique ID.
 func (repo domainRepo) RetrieveByID(ctx context.Context, id string) (domains.Domain, error) {
-	q := `SELECT d.id as id, d.name as name, d.tags as tags,  d.alias as alias, d.metadata as metadata, d.created_at as created_at, d.updated_at as updated_at, d.updated_by as updated_by, d.created_by as created_by, d.status as status
+	q := `SELECT d.id as id, d.name as name, d.tags as tags,  d.alias as alias, d.metadata as metadata, d.created_at as created_at, d.updated_at as updated_at, d.updated_by as updated_by, d.created_by as created_by, d.status as status, d.require_mfa as require_mfa
         FROM domains d WHERE d.id = :id`
 
 	dbdp := dbDomainsPage{
@@ -112,6 +112,7 @@ func (repo domainRepo) RetrieveByUserAndID(ctx context.Context, userID, id strin
 			d.alias as alias,
 			d.metadata as metadata,
 			d.status as status,
+			d.require_mfa as require_mfa,
 			d.role_id AS role_id,
 			d.role_name AS role_name,
 			d.actions AS actions,
@@ -162,7 +163,7 @@ func (repo domainRepo) RetrieveAllByIDs(ctx context.Context, pm domains.Page) (d
 		return domains.DomainsPage{}, errors.Wrap(repoerr.ErrFailedOpDB, err)
 	}
 
-	q = `SELECT d.id as id, d.name as name, d.tags as tags, d.alias as alias, d.metadata as metadata, d.created_at as created_at, d.updated_at as updated_at, d.updated_by as updated_by, d.created_by as created_by, d.status as status
+	q = `SELECT d.id as id, d.name as name, d.tags as tags, d.alias as alias, d.metadata as metadata, d.created_at as created_at, d.updated_at as updated_at, d.updated_by as updated_by, d.created_by as created_by, d.status as status, d.require_mfa as require_mfa
 	FROM domains d`
 	q = fmt.Sprintf("%s %s  LIMIT %d OFFSET %d;", q, query, pm.Limit, pm.Offset)
 
@@ -218,7 +219,8 @@ func (repo domainRepo) ListDomains(ctx context.Context, pm domains.Page) (domain
 			d.updated_at as updated_at,
 			d.updated_by as updated_by,
 			d.created_by as created_by,
-			d.status as status
+			d.status as status,
+			d.require_mfa as require_mfa
 		FROM
 			domains as d
 		%s
@@ -234,6 +236,7 @@ func (repo domainRepo) ListDomains(ctx context.Context, pm domains.Page) (domain
 				d.alias as alias,
 				d.metadata as metadata,
 				d.status as status,
+				d.require_mfa as require_mfa,
 				d.role_id AS role_id,
 				d.role_name AS role_name,
 				d.actions AS actions,
@@ -317,6 +320,10 @@ func (repo domainRepo) Update(ctx context.Context, id, userID string, dr domains
 		query = append(query, "alias = :alias, ")
 		d.Alias = *dr.Alias
 	}
+	if dr.RequireMFA != nil {
+		query = append(query, "require_mfa = :require_mfa, ")
+		d.RequireMFA = *dr.RequireMFA
+	}
 	d.UpdatedAt = time.Now()
 	d.UpdatedBy = userID
 	if len(query) > 0 {
@@ -324,7 +331,7 @@ func (repo domainRepo) Update(ctx context.Context, id, userID string, dr domains
 	}
 	q := fmt.Sprintf(`UPDATE domains SET %s  updated_at = :updated_at, updated_by = :updated_by
         WHERE id = :id %s
-        RETURNING id, name, tags, alias, metadata, created_at, updated_at, updated_by, created_by, status;`,
+        RETURNING id, name, tags, alias, metadata, created_at, updated_at, updated_by, created_by, status, require_mfa;`,
 		upq, ws)
 
 	dbd, err := toDBDomain(d)
@@ -366,6 +373,22 @@ func (repo domainRepo) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+func (repo domainRepo) RequiresMFA(ctx context.Context, userID string) (bool, error) {
+	q := `SELECT EXISTS (
+		SELECT 1 FROM domains d
+		JOIN domains_roles dr ON dr.entity_id = d.id
+		JOIN domains_role_members drm ON drm.role_id = dr.id
+		WHERE drm.member_id = $1 AND d.require_mfa AND d.status = $2
+	)`
+
+	var required bool
+	if err := repo.db.QueryRowxContext(ctx, q, userID, domains.EnabledStatus).Scan(&required); err != nil {
+		return false, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+
+	return required, nil
+}
+
 func (repo domainRepo) userDomainsBaseQuery() string {
 	return `
 		with domains AS (
@@ -380,6 +403,7 @@ func (repo domainRepo) userDomainsBaseQuery() string {
 				d.updated_by as updated_by,
 				d.created_by as created_by,
 				d.status as status,
+				d.require_mfa as require_mfa,
 				dr.entity_id AS entity_id,
 				drm.member_id AS member_id,
 				dr.id AS role_id,
@@ -428,19 +452,20 @@ func applyOrdering(emq string, pm domains.Page) string {
 }
 
 type dbDomain struct {
-	ID        string           `db:"id"`
-	Name      string           `db:"name"`
-	Metadata  []byte           `db:"metadata,omitempty"`
-	Tags      pgtype.TextArray `db:"tags,omitempty"`
-	Alias     *string          `db:"alias,omitempty"`
-	Status    domains.Status   `db:"status"`
-	RoleID    string           `db:"role_id"`
-	RoleName  string           `db:"role_name"`
-	Actions   pq.StringArray   `db:"actions"`
-	CreatedBy string           `db:"created_by"`
-	CreatedAt time.Time        `db:"created_at"`
-	UpdatedBy *string          `db:"updated_by,omitempty"`
-	UpdatedAt sql.NullTime     `db:"updated_at,omitempty"`
+	ID         string           `db:"id"`
+	Name       string           `db:"name"`
+	Metadata   []byte           `db:"metadata,omitempty"`
+	Tags       pgtype.TextArray `db:"tags,omitempty"`
+	Alias      *string          `db:"alias,omitempty"`
+	Status     domains.Status   `db:"status"`
+	RequireMFA bool             `db:"require_mfa"`
+	RoleID     string           `db:"role_id"`
+	RoleName   string           `db:"role_name"`
+	Actions    pq.StringArray   `db:"actions"`
+	CreatedBy  string           `db:"created_by"`
+	CreatedAt  time.Time        `db:"created_at"`
+	UpdatedBy  *string          `db:"updated_by,omitempty"`
+	UpdatedAt  sql.NullTime     `db:"updated_at,omitempty"`
 }
 
 func toDBDomain(d domains.Domain) (dbDomain, error) {
@@ -471,17 +496,18 @@ func toDBDomain(d domains.Domain) (dbDomain, error) {
 	}
 
 	return dbDomain{
-		ID:        d.ID,
-		Name:      d.Name,
-		Metadata:  data,
-		Tags:      tags,
-		Alias:     alias,
-		Status:    d.Status,
-		RoleID:    d.RoleID,
-		CreatedBy: d.CreatedBy,
-		CreatedAt: d.CreatedAt,
-		UpdatedBy: updatedBy,
-		UpdatedAt: updatedAt,
+		ID:         d.ID,
+		Name:       d.Name,
+		Metadata:   data,
+		Tags:       tags,
+		Alias:      alias,
+		Status:     d.Status,
+		RequireMFA: d.RequireMFA,
+		RoleID:     d.RoleID,
+		CreatedBy:  d.CreatedBy,
+		CreatedAt:  d.CreatedAt,
+		UpdatedBy:  updatedBy,
+		UpdatedAt:  updatedAt,
 	}, nil
 }
 
@@ -510,19 +536,20 @@ func toDomain(d dbDomain) (domains.Domain, error) {
 	}
 
 	return domains.Domain{
-		ID:        d.ID,
-		Name:      d.Name,
-		Metadata:  metadata,
-		Tags:      tags,
-		Alias:     alias,
-		RoleID:    d.RoleID,
-		RoleName:  d.RoleName,
-		Actions:   d.Actions,
-		Status:    d.Status,
-		CreatedBy: d.CreatedBy,
-		CreatedAt: d.CreatedAt,
-		UpdatedBy: updatedBy,
-		UpdatedAt: updatedAt,
+		ID:         d.ID,
+		Name:       d.Name,
+		Metadata:   metadata,
+		Tags:       tags,
+		Alias:      alias,
+		RoleID:     d.RoleID,
+		RoleName:   d.RoleName,
+		Actions:    d.Actions,
+		Status:     d.Status,
+		RequireMFA: d.RequireMFA,
+		CreatedBy:  d.CreatedBy,
+		CreatedAt:  d.CreatedAt,
+		UpdatedBy:  updatedBy,
+		UpdatedAt:  updatedAt,
 	}, nil
 }
 
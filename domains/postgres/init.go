@@ -37,6 +37,15 @@ func Migration() (*migrate.MemoryMigrationSource, error) {
 					`DROP TABLE IF EXISTS domains`,
 				},
 			},
+			{
+				Id: "domain_2",
+				Up: []string{
+					`ALTER TABLE domains ADD COLUMN IF NOT EXISTS require_mfa BOOLEAN NOT NULL DEFAULT FALSE`,
+				},
+				Down: []string{
+					`ALTER TABLE domains DROP COLUMN IF EXISTS require_mfa`,
+				},
+			},
 		},
 	}
 
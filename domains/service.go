@@ -155,6 +155,44 @@ func (svc service) ListDomains(ctx context.Context, session authn.Session, p Pag
 	return dp, nil
 }
 
+func (svc service) RequiresMFA(ctx context.Context, userID string) (bool, error) {
+	required, err := svc.repo.RequiresMFA(ctx, userID)
+	if err != nil {
+		return false, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	return required, nil
+}
+
+func (svc service) AddUserRole(ctx context.Context, domainID, roleName, userID string) error {
+	session := authn.Session{DomainID: domainID}
+	exists, err := svc.RoleCheckMembersExists(ctx, session, domainID, roleName, []string{userID})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	if _, err := svc.RoleAddMembers(ctx, session, domainID, roleName, []string{userID}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (svc service) RemoveUserRole(ctx context.Context, domainID, roleName, userID string) error {
+	session := authn.Session{DomainID: domainID}
+	exists, err := svc.RoleCheckMembersExists(ctx, session, domainID, roleName, []string{userID})
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	return svc.RoleRemoveMembers(ctx, session, domainID, roleName, []string{userID})
+}
+
 func (svc service) DeleteUserFromDomains(ctx context.Context, id string) (err error) {
 	domainsPage, err := svc.repo.ListDomains(ctx, Page{UserID: id, Limit: defLimit})
 	if err != nil {
@@ -576,3 +576,173 @@ func TestDeleteUserFromDomains(t *testing.T) {
 		})
 	}
 }
+
+func TestRequiresMFA(t *testing.T) {
+	svc := newService()
+
+	cases := []struct {
+		desc     string
+		userID   string
+		required bool
+		repoErr  error
+		err      error
+	}{
+		{
+			desc:     "check MFA requirement for member of domain requiring MFA",
+			userID:   id,
+			required: true,
+			err:      nil,
+		},
+		{
+			desc:     "check MFA requirement for member of domains not requiring MFA",
+			userID:   id,
+			required: false,
+			err:      nil,
+		},
+		{
+			desc:    "check MFA requirement with repository error",
+			userID:  id,
+			repoErr: repoerr.ErrViewEntity,
+			err:     svcerr.ErrViewEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repoCall := drepo.On("RequiresMFA", context.Background(), tc.userID).Return(tc.required, tc.repoErr)
+			required, err := svc.RequiresMFA(context.Background(), tc.userID)
+			assert.True(t, errors.Contains(err, tc.err))
+			assert.Equal(t, tc.required, required)
+			repoCall.Unset()
+		})
+	}
+}
+
+func TestAddUserRole(t *testing.T) {
+	svc := newService()
+
+	role := roles.Role{ID: "test_role_id", Name: "admin", EntityID: validID}
+
+	cases := []struct {
+		desc           string
+		exists         bool
+		retrieveErr    error
+		checkErr       error
+		addPoliciesErr error
+		addErr         error
+		err            error
+	}{
+		{
+			desc: "add user role successfully",
+			err:  nil,
+		},
+		{
+			desc:   "add user role to existing member",
+			exists: true,
+			err:    nil,
+		},
+		{
+			desc:        "add user role with non-existing role",
+			retrieveErr: repoerr.ErrNotFound,
+			err:         svcerr.ErrViewEntity,
+		},
+		{
+			desc:     "add user role with failed to check members",
+			checkErr: repoerr.ErrViewEntity,
+			err:      svcerr.ErrViewEntity,
+		},
+		{
+			desc:           "add user role with failed to add policies",
+			addPoliciesErr: svcerr.ErrAuthorization,
+			err:            svcerr.ErrAddPolicies,
+		},
+		{
+			desc:   "add user role with failed to add members",
+			addErr: repoerr.ErrCreateEntity,
+			err:    svcerr.ErrCreateEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repoCall := drepo.On("RetrieveRoleByEntityIDAndName", context.Background(), validID, role.Name).Return(role, tc.retrieveErr)
+			repoCall1 := drepo.On("RoleCheckMembersExists", context.Background(), role.ID, []string{userID}).Return(tc.exists, tc.checkErr)
+			policyCall := policy.On("AddPolicies", context.Background(), mock.Anything).Return(tc.addPoliciesErr)
+			policyCall1 := policy.On("DeletePolicies", context.Background(), mock.Anything).Return(nil)
+			repoCall2 := drepo.On("RoleAddMembers", context.Background(), mock.Anything, []string{userID}).Return([]string{userID}, tc.addErr)
+			err := svc.AddUserRole(context.Background(), validID, role.Name, userID)
+			assert.True(t, errors.Contains(err, tc.err))
+			repoCall.Unset()
+			repoCall1.Unset()
+			policyCall.Unset()
+			policyCall1.Unset()
+			repoCall2.Unset()
+		})
+	}
+}
+
+func TestRemoveUserRole(t *testing.T) {
+	svc := newService()
+
+	role := roles.Role{ID: "test_role_id", Name: "admin", EntityID: validID}
+
+	cases := []struct {
+		desc              string
+		exists            bool
+		retrieveErr       error
+		checkErr          error
+		deletePoliciesErr error
+		removeErr         error
+		err               error
+	}{
+		{
+			desc:   "remove user role successfully",
+			exists: true,
+			err:    nil,
+		},
+		{
+			desc:   "remove user role from non-member",
+			exists: false,
+			err:    nil,
+		},
+		{
+			desc:        "remove user role with non-existing role",
+			retrieveErr: repoerr.ErrNotFound,
+			err:         svcerr.ErrViewEntity,
+		},
+		{
+			desc:     "remove user role with failed to check members",
+			checkErr: repoerr.ErrViewEntity,
+			err:      svcerr.ErrViewEntity,
+		},
+		{
+			desc:              "remove user role with failed to delete policies",
+			exists:            true,
+			deletePoliciesErr: svcerr.ErrAuthorization,
+			err:               svcerr.ErrDeletePolicies,
+		},
+		{
+			desc:      "remove user role with failed to remove members",
+			exists:    true,
+			removeErr: repoerr.ErrRemoveEntity,
+			err:       svcerr.ErrRemoveEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repoCall := drepo.On("RetrieveRoleByEntityIDAndName", context.Background(), validID, role.Name).Return(role, tc.retrieveErr)
+			repoCall1 := drepo.On("RoleCheckMembersExists", context.Background(), role.ID, []string{userID}).Return(tc.exists, tc.checkErr)
+			policyCall := policy.On("DeletePolicies", context.Background(), mock.Anything).Return(tc.deletePoliciesErr)
+			policyCall1 := policy.On("AddPolicies", context.Background(), mock.Anything).Return(nil)
+			repoCall2 := drepo.On("RoleRemoveMembers", context.Background(), mock.Anything, []string{userID}).Return(tc.removeErr)
+			err := svc.RemoveUserRole(context.Background(), validID, role.Name, userID)
+			assert.True(t, errors.Contains(err, tc.err))
+			repoCall.Unset()
+			repoCall1.Unset()
+			policyCall.Unset()
+			policyCall1.Unset()
+			repoCall2.Unset()
+		})
+	}
+}
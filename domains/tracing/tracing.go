@@ -77,6 +77,34 @@ func (tm *tracingMiddleware) ListDomains(ctx context.Context, session authn.Sess
 	return tm.svc.ListDomains(ctx, session, p)
 }
 
+func (tm *tracingMiddleware) RequiresMFA(ctx context.Context, userID string) (bool, error) {
+	ctx, span := tm.tracer.Start(ctx, "requires_mfa", trace.WithAttributes(
+		attribute.String("user_id", userID),
+	))
+	defer span.End()
+	return tm.svc.RequiresMFA(ctx, userID)
+}
+
+func (tm *tracingMiddleware) AddUserRole(ctx context.Context, domainID, roleName, userID string) error {
+	ctx, span := tm.tracer.Start(ctx, "add_user_role", trace.WithAttributes(
+		attribute.String("domain_id", domainID),
+		attribute.String("role_name", roleName),
+		attribute.String("user_id", userID),
+	))
+	defer span.End()
+	return tm.svc.AddUserRole(ctx, domainID, roleName, userID)
+}
+
+func (tm *tracingMiddleware) RemoveUserRole(ctx context.Context, domainID, roleName, userID string) error {
+	ctx, span := tm.tracer.Start(ctx, "remove_user_role", trace.WithAttributes(
+		attribute.String("domain_id", domainID),
+		attribute.String("role_name", roleName),
+		attribute.String("user_id", userID),
+	))
+	defer span.End()
+	return tm.svc.RemoveUserRole(ctx, domainID, roleName, userID)
+}
+
 func (tm *tracingMiddleware) DeleteUserFromDomains(ctx context.Context, id string) error {
 	ctx, span := tm.tracer.Start(ctx, "delete_user_from_domains")
 	defer span.End()
@@ -18,3 +18,14 @@ func sendMessageEndpoint() endpoint.Endpoint {
 		return publishMessageRes{}, nil
 	}
 }
+
+func ingestEndpoint() endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(publishReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		return ingestRes{}, nil
+	}
+}
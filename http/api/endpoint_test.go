@@ -1,12 +1,15 @@
 package api_test
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hantdev/hermina"
 	proxy "github.com/hantdev/hermina/pkg/http"
@@ -23,26 +26,32 @@ import (
 	smqauthn "github.com/hantdev/mitras/pkg/authn"
 	authnMocks "github.com/hantdev/mitras/pkg/authn/mocks"
 	"github.com/hantdev/mitras/pkg/connections"
+	"github.com/hantdev/mitras/pkg/messaging"
 	pubsub "github.com/hantdev/mitras/pkg/messaging/mocks"
 	"github.com/hantdev/mitras/pkg/policies"
+	"github.com/hantdev/mitras/pkg/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 const (
 	instanceID   = "5de9b29a-feb9-11ed-be56-0242ac120002"
 	invalidValue = "invalid"
+	bufferSize   = 10
+	heartbeat    = 100 * time.Millisecond
 )
 
 var clientID = testsutil.GenerateUUID(&testing.T{})
 
-func newService(authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient) (session.Handler, *pubsub.PubSub) {
+func newService(authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient) (session.Handler, server.Service, *pubsub.PubSub) {
 	pub := new(pubsub.PubSub)
-	return server.NewHandler(pub, authn, clients, channels, smqlog.NewMock()), pub
+	streams := server.NewService(pub, authn, clients, channels, uuid.NewMock(), bufferSize, time.Minute)
+	return server.NewHandler(pub, authn, clients, channels, 32<<20, smqlog.NewMock()), streams, pub
 }
 
-func newTargetHTTPServer() *httptest.Server {
-	mux := api.MakeHandler(smqlog.NewMock(), instanceID)
+func newTargetHTTPServer(streams server.Service) *httptest.Server {
+	mux := api.MakeHandler(streams, heartbeat, smqlog.NewMock(), instanceID)
 	return httptest.NewServer(mux)
 }
 
@@ -99,8 +108,8 @@ func TestPublish(t *testing.T) {
 	msg := `[{"n":"current","t":-1,"v":1.6}]`
 	msgJSON := `{"field1":"val1","field2":"val2"}`
 	msgCBOR := `81A3616E6763757272656E746174206176FB3FF999999999999A`
-	svc, pub := newService(authn, clients, channels)
-	target := newTargetHTTPServer()
+	svc, streams, pub := newService(authn, clients, channels)
+	target := newTargetHTTPServer(streams)
 	defer target.Close()
 	ts, err := newProxyHTPPServer(svc, target)
 	assert.Nil(t, err, fmt.Sprintf("failed to create proxy server with err: %v", err))
@@ -239,3 +248,200 @@ func TestPublish(t *testing.T) {
 		})
 	}
 }
+
+func TestIngest(t *testing.T) {
+	clients := new(climocks.ClientsServiceClient)
+	authn := new(authnMocks.Authentication)
+	channels := new(chmocks.ChannelsServiceClient)
+	chanID := "1"
+	clientKey := "client_key"
+	svc, streams, pub := newService(authn, clients, channels)
+	target := newTargetHTTPServer(streams)
+	defer target.Close()
+	ts, err := newProxyHTPPServer(svc, target)
+	require.Nil(t, err, fmt.Sprintf("failed to create proxy server with err: %v", err))
+	defer ts.Close()
+
+	clients.On("Authenticate", mock.Anything, &grpcClientsV1.AuthnReq{ClientSecret: clientKey}).Return(&grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true}, nil)
+	clients.On("Authenticate", mock.Anything, mock.Anything).Return(&grpcClientsV1.AuthnRes{Authenticated: false}, nil)
+	channels.On("Authorize", mock.Anything, mock.Anything).Return(&grpcChannelsV1.AuthzRes{Authorized: true}, nil)
+	pub.On("Publish", mock.Anything, chanID, mock.Anything).Return(nil)
+
+	cases := []struct {
+		desc   string
+		path   string
+		auth   string
+		body   string
+		status int
+	}{
+		{
+			desc:   "write influx line protocol",
+			path:   "influx/write?precision=s",
+			auth:   apiutil.ClientPrefix + clientKey,
+			body:   "cpu,host=server1 usage_idle=99.5 1700000000",
+			status: http.StatusNoContent,
+		},
+		{
+			desc:   "write influx v2 line protocol with token",
+			path:   "influx/api/v2/write?org=mitras&bucket=mitras",
+			auth:   "Token " + clientKey,
+			body:   "cpu,host=server1 usage_idle=99.5",
+			status: http.StatusNoContent,
+		},
+		{
+			desc:   "write malformed influx line protocol",
+			path:   "influx/write",
+			auth:   apiutil.ClientPrefix + clientKey,
+			body:   "cpu",
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "write influx line protocol with invalid key",
+			path:   "influx/write",
+			auth:   apiutil.ClientPrefix + invalidValue,
+			body:   "cpu,host=server1 usage_idle=99.5",
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "write malformed prometheus remote write",
+			path:   "prometheus/write",
+			auth:   apiutil.ClientPrefix + clientKey,
+			body:   "invalid",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/channels/%s/%s", ts.URL, chanID, tc.path), strings.NewReader(tc.body))
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			req.Header.Set("Authorization", tc.auth)
+			res, err := ts.Client().Do(req)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			res.Body.Close()
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	clients := new(climocks.ClientsServiceClient)
+	authn := new(authnMocks.Authentication)
+	channels := new(chmocks.ChannelsServiceClient)
+	chanID := "1"
+	clientKey := "client_key"
+	svc, streams, pub := newService(authn, clients, channels)
+	target := newTargetHTTPServer(streams)
+	defer target.Close()
+	ts, err := newProxyHTPPServer(svc, target)
+	require.Nil(t, err, fmt.Sprintf("failed to create proxy server with err: %v", err))
+	defer ts.Close()
+
+	handlers := make(chan messaging.MessageHandler, 1)
+	pub.On("Subscribe", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		handlers <- args.Get(1).(messaging.SubscriberConfig).Handler
+	}).Return(nil)
+	clients.On("Authenticate", mock.Anything, &grpcClientsV1.AuthnReq{ClientSecret: clientKey}).Return(&grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true}, nil)
+	clients.On("Authenticate", mock.Anything, mock.Anything).Return(&grpcClientsV1.AuthnRes{Authenticated: false}, nil)
+	channels.On("Authorize", mock.Anything, &grpcChannelsV1.AuthzReq{
+		ChannelId:  chanID,
+		ClientId:   clientID,
+		ClientType: policies.ClientType,
+		Type:       uint32(connections.Subscribe),
+	}).Return(&grpcChannelsV1.AuthzRes{Authorized: true}, nil)
+	channels.On("Authorize", mock.Anything, mock.Anything).Return(&grpcChannelsV1.AuthzRes{Authorized: false}, nil)
+
+	cases := []struct {
+		desc   string
+		chanID string
+		query  string
+		key    string
+		status int
+	}{
+		{
+			desc:   "stream messages with empty key",
+			chanID: chanID,
+			key:    "",
+			status: http.StatusBadGateway,
+		},
+		{
+			desc:   "stream messages with invalid key",
+			chanID: chanID,
+			key:    invalidValue,
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "stream messages of unauthorized channel",
+			chanID: "2",
+			key:    clientKey,
+			status: http.StatusForbidden,
+		},
+		{
+			desc:   "stream messages with malformed subtopic",
+			chanID: chanID,
+			query:  "?subtopic=sub.a*b",
+			key:    clientKey,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				client: ts.Client(),
+				method: http.MethodGet,
+				url:    fmt.Sprintf("%s/channels/%s/messages/stream%s", ts.URL, tc.chanID, tc.query),
+				token:  tc.key,
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			res.Body.Close()
+		})
+	}
+
+	req := testRequest{
+		client: ts.Client(),
+		method: http.MethodGet,
+		url:    fmt.Sprintf("%s/channels/%s/messages/stream", ts.URL, chanID),
+		token:  clientKey,
+	}
+	res, err := req.make()
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("expected status code %d got %d", http.StatusOK, res.StatusCode))
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"), "got unexpected content type")
+
+	handler := <-handlers
+	msg := &messaging.Message{Channel: chanID, Subtopic: "subtopic", Publisher: "publisher", Protocol: "mqtt", Created: 1, Payload: []byte(`{"v":1}`)}
+	err = handler.Handle(msg)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+
+	// Heartbeats are sent as comment lines, which are skipped by the clients.
+	reader := bufio.NewReader(res.Body)
+	heartbeats, lines := 0, []string{}
+	for len(lines) < 2 || heartbeats == 0 {
+		line, err := reader.ReadString('\n')
+		require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+		switch {
+		case line == ": heartbeat\n":
+			heartbeats++
+		case line != "\n":
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+	}
+	require.Len(t, lines, 2, "expected event ID and data lines")
+	assert.True(t, strings.HasPrefix(lines[0], "id: "), "expected event ID line")
+	var data map[string]any
+	err = json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &data)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	expected := map[string]any{
+		"channel":   chanID,
+		"subtopic":  "subtopic",
+		"publisher": "publisher",
+		"protocol":  "mqtt",
+		"created":   float64(1),
+		"payload":   "eyJ2IjoxfQ==",
+	}
+	assert.Equal(t, expected, data, "got unexpected event data")
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	adapter "github.com/hantdev/mitras/http"
+)
+
+var _ adapter.Service = (*loggingMiddleware)(nil)
+
+type loggingMiddleware struct {
+	logger *slog.Logger
+	svc    adapter.Service
+}
+
+// LoggingMiddleware adds logging facilities to the server-sent events streams.
+func LoggingMiddleware(svc adapter.Service, logger *slog.Logger) adapter.Service {
+	return &loggingMiddleware{logger, svc}
+}
+
+// Subscribe logs the subscribe request. It logs the channel and subtopic(if present) and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) Subscribe(ctx context.Context, token, chanID, subtopic, lastEventID string) (sub *adapter.Subscription, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("channel_id", chanID),
+		}
+		if subtopic != "" {
+			args = append(args, "subtopic", subtopic)
+		}
+		if lastEventID != "" {
+			args = append(args, "last_event_id", lastEventID)
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Subscribe to stream failed", args...)
+			return
+		}
+		lm.logger.Info("Subscribe to stream completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Subscribe(ctx, token, chanID, subtopic, lastEventID)
+}
+
+// Unsubscribe logs the unsubscribe request. It logs the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) Unsubscribe(ctx context.Context, sub *adapter.Subscription) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Unsubscribe from stream failed", args...)
+			return
+		}
+		lm.logger.Info("Unsubscribe from stream completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Unsubscribe(ctx, sub)
+}
@@ -0,0 +1,48 @@
+//go:build !test
+
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	adapter "github.com/hantdev/mitras/http"
+)
+
+var _ adapter.Service = (*metricsMiddleware)(nil)
+
+type metricsMiddleware struct {
+	counter metrics.Counter
+	latency metrics.Histogram
+	svc     adapter.Service
+}
+
+// MetricsMiddleware instruments server-sent events streams by tracking request count and latency.
+func MetricsMiddleware(svc adapter.Service, counter metrics.Counter, latency metrics.Histogram) adapter.Service {
+	return &metricsMiddleware{
+		counter: counter,
+		latency: latency,
+		svc:     svc,
+	}
+}
+
+// Subscribe instruments Subscribe method with metrics.
+func (mm *metricsMiddleware) Subscribe(ctx context.Context, token, chanID, subtopic, lastEventID string) (*adapter.Subscription, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "subscribe").Add(1)
+		mm.latency.With("method", "subscribe").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.Subscribe(ctx, token, chanID, subtopic, lastEventID)
+}
+
+// Unsubscribe instruments Unsubscribe method with metrics.
+func (mm *metricsMiddleware) Unsubscribe(ctx context.Context, sub *adapter.Subscription) error {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "unsubscribe").Add(1)
+		mm.latency.With("method", "unsubscribe").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.Unsubscribe(ctx, sub)
+}
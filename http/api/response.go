@@ -6,7 +6,10 @@ import (
 	"github.com/hantdev/mitras"
 )
 
-var _ mitras.Response = (*publishMessageRes)(nil)
+var (
+	_ mitras.Response = (*publishMessageRes)(nil)
+	_ mitras.Response = (*ingestRes)(nil)
+)
 
 type publishMessageRes struct{}
 
@@ -21,3 +24,17 @@ func (res publishMessageRes) Headers() map[string]string {
 func (res publishMessageRes) Empty() bool {
 	return true
 }
+
+type ingestRes struct{}
+
+func (res ingestRes) Code() int {
+	return http.StatusNoContent
+}
+
+func (res ingestRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res ingestRes) Empty() bool {
+	return true
+}
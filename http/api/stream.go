@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	adapter "github.com/hantdev/mitras/http"
+	"github.com/hantdev/mitras/internal/api"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	"github.com/hantdev/mitras/pkg/errors"
+)
+
+const (
+	ctEventStream    = "text/event-stream"
+	lastEventIDKey   = "Last-Event-ID"
+	subtopicKey      = "subtopic"
+	authorizationKey = "authorization"
+)
+
+var (
+	errMalformedSubtopic    = errors.New("malformed subtopic")
+	errStreamingUnsupported = errors.New("streaming is not supported")
+)
+
+type streamReq struct {
+	token       string
+	chanID      string
+	subtopic    string
+	lastEventID string
+}
+
+func (req streamReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerKey
+	}
+	if req.chanID == "" {
+		return apiutil.ErrMissingID
+	}
+
+	return nil
+}
+
+// eventRes is the data of the server-sent event. Payload is encoded as base64
+// string, since messages may carry binary payloads.
+type eventRes struct {
+	Channel   string `json:"channel"`
+	Subtopic  string `json:"subtopic,omitempty"`
+	Publisher string `json:"publisher,omitempty"`
+	Protocol  string `json:"protocol,omitempty"`
+	Created   int64  `json:"created"`
+	Payload   []byte `json:"payload"`
+}
+
+// streamHandler serves the server-sent events stream of the channel. Stream
+// is kept open until the client disconnects, and the comment line is sent
+// every heartbeat interval to keep the connection alive.
+func streamHandler(svc adapter.Service, logger *slog.Logger, heartbeat time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		encodeError := apiutil.LoggingErrorEncoder(logger, api.EncodeError)
+		req, err := decodeStreamRequest(r)
+		if err != nil {
+			encodeError(ctx, err, w)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			encodeError(ctx, errStreamingUnsupported, w)
+			return
+		}
+
+		sub, err := svc.Subscribe(ctx, req.token, req.chanID, req.subtopic, req.lastEventID)
+		if err != nil {
+			encodeError(ctx, err, w)
+			return
+		}
+		defer func() {
+			if err := svc.Unsubscribe(context.WithoutCancel(ctx), sub); err != nil {
+				logger.Warn(fmt.Sprintf("Failed to unsubscribe stream of channel %s: %s", req.chanID, err))
+			}
+		}()
+
+		w.Header().Set("Content-Type", ctEventStream)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			case ev, ok := <-sub.Events:
+				if !ok {
+					return
+				}
+				if err := encodeEvent(w, ev); err != nil {
+					logger.Warn(fmt.Sprintf("Failed to write event of channel %s: %s", req.chanID, err))
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func decodeStreamRequest(r *http.Request) (streamReq, error) {
+	req := streamReq{
+		chanID:      chi.URLParam(r, "chanID"),
+		lastEventID: r.Header.Get(lastEventIDKey),
+	}
+
+	// Browsers can't set the headers of the event source requests, so the
+	// token and the last event ID may be sent using the query parameters.
+	_, pass, ok := r.BasicAuth()
+	switch {
+	case ok:
+		req.token = pass
+	case r.URL.Query().Get(authorizationKey) != "":
+		req.token = r.URL.Query().Get(authorizationKey)
+	default:
+		req.token = r.Header.Get("Authorization")
+	}
+	if req.lastEventID == "" {
+		req.lastEventID = r.URL.Query().Get(lastEventIDKey)
+	}
+
+	subtopic, err := parseSubtopic(r.URL.Query().Get(subtopicKey))
+	if err != nil {
+		return streamReq{}, errors.Wrap(apiutil.ErrValidation, errors.Wrap(apiutil.ErrInvalidTopic, err))
+	}
+	req.subtopic = subtopic
+
+	if err := req.validate(); err != nil {
+		return streamReq{}, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	return req, nil
+}
+
+func encodeEvent(w http.ResponseWriter, ev adapter.Event) error {
+	data, err := json.Marshal(eventRes{
+		Channel:   ev.Message.GetChannel(),
+		Subtopic:  ev.Message.GetSubtopic(),
+		Publisher: ev.Message.GetPublisher(),
+		Protocol:  ev.Message.GetProtocol(),
+		Created:   ev.Message.GetCreated(),
+		Payload:   ev.Message.GetPayload(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\ndata: %s\n\n", ev.ID, data)
+
+	return err
+}
+
+// parseSubtopic converts the subtopic filter to the broker subject. Filters
+// may use "*" to match a single subtopic level and ">" to match the rest.
+func parseSubtopic(subtopic string) (string, error) {
+	subtopic = strings.ReplaceAll(subtopic, "/", ".")
+
+	elems := []string{}
+	for _, elem := range strings.Split(subtopic, ".") {
+		if elem == "" {
+			continue
+		}
+		if len(elem) > 1 && (strings.Contains(elem, "*") || strings.Contains(elem, ">")) {
+			return "", errMalformedSubtopic
+		}
+		elems = append(elems, elem)
+	}
+
+	return strings.Join(elems, "."), nil
+}
@@ -5,10 +5,13 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/hantdev/mitras"
+	adapter "github.com/hantdev/mitras/http"
 	"github.com/hantdev/mitras/internal/api"
 	"github.com/hantdev/mitras/pkg/apiutil"
 	"github.com/hantdev/mitras/pkg/errors"
@@ -21,10 +24,12 @@ const (
 	ctSenmlJSON = "application/senml+json"
 	ctSenmlCBOR = "application/senml+cbor"
 	contentType = "application/json"
+	tokenPrefix = "Token "
 )
 
-// MakeHandler returns a HTTP handler for API endpoints.
-func MakeHandler(logger *slog.Logger, instanceID string) http.Handler {
+// MakeHandler returns a HTTP handler for API endpoints. Server-sent events
+// streams send the heartbeat every heartbeat interval.
+func MakeHandler(svc adapter.Service, heartbeat time.Duration, logger *slog.Logger, instanceID string) http.Handler {
 	opts := []kithttp.ServerOption{
 		kithttp.ServerErrorEncoder(apiutil.LoggingErrorEncoder(logger, api.EncodeError)),
 	}
@@ -43,6 +48,29 @@ func MakeHandler(logger *slog.Logger, instanceID string) http.Handler {
 		api.EncodeResponse,
 		opts...,
 	), "publish").ServeHTTP)
+
+	r.Post("/channels/{chanID}/prometheus/write", otelhttp.NewHandler(kithttp.NewServer(
+		ingestEndpoint(),
+		decodeIngestRequest,
+		api.EncodeResponse,
+		opts...,
+	), "prometheus_write").ServeHTTP)
+
+	r.Post("/channels/{chanID}/influx/write", otelhttp.NewHandler(kithttp.NewServer(
+		ingestEndpoint(),
+		decodeIngestRequest,
+		api.EncodeResponse,
+		opts...,
+	), "influx_write").ServeHTTP)
+
+	r.Post("/channels/{chanID}/influx/api/v2/write", otelhttp.NewHandler(kithttp.NewServer(
+		ingestEndpoint(),
+		decodeIngestRequest,
+		api.EncodeResponse,
+		opts...,
+	), "influx_write").ServeHTTP)
+
+	r.Get("/channels/{chanID}/messages/stream", otelhttp.NewHandler(streamHandler(svc, logger, heartbeat), "stream").ServeHTTP)
 	r.Get("/health", mitras.Health("http", instanceID))
 	r.Handle("/metrics", promhttp.Handler())
 
@@ -74,3 +102,29 @@ func decodeRequest(_ context.Context, r *http.Request) (interface{}, error) {
 
 	return req, nil
 }
+
+// decodeIngestRequest decodes the Prometheus remote write and the Influx line
+// protocol requests, which are converted to SenML by the handler. Besides the
+// client secret, the Influx token is accepted as the client secret.
+func decodeIngestRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req publishReq
+	_, pass, ok := r.BasicAuth()
+	switch auth := r.Header.Get("Authorization"); {
+	case ok:
+		req.token = pass
+	case strings.HasPrefix(auth, tokenPrefix):
+		req.token = strings.TrimPrefix(auth, tokenPrefix)
+	default:
+		req.token = apiutil.ExtractClientSecret(r)
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.ErrMalformedEntity)
+	}
+	defer r.Body.Close()
+
+	req.msg = &messaging.Message{Payload: payload}
+
+	return req, nil
+}
@@ -21,6 +21,7 @@ import (
 	svcerr "github.com/hantdev/mitras/pkg/errors/service"
 	"github.com/hantdev/mitras/pkg/messaging"
 	"github.com/hantdev/mitras/pkg/policies"
+	"github.com/hantdev/senml"
 )
 
 var _ session.Handler = (*handler)(nil)
@@ -50,9 +51,13 @@ var (
 	errMalformedTopic           = mgate.NewHTTPProxyError(http.StatusBadRequest, errors.New("malformed topic"))
 	errMissingTopicPub          = mgate.NewHTTPProxyError(http.StatusBadRequest, errors.New("failed to publish due to missing topic"))
 	errFailedParseSubtopic      = mgate.NewHTTPProxyError(http.StatusBadRequest, errors.New("failed to parse subtopic"))
+	errReadOnly                 = mgate.NewHTTPProxyError(http.StatusForbidden, errors.New("read-only impersonation can't publish"))
 )
 
-var channelRegExp = regexp.MustCompile(`^\/?channels\/([\w\-]+)\/messages(\/[^?]*)?(\?.*)?$`)
+var (
+	channelRegExp = regexp.MustCompile(`^\/?channels\/([\w\-]+)\/messages(\/[^?]*)?(\?.*)?$`)
+	streamRegExp  = regexp.MustCompile(`^\/?channels\/[\w\-]+\/messages\/stream$`)
+)
 
 // Event implements events.Event interface.
 type handler struct {
@@ -60,16 +65,20 @@ type handler struct {
 	clients   grpcClientsV1.ClientsServiceClient
 	channels  grpcChannelsV1.ChannelsServiceClient
 	authn     smqauthn.Authentication
+	maxIngest int64
 	logger    *slog.Logger
 }
 
-// NewHandler creates new Handler entity.
-func NewHandler(publisher messaging.Publisher, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient, logger *slog.Logger) session.Handler {
+// NewHandler creates new Handler entity. The max ingestion size limits the
+// Prometheus remote write and the Influx line protocol requests, and the
+// decompressed remote write requests.
+func NewHandler(publisher messaging.Publisher, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient, maxIngestSize int64, logger *slog.Logger) session.Handler {
 	return &handler{
 		publisher: publisher,
 		authn:     authn,
 		clients:   clients,
 		channels:  channels,
+		maxIngest: maxIngestSize,
 		logger:    logger,
 	}
 }
@@ -116,16 +125,29 @@ func (h *handler) Publish(ctx context.Context, topic *string, payload *[]byte) e
 	if topic == nil {
 		return errMissingTopicPub
 	}
-	topic = &strings.Split(*topic, "?")[0]
+	path, rawQuery, _ := strings.Cut(*topic, "?")
+	topic = &path
 	s, ok := session.FromContext(ctx)
 	if !ok {
 		return errors.Wrap(errFailedPublish, errClientNotInitialized)
 	}
+	// Stream requests don't carry the payload, and they are authorized by
+	// the stream service once forwarded to the HTTP server.
+	if payload != nil && len(*payload) == 0 && streamRegExp.MatchString(*topic) {
+		return nil
+	}
+
+	query, _ := url.ParseQuery(rawQuery)
+	chanID, convert, ingest := parseIngestTopic(*topic, query, h.maxIngest)
+	password := string(s.Password)
+	if ingest && strings.HasPrefix(password, tokenPrefix) {
+		password = apiutil.ClientPrefix + strings.TrimPrefix(password, tokenPrefix)
+	}
 
-	var clientID, clientType string
+	var clientID, clientType, impersonator string
 	switch {
-	case strings.HasPrefix(string(s.Password), "Client"):
-		secret := strings.TrimPrefix(string(s.Password), apiutil.ClientPrefix)
+	case strings.HasPrefix(password, "Client"):
+		secret := strings.TrimPrefix(password, apiutil.ClientPrefix)
 		authnRes, err := h.clients.Authenticate(ctx, &grpcClientsV1.AuthnReq{ClientSecret: secret})
 		if err != nil {
 			h.logger.Info(fmt.Sprintf(logInfoFailedAuthNClient, secret, *topic, err))
@@ -137,30 +159,60 @@ func (h *handler) Publish(ctx context.Context, topic *string, payload *[]byte) e
 		}
 		clientType = policies.ClientType
 		clientID = authnRes.GetId()
-	case strings.HasPrefix(string(s.Password), apiutil.BearerPrefix):
-		token := strings.TrimPrefix(string(s.Password), apiutil.BearerPrefix)
+	case strings.HasPrefix(password, apiutil.BearerPrefix):
+		token := strings.TrimPrefix(password, apiutil.BearerPrefix)
 		authnSession, err := h.authn.Authenticate(ctx, token)
 		if err != nil {
 			h.logger.Info(fmt.Sprintf(logInfoFailedAuthNToken, *topic, err))
 			return mgate.NewHTTPProxyError(http.StatusUnauthorized, svcerr.ErrAuthentication)
 		}
+		if authnSession.ReadOnly {
+			return errReadOnly
+		}
 		clientType = policies.UserType
 		clientID = authnSession.DomainUserID
+		impersonator = authnSession.ImpersonatorID
 	default:
 		return mgate.NewHTTPProxyError(http.StatusUnauthorized, svcerr.ErrAuthentication)
 	}
 
-	chanID, subtopic, err := parseTopic(*topic)
-	if err != nil {
-		return mgate.NewHTTPProxyError(http.StatusBadRequest, err)
+	var subtopic string
+	var data []byte
+	switch {
+	case ingest:
+		// Prometheus remote write and Influx line protocol requests are
+		// converted to SenML, and requests without the samples aren't
+		// published.
+		if int64(len(*payload)) > h.maxIngest {
+			return mgate.NewHTTPProxyError(http.StatusRequestEntityTooLarge, errIngestTooLarge)
+		}
+		pack, err := convert(*payload)
+		switch {
+		case errors.Contains(err, errIngestTooLarge):
+			return mgate.NewHTTPProxyError(http.StatusRequestEntityTooLarge, err)
+		case err != nil:
+			return mgate.NewHTTPProxyError(http.StatusBadRequest, err)
+		}
+		if len(pack.Records) > 0 {
+			if data, err = senml.Encode(pack, senml.JSON); err != nil {
+				return mgate.NewHTTPProxyError(http.StatusBadRequest, err)
+			}
+		}
+	default:
+		var err error
+		if chanID, subtopic, err = parseTopic(*topic); err != nil {
+			return mgate.NewHTTPProxyError(http.StatusBadRequest, err)
+		}
+		data = *payload
 	}
 
 	msg := messaging.Message{
-		Protocol: protocol,
-		Channel:  chanID,
-		Subtopic: subtopic,
-		Payload:  *payload,
-		Created:  time.Now().UnixNano(),
+		Protocol:     protocol,
+		Channel:      chanID,
+		Subtopic:     subtopic,
+		Payload:      data,
+		Created:      time.Now().UnixNano(),
+		Impersonator: impersonator,
 	}
 
 	ar := &grpcChannelsV1.AuthzReq{
@@ -177,6 +229,10 @@ func (h *handler) Publish(ctx context.Context, topic *string, payload *[]byte) e
 		return mgate.NewHTTPProxyError(http.StatusUnauthorized, svcerr.ErrAuthorization)
 	}
 
+	if ingest && len(data) == 0 {
+		return nil
+	}
+
 	if clientType == policies.ClientType {
 		msg.Publisher = clientID
 	}
@@ -20,6 +20,7 @@ import (
 	authnmocks "github.com/hantdev/mitras/pkg/authn/mocks"
 	"github.com/hantdev/mitras/pkg/errors"
 	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/mitras/pkg/messaging"
 	"github.com/hantdev/mitras/pkg/messaging/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -33,6 +34,7 @@ const (
 	invalidID             = "invalidID"
 	invalidValue          = "invalidValue"
 	invalidChannelIDTopic = "channels/**/messages"
+	maxIngestSize         = 1024
 )
 
 var (
@@ -41,6 +43,8 @@ var (
 	topic         = fmt.Sprintf(topicMsg, chanID)
 	subtopic      = fmt.Sprintf(subtopicMsg, chanID)
 	invalidTopic  = invalidValue
+	streamTopic   = fmt.Sprintf("channels/%s/messages/stream?subtopic=temperature", chanID)
+	emptyPayload  = []byte{}
 	payload       = []byte("[{'n':'test-name', 'v': 1.2}]")
 	sessionClient = session.Session{
 		ID:       clientID,
@@ -48,6 +52,7 @@ var (
 	}
 	validToken                  = "token"
 	validID                     = testsutil.GenerateUUID(&testing.T{})
+	impersonatorID              = testsutil.GenerateUUID(&testing.T{})
 	errClientNotInitialized     = errors.New("client is not initialized")
 	errFailedPublish            = errors.New("failed to publish")
 	errMissingTopicPub          = errors.New("failed to publish due to missing topic")
@@ -55,6 +60,7 @@ var (
 	errFailedParseSubtopic      = errors.New("failed to parse subtopic")
 	errMalformedSubtopic        = errors.New("malformed subtopic")
 	errFailedPublishToMsgBroker = errors.New("failed to publish to mitras message broker")
+	errReadOnly                 = errors.New("read-only impersonation can't publish")
 )
 
 var (
@@ -71,7 +77,7 @@ func newHandler() session.Handler {
 	channels = new(chmocks.ChannelsServiceClient)
 	publisher = new(mocks.PubSub)
 
-	return mhttp.NewHandler(publisher, authn, clients, channels, logger)
+	return mhttp.NewHandler(publisher, authn, clients, channels, maxIngestSize, logger)
 }
 
 func TestAuthConnect(t *testing.T) {
@@ -141,20 +147,21 @@ func TestPublish(t *testing.T) {
 		Password: []byte(apiutil.BearerPrefix + validToken),
 	}
 	cases := []struct {
-		desc       string
-		topic      *string
-		channelID  string
-		payload    *[]byte
-		password   string
-		session    *session.Session
-		status     int
-		authNRes   *grpcClientsV1.AuthnRes
-		authNRes1  smqauthn.Session
-		authNErr   error
-		authZRes   *grpcChannelsV1.AuthzRes
-		authZErr   error
-		publishErr error
-		err        error
+		desc         string
+		topic        *string
+		channelID    string
+		payload      *[]byte
+		password     string
+		session      *session.Session
+		status       int
+		authNRes     *grpcClientsV1.AuthnRes
+		authNRes1    smqauthn.Session
+		authNErr     error
+		authZRes     *grpcChannelsV1.AuthzRes
+		authZErr     error
+		publishErr   error
+		impersonator string
+		err          error
 	}{
 		{
 			desc:      "publish  with key successfully",
@@ -280,6 +287,41 @@ func TestPublish(t *testing.T) {
 			authNErr:  svcerr.ErrAuthentication,
 			err:       svcerr.ErrAuthentication,
 		},
+		{
+			desc:      "publish with token successfully",
+			topic:     &topic,
+			payload:   &payload,
+			password:  validToken,
+			session:   &tokenSession,
+			channelID: chanID,
+			authNRes1: smqauthn.Session{DomainUserID: validID, UserID: validID, DomainID: validID},
+			authZRes:  &grpcChannelsV1.AuthzRes{Authorized: true},
+			err:       nil,
+		},
+		{
+			desc:         "publish with impersonation token successfully",
+			topic:        &topic,
+			payload:      &payload,
+			password:     validToken,
+			session:      &tokenSession,
+			channelID:    chanID,
+			authNRes1:    smqauthn.Session{DomainUserID: validID, UserID: validID, DomainID: validID, ImpersonatorID: impersonatorID},
+			authZRes:     &grpcChannelsV1.AuthzRes{Authorized: true},
+			impersonator: impersonatorID,
+			err:          nil,
+		},
+		{
+			desc:      "publish with read-only impersonation token",
+			topic:     &topic,
+			payload:   &payload,
+			password:  validToken,
+			session:   &tokenSession,
+			channelID: chanID,
+			status:    http.StatusForbidden,
+			authNRes1: smqauthn.Session{DomainUserID: validID, UserID: validID, DomainID: validID, ImpersonatorID: impersonatorID, ReadOnly: true},
+			authZRes:  &grpcChannelsV1.AuthzRes{Authorized: true},
+			err:       errReadOnly,
+		},
 		{
 			desc:      "publish with unauthorized client",
 			topic:     &topic,
@@ -322,6 +364,15 @@ func TestPublish(t *testing.T) {
 			publishErr: errors.New("failed to publish"),
 			err:        errFailedPublishToMsgBroker,
 		},
+		{
+			desc:      "publish to stream without payload",
+			topic:     &streamTopic,
+			payload:   &emptyPayload,
+			password:  clientKey,
+			session:   &clientKeySession,
+			channelID: chanID,
+			err:       nil,
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
@@ -332,13 +383,19 @@ func TestPublish(t *testing.T) {
 			clientsCall := clients.On("Authenticate", ctx, &grpcClientsV1.AuthnReq{ClientSecret: tc.password}).Return(tc.authNRes, tc.authNErr)
 			authCall := authn.On("Authenticate", ctx, mock.Anything).Return(tc.authNRes1, tc.authNErr)
 			channelsCall := channels.On("Authorize", ctx, mock.Anything).Return(tc.authZRes, tc.authZErr)
-			repoCall := publisher.On("Publish", ctx, tc.channelID, mock.Anything).Return(tc.publishErr)
+			var msg *messaging.Message
+			repoCall := publisher.On("Publish", ctx, tc.channelID, mock.Anything).Run(func(args mock.Arguments) {
+				msg = args.Get(2).(*messaging.Message)
+			}).Return(tc.publishErr)
 			err := handler.Publish(ctx, tc.topic, tc.payload)
 			hpe, ok := err.(mghttp.HTTPProxyError)
 			if ok {
 				assert.Equal(t, tc.status, hpe.StatusCode())
 			}
 			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("expected: %v, got: %v", tc.err, err))
+			if msg != nil {
+				assert.Equal(t, tc.impersonator, msg.GetImpersonator())
+			}
 			authCall.Unset()
 			repoCall.Unset()
 			clientsCall.Unset()
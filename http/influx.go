@@ -0,0 +1,177 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/senml"
+)
+
+var (
+	errMalformedLineProtocol = errors.New("malformed influx line protocol")
+	errInvalidPrecision      = errors.New("invalid timestamp precision")
+)
+
+// precisions maps the precision of the Influx timestamps to the number of
+// the timestamp units in a second.
+var precisions = map[string]float64{
+	"":   1e9,
+	"n":  1e9,
+	"ns": 1e9,
+	"u":  1e6,
+	"us": 1e6,
+	"ms": 1e3,
+	"s":  1,
+	"m":  1.0 / 60,
+	"h":  1.0 / 3600,
+}
+
+// fromLineProtocol converts the points in the Influx line protocol to SenML.
+// Each field is converted to the record named after the measurement, the
+// field and the tags of the point. Integer and float fields are converted to
+// the value, and string and boolean fields to the string and boolean value.
+// Points without the timestamp are converted to the records without the time.
+func fromLineProtocol(payload []byte, precision string) (senml.Pack, error) {
+	units, ok := precisions[precision]
+	if !ok {
+		return senml.Pack{}, errInvalidPrecision
+	}
+
+	var pack senml.Pack
+	for i, line := range strings.Split(string(payload), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		recs, err := parsePoint(line, units)
+		if err != nil {
+			return senml.Pack{}, errors.Wrap(errMalformedLineProtocol, errors.Wrap(fmt.Errorf("line %d", i+1), err))
+		}
+		pack.Records = append(pack.Records, recs...)
+	}
+
+	return pack, nil
+}
+
+func parsePoint(line string, units float64) ([]senml.Record, error) {
+	sections := split(line, ' ', true)
+	if len(sections) != 2 && len(sections) != 3 {
+		return nil, errors.New("invalid number of sections")
+	}
+
+	series := split(sections[0], ',', false)
+	measurement := unescape(series[0])
+	if measurement == "" {
+		return nil, errors.New("missing measurement")
+	}
+	tags := make(map[string]string, len(series)-1)
+	for _, t := range series[1:] {
+		kv := split(t, '=', false)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("invalid tag %q", t)
+		}
+		tags[unescape(kv[0])] = unescape(kv[1])
+	}
+
+	var time float64
+	if len(sections) == 3 {
+		ts, err := strconv.ParseInt(sections[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q", sections[2])
+		}
+		time = float64(ts) / units
+	}
+
+	fields := split(sections[1], ',', true)
+	recs := make([]senml.Record, 0, len(fields))
+	for _, f := range fields {
+		kv := split(f, '=', true)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("invalid field %q", f)
+		}
+		rec := senml.Record{
+			Name: recordName(measurement+"_"+unescape(kv[0]), tags),
+			Time: time,
+		}
+		if err := parseFieldValue(&rec, kv[1]); err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+
+	return recs, nil
+}
+
+func parseFieldValue(rec *senml.Record, value string) error {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		if len(value) < 2 || !strings.HasSuffix(value, `"`) {
+			return fmt.Errorf("invalid string value %s", value)
+		}
+		s := strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+		rec.StringValue = &s
+		return nil
+	case strings.HasSuffix(value, "i"):
+		v, err := strconv.ParseInt(strings.TrimSuffix(value, "i"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer value %s", value)
+		}
+		f := float64(v)
+		rec.Value = &f
+		return nil
+	case strings.HasSuffix(value, "u"):
+		v, err := strconv.ParseUint(strings.TrimSuffix(value, "u"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer value %s", value)
+		}
+		f := float64(v)
+		rec.Value = &f
+		return nil
+	}
+
+	switch value {
+	case "t", "T", "true", "True", "TRUE":
+		b := true
+		rec.BoolValue = &b
+		return nil
+	case "f", "F", "false", "False", "FALSE":
+		b := false
+		rec.BoolValue = &b
+		return nil
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || strings.ContainsAny(value, "nN") {
+		return fmt.Errorf("invalid float value %s", value)
+	}
+	rec.Value = &v
+
+	return nil
+}
+
+// split splits the line protocol element by the separator which isn't escaped
+// with the backslash, nor, if quoted is set, enclosed in the double quotes.
+func split(s string, sep byte, quoted bool) []string {
+	var parts []string
+	var inQuotes bool
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '"' && quoted:
+			inQuotes = !inQuotes
+		case s[i] == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\,`, ",", `\=`, "=", `\ `, " ", `\\`, `\`).Replace(s)
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/senml"
+)
+
+// tokenPrefix is the authorization scheme used by the Influx clients. The
+// token is the client secret.
+const tokenPrefix = "Token "
+
+var (
+	remoteWriteRegExp  = regexp.MustCompile(`^\/?channels\/([\w\-]+)\/prometheus\/write$`)
+	lineProtocolRegExp = regexp.MustCompile(`^\/?channels\/([\w\-]+)\/influx(\/api\/v2)?\/write$`)
+)
+
+// errIngestTooLarge indicates the ingestion request which exceeds the max
+// ingestion size, either compressed or decompressed.
+var errIngestTooLarge = errors.New("ingestion request is too large")
+
+// converter converts the payload of the ingestion request to SenML.
+type converter func(payload []byte) (senml.Pack, error)
+
+// parseIngestTopic returns the channel ID and the converter of the Prometheus
+// remote write and the Influx line protocol topics, and false for the other
+// topics. The query of the topic holds the precision of the Influx timestamps.
+// The decompressed remote write requests are limited to the max size.
+func parseIngestTopic(topic string, query url.Values, maxSize int64) (string, converter, bool) {
+	if parts := remoteWriteRegExp.FindStringSubmatch(topic); parts != nil {
+		return parts[1], func(payload []byte) (senml.Pack, error) {
+			return fromRemoteWrite(payload, maxSize)
+		}, true
+	}
+	if parts := lineProtocolRegExp.FindStringSubmatch(topic); parts != nil {
+		precision := query.Get("precision")
+		return parts[1], func(payload []byte) (senml.Pack, error) {
+			return fromLineProtocol(payload, precision)
+		}, true
+	}
+
+	return "", nil, false
+}
+
+// LimitIngestBody rejects the Prometheus remote write and the Influx line
+// protocol requests with the body larger than the max size with the 413
+// status, before the body is read by the proxy.
+func LimitIngestBody(next http.Handler, maxSize int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !remoteWriteRegExp.MatchString(r.URL.Path) && !lineProtocolRegExp.MatchString(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.ContentLength > maxSize {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSize+1))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if int64(len(body)) > maxSize {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
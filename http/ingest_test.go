@@ -0,0 +1,298 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mghttp "github.com/hantdev/hermina/pkg/http"
+	"github.com/hantdev/hermina/pkg/session"
+	mhttp "github.com/hantdev/mitras/http"
+	grpcChannelsV1 "github.com/hantdev/mitras/internal/grpc/channels/v1"
+	grpcClientsV1 "github.com/hantdev/mitras/internal/grpc/clients/v1"
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/mitras/pkg/messaging"
+	"github.com/klauspost/compress/s2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var (
+	errMalformedRemoteWrite  = errors.New("malformed prometheus remote write request")
+	errMalformedLineProtocol = errors.New("malformed influx line protocol")
+	errInvalidPrecision      = errors.New("invalid timestamp precision")
+	errMissingMetricName     = errors.New("missing metric name")
+	errIngestTooLarge        = errors.New("ingestion request is too large")
+)
+
+type series struct {
+	labels  map[string]string
+	samples map[int64]float64
+}
+
+// remoteWrite returns the snappy compressed Prometheus remote write request.
+func remoteWrite(ss ...series) []byte {
+	var req []byte
+	for _, s := range ss {
+		var ts []byte
+		for k, v := range s.labels {
+			var l []byte
+			l = protowire.AppendTag(l, 1, protowire.BytesType)
+			l = protowire.AppendString(l, k)
+			l = protowire.AppendTag(l, 2, protowire.BytesType)
+			l = protowire.AppendString(l, v)
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, l)
+		}
+		for t, v := range s.samples {
+			var smp []byte
+			smp = protowire.AppendTag(smp, 1, protowire.Fixed64Type)
+			smp = protowire.AppendFixed64(smp, math.Float64bits(v))
+			smp = protowire.AppendTag(smp, 2, protowire.VarintType)
+			smp = protowire.AppendVarint(smp, uint64(t))
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, smp)
+		}
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, ts)
+	}
+
+	return s2.EncodeSnappy(nil, req)
+}
+
+func TestIngest(t *testing.T) {
+	handler := newHandler()
+
+	promTopic := fmt.Sprintf("channels/%s/prometheus/write", chanID)
+	influxTopic := fmt.Sprintf("channels/%s/influx/write?db=mitras&precision=s", chanID)
+	influxV2Topic := fmt.Sprintf("channels/%s/influx/api/v2/write?org=mitras&bucket=mitras&precision=ms", chanID)
+	invalidPrecisionTopic := fmt.Sprintf("channels/%s/influx/write?precision=d", chanID)
+
+	promPayload := remoteWrite(
+		series{
+			labels:  map[string]string{"__name__": "node_load1", "instance": "localhost:9100", "job": "node"},
+			samples: map[int64]float64{1700000000000: 0.5},
+		},
+		series{
+			labels:  map[string]string{"__name__": "up"},
+			samples: map[int64]float64{1700000000000: math.NaN()},
+		},
+	)
+	promWithoutName := remoteWrite(series{
+		labels:  map[string]string{"job": "node"},
+		samples: map[int64]float64{1700000000000: 1},
+	})
+	influxPayload := []byte("# comment\n" +
+		`cpu,host=server\ 1,cpu=cpu0 usage_idle=99.5,count=3i,ok=true,state="running \"fast\"" 1700000000` + "\n" +
+		"mem used=10u\n")
+	influxV2Payload := []byte("temperature,room=kitchen value=21.5 1700000000000")
+
+	cases := []struct {
+		desc     string
+		topic    string
+		payload  []byte
+		password string
+		status   int
+		publish  bool
+		expected string
+		err      error
+	}{
+		{
+			desc:     "ingest prometheus remote write",
+			topic:    promTopic,
+			payload:  promPayload,
+			password: "Client " + clientKey,
+			publish:  true,
+			expected: `[{"n":"node_load1/instance:localhost_9100/job:node","t":1700000000,"v":0.5}]`,
+		},
+		{
+			desc:     "ingest malformed prometheus remote write",
+			topic:    promTopic,
+			payload:  []byte("invalid"),
+			password: "Client " + clientKey,
+			status:   http.StatusBadRequest,
+			err:      errors.Wrap(errMalformedRemoteWrite, s2.ErrCorrupt),
+		},
+		{
+			desc:     "ingest prometheus remote write without metric name",
+			topic:    promTopic,
+			payload:  promWithoutName,
+			password: "Client " + clientKey,
+			status:   http.StatusBadRequest,
+			err:      errors.Wrap(errMalformedRemoteWrite, errMissingMetricName),
+		},
+		{
+			desc:     "ingest prometheus remote write larger than max size",
+			topic:    promTopic,
+			payload:  make([]byte, maxIngestSize+1),
+			password: "Client " + clientKey,
+			status:   http.StatusRequestEntityTooLarge,
+			err:      errIngestTooLarge,
+		},
+		{
+			desc:     "ingest prometheus remote write decompressed to more than max size",
+			topic:    promTopic,
+			payload:  s2.EncodeSnappy(nil, make([]byte, 10*maxIngestSize)),
+			password: "Client " + clientKey,
+			status:   http.StatusRequestEntityTooLarge,
+			err:      errIngestTooLarge,
+		},
+		{
+			desc:     "ingest influx line protocol",
+			topic:    influxTopic,
+			payload:  influxPayload,
+			password: "Client " + clientKey,
+			publish:  true,
+			expected: `[{"n":"cpu_usage_idle/cpu:cpu0/host:server_1","t":1700000000,"v":99.5},` +
+				`{"n":"cpu_count/cpu:cpu0/host:server_1","t":1700000000,"v":3},` +
+				`{"n":"cpu_ok/cpu:cpu0/host:server_1","t":1700000000,"vb":true},` +
+				`{"n":"cpu_state/cpu:cpu0/host:server_1","t":1700000000,"vs":"running \"fast\""},` +
+				`{"n":"mem_used","v":10}]`,
+		},
+		{
+			desc:     "ingest influx v2 line protocol with token",
+			topic:    influxV2Topic,
+			payload:  influxV2Payload,
+			password: "Token " + clientKey,
+			publish:  true,
+			expected: `[{"n":"temperature_value/room:kitchen","t":1700000000,"v":21.5}]`,
+		},
+		{
+			desc:     "ingest influx line protocol without points",
+			topic:    influxTopic,
+			payload:  []byte("# comment\n"),
+			password: "Client " + clientKey,
+		},
+		{
+			desc:     "ingest malformed influx line protocol",
+			topic:    influxTopic,
+			payload:  []byte("cpu usage_idle"),
+			password: "Client " + clientKey,
+			status:   http.StatusBadRequest,
+			err:      errors.Wrap(errMalformedLineProtocol, errors.Wrap(errors.New("line 1"), errors.New(`invalid field "usage_idle"`))),
+		},
+		{
+			desc:     "ingest influx line protocol with invalid field value",
+			topic:    influxTopic,
+			payload:  []byte("cpu usage_idle=NaN"),
+			password: "Client " + clientKey,
+			status:   http.StatusBadRequest,
+			err:      errors.Wrap(errMalformedLineProtocol, errors.Wrap(errors.New("line 1"), errors.New("invalid float value NaN"))),
+		},
+		{
+			desc:     "ingest influx line protocol larger than max size",
+			topic:    influxTopic,
+			payload:  bytes.Repeat([]byte("a"), maxIngestSize+1),
+			password: "Client " + clientKey,
+			status:   http.StatusRequestEntityTooLarge,
+			err:      errIngestTooLarge,
+		},
+		{
+			desc:     "ingest influx line protocol with invalid precision",
+			topic:    invalidPrecisionTopic,
+			payload:  influxV2Payload,
+			password: "Client " + clientKey,
+			status:   http.StatusBadRequest,
+			err:      errInvalidPrecision,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := session.NewContext(context.TODO(), &session.Session{Password: []byte(tc.password)})
+			clientsCall := clients.On("Authenticate", ctx, &grpcClientsV1.AuthnReq{ClientSecret: clientKey}).Return(&grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true}, nil)
+			channelsCall := channels.On("Authorize", ctx, mock.Anything).Return(&grpcChannelsV1.AuthzRes{Authorized: true}, nil)
+			var published *messaging.Message
+			repoCall := publisher.On("Publish", ctx, chanID, mock.Anything).Run(func(args mock.Arguments) {
+				published = args.Get(2).(*messaging.Message)
+			}).Return(nil)
+			topic := tc.topic
+			payload := tc.payload
+			err := handler.Publish(ctx, &topic, &payload)
+			if hpe, ok := err.(mghttp.HTTPProxyError); ok {
+				assert.Equal(t, tc.status, hpe.StatusCode())
+			}
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("expected: %v, got: %v", tc.err, err))
+			if !tc.publish {
+				assert.Nil(t, published, "expected message not to be published")
+			} else if assert.NotNil(t, published, "expected message to be published") {
+				assert.JSONEq(t, tc.expected, string(published.Payload))
+				assert.Equal(t, clientID, published.Publisher)
+				assert.Equal(t, "", published.Subtopic)
+			}
+			repoCall.Unset()
+			clientsCall.Unset()
+			channelsCall.Unset()
+		})
+	}
+}
+
+func TestLimitIngestBody(t *testing.T) {
+	var received []byte
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := mhttp.LimitIngestBody(next, maxIngestSize)
+
+	promTopic := fmt.Sprintf("/channels/%s/prometheus/write", chanID)
+	influxTopic := fmt.Sprintf("/channels/%s/influx/api/v2/write?precision=s", chanID)
+	large := strings.Repeat("a", maxIngestSize+1)
+
+	cases := []struct {
+		desc    string
+		url     string
+		body    io.Reader
+		chunked bool
+		status  int
+	}{
+		{
+			desc:   "prometheus remote write within max size",
+			url:    promTopic,
+			body:   strings.NewReader("payload"),
+			status: http.StatusOK,
+		},
+		{
+			desc:   "prometheus remote write larger than max size",
+			url:    promTopic,
+			body:   strings.NewReader(large),
+			status: http.StatusRequestEntityTooLarge,
+		},
+		{
+			desc:    "influx line protocol larger than max size without content length",
+			url:     influxTopic,
+			body:    strings.NewReader(large),
+			chunked: true,
+			status:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			desc:   "publish larger than max size",
+			url:    fmt.Sprintf("/channels/%s/messages", chanID),
+			body:   strings.NewReader(large),
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			received = nil
+			req := httptest.NewRequest(http.MethodPost, tc.url, tc.body)
+			if tc.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			assert.Equal(t, tc.status, w.Code, fmt.Sprintf("%s: expected status %d got %d", tc.desc, tc.status, w.Code))
+			if tc.status == http.StatusOK {
+				assert.NotEmpty(t, received, fmt.Sprintf("%s: expected body to be forwarded", tc.desc))
+			}
+		})
+	}
+}
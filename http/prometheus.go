@@ -0,0 +1,244 @@
+package http
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/senml"
+	"github.com/klauspost/compress/s2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the Prometheus remote write protobuf messages.
+const (
+	timeSeriesField = 1
+	labelsField     = 1
+	samplesField    = 2
+	labelNameField  = 1
+	labelValueField = 2
+	valueField      = 1
+	timestampField  = 2
+)
+
+const metricNameLabel = "__name__"
+
+var (
+	errMalformedRemoteWrite = errors.New("malformed prometheus remote write request")
+	errMissingMetricName    = errors.New("missing metric name")
+)
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	value     float64
+	timestamp int64
+}
+
+// fromRemoteWrite converts the snappy compressed Prometheus remote write
+// request to SenML. Each sample is converted to the record named after the
+// metric name and its labels, and the samples which can't be represented in
+// SenML, such as the staleness markers, are skipped. Requests which decompress
+// to more than the max size are rejected before they are decompressed.
+func fromRemoteWrite(payload []byte, maxSize int64) (senml.Pack, error) {
+	size, err := s2.DecodedLen(payload)
+	if err != nil {
+		return senml.Pack{}, errors.Wrap(errMalformedRemoteWrite, err)
+	}
+	if int64(size) > maxSize {
+		return senml.Pack{}, errIngestTooLarge
+	}
+	data, err := s2.Decode(nil, payload)
+	if err != nil {
+		return senml.Pack{}, errors.Wrap(errMalformedRemoteWrite, err)
+	}
+
+	var pack senml.Pack
+	err = parseMessage(data, func(num protowire.Number, b []byte) error {
+		if num != timeSeriesField {
+			return nil
+		}
+		recs, err := parseTimeSeries(b)
+		if err != nil {
+			return err
+		}
+		pack.Records = append(pack.Records, recs...)
+		return nil
+	})
+	if err != nil {
+		return senml.Pack{}, errors.Wrap(errMalformedRemoteWrite, err)
+	}
+
+	return pack, nil
+}
+
+func parseTimeSeries(data []byte) ([]senml.Record, error) {
+	var labels []label
+	var samples []sample
+	err := parseMessage(data, func(num protowire.Number, b []byte) error {
+		switch num {
+		case labelsField:
+			l, err := parseLabel(b)
+			if err != nil {
+				return err
+			}
+			labels = append(labels, l)
+		case samplesField:
+			s, err := parseSample(b)
+			if err != nil {
+				return err
+			}
+			samples = append(samples, s)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var metric string
+	tags := make(map[string]string)
+	for _, l := range labels {
+		if l.name == metricNameLabel {
+			metric = l.value
+			continue
+		}
+		tags[l.name] = l.value
+	}
+	if metric == "" {
+		return nil, errMissingMetricName
+	}
+	name := recordName(metric, tags)
+
+	recs := make([]senml.Record, 0, len(samples))
+	for _, s := range samples {
+		if math.IsNaN(s.value) || math.IsInf(s.value, 0) {
+			continue
+		}
+		value := s.value
+		recs = append(recs, senml.Record{
+			Name:  name,
+			Time:  float64(s.timestamp) / 1e3,
+			Value: &value,
+		})
+	}
+
+	return recs, nil
+}
+
+func parseLabel(data []byte) (label, error) {
+	var l label
+	err := parseMessage(data, func(num protowire.Number, b []byte) error {
+		switch num {
+		case labelNameField:
+			l.name = string(b)
+		case labelValueField:
+			l.value = string(b)
+		}
+		return nil
+	})
+
+	return l, err
+}
+
+func parseSample(data []byte) (sample, error) {
+	var s sample
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return sample{}, protowire.ParseError(n)
+		}
+		data = data[n:]
+		switch {
+		case num == valueField && typ == protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(data)
+			if n < 0 {
+				return sample{}, protowire.ParseError(n)
+			}
+			s.value = math.Float64frombits(v)
+			data = data[n:]
+		case num == timestampField && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return sample{}, protowire.ParseError(n)
+			}
+			s.timestamp = int64(v)
+			data = data[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return sample{}, protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+
+	return s, nil
+}
+
+// parseMessage calls the handler with the length delimited fields of the
+// protobuf message, and skips the fields of the other types.
+func parseMessage(data []byte, handle func(num protowire.Number, b []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if typ != protowire.BytesType {
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			continue
+		}
+		b, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if err := handle(num, b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordName returns the SenML record name of the metric and its tags in
+// the format <metric>/<tag>:<value>/..., with the tags sorted by the name.
+// The characters which aren't allowed in SenML names are replaced with "_".
+func recordName(metric string, tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(sanitize(metric))
+	for _, k := range keys {
+		sb.WriteString("/")
+		sb.WriteString(sanitize(k))
+		sb.WriteString(":")
+		sb.WriteString(sanitize(tags[k]))
+	}
+
+	return sb.String()
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
@@ -0,0 +1,303 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hantdev/mitras"
+	grpcChannelsV1 "github.com/hantdev/mitras/internal/grpc/channels/v1"
+	grpcClientsV1 "github.com/hantdev/mitras/internal/grpc/clients/v1"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/connections"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/mitras/pkg/messaging"
+	"github.com/hantdev/mitras/pkg/policies"
+)
+
+const chansPrefix = "channels"
+
+var (
+	// ErrFailedSubscription indicates that the stream couldn't subscribe to the channel.
+	ErrFailedSubscription = errors.New("failed to subscribe to a channel")
+
+	// ErrEmptyTopic indicates absence of the channel in the request.
+	ErrEmptyTopic = errors.New("empty topic")
+)
+
+// Event is the message of the stream identified by the event ID.
+type Event struct {
+	ID      string
+	Message *messaging.Message
+	seq     uint64
+}
+
+// Subscription is the subscription of the server-sent events stream to the
+// channel and subtopic. Events channel is closed when the subscription is
+// cancelled or when the subscriber falls behind the stream.
+type Subscription struct {
+	Events <-chan Event
+	events chan Event
+	stream *stream
+}
+
+// Service specifies the API of the server-sent events streams.
+type Service interface {
+	// Subscribe authorizes the token to subscribe to the channel and subscribes
+	// to the channel and subtopic. Buffered events following the lastEventID
+	// are delivered before the new events.
+	Subscribe(ctx context.Context, token, chanID, subtopic, lastEventID string) (*Subscription, error)
+
+	// Unsubscribe cancels the subscription.
+	Unsubscribe(ctx context.Context, sub *Subscription) error
+}
+
+var _ Service = (*streamService)(nil)
+
+// stream is the subscription to the message broker shared by the subscribers
+// of the same channel and subtopic. Stream buffers the last events, so the
+// subscribers can resume after the reconnect, and it's kept for the retention
+// period after the last subscriber leaves.
+type stream struct {
+	id          string
+	topic       string
+	seq         uint64
+	events      []Event
+	subscribers map[*Subscription]struct{}
+	expiry      *time.Timer
+	generation  uint64
+	svc         *streamService
+}
+
+type streamService struct {
+	pubsub     messaging.Subscriber
+	authn      smqauthn.Authentication
+	clients    grpcClientsV1.ClientsServiceClient
+	channels   grpcChannelsV1.ChannelsServiceClient
+	idProvider mitras.IDProvider
+	bufferSize int
+	retention  time.Duration
+	mu         sync.Mutex
+	streams    map[string]*stream
+}
+
+// NewService instantiates the server-sent events streams of the HTTP adapter.
+// Each stream buffers up to bufferSize events, which are kept for the retention
+// period after the last subscriber leaves.
+func NewService(pubsub messaging.Subscriber, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient, idProvider mitras.IDProvider, bufferSize int, retention time.Duration) Service {
+	return &streamService{
+		pubsub:     pubsub,
+		authn:      authn,
+		clients:    clients,
+		channels:   channels,
+		idProvider: idProvider,
+		bufferSize: bufferSize,
+		retention:  retention,
+		streams:    make(map[string]*stream),
+	}
+}
+
+func (svc *streamService) Subscribe(ctx context.Context, token, chanID, subtopic, lastEventID string) (*Subscription, error) {
+	if chanID == "" {
+		return nil, ErrEmptyTopic
+	}
+	if err := svc.authorize(ctx, token, chanID); err != nil {
+		return nil, err
+	}
+
+	topic := fmt.Sprintf("%s.%s", chansPrefix, chanID)
+	if subtopic != "" {
+		topic = fmt.Sprintf("%s.%s", topic, subtopic)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	st, ok := svc.streams[topic]
+	if !ok {
+		id, err := svc.idProvider.ID()
+		if err != nil {
+			return nil, err
+		}
+		st = &stream{
+			id:          id,
+			topic:       topic,
+			subscribers: make(map[*Subscription]struct{}),
+			svc:         svc,
+		}
+		subCfg := messaging.SubscriberConfig{
+			ID:      fmt.Sprintf("%s-%s", protocol, id),
+			Topic:   topic,
+			Handler: st,
+		}
+		if err := svc.pubsub.Subscribe(ctx, subCfg); err != nil {
+			return nil, errors.Wrap(ErrFailedSubscription, err)
+		}
+		svc.streams[topic] = st
+	}
+	if st.expiry != nil {
+		st.expiry.Stop()
+		st.expiry = nil
+	}
+
+	// Buffered events are delivered first, so the events channel has room for
+	// the whole buffer and as many new events.
+	events := make(chan Event, 2*svc.bufferSize)
+	sub := &Subscription{
+		Events: events,
+		events: events,
+		stream: st,
+	}
+	if streamID, seq, ok := parseEventID(lastEventID); ok && streamID == st.id {
+		for _, ev := range st.events {
+			if ev.seq > seq {
+				events <- ev
+			}
+		}
+	}
+	st.subscribers[sub] = struct{}{}
+
+	return sub, nil
+}
+
+func (svc *streamService) Unsubscribe(ctx context.Context, sub *Subscription) error {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	st := sub.stream
+	if _, ok := st.subscribers[sub]; ok {
+		st.remove(sub)
+	}
+
+	return nil
+}
+
+// Handle buffers the message and delivers it to the stream subscribers.
+// Subscribers which fall behind the stream are dropped, and they can resume
+// from the buffer using the last received event ID.
+func (st *stream) Handle(msg *messaging.Message) error {
+	st.svc.mu.Lock()
+	defer st.svc.mu.Unlock()
+
+	st.seq++
+	ev := Event{
+		ID:      fmt.Sprintf("%s:%d", st.id, st.seq),
+		Message: msg,
+		seq:     st.seq,
+	}
+	st.events = append(st.events, ev)
+	if len(st.events) > st.svc.bufferSize {
+		st.events = st.events[len(st.events)-st.svc.bufferSize:]
+	}
+
+	for sub := range st.subscribers {
+		select {
+		case sub.events <- ev:
+		default:
+			st.remove(sub)
+		}
+	}
+
+	return nil
+}
+
+// Cancel is called when the broker subscription is cancelled.
+func (st *stream) Cancel() error {
+	return nil
+}
+
+// remove closes the subscription and schedules the expiry of the stream once
+// it has no subscribers. It must be called with the service lock held.
+func (st *stream) remove(sub *Subscription) {
+	delete(st.subscribers, sub)
+	close(sub.events)
+	if len(st.subscribers) > 0 {
+		return
+	}
+
+	st.generation++
+	generation := st.generation
+	st.expiry = time.AfterFunc(st.svc.retention, func() {
+		st.svc.expire(st, generation)
+	})
+}
+
+// expire drops the stream unless it was resubscribed after the expiry of the
+// given generation was scheduled.
+func (svc *streamService) expire(st *stream, generation uint64) {
+	svc.mu.Lock()
+	if st.expiry == nil || st.generation != generation || svc.streams[st.topic] != st {
+		svc.mu.Unlock()
+		return
+	}
+	delete(svc.streams, st.topic)
+	svc.mu.Unlock()
+
+	// The broker subscription is cancelled without the lock held, since the
+	// broker may wait for the messages being handled by the stream. Errors are
+	// ignored, since the stream is already dropped.
+	_ = svc.pubsub.Unsubscribe(context.Background(), fmt.Sprintf("%s-%s", protocol, st.id), st.topic)
+}
+
+// authorize checks if the client secret or the user token is allowed to
+// subscribe to the channel. The stream only reads the messages, so the
+// read-only impersonation is allowed to subscribe.
+func (svc *streamService) authorize(ctx context.Context, token, chanID string) error {
+	var clientID, clientType string
+	switch {
+	case strings.HasPrefix(token, apiutil.ClientPrefix):
+		secret := strings.TrimPrefix(token, apiutil.ClientPrefix)
+		authnRes, err := svc.clients.Authenticate(ctx, &grpcClientsV1.AuthnReq{ClientSecret: secret})
+		if err != nil {
+			return errors.Wrap(svcerr.ErrAuthentication, err)
+		}
+		if !authnRes.GetAuthenticated() {
+			return svcerr.ErrAuthentication
+		}
+		clientType = policies.ClientType
+		clientID = authnRes.GetId()
+	case strings.HasPrefix(token, apiutil.BearerPrefix):
+		authnSession, err := svc.authn.Authenticate(ctx, strings.TrimPrefix(token, apiutil.BearerPrefix))
+		if err != nil {
+			return errors.Wrap(svcerr.ErrAuthentication, err)
+		}
+		clientType = policies.UserType
+		clientID = authnSession.DomainUserID
+	default:
+		return svcerr.ErrAuthentication
+	}
+
+	res, err := svc.channels.Authorize(ctx, &grpcChannelsV1.AuthzReq{
+		ClientId:   clientID,
+		ClientType: clientType,
+		ChannelId:  chanID,
+		Type:       uint32(connections.Subscribe),
+	})
+	if err != nil {
+		return errors.Wrap(svcerr.ErrAuthorization, err)
+	}
+	if !res.GetAuthorized() {
+		return svcerr.ErrAuthorization
+	}
+
+	return nil
+}
+
+// parseEventID splits the event ID into the stream ID and the sequence number.
+func parseEventID(id string) (string, uint64, bool) {
+	streamID, seq, ok := strings.Cut(id, ":")
+	if !ok {
+		return "", 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+
+	return streamID, n, true
+}
@@ -0,0 +1,274 @@
+package http_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	chmocks "github.com/hantdev/mitras/channels/mocks"
+	clmocks "github.com/hantdev/mitras/clients/mocks"
+	mhttp "github.com/hantdev/mitras/http"
+	grpcChannelsV1 "github.com/hantdev/mitras/internal/grpc/channels/v1"
+	grpcClientsV1 "github.com/hantdev/mitras/internal/grpc/clients/v1"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	authnmocks "github.com/hantdev/mitras/pkg/authn/mocks"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/mitras/pkg/messaging"
+	"github.com/hantdev/mitras/pkg/messaging/mocks"
+	"github.com/hantdev/mitras/pkg/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	bufferSize = 3
+	retention  = 100 * time.Millisecond
+)
+
+func newStreamService() (mhttp.Service, *mocks.PubSub) {
+	authn = new(authnmocks.Authentication)
+	clients = new(clmocks.ClientsServiceClient)
+	channels = new(chmocks.ChannelsServiceClient)
+	pubsub := new(mocks.PubSub)
+
+	return mhttp.NewService(pubsub, authn, clients, channels, uuid.NewMock(), bufferSize, retention), pubsub
+}
+
+func TestSubscribe(t *testing.T) {
+	svc, pubsub := newStreamService()
+
+	cases := []struct {
+		desc         string
+		token        string
+		chanID       string
+		subtopic     string
+		authNRes     *grpcClientsV1.AuthnRes
+		authNRes1    smqauthn.Session
+		authNErr     error
+		authZRes     *grpcChannelsV1.AuthzRes
+		authZErr     error
+		subscribeErr error
+		topic        string
+		err          error
+	}{
+		{
+			desc:     "subscribe with client key successfully",
+			token:    apiutil.ClientPrefix + clientKey,
+			chanID:   chanID,
+			authNRes: &grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true},
+			authZRes: &grpcChannelsV1.AuthzRes{Authorized: true},
+			topic:    "channels." + chanID,
+			err:      nil,
+		},
+		{
+			desc:      "subscribe to subtopic with token successfully",
+			token:     apiutil.BearerPrefix + validToken,
+			chanID:    chanID,
+			subtopic:  "subtopic.*",
+			authNRes1: smqauthn.Session{DomainUserID: validID, UserID: validID, DomainID: validID},
+			authZRes:  &grpcChannelsV1.AuthzRes{Authorized: true},
+			topic:     "channels." + chanID + ".subtopic.*",
+			err:       nil,
+		},
+		{
+			desc:      "subscribe with read-only impersonation token successfully",
+			token:     apiutil.BearerPrefix + validToken,
+			chanID:    chanID,
+			subtopic:  "readonly",
+			authNRes1: smqauthn.Session{DomainUserID: validID, UserID: validID, DomainID: validID, ImpersonatorID: impersonatorID, ReadOnly: true},
+			authZRes:  &grpcChannelsV1.AuthzRes{Authorized: true},
+			topic:     "channels." + chanID + ".readonly",
+			err:       nil,
+		},
+		{
+			desc:   "subscribe with empty channel",
+			token:  apiutil.ClientPrefix + clientKey,
+			chanID: "",
+			err:    mhttp.ErrEmptyTopic,
+		},
+		{
+			desc:   "subscribe with token without prefix",
+			token:  clientKey,
+			chanID: chanID,
+			err:    svcerr.ErrAuthentication,
+		},
+		{
+			desc:     "subscribe with invalid client key",
+			token:    apiutil.ClientPrefix + invalidValue,
+			chanID:   chanID,
+			authNRes: &grpcClientsV1.AuthnRes{Authenticated: false},
+			err:      svcerr.ErrAuthentication,
+		},
+		{
+			desc:     "subscribe with invalid token",
+			token:    apiutil.BearerPrefix + invalidValue,
+			chanID:   chanID,
+			authNErr: svcerr.ErrAuthentication,
+			err:      svcerr.ErrAuthentication,
+		},
+		{
+			desc:     "subscribe with unauthorized client",
+			token:    apiutil.ClientPrefix + clientKey,
+			chanID:   chanID,
+			authNRes: &grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true},
+			authZRes: &grpcChannelsV1.AuthzRes{Authorized: false},
+			err:      svcerr.ErrAuthorization,
+		},
+		{
+			desc:     "subscribe with authorization error",
+			token:    apiutil.ClientPrefix + clientKey,
+			chanID:   chanID,
+			authNRes: &grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true},
+			authZRes: &grpcChannelsV1.AuthzRes{Authorized: false},
+			authZErr: svcerr.ErrAuthorization,
+			err:      svcerr.ErrAuthorization,
+		},
+		{
+			desc:         "subscribe with failed broker subscription",
+			token:        apiutil.ClientPrefix + clientKey,
+			chanID:       chanID,
+			subtopic:     "failed",
+			authNRes:     &grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true},
+			authZRes:     &grpcChannelsV1.AuthzRes{Authorized: true},
+			subscribeErr: errors.New("failed to subscribe"),
+			topic:        "channels." + chanID + ".failed",
+			err:          mhttp.ErrFailedSubscription,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := context.TODO()
+			clientsCall := clients.On("Authenticate", ctx, mock.Anything).Return(tc.authNRes, tc.authNErr)
+			authCall := authn.On("Authenticate", ctx, mock.Anything).Return(tc.authNRes1, tc.authNErr)
+			channelsCall := channels.On("Authorize", ctx, mock.Anything).Return(tc.authZRes, tc.authZErr)
+			repoCall := pubsub.On("Subscribe", ctx, mock.Anything).Return(tc.subscribeErr)
+			sub, err := svc.Subscribe(ctx, tc.token, tc.chanID, tc.subtopic, "")
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected: %v, got: %v", tc.desc, tc.err, err))
+			if tc.topic != "" {
+				ok := repoCall.Parent.AssertCalled(t, "Subscribe", ctx, mock.MatchedBy(func(cfg messaging.SubscriberConfig) bool {
+					return cfg.Topic == tc.topic
+				}))
+				assert.True(t, ok, fmt.Sprintf("%s: expected subscription to topic %s", tc.desc, tc.topic))
+			}
+			if err == nil {
+				assert.NotNil(t, sub, fmt.Sprintf("%s: expected subscription", tc.desc))
+			}
+			authCall.Unset()
+			repoCall.Unset()
+			clientsCall.Unset()
+			channelsCall.Unset()
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	svc, pubsub := newStreamService()
+	ctx := context.TODO()
+	token := apiutil.ClientPrefix + clientKey
+
+	clients.On("Authenticate", ctx, mock.Anything).Return(&grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true}, nil)
+	channels.On("Authorize", ctx, mock.Anything).Return(&grpcChannelsV1.AuthzRes{Authorized: true}, nil)
+	handlers := make(chan messaging.MessageHandler, 1)
+	pubsub.On("Subscribe", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		handlers <- args.Get(1).(messaging.SubscriberConfig).Handler
+	}).Return(nil).Once()
+	unsubscribed := make(chan string, 1)
+	pubsub.On("Unsubscribe", mock.Anything, mock.Anything, "channels."+chanID).Run(func(args mock.Arguments) {
+		unsubscribed <- args.String(2)
+	}).Return(nil)
+
+	sub, err := svc.Subscribe(ctx, token, chanID, "", "")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	handler := <-handlers
+
+	// Subscribers of the same topic share the broker subscription.
+	sub1, err := svc.Subscribe(ctx, token, chanID, "", "")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	var ids []string
+	for i := 0; i < 2*bufferSize; i++ {
+		err := handler.Handle(&messaging.Message{Channel: chanID, Payload: []byte(fmt.Sprintf("%d", i))})
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		ev := <-sub.Events
+		assert.Equal(t, []byte(fmt.Sprintf("%d", i)), ev.Message.GetPayload(), "got unexpected event payload")
+		ids = append(ids, ev.ID)
+	}
+	_, ok := <-sub1.Events
+	assert.True(t, ok, "expected buffered event of the second subscriber")
+
+	cases := []struct {
+		desc        string
+		lastEventID string
+		payloads    []string
+	}{
+		{
+			desc:        "resume from buffered event",
+			lastEventID: ids[len(ids)-2],
+			payloads:    []string{"5"},
+		},
+		{
+			desc:        "resume from event older than buffer",
+			lastEventID: ids[0],
+			payloads:    []string{"3", "4", "5"},
+		},
+		{
+			desc:        "resume from last event",
+			lastEventID: ids[len(ids)-1],
+			payloads:    []string{},
+		},
+		{
+			desc:        "resume from event of another stream",
+			lastEventID: "stream:1",
+			payloads:    []string{},
+		},
+		{
+			desc:        "resume from invalid event ID",
+			lastEventID: invalidValue,
+			payloads:    []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			resumed, err := svc.Subscribe(ctx, token, chanID, "", tc.lastEventID)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+			payloads := []string{}
+			for len(resumed.Events) > 0 {
+				ev := <-resumed.Events
+				payloads = append(payloads, string(ev.Message.GetPayload()))
+			}
+			assert.Equal(t, tc.payloads, payloads, fmt.Sprintf("%s: got unexpected replayed events", tc.desc))
+			err = svc.Unsubscribe(ctx, resumed)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+			_, ok := <-resumed.Events
+			assert.False(t, ok, fmt.Sprintf("%s: expected closed events", tc.desc))
+		})
+	}
+
+	// The second subscriber doesn't read its events, so it's dropped once it
+	// falls behind the stream.
+	for i := 0; i < 2*bufferSize; i++ {
+		err := handler.Handle(&messaging.Message{Channel: chanID, Payload: []byte("payload")})
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		<-sub.Events
+	}
+	for range sub1.Events {
+	}
+
+	err = svc.Unsubscribe(ctx, sub)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	err = svc.Unsubscribe(ctx, sub1)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	select {
+	case topic := <-unsubscribed:
+		assert.Equal(t, "channels."+chanID, topic, "got unexpected unsubscribed topic")
+	case <-time.After(10 * retention):
+		assert.Fail(t, "expected broker unsubscribe after the retention period")
+	}
+}
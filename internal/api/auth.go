@@ -7,11 +7,13 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/hantdev/mitras/pkg/apiutil"
 	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
 )
 
-type sessionKeyType string
+const SessionKey = smqauthn.SessionKey
 
-const SessionKey = sessionKeyType("session")
+var errReadOnly = errors.New("read-only impersonation can't modify the entities")
 
 func AuthenticateMiddleware(authn smqauthn.Authentication, domainCheck bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -28,6 +30,18 @@ func AuthenticateMiddleware(authn smqauthn.Authentication, domainCheck bool) fun
 				return
 			}
 
+			// The service account acts only in the domain owning it.
+			if resp.ServiceAccount && (!domainCheck || chi.URLParam(r, "domainID") != resp.DomainID) {
+				EncodeError(r.Context(), svcerr.ErrDomainAuthorization, w)
+				return
+			}
+
+			// The read-only impersonation is limited to the safe methods.
+			if resp.ReadOnly && !safeMethod(r.Method) {
+				EncodeError(r.Context(), errors.Wrap(svcerr.ErrAuthorization, errReadOnly), w)
+				return
+			}
+
 			if domainCheck {
 				domain := chi.URLParam(r, "domainID")
 				if domain == "" {
@@ -44,3 +58,12 @@ func AuthenticateMiddleware(authn smqauthn.Authentication, domainCheck bool) fun
 		})
 	}
 }
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
@@ -193,7 +193,21 @@ func EncodeError(_ context.Context, err error, w http.ResponseWriter) {
 		errors.Contains(err, apiutil.ErrMissingConnectionType),
 		errors.Contains(err, apiutil.ErrMissingRoleName),
 		errors.Contains(err, apiutil.ErrMissingPolicyEntityType),
-		errors.Contains(err, apiutil.ErrMissingRoleMembers):
+		errors.Contains(err, apiutil.ErrMissingRoleMembers),
+		errors.Contains(err, apiutil.ErrMissingRetention),
+		errors.Contains(err, apiutil.ErrInvalidRetention),
+		errors.Contains(err, apiutil.ErrInvalidInterval),
+		errors.Contains(err, apiutil.ErrTooManyBuckets),
+		errors.Contains(err, apiutil.ErrMissingClientID),
+		errors.Contains(err, apiutil.ErrMissingChannelID),
+		errors.Contains(err, apiutil.ErrInvalidObjectPath),
+		errors.Contains(err, apiutil.ErrMissingMFAToken),
+		errors.Contains(err, apiutil.ErrMissingMFACode),
+		errors.Contains(err, apiutil.ErrMissingVerificationToken),
+		errors.Contains(err, apiutil.ErrMissingPasswordChangeToken),
+		errors.Contains(err, apiutil.ErrUnsupportedGrantType),
+		errors.Contains(err, apiutil.ErrMissingAssertion),
+		errors.Contains(err, apiutil.ErrMissingAddress):
 		err = unwrap(err)
 		w.WriteHeader(http.StatusBadRequest)
 
@@ -28,6 +28,7 @@ const (
 	ClientsService_RemoveConnections_FullMethodName          = "/clients.v1.ClientsService/RemoveConnections"
 	ClientsService_RemoveChannelConnections_FullMethodName   = "/clients.v1.ClientsService/RemoveChannelConnections"
 	ClientsService_UnsetParentGroupFromClient_FullMethodName = "/clients.v1.ClientsService/UnsetParentGroupFromClient"
+	ClientsService_RetrieveSecret_FullMethodName             = "/clients.v1.ClientsService/RetrieveSecret"
 )
 
 // ClientsServiceClient is the client API for ClientsService service.
@@ -45,6 +46,9 @@ type ClientsServiceClient interface {
 	RemoveConnections(ctx context.Context, in *v1.RemoveConnectionsReq, opts ...grpc.CallOption) (*v1.RemoveConnectionsRes, error)
 	RemoveChannelConnections(ctx context.Context, in *RemoveChannelConnectionsReq, opts ...grpc.CallOption) (*RemoveChannelConnectionsRes, error)
 	UnsetParentGroupFromClient(ctx context.Context, in *UnsetParentGroupFromClientReq, opts ...grpc.CallOption) (*UnsetParentGroupFromClientRes, error)
+	// RetrieveSecret retrieves the client secret used as the pre-shared key
+	// of the client.
+	RetrieveSecret(ctx context.Context, in *RetrieveSecretReq, opts ...grpc.CallOption) (*RetrieveSecretRes, error)
 }
 
 type clientsServiceClient struct {
@@ -125,6 +129,16 @@ func (c *clientsServiceClient) UnsetParentGroupFromClient(ctx context.Context, i
 	return out, nil
 }
 
+func (c *clientsServiceClient) RetrieveSecret(ctx context.Context, in *RetrieveSecretReq, opts ...grpc.CallOption) (*RetrieveSecretRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RetrieveSecretRes)
+	err := c.cc.Invoke(ctx, ClientsService_RetrieveSecret_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ClientsServiceServer is the server API for ClientsService service.
 // All implementations must embed UnimplementedClientsServiceServer
 // for forward compatibility.
@@ -140,6 +154,9 @@ type ClientsServiceServer interface {
 	RemoveConnections(context.Context, *v1.RemoveConnectionsReq) (*v1.RemoveConnectionsRes, error)
 	RemoveChannelConnections(context.Context, *RemoveChannelConnectionsReq) (*RemoveChannelConnectionsRes, error)
 	UnsetParentGroupFromClient(context.Context, *UnsetParentGroupFromClientReq) (*UnsetParentGroupFromClientRes, error)
+	// RetrieveSecret retrieves the client secret used as the pre-shared key
+	// of the client.
+	RetrieveSecret(context.Context, *RetrieveSecretReq) (*RetrieveSecretRes, error)
 	mustEmbedUnimplementedClientsServiceServer()
 }
 
@@ -171,6 +188,9 @@ func (UnimplementedClientsServiceServer) RemoveChannelConnections(context.Contex
 func (UnimplementedClientsServiceServer) UnsetParentGroupFromClient(context.Context, *UnsetParentGroupFromClientReq) (*UnsetParentGroupFromClientRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UnsetParentGroupFromClient not implemented")
 }
+func (UnimplementedClientsServiceServer) RetrieveSecret(context.Context, *RetrieveSecretReq) (*RetrieveSecretRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetrieveSecret not implemented")
+}
 func (UnimplementedClientsServiceServer) mustEmbedUnimplementedClientsServiceServer() {}
 func (UnimplementedClientsServiceServer) testEmbeddedByValue()                        {}
 
@@ -318,6 +338,24 @@ func _ClientsService_UnsetParentGroupFromClient_Handler(srv interface{}, ctx con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ClientsService_RetrieveSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetrieveSecretReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClientsServiceServer).RetrieveSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClientsService_RetrieveSecret_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClientsServiceServer).RetrieveSecret(ctx, req.(*RetrieveSecretReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ClientsService_ServiceDesc is the grpc.ServiceDesc for ClientsService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -353,6 +391,10 @@ var ClientsService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UnsetParentGroupFromClient",
 			Handler:    _ClientsService_UnsetParentGroupFromClient_Handler,
 		},
+		{
+			MethodName: "RetrieveSecret",
+			Handler:    _ClientsService_RetrieveSecret_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "clients/v1/clients.proto",
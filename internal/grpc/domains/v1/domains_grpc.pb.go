@@ -21,6 +21,9 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	DomainsService_DeleteUserFromDomains_FullMethodName = "/domains.v1.DomainsService/DeleteUserFromDomains"
+	DomainsService_RequiresMFA_FullMethodName           = "/domains.v1.DomainsService/RequiresMFA"
+	DomainsService_AddUserRole_FullMethodName           = "/domains.v1.DomainsService/AddUserRole"
+	DomainsService_RemoveUserRole_FullMethodName        = "/domains.v1.DomainsService/RemoveUserRole"
 )
 
 // DomainsServiceClient is the client API for DomainsService service.
@@ -31,6 +34,9 @@ const (
 // domains functionalities for Mitras services.
 type DomainsServiceClient interface {
 	DeleteUserFromDomains(ctx context.Context, in *DeleteUserReq, opts ...grpc.CallOption) (*DeleteUserRes, error)
+	RequiresMFA(ctx context.Context, in *RequiresMFAReq, opts ...grpc.CallOption) (*RequiresMFARes, error)
+	AddUserRole(ctx context.Context, in *AddUserRoleReq, opts ...grpc.CallOption) (*AddUserRoleRes, error)
+	RemoveUserRole(ctx context.Context, in *RemoveUserRoleReq, opts ...grpc.CallOption) (*RemoveUserRoleRes, error)
 }
 
 type domainsServiceClient struct {
@@ -51,6 +57,36 @@ func (c *domainsServiceClient) DeleteUserFromDomains(ctx context.Context, in *De
 	return out, nil
 }
 
+func (c *domainsServiceClient) RequiresMFA(ctx context.Context, in *RequiresMFAReq, opts ...grpc.CallOption) (*RequiresMFARes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RequiresMFARes)
+	err := c.cc.Invoke(ctx, DomainsService_RequiresMFA_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *domainsServiceClient) AddUserRole(ctx context.Context, in *AddUserRoleReq, opts ...grpc.CallOption) (*AddUserRoleRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddUserRoleRes)
+	err := c.cc.Invoke(ctx, DomainsService_AddUserRole_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *domainsServiceClient) RemoveUserRole(ctx context.Context, in *RemoveUserRoleReq, opts ...grpc.CallOption) (*RemoveUserRoleRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveUserRoleRes)
+	err := c.cc.Invoke(ctx, DomainsService_RemoveUserRole_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DomainsServiceServer is the server API for DomainsService service.
 // All implementations must embed UnimplementedDomainsServiceServer
 // for forward compatibility.
@@ -59,6 +95,9 @@ func (c *domainsServiceClient) DeleteUserFromDomains(ctx context.Context, in *De
 // domains functionalities for Mitras services.
 type DomainsServiceServer interface {
 	DeleteUserFromDomains(context.Context, *DeleteUserReq) (*DeleteUserRes, error)
+	RequiresMFA(context.Context, *RequiresMFAReq) (*RequiresMFARes, error)
+	AddUserRole(context.Context, *AddUserRoleReq) (*AddUserRoleRes, error)
+	RemoveUserRole(context.Context, *RemoveUserRoleReq) (*RemoveUserRoleRes, error)
 	mustEmbedUnimplementedDomainsServiceServer()
 }
 
@@ -72,6 +111,15 @@ type UnimplementedDomainsServiceServer struct{}
 func (UnimplementedDomainsServiceServer) DeleteUserFromDomains(context.Context, *DeleteUserReq) (*DeleteUserRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteUserFromDomains not implemented")
 }
+func (UnimplementedDomainsServiceServer) RequiresMFA(context.Context, *RequiresMFAReq) (*RequiresMFARes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequiresMFA not implemented")
+}
+func (UnimplementedDomainsServiceServer) AddUserRole(context.Context, *AddUserRoleReq) (*AddUserRoleRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddUserRole not implemented")
+}
+func (UnimplementedDomainsServiceServer) RemoveUserRole(context.Context, *RemoveUserRoleReq) (*RemoveUserRoleRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveUserRole not implemented")
+}
 func (UnimplementedDomainsServiceServer) mustEmbedUnimplementedDomainsServiceServer() {}
 func (UnimplementedDomainsServiceServer) testEmbeddedByValue()                        {}
 
@@ -111,6 +159,60 @@ func _DomainsService_DeleteUserFromDomains_Handler(srv interface{}, ctx context.
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DomainsService_RequiresMFA_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequiresMFAReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DomainsServiceServer).RequiresMFA(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DomainsService_RequiresMFA_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DomainsServiceServer).RequiresMFA(ctx, req.(*RequiresMFAReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DomainsService_AddUserRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddUserRoleReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DomainsServiceServer).AddUserRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DomainsService_AddUserRole_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DomainsServiceServer).AddUserRole(ctx, req.(*AddUserRoleReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DomainsService_RemoveUserRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveUserRoleReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DomainsServiceServer).RemoveUserRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DomainsService_RemoveUserRole_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DomainsServiceServer).RemoveUserRole(ctx, req.(*RemoveUserRoleReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DomainsService_ServiceDesc is the grpc.ServiceDesc for DomainsService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -122,6 +224,18 @@ var DomainsService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteUserFromDomains",
 			Handler:    _DomainsService_DeleteUserFromDomains_Handler,
 		},
+		{
+			MethodName: "RequiresMFA",
+			Handler:    _DomainsService_RequiresMFA_Handler,
+		},
+		{
+			MethodName: "AddUserRole",
+			Handler:    _DomainsService_AddUserRole_Handler,
+		},
+		{
+			MethodName: "RemoveUserRole",
+			Handler:    _DomainsService_RemoveUserRole_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "domains/v1/domains.proto",
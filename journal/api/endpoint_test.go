@@ -317,6 +317,14 @@ func TestListEntityJournalsEndpoint(t *testing.T) {
 			status:   http.StatusOK,
 			svcErr:   nil,
 		},
+		{
+			desc:     "with service account type successful",
+			token:    validToken,
+			domainID: domainID,
+			url:      "/service_account/123",
+			status:   http.StatusOK,
+			svcErr:   nil,
+		},
 		{
 			desc:     "with service error",
 			token:    validToken,
@@ -69,6 +69,7 @@ func decodeRetrieveEntityJournalReq(_ context.Context, r *http.Request) (interfa
 	}
 	page.EntityID = chi.URLParam(r, "entityID")
 	page.EntityType = entityType
+	page.Domain = chi.URLParam(r, "domainID")
 
 	if entityType == journal.ChannelEntity {
 		page.Operation = strings.ReplaceAll(page.Operation, "channel", "group")
@@ -17,6 +17,7 @@ const (
 	GroupEntity
 	ClientEntity
 	ChannelEntity
+	ServiceAccountEntity
 )
 
 // String representation of the possible entity type values.
@@ -25,6 +26,8 @@ const (
 	groupEntityType   = "group"
 	clientEntityType  = "client"
 	channelEntityType = "channel"
+
+	serviceAccountEntityType = "service_account"
 )
 
 // String converts entity type to string literal.
@@ -38,6 +41,8 @@ func (e EntityType) String() string {
 		return clientEntityType
 	case ChannelEntity:
 		return channelEntityType
+	case ServiceAccountEntity:
+		return serviceAccountEntityType
 	default:
 		return ""
 	}
@@ -52,6 +57,8 @@ func (e EntityType) AuthString() string {
 		return policies.GroupType
 	case ClientEntity:
 		return policies.ClientType
+	case ServiceAccountEntity:
+		return policies.DomainType
 	default:
 		return ""
 	}
@@ -68,6 +75,8 @@ func ToEntityType(entityType string) (EntityType, error) {
 		return ClientEntity, nil
 	case channelEntityType:
 		return ChannelEntity, nil
+	case serviceAccountEntityType:
+		return ServiceAccountEntity, nil
 	default:
 		return EntityType(0), apiutil.ErrInvalidEntityType
 	}
@@ -77,11 +86,17 @@ func ToEntityType(entityType string) (EntityType, error) {
 func (e EntityType) Query() string {
 	switch e {
 	case UserEntity:
-		return "((operation LIKE 'user.%' AND attributes->>'id' = :entity_id) OR (attributes->>'user_id' = :entity_id))"
+		// The events caused during the impersonation are in the journals of
+		// both the impersonated user and the super admin.
+		return "((operation LIKE 'user.%' AND attributes->>'id' = :entity_id) OR (attributes->>'user_id' = :entity_id) OR (attributes->>'impersonator_id' = :entity_id) OR (attributes->>'impersonator_id' IS NOT NULL AND attributes->>'actor_id' = :entity_id))"
 	case GroupEntity, ChannelEntity:
 		return "((operation LIKE 'group.%' AND attributes->>'id' = :entity_id) OR (attributes->>'group_id' = :entity_id))"
 	case ClientEntity:
 		return "((operation LIKE 'client.%' AND attributes->>'id' = :entity_id) OR (attributes->>'client_id' = :entity_id))"
+	case ServiceAccountEntity:
+		// The service account journal contains the events of the service
+		// account and the events of the actions it performed in its domain.
+		return "(((operation LIKE 'service_account.%' AND attributes->>'id' = :entity_id) OR (attributes->>'actor_id' = :entity_id)) AND COALESCE(attributes->>'actor_domain', attributes->>'domain') = :domain)"
 	default:
 		return ""
 	}
@@ -115,6 +130,7 @@ type Page struct {
 	WithMetadata   bool       `json:"with_metadata,omitempty"`
 	EntityID       string     `json:"entity_id,omitempty" db:"entity_id,omitempty"`
 	EntityType     EntityType `json:"entity_type,omitempty" db:"entity_type,omitempty"`
+	Domain         string     `json:"domain,omitempty" db:"domain,omitempty"`
 	Direction      string     `json:"direction,omitempty"`
 }
 
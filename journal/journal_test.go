@@ -85,6 +85,12 @@ func TestEntityType(t *testing.T) {
 			str:        "channel",
 			authString: "group",
 		},
+		{
+			desc:       "ServiceAccountEntity",
+			e:          journal.ServiceAccountEntity,
+			str:        "service_account",
+			authString: "domain",
+		},
 	}
 
 	for _, tc := range cases {
@@ -123,6 +129,11 @@ func TestToEntityType(t *testing.T) {
 			entityType: "channel",
 			expected:   journal.ChannelEntity,
 		},
+		{
+			desc:       "ServiceAccountEntity",
+			entityType: "service_account",
+			expected:   journal.ServiceAccountEntity,
+		},
 		{
 			desc:        "Invalid entity type",
 			entityType:  "invalid",
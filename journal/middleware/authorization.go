@@ -42,6 +42,13 @@ func (am *authorizationMiddleware) RetrieveAll(ctx context.Context, session smqa
 		subject = session.UserID
 	}
 
+	// The service account journal is visible to the domain admins.
+	if page.EntityType == journal.ServiceAccountEntity {
+		permission = policies.AdminPermission
+		objectType = policies.DomainType
+		object = session.DomainID
+	}
+
 	req := smqauthz.PolicyReq{
 		Domain:      session.DomainID,
 		SubjectType: policies.UserType,
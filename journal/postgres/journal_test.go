@@ -697,12 +697,122 @@ func TestJournalRetrieveAll(t *testing.T) {
 	}
 }
 
+func TestJournalRetrieveServiceAccount(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := db.Exec("DELETE FROM journal")
+		require.Nil(t, err, fmt.Sprintf("clean journal unexpected error: %s", err))
+	})
+	repo := postgres.NewRepository(database)
+
+	saID := testsutil.GenerateUUID(t)
+	domainID := testsutil.GenerateUUID(t)
+	items := []journal.Journal{
+		{
+			Operation:  "service_account.create",
+			Attributes: map[string]interface{}{"id": saID, "domain": domainID},
+		},
+		{
+			Operation:  "client.create",
+			Attributes: map[string]interface{}{"id": testsutil.GenerateUUID(t), "actor_id": saID, "actor_type": "service_account", "actor_domain": domainID},
+		},
+		{
+			Operation:  "client.create",
+			Attributes: map[string]interface{}{"id": testsutil.GenerateUUID(t), "actor_id": saID, "actor_type": "service_account", "actor_domain": testsutil.GenerateUUID(t)},
+		},
+		{
+			Operation:  "client.create",
+			Attributes: map[string]interface{}{"id": testsutil.GenerateUUID(t), "actor_id": testsutil.GenerateUUID(t), "actor_type": "user", "actor_domain": domainID},
+		},
+	}
+	for _, item := range items {
+		item.ID = testsutil.GenerateUUID(t)
+		item.OccurredAt = time.Now()
+		err := repo.Save(context.Background(), item)
+		require.Nil(t, err, fmt.Sprintf("save journal unexpected error: %s", err))
+	}
+
+	page, err := repo.RetrieveAll(context.Background(), journal.Page{
+		Limit:          10,
+		EntityID:       saID,
+		EntityType:     journal.ServiceAccountEntity,
+		Domain:         domainID,
+		WithAttributes: true,
+	})
+	assert.Nil(t, err, fmt.Sprintf("retrieve service account journal unexpected error: %s", err))
+	assert.Equal(t, uint64(2), page.Total)
+	for _, j := range page.Journals {
+		assert.NotEqual(t, "user", j.Attributes["actor_type"])
+	}
+}
+
+func TestJournalRetrieveImpersonation(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := db.Exec("DELETE FROM journal")
+		require.Nil(t, err, fmt.Sprintf("clean journal unexpected error: %s", err))
+	})
+	repo := postgres.NewRepository(database)
+
+	userID := testsutil.GenerateUUID(t)
+	adminID := testsutil.GenerateUUID(t)
+	items := []journal.Journal{
+		{
+			Operation:  "user.impersonate",
+			Attributes: map[string]interface{}{"id": userID, "impersonator_id": adminID, "actor_id": adminID, "actor_type": "user"},
+		},
+		{
+			Operation:  "client.create",
+			Attributes: map[string]interface{}{"id": testsutil.GenerateUUID(t), "actor_id": userID, "actor_type": "user", "impersonator_id": adminID},
+		},
+		{
+			Operation:  "client.create",
+			Attributes: map[string]interface{}{"id": testsutil.GenerateUUID(t), "actor_id": userID, "actor_type": "user"},
+		},
+	}
+	for _, item := range items {
+		item.ID = testsutil.GenerateUUID(t)
+		item.OccurredAt = time.Now()
+		err := repo.Save(context.Background(), item)
+		require.Nil(t, err, fmt.Sprintf("save journal unexpected error: %s", err))
+	}
+
+	cases := []struct {
+		desc     string
+		entityID string
+		total    uint64
+	}{
+		{
+			desc:     "retrieve impersonated user journal",
+			entityID: userID,
+			total:    2,
+		},
+		{
+			desc:     "retrieve super admin journal",
+			entityID: adminID,
+			total:    2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			page, err := repo.RetrieveAll(context.Background(), journal.Page{
+				Limit:      10,
+				EntityID:   tc.entityID,
+				EntityType: journal.UserEntity,
+			})
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+			assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected total %d got %d", tc.desc, tc.total, page.Total))
+		})
+	}
+}
+
 func extractEntities(journals []journal.Journal, entityType journal.EntityType, entityID string) []journal.Journal {
 	var entities []journal.Journal
 	for _, j := range journals {
 		switch entityType {
 		case journal.UserEntity:
-			if strings.HasPrefix(j.Operation, "user.") && j.Attributes["id"] == entityID || j.Attributes["user_id"] == entityID {
+			_, impersonated := j.Attributes["impersonator_id"]
+			if strings.HasPrefix(j.Operation, "user.") && j.Attributes["id"] == entityID || j.Attributes["user_id"] == entityID ||
+				j.Attributes["impersonator_id"] == entityID || impersonated && j.Attributes["actor_id"] == entityID {
 				entities = append(entities, j)
 			}
 		case journal.GroupEntity:
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hantdev/mitras/coap"
+	"github.com/hantdev/mitras/lwm2m"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	piondtls "github.com/pion/dtls/v3"
+	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+	"github.com/plgd-dev/go-coap/v3/mux"
+)
+
+// Paths and query parameters of the LwM2M bootstrap and registration
+// interfaces.
+const (
+	bootstrapPath = "bs"
+	registerPath  = "rd"
+
+	authQuery     = "auth"
+	endpointQuery = "ep"
+	lifetimeQuery = "lt"
+	versionQuery  = "lwm2m"
+	bindingQuery  = "b"
+)
+
+var (
+	errBadRequest       = errors.New("bad request")
+	errMethodNotAllowed = errors.New("method not allowed")
+)
+
+// dtlsConn is the DTLS connection exposing the state of the handshake.
+type dtlsConn interface {
+	ConnectionState() (piondtls.State, bool)
+}
+
+// tlsConn is the TLS connection exposing the state of the handshake.
+type tlsConn interface {
+	ConnectionState() tls.ConnectionState
+}
+
+// MakeCoAPHandler creates the handler of the LwM2M bootstrap and registration
+// requests of the devices.
+func MakeCoAPHandler(svc lwm2m.Service, logger *slog.Logger) mux.HandlerFunc {
+	return func(w mux.ResponseWriter, m *mux.Message) {
+		code, opts, err := handle(svc, w, m)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Failed to handle LwM2M request: %s", err))
+			code = errorCode(err)
+		}
+		if err := w.SetResponse(code, message.TextPlain, nil, opts...); err != nil {
+			logger.Warn(fmt.Sprintf("Can't set response: %s", err))
+		}
+	}
+}
+
+func handle(svc lwm2m.Service, w mux.ResponseWriter, m *mux.Message) (codes.Code, []message.Option, error) {
+	path, err := m.Path()
+	if err != nil {
+		return 0, nil, errors.Wrap(errBadRequest, err)
+	}
+	queries, err := parseQueries(m)
+	if err != nil {
+		return 0, nil, err
+	}
+	ctx := m.Context()
+	dev := lwm2m.NewDevice(w.Conn())
+
+	elems := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(elems) == 1 && elems[0] == bootstrapPath && m.Code() == codes.POST:
+		ctx, key, err := authenticate(ctx, w.Conn(), queries)
+		if err != nil {
+			return 0, nil, err
+		}
+		if queries[endpointQuery] == "" {
+			return 0, nil, errBadRequest
+		}
+		return codes.Changed, nil, svc.Bootstrap(ctx, key, queries[endpointQuery], dev)
+	case len(elems) == 1 && elems[0] == registerPath && m.Code() == codes.POST:
+		ctx, key, err := authenticate(ctx, w.Conn(), queries)
+		if err != nil {
+			return 0, nil, err
+		}
+		reg, err := decodeRegistration(queries, m.Body())
+		if err != nil {
+			return 0, nil, err
+		}
+		id, err := svc.Register(ctx, key, reg, dev)
+		if err != nil {
+			return 0, nil, err
+		}
+		opts := []message.Option{
+			{ID: message.LocationPath, Value: []byte(registerPath)},
+			{ID: message.LocationPath, Value: []byte(id)},
+		}
+		return codes.Created, opts, nil
+	case len(elems) == 2 && elems[0] == registerPath && m.Code() == codes.POST:
+		lifetime, err := parseLifetime(queries)
+		if err != nil {
+			return 0, nil, err
+		}
+		objects, err := readObjects(m.Body())
+		if err != nil {
+			return 0, nil, err
+		}
+		ctx, key, err := credentials(ctx, w.Conn(), queries)
+		if err != nil {
+			return 0, nil, err
+		}
+		return codes.Changed, nil, svc.Update(ctx, key, elems[1], lifetime, objects, dev)
+	case len(elems) == 2 && elems[0] == registerPath && m.Code() == codes.DELETE:
+		ctx, key, err := credentials(ctx, w.Conn(), queries)
+		if err != nil {
+			return 0, nil, err
+		}
+		return codes.Deleted, nil, svc.Deregister(ctx, key, elems[1])
+	case (elems[0] == bootstrapPath || elems[0] == registerPath) && len(elems) <= 2:
+		return 0, nil, errMethodNotAllowed
+	default:
+		return 0, nil, svcerr.ErrNotFound
+	}
+}
+
+func decodeRegistration(queries map[string]string, body io.ReadSeeker) (lwm2m.Registration, error) {
+	if queries[endpointQuery] == "" {
+		return lwm2m.Registration{}, errBadRequest
+	}
+	lifetime, err := parseLifetime(queries)
+	if err != nil {
+		return lwm2m.Registration{}, err
+	}
+	objects, err := readObjects(body)
+	if err != nil {
+		return lwm2m.Registration{}, err
+	}
+
+	return lwm2m.Registration{
+		Endpoint: queries[endpointQuery],
+		Lifetime: lifetime,
+		Version:  queries[versionQuery],
+		Binding:  queries[bindingQuery],
+		Objects:  objects,
+	}, nil
+}
+
+func parseQueries(m *mux.Message) (map[string]string, error) {
+	queries, err := m.Queries()
+	if err != nil {
+		return nil, errors.Wrap(errBadRequest, err)
+	}
+	ret := make(map[string]string, len(queries))
+	for _, q := range queries {
+		k, v, _ := strings.Cut(q, "=")
+		ret[k] = v
+	}
+
+	return ret, nil
+}
+
+func parseLifetime(queries map[string]string) (time.Duration, error) {
+	lt, ok := queries[lifetimeQuery]
+	if !ok {
+		return 0, nil
+	}
+	secs, err := strconv.ParseUint(lt, 10, 32)
+	if err != nil {
+		return 0, errors.Wrap(errBadRequest, err)
+	}
+
+	return time.Duration(secs) * time.Second, nil
+}
+
+// readObjects returns the object and object instance paths of the CoRE link
+// format payload, e.g. "</1/0>,</3/0>,</3303>". Links carrying the attributes
+// of the root path are skipped.
+func readObjects(body io.ReadSeeker) ([]string, error) {
+	if body == nil {
+		return nil, nil
+	}
+	payload, err := io.ReadAll(body)
+	if err != nil {
+		return nil, errors.Wrap(errBadRequest, err)
+	}
+
+	var objects []string
+	for _, link := range strings.Split(string(payload), ",") {
+		target, _, _ := strings.Cut(strings.TrimSpace(link), ";")
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		target = strings.Trim(target, "<>")
+		if lwm2m.ValidatePath(target) != nil {
+			continue
+		}
+		objects = append(objects, target)
+	}
+
+	return objects, nil
+}
+
+// authenticate returns the context carrying the client ID of the client
+// authenticated by the transport, or the key of the client. Requests without
+// the client identity are rejected.
+func authenticate(ctx context.Context, conn mux.Conn, queries map[string]string) (context.Context, string, error) {
+	ctx, key, err := credentials(ctx, conn, queries)
+	if err != nil {
+		return ctx, "", err
+	}
+	if _, ok := coap.ClientID(ctx); !ok && key == "" {
+		return ctx, "", svcerr.ErrAuthentication
+	}
+
+	return ctx, key, nil
+}
+
+// credentials returns the context carrying the client ID of the client
+// authenticated by the transport, or the key of the client, which is empty if
+// the request doesn't carry the client identity. Clients connected over DTLS
+// are identified by the PSK identity, which is the client ID. Clients
+// connected using a verified client certificate are identified by the
+// certificate common name, which is the client key. Other clients
+// authenticate using the auth query key.
+func credentials(ctx context.Context, conn mux.Conn, queries map[string]string) (context.Context, string, error) {
+	switch c := conn.NetConn().(type) {
+	case dtlsConn:
+		state, ok := c.ConnectionState()
+		switch {
+		case ok && len(state.IdentityHint) > 0:
+			return coap.WithClientID(ctx, string(state.IdentityHint)), "", nil
+		case ok && len(state.PeerCertificates) > 0:
+			cert, err := x509.ParseCertificate(state.PeerCertificates[0])
+			if err != nil {
+				return ctx, "", errors.Wrap(svcerr.ErrAuthentication, err)
+			}
+			if cert.Subject.CommonName != "" {
+				return ctx, cert.Subject.CommonName, nil
+			}
+		}
+	case tlsConn:
+		state := c.ConnectionState()
+		if len(state.PeerCertificates) > 0 && state.PeerCertificates[0].Subject.CommonName != "" {
+			return ctx, state.PeerCertificates[0].Subject.CommonName, nil
+		}
+	}
+
+	return ctx, queries[authQuery], nil
+}
+
+func errorCode(err error) codes.Code {
+	switch {
+	case errors.Contains(err, errBadRequest):
+		return codes.BadRequest
+	case errors.Contains(err, errMethodNotAllowed):
+		return codes.MethodNotAllowed
+	case errors.Contains(err, svcerr.ErrNotFound):
+		return codes.NotFound
+	case errors.Contains(err, svcerr.ErrAuthorization):
+		return codes.Forbidden
+	case errors.Contains(err, svcerr.ErrAuthentication):
+		return codes.Unauthorized
+	default:
+		return codes.InternalServerError
+	}
+}
@@ -0,0 +1,4 @@
+// Package api contains API-related concerns: the CoAP transport of the LwM2M
+// bootstrap and registration interfaces, the HTTP endpoint definitions, and
+// all resource representations.
+package api
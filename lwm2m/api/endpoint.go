@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/hantdev/mitras/internal/api"
+	"github.com/hantdev/mitras/lwm2m"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	"github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+)
+
+func createEndpointEndpoint(svc lwm2m.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createEndpointReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		ep := lwm2m.Endpoint{
+			Name:      req.Name,
+			ClientID:  req.ClientID,
+			ChannelID: req.ChannelID,
+		}
+		ep, err := svc.CreateEndpoint(ctx, session, ep)
+		if err != nil {
+			return nil, err
+		}
+
+		return endpointRes{Endpoint: ep, created: true}, nil
+	}
+}
+
+func viewEndpointEndpoint(svc lwm2m.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(endpointReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		ep, reg, err := svc.ViewEndpoint(ctx, session, req.clientID)
+		if err != nil {
+			return nil, err
+		}
+
+		res := endpointRes{Endpoint: ep}
+		if reg.ID != "" {
+			res.Registration = &reg
+		}
+
+		return res, nil
+	}
+}
+
+func removeEndpointEndpoint(svc lwm2m.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(endpointReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		if err := svc.RemoveEndpoint(ctx, session, req.clientID); err != nil {
+			return nil, err
+		}
+
+		return emptyRes{}, nil
+	}
+}
+
+func readEndpoint(svc lwm2m.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(resourceReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		pack, err := svc.Read(ctx, session, req.clientID, req.path)
+		if err != nil {
+			return nil, err
+		}
+
+		return readRes{Records: pack.Records}, nil
+	}
+}
+
+func writeEndpoint(svc lwm2m.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(writeReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		if err := svc.Write(ctx, session, req.clientID, req.path, req.pack); err != nil {
+			return nil, err
+		}
+
+		return emptyRes{}, nil
+	}
+}
+
+func executeEndpoint(svc lwm2m.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(executeReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		if err := svc.Execute(ctx, session, req.clientID, req.path, req.Args); err != nil {
+			return nil, err
+		}
+
+		return emptyRes{}, nil
+	}
+}
+
+func observeEndpoint(svc lwm2m.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(resourceReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		if err := svc.Observe(ctx, session, req.clientID, req.path); err != nil {
+			return nil, err
+		}
+
+		return emptyRes{}, nil
+	}
+}
+
+func cancelObservationEndpoint(svc lwm2m.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(resourceReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		if err := svc.CancelObservation(ctx, session, req.clientID, req.path); err != nil {
+			return nil, err
+		}
+
+		return emptyRes{}, nil
+	}
+}
@@ -0,0 +1,344 @@
+package api_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hantdev/mitras/internal/testsutil"
+	smqlog "github.com/hantdev/mitras/logger"
+	"github.com/hantdev/mitras/lwm2m"
+	"github.com/hantdev/mitras/lwm2m/api"
+	"github.com/hantdev/mitras/lwm2m/mocks"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	authnmocks "github.com/hantdev/mitras/pkg/authn/mocks"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/senml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	validToken  = "valid"
+	contentType = "application/json"
+	ctSenMLJSON = "application/senml+json"
+)
+
+var (
+	domainID = testsutil.GenerateUUID(&testing.T{})
+	clientID = testsutil.GenerateUUID(&testing.T{})
+	chanID   = testsutil.GenerateUUID(&testing.T{})
+)
+
+type testRequest struct {
+	client      *http.Client
+	method      string
+	url         string
+	contentType string
+	token       string
+	body        io.Reader
+}
+
+func (tr testRequest) make() (*http.Response, error) {
+	req, err := http.NewRequest(tr.method, tr.url, tr.body)
+	if err != nil {
+		return nil, err
+	}
+
+	if tr.token != "" {
+		req.Header.Set("Authorization", apiutil.BearerPrefix+tr.token)
+	}
+	if tr.contentType != "" {
+		req.Header.Set("Content-Type", tr.contentType)
+	}
+
+	return tr.client.Do(req)
+}
+
+func newServer() (*httptest.Server, *mocks.Service, *authnmocks.Authentication) {
+	svc := new(mocks.Service)
+	authn := new(authnmocks.Authentication)
+	mux := api.MakeHandler(svc, authn, smqlog.NewMock(), "lwm2m", "test")
+
+	return httptest.NewServer(mux), svc, authn
+}
+
+func TestCreateEndpoint(t *testing.T) {
+	ts, svc, authn := newServer()
+	defer ts.Close()
+
+	valid := fmt.Sprintf(`{"name":"urn:dev:1","client_id":"%s","channel_id":"%s"}`, clientID, chanID)
+
+	cases := []struct {
+		desc        string
+		token       string
+		contentType string
+		body        string
+		svcErr      error
+		status      int
+	}{
+		{
+			desc:        "create endpoint successfully",
+			token:       validToken,
+			contentType: contentType,
+			body:        valid,
+			status:      http.StatusCreated,
+		},
+		{
+			desc:        "create endpoint without token",
+			contentType: contentType,
+			body:        valid,
+			status:      http.StatusUnauthorized,
+		},
+		{
+			desc:   "create endpoint with invalid content type",
+			token:  validToken,
+			body:   valid,
+			status: http.StatusUnsupportedMediaType,
+		},
+		{
+			desc:        "create endpoint with malformed body",
+			token:       validToken,
+			contentType: contentType,
+			body:        "{",
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "create endpoint without name",
+			token:       validToken,
+			contentType: contentType,
+			body:        fmt.Sprintf(`{"client_id":"%s","channel_id":"%s"}`, clientID, chanID),
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "create endpoint with invalid client ID",
+			token:       validToken,
+			contentType: contentType,
+			body:        fmt.Sprintf(`{"name":"urn:dev:1","client_id":"invalid","channel_id":"%s"}`, chanID),
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "create endpoint without channel ID",
+			token:       validToken,
+			contentType: contentType,
+			body:        fmt.Sprintf(`{"name":"urn:dev:1","client_id":"%s"}`, clientID),
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "create endpoint with unauthorized user",
+			token:       validToken,
+			contentType: contentType,
+			body:        valid,
+			svcErr:      svcerr.ErrAuthorization,
+			status:      http.StatusForbidden,
+		},
+		{
+			desc:        "create endpoint with existing name",
+			token:       validToken,
+			contentType: contentType,
+			body:        valid,
+			svcErr:      svcerr.ErrConflict,
+			status:      http.StatusConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			authCall := authn.On("Authenticate", mock.Anything, tc.token).Return(smqauthn.Session{UserID: testsutil.GenerateUUID(t)}, nil)
+			svcCall := svc.On("CreateEndpoint", mock.Anything, mock.Anything, mock.Anything).Return(lwm2m.Endpoint{ClientID: clientID, DomainID: domainID}, tc.svcErr)
+			req := testRequest{
+				client:      ts.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/%s/lwm2m/endpoints", ts.URL, domainID),
+				contentType: tc.contentType,
+				token:       tc.token,
+				body:        strings.NewReader(tc.body),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			defer res.Body.Close()
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			if tc.status == http.StatusCreated {
+				location := fmt.Sprintf("/%s/lwm2m/endpoints/%s", domainID, clientID)
+				assert.Equal(t, location, res.Header.Get("Location"), fmt.Sprintf("%s: expected location %s got %s", tc.desc, location, res.Header.Get("Location")))
+			}
+			svcCall.Unset()
+			authCall.Unset()
+		})
+	}
+}
+
+func TestReadEndpoint(t *testing.T) {
+	ts, svc, authn := newServer()
+	defer ts.Close()
+
+	cases := []struct {
+		desc   string
+		path   string
+		svcErr error
+		status int
+	}{
+		{
+			desc:   "read resource successfully",
+			path:   "3303/0/5700",
+			status: http.StatusOK,
+		},
+		{
+			desc:   "read resource with invalid path",
+			path:   "3303/zero",
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "read resource instance with too deep path",
+			path:   "3303/0/5700/0/1",
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "read resource of unregistered endpoint",
+			path:   "3303/0/5700",
+			svcErr: svcerr.ErrNotFound,
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			authCall := authn.On("Authenticate", mock.Anything, validToken).Return(smqauthn.Session{UserID: testsutil.GenerateUUID(t)}, nil)
+			svcCall := svc.On("Read", mock.Anything, mock.Anything, clientID, "/"+tc.path).Return(senml.Pack{}, tc.svcErr)
+			req := testRequest{
+				client: ts.Client(),
+				method: http.MethodGet,
+				url:    fmt.Sprintf("%s/%s/lwm2m/endpoints/%s/objects/%s", ts.URL, domainID, clientID, tc.path),
+				token:  validToken,
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			defer res.Body.Close()
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+			authCall.Unset()
+		})
+	}
+}
+
+func TestWriteEndpoint(t *testing.T) {
+	ts, svc, authn := newServer()
+	defer ts.Close()
+
+	cases := []struct {
+		desc        string
+		contentType string
+		body        string
+		svcErr      error
+		status      int
+	}{
+		{
+			desc:        "write resource successfully",
+			contentType: ctSenMLJSON,
+			body:        `[{"n":"5850","vb":true}]`,
+			status:      http.StatusNoContent,
+		},
+		{
+			desc:        "write resource with invalid content type",
+			contentType: contentType,
+			body:        `[{"n":"5850","vb":true}]`,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			desc:        "write resource with malformed body",
+			contentType: ctSenMLJSON,
+			body:        `{`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "write resource without records",
+			contentType: ctSenMLJSON,
+			body:        `[]`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "write resource with unsupported records",
+			contentType: ctSenMLJSON,
+			body:        `[{"n":"5850","vb":true}]`,
+			svcErr:      svcerr.ErrMalformedEntity,
+			status:      http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			authCall := authn.On("Authenticate", mock.Anything, validToken).Return(smqauthn.Session{UserID: testsutil.GenerateUUID(t)}, nil)
+			svcCall := svc.On("Write", mock.Anything, mock.Anything, clientID, "/3311/0", mock.Anything).Return(tc.svcErr)
+			req := testRequest{
+				client:      ts.Client(),
+				method:      http.MethodPut,
+				url:         fmt.Sprintf("%s/%s/lwm2m/endpoints/%s/objects/3311/0", ts.URL, domainID, clientID),
+				contentType: tc.contentType,
+				token:       validToken,
+				body:        strings.NewReader(tc.body),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			defer res.Body.Close()
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+			authCall.Unset()
+		})
+	}
+}
+
+func TestExecuteEndpoint(t *testing.T) {
+	ts, svc, authn := newServer()
+	defer ts.Close()
+
+	cases := []struct {
+		desc        string
+		contentType string
+		body        string
+		args        string
+		status      int
+	}{
+		{
+			desc:   "execute resource without arguments",
+			status: http.StatusNoContent,
+		},
+		{
+			desc:        "execute resource with arguments",
+			contentType: contentType,
+			body:        `{"args":"0='on'"}`,
+			args:        "0='on'",
+			status:      http.StatusNoContent,
+		},
+		{
+			desc:        "execute resource with malformed body",
+			contentType: contentType,
+			body:        `{`,
+			status:      http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			authCall := authn.On("Authenticate", mock.Anything, validToken).Return(smqauthn.Session{UserID: testsutil.GenerateUUID(t)}, nil)
+			svcCall := svc.On("Execute", mock.Anything, mock.Anything, clientID, "/3/0/4", tc.args).Return(nil)
+			req := testRequest{
+				client:      ts.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/%s/lwm2m/endpoints/%s/objects/3/0/4", ts.URL, domainID, clientID),
+				contentType: tc.contentType,
+				token:       validToken,
+				body:        strings.NewReader(tc.body),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			defer res.Body.Close()
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+			authCall.Unset()
+		})
+	}
+}
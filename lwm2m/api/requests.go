@@ -0,0 +1,85 @@
+package api
+
+import (
+	"github.com/hantdev/mitras/internal/api"
+	"github.com/hantdev/mitras/lwm2m"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	"github.com/hantdev/senml"
+)
+
+const maxNameSize = 1024
+
+type createEndpointReq struct {
+	Name      string `json:"name"`
+	ClientID  string `json:"client_id"`
+	ChannelID string `json:"channel_id"`
+}
+
+func (req createEndpointReq) validate() error {
+	if req.Name == "" {
+		return apiutil.ErrMissingName
+	}
+	if len(req.Name) > maxNameSize {
+		return apiutil.ErrNameSize
+	}
+	if req.ClientID == "" {
+		return apiutil.ErrMissingClientID
+	}
+	if req.ChannelID == "" {
+		return apiutil.ErrMissingChannelID
+	}
+	if err := api.ValidateUUID(req.ClientID); err != nil {
+		return err
+	}
+
+	return api.ValidateUUID(req.ChannelID)
+}
+
+type endpointReq struct {
+	clientID string
+}
+
+func (req endpointReq) validate() error {
+	if req.clientID == "" {
+		return apiutil.ErrMissingClientID
+	}
+
+	return nil
+}
+
+type resourceReq struct {
+	clientID string
+	path     string
+}
+
+func (req resourceReq) validate() error {
+	if req.clientID == "" {
+		return apiutil.ErrMissingClientID
+	}
+	if err := lwm2m.ValidatePath(req.path); err != nil {
+		return apiutil.ErrInvalidObjectPath
+	}
+
+	return nil
+}
+
+type writeReq struct {
+	resourceReq
+	pack senml.Pack
+}
+
+func (req writeReq) validate() error {
+	if err := req.resourceReq.validate(); err != nil {
+		return err
+	}
+	if len(req.pack.Records) == 0 {
+		return apiutil.ErrEmptyList
+	}
+
+	return nil
+}
+
+type executeReq struct {
+	resourceReq
+	Args string `json:"args,omitempty"`
+}
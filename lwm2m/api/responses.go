@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hantdev/mitras"
+	"github.com/hantdev/mitras/lwm2m"
+	"github.com/hantdev/senml"
+)
+
+var (
+	_ mitras.Response = (*endpointRes)(nil)
+	_ mitras.Response = (*readRes)(nil)
+	_ mitras.Response = (*emptyRes)(nil)
+)
+
+type endpointRes struct {
+	lwm2m.Endpoint `json:",inline"`
+	Registration   *lwm2m.Registration `json:"registration,omitempty"`
+	created        bool
+}
+
+func (res endpointRes) Code() int {
+	if res.created {
+		return http.StatusCreated
+	}
+
+	return http.StatusOK
+}
+
+func (res endpointRes) Headers() map[string]string {
+	if res.created {
+		return map[string]string{
+			"Location": fmt.Sprintf("/%s/lwm2m/endpoints/%s", res.DomainID, res.ClientID),
+		}
+	}
+
+	return map[string]string{}
+}
+
+func (res endpointRes) Empty() bool {
+	return false
+}
+
+type readRes struct {
+	Records []senml.Record `json:"records"`
+}
+
+func (res readRes) Code() int {
+	return http.StatusOK
+}
+
+func (res readRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res readRes) Empty() bool {
+	return false
+}
+
+type emptyRes struct{}
+
+func (res emptyRes) Code() int {
+	return http.StatusNoContent
+}
+
+func (res emptyRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res emptyRes) Empty() bool {
+	return true
+}
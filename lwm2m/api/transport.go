@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/hantdev/mitras"
+	"github.com/hantdev/mitras/internal/api"
+	"github.com/hantdev/mitras/lwm2m"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/senml"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	ctSenMLJSON = "application/senml+json"
+	clientIDKey = "clientID"
+)
+
+// MakeHandler returns a HTTP API handler with health check and metrics.
+func MakeHandler(svc lwm2m.Service, authn smqauthn.Authentication, logger *slog.Logger, svcName, instanceID string) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(apiutil.LoggingErrorEncoder(logger, api.EncodeError)),
+	}
+
+	mux := chi.NewRouter()
+
+	mux.Route("/{domainID}/lwm2m/endpoints", func(r chi.Router) {
+		r.Use(api.AuthenticateMiddleware(authn, true))
+
+		r.Post("/", otelhttp.NewHandler(kithttp.NewServer(
+			createEndpointEndpoint(svc),
+			decodeCreateEndpointReq,
+			api.EncodeResponse,
+			opts...,
+		), "create_lwm2m_endpoint").ServeHTTP)
+
+		r.Get("/{clientID}", otelhttp.NewHandler(kithttp.NewServer(
+			viewEndpointEndpoint(svc),
+			decodeEndpointReq,
+			api.EncodeResponse,
+			opts...,
+		), "view_lwm2m_endpoint").ServeHTTP)
+
+		r.Delete("/{clientID}", otelhttp.NewHandler(kithttp.NewServer(
+			removeEndpointEndpoint(svc),
+			decodeEndpointReq,
+			api.EncodeResponse,
+			opts...,
+		), "remove_lwm2m_endpoint").ServeHTTP)
+
+		r.Get("/{clientID}/objects/*", otelhttp.NewHandler(kithttp.NewServer(
+			readEndpoint(svc),
+			decodeResourceReq,
+			api.EncodeResponse,
+			opts...,
+		), "read_lwm2m_resource").ServeHTTP)
+
+		r.Put("/{clientID}/objects/*", otelhttp.NewHandler(kithttp.NewServer(
+			writeEndpoint(svc),
+			decodeWriteReq,
+			api.EncodeResponse,
+			opts...,
+		), "write_lwm2m_resource").ServeHTTP)
+
+		r.Post("/{clientID}/objects/*", otelhttp.NewHandler(kithttp.NewServer(
+			executeEndpoint(svc),
+			decodeExecuteReq,
+			api.EncodeResponse,
+			opts...,
+		), "execute_lwm2m_resource").ServeHTTP)
+
+		r.Post("/{clientID}/observations/*", otelhttp.NewHandler(kithttp.NewServer(
+			observeEndpoint(svc),
+			decodeResourceReq,
+			api.EncodeResponse,
+			opts...,
+		), "observe_lwm2m_resource").ServeHTTP)
+
+		r.Delete("/{clientID}/observations/*", otelhttp.NewHandler(kithttp.NewServer(
+			cancelObservationEndpoint(svc),
+			decodeResourceReq,
+			api.EncodeResponse,
+			opts...,
+		), "cancel_lwm2m_observation").ServeHTTP)
+	})
+
+	mux.Get("/health", mitras.Health(svcName, instanceID))
+	mux.Handle("/metrics", promhttp.Handler())
+
+	return mux
+}
+
+func decodeCreateEndpointReq(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := createEndpointReq{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
+
+func decodeEndpointReq(_ context.Context, r *http.Request) (interface{}, error) {
+	return endpointReq{clientID: chi.URLParam(r, clientIDKey)}, nil
+}
+
+func decodeResourceReq(_ context.Context, r *http.Request) (interface{}, error) {
+	return resourceReq{
+		clientID: chi.URLParam(r, clientIDKey),
+		path:     "/" + chi.URLParam(r, "*"),
+	}, nil
+}
+
+func decodeWriteReq(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), ctSenMLJSON) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+	pack, err := senml.Decode(body, senml.JSON)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	req := writeReq{
+		resourceReq: resourceReq{
+			clientID: chi.URLParam(r, clientIDKey),
+			path:     "/" + chi.URLParam(r, "*"),
+		},
+		pack: pack,
+	}
+
+	return req, nil
+}
+
+// decodeExecuteReq decodes the execute request. Arguments of the execution
+// are optional, so the request may be sent without the body.
+func decodeExecuteReq(_ context.Context, r *http.Request) (interface{}, error) {
+	req := executeReq{
+		resourceReq: resourceReq{
+			clientID: chi.URLParam(r, clientIDKey),
+			path:     "/" + chi.URLParam(r, "*"),
+		},
+	}
+	if r.ContentLength == 0 {
+		return req, nil
+	}
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
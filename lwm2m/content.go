@@ -0,0 +1,219 @@
+package lwm2m
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/senml"
+)
+
+// maxPathDepth is the depth of the resource instance path.
+const maxPathDepth = 4
+
+var formats = map[uint16]senml.Format{
+	SenMLJSON: senml.JSON,
+	SenMLCBOR: senml.CBOR,
+}
+
+// parsePath returns the object, object instance, resource and resource
+// instance IDs of the path.
+func parsePath(path string) ([]uint16, error) {
+	elems := strings.Split(strings.Trim(path, "/"), "/")
+	if len(elems) > maxPathDepth || elems[0] == "" {
+		return nil, ErrInvalidPath
+	}
+
+	ids := make([]uint16, len(elems))
+	for i, elem := range elems {
+		id, err := strconv.ParseUint(elem, 10, 16)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidPath, err)
+		}
+		ids[i] = uint16(id)
+	}
+
+	return ids, nil
+}
+
+// ValidatePath validates the path of the object, object instance, resource
+// or resource instance.
+func ValidatePath(path string) error {
+	_, err := parsePath(path)
+	return err
+}
+
+func formatPath(ids []uint16) string {
+	var b strings.Builder
+	for _, id := range ids {
+		fmt.Fprintf(&b, "/%d", id)
+	}
+
+	return b.String()
+}
+
+// toPack translates the content read from the path into the SenML pack. TLV
+// values aren't typed without the object model, so they are translated into
+// the data values. Records of the pack are named by the resource paths.
+func toPack(path string, c Content) (senml.Pack, error) {
+	ids, err := parsePath(path)
+	if err != nil {
+		return senml.Pack{}, err
+	}
+
+	switch c.Format {
+	case SenMLJSON, SenMLCBOR:
+		p, err := senml.Decode(c.Payload, formats[c.Format])
+		if err != nil {
+			return senml.Pack{}, errors.Wrap(ErrUnsupportedFormat, err)
+		}
+		return senml.Normalize(p)
+	case TLV:
+		entries, err := decodeTLV(c.Payload)
+		if err != nil {
+			return senml.Pack{}, errors.Wrap(ErrUnsupportedFormat, err)
+		}
+		// Entries read from the resource or the resource instance carry the
+		// ID of the path, so they are named relative to the parent path.
+		if len(ids) > 2 {
+			ids = ids[:len(ids)-1]
+		}
+		var p senml.Pack
+		for _, v := range flattenTLV(formatPath(ids), entries) {
+			data := base64.RawURLEncoding.EncodeToString(v.value)
+			p.Records = append(p.Records, senml.Record{Name: v.path, DataValue: &data})
+		}
+		return p, nil
+	case TextPlain:
+		value := string(c.Payload)
+		return senml.Pack{Records: []senml.Record{{Name: formatPath(ids), StringValue: &value}}}, nil
+	case Opaque:
+		data := base64.RawURLEncoding.EncodeToString(c.Payload)
+		return senml.Pack{Records: []senml.Record{{Name: formatPath(ids), DataValue: &data}}}, nil
+	default:
+		return senml.Pack{}, ErrUnsupportedFormat
+	}
+}
+
+// fromPack translates the records written to the path into the content of
+// the given format. Records are named by the resource paths relative to the
+// path.
+func fromPack(path string, p senml.Pack, format uint16) (Content, error) {
+	ids, err := parsePath(path)
+	if err != nil {
+		return Content{}, err
+	}
+	p, err = senml.Normalize(p)
+	if err != nil {
+		return Content{}, err
+	}
+
+	switch format {
+	case SenMLJSON, SenMLCBOR:
+		for i, r := range p.Records {
+			p.Records[i].Name = resolve(path, r.Name)
+		}
+		payload, err := senml.Encode(p, formats[format])
+		if err != nil {
+			return Content{}, err
+		}
+		return Content{Format: format, Payload: payload}, nil
+	case TLV:
+		entries, err := packTLV(ids, p)
+		if err != nil {
+			return Content{}, err
+		}
+		return Content{Format: TLV, Payload: encodeTLV(entries)}, nil
+	default:
+		return Content{}, ErrUnsupportedFormat
+	}
+}
+
+// packTLV returns the TLV entries of the records written to the object
+// instance or the resource.
+func packTLV(ids []uint16, p senml.Pack) ([]tlv, error) {
+	if len(ids) < 2 || len(ids) > 3 {
+		return nil, ErrInvalidPath
+	}
+
+	var entries []tlv
+	multiple := make(map[uint16]int)
+	for _, r := range p.Records {
+		rids, err := parsePath(resolve(formatPath(ids), r.Name))
+		if err != nil {
+			return nil, err
+		}
+		if len(rids) < 3 || len(rids) < len(ids) || formatPath(rids[:len(ids)]) != formatPath(ids) {
+			return nil, ErrInvalidPath
+		}
+		value, err := tlvValue(r)
+		if err != nil {
+			return nil, err
+		}
+
+		switch len(rids) {
+		case 3:
+			entries = append(entries, tlv{typ: tlvResource, id: rids[2], value: value})
+		case 4:
+			i, ok := multiple[rids[2]]
+			if !ok {
+				i = len(entries)
+				multiple[rids[2]] = i
+				entries = append(entries, tlv{typ: tlvMultipleResource, id: rids[2]})
+			}
+			entries[i].children = append(entries[i].children, tlv{typ: tlvResourceInstance, id: rids[3], value: value})
+		}
+	}
+
+	return entries, nil
+}
+
+// tlvValue encodes the value of the record. Integral numbers are encoded as
+// the integers, and the other numbers as the 64-bit floats.
+func tlvValue(r senml.Record) ([]byte, error) {
+	switch {
+	case r.StringValue != nil:
+		return []byte(*r.StringValue), nil
+	case r.BoolValue != nil:
+		if *r.BoolValue {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case r.DataValue != nil:
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(*r.DataValue, "="))
+	case r.Value != nil:
+		v := *r.Value
+		if v != math.Trunc(v) || v > math.MaxInt64 || v < math.MinInt64 {
+			return binary.BigEndian.AppendUint64(nil, math.Float64bits(v)), nil
+		}
+		n := int64(v)
+		switch {
+		case n >= math.MinInt8 && n <= math.MaxInt8:
+			return []byte{byte(n)}, nil
+		case n >= math.MinInt16 && n <= math.MaxInt16:
+			return binary.BigEndian.AppendUint16(nil, uint16(n)), nil
+		case n >= math.MinInt32 && n <= math.MaxInt32:
+			return binary.BigEndian.AppendUint32(nil, uint32(n)), nil
+		default:
+			return binary.BigEndian.AppendUint64(nil, uint64(n)), nil
+		}
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// resolve returns the absolute path of the record name relative to the path.
+func resolve(path, name string) string {
+	switch {
+	case name == "":
+		return path
+	case strings.HasPrefix(name, "/"):
+		return name
+	default:
+		return strings.TrimSuffix(path, "/") + "/" + name
+	}
+}
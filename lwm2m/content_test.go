@@ -0,0 +1,206 @@
+package lwm2m
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/senml"
+	"github.com/stretchr/testify/assert"
+)
+
+func data(b ...byte) *string {
+	s := base64.RawURLEncoding.EncodeToString(b)
+	return &s
+}
+
+func TestTLV(t *testing.T) {
+	long := make([]byte, 300)
+	cases := []struct {
+		desc    string
+		entries []tlv
+		payload []byte
+	}{
+		{
+			desc:    "resource with short value",
+			entries: []tlv{{typ: tlvResource, id: 1, value: []byte{0x2C}}},
+			payload: []byte{0xC1, 0x01, 0x2C},
+		},
+		{
+			desc:    "resource with 16-bit ID",
+			entries: []tlv{{typ: tlvResource, id: 5700, value: []byte{0x01}}},
+			payload: []byte{0xE1, 0x16, 0x44, 0x01},
+		},
+		{
+			desc:    "resource with 16-bit length",
+			entries: []tlv{{typ: tlvResource, id: 0, value: long}},
+			payload: append([]byte{0xD0, 0x00, 0x01, 0x2C}, long...),
+		},
+		{
+			desc: "object instance with multiple resource",
+			entries: []tlv{{typ: tlvObjectInstance, id: 0, children: []tlv{
+				{typ: tlvResource, id: 0, value: []byte("a")},
+				{typ: tlvMultipleResource, id: 6, children: []tlv{
+					{typ: tlvResourceInstance, id: 0, value: []byte{0x01}},
+					{typ: tlvResourceInstance, id: 1, value: []byte{0x05}},
+				}},
+			}}},
+			payload: []byte{0x08, 0x00, 0x0B, 0xC1, 0x00, 'a', 0x86, 0x06, 0x41, 0x00, 0x01, 0x41, 0x01, 0x05},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			payload := encodeTLV(tc.entries)
+			assert.Equal(t, tc.payload, payload)
+			entries, err := decodeTLV(payload)
+			assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+			assert.Equal(t, tc.entries, entries)
+		})
+	}
+
+	_, err := decodeTLV([]byte{0xC8, 0x00, 0x05, 0x01})
+	assert.True(t, errors.Contains(err, errMalformedTLV), fmt.Sprintf("expected error %s, got %s", errMalformedTLV, err))
+}
+
+func TestToPack(t *testing.T) {
+	text := "mitras"
+	cases := []struct {
+		desc    string
+		path    string
+		content Content
+		pack    senml.Pack
+		err     error
+	}{
+		{
+			desc: "read object instance as TLV",
+			path: "/3303/0",
+			content: Content{Format: TLV, Payload: encodeTLV([]tlv{
+				{typ: tlvResource, id: 5700, value: []byte{0x15}},
+				{typ: tlvMultipleResource, id: 5701, children: []tlv{
+					{typ: tlvResourceInstance, id: 0, value: []byte("C")},
+				}},
+			})},
+			pack: senml.Pack{Records: []senml.Record{
+				{Name: "/3303/0/5700", DataValue: data(0x15)},
+				{Name: "/3303/0/5701/0", DataValue: data('C')},
+			}},
+		},
+		{
+			desc:    "read resource as TLV",
+			path:    "/3303/0/5700",
+			content: Content{Format: TLV, Payload: []byte{0xE1, 0x16, 0x44, 0x15}},
+			pack:    senml.Pack{Records: []senml.Record{{Name: "/3303/0/5700", DataValue: data(0x15)}}},
+		},
+		{
+			desc:    "read resource as plain text",
+			path:    "/3/0/0",
+			content: Content{Format: TextPlain, Payload: []byte(text)},
+			pack:    senml.Pack{Records: []senml.Record{{Name: "/3/0/0", StringValue: &text}}},
+		},
+		{
+			desc:    "read resource as opaque",
+			path:    "/5/0/0",
+			content: Content{Format: Opaque, Payload: []byte{0xFF}},
+			pack:    senml.Pack{Records: []senml.Record{{Name: "/5/0/0", DataValue: data(0xFF)}}},
+		},
+		{
+			desc:    "read with malformed TLV",
+			path:    "/3/0",
+			content: Content{Format: TLV, Payload: []byte{0xC8}},
+			err:     ErrUnsupportedFormat,
+		},
+		{
+			desc:    "read with unsupported format",
+			path:    "/3/0",
+			content: Content{Format: LinkFormat},
+			err:     ErrUnsupportedFormat,
+		},
+		{
+			desc:    "read with invalid path",
+			path:    "/3/a",
+			content: Content{Format: TextPlain},
+			err:     ErrInvalidPath,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			pack, err := toPack(tc.path, tc.content)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("expected error %s, got %s", tc.err, err))
+			assert.Equal(t, tc.pack, pack)
+		})
+	}
+}
+
+func TestFromPack(t *testing.T) {
+	value := 21.0
+	fraction := 21.5
+	on := true
+	text := "mitras"
+	cases := []struct {
+		desc    string
+		path    string
+		pack    senml.Pack
+		format  uint16
+		content Content
+		err     error
+	}{
+		{
+			desc:   "write object instance as TLV",
+			path:   "/3303/0",
+			format: TLV,
+			pack: senml.Pack{Records: []senml.Record{
+				{Name: "5700", Value: &value},
+				{Name: "5850", BoolValue: &on},
+				{Name: "5701/0", StringValue: &text},
+			}},
+			content: Content{Format: TLV, Payload: encodeTLV([]tlv{
+				{typ: tlvResource, id: 5700, value: []byte{0x15}},
+				{typ: tlvResource, id: 5850, value: []byte{0x01}},
+				{typ: tlvMultipleResource, id: 5701, children: []tlv{
+					{typ: tlvResourceInstance, id: 0, value: []byte(text)},
+				}},
+			})},
+		},
+		{
+			desc:   "write resource with float as TLV",
+			path:   "/3303/0/5700",
+			format: TLV,
+			pack:   senml.Pack{Records: []senml.Record{{Value: &fraction}}},
+			content: Content{Format: TLV, Payload: encodeTLV([]tlv{
+				{typ: tlvResource, id: 5700, value: []byte{0x40, 0x35, 0x80, 0, 0, 0, 0, 0}},
+			})},
+		},
+		{
+			desc:   "write object as TLV",
+			path:   "/3303",
+			format: TLV,
+			pack:   senml.Pack{Records: []senml.Record{{Name: "0/5700", Value: &value}}},
+			err:    ErrInvalidPath,
+		},
+		{
+			desc:   "write resource outside of the path",
+			path:   "/3303/0",
+			format: TLV,
+			pack:   senml.Pack{Records: []senml.Record{{Name: "/3303/1/5700", Value: &value}}},
+			err:    ErrInvalidPath,
+		},
+		{
+			desc:   "write with unsupported format",
+			path:   "/3303/0",
+			format: TextPlain,
+			pack:   senml.Pack{Records: []senml.Record{{Name: "5700", Value: &value}}},
+			err:    ErrUnsupportedFormat,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c, err := fromPack(tc.path, tc.pack, tc.format)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("expected error %s, got %s", tc.err, err))
+			assert.Equal(t, tc.content, c)
+		})
+	}
+}
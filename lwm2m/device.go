@@ -0,0 +1,138 @@
+package lwm2m
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+	"github.com/plgd-dev/go-coap/v3/message/pool"
+	mux "github.com/plgd-dev/go-coap/v3/mux"
+)
+
+const bootstrapPath = "/bs"
+
+var _ Device = (*device)(nil)
+
+type device struct {
+	conn mux.Conn
+}
+
+// NewDevice wraps the CoAP connection of the endpoint. Devices choose the
+// content format of the responses, which is TLV for the LwM2M 1.0 devices and
+// SenML for the newer ones.
+func NewDevice(conn mux.Conn) Device {
+	return &device{conn: conn}
+}
+
+func (d *device) Read(ctx context.Context, path string) (Content, error) {
+	res, err := d.conn.Get(ctx, path)
+	if err != nil {
+		return Content{}, err
+	}
+	if err := check(res); err != nil {
+		return Content{}, err
+	}
+
+	return content(res)
+}
+
+func (d *device) Write(ctx context.Context, path string, c Content) error {
+	res, err := d.conn.Put(ctx, path, message.MediaType(c.Format), bytes.NewReader(c.Payload))
+	if err != nil {
+		return err
+	}
+
+	return check(res)
+}
+
+func (d *device) Execute(ctx context.Context, path, args string) error {
+	var payload io.ReadSeeker
+	if args != "" {
+		payload = bytes.NewReader([]byte(args))
+	}
+	res, err := d.conn.Post(ctx, path, message.TextPlain, payload)
+	if err != nil {
+		return err
+	}
+
+	return check(res)
+}
+
+func (d *device) Delete(ctx context.Context, path string) error {
+	res, err := d.conn.Delete(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	return check(res)
+}
+
+func (d *device) Observe(ctx context.Context, path string, handler func(Content)) (Observation, error) {
+	obs, err := d.conn.Observe(ctx, path, func(n *pool.Message) {
+		if check(n) != nil {
+			return
+		}
+		if c, err := content(n); err == nil {
+			handler(c)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return observation{obs: obs}, nil
+}
+
+func (d *device) BootstrapFinish(ctx context.Context) error {
+	res, err := d.conn.Post(ctx, bootstrapPath, message.TextPlain, nil)
+	if err != nil {
+		return err
+	}
+
+	return check(res)
+}
+
+func (d *device) Done() <-chan struct{} {
+	return d.conn.Done()
+}
+
+type observation struct {
+	obs mux.Observation
+}
+
+func (o observation) Cancel(ctx context.Context) error {
+	return o.obs.Cancel(ctx)
+}
+
+// check returns the error of the device error response.
+func check(res *pool.Message) error {
+	code := res.Code()
+	if code >= codes.Created && code < codes.BadRequest {
+		return nil
+	}
+	err := errors.Wrap(ErrDeviceResponse, errors.New(code.String()))
+	if code == codes.NotFound {
+		return errors.Wrap(svcerr.ErrNotFound, err)
+	}
+
+	return err
+}
+
+func content(res *pool.Message) (Content, error) {
+	payload, err := res.ReadBody()
+	if err != nil {
+		return Content{}, err
+	}
+	format, err := res.ContentFormat()
+	if err != nil {
+		// Content format is elective, and the payload without it is
+		// considered to be plain text.
+		format = message.TextPlain
+	}
+
+	return Content{Format: uint16(format), Payload: payload}, nil
+}
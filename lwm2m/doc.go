@@ -0,0 +1,6 @@
+// Package lwm2m contains the LwM2M adapter service.
+// This service serves the LwM2M bootstrap and registration interfaces over
+// CoAP, maps the LwM2M endpoints to mitras clients and publishes the device
+// readings to the channels of the endpoints. It also provides a REST API to
+// read, write, execute and observe the device resources.
+package lwm2m
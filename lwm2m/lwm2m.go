@@ -0,0 +1,165 @@
+package lwm2m
+
+import (
+	"context"
+	"time"
+
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/senml"
+)
+
+// LwM2M content formats of the payloads exchanged with the devices.
+const (
+	TextPlain  uint16 = 0
+	LinkFormat uint16 = 40
+	Opaque     uint16 = 42
+	SenMLJSON  uint16 = 110
+	SenMLCBOR  uint16 = 112
+	TLV        uint16 = 11542
+)
+
+var (
+	// ErrNotRegistered indicates that the endpoint of the client isn't registered.
+	ErrNotRegistered = errors.New("endpoint is not registered")
+
+	// ErrUnsupportedFormat indicates the content format which can't be translated.
+	ErrUnsupportedFormat = errors.New("unsupported content format")
+
+	// ErrInvalidPath indicates the malformed path of the object, instance or resource.
+	ErrInvalidPath = errors.New("invalid object path")
+
+	// ErrDeviceResponse indicates the error response of the device.
+	ErrDeviceResponse = errors.New("device responded with error")
+)
+
+// Endpoint maps the LwM2M endpoint name to the mitras client. Read and
+// Observe results of the endpoint are published to the channel.
+type Endpoint struct {
+	ClientID  string    `json:"client_id" db:"client_id"`
+	DomainID  string    `json:"domain_id" db:"domain_id"`
+	Name      string    `json:"name" db:"name"`
+	ChannelID string    `json:"channel_id" db:"channel_id"`
+	CreatedBy string    `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Registration is the registration of the endpoint with the LwM2M server.
+type Registration struct {
+	ID           string        `json:"id"`
+	Endpoint     string        `json:"endpoint"`
+	ClientID     string        `json:"client_id"`
+	Lifetime     time.Duration `json:"lifetime"`
+	Version      string        `json:"version,omitempty"`
+	Binding      string        `json:"binding,omitempty"`
+	Objects      []string      `json:"objects,omitempty"`
+	Observations []string      `json:"observations,omitempty"`
+	RegisteredAt time.Time     `json:"registered_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// Content is the payload of the given content format.
+type Content struct {
+	Format  uint16
+	Payload []byte
+}
+
+// Observation is the observation of the device resource.
+type Observation interface {
+	// Cancel cancels the observation.
+	Cancel(ctx context.Context) error
+}
+
+// Device is the connection of the registered endpoint, which is used to
+// issue the device management requests.
+//
+//go:generate mockery --name Device --output=./mocks --filename device.go --quiet
+type Device interface {
+	// Read reads the object, object instance or resource.
+	Read(ctx context.Context, path string) (Content, error)
+
+	// Write writes the content to the object instance or resource.
+	Write(ctx context.Context, path string, content Content) error
+
+	// Execute executes the resource with the given arguments.
+	Execute(ctx context.Context, path, args string) error
+
+	// Delete deletes the object or object instance.
+	Delete(ctx context.Context, path string) error
+
+	// Observe observes the object, object instance or resource. The handler
+	// is called with the content of each notification.
+	Observe(ctx context.Context, path string, handler func(Content)) (Observation, error)
+
+	// BootstrapFinish ends the bootstrap sequence of the device.
+	BootstrapFinish(ctx context.Context) error
+
+	// Done returns a channel that's closed when the connection is closed.
+	Done() <-chan struct{}
+}
+
+// Service specifies the API of the LwM2M adapter.
+//
+//go:generate mockery --name Service --output=./mocks --filename service.go --quiet
+type Service interface {
+	// Bootstrap provisions the LwM2M server account of the endpoint. The
+	// key is used to authenticate the client of the endpoint.
+	Bootstrap(ctx context.Context, key, endpoint string, dev Device) error
+
+	// Register registers the endpoint and returns the registration ID. The
+	// key is used to authenticate the client of the endpoint.
+	Register(ctx context.Context, key string, reg Registration, dev Device) (string, error)
+
+	// Update updates the lifetime and the objects of the registration. The
+	// device may be updated from another connection, e.g. after the NAT
+	// rebinding. The key is used to authenticate the client of the
+	// registration.
+	Update(ctx context.Context, key, id string, lifetime time.Duration, objects []string, dev Device) error
+
+	// Deregister removes the registration and cancels its observations. The
+	// key is used to authenticate the client of the registration.
+	Deregister(ctx context.Context, key, id string) error
+
+	// CreateEndpoint maps the endpoint to the client.
+	CreateEndpoint(ctx context.Context, session smqauthn.Session, ep Endpoint) (Endpoint, error)
+
+	// ViewEndpoint returns the endpoint of the client and its registration.
+	ViewEndpoint(ctx context.Context, session smqauthn.Session, clientID string) (Endpoint, Registration, error)
+
+	// RemoveEndpoint removes the endpoint of the client.
+	RemoveEndpoint(ctx context.Context, session smqauthn.Session, clientID string) error
+
+	// Read reads the path of the registered endpoint of the client. The
+	// result is published to the channel of the endpoint.
+	Read(ctx context.Context, session smqauthn.Session, clientID, path string) (senml.Pack, error)
+
+	// Write writes the records to the path of the registered endpoint.
+	Write(ctx context.Context, session smqauthn.Session, clientID, path string, pack senml.Pack) error
+
+	// Execute executes the resource of the registered endpoint.
+	Execute(ctx context.Context, session smqauthn.Session, clientID, path, args string) error
+
+	// Observe observes the path of the registered endpoint. Notifications
+	// are published to the channel of the endpoint.
+	Observe(ctx context.Context, session smqauthn.Session, clientID, path string) error
+
+	// CancelObservation cancels the observation of the path.
+	CancelObservation(ctx context.Context, session smqauthn.Session, clientID, path string) error
+}
+
+// Repository specifies the endpoints persistence API.
+//
+//go:generate mockery --name Repository --output=./mocks --filename repository.go --quiet
+type Repository interface {
+	// Save persists the endpoint.
+	Save(ctx context.Context, ep Endpoint) (Endpoint, error)
+
+	// RetrieveByClient retrieves the endpoint of the client.
+	RetrieveByClient(ctx context.Context, clientID string) (Endpoint, error)
+
+	// RetrieveByName retrieves the endpoint by the endpoint name.
+	RetrieveByName(ctx context.Context, name string) (Endpoint, error)
+
+	// Remove removes the endpoint of the client.
+	Remove(ctx context.Context, clientID string) error
+}
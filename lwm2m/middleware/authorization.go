@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/hantdev/mitras/lwm2m"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	smqauthz "github.com/hantdev/mitras/pkg/authz"
+	"github.com/hantdev/mitras/pkg/policies"
+	"github.com/hantdev/senml"
+)
+
+var _ lwm2m.Service = (*authorizationMiddleware)(nil)
+
+type authorizationMiddleware struct {
+	svc   lwm2m.Service
+	authz smqauthz.Authorization
+}
+
+// AuthorizationMiddleware adds authorization to the LwM2M adapter service.
+// Users need the view permission on the client to read and observe its
+// endpoint, and the edit permission to manage the endpoint and to write and
+// execute its resources. Device requests are authenticated by the service.
+func AuthorizationMiddleware(svc lwm2m.Service, authz smqauthz.Authorization) lwm2m.Service {
+	return &authorizationMiddleware{
+		svc:   svc,
+		authz: authz,
+	}
+}
+
+func (am *authorizationMiddleware) Bootstrap(ctx context.Context, key, endpoint string, dev lwm2m.Device) error {
+	return am.svc.Bootstrap(ctx, key, endpoint, dev)
+}
+
+func (am *authorizationMiddleware) Register(ctx context.Context, key string, reg lwm2m.Registration, dev lwm2m.Device) (string, error) {
+	return am.svc.Register(ctx, key, reg, dev)
+}
+
+func (am *authorizationMiddleware) Update(ctx context.Context, key, id string, lifetime time.Duration, objects []string, dev lwm2m.Device) error {
+	return am.svc.Update(ctx, key, id, lifetime, objects, dev)
+}
+
+func (am *authorizationMiddleware) Deregister(ctx context.Context, key, id string) error {
+	return am.svc.Deregister(ctx, key, id)
+}
+
+func (am *authorizationMiddleware) CreateEndpoint(ctx context.Context, session smqauthn.Session, ep lwm2m.Endpoint) (lwm2m.Endpoint, error) {
+	if err := am.authorize(ctx, session, policies.EditPermission, policies.ClientType, ep.ClientID); err != nil {
+		return lwm2m.Endpoint{}, err
+	}
+	if err := am.authorize(ctx, session, policies.ViewPermission, policies.ChannelType, ep.ChannelID); err != nil {
+		return lwm2m.Endpoint{}, err
+	}
+
+	return am.svc.CreateEndpoint(ctx, session, ep)
+}
+
+func (am *authorizationMiddleware) ViewEndpoint(ctx context.Context, session smqauthn.Session, clientID string) (lwm2m.Endpoint, lwm2m.Registration, error) {
+	if err := am.authorize(ctx, session, policies.ViewPermission, policies.ClientType, clientID); err != nil {
+		return lwm2m.Endpoint{}, lwm2m.Registration{}, err
+	}
+
+	return am.svc.ViewEndpoint(ctx, session, clientID)
+}
+
+func (am *authorizationMiddleware) RemoveEndpoint(ctx context.Context, session smqauthn.Session, clientID string) error {
+	if err := am.authorize(ctx, session, policies.EditPermission, policies.ClientType, clientID); err != nil {
+		return err
+	}
+
+	return am.svc.RemoveEndpoint(ctx, session, clientID)
+}
+
+func (am *authorizationMiddleware) Read(ctx context.Context, session smqauthn.Session, clientID, path string) (senml.Pack, error) {
+	if err := am.authorize(ctx, session, policies.ViewPermission, policies.ClientType, clientID); err != nil {
+		return senml.Pack{}, err
+	}
+
+	return am.svc.Read(ctx, session, clientID, path)
+}
+
+func (am *authorizationMiddleware) Write(ctx context.Context, session smqauthn.Session, clientID, path string, pack senml.Pack) error {
+	if err := am.authorize(ctx, session, policies.EditPermission, policies.ClientType, clientID); err != nil {
+		return err
+	}
+
+	return am.svc.Write(ctx, session, clientID, path, pack)
+}
+
+func (am *authorizationMiddleware) Execute(ctx context.Context, session smqauthn.Session, clientID, path, args string) error {
+	if err := am.authorize(ctx, session, policies.EditPermission, policies.ClientType, clientID); err != nil {
+		return err
+	}
+
+	return am.svc.Execute(ctx, session, clientID, path, args)
+}
+
+func (am *authorizationMiddleware) Observe(ctx context.Context, session smqauthn.Session, clientID, path string) error {
+	if err := am.authorize(ctx, session, policies.ViewPermission, policies.ClientType, clientID); err != nil {
+		return err
+	}
+
+	return am.svc.Observe(ctx, session, clientID, path)
+}
+
+func (am *authorizationMiddleware) CancelObservation(ctx context.Context, session smqauthn.Session, clientID, path string) error {
+	if err := am.authorize(ctx, session, policies.ViewPermission, policies.ClientType, clientID); err != nil {
+		return err
+	}
+
+	return am.svc.CancelObservation(ctx, session, clientID, path)
+}
+
+func (am *authorizationMiddleware) authorize(ctx context.Context, session smqauthn.Session, permission, objectType, object string) error {
+	return am.authz.Authorize(ctx, smqauthz.PolicyReq{
+		Domain:      session.DomainID,
+		SubjectType: policies.UserType,
+		SubjectKind: policies.UsersKind,
+		Subject:     session.DomainUserID,
+		Permission:  permission,
+		ObjectType:  objectType,
+		Object:      object,
+	})
+}
@@ -0,0 +1,3 @@
+// Package middleware provides middleware for the LwM2M adapter service.
+// This is authorization and logging middleware.
+package middleware
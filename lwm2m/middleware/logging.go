@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/hantdev/mitras/lwm2m"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/senml"
+)
+
+var _ lwm2m.Service = (*loggingMiddleware)(nil)
+
+type loggingMiddleware struct {
+	logger *slog.Logger
+	svc    lwm2m.Service
+}
+
+// LoggingMiddleware adds logging facilities to the LwM2M adapter service.
+func LoggingMiddleware(svc lwm2m.Service, logger *slog.Logger) lwm2m.Service {
+	return &loggingMiddleware{
+		logger: logger,
+		svc:    svc,
+	}
+}
+
+func (lm *loggingMiddleware) Bootstrap(ctx context.Context, key, endpoint string, dev lwm2m.Device) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("endpoint", endpoint),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Bootstrap LwM2M endpoint failed", args...)
+			return
+		}
+		lm.logger.Info("Bootstrap LwM2M endpoint completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Bootstrap(ctx, key, endpoint, dev)
+}
+
+func (lm *loggingMiddleware) Register(ctx context.Context, key string, reg lwm2m.Registration, dev lwm2m.Device) (id string, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("registration",
+				slog.String("id", id),
+				slog.String("endpoint", reg.Endpoint),
+				slog.String("version", reg.Version),
+				slog.String("lifetime", reg.Lifetime.String()),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Register LwM2M endpoint failed", args...)
+			return
+		}
+		lm.logger.Info("Register LwM2M endpoint completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Register(ctx, key, reg, dev)
+}
+
+func (lm *loggingMiddleware) Update(ctx context.Context, key, id string, lifetime time.Duration, objects []string, dev lwm2m.Device) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("registration_id", id),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Update LwM2M registration failed", args...)
+			return
+		}
+		lm.logger.Info("Update LwM2M registration completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Update(ctx, key, id, lifetime, objects, dev)
+}
+
+func (lm *loggingMiddleware) Deregister(ctx context.Context, key, id string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("registration_id", id),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Deregister LwM2M endpoint failed", args...)
+			return
+		}
+		lm.logger.Info("Deregister LwM2M endpoint completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Deregister(ctx, key, id)
+}
+
+func (lm *loggingMiddleware) CreateEndpoint(ctx context.Context, session smqauthn.Session, ep lwm2m.Endpoint) (e lwm2m.Endpoint, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("endpoint",
+				slog.String("name", ep.Name),
+				slog.String("client_id", ep.ClientID),
+				slog.String("channel_id", ep.ChannelID),
+				slog.String("domain_id", session.DomainID),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Create LwM2M endpoint failed", args...)
+			return
+		}
+		lm.logger.Info("Create LwM2M endpoint completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.CreateEndpoint(ctx, session, ep)
+}
+
+func (lm *loggingMiddleware) ViewEndpoint(ctx context.Context, session smqauthn.Session, clientID string) (ep lwm2m.Endpoint, reg lwm2m.Registration, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("domain_id", session.DomainID),
+			slog.String("client_id", clientID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("View LwM2M endpoint failed", args...)
+			return
+		}
+		lm.logger.Info("View LwM2M endpoint completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ViewEndpoint(ctx, session, clientID)
+}
+
+func (lm *loggingMiddleware) RemoveEndpoint(ctx context.Context, session smqauthn.Session, clientID string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("domain_id", session.DomainID),
+			slog.String("client_id", clientID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Remove LwM2M endpoint failed", args...)
+			return
+		}
+		lm.logger.Info("Remove LwM2M endpoint completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.RemoveEndpoint(ctx, session, clientID)
+}
+
+func (lm *loggingMiddleware) Read(ctx context.Context, session smqauthn.Session, clientID, path string) (pack senml.Pack, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("client_id", clientID),
+			slog.String("path", path),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Read LwM2M resource failed", args...)
+			return
+		}
+		lm.logger.Info("Read LwM2M resource completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Read(ctx, session, clientID, path)
+}
+
+func (lm *loggingMiddleware) Write(ctx context.Context, session smqauthn.Session, clientID, path string, pack senml.Pack) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("client_id", clientID),
+			slog.String("path", path),
+			slog.Int("records", len(pack.Records)),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Write LwM2M resource failed", args...)
+			return
+		}
+		lm.logger.Info("Write LwM2M resource completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Write(ctx, session, clientID, path, pack)
+}
+
+func (lm *loggingMiddleware) Execute(ctx context.Context, session smqauthn.Session, clientID, path, args string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("client_id", clientID),
+			slog.String("path", path),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Execute LwM2M resource failed", args...)
+			return
+		}
+		lm.logger.Info("Execute LwM2M resource completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Execute(ctx, session, clientID, path, args)
+}
+
+func (lm *loggingMiddleware) Observe(ctx context.Context, session smqauthn.Session, clientID, path string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("client_id", clientID),
+			slog.String("path", path),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Observe LwM2M resource failed", args...)
+			return
+		}
+		lm.logger.Info("Observe LwM2M resource completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Observe(ctx, session, clientID, path)
+}
+
+func (lm *loggingMiddleware) CancelObservation(ctx context.Context, session smqauthn.Session, clientID, path string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("client_id", clientID),
+			slog.String("path", path),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Cancel LwM2M observation failed", args...)
+			return
+		}
+		lm.logger.Info("Cancel LwM2M observation completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.CancelObservation(ctx, session, clientID, path)
+}
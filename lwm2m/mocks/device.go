@@ -0,0 +1,179 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	lwm2m "github.com/hantdev/mitras/lwm2m"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Device is an autogenerated mock type for the Device type
+type Device struct {
+	mock.Mock
+}
+
+// BootstrapFinish provides a mock function with given fields: ctx
+func (_m *Device) BootstrapFinish(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BootstrapFinish")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: ctx, path
+func (_m *Device) Delete(ctx context.Context, path string) error {
+	ret := _m.Called(ctx, path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, path)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Done provides a mock function with no fields
+func (_m *Device) Done() <-chan struct{} {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Done")
+	}
+
+	var r0 <-chan struct{}
+	if rf, ok := ret.Get(0).(func() <-chan struct{}); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan struct{})
+		}
+	}
+
+	return r0
+}
+
+// Execute provides a mock function with given fields: ctx, path, args
+func (_m *Device) Execute(ctx context.Context, path string, args string) error {
+	ret := _m.Called(ctx, path, args)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Execute")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, path, args)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Observe provides a mock function with given fields: ctx, path, handler
+func (_m *Device) Observe(ctx context.Context, path string, handler func(lwm2m.Content)) (lwm2m.Observation, error) {
+	ret := _m.Called(ctx, path, handler)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Observe")
+	}
+
+	var r0 lwm2m.Observation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, func(lwm2m.Content)) (lwm2m.Observation, error)); ok {
+		return rf(ctx, path, handler)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, func(lwm2m.Content)) lwm2m.Observation); ok {
+		r0 = rf(ctx, path, handler)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(lwm2m.Observation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, func(lwm2m.Content)) error); ok {
+		r1 = rf(ctx, path, handler)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Read provides a mock function with given fields: ctx, path
+func (_m *Device) Read(ctx context.Context, path string) (lwm2m.Content, error) {
+	ret := _m.Called(ctx, path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Read")
+	}
+
+	var r0 lwm2m.Content
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (lwm2m.Content, error)); ok {
+		return rf(ctx, path)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) lwm2m.Content); ok {
+		r0 = rf(ctx, path)
+	} else {
+		r0 = ret.Get(0).(lwm2m.Content)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Write provides a mock function with given fields: ctx, path, content
+func (_m *Device) Write(ctx context.Context, path string, content lwm2m.Content) error {
+	ret := _m.Called(ctx, path, content)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Write")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, lwm2m.Content) error); ok {
+		r0 = rf(ctx, path, content)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewDevice creates a new instance of Device. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewDevice(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Device {
+	mock := &Device{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
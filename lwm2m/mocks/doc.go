@@ -0,0 +1,2 @@
+// Package mocks contains mocks for testing purposes.
+package mocks
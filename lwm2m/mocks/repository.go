@@ -0,0 +1,131 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	lwm2m "github.com/hantdev/mitras/lwm2m"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Repository is an autogenerated mock type for the Repository type
+type Repository struct {
+	mock.Mock
+}
+
+// Remove provides a mock function with given fields: ctx, clientID
+func (_m *Repository) Remove(ctx context.Context, clientID string) error {
+	ret := _m.Called(ctx, clientID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Remove")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, clientID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RetrieveByClient provides a mock function with given fields: ctx, clientID
+func (_m *Repository) RetrieveByClient(ctx context.Context, clientID string) (lwm2m.Endpoint, error) {
+	ret := _m.Called(ctx, clientID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveByClient")
+	}
+
+	var r0 lwm2m.Endpoint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (lwm2m.Endpoint, error)); ok {
+		return rf(ctx, clientID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) lwm2m.Endpoint); ok {
+		r0 = rf(ctx, clientID)
+	} else {
+		r0 = ret.Get(0).(lwm2m.Endpoint)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, clientID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RetrieveByName provides a mock function with given fields: ctx, name
+func (_m *Repository) RetrieveByName(ctx context.Context, name string) (lwm2m.Endpoint, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveByName")
+	}
+
+	var r0 lwm2m.Endpoint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (lwm2m.Endpoint, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) lwm2m.Endpoint); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(lwm2m.Endpoint)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, ep
+func (_m *Repository) Save(ctx context.Context, ep lwm2m.Endpoint) (lwm2m.Endpoint, error) {
+	ret := _m.Called(ctx, ep)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 lwm2m.Endpoint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, lwm2m.Endpoint) (lwm2m.Endpoint, error)); ok {
+		return rf(ctx, ep)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, lwm2m.Endpoint) lwm2m.Endpoint); ok {
+		r0 = rf(ctx, ep)
+	} else {
+		r0 = ret.Get(0).(lwm2m.Endpoint)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, lwm2m.Endpoint) error); ok {
+		r1 = rf(ctx, ep)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Repository {
+	mock := &Repository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,299 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	authn "github.com/hantdev/mitras/pkg/authn"
+
+	lwm2m "github.com/hantdev/mitras/lwm2m"
+
+	mock "github.com/stretchr/testify/mock"
+
+	senml "github.com/hantdev/senml"
+
+	time "time"
+)
+
+// Service is an autogenerated mock type for the Service type
+type Service struct {
+	mock.Mock
+}
+
+// Bootstrap provides a mock function with given fields: ctx, key, endpoint, dev
+func (_m *Service) Bootstrap(ctx context.Context, key string, endpoint string, dev lwm2m.Device) error {
+	ret := _m.Called(ctx, key, endpoint, dev)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Bootstrap")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, lwm2m.Device) error); ok {
+		r0 = rf(ctx, key, endpoint, dev)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CancelObservation provides a mock function with given fields: ctx, session, clientID, path
+func (_m *Service) CancelObservation(ctx context.Context, session authn.Session, clientID string, path string) error {
+	ret := _m.Called(ctx, session, clientID, path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelObservation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string, string) error); ok {
+		r0 = rf(ctx, session, clientID, path)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateEndpoint provides a mock function with given fields: ctx, session, ep
+func (_m *Service) CreateEndpoint(ctx context.Context, session authn.Session, ep lwm2m.Endpoint) (lwm2m.Endpoint, error) {
+	ret := _m.Called(ctx, session, ep)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateEndpoint")
+	}
+
+	var r0 lwm2m.Endpoint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, lwm2m.Endpoint) (lwm2m.Endpoint, error)); ok {
+		return rf(ctx, session, ep)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, lwm2m.Endpoint) lwm2m.Endpoint); ok {
+		r0 = rf(ctx, session, ep)
+	} else {
+		r0 = ret.Get(0).(lwm2m.Endpoint)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, lwm2m.Endpoint) error); ok {
+		r1 = rf(ctx, session, ep)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Deregister provides a mock function with given fields: ctx, key, id
+func (_m *Service) Deregister(ctx context.Context, key string, id string) error {
+	ret := _m.Called(ctx, key, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Deregister")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, key, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Execute provides a mock function with given fields: ctx, session, clientID, path, args
+func (_m *Service) Execute(ctx context.Context, session authn.Session, clientID string, path string, args string) error {
+	ret := _m.Called(ctx, session, clientID, path, args)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Execute")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string, string, string) error); ok {
+		r0 = rf(ctx, session, clientID, path, args)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Observe provides a mock function with given fields: ctx, session, clientID, path
+func (_m *Service) Observe(ctx context.Context, session authn.Session, clientID string, path string) error {
+	ret := _m.Called(ctx, session, clientID, path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Observe")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string, string) error); ok {
+		r0 = rf(ctx, session, clientID, path)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Read provides a mock function with given fields: ctx, session, clientID, path
+func (_m *Service) Read(ctx context.Context, session authn.Session, clientID string, path string) (senml.Pack, error) {
+	ret := _m.Called(ctx, session, clientID, path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Read")
+	}
+
+	var r0 senml.Pack
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string, string) (senml.Pack, error)); ok {
+		return rf(ctx, session, clientID, path)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string, string) senml.Pack); ok {
+		r0 = rf(ctx, session, clientID, path)
+	} else {
+		r0 = ret.Get(0).(senml.Pack)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, string, string) error); ok {
+		r1 = rf(ctx, session, clientID, path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Register provides a mock function with given fields: ctx, key, reg, dev
+func (_m *Service) Register(ctx context.Context, key string, reg lwm2m.Registration, dev lwm2m.Device) (string, error) {
+	ret := _m.Called(ctx, key, reg, dev)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Register")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, lwm2m.Registration, lwm2m.Device) (string, error)); ok {
+		return rf(ctx, key, reg, dev)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, lwm2m.Registration, lwm2m.Device) string); ok {
+		r0 = rf(ctx, key, reg, dev)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, lwm2m.Registration, lwm2m.Device) error); ok {
+		r1 = rf(ctx, key, reg, dev)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveEndpoint provides a mock function with given fields: ctx, session, clientID
+func (_m *Service) RemoveEndpoint(ctx context.Context, session authn.Session, clientID string) error {
+	ret := _m.Called(ctx, session, clientID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveEndpoint")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) error); ok {
+		r0 = rf(ctx, session, clientID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, key, id, lifetime, objects, dev
+func (_m *Service) Update(ctx context.Context, key string, id string, lifetime time.Duration, objects []string, dev lwm2m.Device) error {
+	ret := _m.Called(ctx, key, id, lifetime, objects, dev)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration, []string, lwm2m.Device) error); ok {
+		r0 = rf(ctx, key, id, lifetime, objects, dev)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ViewEndpoint provides a mock function with given fields: ctx, session, clientID
+func (_m *Service) ViewEndpoint(ctx context.Context, session authn.Session, clientID string) (lwm2m.Endpoint, lwm2m.Registration, error) {
+	ret := _m.Called(ctx, session, clientID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ViewEndpoint")
+	}
+
+	var r0 lwm2m.Endpoint
+	var r1 lwm2m.Registration
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) (lwm2m.Endpoint, lwm2m.Registration, error)); ok {
+		return rf(ctx, session, clientID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) lwm2m.Endpoint); ok {
+		r0 = rf(ctx, session, clientID)
+	} else {
+		r0 = ret.Get(0).(lwm2m.Endpoint)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, string) lwm2m.Registration); ok {
+		r1 = rf(ctx, session, clientID)
+	} else {
+		r1 = ret.Get(1).(lwm2m.Registration)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, authn.Session, string) error); ok {
+		r2 = rf(ctx, session, clientID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Write provides a mock function with given fields: ctx, session, clientID, path, pack
+func (_m *Service) Write(ctx context.Context, session authn.Session, clientID string, path string, pack senml.Pack) error {
+	ret := _m.Called(ctx, session, clientID, path, pack)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Write")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string, string, senml.Pack) error); ok {
+		r0 = rf(ctx, session, clientID, path, pack)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewService creates a new instance of Service. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Service {
+	mock := &Service{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,3 @@
+// Package postgres provides a postgres implementation of the LwM2M endpoints
+// repository.
+package postgres
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/hantdev/mitras/lwm2m"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/hantdev/mitras/pkg/postgres"
+)
+
+type repository struct {
+	db postgres.Database
+}
+
+// NewRepository instantiates a PostgreSQL implementation of the LwM2M
+// endpoints repository.
+func NewRepository(db postgres.Database) lwm2m.Repository {
+	return &repository{db: db}
+}
+
+func (repo *repository) Save(ctx context.Context, ep lwm2m.Endpoint) (lwm2m.Endpoint, error) {
+	q := `INSERT INTO lwm2m_endpoints (client_id, domain_id, name, channel_id, created_by, created_at)
+		VALUES (:client_id, :domain_id, :name, :channel_id, :created_by, :created_at);`
+
+	if _, err := repo.db.NamedExecContext(ctx, q, ep); err != nil {
+		return lwm2m.Endpoint{}, postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+
+	return ep, nil
+}
+
+func (repo *repository) RetrieveByClient(ctx context.Context, clientID string) (lwm2m.Endpoint, error) {
+	q := `SELECT client_id, domain_id, name, channel_id, COALESCE(created_by, '') AS created_by, created_at
+		FROM lwm2m_endpoints WHERE client_id = :client_id;`
+
+	return repo.retrieve(ctx, q, lwm2m.Endpoint{ClientID: clientID})
+}
+
+func (repo *repository) RetrieveByName(ctx context.Context, name string) (lwm2m.Endpoint, error) {
+	q := `SELECT client_id, domain_id, name, channel_id, COALESCE(created_by, '') AS created_by, created_at
+		FROM lwm2m_endpoints WHERE name = :name;`
+
+	return repo.retrieve(ctx, q, lwm2m.Endpoint{Name: name})
+}
+
+func (repo *repository) Remove(ctx context.Context, clientID string) error {
+	q := `DELETE FROM lwm2m_endpoints WHERE client_id = $1;`
+
+	res, err := repo.db.ExecContext(ctx, q, clientID)
+	if err != nil {
+		return postgres.HandleError(repoerr.ErrRemoveEntity, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo *repository) retrieve(ctx context.Context, q string, arg lwm2m.Endpoint) (lwm2m.Endpoint, error) {
+	rows, err := repo.db.NamedQueryContext(ctx, q, arg)
+	if err != nil {
+		return lwm2m.Endpoint{}, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	var ep lwm2m.Endpoint
+	if !rows.Next() {
+		return lwm2m.Endpoint{}, repoerr.ErrNotFound
+	}
+	if err := rows.StructScan(&ep); err != nil {
+		return lwm2m.Endpoint{}, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+
+	return ep, nil
+}
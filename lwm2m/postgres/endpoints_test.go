@@ -0,0 +1,132 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/internal/testsutil"
+	"github.com/hantdev/mitras/lwm2m"
+	"github.com/hantdev/mitras/lwm2m/postgres"
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEndpoint(t *testing.T, name string) lwm2m.Endpoint {
+	return lwm2m.Endpoint{
+		ClientID:  testsutil.GenerateUUID(t),
+		DomainID:  testsutil.GenerateUUID(t),
+		Name:      name,
+		ChannelID: testsutil.GenerateUUID(t),
+		CreatedBy: testsutil.GenerateUUID(t),
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
+
+func TestSave(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := db.Exec("DELETE FROM lwm2m_endpoints")
+		require.Nil(t, err, fmt.Sprintf("clean endpoints unexpected error: %s", err))
+	})
+	repo := postgres.NewRepository(database)
+
+	ep := newEndpoint(t, "urn:dev:1")
+	duplicateName := newEndpoint(t, ep.Name)
+	duplicateClient := newEndpoint(t, "urn:dev:2")
+	duplicateClient.ClientID = ep.ClientID
+
+	cases := []struct {
+		desc string
+		ep   lwm2m.Endpoint
+		err  error
+	}{
+		{
+			desc: "save new endpoint",
+			ep:   ep,
+			err:  nil,
+		},
+		{
+			desc: "save endpoint with duplicate name",
+			ep:   duplicateName,
+			err:  repoerr.ErrConflict,
+		},
+		{
+			desc: "save endpoint with duplicate client",
+			ep:   duplicateClient,
+			err:  repoerr.ErrConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			saved, err := repo.Save(context.Background(), tc.ep)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("expected error %s, got %s", tc.err, err))
+			if err == nil {
+				assert.Equal(t, tc.ep, saved)
+			}
+		})
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := db.Exec("DELETE FROM lwm2m_endpoints")
+		require.Nil(t, err, fmt.Sprintf("clean endpoints unexpected error: %s", err))
+	})
+	repo := postgres.NewRepository(database)
+
+	ep, err := repo.Save(context.Background(), newEndpoint(t, "urn:dev:1"))
+	require.Nil(t, err, fmt.Sprintf("save endpoint unexpected error: %s", err))
+
+	byClient, err := repo.RetrieveByClient(context.Background(), ep.ClientID)
+	assert.Nil(t, err, fmt.Sprintf("retrieve by client unexpected error: %s", err))
+	assert.Equal(t, ep, byClient)
+
+	byName, err := repo.RetrieveByName(context.Background(), ep.Name)
+	assert.Nil(t, err, fmt.Sprintf("retrieve by name unexpected error: %s", err))
+	assert.Equal(t, ep, byName)
+
+	_, err = repo.RetrieveByClient(context.Background(), testsutil.GenerateUUID(t))
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+
+	_, err = repo.RetrieveByName(context.Background(), "urn:dev:unknown")
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+}
+
+func TestRemove(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := db.Exec("DELETE FROM lwm2m_endpoints")
+		require.Nil(t, err, fmt.Sprintf("clean endpoints unexpected error: %s", err))
+	})
+	repo := postgres.NewRepository(database)
+
+	ep, err := repo.Save(context.Background(), newEndpoint(t, "urn:dev:1"))
+	require.Nil(t, err, fmt.Sprintf("save endpoint unexpected error: %s", err))
+
+	cases := []struct {
+		desc     string
+		clientID string
+		err      error
+	}{
+		{
+			desc:     "remove existing endpoint",
+			clientID: ep.ClientID,
+			err:      nil,
+		},
+		{
+			desc:     "remove removed endpoint",
+			clientID: ep.ClientID,
+			err:      repoerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := repo.Remove(context.Background(), tc.clientID)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("expected error %s, got %s", tc.err, err))
+		})
+	}
+}
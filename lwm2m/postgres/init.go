@@ -0,0 +1,29 @@
+package postgres
+
+import (
+	_ "github.com/jackc/pgx/v5/stdlib" // required for SQL access
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+func Migration() *migrate.MemoryMigrationSource {
+	return &migrate.MemoryMigrationSource{
+		Migrations: []*migrate.Migration{
+			{
+				Id: "lwm2m_01",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS lwm2m_endpoints (
+						client_id	VARCHAR(36) PRIMARY KEY,
+						domain_id	VARCHAR(36) NOT NULL,
+						name		VARCHAR(1024) NOT NULL UNIQUE,
+						channel_id	VARCHAR(36) NOT NULL,
+						created_by	VARCHAR(254),
+						created_at	TIMESTAMP NOT NULL
+					)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS lwm2m_endpoints`,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,558 @@
+package lwm2m
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hantdev/mitras"
+	"github.com/hantdev/mitras/coap"
+	grpcChannelsV1 "github.com/hantdev/mitras/internal/grpc/channels/v1"
+	grpcClientsV1 "github.com/hantdev/mitras/internal/grpc/clients/v1"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/connections"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/mitras/pkg/messaging"
+	"github.com/hantdev/mitras/pkg/policies"
+	"github.com/hantdev/senml"
+)
+
+const (
+	protocol = "lwm2m"
+
+	// defLifetime is the registration lifetime of the endpoints which don't
+	// send the lifetime, as defined by the LwM2M specification.
+	defLifetime = 86400 * time.Second
+
+	// bootstrapTimeout is the time allowed to provision the device.
+	bootstrapTimeout = 30 * time.Second
+
+	// shortServerID is the short ID of the LwM2M server account written to
+	// the devices in bootstrap.
+	shortServerID = 1
+)
+
+// Security modes of the LwM2M Security object.
+const (
+	securityPSK  = 0
+	securityNone = 3
+)
+
+// Config is the configuration of the LwM2M server account written to the
+// devices in bootstrap.
+type Config struct {
+	// ServerURI is the URI of the LwM2M server. Devices use the DTLS PSK
+	// security mode with the client ID as the identity and the client secret
+	// as the key if the URI scheme is coaps.
+	ServerURI string
+
+	// Lifetime is the registration lifetime of the devices.
+	Lifetime time.Duration
+}
+
+// registration is the registration of the endpoint. Observations of the
+// registration are identified by the path.
+type registration struct {
+	Registration
+	endpoint     Endpoint
+	dev          Device
+	observations map[string]Observation
+	expiry       *time.Timer
+	generation   uint64
+}
+
+type service struct {
+	repo          Repository
+	clients       grpcClientsV1.ClientsServiceClient
+	channels      grpcChannelsV1.ChannelsServiceClient
+	publisher     messaging.Publisher
+	idProvider    mitras.IDProvider
+	config        Config
+	logger        *slog.Logger
+	mu            sync.Mutex
+	registrations map[string]*registration
+	byClient      map[string]*registration
+}
+
+var _ Service = (*service)(nil)
+
+// NewService instantiates the LwM2M adapter service.
+func NewService(repo Repository, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient, publisher messaging.Publisher, idProvider mitras.IDProvider, config Config, logger *slog.Logger) Service {
+	return &service{
+		repo:          repo,
+		clients:       clients,
+		channels:      channels,
+		publisher:     publisher,
+		idProvider:    idProvider,
+		config:        config,
+		logger:        logger,
+		registrations: make(map[string]*registration),
+		byClient:      make(map[string]*registration),
+	}
+}
+
+func (svc *service) Bootstrap(ctx context.Context, key, endpoint string, dev Device) error {
+	ep, err := svc.identify(ctx, key, endpoint)
+	if err != nil {
+		return err
+	}
+
+	security := []tlv{
+		{typ: tlvResource, id: 0, value: []byte(svc.config.ServerURI)},
+		{typ: tlvResource, id: 1, value: []byte{0}},
+		{typ: tlvResource, id: 2, value: []byte{securityNone}},
+		{typ: tlvResource, id: 10, value: []byte{shortServerID}},
+	}
+	if u, err := url.Parse(svc.config.ServerURI); err == nil && u.Scheme == "coaps" {
+		res, err := svc.clients.RetrieveSecret(ctx, &grpcClientsV1.RetrieveSecretReq{Id: ep.ClientID})
+		if err != nil {
+			return errors.Wrap(svcerr.ErrAuthentication, err)
+		}
+		security[2].value = []byte{securityPSK}
+		security = append(security,
+			tlv{typ: tlvResource, id: 3, value: []byte(ep.ClientID)},
+			tlv{typ: tlvResource, id: 5, value: []byte(res.GetSecret())},
+		)
+	}
+	lifetime := int64(svc.config.Lifetime / time.Second)
+	server := []tlv{
+		{typ: tlvResource, id: 0, value: []byte{shortServerID}},
+		{typ: tlvResource, id: 1, value: encodeInt(lifetime)},
+		{typ: tlvResource, id: 7, value: []byte("U")},
+	}
+
+	// Bootstrap requests are sent once the device receives the response to
+	// the bootstrap request, so they are sent without blocking the handler.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), bootstrapTimeout)
+		defer cancel()
+		if err := svc.provision(ctx, dev, security, server); err != nil {
+			svc.logger.Warn(fmt.Sprintf("Failed to bootstrap endpoint %s: %s", endpoint, err))
+			return
+		}
+		svc.logger.Info(fmt.Sprintf("Bootstrapped endpoint %s of client %s", endpoint, ep.ClientID))
+	}()
+
+	return nil
+}
+
+func (svc *service) provision(ctx context.Context, dev Device, security, server []tlv) error {
+	if err := dev.Delete(ctx, "/"); err != nil {
+		return err
+	}
+	if err := dev.Write(ctx, fmt.Sprintf("/0/%d", shortServerID), Content{Format: TLV, Payload: encodeTLV(security)}); err != nil {
+		return err
+	}
+	if err := dev.Write(ctx, fmt.Sprintf("/1/%d", shortServerID), Content{Format: TLV, Payload: encodeTLV(server)}); err != nil {
+		return err
+	}
+
+	return dev.BootstrapFinish(ctx)
+}
+
+func (svc *service) Register(ctx context.Context, key string, r Registration, dev Device) (string, error) {
+	ep, err := svc.identify(ctx, key, r.Endpoint)
+	if err != nil {
+		return "", err
+	}
+	id, err := svc.idProvider.ID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	r.ID = id
+	r.ClientID = ep.ClientID
+	r.RegisteredAt = now
+	r.UpdatedAt = now
+	if r.Lifetime <= 0 {
+		r.Lifetime = defLifetime
+	}
+	reg := &registration{
+		Registration: r,
+		endpoint:     ep,
+		dev:          dev,
+		observations: make(map[string]Observation),
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	// The endpoint which registers again replaces its previous registration.
+	if old, ok := svc.byClient[ep.ClientID]; ok {
+		svc.remove(old)
+	}
+	svc.registrations[id] = reg
+	svc.byClient[ep.ClientID] = reg
+	svc.schedule(reg)
+
+	return id, nil
+}
+
+func (svc *service) Update(ctx context.Context, key, id string, lifetime time.Duration, objects []string, dev Device) error {
+	clientID, err := svc.owner(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	reg, ok := svc.registrations[id]
+	if !ok {
+		return errors.Wrap(svcerr.ErrNotFound, ErrNotRegistered)
+	}
+	if clientID != reg.ClientID {
+		return svcerr.ErrAuthorization
+	}
+
+	if lifetime > 0 {
+		reg.Lifetime = lifetime
+	}
+	if len(objects) > 0 {
+		reg.Objects = objects
+	}
+	if dev != nil {
+		reg.dev = dev
+	}
+	reg.UpdatedAt = time.Now().UTC()
+	svc.schedule(reg)
+
+	return nil
+}
+
+func (svc *service) Deregister(ctx context.Context, key, id string) error {
+	clientID, err := svc.owner(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	reg, ok := svc.registrations[id]
+	if !ok {
+		return errors.Wrap(svcerr.ErrNotFound, ErrNotRegistered)
+	}
+	if clientID != reg.ClientID {
+		return svcerr.ErrAuthorization
+	}
+	svc.remove(reg)
+
+	return nil
+}
+
+func (svc *service) CreateEndpoint(ctx context.Context, session smqauthn.Session, ep Endpoint) (Endpoint, error) {
+	ep.DomainID = session.DomainID
+	ep.CreatedBy = session.UserID
+	ep.CreatedAt = time.Now().UTC()
+
+	ep, err := svc.repo.Save(ctx, ep)
+	if err != nil {
+		return Endpoint{}, errors.Wrap(svcerr.ErrCreateEntity, err)
+	}
+
+	return ep, nil
+}
+
+func (svc *service) ViewEndpoint(ctx context.Context, session smqauthn.Session, clientID string) (Endpoint, Registration, error) {
+	ep, err := svc.repo.RetrieveByClient(ctx, clientID)
+	if err != nil {
+		return Endpoint{}, Registration{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	reg, ok := svc.byClient[clientID]
+	if !ok {
+		return ep, Registration{}, nil
+	}
+	r := reg.Registration
+	for path := range reg.observations {
+		r.Observations = append(r.Observations, path)
+	}
+	sort.Strings(r.Observations)
+
+	return ep, r, nil
+}
+
+func (svc *service) RemoveEndpoint(ctx context.Context, session smqauthn.Session, clientID string) error {
+	if err := svc.repo.Remove(ctx, clientID); err != nil {
+		return errors.Wrap(svcerr.ErrRemoveEntity, err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	if reg, ok := svc.byClient[clientID]; ok {
+		svc.remove(reg)
+	}
+
+	return nil
+}
+
+func (svc *service) Read(ctx context.Context, session smqauthn.Session, clientID, path string) (senml.Pack, error) {
+	reg, err := svc.registration(clientID)
+	if err != nil {
+		return senml.Pack{}, err
+	}
+
+	c, err := reg.dev.Read(ctx, path)
+	if err != nil {
+		return senml.Pack{}, err
+	}
+	pack, err := toPack(path, c)
+	if err != nil {
+		return senml.Pack{}, err
+	}
+	if err := svc.publish(ctx, reg.endpoint, path, pack); err != nil {
+		return senml.Pack{}, err
+	}
+
+	return pack, nil
+}
+
+func (svc *service) Write(ctx context.Context, session smqauthn.Session, clientID, path string, pack senml.Pack) error {
+	reg, err := svc.registration(clientID)
+	if err != nil {
+		return err
+	}
+
+	// SenML is supported since LwM2M 1.1, and the older devices are written
+	// using TLV.
+	format := TLV
+	if reg.Version != "" && reg.Version != "1.0" {
+		format = SenMLCBOR
+	}
+	c, err := fromPack(path, pack, format)
+	if err != nil {
+		return errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+
+	return reg.dev.Write(ctx, path, c)
+}
+
+func (svc *service) Execute(ctx context.Context, session smqauthn.Session, clientID, path, args string) error {
+	reg, err := svc.registration(clientID)
+	if err != nil {
+		return err
+	}
+
+	return reg.dev.Execute(ctx, path, args)
+}
+
+func (svc *service) Observe(ctx context.Context, session smqauthn.Session, clientID, path string) error {
+	reg, err := svc.registration(clientID)
+	if err != nil {
+		return err
+	}
+
+	svc.mu.Lock()
+	_, ok := reg.observations[path]
+	svc.mu.Unlock()
+	if ok {
+		return nil
+	}
+
+	ep := reg.endpoint
+	obs, err := reg.dev.Observe(context.WithoutCancel(ctx), path, func(c Content) {
+		pack, err := toPack(path, c)
+		if err == nil {
+			err = svc.publish(context.Background(), ep, path, pack)
+		}
+		if err != nil {
+			svc.logger.Warn(fmt.Sprintf("Failed to publish notification of %s of endpoint %s: %s", path, ep.Name, err))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	// The registration may be removed or replaced while the observation is
+	// being established.
+	if svc.registrations[reg.ID] != reg {
+		return obs.Cancel(context.WithoutCancel(ctx))
+	}
+	if _, ok := reg.observations[path]; ok {
+		return obs.Cancel(context.WithoutCancel(ctx))
+	}
+	reg.observations[path] = obs
+
+	return nil
+}
+
+func (svc *service) CancelObservation(ctx context.Context, session smqauthn.Session, clientID, path string) error {
+	reg, err := svc.registration(clientID)
+	if err != nil {
+		return err
+	}
+
+	svc.mu.Lock()
+	obs, ok := reg.observations[path]
+	delete(reg.observations, path)
+	svc.mu.Unlock()
+	if !ok {
+		return svcerr.ErrNotFound
+	}
+
+	return obs.Cancel(ctx)
+}
+
+// identify authenticates the client of the endpoint. Clients authenticated
+// by the transport, e.g. by the DTLS handshake, are identified by the client
+// ID from the context, otherwise the key is used.
+func (svc *service) identify(ctx context.Context, key, endpoint string) (Endpoint, error) {
+	clientID, err := svc.authenticate(ctx, key)
+	if err != nil {
+		return Endpoint{}, err
+	}
+
+	ep, err := svc.repo.RetrieveByName(ctx, endpoint)
+	if err != nil {
+		return Endpoint{}, errors.Wrap(svcerr.ErrAuthorization, err)
+	}
+	if ep.ClientID != clientID {
+		return Endpoint{}, svcerr.ErrAuthorization
+	}
+
+	return ep, nil
+}
+
+// authenticate returns the ID of the client authenticated by the transport,
+// or by the client key.
+func (svc *service) authenticate(ctx context.Context, key string) (string, error) {
+	if clientID, ok := coap.ClientID(ctx); ok {
+		return clientID, nil
+	}
+	res, err := svc.clients.Authenticate(ctx, &grpcClientsV1.AuthnReq{ClientSecret: key})
+	if err != nil {
+		return "", errors.Wrap(svcerr.ErrAuthentication, err)
+	}
+	if !res.GetAuthenticated() {
+		return "", svcerr.ErrAuthentication
+	}
+
+	return res.GetId(), nil
+}
+
+// owner returns the ID of the client which requests the change of the
+// registration. Requests without the client identity aren't allowed to change
+// any registration.
+func (svc *service) owner(ctx context.Context, key string) (string, error) {
+	if _, ok := coap.ClientID(ctx); !ok && key == "" {
+		return "", svcerr.ErrAuthorization
+	}
+
+	return svc.authenticate(ctx, key)
+}
+
+func (svc *service) registration(clientID string) (*registration, error) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	reg, ok := svc.byClient[clientID]
+	if !ok {
+		return nil, errors.Wrap(svcerr.ErrNotFound, ErrNotRegistered)
+	}
+
+	return reg, nil
+}
+
+// publish publishes the records read from the path of the endpoint to the
+// channel of the endpoint.
+func (svc *service) publish(ctx context.Context, ep Endpoint, path string, pack senml.Pack) error {
+	payload, err := senml.Encode(pack, senml.JSON)
+	if err != nil {
+		return err
+	}
+
+	res, err := svc.channels.Authorize(ctx, &grpcChannelsV1.AuthzReq{
+		ClientId:   ep.ClientID,
+		ClientType: policies.ClientType,
+		Type:       uint32(connections.Publish),
+		ChannelId:  ep.ChannelID,
+	})
+	if err != nil {
+		return errors.Wrap(svcerr.ErrAuthorization, err)
+	}
+	if !res.GetAuthorized() {
+		return svcerr.ErrAuthorization
+	}
+
+	msg := &messaging.Message{
+		Protocol:  protocol,
+		Channel:   ep.ChannelID,
+		Subtopic:  strings.ReplaceAll(strings.Trim(path, "/"), "/", "."),
+		Publisher: ep.ClientID,
+		Payload:   payload,
+		Created:   time.Now().UnixNano(),
+	}
+
+	return svc.publisher.Publish(ctx, msg.GetChannel(), msg)
+}
+
+// schedule schedules the expiry of the registration after its lifetime. It
+// must be called with the service lock held.
+func (svc *service) schedule(reg *registration) {
+	if reg.expiry != nil {
+		reg.expiry.Stop()
+	}
+	reg.generation++
+	generation := reg.generation
+	reg.expiry = time.AfterFunc(reg.Lifetime, func() {
+		svc.expire(reg, generation)
+	})
+}
+
+// expire removes the registration unless it was updated after the expiry of
+// the given generation was scheduled.
+func (svc *service) expire(reg *registration, generation uint64) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	if reg.generation != generation || svc.registrations[reg.ID] != reg {
+		return
+	}
+	svc.logger.Info(fmt.Sprintf("Registration of endpoint %s expired", reg.Endpoint))
+	svc.remove(reg)
+}
+
+// remove removes the registration and cancels its observations. It must be
+// called with the service lock held.
+func (svc *service) remove(reg *registration) {
+	if reg.expiry != nil {
+		reg.expiry.Stop()
+	}
+	delete(svc.registrations, reg.ID)
+	if svc.byClient[reg.ClientID] == reg {
+		delete(svc.byClient, reg.ClientID)
+	}
+
+	observations := reg.observations
+	reg.observations = make(map[string]Observation)
+	// Observations are cancelled without blocking, since the device of the
+	// expired registration may not respond.
+	go func() {
+		for path, obs := range observations {
+			if err := obs.Cancel(context.Background()); err != nil {
+				svc.logger.Debug(fmt.Sprintf("Failed to cancel observation of %s of endpoint %s: %s", path, reg.Endpoint, err))
+			}
+		}
+	}()
+}
+
+// encodeInt encodes the integer as the TLV resource value.
+func encodeInt(n int64) []byte {
+	v := float64(n)
+	b, _ := tlvValue(senml.Record{Value: &v})
+	return b
+}
@@ -0,0 +1,556 @@
+package lwm2m_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	chmocks "github.com/hantdev/mitras/channels/mocks"
+	clmocks "github.com/hantdev/mitras/clients/mocks"
+	"github.com/hantdev/mitras/coap"
+	grpcChannelsV1 "github.com/hantdev/mitras/internal/grpc/channels/v1"
+	grpcClientsV1 "github.com/hantdev/mitras/internal/grpc/clients/v1"
+	"github.com/hantdev/mitras/internal/testsutil"
+	mglog "github.com/hantdev/mitras/logger"
+	"github.com/hantdev/mitras/lwm2m"
+	"github.com/hantdev/mitras/lwm2m/mocks"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/mitras/pkg/messaging"
+	msgmocks "github.com/hantdev/mitras/pkg/messaging/mocks"
+	"github.com/hantdev/mitras/pkg/uuid"
+	"github.com/hantdev/senml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	endpointName = "urn:dev:os:mitras-1"
+	clientKey    = "client-key"
+	serverURI    = "coaps://localhost:5684"
+)
+
+var (
+	clientID = testsutil.GenerateUUID(&testing.T{})
+	chanID   = testsutil.GenerateUUID(&testing.T{})
+	endpoint = lwm2m.Endpoint{ClientID: clientID, Name: endpointName, ChannelID: chanID}
+	session  = smqauthn.Session{UserID: testsutil.GenerateUUID(&testing.T{}), DomainID: testsutil.GenerateUUID(&testing.T{})}
+)
+
+type observation struct {
+	mu        sync.Mutex
+	cancelled bool
+}
+
+func (o *observation) Cancel(context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.cancelled = true
+	return nil
+}
+
+func (o *observation) isCancelled() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.cancelled
+}
+
+func newService(lifetime time.Duration) (lwm2m.Service, *mocks.Repository, *clmocks.ClientsServiceClient, *chmocks.ChannelsServiceClient, *msgmocks.PubSub) {
+	repo := new(mocks.Repository)
+	clients := new(clmocks.ClientsServiceClient)
+	channels := new(chmocks.ChannelsServiceClient)
+	pubsub := new(msgmocks.PubSub)
+	config := lwm2m.Config{ServerURI: serverURI, Lifetime: lifetime}
+
+	return lwm2m.NewService(repo, clients, channels, pubsub, uuid.NewMock(), config, mglog.NewMock()), repo, clients, channels, pubsub
+}
+
+func register(t *testing.T, svc lwm2m.Service, repo *mocks.Repository, reg lwm2m.Registration, dev lwm2m.Device) string {
+	repoCall := repo.On("RetrieveByName", mock.Anything, endpointName).Return(endpoint, nil)
+	defer repoCall.Unset()
+
+	reg.Endpoint = endpointName
+	id, err := svc.Register(coap.WithClientID(context.Background(), clientID), "", reg, dev)
+	require.Nil(t, err, fmt.Sprintf("register unexpected error: %s", err))
+
+	return id
+}
+
+func TestRegister(t *testing.T) {
+	svc, repo, clients, _, _ := newService(time.Hour)
+
+	cases := []struct {
+		desc     string
+		ctx      context.Context
+		key      string
+		authnRes *grpcClientsV1.AuthnRes
+		authnErr error
+		endpoint lwm2m.Endpoint
+		repoErr  error
+		err      error
+	}{
+		{
+			desc:     "register with client key successfully",
+			ctx:      context.Background(),
+			key:      clientKey,
+			authnRes: &grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true},
+			endpoint: endpoint,
+		},
+		{
+			desc:     "register with DTLS identity successfully",
+			ctx:      coap.WithClientID(context.Background(), clientID),
+			authnErr: svcerr.ErrAuthentication,
+			endpoint: endpoint,
+		},
+		{
+			desc:     "register with invalid client key",
+			ctx:      context.Background(),
+			key:      "invalid",
+			authnRes: &grpcClientsV1.AuthnRes{Authenticated: false},
+			err:      svcerr.ErrAuthentication,
+		},
+		{
+			desc:     "register with failed authentication",
+			ctx:      context.Background(),
+			key:      clientKey,
+			authnRes: &grpcClientsV1.AuthnRes{},
+			authnErr: svcerr.ErrAuthentication,
+			err:      svcerr.ErrAuthentication,
+		},
+		{
+			desc:     "register unknown endpoint",
+			ctx:      coap.WithClientID(context.Background(), clientID),
+			endpoint: lwm2m.Endpoint{},
+			repoErr:  repoerr.ErrNotFound,
+			err:      svcerr.ErrAuthorization,
+		},
+		{
+			desc:     "register endpoint of another client",
+			ctx:      coap.WithClientID(context.Background(), testsutil.GenerateUUID(t)),
+			endpoint: endpoint,
+			err:      svcerr.ErrAuthorization,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			authnCall := clients.On("Authenticate", mock.Anything, &grpcClientsV1.AuthnReq{ClientSecret: tc.key}).Return(tc.authnRes, tc.authnErr)
+			repoCall := repo.On("RetrieveByName", mock.Anything, endpointName).Return(tc.endpoint, tc.repoErr)
+			id, err := svc.Register(tc.ctx, tc.key, lwm2m.Registration{Endpoint: endpointName, Version: "1.1"}, new(mocks.Device))
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if tc.err == nil {
+				assert.NotEmpty(t, id, fmt.Sprintf("%s: expected non-empty registration ID", tc.desc))
+			}
+			authnCall.Unset()
+			repoCall.Unset()
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	svc, repo, clients, _, _ := newService(time.Hour)
+	id := register(t, svc, repo, lwm2m.Registration{}, new(mocks.Device))
+
+	cases := []struct {
+		desc     string
+		ctx      context.Context
+		key      string
+		id       string
+		authnRes *grpcClientsV1.AuthnRes
+		authnErr error
+		err      error
+	}{
+		{
+			desc: "update registration with DTLS identity successfully",
+			ctx:  coap.WithClientID(context.Background(), clientID),
+			id:   id,
+		},
+		{
+			desc:     "update registration with client key successfully",
+			ctx:      context.Background(),
+			key:      clientKey,
+			id:       id,
+			authnRes: &grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true},
+		},
+		{
+			desc: "update unknown registration",
+			ctx:  coap.WithClientID(context.Background(), clientID),
+			id:   testsutil.GenerateUUID(t),
+			err:  svcerr.ErrNotFound,
+		},
+		{
+			desc: "update registration without client identity",
+			ctx:  context.Background(),
+			id:   id,
+			err:  svcerr.ErrAuthorization,
+		},
+		{
+			desc:     "update registration with invalid client key",
+			ctx:      context.Background(),
+			key:      "invalid",
+			id:       id,
+			authnRes: &grpcClientsV1.AuthnRes{Authenticated: false},
+			err:      svcerr.ErrAuthentication,
+		},
+		{
+			desc:     "update registration of another client with client key",
+			ctx:      context.Background(),
+			key:      "another-key",
+			id:       id,
+			authnRes: &grpcClientsV1.AuthnRes{Id: testsutil.GenerateUUID(t), Authenticated: true},
+			err:      svcerr.ErrAuthorization,
+		},
+		{
+			desc: "update registration of another client with DTLS identity",
+			ctx:  coap.WithClientID(context.Background(), testsutil.GenerateUUID(t)),
+			id:   id,
+			err:  svcerr.ErrAuthorization,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			authnCall := clients.On("Authenticate", mock.Anything, &grpcClientsV1.AuthnReq{ClientSecret: tc.key}).Return(tc.authnRes, tc.authnErr)
+			err := svc.Update(tc.ctx, tc.key, tc.id, time.Minute, []string{"/3/0"}, new(mocks.Device))
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			authnCall.Unset()
+		})
+	}
+}
+
+func TestDeregister(t *testing.T) {
+	svc, repo, clients, _, _ := newService(time.Hour)
+	anotherID := testsutil.GenerateUUID(t)
+
+	cases := []struct {
+		desc     string
+		ctx      context.Context
+		key      string
+		unknown  bool
+		authnRes *grpcClientsV1.AuthnRes
+		authnErr error
+		err      error
+	}{
+		{
+			desc: "deregister with DTLS identity successfully",
+			ctx:  coap.WithClientID(context.Background(), clientID),
+		},
+		{
+			desc:     "deregister with client key successfully",
+			ctx:      context.Background(),
+			key:      clientKey,
+			authnRes: &grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true},
+		},
+		{
+			desc:    "deregister unknown registration",
+			ctx:     coap.WithClientID(context.Background(), clientID),
+			unknown: true,
+			err:     svcerr.ErrNotFound,
+		},
+		{
+			desc: "deregister without client identity",
+			ctx:  context.Background(),
+			err:  svcerr.ErrAuthorization,
+		},
+		{
+			desc:     "deregister with failed authentication",
+			ctx:      context.Background(),
+			key:      clientKey,
+			authnRes: &grpcClientsV1.AuthnRes{},
+			authnErr: svcerr.ErrAuthentication,
+			err:      svcerr.ErrAuthentication,
+		},
+		{
+			desc:     "deregister another client with client key",
+			ctx:      context.Background(),
+			key:      "another-key",
+			authnRes: &grpcClientsV1.AuthnRes{Id: anotherID, Authenticated: true},
+			err:      svcerr.ErrAuthorization,
+		},
+		{
+			desc: "deregister another client with DTLS identity",
+			ctx:  coap.WithClientID(context.Background(), anotherID),
+			err:  svcerr.ErrAuthorization,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			id := register(t, svc, repo, lwm2m.Registration{}, new(mocks.Device))
+			if tc.unknown {
+				id = testsutil.GenerateUUID(t)
+			}
+			authnCall := clients.On("Authenticate", mock.Anything, &grpcClientsV1.AuthnReq{ClientSecret: tc.key}).Return(tc.authnRes, tc.authnErr)
+			err := svc.Deregister(tc.ctx, tc.key, id)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			authnCall.Unset()
+		})
+	}
+}
+
+func TestRegistrationExpiry(t *testing.T) {
+	svc, repo, _, _, _ := newService(time.Hour)
+	id := register(t, svc, repo, lwm2m.Registration{Lifetime: 50 * time.Millisecond}, new(mocks.Device))
+	repo.On("RetrieveByClient", mock.Anything, clientID).Return(endpoint, nil)
+
+	_, reg, err := svc.ViewEndpoint(context.Background(), session, clientID)
+	require.Nil(t, err, fmt.Sprintf("view endpoint unexpected error: %s", err))
+	assert.Equal(t, id, reg.ID)
+
+	assert.Eventually(t, func() bool {
+		_, reg, err := svc.ViewEndpoint(context.Background(), session, clientID)
+		return err == nil && reg.ID == ""
+	}, time.Second, 10*time.Millisecond, "expected registration to expire")
+}
+
+func TestRead(t *testing.T) {
+	svc, repo, _, channels, pubsub := newService(time.Hour)
+	dev := new(mocks.Device)
+	register(t, svc, repo, lwm2m.Registration{Version: "1.0"}, dev)
+
+	text := "mitras"
+	cases := []struct {
+		desc     string
+		clientID string
+		path     string
+		content  lwm2m.Content
+		readErr  error
+		authzRes *grpcChannelsV1.AuthzRes
+		pubErr   error
+		pack     senml.Pack
+		err      error
+	}{
+		{
+			desc:     "read resource successfully",
+			clientID: clientID,
+			path:     "/3/0/0",
+			content:  lwm2m.Content{Format: lwm2m.TextPlain, Payload: []byte(text)},
+			authzRes: &grpcChannelsV1.AuthzRes{Authorized: true},
+			pack:     senml.Pack{Records: []senml.Record{{Name: "/3/0/0", StringValue: &text}}},
+		},
+		{
+			desc:     "read resource of unregistered endpoint",
+			clientID: testsutil.GenerateUUID(t),
+			path:     "/3/0/0",
+			err:      lwm2m.ErrNotRegistered,
+		},
+		{
+			desc:     "read resource with device error",
+			clientID: clientID,
+			path:     "/3/0/0",
+			readErr:  lwm2m.ErrDeviceResponse,
+			err:      lwm2m.ErrDeviceResponse,
+		},
+		{
+			desc:     "read resource with unsupported format",
+			clientID: clientID,
+			path:     "/3/0/0",
+			content:  lwm2m.Content{Format: lwm2m.LinkFormat},
+			err:      lwm2m.ErrUnsupportedFormat,
+		},
+		{
+			desc:     "read resource of client not connected to channel",
+			clientID: clientID,
+			path:     "/3/0/0",
+			content:  lwm2m.Content{Format: lwm2m.TextPlain, Payload: []byte(text)},
+			authzRes: &grpcChannelsV1.AuthzRes{Authorized: false},
+			err:      svcerr.ErrAuthorization,
+		},
+		{
+			desc:     "read resource with failed publish",
+			clientID: clientID,
+			path:     "/3/0/0",
+			content:  lwm2m.Content{Format: lwm2m.TextPlain, Payload: []byte(text)},
+			authzRes: &grpcChannelsV1.AuthzRes{Authorized: true},
+			pubErr:   errors.New("failed to publish"),
+			err:      errors.New("failed to publish"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			readCall := dev.On("Read", mock.Anything, tc.path).Return(tc.content, tc.readErr)
+			authzCall := channels.On("Authorize", mock.Anything, mock.Anything).Return(tc.authzRes, nil)
+			var msg *messaging.Message
+			pubCall := pubsub.On("Publish", mock.Anything, chanID, mock.Anything).Run(func(args mock.Arguments) {
+				msg = args.Get(2).(*messaging.Message)
+			}).Return(tc.pubErr)
+			pack, err := svc.Read(context.Background(), session, tc.clientID, tc.path)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			assert.Equal(t, tc.pack, pack, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.pack, pack))
+			if tc.err == nil {
+				assert.Equal(t, "3.0.0", msg.GetSubtopic(), fmt.Sprintf("%s: expected subtopic %s got %s\n", tc.desc, "3.0.0", msg.GetSubtopic()))
+				assert.Equal(t, clientID, msg.GetPublisher(), fmt.Sprintf("%s: expected publisher %s got %s\n", tc.desc, clientID, msg.GetPublisher()))
+			}
+			readCall.Unset()
+			authzCall.Unset()
+			pubCall.Unset()
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	value := 21.0
+	pack := senml.Pack{Records: []senml.Record{{Name: "5700", Value: &value}}}
+
+	cases := []struct {
+		desc     string
+		version  string
+		clientID string
+		path     string
+		format   uint16
+		writeErr error
+		err      error
+	}{
+		{
+			desc:     "write to LwM2M 1.0 endpoint",
+			version:  "1.0",
+			clientID: clientID,
+			path:     "/3303/0",
+			format:   lwm2m.TLV,
+		},
+		{
+			desc:     "write to LwM2M 1.1 endpoint",
+			version:  "1.1",
+			clientID: clientID,
+			path:     "/3303/0",
+			format:   lwm2m.SenMLCBOR,
+		},
+		{
+			desc:     "write to unregistered endpoint",
+			version:  "1.0",
+			clientID: testsutil.GenerateUUID(t),
+			path:     "/3303/0",
+			err:      lwm2m.ErrNotRegistered,
+		},
+		{
+			desc:     "write object as TLV",
+			version:  "1.0",
+			clientID: clientID,
+			path:     "/3303",
+			err:      svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:     "write with device error",
+			version:  "1.0",
+			clientID: clientID,
+			path:     "/3303/0",
+			format:   lwm2m.TLV,
+			writeErr: lwm2m.ErrDeviceResponse,
+			err:      lwm2m.ErrDeviceResponse,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, repo, _, _, _ := newService(time.Hour)
+			dev := new(mocks.Device)
+			register(t, svc, repo, lwm2m.Registration{Version: tc.version}, dev)
+			dev.On("Write", mock.Anything, tc.path, mock.MatchedBy(func(c lwm2m.Content) bool {
+				return c.Format == tc.format
+			})).Return(tc.writeErr)
+			err := svc.Write(context.Background(), session, tc.clientID, tc.path, pack)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		})
+	}
+}
+
+func TestExecute(t *testing.T) {
+	svc, repo, _, _, _ := newService(time.Hour)
+	dev := new(mocks.Device)
+	register(t, svc, repo, lwm2m.Registration{}, dev)
+
+	dev.On("Execute", mock.Anything, "/3/0/4", "").Return(nil)
+
+	err := svc.Execute(context.Background(), session, clientID, "/3/0/4", "")
+	assert.Nil(t, err, fmt.Sprintf("execute unexpected error: %s", err))
+
+	err = svc.Execute(context.Background(), session, testsutil.GenerateUUID(t), "/3/0/4", "")
+	assert.True(t, errors.Contains(err, lwm2m.ErrNotRegistered), fmt.Sprintf("execute on unregistered endpoint: expected %s got %s\n", lwm2m.ErrNotRegistered, err))
+}
+
+func TestObserve(t *testing.T) {
+	svc, repo, _, channels, pubsub := newService(time.Hour)
+	dev := new(mocks.Device)
+	id := register(t, svc, repo, lwm2m.Registration{}, dev)
+
+	path := "/3303/0/5700"
+	obs := new(observation)
+	var handler func(lwm2m.Content)
+	dev.On("Observe", mock.Anything, path, mock.Anything).Run(func(args mock.Arguments) {
+		handler = args.Get(2).(func(lwm2m.Content))
+	}).Return(obs, nil).Once()
+	channels.On("Authorize", mock.Anything, mock.Anything).Return(&grpcChannelsV1.AuthzRes{Authorized: true}, nil)
+	published := make(chan *messaging.Message, 1)
+	pubsub.On("Publish", mock.Anything, chanID, mock.Anything).Run(func(args mock.Arguments) {
+		published <- args.Get(2).(*messaging.Message)
+	}).Return(nil)
+
+	err := svc.Observe(context.Background(), session, clientID, path)
+	require.Nil(t, err, fmt.Sprintf("observe unexpected error: %s", err))
+
+	// Observing the observed path doesn't start another observation.
+	err = svc.Observe(context.Background(), session, clientID, path)
+	assert.Nil(t, err, fmt.Sprintf("observe observed path unexpected error: %s", err))
+
+	_, reg, err := func() (lwm2m.Endpoint, lwm2m.Registration, error) {
+		repoCall := repo.On("RetrieveByClient", mock.Anything, clientID).Return(endpoint, nil)
+		defer repoCall.Unset()
+		return svc.ViewEndpoint(context.Background(), session, clientID)
+	}()
+	assert.Nil(t, err, fmt.Sprintf("view endpoint unexpected error: %s", err))
+	assert.Equal(t, id, reg.ID)
+	assert.Equal(t, []string{path}, reg.Observations)
+
+	handler(lwm2m.Content{Format: lwm2m.TextPlain, Payload: []byte("21.5")})
+	select {
+	case msg := <-published:
+		assert.Equal(t, "3303.0.5700", msg.GetSubtopic())
+	case <-time.After(time.Second):
+		t.Error("expected notification to be published")
+	}
+
+	err = svc.CancelObservation(context.Background(), session, clientID, path)
+	assert.Nil(t, err, fmt.Sprintf("cancel observation unexpected error: %s", err))
+	assert.True(t, obs.isCancelled(), "expected observation to be cancelled")
+
+	err = svc.CancelObservation(context.Background(), session, clientID, path)
+	assert.True(t, errors.Contains(err, svcerr.ErrNotFound), fmt.Sprintf("cancel cancelled observation: expected %s got %s\n", svcerr.ErrNotFound, err))
+}
+
+func TestBootstrap(t *testing.T) {
+	svc, repo, clients, _, _ := newService(time.Hour)
+	dev := new(mocks.Device)
+
+	repo.On("RetrieveByName", mock.Anything, endpointName).Return(endpoint, nil)
+	clients.On("RetrieveSecret", mock.Anything, &grpcClientsV1.RetrieveSecretReq{Id: clientID}).Return(&grpcClientsV1.RetrieveSecretRes{Secret: clientKey}, nil)
+	dev.On("Delete", mock.Anything, "/").Return(nil)
+	dev.On("Write", mock.Anything, "/0/1", mock.MatchedBy(func(c lwm2m.Content) bool {
+		return c.Format == lwm2m.TLV
+	})).Return(nil)
+	dev.On("Write", mock.Anything, "/1/1", mock.MatchedBy(func(c lwm2m.Content) bool {
+		return c.Format == lwm2m.TLV
+	})).Return(nil)
+	finished := make(chan struct{})
+	dev.On("BootstrapFinish", mock.Anything).Run(func(mock.Arguments) {
+		close(finished)
+	}).Return(nil)
+
+	err := svc.Bootstrap(coap.WithClientID(context.Background(), clientID), "", endpointName, dev)
+	require.Nil(t, err, fmt.Sprintf("bootstrap unexpected error: %s", err))
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("expected bootstrap to finish")
+	}
+	dev.AssertExpectations(t)
+
+	err = svc.Bootstrap(coap.WithClientID(context.Background(), testsutil.GenerateUUID(t)), "", endpointName, dev)
+	assert.True(t, errors.Contains(err, svcerr.ErrAuthorization), fmt.Sprintf("bootstrap endpoint of another client: expected %s got %s\n", svcerr.ErrAuthorization, err))
+}
@@ -0,0 +1,166 @@
+package lwm2m
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/hantdev/mitras/pkg/errors"
+)
+
+// TLV identifier types, as defined in the section 7.4.3 of the LwM2M 1.0
+// technical specification.
+const (
+	tlvObjectInstance   byte = 0x00
+	tlvResourceInstance byte = 0x40
+	tlvMultipleResource byte = 0x80
+	tlvResource         byte = 0xC0
+
+	tlvTypeMask     byte = 0xC0
+	tlvID16         byte = 0x20
+	tlvLengthMask   byte = 0x18
+	tlvLength8      byte = 0x08
+	tlvLength16     byte = 0x10
+	tlvLength24     byte = 0x18
+	tlvShortLength  byte = 0x07
+	tlvMaxShortSize      = 7
+)
+
+var errMalformedTLV = errors.New("malformed TLV")
+
+// tlv is the TLV entry. Value of the object instances and the multiple
+// resources is the list of the nested entries.
+type tlv struct {
+	typ      byte
+	id       uint16
+	value    []byte
+	children []tlv
+}
+
+// resourceValue is the value of the resource or the resource instance
+// identified by the path.
+type resourceValue struct {
+	path  string
+	value []byte
+}
+
+func decodeTLV(payload []byte) ([]tlv, error) {
+	var entries []tlv
+	for len(payload) > 0 {
+		e, n, err := decodeTLVEntry(payload)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+		payload = payload[n:]
+	}
+
+	return entries, nil
+}
+
+func decodeTLVEntry(b []byte) (tlv, int, error) {
+	if len(b) < 2 {
+		return tlv{}, 0, errMalformedTLV
+	}
+	e := tlv{typ: b[0] & tlvTypeMask}
+	pos := 1
+
+	switch b[0] & tlvID16 {
+	case tlvID16:
+		if len(b) < pos+2 {
+			return tlv{}, 0, errMalformedTLV
+		}
+		e.id = binary.BigEndian.Uint16(b[pos:])
+		pos += 2
+	default:
+		e.id = uint16(b[pos])
+		pos++
+	}
+
+	var size int
+	switch lt := b[0] & tlvLengthMask; lt {
+	case 0:
+		size = int(b[0] & tlvShortLength)
+	default:
+		n := int(lt >> 3)
+		if len(b) < pos+n {
+			return tlv{}, 0, errMalformedTLV
+		}
+		for _, v := range b[pos : pos+n] {
+			size = size<<8 | int(v)
+		}
+		pos += n
+	}
+	if len(b) < pos+size {
+		return tlv{}, 0, errMalformedTLV
+	}
+	e.value = b[pos : pos+size]
+
+	if e.typ == tlvObjectInstance || e.typ == tlvMultipleResource {
+		children, err := decodeTLV(e.value)
+		if err != nil {
+			return tlv{}, 0, err
+		}
+		e.value, e.children = nil, children
+	}
+
+	return e, pos + size, nil
+}
+
+func encodeTLV(entries []tlv) []byte {
+	var b []byte
+	for _, e := range entries {
+		value := e.value
+		if e.typ == tlvObjectInstance || e.typ == tlvMultipleResource {
+			value = encodeTLV(e.children)
+		}
+
+		typ := e.typ
+		var id []byte
+		switch {
+		case e.id > 0xFF:
+			typ |= tlvID16
+			id = binary.BigEndian.AppendUint16(nil, e.id)
+		default:
+			id = []byte{byte(e.id)}
+		}
+
+		var size []byte
+		switch n := len(value); {
+		case n <= tlvMaxShortSize:
+			typ |= byte(n)
+		case n <= 0xFF:
+			typ |= tlvLength8
+			size = []byte{byte(n)}
+		case n <= 0xFFFF:
+			typ |= tlvLength16
+			size = binary.BigEndian.AppendUint16(nil, uint16(n))
+		default:
+			typ |= tlvLength24
+			size = []byte{byte(n >> 16), byte(n >> 8), byte(n)}
+		}
+
+		b = append(b, typ)
+		b = append(b, id...)
+		b = append(b, size...)
+		b = append(b, value...)
+	}
+
+	return b
+}
+
+// flattenTLV returns the values of the resources and the resource instances
+// of the entries read from the path.
+func flattenTLV(path string, entries []tlv) []resourceValue {
+	var values []resourceValue
+	for _, e := range entries {
+		p := fmt.Sprintf("%s/%d", path, e.id)
+		switch e.typ {
+		case tlvObjectInstance, tlvMultipleResource:
+			values = append(values, flattenTLV(p, e.children)...)
+		default:
+			values = append(values, resourceValue{path: p, value: e.value})
+		}
+	}
+
+	return values
+}
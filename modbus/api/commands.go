@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/hantdev/mitras/modbus"
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/mitras/pkg/messaging"
+	"github.com/hantdev/senml"
+)
+
+// CommandsTopic is the topic of the control subtopics of all the channels.
+const CommandsTopic = "channels.*." + modbus.ControlSubtopic + ".*"
+
+var errMalformedCommand = errors.New("malformed command")
+
+var _ messaging.MessageHandler = (*commandHandler)(nil)
+
+type commandHandler struct {
+	svc    modbus.Service
+	logger *slog.Logger
+}
+
+// MakeCommandHandler returns the handler of the commands published to the
+// control subtopic of the channels. The last token of the subtopic is the
+// client ID of the device, and the payload contains SenML records named
+// after the registers of the device.
+func MakeCommandHandler(svc modbus.Service, logger *slog.Logger) messaging.MessageHandler {
+	return &commandHandler{
+		svc:    svc,
+		logger: logger,
+	}
+}
+
+func (h *commandHandler) Handle(msg *messaging.Message) error {
+	clientID, ok := strings.CutPrefix(msg.GetSubtopic(), modbus.ControlSubtopic+".")
+	if !ok || clientID == "" || strings.Contains(clientID, ".") {
+		return nil
+	}
+
+	pack, err := senml.Decode(msg.GetPayload(), senml.JSON)
+	if err != nil {
+		return errors.Wrap(errMalformedCommand, err)
+	}
+	if err := h.svc.Write(context.Background(), msg.GetChannel(), clientID, pack); err != nil {
+		return err
+	}
+	h.logger.Debug(fmt.Sprintf("Wrote command of %s to device of client %s", msg.GetPublisher(), clientID))
+
+	return nil
+}
+
+func (h *commandHandler) Cancel() error {
+	return nil
+}
@@ -0,0 +1,4 @@
+// Package api contains API-related concerns: the handler of the commands
+// received from the message broker, the HTTP endpoint definitions, and all
+// resource representations.
+package api
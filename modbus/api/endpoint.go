@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/hantdev/mitras/internal/api"
+	"github.com/hantdev/mitras/modbus"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	"github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+)
+
+func createDeviceEndpoint(svc modbus.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deviceReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		dev, err := svc.CreateDevice(ctx, session, req.device())
+		if err != nil {
+			return nil, err
+		}
+
+		return deviceRes{Device: dev, created: true}, nil
+	}
+}
+
+func viewDeviceEndpoint(svc modbus.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewDeviceReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		dev, err := svc.ViewDevice(ctx, session, req.clientID)
+		if err != nil {
+			return nil, err
+		}
+
+		return deviceRes{Device: dev}, nil
+	}
+}
+
+func updateDeviceEndpoint(svc modbus.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deviceReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		dev, err := svc.UpdateDevice(ctx, session, req.device())
+		if err != nil {
+			return nil, err
+		}
+
+		return deviceRes{Device: dev}, nil
+	}
+}
+
+func removeDeviceEndpoint(svc modbus.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewDeviceReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		if err := svc.RemoveDevice(ctx, session, req.clientID); err != nil {
+			return nil, err
+		}
+
+		return removeDeviceRes{}, nil
+	}
+}
@@ -0,0 +1,299 @@
+package api_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hantdev/mitras/internal/testsutil"
+	smqlog "github.com/hantdev/mitras/logger"
+	"github.com/hantdev/mitras/modbus"
+	"github.com/hantdev/mitras/modbus/api"
+	"github.com/hantdev/mitras/modbus/mocks"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	authnmocks "github.com/hantdev/mitras/pkg/authn/mocks"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/mitras/pkg/messaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	validToken  = "valid"
+	contentType = "application/json"
+	registers   = `[{"name":"temperature","type":"holding_register","address":0,"data_type":"int16","scale":0.1}]`
+)
+
+var (
+	domainID = testsutil.GenerateUUID(&testing.T{})
+	clientID = testsutil.GenerateUUID(&testing.T{})
+	chanID   = testsutil.GenerateUUID(&testing.T{})
+)
+
+type testRequest struct {
+	client      *http.Client
+	method      string
+	url         string
+	contentType string
+	token       string
+	body        io.Reader
+}
+
+func (tr testRequest) make() (*http.Response, error) {
+	req, err := http.NewRequest(tr.method, tr.url, tr.body)
+	if err != nil {
+		return nil, err
+	}
+
+	if tr.token != "" {
+		req.Header.Set("Authorization", apiutil.BearerPrefix+tr.token)
+	}
+	if tr.contentType != "" {
+		req.Header.Set("Content-Type", tr.contentType)
+	}
+
+	return tr.client.Do(req)
+}
+
+func newServer() (*httptest.Server, *mocks.Service, *authnmocks.Authentication) {
+	svc := new(mocks.Service)
+	authn := new(authnmocks.Authentication)
+	mux := api.MakeHandler(svc, authn, smqlog.NewMock(), "modbus", "test")
+
+	return httptest.NewServer(mux), svc, authn
+}
+
+func TestCreateDevice(t *testing.T) {
+	ts, svc, authn := newServer()
+	defer ts.Close()
+
+	valid := fmt.Sprintf(`{"client_id":"%s","channel_id":"%s","address":"localhost:502","unit_id":1,"interval":1000000000,"registers":%s}`, clientID, chanID, registers)
+
+	cases := []struct {
+		desc        string
+		token       string
+		contentType string
+		body        string
+		svcErr      error
+		status      int
+	}{
+		{
+			desc:        "create device successfully",
+			token:       validToken,
+			contentType: contentType,
+			body:        valid,
+			status:      http.StatusCreated,
+		},
+		{
+			desc:        "create device without token",
+			contentType: contentType,
+			body:        valid,
+			status:      http.StatusUnauthorized,
+		},
+		{
+			desc:   "create device with invalid content type",
+			token:  validToken,
+			body:   valid,
+			status: http.StatusUnsupportedMediaType,
+		},
+		{
+			desc:        "create device with malformed body",
+			token:       validToken,
+			contentType: contentType,
+			body:        "{",
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "create device without client ID",
+			token:       validToken,
+			contentType: contentType,
+			body:        fmt.Sprintf(`{"channel_id":"%s","address":"localhost:502","registers":%s}`, chanID, registers),
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "create device without address",
+			token:       validToken,
+			contentType: contentType,
+			body:        fmt.Sprintf(`{"client_id":"%s","channel_id":"%s","registers":%s}`, clientID, chanID, registers),
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "create device without registers",
+			token:       validToken,
+			contentType: contentType,
+			body:        fmt.Sprintf(`{"client_id":"%s","channel_id":"%s","address":"localhost:502"}`, clientID, chanID),
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "create device with invalid channel ID",
+			token:       validToken,
+			contentType: contentType,
+			body:        fmt.Sprintf(`{"client_id":"%s","channel_id":"invalid","address":"localhost:502","registers":%s}`, clientID, registers),
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "create device with invalid register map",
+			token:       validToken,
+			contentType: contentType,
+			body:        valid,
+			svcErr:      svcerr.ErrMalformedEntity,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "create device with unauthorized user",
+			token:       validToken,
+			contentType: contentType,
+			body:        valid,
+			svcErr:      svcerr.ErrAuthorization,
+			status:      http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			authCall := authn.On("Authenticate", mock.Anything, tc.token).Return(smqauthn.Session{UserID: testsutil.GenerateUUID(t)}, nil)
+			svcCall := svc.On("CreateDevice", mock.Anything, mock.Anything, mock.Anything).Return(modbus.Device{ClientID: clientID, DomainID: domainID}, tc.svcErr)
+			req := testRequest{
+				client:      ts.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/%s/modbus/devices", ts.URL, domainID),
+				contentType: tc.contentType,
+				token:       tc.token,
+				body:        strings.NewReader(tc.body),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			defer res.Body.Close()
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			if tc.status == http.StatusCreated {
+				location := fmt.Sprintf("/%s/modbus/devices/%s", domainID, clientID)
+				assert.Equal(t, location, res.Header.Get("Location"), fmt.Sprintf("%s: expected location %s got %s", tc.desc, location, res.Header.Get("Location")))
+			}
+			svcCall.Unset()
+			authCall.Unset()
+		})
+	}
+}
+
+func TestUpdateDevice(t *testing.T) {
+	ts, svc, authn := newServer()
+	defer ts.Close()
+
+	valid := fmt.Sprintf(`{"channel_id":"%s","address":"localhost:502","interval":1000000000,"registers":%s}`, chanID, registers)
+
+	cases := []struct {
+		desc     string
+		clientID string
+		body     string
+		svcErr   error
+		status   int
+	}{
+		{
+			desc:     "update device successfully",
+			clientID: clientID,
+			body:     valid,
+			status:   http.StatusOK,
+		},
+		{
+			desc:     "update device with invalid client ID",
+			clientID: "invalid",
+			body:     valid,
+			status:   http.StatusBadRequest,
+		},
+		{
+			desc:     "update device without channel ID",
+			clientID: clientID,
+			body:     fmt.Sprintf(`{"address":"localhost:502","registers":%s}`, registers),
+			status:   http.StatusBadRequest,
+		},
+		{
+			desc:     "update non-existing device",
+			clientID: clientID,
+			body:     valid,
+			svcErr:   svcerr.ErrNotFound,
+			status:   http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			authCall := authn.On("Authenticate", mock.Anything, validToken).Return(smqauthn.Session{UserID: testsutil.GenerateUUID(t)}, nil)
+			svcCall := svc.On("UpdateDevice", mock.Anything, mock.Anything, mock.Anything).Return(modbus.Device{ClientID: clientID}, tc.svcErr)
+			req := testRequest{
+				client:      ts.Client(),
+				method:      http.MethodPut,
+				url:         fmt.Sprintf("%s/%s/modbus/devices/%s", ts.URL, domainID, tc.clientID),
+				contentType: contentType,
+				token:       validToken,
+				body:        strings.NewReader(tc.body),
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			defer res.Body.Close()
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+			authCall.Unset()
+		})
+	}
+}
+
+func TestCommandHandler(t *testing.T) {
+	cases := []struct {
+		desc     string
+		subtopic string
+		payload  string
+		write    bool
+		svcErr   error
+		err      error
+	}{
+		{
+			desc:     "handle command",
+			subtopic: "control." + clientID,
+			payload:  `[{"n":"temperature","v":22.5}]`,
+			write:    true,
+		},
+		{
+			desc:     "handle command with malformed payload",
+			subtopic: "control." + clientID,
+			payload:  `{`,
+			err:      errors.New("malformed command"),
+		},
+		{
+			desc:     "handle command rejected by the service",
+			subtopic: "control." + clientID,
+			payload:  `[{"n":"temperature","v":22.5}]`,
+			write:    true,
+			svcErr:   svcerr.ErrAuthorization,
+			err:      svcerr.ErrAuthorization,
+		},
+		{
+			desc:     "ignore message of another subtopic",
+			subtopic: "control." + clientID + ".status",
+			payload:  `[{"n":"temperature","v":22.5}]`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc := new(mocks.Service)
+			h := api.MakeCommandHandler(svc, smqlog.NewMock())
+			svc.On("Write", mock.Anything, chanID, clientID, mock.Anything).Return(tc.svcErr)
+			err := h.Handle(&messaging.Message{
+				Channel:  chanID,
+				Subtopic: tc.subtopic,
+				Payload:  []byte(tc.payload),
+			})
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected error %s, got %s", tc.desc, tc.err, err))
+			if tc.write {
+				svc.AssertCalled(t, "Write", mock.Anything, chanID, clientID, mock.Anything)
+			} else {
+				svc.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
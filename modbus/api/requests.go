@@ -0,0 +1,67 @@
+package api
+
+import (
+	"time"
+
+	"github.com/hantdev/mitras/internal/api"
+	"github.com/hantdev/mitras/modbus"
+	"github.com/hantdev/mitras/pkg/apiutil"
+)
+
+type deviceReq struct {
+	clientID  string
+	ChannelID string            `json:"channel_id"`
+	Address   string            `json:"address"`
+	UnitID    uint8             `json:"unit_id"`
+	Interval  time.Duration     `json:"interval"`
+	Registers []modbus.Register `json:"registers"`
+}
+
+func (req deviceReq) validate() error {
+	if req.clientID == "" {
+		return apiutil.ErrMissingClientID
+	}
+	if req.ChannelID == "" {
+		return apiutil.ErrMissingChannelID
+	}
+	if req.Address == "" {
+		return apiutil.ErrMissingAddress
+	}
+	if len(req.Registers) == 0 {
+		return apiutil.ErrEmptyList
+	}
+	if err := api.ValidateUUID(req.clientID); err != nil {
+		return err
+	}
+
+	return api.ValidateUUID(req.ChannelID)
+}
+
+func (req deviceReq) device() modbus.Device {
+	return modbus.Device{
+		ClientID:  req.clientID,
+		ChannelID: req.ChannelID,
+		Address:   req.Address,
+		UnitID:    req.UnitID,
+		Interval:  req.Interval,
+		Registers: req.Registers,
+	}
+}
+
+// createDeviceReq is the device request which carries the client ID in the body.
+type createDeviceReq struct {
+	ClientID string `json:"client_id"`
+	deviceReq
+}
+
+type viewDeviceReq struct {
+	clientID string
+}
+
+func (req viewDeviceReq) validate() error {
+	if req.clientID == "" {
+		return apiutil.ErrMissingClientID
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hantdev/mitras"
+	"github.com/hantdev/mitras/modbus"
+)
+
+var (
+	_ mitras.Response = (*deviceRes)(nil)
+	_ mitras.Response = (*removeDeviceRes)(nil)
+)
+
+type deviceRes struct {
+	modbus.Device `json:",inline"`
+	created       bool
+}
+
+func (res deviceRes) Code() int {
+	if res.created {
+		return http.StatusCreated
+	}
+
+	return http.StatusOK
+}
+
+func (res deviceRes) Headers() map[string]string {
+	if res.created {
+		return map[string]string{
+			"Location": fmt.Sprintf("/%s/modbus/devices/%s", res.DomainID, res.ClientID),
+		}
+	}
+
+	return map[string]string{}
+}
+
+func (res deviceRes) Empty() bool {
+	return false
+}
+
+type removeDeviceRes struct{}
+
+func (res removeDeviceRes) Code() int {
+	return http.StatusNoContent
+}
+
+func (res removeDeviceRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res removeDeviceRes) Empty() bool {
+	return true
+}
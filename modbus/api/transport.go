@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/hantdev/mitras"
+	"github.com/hantdev/mitras/internal/api"
+	"github.com/hantdev/mitras/modbus"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const clientIDKey = "clientID"
+
+// MakeHandler returns a HTTP API handler with health check and metrics.
+func MakeHandler(svc modbus.Service, authn smqauthn.Authentication, logger *slog.Logger, svcName, instanceID string) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(apiutil.LoggingErrorEncoder(logger, api.EncodeError)),
+	}
+
+	mux := chi.NewRouter()
+
+	mux.Route("/{domainID}/modbus/devices", func(r chi.Router) {
+		r.Use(api.AuthenticateMiddleware(authn, true))
+
+		r.Post("/", otelhttp.NewHandler(kithttp.NewServer(
+			createDeviceEndpoint(svc),
+			decodeCreateDeviceReq,
+			api.EncodeResponse,
+			opts...,
+		), "create_modbus_device").ServeHTTP)
+
+		r.Get("/{clientID}", otelhttp.NewHandler(kithttp.NewServer(
+			viewDeviceEndpoint(svc),
+			decodeViewDeviceReq,
+			api.EncodeResponse,
+			opts...,
+		), "view_modbus_device").ServeHTTP)
+
+		r.Put("/{clientID}", otelhttp.NewHandler(kithttp.NewServer(
+			updateDeviceEndpoint(svc),
+			decodeUpdateDeviceReq,
+			api.EncodeResponse,
+			opts...,
+		), "update_modbus_device").ServeHTTP)
+
+		r.Delete("/{clientID}", otelhttp.NewHandler(kithttp.NewServer(
+			removeDeviceEndpoint(svc),
+			decodeViewDeviceReq,
+			api.EncodeResponse,
+			opts...,
+		), "remove_modbus_device").ServeHTTP)
+	})
+
+	mux.Get("/health", mitras.Health(svcName, instanceID))
+	mux.Handle("/metrics", promhttp.Handler())
+
+	return mux
+}
+
+func decodeCreateDeviceReq(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := createDeviceReq{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+	req.deviceReq.clientID = req.ClientID
+
+	return req.deviceReq, nil
+}
+
+func decodeUpdateDeviceReq(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := deviceReq{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+	req.clientID = chi.URLParam(r, clientIDKey)
+
+	return req, nil
+}
+
+func decodeViewDeviceReq(_ context.Context, r *http.Request) (interface{}, error) {
+	return viewDeviceReq{clientID: chi.URLParam(r, clientIDKey)}, nil
+}
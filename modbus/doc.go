@@ -0,0 +1,7 @@
+// Package modbus contains the Modbus gateway service.
+// This service polls Modbus TCP slaves on behalf of mitras clients, publishes
+// the readings of their registers as SenML messages to the channels of the
+// clients and writes the registers using the commands received from the
+// control subtopic of the channels. It also provides a REST API to manage the
+// devices and their register maps.
+package modbus
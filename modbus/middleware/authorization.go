@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/hantdev/mitras/modbus"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	smqauthz "github.com/hantdev/mitras/pkg/authz"
+	"github.com/hantdev/mitras/pkg/policies"
+	"github.com/hantdev/senml"
+)
+
+var _ modbus.Service = (*authorizationMiddleware)(nil)
+
+type authorizationMiddleware struct {
+	svc   modbus.Service
+	authz smqauthz.Authorization
+}
+
+// AuthorizationMiddleware adds authorization to the Modbus gateway service.
+// Users need the view permission on the client to view its device, and the
+// edit permission to manage the device. Commands are accepted only from the
+// channel of the device, which is checked by the service.
+func AuthorizationMiddleware(svc modbus.Service, authz smqauthz.Authorization) modbus.Service {
+	return &authorizationMiddleware{
+		svc:   svc,
+		authz: authz,
+	}
+}
+
+func (am *authorizationMiddleware) Start(ctx context.Context) error {
+	return am.svc.Start(ctx)
+}
+
+func (am *authorizationMiddleware) CreateDevice(ctx context.Context, session smqauthn.Session, dev modbus.Device) (modbus.Device, error) {
+	if err := am.authorize(ctx, session, policies.EditPermission, policies.ClientType, dev.ClientID); err != nil {
+		return modbus.Device{}, err
+	}
+	if err := am.authorize(ctx, session, policies.ViewPermission, policies.ChannelType, dev.ChannelID); err != nil {
+		return modbus.Device{}, err
+	}
+
+	return am.svc.CreateDevice(ctx, session, dev)
+}
+
+func (am *authorizationMiddleware) ViewDevice(ctx context.Context, session smqauthn.Session, clientID string) (modbus.Device, error) {
+	if err := am.authorize(ctx, session, policies.ViewPermission, policies.ClientType, clientID); err != nil {
+		return modbus.Device{}, err
+	}
+
+	return am.svc.ViewDevice(ctx, session, clientID)
+}
+
+func (am *authorizationMiddleware) UpdateDevice(ctx context.Context, session smqauthn.Session, dev modbus.Device) (modbus.Device, error) {
+	if err := am.authorize(ctx, session, policies.EditPermission, policies.ClientType, dev.ClientID); err != nil {
+		return modbus.Device{}, err
+	}
+	if err := am.authorize(ctx, session, policies.ViewPermission, policies.ChannelType, dev.ChannelID); err != nil {
+		return modbus.Device{}, err
+	}
+
+	return am.svc.UpdateDevice(ctx, session, dev)
+}
+
+func (am *authorizationMiddleware) RemoveDevice(ctx context.Context, session smqauthn.Session, clientID string) error {
+	if err := am.authorize(ctx, session, policies.EditPermission, policies.ClientType, clientID); err != nil {
+		return err
+	}
+
+	return am.svc.RemoveDevice(ctx, session, clientID)
+}
+
+func (am *authorizationMiddleware) Write(ctx context.Context, channelID, clientID string, pack senml.Pack) error {
+	return am.svc.Write(ctx, channelID, clientID, pack)
+}
+
+func (am *authorizationMiddleware) authorize(ctx context.Context, session smqauthn.Session, permission, objectType, object string) error {
+	return am.authz.Authorize(ctx, smqauthz.PolicyReq{
+		Domain:      session.DomainID,
+		SubjectType: policies.UserType,
+		SubjectKind: policies.UsersKind,
+		Subject:     session.DomainUserID,
+		Permission:  permission,
+		ObjectType:  objectType,
+		Object:      object,
+	})
+}
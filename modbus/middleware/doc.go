@@ -0,0 +1,3 @@
+// Package middleware provides middleware for the Modbus gateway service.
+// This is authorization and logging middleware.
+package middleware
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/hantdev/mitras/modbus"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/senml"
+)
+
+var _ modbus.Service = (*loggingMiddleware)(nil)
+
+type loggingMiddleware struct {
+	logger *slog.Logger
+	svc    modbus.Service
+}
+
+// LoggingMiddleware adds logging facilities to the Modbus gateway service.
+func LoggingMiddleware(svc modbus.Service, logger *slog.Logger) modbus.Service {
+	return &loggingMiddleware{
+		logger: logger,
+		svc:    svc,
+	}
+}
+
+func (lm *loggingMiddleware) Start(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Start polling Modbus devices failed", args...)
+			return
+		}
+		lm.logger.Info("Start polling Modbus devices completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Start(ctx)
+}
+
+func (lm *loggingMiddleware) CreateDevice(ctx context.Context, session smqauthn.Session, dev modbus.Device) (d modbus.Device, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("device",
+				slog.String("client_id", dev.ClientID),
+				slog.String("channel_id", dev.ChannelID),
+				slog.String("address", dev.Address),
+				slog.Int("registers", len(dev.Registers)),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Create Modbus device failed", args...)
+			return
+		}
+		lm.logger.Info("Create Modbus device completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.CreateDevice(ctx, session, dev)
+}
+
+func (lm *loggingMiddleware) ViewDevice(ctx context.Context, session smqauthn.Session, clientID string) (d modbus.Device, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("client_id", clientID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("View Modbus device failed", args...)
+			return
+		}
+		lm.logger.Info("View Modbus device completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ViewDevice(ctx, session, clientID)
+}
+
+func (lm *loggingMiddleware) UpdateDevice(ctx context.Context, session smqauthn.Session, dev modbus.Device) (d modbus.Device, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("device",
+				slog.String("client_id", dev.ClientID),
+				slog.String("channel_id", dev.ChannelID),
+				slog.String("address", dev.Address),
+				slog.Int("registers", len(dev.Registers)),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Update Modbus device failed", args...)
+			return
+		}
+		lm.logger.Info("Update Modbus device completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.UpdateDevice(ctx, session, dev)
+}
+
+func (lm *loggingMiddleware) RemoveDevice(ctx context.Context, session smqauthn.Session, clientID string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("client_id", clientID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Remove Modbus device failed", args...)
+			return
+		}
+		lm.logger.Info("Remove Modbus device completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.RemoveDevice(ctx, session, clientID)
+}
+
+func (lm *loggingMiddleware) Write(ctx context.Context, channelID, clientID string, pack senml.Pack) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("channel_id", channelID),
+			slog.String("client_id", clientID),
+			slog.Int("records", len(pack.Records)),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Write Modbus command failed", args...)
+			return
+		}
+		lm.logger.Info("Write Modbus command completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Write(ctx, channelID, clientID, pack)
+}
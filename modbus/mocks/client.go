@@ -0,0 +1,220 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Client is an autogenerated mock type for the Client type
+type Client struct {
+	mock.Mock
+}
+
+// Close provides a mock function with no fields
+func (_m *Client) Close() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Close")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReadCoils provides a mock function with given fields: ctx, address, quantity
+func (_m *Client) ReadCoils(ctx context.Context, address uint16, quantity uint16) ([]bool, error) {
+	ret := _m.Called(ctx, address, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReadCoils")
+	}
+
+	var r0 []bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint16, uint16) ([]bool, error)); ok {
+		return rf(ctx, address, quantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint16, uint16) []bool); ok {
+		r0 = rf(ctx, address, quantity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]bool)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint16, uint16) error); ok {
+		r1 = rf(ctx, address, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReadDiscreteInputs provides a mock function with given fields: ctx, address, quantity
+func (_m *Client) ReadDiscreteInputs(ctx context.Context, address uint16, quantity uint16) ([]bool, error) {
+	ret := _m.Called(ctx, address, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReadDiscreteInputs")
+	}
+
+	var r0 []bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint16, uint16) ([]bool, error)); ok {
+		return rf(ctx, address, quantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint16, uint16) []bool); ok {
+		r0 = rf(ctx, address, quantity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]bool)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint16, uint16) error); ok {
+		r1 = rf(ctx, address, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReadHoldingRegisters provides a mock function with given fields: ctx, address, quantity
+func (_m *Client) ReadHoldingRegisters(ctx context.Context, address uint16, quantity uint16) ([]uint16, error) {
+	ret := _m.Called(ctx, address, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReadHoldingRegisters")
+	}
+
+	var r0 []uint16
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint16, uint16) ([]uint16, error)); ok {
+		return rf(ctx, address, quantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint16, uint16) []uint16); ok {
+		r0 = rf(ctx, address, quantity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uint16)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint16, uint16) error); ok {
+		r1 = rf(ctx, address, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReadInputRegisters provides a mock function with given fields: ctx, address, quantity
+func (_m *Client) ReadInputRegisters(ctx context.Context, address uint16, quantity uint16) ([]uint16, error) {
+	ret := _m.Called(ctx, address, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReadInputRegisters")
+	}
+
+	var r0 []uint16
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint16, uint16) ([]uint16, error)); ok {
+		return rf(ctx, address, quantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint16, uint16) []uint16); ok {
+		r0 = rf(ctx, address, quantity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uint16)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint16, uint16) error); ok {
+		r1 = rf(ctx, address, quantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WriteMultipleRegisters provides a mock function with given fields: ctx, address, values
+func (_m *Client) WriteMultipleRegisters(ctx context.Context, address uint16, values []uint16) error {
+	ret := _m.Called(ctx, address, values)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WriteMultipleRegisters")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint16, []uint16) error); ok {
+		r0 = rf(ctx, address, values)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WriteSingleCoil provides a mock function with given fields: ctx, address, value
+func (_m *Client) WriteSingleCoil(ctx context.Context, address uint16, value bool) error {
+	ret := _m.Called(ctx, address, value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WriteSingleCoil")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint16, bool) error); ok {
+		r0 = rf(ctx, address, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WriteSingleRegister provides a mock function with given fields: ctx, address, value
+func (_m *Client) WriteSingleRegister(ctx context.Context, address uint16, value uint16) error {
+	ret := _m.Called(ctx, address, value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WriteSingleRegister")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint16, uint16) error); ok {
+		r0 = rf(ctx, address, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewClient creates a new instance of Client. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewClient(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Client {
+	mock := &Client{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
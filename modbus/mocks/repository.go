@@ -0,0 +1,161 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	modbus "github.com/hantdev/mitras/modbus"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Repository is an autogenerated mock type for the Repository type
+type Repository struct {
+	mock.Mock
+}
+
+// Remove provides a mock function with given fields: ctx, clientID
+func (_m *Repository) Remove(ctx context.Context, clientID string) error {
+	ret := _m.Called(ctx, clientID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Remove")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, clientID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RetrieveAll provides a mock function with given fields: ctx
+func (_m *Repository) RetrieveAll(ctx context.Context) ([]modbus.Device, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveAll")
+	}
+
+	var r0 []modbus.Device
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]modbus.Device, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []modbus.Device); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]modbus.Device)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RetrieveByClient provides a mock function with given fields: ctx, clientID
+func (_m *Repository) RetrieveByClient(ctx context.Context, clientID string) (modbus.Device, error) {
+	ret := _m.Called(ctx, clientID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveByClient")
+	}
+
+	var r0 modbus.Device
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (modbus.Device, error)); ok {
+		return rf(ctx, clientID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) modbus.Device); ok {
+		r0 = rf(ctx, clientID)
+	} else {
+		r0 = ret.Get(0).(modbus.Device)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, clientID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, dev
+func (_m *Repository) Save(ctx context.Context, dev modbus.Device) (modbus.Device, error) {
+	ret := _m.Called(ctx, dev)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 modbus.Device
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, modbus.Device) (modbus.Device, error)); ok {
+		return rf(ctx, dev)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, modbus.Device) modbus.Device); ok {
+		r0 = rf(ctx, dev)
+	} else {
+		r0 = ret.Get(0).(modbus.Device)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, modbus.Device) error); ok {
+		r1 = rf(ctx, dev)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, dev
+func (_m *Repository) Update(ctx context.Context, dev modbus.Device) (modbus.Device, error) {
+	ret := _m.Called(ctx, dev)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 modbus.Device
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, modbus.Device) (modbus.Device, error)); ok {
+		return rf(ctx, dev)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, modbus.Device) modbus.Device); ok {
+		r0 = rf(ctx, dev)
+	} else {
+		r0 = ret.Get(0).(modbus.Device)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, modbus.Device) error); ok {
+		r1 = rf(ctx, dev)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Repository {
+	mock := &Repository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,172 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	authn "github.com/hantdev/mitras/pkg/authn"
+
+	mock "github.com/stretchr/testify/mock"
+
+	modbus "github.com/hantdev/mitras/modbus"
+
+	senml "github.com/hantdev/senml"
+)
+
+// Service is an autogenerated mock type for the Service type
+type Service struct {
+	mock.Mock
+}
+
+// CreateDevice provides a mock function with given fields: ctx, session, dev
+func (_m *Service) CreateDevice(ctx context.Context, session authn.Session, dev modbus.Device) (modbus.Device, error) {
+	ret := _m.Called(ctx, session, dev)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateDevice")
+	}
+
+	var r0 modbus.Device
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, modbus.Device) (modbus.Device, error)); ok {
+		return rf(ctx, session, dev)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, modbus.Device) modbus.Device); ok {
+		r0 = rf(ctx, session, dev)
+	} else {
+		r0 = ret.Get(0).(modbus.Device)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, modbus.Device) error); ok {
+		r1 = rf(ctx, session, dev)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveDevice provides a mock function with given fields: ctx, session, clientID
+func (_m *Service) RemoveDevice(ctx context.Context, session authn.Session, clientID string) error {
+	ret := _m.Called(ctx, session, clientID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveDevice")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) error); ok {
+		r0 = rf(ctx, session, clientID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Start provides a mock function with given fields: ctx
+func (_m *Service) Start(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Start")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateDevice provides a mock function with given fields: ctx, session, dev
+func (_m *Service) UpdateDevice(ctx context.Context, session authn.Session, dev modbus.Device) (modbus.Device, error) {
+	ret := _m.Called(ctx, session, dev)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateDevice")
+	}
+
+	var r0 modbus.Device
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, modbus.Device) (modbus.Device, error)); ok {
+		return rf(ctx, session, dev)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, modbus.Device) modbus.Device); ok {
+		r0 = rf(ctx, session, dev)
+	} else {
+		r0 = ret.Get(0).(modbus.Device)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, modbus.Device) error); ok {
+		r1 = rf(ctx, session, dev)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ViewDevice provides a mock function with given fields: ctx, session, clientID
+func (_m *Service) ViewDevice(ctx context.Context, session authn.Session, clientID string) (modbus.Device, error) {
+	ret := _m.Called(ctx, session, clientID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ViewDevice")
+	}
+
+	var r0 modbus.Device
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) (modbus.Device, error)); ok {
+		return rf(ctx, session, clientID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) modbus.Device); ok {
+		r0 = rf(ctx, session, clientID)
+	} else {
+		r0 = ret.Get(0).(modbus.Device)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, string) error); ok {
+		r1 = rf(ctx, session, clientID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Write provides a mock function with given fields: ctx, channelID, clientID, pack
+func (_m *Service) Write(ctx context.Context, channelID string, clientID string, pack senml.Pack) error {
+	ret := _m.Called(ctx, channelID, clientID, pack)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Write")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, senml.Pack) error); ok {
+		r0 = rf(ctx, channelID, clientID, pack)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewService creates a new instance of Service. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Service {
+	mock := &Service{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
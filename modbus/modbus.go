@@ -0,0 +1,169 @@
+package modbus
+
+import (
+	"context"
+	"time"
+
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/senml"
+)
+
+// ControlSubtopic is the subtopic of the device channel used to send the
+// write commands. Commands of the device are published to the
+// control.<client_id> subtopic as SenML records named after the registers.
+const ControlSubtopic = "control"
+
+// MinInterval is the minimal polling interval of the device.
+const MinInterval = 100 * time.Millisecond
+
+// RegisterType is the Modbus data model table of the register.
+type RegisterType string
+
+// Modbus data model tables.
+const (
+	Coil            RegisterType = "coil"
+	DiscreteInput   RegisterType = "discrete_input"
+	HoldingRegister RegisterType = "holding_register"
+	InputRegister   RegisterType = "input_register"
+)
+
+// DataType is the type of the register value. 32-bit values are stored in
+// two consecutive registers with the high word first.
+type DataType string
+
+// Supported data types.
+const (
+	Bool    DataType = "bool"
+	Int16   DataType = "int16"
+	Uint16  DataType = "uint16"
+	Int32   DataType = "int32"
+	Uint32  DataType = "uint32"
+	Float32 DataType = "float32"
+)
+
+var (
+	// ErrInvalidRegister indicates the malformed register of the register map.
+	ErrInvalidRegister = errors.New("invalid register")
+
+	// ErrInvalidInterval indicates the polling interval shorter than MinInterval.
+	ErrInvalidInterval = errors.New("invalid polling interval")
+
+	// ErrInvalidValue indicates the value which can't be written to the register.
+	ErrInvalidValue = errors.New("invalid register value")
+
+	// ErrReadOnly indicates the write to the discrete input or input register.
+	ErrReadOnly = errors.New("register is read only")
+
+	// ErrUnknownRegister indicates the command for the register which isn't in the register map.
+	ErrUnknownRegister = errors.New("unknown register")
+
+	// ErrConnection indicates the failure to connect to the device.
+	ErrConnection = errors.New("failed to connect to the device")
+)
+
+// Register describes the register of the device and the value published for it.
+// The published value is the raw value multiplied by the scale and increased
+// by the offset.
+type Register struct {
+	Name     string       `json:"name"`
+	Type     RegisterType `json:"type"`
+	Address  uint16       `json:"address"`
+	DataType DataType     `json:"data_type"`
+	Scale    float64      `json:"scale,omitempty"`
+	Offset   float64      `json:"offset,omitempty"`
+	Unit     string       `json:"unit,omitempty"`
+}
+
+// Device is the Modbus TCP slave polled on behalf of the mitras client.
+// Readings are published to the channel of the device.
+type Device struct {
+	ClientID  string        `json:"client_id"`
+	DomainID  string        `json:"domain_id"`
+	ChannelID string        `json:"channel_id"`
+	Address   string        `json:"address"`
+	UnitID    uint8         `json:"unit_id"`
+	Interval  time.Duration `json:"interval"`
+	Registers []Register    `json:"registers"`
+	CreatedBy string        `json:"created_by,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at,omitempty"`
+}
+
+// Client is the Modbus client connected to the device.
+//
+//go:generate mockery --name Client --output=./mocks --filename client.go --quiet
+type Client interface {
+	// ReadCoils reads the quantity of coils starting from the address.
+	ReadCoils(ctx context.Context, address, quantity uint16) ([]bool, error)
+
+	// ReadDiscreteInputs reads the quantity of discrete inputs starting from the address.
+	ReadDiscreteInputs(ctx context.Context, address, quantity uint16) ([]bool, error)
+
+	// ReadHoldingRegisters reads the quantity of holding registers starting from the address.
+	ReadHoldingRegisters(ctx context.Context, address, quantity uint16) ([]uint16, error)
+
+	// ReadInputRegisters reads the quantity of input registers starting from the address.
+	ReadInputRegisters(ctx context.Context, address, quantity uint16) ([]uint16, error)
+
+	// WriteSingleCoil writes the coil.
+	WriteSingleCoil(ctx context.Context, address uint16, value bool) error
+
+	// WriteSingleRegister writes the holding register.
+	WriteSingleRegister(ctx context.Context, address, value uint16) error
+
+	// WriteMultipleRegisters writes the consecutive holding registers starting from the address.
+	WriteMultipleRegisters(ctx context.Context, address uint16, values []uint16) error
+
+	// Close closes the connection to the device.
+	Close() error
+}
+
+// Dialer connects to the unit of the device at the address.
+type Dialer func(ctx context.Context, address string, unitID uint8) (Client, error)
+
+// Service specifies the API of the Modbus gateway.
+//
+//go:generate mockery --name Service --output=./mocks --filename service.go --quiet
+type Service interface {
+	// Start starts polling of the stored devices.
+	Start(ctx context.Context) error
+
+	// CreateDevice saves the device and starts polling it.
+	CreateDevice(ctx context.Context, session smqauthn.Session, dev Device) (Device, error)
+
+	// ViewDevice returns the device of the client.
+	ViewDevice(ctx context.Context, session smqauthn.Session, clientID string) (Device, error)
+
+	// UpdateDevice updates the connection, the polling interval and the
+	// register map of the device.
+	UpdateDevice(ctx context.Context, session smqauthn.Session, dev Device) (Device, error)
+
+	// RemoveDevice stops polling the device and removes it.
+	RemoveDevice(ctx context.Context, session smqauthn.Session, clientID string) error
+
+	// Write writes the records received from the control subtopic of the
+	// channel to the registers of the device.
+	Write(ctx context.Context, channelID, clientID string, pack senml.Pack) error
+}
+
+// Repository specifies the devices persistence API.
+//
+//go:generate mockery --name Repository --output=./mocks --filename repository.go --quiet
+type Repository interface {
+	// Save persists the device.
+	Save(ctx context.Context, dev Device) (Device, error)
+
+	// RetrieveByClient retrieves the device of the client.
+	RetrieveByClient(ctx context.Context, clientID string) (Device, error)
+
+	// RetrieveAll retrieves all the devices.
+	RetrieveAll(ctx context.Context) ([]Device, error)
+
+	// Update updates the connection, the polling interval and the register
+	// map of the device.
+	Update(ctx context.Context, dev Device) (Device, error)
+
+	// Remove removes the device of the client.
+	Remove(ctx context.Context, clientID string) error
+}
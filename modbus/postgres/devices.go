@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/hantdev/mitras/modbus"
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/hantdev/mitras/pkg/postgres"
+)
+
+const selectDevices = `SELECT client_id, domain_id, channel_id, address, unit_id, interval, registers,
+	COALESCE(created_by, '') AS created_by, created_at, updated_at FROM modbus_devices`
+
+type repository struct {
+	db postgres.Database
+}
+
+// NewRepository instantiates a PostgreSQL implementation of the Modbus
+// devices repository.
+func NewRepository(db postgres.Database) modbus.Repository {
+	return &repository{db: db}
+}
+
+func (repo *repository) Save(ctx context.Context, dev modbus.Device) (modbus.Device, error) {
+	q := `INSERT INTO modbus_devices (client_id, domain_id, channel_id, address, unit_id, interval, registers, created_by, created_at)
+		VALUES (:client_id, :domain_id, :channel_id, :address, :unit_id, :interval, :registers, :created_by, :created_at);`
+
+	dbd, err := toDBDevice(dev)
+	if err != nil {
+		return modbus.Device{}, errors.Wrap(repoerr.ErrCreateEntity, err)
+	}
+	if _, err := repo.db.NamedExecContext(ctx, q, dbd); err != nil {
+		return modbus.Device{}, postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+
+	return dev, nil
+}
+
+func (repo *repository) RetrieveByClient(ctx context.Context, clientID string) (modbus.Device, error) {
+	q := selectDevices + ` WHERE client_id = :client_id;`
+
+	devs, err := repo.retrieve(ctx, q, dbDevice{ClientID: clientID})
+	if err != nil {
+		return modbus.Device{}, err
+	}
+	if len(devs) == 0 {
+		return modbus.Device{}, repoerr.ErrNotFound
+	}
+
+	return devs[0], nil
+}
+
+func (repo *repository) RetrieveAll(ctx context.Context) ([]modbus.Device, error) {
+	return repo.retrieve(ctx, selectDevices+";", dbDevice{})
+}
+
+func (repo *repository) Update(ctx context.Context, dev modbus.Device) (modbus.Device, error) {
+	q := `UPDATE modbus_devices SET channel_id = :channel_id, address = :address, unit_id = :unit_id,
+		interval = :interval, registers = :registers, updated_at = :updated_at
+		WHERE client_id = :client_id
+		RETURNING client_id, domain_id, channel_id, address, unit_id, interval, registers,
+		COALESCE(created_by, '') AS created_by, created_at, updated_at;`
+
+	dbd, err := toDBDevice(dev)
+	if err != nil {
+		return modbus.Device{}, errors.Wrap(repoerr.ErrUpdateEntity, err)
+	}
+	devs, err := repo.retrieve(ctx, q, dbd)
+	if err != nil {
+		return modbus.Device{}, postgres.HandleError(repoerr.ErrUpdateEntity, err)
+	}
+	if len(devs) == 0 {
+		return modbus.Device{}, repoerr.ErrNotFound
+	}
+
+	return devs[0], nil
+}
+
+func (repo *repository) Remove(ctx context.Context, clientID string) error {
+	q := `DELETE FROM modbus_devices WHERE client_id = $1;`
+
+	res, err := repo.db.ExecContext(ctx, q, clientID)
+	if err != nil {
+		return postgres.HandleError(repoerr.ErrRemoveEntity, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo *repository) retrieve(ctx context.Context, q string, arg dbDevice) ([]modbus.Device, error) {
+	rows, err := repo.db.NamedQueryContext(ctx, q, arg)
+	if err != nil {
+		return nil, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	var devs []modbus.Device
+	for rows.Next() {
+		var dbd dbDevice
+		if err := rows.StructScan(&dbd); err != nil {
+			return nil, postgres.HandleError(repoerr.ErrViewEntity, err)
+		}
+		dev, err := toDevice(dbd)
+		if err != nil {
+			return nil, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		devs = append(devs, dev)
+	}
+
+	return devs, nil
+}
+
+type dbDevice struct {
+	ClientID  string       `db:"client_id"`
+	DomainID  string       `db:"domain_id"`
+	ChannelID string       `db:"channel_id"`
+	Address   string       `db:"address"`
+	UnitID    int16        `db:"unit_id"`
+	Interval  int64        `db:"interval"`
+	Registers []byte       `db:"registers"`
+	CreatedBy string       `db:"created_by"`
+	CreatedAt time.Time    `db:"created_at"`
+	UpdatedAt sql.NullTime `db:"updated_at"`
+}
+
+func toDBDevice(dev modbus.Device) (dbDevice, error) {
+	registers, err := json.Marshal(dev.Registers)
+	if err != nil {
+		return dbDevice{}, errors.Wrap(repoerr.ErrMalformedEntity, err)
+	}
+
+	return dbDevice{
+		ClientID:  dev.ClientID,
+		DomainID:  dev.DomainID,
+		ChannelID: dev.ChannelID,
+		Address:   dev.Address,
+		UnitID:    int16(dev.UnitID),
+		Interval:  int64(dev.Interval),
+		Registers: registers,
+		CreatedBy: dev.CreatedBy,
+		CreatedAt: dev.CreatedAt,
+		UpdatedAt: sql.NullTime{Time: dev.UpdatedAt, Valid: !dev.UpdatedAt.IsZero()},
+	}, nil
+}
+
+func toDevice(dbd dbDevice) (modbus.Device, error) {
+	var registers []modbus.Register
+	if err := json.Unmarshal(dbd.Registers, &registers); err != nil {
+		return modbus.Device{}, errors.Wrap(repoerr.ErrMalformedEntity, err)
+	}
+
+	return modbus.Device{
+		ClientID:  dbd.ClientID,
+		DomainID:  dbd.DomainID,
+		ChannelID: dbd.ChannelID,
+		Address:   dbd.Address,
+		UnitID:    uint8(dbd.UnitID),
+		Interval:  time.Duration(dbd.Interval),
+		Registers: registers,
+		CreatedBy: dbd.CreatedBy,
+		CreatedAt: dbd.CreatedAt,
+		UpdatedAt: dbd.UpdatedAt.Time,
+	}, nil
+}
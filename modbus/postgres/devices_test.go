@@ -0,0 +1,132 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/internal/testsutil"
+	"github.com/hantdev/mitras/modbus"
+	"github.com/hantdev/mitras/modbus/postgres"
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDevice(t *testing.T) modbus.Device {
+	return modbus.Device{
+		ClientID:  testsutil.GenerateUUID(t),
+		DomainID:  testsutil.GenerateUUID(t),
+		ChannelID: testsutil.GenerateUUID(t),
+		Address:   "localhost:502",
+		UnitID:    1,
+		Interval:  time.Second,
+		Registers: []modbus.Register{
+			{Name: "temperature", Type: modbus.HoldingRegister, Address: 0, DataType: modbus.Int16, Scale: 0.1, Unit: "Cel"},
+			{Name: "relay", Type: modbus.Coil, Address: 1, DataType: modbus.Bool},
+		},
+		CreatedBy: testsutil.GenerateUUID(t),
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
+
+func cleanup(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := db.Exec("DELETE FROM modbus_devices")
+		require.Nil(t, err, fmt.Sprintf("clean devices unexpected error: %s", err))
+	})
+}
+
+func TestSave(t *testing.T) {
+	cleanup(t)
+	repo := postgres.NewRepository(database)
+
+	dev := newDevice(t)
+	duplicate := newDevice(t)
+	duplicate.ClientID = dev.ClientID
+
+	cases := []struct {
+		desc string
+		dev  modbus.Device
+		err  error
+	}{
+		{
+			desc: "save new device",
+			dev:  dev,
+			err:  nil,
+		},
+		{
+			desc: "save device of the same client",
+			dev:  duplicate,
+			err:  repoerr.ErrConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := repo.Save(context.Background(), tc.dev)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected error %s, got %s", tc.desc, tc.err, err))
+		})
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	cleanup(t)
+	repo := postgres.NewRepository(database)
+
+	dev := newDevice(t)
+	_, err := repo.Save(context.Background(), dev)
+	require.Nil(t, err, fmt.Sprintf("save device unexpected error: %s", err))
+
+	saved, err := repo.RetrieveByClient(context.Background(), dev.ClientID)
+	assert.Nil(t, err, fmt.Sprintf("retrieve device unexpected error: %s", err))
+	assert.Equal(t, dev, saved)
+
+	_, err = repo.RetrieveByClient(context.Background(), testsutil.GenerateUUID(t))
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+
+	other := newDevice(t)
+	_, err = repo.Save(context.Background(), other)
+	require.Nil(t, err, fmt.Sprintf("save device unexpected error: %s", err))
+	devs, err := repo.RetrieveAll(context.Background())
+	assert.Nil(t, err, fmt.Sprintf("retrieve devices unexpected error: %s", err))
+	assert.ElementsMatch(t, []modbus.Device{dev, other}, devs)
+}
+
+func TestUpdate(t *testing.T) {
+	cleanup(t)
+	repo := postgres.NewRepository(database)
+
+	dev := newDevice(t)
+	_, err := repo.Save(context.Background(), dev)
+	require.Nil(t, err, fmt.Sprintf("save device unexpected error: %s", err))
+
+	dev.Address = "10.0.0.1:502"
+	dev.Interval = time.Minute
+	dev.Registers = dev.Registers[:1]
+	dev.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	updated, err := repo.Update(context.Background(), dev)
+	assert.Nil(t, err, fmt.Sprintf("update device unexpected error: %s", err))
+	assert.Equal(t, dev, updated)
+
+	unknown := newDevice(t)
+	_, err = repo.Update(context.Background(), unknown)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+}
+
+func TestRemove(t *testing.T) {
+	cleanup(t)
+	repo := postgres.NewRepository(database)
+
+	dev := newDevice(t)
+	_, err := repo.Save(context.Background(), dev)
+	require.Nil(t, err, fmt.Sprintf("save device unexpected error: %s", err))
+
+	err = repo.Remove(context.Background(), dev.ClientID)
+	assert.Nil(t, err, fmt.Sprintf("remove device unexpected error: %s", err))
+
+	err = repo.Remove(context.Background(), dev.ClientID)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+}
@@ -0,0 +1,3 @@
+// Package postgres provides a postgres implementation of the Modbus devices
+// repository.
+package postgres
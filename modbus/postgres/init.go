@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	_ "github.com/jackc/pgx/v5/stdlib" // required for SQL access
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+func Migration() *migrate.MemoryMigrationSource {
+	return &migrate.MemoryMigrationSource{
+		Migrations: []*migrate.Migration{
+			{
+				Id: "modbus_01",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS modbus_devices (
+						client_id	VARCHAR(36) PRIMARY KEY,
+						domain_id	VARCHAR(36) NOT NULL,
+						channel_id	VARCHAR(36) NOT NULL,
+						address		VARCHAR(1024) NOT NULL,
+						unit_id		SMALLINT NOT NULL,
+						interval	BIGINT NOT NULL,
+						registers	JSONB NOT NULL,
+						created_by	VARCHAR(254),
+						created_at	TIMESTAMP NOT NULL,
+						updated_at	TIMESTAMP
+					)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS modbus_devices`,
+				},
+			},
+		},
+	}
+}
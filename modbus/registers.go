@@ -0,0 +1,202 @@
+package modbus
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/senml"
+)
+
+// Validate checks the polling interval and the register map of the device.
+func (dev Device) Validate() error {
+	if dev.Interval < MinInterval {
+		return ErrInvalidInterval
+	}
+	names := make(map[string]bool, len(dev.Registers))
+	for _, r := range dev.Registers {
+		if err := r.validate(); err != nil {
+			return errors.Wrap(ErrInvalidRegister, err)
+		}
+		if names[r.Name] {
+			return errors.Wrap(ErrInvalidRegister, fmt.Errorf("duplicate register name %s", r.Name))
+		}
+		names[r.Name] = true
+	}
+
+	return nil
+}
+
+func (r Register) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("missing name of register %d", r.Address)
+	}
+	switch r.Type {
+	case Coil, DiscreteInput:
+		if r.DataType != Bool {
+			return fmt.Errorf("data type of %s %s must be %s", r.Type, r.Name, Bool)
+		}
+	case HoldingRegister, InputRegister:
+		if r.DataType == Bool || r.size() == 0 {
+			return fmt.Errorf("unsupported data type %q of register %s", r.DataType, r.Name)
+		}
+	default:
+		return fmt.Errorf("unsupported type %q of register %s", r.Type, r.Name)
+	}
+	if int(r.Address)+int(r.size()) > math.MaxUint16+1 {
+		return fmt.Errorf("register %s exceeds the address space", r.Name)
+	}
+
+	return nil
+}
+
+// size returns the number of registers or bits which store the value.
+func (r Register) size() uint16 {
+	switch r.DataType {
+	case Bool, Int16, Uint16:
+		return 1
+	case Int32, Uint32, Float32:
+		return 2
+	default:
+		return 0
+	}
+}
+
+func (r Register) scale() float64 {
+	if r.Scale == 0 {
+		return 1
+	}
+
+	return r.Scale
+}
+
+// read reads the register from the device and returns its record.
+func (r Register) read(ctx context.Context, c Client) (senml.Record, error) {
+	rec := senml.Record{Name: r.Name, Unit: r.Unit}
+
+	var bits []bool
+	var words []uint16
+	var err error
+	switch r.Type {
+	case Coil:
+		bits, err = c.ReadCoils(ctx, r.Address, 1)
+	case DiscreteInput:
+		bits, err = c.ReadDiscreteInputs(ctx, r.Address, 1)
+	case HoldingRegister:
+		words, err = c.ReadHoldingRegisters(ctx, r.Address, r.size())
+	case InputRegister:
+		words, err = c.ReadInputRegisters(ctx, r.Address, r.size())
+	}
+	if err != nil {
+		return senml.Record{}, err
+	}
+
+	if bits != nil {
+		rec.BoolValue = &bits[0]
+		return rec, nil
+	}
+	v := r.decode(words)*r.scale() + r.Offset
+	rec.Value = &v
+
+	return rec, nil
+}
+
+// command is the write of the value to the register of the device.
+type command func(ctx context.Context, c Client) error
+
+// command returns the command which writes the value of the record to the
+// register. The published value is converted back to the raw value.
+func (r Register) command(rec senml.Record) (command, error) {
+	switch r.Type {
+	case Coil:
+		var on bool
+		switch {
+		case rec.BoolValue != nil:
+			on = *rec.BoolValue
+		case rec.Value != nil:
+			on = *rec.Value != 0
+		default:
+			return nil, ErrInvalidValue
+		}
+		return func(ctx context.Context, c Client) error {
+			return c.WriteSingleCoil(ctx, r.Address, on)
+		}, nil
+	case HoldingRegister:
+		if rec.Value == nil {
+			return nil, ErrInvalidValue
+		}
+		words, err := r.encode((*rec.Value - r.Offset) / r.scale())
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, c Client) error {
+			if len(words) == 1 {
+				return c.WriteSingleRegister(ctx, r.Address, words[0])
+			}
+			return c.WriteMultipleRegisters(ctx, r.Address, words)
+		}, nil
+	default:
+		return nil, ErrReadOnly
+	}
+}
+
+func (r Register) decode(words []uint16) float64 {
+	switch r.DataType {
+	case Int16:
+		return float64(int16(words[0]))
+	case Uint16:
+		return float64(words[0])
+	case Int32:
+		return float64(int32(uint32(words[0])<<16 | uint32(words[1])))
+	case Uint32:
+		return float64(uint32(words[0])<<16 | uint32(words[1]))
+	case Float32:
+		return float64(math.Float32frombits(uint32(words[0])<<16 | uint32(words[1])))
+	default:
+		return 0
+	}
+}
+
+// encode encodes the raw value of the register. Integers are rounded to the
+// nearest value, and values out of the range of the data type are rejected.
+func (r Register) encode(v float64) ([]uint16, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, ErrInvalidValue
+	}
+	if r.DataType == Float32 {
+		if math.Abs(v) > math.MaxFloat32 {
+			return nil, ErrInvalidValue
+		}
+		bits := math.Float32bits(float32(v))
+		return []uint16{uint16(bits >> 16), uint16(bits)}, nil
+	}
+
+	v = math.Round(v)
+	switch r.DataType {
+	case Int16:
+		if v < math.MinInt16 || v > math.MaxInt16 {
+			return nil, ErrInvalidValue
+		}
+		return []uint16{uint16(int16(v))}, nil
+	case Uint16:
+		if v < 0 || v > math.MaxUint16 {
+			return nil, ErrInvalidValue
+		}
+		return []uint16{uint16(v)}, nil
+	case Int32:
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return nil, ErrInvalidValue
+		}
+		u := uint32(int32(v))
+		return []uint16{uint16(u >> 16), uint16(u)}, nil
+	case Uint32:
+		if v < 0 || v > math.MaxUint32 {
+			return nil, ErrInvalidValue
+		}
+		u := uint32(v)
+		return []uint16{uint16(u >> 16), uint16(u)}, nil
+	default:
+		return nil, ErrInvalidValue
+	}
+}
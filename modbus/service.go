@@ -0,0 +1,321 @@
+package modbus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	grpcChannelsV1 "github.com/hantdev/mitras/internal/grpc/channels/v1"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/connections"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/mitras/pkg/messaging"
+	"github.com/hantdev/mitras/pkg/policies"
+	"github.com/hantdev/senml"
+)
+
+const protocol = "modbus"
+
+// poller polls the device over a single connection, which is shared with the
+// writes of the device commands.
+type poller struct {
+	dev    Device
+	dial   Dialer
+	mu     sync.Mutex
+	client Client
+	closed bool
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type service struct {
+	repo      Repository
+	channels  grpcChannelsV1.ChannelsServiceClient
+	publisher messaging.Publisher
+	dial      Dialer
+	logger    *slog.Logger
+	mu        sync.Mutex
+	pollers   map[string]*poller
+}
+
+var _ Service = (*service)(nil)
+
+// NewService instantiates the Modbus gateway service.
+func NewService(repo Repository, channels grpcChannelsV1.ChannelsServiceClient, publisher messaging.Publisher, dial Dialer, logger *slog.Logger) Service {
+	return &service{
+		repo:      repo,
+		channels:  channels,
+		publisher: publisher,
+		dial:      dial,
+		logger:    logger,
+		pollers:   make(map[string]*poller),
+	}
+}
+
+func (svc *service) Start(ctx context.Context) error {
+	devs, err := svc.repo.RetrieveAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	for _, dev := range devs {
+		svc.start(dev)
+	}
+
+	return nil
+}
+
+func (svc *service) CreateDevice(ctx context.Context, session smqauthn.Session, dev Device) (Device, error) {
+	if err := dev.Validate(); err != nil {
+		return Device{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+	dev.DomainID = session.DomainID
+	dev.CreatedBy = session.UserID
+	dev.CreatedAt = time.Now().UTC()
+
+	dev, err := svc.repo.Save(ctx, dev)
+	if err != nil {
+		return Device{}, errors.Wrap(svcerr.ErrCreateEntity, err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.start(dev)
+
+	return dev, nil
+}
+
+func (svc *service) ViewDevice(ctx context.Context, session smqauthn.Session, clientID string) (Device, error) {
+	dev, err := svc.repo.RetrieveByClient(ctx, clientID)
+	if err != nil {
+		return Device{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	return dev, nil
+}
+
+func (svc *service) UpdateDevice(ctx context.Context, session smqauthn.Session, dev Device) (Device, error) {
+	if err := dev.Validate(); err != nil {
+		return Device{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+	dev.UpdatedAt = time.Now().UTC()
+
+	dev, err := svc.repo.Update(ctx, dev)
+	if err != nil {
+		return Device{}, errors.Wrap(svcerr.ErrUpdateEntity, err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.start(dev)
+
+	return dev, nil
+}
+
+func (svc *service) RemoveDevice(ctx context.Context, session smqauthn.Session, clientID string) error {
+	if err := svc.repo.Remove(ctx, clientID); err != nil {
+		return errors.Wrap(svcerr.ErrRemoveEntity, err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.stop(clientID)
+
+	return nil
+}
+
+func (svc *service) Write(ctx context.Context, channelID, clientID string, pack senml.Pack) error {
+	svc.mu.Lock()
+	p, ok := svc.pollers[clientID]
+	svc.mu.Unlock()
+	if !ok {
+		return svcerr.ErrNotFound
+	}
+	// Commands are accepted only from the channel of the device.
+	if p.dev.ChannelID != channelID {
+		return svcerr.ErrAuthorization
+	}
+
+	registers := make(map[string]Register, len(p.dev.Registers))
+	for _, r := range p.dev.Registers {
+		registers[r.Name] = r
+	}
+	pack, err := senml.Normalize(pack)
+	if err != nil {
+		return errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+
+	// All the records are checked before any of them is written to the device.
+	cmds := make([]command, len(pack.Records))
+	for i, rec := range pack.Records {
+		r, ok := registers[rec.Name]
+		if !ok {
+			return errors.Wrap(svcerr.ErrMalformedEntity, errors.Wrap(ErrUnknownRegister, fmt.Errorf("register %s", rec.Name)))
+		}
+		cmd, err := r.command(rec)
+		if err != nil {
+			return errors.Wrap(svcerr.ErrMalformedEntity, errors.Wrap(fmt.Errorf("register %s", r.Name), err))
+		}
+		cmds[i] = cmd
+	}
+
+	return p.do(ctx, func(c Client) error {
+		for _, cmd := range cmds {
+			if err := cmd(ctx, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// start starts polling the device, replacing the poller of the previous
+// version of the device. It must be called with the service lock held.
+func (svc *service) start(dev Device) {
+	svc.stop(dev.ClientID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &poller{
+		dev:    dev,
+		dial:   svc.dial,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	svc.pollers[dev.ClientID] = p
+
+	go func() {
+		defer close(p.done)
+		defer p.close()
+
+		ticker := time.NewTicker(dev.Interval)
+		defer ticker.Stop()
+		for {
+			if err := svc.poll(ctx, p); err != nil && ctx.Err() == nil {
+				svc.logger.Warn(fmt.Sprintf("Failed to poll device %s of client %s: %s", dev.Address, dev.ClientID, err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stop stops polling the device and waits for the poll in progress to end.
+// It must be called with the service lock held.
+func (svc *service) stop(clientID string) {
+	p, ok := svc.pollers[clientID]
+	if !ok {
+		return
+	}
+	delete(svc.pollers, clientID)
+	p.cancel()
+	<-p.done
+}
+
+// poll reads the registers of the device and publishes the readings to the
+// channel of the device. Registers which the device fails to read are
+// skipped, so that a single misconfigured register doesn't stop the others.
+func (svc *service) poll(ctx context.Context, p *poller) error {
+	if len(p.dev.Registers) == 0 {
+		return nil
+	}
+
+	var pack senml.Pack
+	err := p.do(ctx, func(c Client) error {
+		now := float64(time.Now().UnixNano()) / float64(time.Second)
+		for _, r := range p.dev.Registers {
+			rec, err := r.read(ctx, c)
+			if errors.Contains(err, ErrException) {
+				svc.logger.Warn(fmt.Sprintf("Failed to read register %s of client %s: %s", r.Name, p.dev.ClientID, err))
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			rec.Time = now
+			pack.Records = append(pack.Records, rec)
+		}
+		return nil
+	})
+	if err != nil || len(pack.Records) == 0 {
+		return err
+	}
+
+	return svc.publish(ctx, p.dev, pack)
+}
+
+func (svc *service) publish(ctx context.Context, dev Device, pack senml.Pack) error {
+	payload, err := senml.Encode(pack, senml.JSON)
+	if err != nil {
+		return err
+	}
+
+	res, err := svc.channels.Authorize(ctx, &grpcChannelsV1.AuthzReq{
+		ClientId:   dev.ClientID,
+		ClientType: policies.ClientType,
+		Type:       uint32(connections.Publish),
+		ChannelId:  dev.ChannelID,
+	})
+	if err != nil {
+		return errors.Wrap(svcerr.ErrAuthorization, err)
+	}
+	if !res.GetAuthorized() {
+		return svcerr.ErrAuthorization
+	}
+
+	msg := &messaging.Message{
+		Protocol:  protocol,
+		Channel:   dev.ChannelID,
+		Publisher: dev.ClientID,
+		Payload:   payload,
+		Created:   time.Now().UnixNano(),
+	}
+
+	return svc.publisher.Publish(ctx, msg.GetChannel(), msg)
+}
+
+// do calls the function with the client connected to the device. The device
+// is connected on the first use, and the connection is dropped if the request
+// fails for any reason other than the exception response of the device.
+func (p *poller) do(ctx context.Context, f func(Client) error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Commands may be received while the device is being removed.
+	if p.closed {
+		return svcerr.ErrNotFound
+	}
+	if p.client == nil {
+		c, err := p.dial(ctx, p.dev.Address, p.dev.UnitID)
+		if err != nil {
+			return errors.Wrap(ErrConnection, err)
+		}
+		p.client = c
+	}
+
+	err := f(p.client)
+	if err != nil && !errors.Contains(err, ErrException) {
+		p.client.Close()
+		p.client = nil
+	}
+
+	return err
+}
+
+func (p *poller) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	if p.client != nil {
+		p.client.Close()
+		p.client = nil
+	}
+}
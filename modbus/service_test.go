@@ -0,0 +1,416 @@
+package modbus_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	chmocks "github.com/hantdev/mitras/channels/mocks"
+	grpcChannelsV1 "github.com/hantdev/mitras/internal/grpc/channels/v1"
+	"github.com/hantdev/mitras/internal/testsutil"
+	mglog "github.com/hantdev/mitras/logger"
+	"github.com/hantdev/mitras/modbus"
+	"github.com/hantdev/mitras/modbus/mocks"
+	"github.com/hantdev/mitras/modbus/simulator"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/mitras/pkg/messaging"
+	msgmocks "github.com/hantdev/mitras/pkg/messaging/mocks"
+	"github.com/hantdev/senml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	interval = modbus.MinInterval
+	timeout  = 2 * time.Second
+)
+
+var (
+	clientID = testsutil.GenerateUUID(&testing.T{})
+	chanID   = testsutil.GenerateUUID(&testing.T{})
+	session  = smqauthn.Session{UserID: testsutil.GenerateUUID(&testing.T{}), DomainID: testsutil.GenerateUUID(&testing.T{})}
+
+	registers = []modbus.Register{
+		{Name: "temperature", Type: modbus.HoldingRegister, Address: 0, DataType: modbus.Int16, Scale: 0.1, Unit: "Cel"},
+		{Name: "energy", Type: modbus.InputRegister, Address: 10, DataType: modbus.Uint32},
+		{Name: "pressure", Type: modbus.InputRegister, Address: 20, DataType: modbus.Float32, Offset: 1},
+		{Name: "setpoint", Type: modbus.HoldingRegister, Address: 30, DataType: modbus.Int32},
+		{Name: "relay", Type: modbus.Coil, Address: 0, DataType: modbus.Bool},
+		{Name: "door", Type: modbus.DiscreteInput, Address: 0, DataType: modbus.Bool},
+	}
+)
+
+func newService(t *testing.T) (modbus.Service, *simulator.Simulator, *mocks.Repository, *msgmocks.PubSub, chan *messaging.Message) {
+	sim := simulator.New()
+	require.Nil(t, sim.Listen("127.0.0.1:0"), "failed to start simulator")
+	t.Cleanup(func() { sim.Close() })
+
+	repo := new(mocks.Repository)
+	channels := new(chmocks.ChannelsServiceClient)
+	pubsub := new(msgmocks.PubSub)
+	msgs := make(chan *messaging.Message, 100)
+	channels.On("Authorize", mock.Anything, mock.Anything).Return(&grpcChannelsV1.AuthzRes{Authorized: true}, nil)
+	pubsub.On("Publish", mock.Anything, chanID, mock.Anything).Run(func(args mock.Arguments) {
+		msgs <- args.Get(2).(*messaging.Message)
+	}).Return(nil)
+
+	svc := modbus.NewService(repo, channels, pubsub, modbus.NewDialer(time.Second), mglog.NewMock())
+
+	return svc, sim, repo, pubsub, msgs
+}
+
+func device(sim *simulator.Simulator) modbus.Device {
+	return modbus.Device{
+		ClientID:  clientID,
+		ChannelID: chanID,
+		Address:   sim.Addr(),
+		UnitID:    1,
+		Interval:  interval,
+		Registers: registers,
+	}
+}
+
+func create(t *testing.T, svc modbus.Service, repo *mocks.Repository, dev modbus.Device) {
+	repoCall := repo.On("Save", mock.Anything, mock.Anything).Return(dev, nil)
+	defer repoCall.Unset()
+
+	_, err := svc.CreateDevice(context.Background(), session, dev)
+	require.Nil(t, err, fmt.Sprintf("create device unexpected error: %s", err))
+	t.Cleanup(func() {
+		repoCall := repo.On("Remove", mock.Anything, dev.ClientID).Return(nil)
+		defer repoCall.Unset()
+		svc.RemoveDevice(context.Background(), session, dev.ClientID)
+	})
+}
+
+func receive(t *testing.T, msgs chan *messaging.Message) senml.Pack {
+	select {
+	case msg := <-msgs:
+		assert.Equal(t, "modbus", msg.GetProtocol())
+		assert.Equal(t, clientID, msg.GetPublisher())
+		pack, err := senml.Decode(msg.GetPayload(), senml.JSON)
+		require.Nil(t, err, fmt.Sprintf("decode message unexpected error: %s", err))
+		return pack
+	case <-time.After(timeout):
+		require.Fail(t, "readings not published")
+		return senml.Pack{}
+	}
+}
+
+// await waits for the readings with the expected values, skipping the
+// readings published before the change.
+func await(t *testing.T, msgs chan *messaging.Message, expected map[string]any) {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case msg := <-msgs:
+			pack, err := senml.Decode(msg.GetPayload(), senml.JSON)
+			require.Nil(t, err, fmt.Sprintf("decode message unexpected error: %s", err))
+			if assert.ObjectsAreEqual(expected, values(pack)) {
+				return
+			}
+		case <-deadline:
+			require.Fail(t, fmt.Sprintf("readings %v not published", expected))
+		}
+	}
+}
+
+func values(pack senml.Pack) map[string]any {
+	ret := make(map[string]any)
+	for _, rec := range pack.Records {
+		switch {
+		case rec.Value != nil:
+			ret[rec.Name] = math.Round(*rec.Value*1000) / 1000
+		case rec.BoolValue != nil:
+			ret[rec.Name] = *rec.BoolValue
+		}
+	}
+
+	return ret
+}
+
+func TestCreateDevice(t *testing.T) {
+	svc, sim, repo, _, _ := newService(t)
+
+	cases := []struct {
+		desc    string
+		dev     modbus.Device
+		repoErr error
+		err     error
+	}{
+		{
+			desc: "create device with too short interval",
+			dev:  modbus.Device{ClientID: clientID, Address: sim.Addr(), Interval: time.Millisecond},
+			err:  svcerr.ErrMalformedEntity,
+		},
+		{
+			desc: "create device with coil of numeric type",
+			dev: modbus.Device{ClientID: clientID, Address: sim.Addr(), Interval: interval, Registers: []modbus.Register{
+				{Name: "relay", Type: modbus.Coil, DataType: modbus.Uint16},
+			}},
+			err: svcerr.ErrMalformedEntity,
+		},
+		{
+			desc: "create device with register of unsupported type",
+			dev: modbus.Device{ClientID: clientID, Address: sim.Addr(), Interval: interval, Registers: []modbus.Register{
+				{Name: "value", Type: "file_record", DataType: modbus.Uint16},
+			}},
+			err: svcerr.ErrMalformedEntity,
+		},
+		{
+			desc: "create device with register exceeding the address space",
+			dev: modbus.Device{ClientID: clientID, Address: sim.Addr(), Interval: interval, Registers: []modbus.Register{
+				{Name: "value", Type: modbus.HoldingRegister, Address: math.MaxUint16, DataType: modbus.Uint32},
+			}},
+			err: svcerr.ErrMalformedEntity,
+		},
+		{
+			desc: "create device with duplicate register names",
+			dev: modbus.Device{ClientID: clientID, Address: sim.Addr(), Interval: interval, Registers: []modbus.Register{
+				{Name: "value", Type: modbus.HoldingRegister, DataType: modbus.Uint16},
+				{Name: "value", Type: modbus.InputRegister, DataType: modbus.Uint16},
+			}},
+			err: svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:    "create existing device",
+			dev:     device(sim),
+			repoErr: repoerr.ErrConflict,
+			err:     svcerr.ErrCreateEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repoCall := repo.On("Save", mock.Anything, mock.Anything).Return(tc.dev, tc.repoErr)
+			_, err := svc.CreateDevice(context.Background(), session, tc.dev)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected error %s, got %s", tc.desc, tc.err, err))
+			repoCall.Unset()
+		})
+	}
+}
+
+func TestPoll(t *testing.T) {
+	svc, sim, repo, _, msgs := newService(t)
+
+	pressure := math.Float32bits(2.5)
+	sim.SetHoldingRegisters(0, uint16(0xFFFF-214))
+	sim.SetInputRegisters(10, 0x0001, 0x0002)
+	sim.SetInputRegisters(20, uint16(pressure>>16), uint16(pressure))
+	sim.SetHoldingRegisters(30, 0xFFFF, 0xFFFE)
+	sim.SetCoil(0, true)
+	sim.SetDiscreteInput(0, false)
+
+	create(t, svc, repo, device(sim))
+
+	pack := receive(t, msgs)
+	expected := map[string]any{
+		"temperature": -21.5,
+		"energy":      65538.0,
+		"pressure":    3.5,
+		"setpoint":    -2.0,
+		"relay":       true,
+		"door":        false,
+	}
+	assert.Equal(t, expected, values(pack))
+	for _, rec := range pack.Records {
+		if rec.Name == "temperature" {
+			assert.Equal(t, "Cel", rec.Unit)
+		}
+	}
+
+	// Registers which can't be read are skipped.
+	sim2 := simulator.New()
+	require.Nil(t, sim2.Listen("127.0.0.1:0"), "failed to start simulator")
+	defer sim2.Close()
+	sim2.SetCoil(0, false)
+	dev := device(sim2)
+	repoCall := repo.On("Update", mock.Anything, mock.Anything).Return(dev, nil)
+	_, err := svc.UpdateDevice(context.Background(), session, dev)
+	require.Nil(t, err, fmt.Sprintf("update device unexpected error: %s", err))
+	repoCall.Unset()
+
+	await(t, msgs, map[string]any{"relay": false})
+}
+
+func TestReconnect(t *testing.T) {
+	svc, sim, repo, _, msgs := newService(t)
+	sim.SetHoldingRegisters(0, 100)
+	dev := device(sim)
+	dev.Registers = registers[:1]
+
+	create(t, svc, repo, dev)
+	receive(t, msgs)
+
+	sim.Disconnect()
+	sim.SetHoldingRegisters(0, 200)
+	await(t, msgs, map[string]any{"temperature": 20.0})
+}
+
+func TestRemoveDevice(t *testing.T) {
+	svc, sim, repo, _, msgs := newService(t)
+	sim.SetCoil(0, true)
+	dev := device(sim)
+	dev.Registers = []modbus.Register{registers[4]}
+
+	repoCall := repo.On("Save", mock.Anything, mock.Anything).Return(dev, nil)
+	_, err := svc.CreateDevice(context.Background(), session, dev)
+	require.Nil(t, err, fmt.Sprintf("create device unexpected error: %s", err))
+	repoCall.Unset()
+	receive(t, msgs)
+
+	repoCall = repo.On("Remove", mock.Anything, clientID).Return(repoerr.ErrNotFound)
+	err = svc.RemoveDevice(context.Background(), session, clientID)
+	assert.True(t, errors.Contains(err, svcerr.ErrRemoveEntity), fmt.Sprintf("expected error %s, got %s", svcerr.ErrRemoveEntity, err))
+	repoCall.Unset()
+
+	repoCall = repo.On("Remove", mock.Anything, clientID).Return(nil)
+	err = svc.RemoveDevice(context.Background(), session, clientID)
+	assert.Nil(t, err, fmt.Sprintf("remove device unexpected error: %s", err))
+	repoCall.Unset()
+
+	// Drain the readings published before the removal.
+	time.Sleep(2 * interval)
+	for len(msgs) > 0 {
+		<-msgs
+	}
+	time.Sleep(2 * interval)
+	assert.Empty(t, msgs, "readings published after the removal")
+
+	err = svc.Write(context.Background(), chanID, clientID, senml.Pack{Records: []senml.Record{{Name: "relay"}}})
+	assert.True(t, errors.Contains(err, svcerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", svcerr.ErrNotFound, err))
+}
+
+func TestWrite(t *testing.T) {
+	svc, sim, repo, _, _ := newService(t)
+	dev := device(sim)
+	dev.Interval = time.Hour
+	dev.Registers = append(dev.Registers, modbus.Register{Name: "limit", Type: modbus.HoldingRegister, Address: 40, DataType: modbus.Uint16})
+	create(t, svc, repo, dev)
+
+	temperature := 22.5
+	setpoint := -70000.0
+	limit := 70000.0
+	fraction := 0.5
+	on := true
+
+	cases := []struct {
+		desc      string
+		channelID string
+		clientID  string
+		records   []senml.Record
+		check     func() bool
+		err       error
+	}{
+		{
+			desc:      "write scaled register",
+			channelID: chanID,
+			clientID:  clientID,
+			records:   []senml.Record{{Name: "temperature", Value: &temperature}},
+			check: func() bool {
+				v, _ := sim.HoldingRegister(0)
+				return v == 225
+			},
+		},
+		{
+			desc:      "write 32-bit register",
+			channelID: chanID,
+			clientID:  clientID,
+			records:   []senml.Record{{Name: "setpoint", Value: &setpoint}},
+			check: func() bool {
+				hi, _ := sim.HoldingRegister(30)
+				lo, _ := sim.HoldingRegister(31)
+				return int32(uint32(hi)<<16|uint32(lo)) == -70000
+			},
+		},
+		{
+			desc:      "write coil",
+			channelID: chanID,
+			clientID:  clientID,
+			records:   []senml.Record{{Name: "relay", BoolValue: &on}},
+			check: func() bool {
+				v, _ := sim.Coil(0)
+				return v
+			},
+		},
+		{
+			desc:      "write coil using numeric value",
+			channelID: chanID,
+			clientID:  clientID,
+			records:   []senml.Record{{Name: "relay", Value: &fraction}},
+			check: func() bool {
+				v, _ := sim.Coil(0)
+				return v
+			},
+		},
+		{
+			desc:      "write value out of range",
+			channelID: chanID,
+			clientID:  clientID,
+			records:   []senml.Record{{Name: "limit", Value: &limit}},
+			err:       svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:      "write register without value",
+			channelID: chanID,
+			clientID:  clientID,
+			records:   []senml.Record{{Name: "temperature", BoolValue: &on}},
+			err:       svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:      "write input register",
+			channelID: chanID,
+			clientID:  clientID,
+			records:   []senml.Record{{Name: "energy", Value: &limit}},
+			err:       modbus.ErrReadOnly,
+		},
+		{
+			desc:      "write unknown register",
+			channelID: chanID,
+			clientID:  clientID,
+			records:   []senml.Record{{Name: "unknown", Value: &limit}},
+			err:       modbus.ErrUnknownRegister,
+		},
+		{
+			desc:      "write from another channel",
+			channelID: testsutil.GenerateUUID(t),
+			clientID:  clientID,
+			records:   []senml.Record{{Name: "temperature", Value: &temperature}},
+			err:       svcerr.ErrAuthorization,
+		},
+		{
+			desc:      "write to unknown device",
+			channelID: chanID,
+			clientID:  testsutil.GenerateUUID(t),
+			records:   []senml.Record{{Name: "temperature", Value: &temperature}},
+			err:       svcerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := svc.Write(context.Background(), tc.channelID, tc.clientID, senml.Pack{Records: tc.records})
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected error %s, got %s", tc.desc, tc.err, err))
+			if tc.check != nil {
+				assert.True(t, tc.check(), fmt.Sprintf("%s: register not written", tc.desc))
+			}
+		})
+	}
+
+	// The connection closed by the device is dropped, and the device is
+	// connected again on the next write.
+	sim.Close()
+	sim2 := simulator.New()
+	require.Nil(t, sim2.Listen(sim.Addr()), "failed to restart simulator")
+	defer sim2.Close()
+	err := svc.Write(context.Background(), chanID, clientID, senml.Pack{Records: []senml.Record{{Name: "temperature", Value: &temperature}}})
+	assert.NotNil(t, err, "expected error writing to the closed connection")
+	err = svc.Write(context.Background(), chanID, clientID, senml.Pack{Records: []senml.Record{{Name: "temperature", Value: &temperature}}})
+	assert.Nil(t, err, fmt.Sprintf("write after reconnect unexpected error: %s", err))
+}
@@ -0,0 +1,281 @@
+// Package simulator provides the in-process Modbus TCP slave, which is used
+// to test the Modbus gateway without the devices.
+package simulator
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/hantdev/mitras/pkg/errors"
+)
+
+const (
+	mbapSize      = 7
+	exceptionFlag = 0x80
+	coilOn        = 0xFF00
+)
+
+// Modbus exception codes.
+const (
+	illegalFunction byte = 0x01
+	illegalAddress  byte = 0x02
+	illegalValue    byte = 0x03
+)
+
+// Simulator is the Modbus TCP slave which stores the data model in memory.
+// Reading the address which isn't set results with the illegal data address
+// exception, and writing the holding register or the coil sets it.
+type Simulator struct {
+	mu        sync.Mutex
+	ln        net.Listener
+	closed    bool
+	conns     map[net.Conn]struct{}
+	coils     map[uint16]bool
+	discretes map[uint16]bool
+	holdings  map[uint16]uint16
+	inputs    map[uint16]uint16
+	wg        sync.WaitGroup
+}
+
+// New returns the simulator with the empty data model.
+func New() *Simulator {
+	return &Simulator{
+		conns:     make(map[net.Conn]struct{}),
+		coils:     make(map[uint16]bool),
+		discretes: make(map[uint16]bool),
+		holdings:  make(map[uint16]uint16),
+		inputs:    make(map[uint16]uint16),
+	}
+}
+
+// Listen starts serving the requests on the TCP address. The port 0 picks
+// an available port, which is returned by Addr.
+func (s *Simulator) Listen(address string) error {
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	s.ln = ln
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			if s.closed {
+				s.mu.Unlock()
+				conn.Close()
+				return
+			}
+			s.conns[conn] = struct{}{}
+			s.mu.Unlock()
+
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serve(conn)
+			}()
+		}
+	}()
+
+	return nil
+}
+
+// Addr returns the address the simulator listens on.
+func (s *Simulator) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Disconnect closes the connections of the clients.
+func (s *Simulator) Disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// Close stops the simulator and closes the connections of the clients.
+func (s *Simulator) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	err := s.ln.Close()
+	s.Disconnect()
+	s.wg.Wait()
+
+	return err
+}
+
+// SetCoil sets the coil.
+func (s *Simulator) SetCoil(address uint16, value bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.coils[address] = value
+}
+
+// Coil returns the coil and whether it's set.
+func (s *Simulator) Coil(address uint16) (bool, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.coils[address]
+	return v, ok
+}
+
+// SetDiscreteInput sets the discrete input.
+func (s *Simulator) SetDiscreteInput(address uint16, value bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.discretes[address] = value
+}
+
+// SetHoldingRegisters sets the consecutive holding registers starting from the address.
+func (s *Simulator) SetHoldingRegisters(address uint16, values ...uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, v := range values {
+		s.holdings[address+uint16(i)] = v
+	}
+}
+
+// HoldingRegister returns the holding register and whether it's set.
+func (s *Simulator) HoldingRegister(address uint16) (uint16, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.holdings[address]
+	return v, ok
+}
+
+// SetInputRegisters sets the consecutive input registers starting from the address.
+func (s *Simulator) SetInputRegisters(address uint16, values ...uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, v := range values {
+		s.inputs[address+uint16(i)] = v
+	}
+}
+
+func (s *Simulator) serve(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	header := make([]byte, mbapSize)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		length := int(binary.BigEndian.Uint16(header[4:]))
+		if length < 2 {
+			return
+		}
+		pdu := make([]byte, length-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			return
+		}
+
+		res := s.handle(pdu)
+		frame := make([]byte, mbapSize, mbapSize+len(res))
+		copy(frame, header[:4])
+		binary.BigEndian.PutUint16(frame[4:], uint16(1+len(res)))
+		frame[6] = header[6]
+		if _, err := conn.Write(append(frame, res...)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Simulator) handle(pdu []byte) []byte {
+	fc := pdu[0]
+	data := pdu[1:]
+	if len(data) < 4 {
+		return exception(fc, illegalValue)
+	}
+	address := binary.BigEndian.Uint16(data)
+	value := binary.BigEndian.Uint16(data[2:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res []byte
+	var err error
+	switch fc {
+	case 0x01:
+		res, err = readBits(s.coils, address, value)
+	case 0x02:
+		res, err = readBits(s.discretes, address, value)
+	case 0x03:
+		res, err = readRegisters(s.holdings, address, value)
+	case 0x04:
+		res, err = readRegisters(s.inputs, address, value)
+	case 0x05:
+		if value != coilOn && value != 0 {
+			return exception(fc, illegalValue)
+		}
+		s.coils[address] = value == coilOn
+		res = data[:4]
+	case 0x06:
+		s.holdings[address] = value
+		res = data[:4]
+	case 0x10:
+		if len(data) != 5+2*int(value) || int(data[4]) != 2*int(value) {
+			return exception(fc, illegalValue)
+		}
+		for i := 0; i < int(value); i++ {
+			s.holdings[address+uint16(i)] = binary.BigEndian.Uint16(data[5+2*i:])
+		}
+		res = data[:4]
+	default:
+		return exception(fc, illegalFunction)
+	}
+	if err != nil {
+		return exception(fc, illegalAddress)
+	}
+
+	return append([]byte{fc}, res...)
+}
+
+var errAddress = errors.New("address not set")
+
+func readBits(table map[uint16]bool, address, quantity uint16) ([]byte, error) {
+	res := make([]byte, 1+(quantity+7)/8)
+	res[0] = byte(len(res) - 1)
+	for i := uint16(0); i < quantity; i++ {
+		v, ok := table[address+i]
+		if !ok {
+			return nil, errAddress
+		}
+		if v {
+			res[1+i/8] |= 1 << (i % 8)
+		}
+	}
+
+	return res, nil
+}
+
+func readRegisters(table map[uint16]uint16, address, quantity uint16) ([]byte, error) {
+	res := []byte{byte(2 * quantity)}
+	for i := uint16(0); i < quantity; i++ {
+		v, ok := table[address+i]
+		if !ok {
+			return nil, errAddress
+		}
+		res = binary.BigEndian.AppendUint16(res, v)
+	}
+
+	return res, nil
+}
+
+func exception(fc, code byte) []byte {
+	return []byte{fc | exceptionFlag, code}
+}
@@ -0,0 +1,278 @@
+package modbus
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hantdev/mitras/pkg/errors"
+)
+
+// Modbus function codes.
+const (
+	fcReadCoils              byte = 0x01
+	fcReadDiscreteInputs     byte = 0x02
+	fcReadHoldingRegisters   byte = 0x03
+	fcReadInputRegisters     byte = 0x04
+	fcWriteSingleCoil        byte = 0x05
+	fcWriteSingleRegister    byte = 0x06
+	fcWriteMultipleRegisters byte = 0x10
+)
+
+const (
+	// mbapSize is the size of the Modbus application protocol header.
+	mbapSize = 7
+
+	// maxPDUSize is the maximal size of the protocol data unit.
+	maxPDUSize = 253
+
+	// maxRegisters is the maximal quantity of registers read by a single request.
+	maxRegisters = 125
+
+	// maxCoils is the maximal quantity of coils read by a single request.
+	maxCoils = 2000
+
+	exceptionFlag byte = 0x80
+	coilOn             = 0xFF00
+)
+
+var (
+	// ErrException indicates the exception response of the device.
+	ErrException = errors.New("device responded with exception")
+
+	// ErrIllegalFunction indicates the function not supported by the device.
+	ErrIllegalFunction = errors.New("illegal function")
+
+	// ErrIllegalAddress indicates the address not available on the device.
+	ErrIllegalAddress = errors.New("illegal data address")
+
+	// ErrIllegalValue indicates the value not accepted by the device.
+	ErrIllegalValue = errors.New("illegal data value")
+
+	// ErrDeviceFailure indicates the unrecoverable error of the device.
+	ErrDeviceFailure = errors.New("server device failure")
+
+	// ErrMalformedResponse indicates the response which doesn't match the request.
+	ErrMalformedResponse = errors.New("malformed response")
+
+	exceptions = map[byte]error{
+		0x01: ErrIllegalFunction,
+		0x02: ErrIllegalAddress,
+		0x03: ErrIllegalValue,
+		0x04: ErrDeviceFailure,
+	}
+)
+
+var _ Client = (*tcpClient)(nil)
+
+type tcpClient struct {
+	conn    net.Conn
+	unitID  uint8
+	timeout time.Duration
+	mu      sync.Mutex
+	tid     uint16
+}
+
+// NewClient returns the Modbus TCP client of the unit connected over the
+// connection. Requests which aren't answered within the timeout fail.
+func NewClient(conn net.Conn, unitID uint8, timeout time.Duration) Client {
+	return &tcpClient{
+		conn:    conn,
+		unitID:  unitID,
+		timeout: timeout,
+	}
+}
+
+// NewDialer returns the dialer of the Modbus TCP devices. The timeout
+// limits both connecting to the device and the requests.
+func NewDialer(timeout time.Duration) Dialer {
+	return func(ctx context.Context, address string, unitID uint8) (Client, error) {
+		d := net.Dialer{Timeout: timeout}
+		conn, err := d.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewClient(conn, unitID, timeout), nil
+	}
+}
+
+func (c *tcpClient) ReadCoils(ctx context.Context, address, quantity uint16) ([]bool, error) {
+	return c.readBits(ctx, fcReadCoils, address, quantity)
+}
+
+func (c *tcpClient) ReadDiscreteInputs(ctx context.Context, address, quantity uint16) ([]bool, error) {
+	return c.readBits(ctx, fcReadDiscreteInputs, address, quantity)
+}
+
+func (c *tcpClient) ReadHoldingRegisters(ctx context.Context, address, quantity uint16) ([]uint16, error) {
+	return c.readRegisters(ctx, fcReadHoldingRegisters, address, quantity)
+}
+
+func (c *tcpClient) ReadInputRegisters(ctx context.Context, address, quantity uint16) ([]uint16, error) {
+	return c.readRegisters(ctx, fcReadInputRegisters, address, quantity)
+}
+
+func (c *tcpClient) WriteSingleCoil(ctx context.Context, address uint16, value bool) error {
+	var v uint16
+	if value {
+		v = coilOn
+	}
+	req := binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(nil, address), v)
+	res, err := c.send(ctx, fcWriteSingleCoil, req)
+	if err != nil {
+		return err
+	}
+
+	return echo(req, res)
+}
+
+func (c *tcpClient) WriteSingleRegister(ctx context.Context, address, value uint16) error {
+	req := binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(nil, address), value)
+	res, err := c.send(ctx, fcWriteSingleRegister, req)
+	if err != nil {
+		return err
+	}
+
+	return echo(req, res)
+}
+
+func (c *tcpClient) WriteMultipleRegisters(ctx context.Context, address uint16, values []uint16) error {
+	if len(values) == 0 || len(values) > maxRegisters {
+		return ErrInvalidValue
+	}
+	req := binary.BigEndian.AppendUint16(nil, address)
+	req = binary.BigEndian.AppendUint16(req, uint16(len(values)))
+	req = append(req, byte(2*len(values)))
+	for _, v := range values {
+		req = binary.BigEndian.AppendUint16(req, v)
+	}
+	res, err := c.send(ctx, fcWriteMultipleRegisters, req)
+	if err != nil {
+		return err
+	}
+
+	return echo(req[:4], res)
+}
+
+func (c *tcpClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *tcpClient) readBits(ctx context.Context, fc byte, address, quantity uint16) ([]bool, error) {
+	if quantity == 0 || quantity > maxCoils {
+		return nil, ErrInvalidValue
+	}
+	res, err := c.send(ctx, fc, readReq(address, quantity))
+	if err != nil {
+		return nil, err
+	}
+	n := (int(quantity) + 7) / 8
+	if len(res) != n+1 || int(res[0]) != n {
+		return nil, ErrMalformedResponse
+	}
+
+	bits := make([]bool, quantity)
+	for i := range bits {
+		bits[i] = res[1+i/8]&(1<<(i%8)) != 0
+	}
+
+	return bits, nil
+}
+
+func (c *tcpClient) readRegisters(ctx context.Context, fc byte, address, quantity uint16) ([]uint16, error) {
+	if quantity == 0 || quantity > maxRegisters {
+		return nil, ErrInvalidValue
+	}
+	res, err := c.send(ctx, fc, readReq(address, quantity))
+	if err != nil {
+		return nil, err
+	}
+	n := 2 * int(quantity)
+	if len(res) != n+1 || int(res[0]) != n {
+		return nil, ErrMalformedResponse
+	}
+
+	regs := make([]uint16, quantity)
+	for i := range regs {
+		regs[i] = binary.BigEndian.Uint16(res[1+2*i:])
+	}
+
+	return regs, nil
+}
+
+// send sends the request of the function and returns the data of the
+// response. Requests are sent one at a time, since most devices don't
+// process the concurrent transactions.
+func (c *tcpClient) send(ctx context.Context, fc byte, data []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	c.tid++
+	frame := make([]byte, mbapSize, mbapSize+1+len(data))
+	binary.BigEndian.PutUint16(frame[0:], c.tid)
+	binary.BigEndian.PutUint16(frame[4:], uint16(2+len(data)))
+	frame[6] = c.unitID
+	frame = append(frame, fc)
+	frame = append(frame, data...)
+	if _, err := c.conn.Write(frame); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, mbapSize)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		return nil, err
+	}
+	length := int(binary.BigEndian.Uint16(header[4:]))
+	if length < 2 || length > maxPDUSize+1 {
+		return nil, ErrMalformedResponse
+	}
+	pdu := make([]byte, length-1)
+	if _, err := io.ReadFull(c.conn, pdu); err != nil {
+		return nil, err
+	}
+	if binary.BigEndian.Uint16(header[0:]) != c.tid || binary.BigEndian.Uint16(header[2:]) != 0 || header[6] != c.unitID {
+		return nil, ErrMalformedResponse
+	}
+
+	switch pdu[0] {
+	case fc:
+		return pdu[1:], nil
+	case fc | exceptionFlag:
+		if len(pdu) != 2 {
+			return nil, ErrMalformedResponse
+		}
+		if err, ok := exceptions[pdu[1]]; ok {
+			return nil, errors.Wrap(ErrException, err)
+		}
+		return nil, errors.Wrap(ErrException, fmt.Errorf("exception code %d", pdu[1]))
+	default:
+		return nil, ErrMalformedResponse
+	}
+}
+
+func readReq(address, quantity uint16) []byte {
+	return binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(nil, address), quantity)
+}
+
+// echo checks that the response of the write function echoes the request.
+func echo(req, res []byte) error {
+	if !bytes.Equal(req, res) {
+		return ErrMalformedResponse
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package modbus_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/modbus"
+	"github.com/hantdev/mitras/modbus/simulator"
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	sim := simulator.New()
+	require.Nil(t, sim.Listen("127.0.0.1:0"), "failed to start simulator")
+	defer sim.Close()
+
+	for i := uint16(0); i < 10; i++ {
+		sim.SetCoil(i, i%3 == 0)
+	}
+	sim.SetInputRegisters(100, 1, 2, 3)
+
+	c, err := modbus.NewDialer(time.Second)(context.Background(), sim.Addr(), 1)
+	require.Nil(t, err, fmt.Sprintf("dial unexpected error: %s", err))
+	defer c.Close()
+	ctx := context.Background()
+
+	coils, err := c.ReadCoils(ctx, 0, 10)
+	assert.Nil(t, err, fmt.Sprintf("read coils unexpected error: %s", err))
+	assert.Equal(t, []bool{true, false, false, true, false, false, true, false, false, true}, coils)
+
+	regs, err := c.ReadInputRegisters(ctx, 100, 3)
+	assert.Nil(t, err, fmt.Sprintf("read input registers unexpected error: %s", err))
+	assert.Equal(t, []uint16{1, 2, 3}, regs)
+
+	err = c.WriteMultipleRegisters(ctx, 200, []uint16{4, 5})
+	assert.Nil(t, err, fmt.Sprintf("write registers unexpected error: %s", err))
+	regs, err = c.ReadHoldingRegisters(ctx, 200, 2)
+	assert.Nil(t, err, fmt.Sprintf("read holding registers unexpected error: %s", err))
+	assert.Equal(t, []uint16{4, 5}, regs)
+
+	_, err = c.ReadHoldingRegisters(ctx, 300, 1)
+	assert.True(t, errors.Contains(err, modbus.ErrException), fmt.Sprintf("expected error %s, got %s", modbus.ErrException, err))
+	assert.True(t, errors.Contains(err, modbus.ErrIllegalAddress), fmt.Sprintf("expected error %s, got %s", modbus.ErrIllegalAddress, err))
+
+	_, err = c.ReadInputRegisters(ctx, 0, 126)
+	assert.True(t, errors.Contains(err, modbus.ErrInvalidValue), fmt.Sprintf("expected error %s, got %s", modbus.ErrInvalidValue, err))
+
+	// The client is usable after the exception response.
+	err = c.WriteSingleCoil(ctx, 1, true)
+	assert.Nil(t, err, fmt.Sprintf("write coil unexpected error: %s", err))
+	on, _ := sim.Coil(1)
+	assert.True(t, on, "coil not written")
+}
@@ -2,18 +2,105 @@ package events
 
 import "github.com/hantdev/mitras/pkg/events"
 
-var _ events.Event = (*mqttEvent)(nil)
+const (
+	mqttPrefix      = "mqtt."
+	mqttConnect     = mqttPrefix + "connect"
+	mqttDisconnect  = mqttPrefix + "disconnect"
+	mqttWill        = mqttPrefix + "will"
+	mqttSubscribe   = mqttPrefix + "subscribe"
+	mqttUnsubscribe = mqttPrefix + "unsubscribe"
+)
+
+var (
+	_ events.Event = (*connectEvent)(nil)
+	_ events.Event = (*disconnectEvent)(nil)
+	_ events.Event = (*willEvent)(nil)
+	_ events.Event = (*subscribeEvent)(nil)
+)
 
 type mqttEvent struct {
 	clientID  string
+	sessionID string
 	operation string
 	instance  string
 }
 
 func (me mqttEvent) Encode() (map[string]interface{}, error) {
-	return map[string]interface{}{
+	val := map[string]interface{}{
 		"client_id": me.clientID,
 		"operation": me.operation,
 		"instance":  me.instance,
-	}, nil
+	}
+	if me.sessionID != "" {
+		val["session_id"] = me.sessionID
+	}
+
+	return val, nil
+}
+
+type connectEvent struct {
+	mqttEvent
+	Connection
+}
+
+func (ce connectEvent) Encode() (map[string]interface{}, error) {
+	val, err := ce.mqttEvent.Encode()
+	if err != nil {
+		return nil, err
+	}
+	if ce.ProtocolVersion != 0 {
+		val["protocol_version"] = ce.ProtocolVersion
+	}
+	val["keepalive"] = ce.KeepAlive
+
+	return val, nil
+}
+
+type disconnectEvent struct {
+	mqttEvent
+	reason string
+}
+
+func (de disconnectEvent) Encode() (map[string]interface{}, error) {
+	val, err := de.mqttEvent.Encode()
+	if err != nil {
+		return nil, err
+	}
+	val["reason"] = de.reason
+
+	return val, nil
+}
+
+type willEvent struct {
+	mqttEvent
+	topic  string
+	qos    uint8
+	retain bool
+}
+
+func (we willEvent) Encode() (map[string]interface{}, error) {
+	val, err := we.mqttEvent.Encode()
+	if err != nil {
+		return nil, err
+	}
+	val["topic"] = we.topic
+	val["qos"] = we.qos
+	val["retain"] = we.retain
+
+	return val, nil
+}
+
+type subscribeEvent struct {
+	mqttEvent
+	topics []string
+}
+
+func (se subscribeEvent) Encode() (map[string]interface{}, error) {
+	val, err := se.mqttEvent.Encode()
+	if err != nil {
+		return nil, err
+	}
+	val["topics"] = se.topics
+
+	return val, nil
 }
@@ -9,10 +9,36 @@ import (
 
 const streamID = "mitras.mqtt"
 
+// Connection is the information of the MQTT CONNECT packet.
+type Connection struct {
+	// ClientID is the ID of the authenticated client.
+	ClientID string
+	// SessionID is the MQTT client identifier of the session.
+	SessionID       string
+	ProtocolVersion uint8
+	KeepAlive       uint16
+}
+
+// EventStore publishes the session lifecycle events of the MQTT clients. Events
+// carry the ID of the authenticated client, so they can be queried per client.
+//
 //go:generate mockery --name EventStore --output=../mocks --filename events.go --quiet
 type EventStore interface {
-	Connect(ctx context.Context, clientID string) error
-	Disconnect(ctx context.Context, clientID string) error
+	// Connect issues event on MQTT CONNECT.
+	Connect(ctx context.Context, conn Connection) error
+
+	// Disconnect issues event when the MQTT session ends with the given reason.
+	Disconnect(ctx context.Context, clientID, sessionID, reason string) error
+
+	// Will issues event when the Last Will and Testament of the session is
+	// published by the broker.
+	Will(ctx context.Context, clientID, sessionID, topic string, qos uint8, retain bool) error
+
+	// Subscribe issues event on MQTT SUBSCRIBE.
+	Subscribe(ctx context.Context, clientID, sessionID string, topics []string) error
+
+	// Unsubscribe issues event on MQTT UNSUBSCRIBE.
+	Unsubscribe(ctx context.Context, clientID, sessionID string, topics []string) error
 }
 
 // EventStore is a struct used to store event streams in Redis.
@@ -35,24 +61,58 @@ func NewEventStore(ctx context.Context, url, instance string) (EventStore, error
 	}, nil
 }
 
-// Connect issues event on MQTT CONNECT.
-func (es *eventStore) Connect(ctx context.Context, clientID string) error {
-	ev := mqttEvent{
-		clientID:  clientID,
-		operation: "connect",
-		instance:  es.instance,
+func (es *eventStore) Connect(ctx context.Context, conn Connection) error {
+	ev := connectEvent{
+		mqttEvent:  es.event(mqttConnect, conn.ClientID, conn.SessionID),
+		Connection: conn,
 	}
 
 	return es.Publish(ctx, ev)
 }
 
-// Disconnect issues event on MQTT CONNECT.
-func (es *eventStore) Disconnect(ctx context.Context, clientID string) error {
-	ev := mqttEvent{
-		clientID:  clientID,
-		operation: "disconnect",
-		instance:  es.instance,
+func (es *eventStore) Disconnect(ctx context.Context, clientID, sessionID, reason string) error {
+	ev := disconnectEvent{
+		mqttEvent: es.event(mqttDisconnect, clientID, sessionID),
+		reason:    reason,
+	}
+
+	return es.Publish(ctx, ev)
+}
+
+func (es *eventStore) Will(ctx context.Context, clientID, sessionID, topic string, qos uint8, retain bool) error {
+	ev := willEvent{
+		mqttEvent: es.event(mqttWill, clientID, sessionID),
+		topic:     topic,
+		qos:       qos,
+		retain:    retain,
 	}
 
 	return es.Publish(ctx, ev)
 }
+
+func (es *eventStore) Subscribe(ctx context.Context, clientID, sessionID string, topics []string) error {
+	ev := subscribeEvent{
+		mqttEvent: es.event(mqttSubscribe, clientID, sessionID),
+		topics:    topics,
+	}
+
+	return es.Publish(ctx, ev)
+}
+
+func (es *eventStore) Unsubscribe(ctx context.Context, clientID, sessionID string, topics []string) error {
+	ev := subscribeEvent{
+		mqttEvent: es.event(mqttUnsubscribe, clientID, sessionID),
+		topics:    topics,
+	}
+
+	return es.Publish(ctx, ev)
+}
+
+func (es *eventStore) event(operation, clientID, sessionID string) mqttEvent {
+	return mqttEvent{
+		clientID:  clientID,
+		sessionID: sessionID,
+		operation: operation,
+		instance:  es.instance,
+	}
+}
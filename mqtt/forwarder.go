@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/hantdev/mitras/pkg/messaging"
 )
@@ -44,6 +45,10 @@ func handle(ctx context.Context, pub messaging.Publisher, logger *slog.Logger) h
 		if msg.GetProtocol() == protocol {
 			return nil
 		}
+		// Messages are dropped once their expiry interval has elapsed.
+		if expired(msg) {
+			return nil
+		}
 		// Use concatenation instead of fmt.Sprintf for the
 		// sake of simplicity and performance.
 		topic := "channels/" + msg.GetChannel() + "/messages"
@@ -61,6 +66,15 @@ func handle(ctx context.Context, pub messaging.Publisher, logger *slog.Logger) h
 	}
 }
 
+func expired(msg *messaging.Message) bool {
+	if msg.GetExpiry() == 0 {
+		return false
+	}
+	expiry := time.Unix(0, msg.GetCreated()).Add(time.Duration(msg.GetExpiry()) * time.Second)
+
+	return time.Now().After(expiry)
+}
+
 type handleFunc func(msg *messaging.Message) error
 
 func (h handleFunc) Handle(msg *messaging.Message) error {
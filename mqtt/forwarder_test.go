@@ -0,0 +1,73 @@
+package mqtt_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	smqlog "github.com/hantdev/mitras/logger"
+	"github.com/hantdev/mitras/mqtt"
+	"github.com/hantdev/mitras/pkg/messaging"
+	msgmocks "github.com/hantdev/mitras/pkg/messaging/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForward(t *testing.T) {
+	sub := new(msgmocks.PubSub)
+	pub := new(msgmocks.PubSub)
+	fwd := mqtt.NewForwarder("channels.>", smqlog.NewMock())
+
+	var handler messaging.MessageHandler
+	sub.On("Subscribe", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		handler = args.Get(1).(messaging.SubscriberConfig).Handler
+	}).Return(nil)
+	forwarded := make(chan string, 1)
+	pub.On("Publish", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		forwarded <- args.String(1)
+	}).Return(nil)
+
+	err := fwd.Forward(context.Background(), "mqtt", sub, pub)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+
+	now := time.Now()
+	cases := []struct {
+		desc  string
+		msg   *messaging.Message
+		topic string
+	}{
+		{
+			desc:  "forward message",
+			msg:   &messaging.Message{Channel: chanID, Subtopic: "sub.topic", Protocol: "http", Created: now.UnixNano()},
+			topic: fmt.Sprintf("channels/%s/messages/sub/topic", chanID),
+		},
+		{
+			desc:  "forward message before expiry",
+			msg:   &messaging.Message{Channel: chanID, Protocol: "http", Created: now.UnixNano(), Expiry: 60},
+			topic: fmt.Sprintf("channels/%s/messages", chanID),
+		},
+		{
+			desc: "forward expired message",
+			msg:  &messaging.Message{Channel: chanID, Protocol: "http", Created: now.Add(-time.Minute).UnixNano(), Expiry: 30},
+		},
+		{
+			desc: "forward message published over MQTT",
+			msg:  &messaging.Message{Channel: chanID, Protocol: "mqtt", Created: now.UnixNano()},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := handler.Handle(tc.msg)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			select {
+			case topic := <-forwarded:
+				assert.Equal(t, tc.topic, topic, fmt.Sprintf("%s: got unexpected topic", tc.desc))
+			case <-time.After(100 * time.Millisecond):
+				assert.Empty(t, tc.topic, fmt.Sprintf("%s: expected message to be forwarded", tc.desc))
+			}
+		})
+	}
+}
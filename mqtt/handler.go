@@ -7,8 +7,10 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/eclipse/paho.mqtt.golang/packets"
 	"github.com/hantdev/hermina/pkg/session"
 	grpcChannelsV1 "github.com/hantdev/mitras/internal/grpc/channels/v1"
 	grpcClientsV1 "github.com/hantdev/mitras/internal/grpc/clients/v1"
@@ -20,9 +22,12 @@ import (
 	"github.com/hantdev/mitras/pkg/policies"
 )
 
-var _ session.Handler = (*handler)(nil)
+var _ Handler = (*handler)(nil)
 
-const protocol = "mqtt"
+const (
+	protocol    = "mqtt"
+	sharePrefix = "$share/"
+)
 
 // Log message formats.
 const (
@@ -35,21 +40,24 @@ const (
 
 // Error wrappers for MQTT errors.
 var (
-	ErrMalformedSubtopic            = errors.New("malformed subtopic")
-	ErrClientNotInitialized         = errors.New("client is not initialized")
-	ErrMalformedTopic               = errors.New("malformed topic")
-	ErrMissingClientID              = errors.New("client_id not found")
-	ErrMissingTopicPub              = errors.New("failed to publish due to missing topic")
-	ErrMissingTopicSub              = errors.New("failed to subscribe due to missing topic")
-	ErrFailedConnect                = errors.New("failed to connect")
-	ErrFailedSubscribe              = errors.New("failed to subscribe")
-	ErrFailedUnsubscribe            = errors.New("failed to unsubscribe")
-	ErrFailedPublish                = errors.New("failed to publish")
-	ErrFailedDisconnect             = errors.New("failed to disconnect")
-	ErrFailedPublishDisconnectEvent = errors.New("failed to publish disconnect event")
-	ErrFailedParseSubtopic          = errors.New("failed to parse subtopic")
-	ErrFailedPublishConnectEvent    = errors.New("failed to publish connect event")
-	ErrFailedPublishToMsgBroker     = errors.New("failed to publish to mitras message broker")
+	ErrMalformedSubtopic             = errors.New("malformed subtopic")
+	ErrClientNotInitialized          = errors.New("client is not initialized")
+	ErrMalformedTopic                = errors.New("malformed topic")
+	ErrMissingClientID               = errors.New("client_id not found")
+	ErrMissingTopicPub               = errors.New("failed to publish due to missing topic")
+	ErrMissingTopicSub               = errors.New("failed to subscribe due to missing topic")
+	ErrFailedConnect                 = errors.New("failed to connect")
+	ErrFailedSubscribe               = errors.New("failed to subscribe")
+	ErrFailedUnsubscribe             = errors.New("failed to unsubscribe")
+	ErrFailedPublish                 = errors.New("failed to publish")
+	ErrFailedDisconnect              = errors.New("failed to disconnect")
+	ErrFailedPublishDisconnectEvent  = errors.New("failed to publish disconnect event")
+	ErrFailedParseSubtopic           = errors.New("failed to parse subtopic")
+	ErrFailedPublishConnectEvent     = errors.New("failed to publish connect event")
+	ErrFailedPublishWillEvent        = errors.New("failed to publish will event")
+	ErrFailedPublishSubscribeEvent   = errors.New("failed to publish subscribe event")
+	ErrFailedPublishUnsubscribeEvent = errors.New("failed to publish unsubscribe event")
+	ErrFailedPublishToMsgBroker      = errors.New("failed to publish to mitras message broker")
 )
 
 var (
@@ -57,17 +65,27 @@ var (
 	channelRegExp    = regexp.MustCompile(`^\/?channels\/([\w\-]+)\/messages(\/[^?]*)?(\?.*)?$`)
 )
 
-// Event implements events.Event interface.
+// Handler handles the MQTT sessions of the proxy. It intercepts the packets
+// sent by the clients to record the CONNECT and DISCONNECT packet information
+// of the session, so the handler has to be set as the interceptor of the
+// proxy too.
+type Handler interface {
+	session.Handler
+	session.Interceptor
+}
+
 type handler struct {
 	publisher messaging.Publisher
 	clients   grpcClientsV1.ClientsServiceClient
 	channels  grpcChannelsV1.ChannelsServiceClient
 	logger    *slog.Logger
 	es        events.EventStore
+	// sessions maps the proxy sessions to their state.
+	sessions sync.Map
 }
 
 // NewHandler creates new Handler entity.
-func NewHandler(publisher messaging.Publisher, es events.EventStore, logger *slog.Logger, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient) session.Handler {
+func NewHandler(publisher messaging.Publisher, es events.EventStore, logger *slog.Logger, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient) Handler {
 	return &handler{
 		es:        es,
 		logger:    logger,
@@ -86,26 +104,23 @@ func (h *handler) AuthConnect(ctx context.Context) error {
 	}
 
 	if s.ID == "" {
-		return ErrMissingClientID
+		return NewReasonCodeError(ReasonClientIDNotValid, ErrMissingClientID)
 	}
 
 	pwd := string(s.Password)
 
 	res, err := h.clients.Authenticate(ctx, &grpcClientsV1.AuthnReq{ClientSecret: pwd})
 	if err != nil {
-		return errors.Wrap(svcerr.ErrAuthentication, err)
+		return NewReasonCodeError(ReasonBadUserNameOrPassword, errors.Wrap(svcerr.ErrAuthentication, err))
 	}
 	if !res.GetAuthenticated() {
-		return svcerr.ErrAuthentication
+		return NewReasonCodeError(ReasonBadUserNameOrPassword, svcerr.ErrAuthentication)
 	}
 
 	if s.Username != "" && res.GetId() != s.Username {
-		return errInvalidUserId
-	}
-
-	if err := h.es.Connect(ctx, pwd); err != nil {
-		h.logger.Error(errors.Wrap(ErrFailedPublishConnectEvent, err).Error())
+		return NewReasonCodeError(ReasonBadUserNameOrPassword, errInvalidUserId)
 	}
+	h.state(s).setClientID(res.GetId())
 
 	return nil
 }
@@ -116,27 +131,31 @@ func (h *handler) AuthPublish(ctx context.Context, topic *string, payload *[]byt
 	if topic == nil {
 		return ErrMissingTopicPub
 	}
-	s, ok := session.FromContext(ctx)
-	if !ok {
-		return ErrClientNotInitialized
+	clientID, err := h.clientID(ctx)
+	if err != nil {
+		return err
 	}
 
-	return h.authAccess(ctx, string(s.Username), *topic, connections.Publish)
+	return h.authAccess(ctx, clientID, *topic, connections.Publish)
 }
 
 // AuthSubscribe is called on device subscribe,
 // prior forwarding to the MQTT broker.
 func (h *handler) AuthSubscribe(ctx context.Context, topics *[]string) error {
-	s, ok := session.FromContext(ctx)
-	if !ok {
-		return ErrClientNotInitialized
+	clientID, err := h.clientID(ctx)
+	if err != nil {
+		return err
 	}
 	if topics == nil || *topics == nil {
 		return ErrMissingTopicSub
 	}
 
 	for _, topic := range *topics {
-		if err := h.authAccess(ctx, string(s.Username), topic, connections.Subscribe); err != nil {
+		topic, err := parseSharedSubscription(topic)
+		if err != nil {
+			return err
+		}
+		if err := h.authAccess(ctx, clientID, topic, connections.Subscribe); err != nil {
 			return err
 		}
 	}
@@ -151,6 +170,21 @@ func (h *handler) Connect(ctx context.Context) error {
 		return errors.Wrap(ErrFailedConnect, ErrClientNotInitialized)
 	}
 	h.logger.Info(fmt.Sprintf(LogInfoConnected, s.ID))
+
+	clientID, conn, _ := h.state(s).get()
+	if clientID == "" {
+		return errors.Wrap(ErrFailedConnect, ErrClientNotInitialized)
+	}
+	ev := events.Connection{
+		ClientID:        clientID,
+		SessionID:       s.ID,
+		ProtocolVersion: conn.ProtocolVersion,
+		KeepAlive:       conn.KeepAlive,
+	}
+	if err := h.es.Connect(ctx, ev); err != nil {
+		h.logger.Error(errors.Wrap(ErrFailedPublishConnectEvent, err).Error())
+	}
+
 	return nil
 }
 
@@ -161,6 +195,10 @@ func (h *handler) Publish(ctx context.Context, topic *string, payload *[]byte) e
 		return errors.Wrap(ErrFailedPublish, ErrClientNotInitialized)
 	}
 	h.logger.Info(fmt.Sprintf(LogInfoPublished, s.ID, *topic))
+	clientID, _, _ := h.state(s).get()
+	if clientID == "" {
+		return errors.Wrap(ErrFailedPublish, ErrClientNotInitialized)
+	}
 	// Topics are in the format:
 	// channels/<channel_id>/messages/<subtopic>/.../ct/<content_type>
 
@@ -181,10 +219,16 @@ func (h *handler) Publish(ctx context.Context, topic *string, payload *[]byte) e
 		Protocol:  protocol,
 		Channel:   chanID,
 		Subtopic:  subtopic,
-		Publisher: s.Username,
+		Publisher: clientID,
 		Payload:   *payload,
 		Created:   time.Now().UnixNano(),
 	}
+	if props, ok := PropertiesFromContext(ctx); ok {
+		msg.UserProperties = props.UserProperties
+		msg.Expiry = props.MessageExpiry
+		msg.ResponseTopic = props.ResponseTopic
+		msg.CorrelationData = props.CorrelationData
+	}
 
 	if err := h.publisher.Publish(ctx, msg.GetChannel(), &msg); err != nil {
 		return errors.Wrap(ErrFailedPublishToMsgBroker, err)
@@ -200,6 +244,13 @@ func (h *handler) Subscribe(ctx context.Context, topics *[]string) error {
 		return errors.Wrap(ErrFailedSubscribe, ErrClientNotInitialized)
 	}
 	h.logger.Info(fmt.Sprintf(LogInfoSubscribed, s.ID, strings.Join(*topics, ",")))
+	clientID, _, _ := h.state(s).get()
+	if clientID == "" {
+		return errors.Wrap(ErrFailedSubscribe, ErrClientNotInitialized)
+	}
+	if err := h.es.Subscribe(ctx, clientID, s.ID, *topics); err != nil {
+		return errors.Wrap(ErrFailedPublishSubscribeEvent, err)
+	}
 	return nil
 }
 
@@ -210,6 +261,13 @@ func (h *handler) Unsubscribe(ctx context.Context, topics *[]string) error {
 		return errors.Wrap(ErrFailedUnsubscribe, ErrClientNotInitialized)
 	}
 	h.logger.Info(fmt.Sprintf(LogInfoUnsubscribed, s.ID, strings.Join(*topics, ",")))
+	clientID, _, _ := h.state(s).get()
+	if clientID == "" {
+		return errors.Wrap(ErrFailedUnsubscribe, ErrClientNotInitialized)
+	}
+	if err := h.es.Unsubscribe(ctx, clientID, s.ID, *topics); err != nil {
+		return errors.Wrap(ErrFailedPublishUnsubscribeEvent, err)
+	}
 	return nil
 }
 
@@ -219,23 +277,98 @@ func (h *handler) Disconnect(ctx context.Context) error {
 	if !ok {
 		return errors.Wrap(ErrFailedDisconnect, ErrClientNotInitialized)
 	}
-	h.logger.Error(fmt.Sprintf(LogInfoDisconnected, s.ID, s.Password))
-	if err := h.es.Disconnect(ctx, string(s.Password)); err != nil {
+	h.logger.Info(fmt.Sprintf(LogInfoDisconnected, s.ID, s.Username))
+
+	st, ok := h.sessions.LoadAndDelete(s)
+	if !ok {
+		return nil
+	}
+	// Events are not issued for sessions which weren't authenticated.
+	clientID, conn, code := st.(*sessionState).get()
+	if clientID == "" {
+		return nil
+	}
+
+	reason, will := disconnectReason(code)
+	if err := h.es.Disconnect(ctx, clientID, s.ID, reason); err != nil {
 		return errors.Wrap(ErrFailedPublishDisconnectEvent, err)
 	}
+	// The broker publishes the will of the session which ends without the
+	// normal disconnection.
+	if conn.Will != nil && will {
+		if err := h.es.Will(ctx, clientID, s.ID, conn.Will.Topic, conn.Will.QoS, conn.Will.Retain); err != nil {
+			return errors.Wrap(ErrFailedPublishWillEvent, err)
+		}
+	}
 	return nil
 }
 
+// Intercept records the CONNECT and DISCONNECT packet information of the
+// packets sent by the client. Packets are forwarded unchanged.
+func (h *handler) Intercept(ctx context.Context, pkt packets.ControlPacket, dir session.Direction) (packets.ControlPacket, error) {
+	s, ok := session.FromContext(ctx)
+	if !ok || dir != session.Up {
+		return pkt, nil
+	}
+
+	switch p := pkt.(type) {
+	case *packets.ConnectPacket:
+		conn := Connection{
+			ProtocolVersion: p.ProtocolVersion,
+			KeepAlive:       p.Keepalive,
+		}
+		if p.WillFlag {
+			conn.Will = &Will{
+				Topic:   p.WillTopic,
+				Payload: p.WillMessage,
+				QoS:     p.WillQos,
+				Retain:  p.WillRetain,
+			}
+		}
+		h.state(s).setConnection(conn)
+	case *packets.DisconnectPacket:
+		// MQTT 3.1.1 DISCONNECT packet doesn't carry the reason code.
+		h.state(s).setDisconnect(ReasonNormalDisconnection)
+	}
+
+	return pkt, nil
+}
+
+// state returns the state of the session, creating one if needed.
+func (h *handler) state(s *session.Session) *sessionState {
+	st, _ := h.sessions.LoadOrStore(s, &sessionState{})
+	return st.(*sessionState)
+}
+
+// clientID returns the ID of the client authenticated by AuthConnect.
+func (h *handler) clientID(ctx context.Context) (string, error) {
+	s, ok := session.FromContext(ctx)
+	if !ok {
+		return "", ErrClientNotInitialized
+	}
+	clientID, _, _ := h.state(s).get()
+	if clientID == "" {
+		return "", ErrClientNotInitialized
+	}
+
+	return clientID, nil
+}
+
 func (h *handler) authAccess(ctx context.Context, clientID, topic string, msgType connections.ConnType) error {
+	invalidTopic := ReasonTopicNameInvalid
+	if msgType == connections.Subscribe {
+		invalidTopic = ReasonTopicFilterInvalid
+	}
+
 	// Topics are in the format:
 	// channels/<channel_id>/messages/<subtopic>/.../ct/<content_type>
 	if !channelRegExp.MatchString(topic) {
-		return ErrMalformedTopic
+		return NewReasonCodeError(invalidTopic, ErrMalformedTopic)
 	}
 
 	channelParts := channelRegExp.FindStringSubmatch(topic)
 	if len(channelParts) < 1 {
-		return ErrMalformedTopic
+		return NewReasonCodeError(invalidTopic, ErrMalformedTopic)
 	}
 
 	chanID := channelParts[1]
@@ -248,15 +381,31 @@ func (h *handler) authAccess(ctx context.Context, clientID, topic string, msgTyp
 	}
 	res, err := h.channels.Authorize(ctx, ar)
 	if err != nil {
-		return err
+		return NewReasonCodeError(ReasonUnspecifiedError, err)
 	}
 	if !res.GetAuthorized() {
-		return svcerr.ErrAuthorization
+		return NewReasonCodeError(ReasonNotAuthorized, svcerr.ErrAuthorization)
 	}
 
 	return nil
 }
 
+// parseSharedSubscription returns the topic filter of the MQTT 5 shared
+// subscription in the format $share/<group>/<topic_filter>, so the shared
+// subscriptions are authorized like the subscriptions to the topic filter.
+func parseSharedSubscription(topic string) (string, error) {
+	if !strings.HasPrefix(topic, sharePrefix) {
+		return topic, nil
+	}
+
+	group, filter, ok := strings.Cut(strings.TrimPrefix(topic, sharePrefix), "/")
+	if !ok || group == "" || strings.ContainsAny(group, "+#") {
+		return "", NewReasonCodeError(ReasonTopicFilterInvalid, ErrMalformedTopic)
+	}
+
+	return filter, nil
+}
+
 func parseSubtopic(subtopic string) (string, error) {
 	if subtopic == "" {
 		return subtopic, nil
@@ -7,6 +7,7 @@ import (
 	"log"
 	"testing"
 
+	"github.com/eclipse/paho.mqtt.golang/packets"
 	"github.com/hantdev/hermina/pkg/session"
 	chmocks "github.com/hantdev/mitras/channels/mocks"
 	climocks "github.com/hantdev/mitras/clients/mocks"
@@ -15,13 +16,17 @@ import (
 	"github.com/hantdev/mitras/internal/testsutil"
 	smqlog "github.com/hantdev/mitras/logger"
 	"github.com/hantdev/mitras/mqtt"
+	"github.com/hantdev/mitras/mqtt/events"
 	"github.com/hantdev/mitras/mqtt/mocks"
 	"github.com/hantdev/mitras/pkg/connections"
 	"github.com/hantdev/mitras/pkg/errors"
 	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/mitras/pkg/messaging"
+	msgmocks "github.com/hantdev/mitras/pkg/messaging/mocks"
 	"github.com/hantdev/mitras/pkg/policies"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -32,6 +37,7 @@ const (
 	invalidValue          = "invalidValue"
 	clientID              = "clientID"
 	clientID1             = "clientID1"
+	sessionID             = "sessionID"
 	subtopic              = "testSubtopic"
 	invalidChannelIDTopic = "channels/**/messages"
 )
@@ -42,6 +48,7 @@ var (
 	invalidTopic        = invalidValue
 	payload             = []byte("[{'n':'test-name', 'v': 1.2}]")
 	topics              = []string{topic}
+	sharedTopics        = []string{"$share/group/" + topic}
 	invalidTopics       = []string{invalidValue}
 	invalidChanIDTopics = []string{fmt.Sprintf(topicMsg, invalidValue)}
 	// Test log messages for cases the handler does not provide a return value.
@@ -61,6 +68,12 @@ var (
 		Username: invalidID,
 		Password: []byte(password),
 	}
+	// sessionNoUsername is the session of the client which connects without
+	// the username, so the client is identified by the secret only.
+	sessionNoUsername = session.Session{
+		ID:       sessionID,
+		Password: []byte(password),
+	}
 	errInvalidUserId = errors.New("invalid user id")
 )
 
@@ -79,11 +92,13 @@ func TestAuthConnect(t *testing.T) {
 		authNRes *grpcClientsV1.AuthnRes
 		authNErr error
 		err      error
+		code     byte
 	}{
 		{
 			desc:    "connect without active session",
 			err:     mqtt.ErrClientNotInitialized,
 			session: nil,
+			code:    mqtt.ReasonUnspecifiedError,
 		},
 		{
 			desc: "connect without clientID",
@@ -93,6 +108,7 @@ func TestAuthConnect(t *testing.T) {
 				Username: clientID,
 				Password: []byte(password),
 			},
+			code: mqtt.ReasonClientIDNotValid,
 		},
 		{
 			desc: "connect with empty password",
@@ -103,6 +119,7 @@ func TestAuthConnect(t *testing.T) {
 			},
 			authNErr: svcerr.ErrAuthentication,
 			err:      svcerr.ErrAuthentication,
+			code:     mqtt.ReasonBadUserNameOrPassword,
 		},
 		{
 			desc: "connect with invalid password",
@@ -114,7 +131,8 @@ func TestAuthConnect(t *testing.T) {
 			authNRes: &grpcClientsV1.AuthnRes{
 				Authenticated: false,
 			},
-			err: svcerr.ErrAuthentication,
+			err:  svcerr.ErrAuthentication,
+			code: mqtt.ReasonBadUserNameOrPassword,
 		},
 		{
 			desc:    "connect with valid password and invalid username",
@@ -123,7 +141,8 @@ func TestAuthConnect(t *testing.T) {
 				Authenticated: true,
 				Id:            testsutil.GenerateUUID(t),
 			},
-			err: errInvalidUserId,
+			err:  errInvalidUserId,
+			code: mqtt.ReasonBadUserNameOrPassword,
 		},
 		{
 			desc:    "connect with valid username and password",
@@ -144,10 +163,11 @@ func TestAuthConnect(t *testing.T) {
 				password = string(tc.session.Password)
 			}
 			clientsCall := clients.On("Authenticate", mock.Anything, &grpcClientsV1.AuthnReq{ClientSecret: password}).Return(tc.authNRes, tc.authNErr)
-			svcCall := eventStore.On("Connect", mock.Anything, password).Return(tc.err)
 			err := handler.AuthConnect(ctx)
 			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
-			svcCall.Unset()
+			if err != nil {
+				assert.Equal(t, tc.code, mqtt.ReasonCode(err), fmt.Sprintf("%s: expected reason code %#x got %#x\n", tc.desc, tc.code, mqtt.ReasonCode(err)))
+			}
 			clientsCall.Unset()
 		})
 	}
@@ -155,6 +175,9 @@ func TestAuthConnect(t *testing.T) {
 
 func TestAuthPublish(t *testing.T) {
 	handler := newHandler()
+	noUsername := sessionNoUsername
+	connect(t, handler, &sessionClient, clientID)
+	connect(t, handler, &noUsername, clientID)
 
 	cases := []struct {
 		desc     string
@@ -164,6 +187,7 @@ func TestAuthPublish(t *testing.T) {
 		payload  []byte
 		authZRes *grpcChannelsV1.AuthzRes
 		authZErr error
+		code     byte
 	}{
 		{
 			desc:     "publish successfully",
@@ -173,12 +197,29 @@ func TestAuthPublish(t *testing.T) {
 			payload:  payload,
 			authZRes: &grpcChannelsV1.AuthzRes{Authorized: true},
 		},
+		{
+			desc:     "publish successfully as client connected without username",
+			session:  &noUsername,
+			err:      nil,
+			topic:    &topic,
+			payload:  payload,
+			authZRes: &grpcChannelsV1.AuthzRes{Authorized: true},
+		},
 		{
 			desc:    "publish with an inactive client",
 			session: nil,
 			err:     mqtt.ErrClientNotInitialized,
 			topic:   &topic,
 			payload: payload,
+			code:    mqtt.ReasonUnspecifiedError,
+		},
+		{
+			desc:    "publish with unauthenticated session",
+			session: &session.Session{ID: clientID, Username: clientID},
+			err:     mqtt.ErrClientNotInitialized,
+			topic:   &topic,
+			payload: payload,
+			code:    mqtt.ReasonUnspecifiedError,
 		},
 		{
 			desc:    "publish without topic",
@@ -186,6 +227,7 @@ func TestAuthPublish(t *testing.T) {
 			err:     mqtt.ErrMissingTopicPub,
 			topic:   nil,
 			payload: payload,
+			code:    mqtt.ReasonUnspecifiedError,
 		},
 		{
 			desc:    "publish with malformed topic",
@@ -193,6 +235,7 @@ func TestAuthPublish(t *testing.T) {
 			err:     mqtt.ErrMalformedTopic,
 			topic:   &invalidTopic,
 			payload: payload,
+			code:    mqtt.ReasonTopicNameInvalid,
 		},
 		{
 			desc:     "publish with authorization error",
@@ -202,6 +245,7 @@ func TestAuthPublish(t *testing.T) {
 			payload:  payload,
 			authZRes: &grpcChannelsV1.AuthzRes{Authorized: false},
 			authZErr: svcerr.ErrAuthorization,
+			code:     mqtt.ReasonUnspecifiedError,
 		},
 	}
 
@@ -219,6 +263,9 @@ func TestAuthPublish(t *testing.T) {
 			}).Return(tc.authZRes, tc.authZErr)
 			err := handler.AuthPublish(ctx, tc.topic, &tc.payload)
 			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if err != nil {
+				assert.Equal(t, tc.code, mqtt.ReasonCode(err), fmt.Sprintf("%s: expected reason code %#x got %#x\n", tc.desc, tc.code, mqtt.ReasonCode(err)))
+			}
 			channelsCall.Unset()
 		})
 	}
@@ -226,6 +273,8 @@ func TestAuthPublish(t *testing.T) {
 
 func TestAuthSubscribe(t *testing.T) {
 	handler := newHandler()
+	connect(t, handler, &sessionClient, clientID)
+	connect(t, handler, &sessionClientSub, clientID1)
 
 	cases := []struct {
 		desc      string
@@ -235,24 +284,35 @@ func TestAuthSubscribe(t *testing.T) {
 		channelID string
 		authZRes  *grpcChannelsV1.AuthzRes
 		authZErr  error
+		code      byte
 	}{
 		{
 			desc:    "subscribe without active session",
 			session: nil,
 			err:     mqtt.ErrClientNotInitialized,
 			topic:   &topics,
+			code:    mqtt.ReasonUnspecifiedError,
+		},
+		{
+			desc:    "subscribe with unauthenticated session",
+			session: &session.Session{ID: clientID1, Username: clientID1},
+			err:     mqtt.ErrClientNotInitialized,
+			topic:   &topics,
+			code:    mqtt.ReasonUnspecifiedError,
 		},
 		{
 			desc:    "subscribe without topics",
 			session: &sessionClient,
 			err:     mqtt.ErrMissingTopicSub,
 			topic:   nil,
+			code:    mqtt.ReasonUnspecifiedError,
 		},
 		{
 			desc:    "subscribe with invalid topics",
 			session: &sessionClient,
 			err:     mqtt.ErrMalformedTopic,
 			topic:   &invalidTopics,
+			code:    mqtt.ReasonTopicFilterInvalid,
 		},
 		{
 			desc:      "subscribe with invalid channel ID",
@@ -261,6 +321,7 @@ func TestAuthSubscribe(t *testing.T) {
 			topic:     &invalidChanIDTopics,
 			authZRes:  &grpcChannelsV1.AuthzRes{Authorized: false},
 			channelID: invalidValue,
+			code:      mqtt.ReasonNotAuthorized,
 		},
 		{
 			desc:      "subscribe successfully",
@@ -270,6 +331,35 @@ func TestAuthSubscribe(t *testing.T) {
 			authZRes:  &grpcChannelsV1.AuthzRes{Authorized: true},
 			channelID: chanID,
 		},
+		{
+			desc:      "subscribe to shared subscription successfully",
+			session:   &sessionClientSub,
+			err:       nil,
+			topic:     &sharedTopics,
+			authZRes:  &grpcChannelsV1.AuthzRes{Authorized: true},
+			channelID: chanID,
+		},
+		{
+			desc:    "subscribe to shared subscription without group",
+			session: &sessionClientSub,
+			err:     mqtt.ErrMalformedTopic,
+			topic:   &[]string{"$share//" + topic},
+			code:    mqtt.ReasonTopicFilterInvalid,
+		},
+		{
+			desc:    "subscribe to shared subscription with wildcard group",
+			session: &sessionClientSub,
+			err:     mqtt.ErrMalformedTopic,
+			topic:   &[]string{"$share/+/" + topic},
+			code:    mqtt.ReasonTopicFilterInvalid,
+		},
+		{
+			desc:    "subscribe to shared subscription without topic filter",
+			session: &sessionClientSub,
+			err:     mqtt.ErrMalformedTopic,
+			topic:   &[]string{"$share/group"},
+			code:    mqtt.ReasonTopicFilterInvalid,
+		},
 		{
 			desc:      "subscribe with failed authorization",
 			session:   &sessionClientSub,
@@ -277,6 +367,7 @@ func TestAuthSubscribe(t *testing.T) {
 			topic:     &topics,
 			authZRes:  &grpcChannelsV1.AuthzRes{Authorized: false},
 			channelID: chanID,
+			code:      mqtt.ReasonNotAuthorized,
 		},
 	}
 
@@ -294,6 +385,9 @@ func TestAuthSubscribe(t *testing.T) {
 			}).Return(tc.authZRes, tc.authZErr)
 			err := handler.AuthSubscribe(ctx, tc.topic)
 			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if err != nil {
+				assert.Equal(t, tc.code, mqtt.ReasonCode(err), fmt.Sprintf("%s: expected reason code %#x got %#x\n", tc.desc, tc.code, mqtt.ReasonCode(err)))
+			}
 			channelsCall.Unset()
 		})
 	}
@@ -301,11 +395,21 @@ func TestAuthSubscribe(t *testing.T) {
 
 func TestConnect(t *testing.T) {
 	handler := newHandler()
+	connect(t, handler, &sessionClient, clientID)
+	noUsername := sessionNoUsername
+	connect(t, handler, &noUsername, clientID)
 	logBuffer.Reset()
 
+	connPkt := packets.NewControlPacket(packets.Connect).(*packets.ConnectPacket)
+	connPkt.ProtocolVersion = 4
+	connPkt.Keepalive = 60
+	intercept(t, handler, &noUsername, connPkt)
+
 	cases := []struct {
 		desc    string
 		session *session.Session
+		event   events.Connection
+		esErr   error
 		err     error
 		logMsg  string
 	}{
@@ -314,27 +418,57 @@ func TestConnect(t *testing.T) {
 			session: nil,
 			err:     errors.Wrap(mqtt.ErrFailedConnect, mqtt.ErrClientNotInitialized),
 		},
+		{
+			desc:    "connect with unauthenticated session",
+			session: &session.Session{ID: clientID, Username: clientID},
+			err:     errors.Wrap(mqtt.ErrFailedConnect, mqtt.ErrClientNotInitialized),
+		},
 		{
 			desc:    "connect with active session",
 			session: &sessionClient,
+			event:   events.Connection{ClientID: clientID, SessionID: clientID},
 			logMsg:  fmt.Sprintf(mqtt.LogInfoConnected, clientID),
 			err:     nil,
 		},
+		{
+			desc:    "connect with intercepted CONNECT packet",
+			session: &noUsername,
+			event:   events.Connection{ClientID: clientID, SessionID: sessionID, ProtocolVersion: 4, KeepAlive: 60},
+			logMsg:  fmt.Sprintf(mqtt.LogInfoConnected, sessionID),
+			err:     nil,
+		},
+		{
+			desc:    "connect with failed connect event",
+			session: &sessionClient,
+			event:   events.Connection{ClientID: clientID, SessionID: clientID},
+			esErr:   errors.New("failed to publish event"),
+			logMsg:  mqtt.ErrFailedPublishConnectEvent.Error(),
+			err:     nil,
+		},
 	}
 
 	for _, tc := range cases {
-		ctx := context.TODO()
-		if tc.session != nil {
-			ctx = session.NewContext(ctx, tc.session)
-		}
-		err := handler.Connect(ctx)
-		assert.Contains(t, logBuffer.String(), tc.logMsg)
-		assert.Equal(t, tc.err, err)
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := context.TODO()
+			if tc.session != nil {
+				ctx = session.NewContext(ctx, tc.session)
+			}
+			svcCall := eventStore.On("Connect", mock.Anything, mock.Anything).Return(tc.esErr)
+			err := handler.Connect(ctx)
+			assert.Contains(t, logBuffer.String(), tc.logMsg)
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				svcCall.Parent.AssertCalled(t, "Connect", mock.Anything, tc.event)
+			}
+			svcCall.Unset()
+			eventStore.Calls = nil
+		})
 	}
 }
 
 func TestPublish(t *testing.T) {
 	handler := newHandler()
+	connect(t, handler, &sessionClient, clientID)
 	logBuffer.Reset()
 
 	malformedSubtopics := topic + "/" + subtopic + "%"
@@ -356,6 +490,13 @@ func TestPublish(t *testing.T) {
 			payload: payload,
 			err:     errors.Wrap(mqtt.ErrFailedPublish, mqtt.ErrClientNotInitialized),
 		},
+		{
+			desc:    "publish with unauthenticated session",
+			session: &session.Session{ID: clientID, Username: clientID},
+			topic:   topic,
+			payload: payload,
+			err:     errors.Wrap(mqtt.ErrFailedPublish, mqtt.ErrClientNotInitialized),
+		},
 		{
 			desc:    "publish with invalid topic",
 			session: &sessionClient,
@@ -412,8 +553,67 @@ func TestPublish(t *testing.T) {
 	}
 }
 
+func TestPublishProperties(t *testing.T) {
+	logger, err := smqlog.New(&logBuffer, "debug")
+	require.Nil(t, err, fmt.Sprintf("failed to create logger: %s", err))
+	pub := new(msgmocks.PubSub)
+	handler := mqtt.NewHandler(pub, eventStore, logger, clients, channels)
+	noUsername := sessionNoUsername
+	connect(t, handler, &sessionClient, clientID)
+	connect(t, handler, &noUsername, clientID)
+
+	props := mqtt.Properties{
+		UserProperties:  map[string]string{"unit": "celsius"},
+		MessageExpiry:   60,
+		ResponseTopic:   topic + "/response",
+		CorrelationData: []byte("correlation"),
+	}
+
+	cases := []struct {
+		desc  string
+		ctx   context.Context
+		props mqtt.Properties
+	}{
+		{
+			desc:  "publish with properties",
+			ctx:   mqtt.NewPropertiesContext(session.NewContext(context.TODO(), &sessionClient), props),
+			props: props,
+		},
+		{
+			desc:  "publish without properties",
+			ctx:   session.NewContext(context.TODO(), &sessionClient),
+			props: mqtt.Properties{},
+		},
+		{
+			desc:  "publish as client connected without username",
+			ctx:   session.NewContext(context.TODO(), &noUsername),
+			props: mqtt.Properties{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var msg *messaging.Message
+			repoCall := pub.On("Publish", mock.Anything, chanID, mock.Anything).Run(func(args mock.Arguments) {
+				msg = args.Get(2).(*messaging.Message)
+			}).Return(nil)
+			err := handler.Publish(tc.ctx, &topic, &payload)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, clientID, msg.GetPublisher(), fmt.Sprintf("%s: got unexpected publisher", tc.desc))
+			assert.Equal(t, tc.props.UserProperties, msg.GetUserProperties(), fmt.Sprintf("%s: got unexpected user properties", tc.desc))
+			assert.Equal(t, tc.props.MessageExpiry, msg.GetExpiry(), fmt.Sprintf("%s: got unexpected expiry", tc.desc))
+			assert.Equal(t, tc.props.ResponseTopic, msg.GetResponseTopic(), fmt.Sprintf("%s: got unexpected response topic", tc.desc))
+			assert.Equal(t, tc.props.CorrelationData, msg.GetCorrelationData(), fmt.Sprintf("%s: got unexpected correlation data", tc.desc))
+			repoCall.Unset()
+		})
+	}
+}
+
 func TestSubscribe(t *testing.T) {
 	handler := newHandler()
+	noUsername := sessionNoUsername
+	connect(t, handler, &sessionClient, clientID)
+	connect(t, handler, &noUsername, clientID)
 	logBuffer.Reset()
 
 	cases := []struct {
@@ -421,6 +621,7 @@ func TestSubscribe(t *testing.T) {
 		session *session.Session
 		topic   []string
 		logMsg  string
+		esErr   error
 		err     error
 	}{
 		{
@@ -429,12 +630,31 @@ func TestSubscribe(t *testing.T) {
 			topic:   topics,
 			err:     errors.Wrap(mqtt.ErrFailedSubscribe, mqtt.ErrClientNotInitialized),
 		},
+		{
+			desc:    "subscribe with unauthenticated session",
+			session: &session.Session{ID: clientID, Username: clientID},
+			topic:   topics,
+			err:     errors.Wrap(mqtt.ErrFailedSubscribe, mqtt.ErrClientNotInitialized),
+		},
+		{
+			desc:    "subscribe as client connected without username",
+			session: &noUsername,
+			topic:   topics,
+			logMsg:  fmt.Sprintf(mqtt.LogInfoSubscribed, sessionID, topics[0]),
+		},
 		{
 			desc:    "subscribe with valid session and topics",
 			session: &sessionClient,
 			topic:   topics,
 			logMsg:  fmt.Sprintf(mqtt.LogInfoSubscribed, clientID, topics[0]),
 		},
+		{
+			desc:    "subscribe with failed subscribe event",
+			session: &sessionClient,
+			topic:   topics,
+			esErr:   errors.New("failed to publish event"),
+			err:     errors.Wrap(mqtt.ErrFailedPublishSubscribeEvent, errors.New("failed to publish event")),
+		},
 	}
 
 	for _, tc := range cases {
@@ -442,14 +662,22 @@ func TestSubscribe(t *testing.T) {
 		if tc.session != nil {
 			ctx = session.NewContext(ctx, tc.session)
 		}
+		svcCall := eventStore.On("Subscribe", mock.Anything, clientID, mock.Anything, tc.topic).Return(tc.esErr)
 		err := handler.Subscribe(ctx, &tc.topic)
 		assert.Contains(t, logBuffer.String(), tc.logMsg)
-		assert.Equal(t, tc.err, err)
+		assert.Equal(t, tc.err, err, tc.desc)
+		if err == nil {
+			svcCall.Parent.AssertCalled(t, "Subscribe", mock.Anything, clientID, tc.session.ID, tc.topic)
+		}
+		svcCall.Unset()
 	}
 }
 
 func TestUnsubscribe(t *testing.T) {
 	handler := newHandler()
+	noUsername := sessionNoUsername
+	connect(t, handler, &sessionClient, clientID)
+	connect(t, handler, &noUsername, clientID)
 	logBuffer.Reset()
 
 	cases := []struct {
@@ -457,6 +685,7 @@ func TestUnsubscribe(t *testing.T) {
 		session *session.Session
 		topic   []string
 		logMsg  string
+		esErr   error
 		err     error
 	}{
 		{
@@ -465,12 +694,31 @@ func TestUnsubscribe(t *testing.T) {
 			topic:   topics,
 			err:     errors.Wrap(mqtt.ErrFailedUnsubscribe, mqtt.ErrClientNotInitialized),
 		},
+		{
+			desc:    "unsubscribe with unauthenticated session",
+			session: &session.Session{ID: clientID, Username: clientID},
+			topic:   topics,
+			err:     errors.Wrap(mqtt.ErrFailedUnsubscribe, mqtt.ErrClientNotInitialized),
+		},
+		{
+			desc:    "unsubscribe as client connected without username",
+			session: &noUsername,
+			topic:   topics,
+			logMsg:  fmt.Sprintf(mqtt.LogInfoUnsubscribed, sessionID, topics[0]),
+		},
 		{
 			desc:    "unsubscribe with valid session and topics",
 			session: &sessionClient,
 			topic:   topics,
 			logMsg:  fmt.Sprintf(mqtt.LogInfoUnsubscribed, clientID, topics[0]),
 		},
+		{
+			desc:    "unsubscribe with failed unsubscribe event",
+			session: &sessionClient,
+			topic:   topics,
+			esErr:   errors.New("failed to publish event"),
+			err:     errors.Wrap(mqtt.ErrFailedPublishUnsubscribeEvent, errors.New("failed to publish event")),
+		},
 	}
 
 	for _, tc := range cases {
@@ -478,9 +726,14 @@ func TestUnsubscribe(t *testing.T) {
 		if tc.session != nil {
 			ctx = session.NewContext(ctx, tc.session)
 		}
+		svcCall := eventStore.On("Unsubscribe", mock.Anything, clientID, mock.Anything, tc.topic).Return(tc.esErr)
 		err := handler.Unsubscribe(ctx, &tc.topic)
 		assert.Contains(t, logBuffer.String(), tc.logMsg)
-		assert.Equal(t, tc.err, err)
+		assert.Equal(t, tc.err, err, tc.desc)
+		if err == nil {
+			svcCall.Parent.AssertCalled(t, "Unsubscribe", mock.Anything, clientID, tc.session.ID, tc.topic)
+		}
+		svcCall.Unset()
 	}
 }
 
@@ -488,43 +741,165 @@ func TestDisconnect(t *testing.T) {
 	handler := newHandler()
 	logBuffer.Reset()
 
+	will := &mqtt.Will{Topic: topic, Payload: payload, QoS: 1}
+	withWill := packets.NewControlPacket(packets.Connect).(*packets.ConnectPacket)
+	withWill.ProtocolVersion = 4
+	withWill.WillFlag = true
+	withWill.WillTopic = will.Topic
+	withWill.WillMessage = will.Payload
+	withWill.WillQos = will.QoS
+	withoutWill := packets.NewControlPacket(packets.Connect).(*packets.ConnectPacket)
+	withoutWill.ProtocolVersion = 4
+
 	cases := []struct {
-		desc    string
-		session *session.Session
-		topic   []string
-		logMsg  string
-		err     error
+		desc          string
+		session       *session.Session
+		authenticated bool
+		conn          *packets.ConnectPacket
+		disconnect    bool
+		reason        string
+		will          bool
+		logMsg        string
+		esErr         error
+		willErr       error
+		err           error
 	}{
 		{
 			desc:    "disconnect without active session",
 			session: nil,
-			topic:   topics,
 			err:     errors.Wrap(mqtt.ErrFailedDisconnect, mqtt.ErrClientNotInitialized),
 		},
 		{
-			desc:    "disconnect with valid session",
-			session: &sessionClient,
-			topic:   topics,
+			desc:    "disconnect with unauthenticated session",
+			session: &session.Session{ID: clientID, Username: clientID},
+			logMsg:  fmt.Sprintf(mqtt.LogInfoDisconnected, clientID, clientID),
 			err:     nil,
 		},
+		{
+			desc:          "disconnect with valid session",
+			session:       &sessionClient,
+			authenticated: true,
+			reason:        "connection lost",
+			logMsg:        fmt.Sprintf(mqtt.LogInfoDisconnected, clientID, clientID),
+			err:           nil,
+		},
+		{
+			desc:          "disconnect normally with will",
+			session:       &sessionClient,
+			authenticated: true,
+			conn:          withWill,
+			disconnect:    true,
+			reason:        "normal disconnection",
+			err:           nil,
+		},
+		{
+			desc:          "disconnect normally as client connected without username",
+			session:       &sessionNoUsername,
+			authenticated: true,
+			conn:          withoutWill,
+			disconnect:    true,
+			reason:        "normal disconnection",
+			logMsg:        fmt.Sprintf(mqtt.LogInfoDisconnected, sessionID, ""),
+			err:           nil,
+		},
+		{
+			desc:          "lose connection with will",
+			session:       &sessionClient,
+			authenticated: true,
+			conn:          withWill,
+			reason:        "connection lost",
+			will:          true,
+			err:           nil,
+		},
+		{
+			desc:          "lose connection without will",
+			session:       &sessionClient,
+			authenticated: true,
+			conn:          withoutWill,
+			reason:        "connection lost",
+			err:           nil,
+		},
+		{
+			desc:          "disconnect with failed disconnect event",
+			session:       &sessionClient,
+			authenticated: true,
+			conn:          withWill,
+			reason:        "connection lost",
+			esErr:         errors.New("failed to publish event"),
+			err:           errors.Wrap(mqtt.ErrFailedPublishDisconnectEvent, errors.New("failed to publish event")),
+		},
+		{
+			desc:          "disconnect with failed will event",
+			session:       &sessionClient,
+			authenticated: true,
+			conn:          withWill,
+			reason:        "connection lost",
+			will:          true,
+			willErr:       errors.New("failed to publish event"),
+			err:           errors.Wrap(mqtt.ErrFailedPublishWillEvent, errors.New("failed to publish event")),
+		},
 	}
 
 	for _, tc := range cases {
-		ctx := context.TODO()
-		password := ""
-		if tc.session != nil {
-			ctx = session.NewContext(ctx, tc.session)
-			password = string(tc.session.Password)
-		}
-		svcCall := eventStore.On("Disconnect", mock.Anything, password).Return(tc.err)
-		err := handler.Disconnect(ctx)
-		assert.Contains(t, logBuffer.String(), tc.logMsg)
-		assert.Equal(t, tc.err, err)
-		svcCall.Unset()
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := context.TODO()
+			var sessionID string
+			if tc.session != nil {
+				// Every case is a new session of the client.
+				s := *tc.session
+				sessionID = s.ID
+				ctx = session.NewContext(ctx, &s)
+				if tc.authenticated {
+					connect(t, handler, &s, clientID)
+				}
+				if tc.conn != nil {
+					intercept(t, handler, &s, tc.conn)
+				}
+				if tc.disconnect {
+					intercept(t, handler, &s, packets.NewControlPacket(packets.Disconnect))
+				}
+			}
+			svcCall := eventStore.On("Disconnect", mock.Anything, clientID, sessionID, tc.reason).Return(tc.esErr)
+			willCall := eventStore.On("Will", mock.Anything, clientID, sessionID, will.Topic, will.QoS, will.Retain).Return(tc.willErr)
+			err := handler.Disconnect(ctx)
+			assert.Contains(t, logBuffer.String(), tc.logMsg)
+			assert.Equal(t, tc.err, err)
+			switch tc.authenticated {
+			case true:
+				svcCall.Parent.AssertCalled(t, "Disconnect", mock.Anything, clientID, sessionID, tc.reason)
+			default:
+				svcCall.Parent.AssertNotCalled(t, "Disconnect", mock.Anything, clientID, sessionID, tc.reason)
+			}
+			switch tc.will {
+			case true:
+				willCall.Parent.AssertCalled(t, "Will", mock.Anything, clientID, sessionID, will.Topic, will.QoS, will.Retain)
+			default:
+				willCall.Parent.AssertNotCalled(t, "Will", mock.Anything, clientID, sessionID, will.Topic, will.QoS, will.Retain)
+			}
+			svcCall.Unset()
+			willCall.Unset()
+			eventStore.Calls = nil
+		})
 	}
 }
 
-func newHandler() session.Handler {
+// connect authenticates the session as the client with the given ID, like
+// the proxy does once the client connects.
+func connect(t *testing.T, handler mqtt.Handler, s *session.Session, id string) {
+	clientsCall := clients.On("Authenticate", mock.Anything, &grpcClientsV1.AuthnReq{ClientSecret: string(s.Password)}).Return(&grpcClientsV1.AuthnRes{Authenticated: true, Id: id}, nil)
+	defer clientsCall.Unset()
+
+	err := handler.AuthConnect(session.NewContext(context.TODO(), s))
+	require.Nil(t, err, fmt.Sprintf("connect unexpected error: %s", err))
+}
+
+// intercept passes the packet sent by the client through the handler.
+func intercept(t *testing.T, handler mqtt.Handler, s *session.Session, pkt packets.ControlPacket) {
+	_, err := handler.Intercept(session.NewContext(context.TODO(), s), pkt, session.Up)
+	require.Nil(t, err, fmt.Sprintf("intercept unexpected error: %s", err))
+}
+
+func newHandler() mqtt.Handler {
 	logger, err := smqlog.New(&logBuffer, "debug")
 	if err != nil {
 		log.Fatalf("failed to create logger: %s", err)
@@ -5,6 +5,7 @@ package mocks
 import (
 	context "context"
 
+	events "github.com/hantdev/mitras/mqtt/events"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -13,17 +14,17 @@ type EventStore struct {
 	mock.Mock
 }
 
-// Connect provides a mock function with given fields: ctx, clientID
-func (_m *EventStore) Connect(ctx context.Context, clientID string) error {
-	ret := _m.Called(ctx, clientID)
+// Connect provides a mock function with given fields: ctx, conn
+func (_m *EventStore) Connect(ctx context.Context, conn events.Connection) error {
+	ret := _m.Called(ctx, conn)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Connect")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, clientID)
+	if rf, ok := ret.Get(0).(func(context.Context, events.Connection) error); ok {
+		r0 = rf(ctx, conn)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -31,17 +32,71 @@ func (_m *EventStore) Connect(ctx context.Context, clientID string) error {
 	return r0
 }
 
-// Disconnect provides a mock function with given fields: ctx, clientID
-func (_m *EventStore) Disconnect(ctx context.Context, clientID string) error {
-	ret := _m.Called(ctx, clientID)
+// Disconnect provides a mock function with given fields: ctx, clientID, sessionID, reason
+func (_m *EventStore) Disconnect(ctx context.Context, clientID string, sessionID string, reason string) error {
+	ret := _m.Called(ctx, clientID, sessionID, reason)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Disconnect")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, clientID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, clientID, sessionID, reason)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Subscribe provides a mock function with given fields: ctx, clientID, sessionID, topics
+func (_m *EventStore) Subscribe(ctx context.Context, clientID string, sessionID string, topics []string) error {
+	ret := _m.Called(ctx, clientID, sessionID, topics)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Subscribe")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string) error); ok {
+		r0 = rf(ctx, clientID, sessionID, topics)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Unsubscribe provides a mock function with given fields: ctx, clientID, sessionID, topics
+func (_m *EventStore) Unsubscribe(ctx context.Context, clientID string, sessionID string, topics []string) error {
+	ret := _m.Called(ctx, clientID, sessionID, topics)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Unsubscribe")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string) error); ok {
+		r0 = rf(ctx, clientID, sessionID, topics)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Will provides a mock function with given fields: ctx, clientID, sessionID, topic, qos, retain
+func (_m *EventStore) Will(ctx context.Context, clientID string, sessionID string, topic string, qos uint8, retain bool) error {
+	ret := _m.Called(ctx, clientID, sessionID, topic, qos, retain)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Will")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, uint8, bool) error); ok {
+		r0 = rf(ctx, clientID, sessionID, topic, qos, retain)
 	} else {
 		r0 = ret.Error(0)
 	}
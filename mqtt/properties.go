@@ -0,0 +1,26 @@
+package mqtt
+
+import "context"
+
+type propertiesKey struct{}
+
+// Properties are the MQTT 5 properties of the published message.
+type Properties struct {
+	UserProperties  map[string]string
+	MessageExpiry   uint32
+	ResponseTopic   string
+	CorrelationData []byte
+}
+
+// NewPropertiesContext returns the context carrying the MQTT 5 properties of
+// the publish packet. Properties are set by proxies supporting MQTT 5, and
+// they are mapped into the message envelope once the message is published.
+func NewPropertiesContext(ctx context.Context, p Properties) context.Context {
+	return context.WithValue(ctx, propertiesKey{}, p)
+}
+
+// PropertiesFromContext returns the MQTT 5 properties of the publish packet.
+func PropertiesFromContext(ctx context.Context) (Properties, bool) {
+	p, ok := ctx.Value(propertiesKey{}).(Properties)
+	return p, ok
+}
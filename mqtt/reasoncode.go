@@ -0,0 +1,75 @@
+package mqtt
+
+import "github.com/hantdev/mitras/pkg/errors"
+
+// MQTT 5 reason codes sent to the clients when the connection, publish or
+// subscription is rejected.
+const (
+	ReasonUnspecifiedError      byte = 0x80
+	ReasonClientIDNotValid      byte = 0x85
+	ReasonBadUserNameOrPassword byte = 0x86
+	ReasonNotAuthorized         byte = 0x87
+	ReasonTopicFilterInvalid    byte = 0x8F
+	ReasonTopicNameInvalid      byte = 0x90
+)
+
+// MQTT 5 reason codes of the DISCONNECT packet sent by the client.
+const (
+	ReasonNormalDisconnection byte = 0x00
+	ReasonDisconnectWithWill  byte = 0x04
+)
+
+var _ errors.Error = (*ReasonCodeError)(nil)
+
+// ReasonCodeError is the error of the MQTT session which carries the MQTT 5
+// reason code. Proxies supporting MQTT 5 send the reason code to the clients
+// in the CONNACK, PUBACK or SUBACK packet, while MQTT 3.1.1 clients are
+// disconnected.
+type ReasonCodeError struct {
+	err  errors.Error
+	code byte
+}
+
+// NewReasonCodeError returns the error with the MQTT 5 reason code.
+func NewReasonCodeError(code byte, err error) *ReasonCodeError {
+	e, ok := err.(errors.Error)
+	if !ok {
+		e = errors.New(err.Error())
+	}
+
+	return &ReasonCodeError{
+		err:  e,
+		code: code,
+	}
+}
+
+func (e *ReasonCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *ReasonCodeError) Msg() string {
+	return e.err.Msg()
+}
+
+func (e *ReasonCodeError) Err() errors.Error {
+	return e.err.Err()
+}
+
+func (e *ReasonCodeError) MarshalJSON() ([]byte, error) {
+	return e.err.MarshalJSON()
+}
+
+// ReasonCode returns the MQTT 5 reason code of the error.
+func (e *ReasonCodeError) ReasonCode() byte {
+	return e.code
+}
+
+// ReasonCode returns the MQTT 5 reason code of the session error, or the
+// unspecified error reason code if the error doesn't carry one.
+func ReasonCode(err error) byte {
+	if e, ok := err.(*ReasonCodeError); ok {
+		return e.ReasonCode()
+	}
+
+	return ReasonUnspecifiedError
+}
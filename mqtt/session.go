@@ -0,0 +1,74 @@
+package mqtt
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Will is the Last Will and Testament of the MQTT session.
+type Will struct {
+	Topic   string
+	Payload []byte
+	QoS     byte
+	Retain  bool
+}
+
+// Connection is the information of the MQTT CONNECT packet which isn't carried
+// by the session.
+type Connection struct {
+	ProtocolVersion byte
+	KeepAlive       uint16
+	Will            *Will
+}
+
+// sessionState is the state of the MQTT session which isn't carried by the
+// proxy session. The client ID is set once the client is authenticated, while
+// the CONNECT and DISCONNECT packet information is recorded by the
+// interceptor.
+type sessionState struct {
+	mu       sync.Mutex
+	clientID string
+	conn     Connection
+	reason   *byte
+}
+
+func (st *sessionState) setClientID(clientID string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.clientID = clientID
+}
+
+func (st *sessionState) setConnection(conn Connection) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.conn = conn
+}
+
+func (st *sessionState) setDisconnect(code byte) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.reason = &code
+}
+
+func (st *sessionState) get() (string, Connection, *byte) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.clientID, st.conn, st.reason
+}
+
+// disconnectReason returns the reason of the session end and reports whether
+// the broker publishes the will of the session. The session without the
+// DISCONNECT packet reason code was lost. The will is discarded only when the
+// client disconnects with the ReasonNormalDisconnection code.
+func disconnectReason(code *byte) (string, bool) {
+	switch {
+	case code == nil:
+		return "connection lost", true
+	case *code == ReasonNormalDisconnection:
+		return "normal disconnection", false
+	case *code == ReasonDisconnectWithWill:
+		return "disconnect with will message", true
+	default:
+		return fmt.Sprintf("disconnect with reason code %#02x", *code), true
+	}
+}
@@ -195,6 +195,24 @@ var (
 	// ErrInvalidInterval indicates invalid interval value.
 	ErrInvalidInterval = errors.New("invalid interval value")
 
+	// ErrMissingAggregation indicates missing aggregation value.
+	ErrMissingAggregation = errors.New("missing aggregation value")
+
+	// ErrInvalidFill indicates invalid gap filling value.
+	ErrInvalidFill = errors.New("invalid fill value")
+
+	// ErrTooManyBuckets indicates that gap filling exceeds the max number of buckets.
+	ErrTooManyBuckets = errors.New("too many gap-filled buckets")
+
+	// ErrInvalidPayloadFilter indicates invalid JSON payload filter.
+	ErrInvalidPayloadFilter = errors.New("invalid payload filter")
+
+	// ErrInvalidPayloadField indicates invalid JSON payload field path.
+	ErrInvalidPayloadField = errors.New("invalid payload field")
+
+	// ErrPayloadFormat indicates JSON payload query of SenML messages.
+	ErrPayloadFormat = errors.New("payload queries require JSON message format")
+
 	// ErrMissingFrom indicates missing from value.
 	ErrMissingFrom = errors.New("missing from time value")
 
@@ -238,4 +256,37 @@ var (
 	ErrInvalidProfilePictureURL = errors.New("invalid profile picture url")
 
 	ErrMultipleEntitiesFilter = errors.New("multiple entities are provided in filter are not supported")
+
+	// ErrMissingRetention indicates missing raw data retention period.
+	ErrMissingRetention = errors.New("missing raw data retention period")
+
+	// ErrInvalidRetention indicates that downsampled data expires before raw data.
+	ErrInvalidRetention = errors.New("downsampled data must be retained longer than raw data")
+
+	// ErrInvalidObjectPath indicates malformed path of the LwM2M object, instance or resource.
+	ErrInvalidObjectPath = errors.New("invalid LwM2M object path")
+
+	// ErrMissingAddress indicates missing network address of the device.
+	ErrMissingAddress = errors.New("missing device address")
+
+	// ErrMissingVerificationToken indicates missing email verification token.
+	ErrMissingVerificationToken = errors.New("missing email verification token")
+
+	// ErrMissingPasswordChangeToken indicates missing expired password change token.
+	ErrMissingPasswordChangeToken = errors.New("missing password change token")
+
+	// ErrMissingMFAToken indicates missing multi-factor authentication token.
+	ErrMissingMFAToken = errors.New("missing multi-factor authentication token")
+
+	// ErrMissingMFACode indicates missing multi-factor authentication code.
+	ErrMissingMFACode = errors.New("missing multi-factor authentication code")
+
+	// ErrUnsupportedGrantType indicates unsupported OAuth 2.0 grant type.
+	ErrUnsupportedGrantType = errors.New("unsupported grant type")
+
+	// ErrMissingAssertion indicates missing signed JWT assertion.
+	ErrMissingAssertion = errors.New("missing assertion")
+
+	// ErrInvalidTrustedProxy indicates malformed IP address or CIDR range of the trusted proxy.
+	ErrInvalidTrustedProxy = errors.New("invalid trusted proxy")
 )
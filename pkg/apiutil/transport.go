@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
 	"strconv"
+	"strings"
 
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/hantdev/mitras/pkg/errors"
@@ -118,3 +121,77 @@ func ReadNumQuery[N number](r *http.Request, key string, def N) (N, error) {
 		return def, nil
 	}
 }
+
+// ParseTrustedProxies parses the IP addresses and the CIDR ranges of the
+// trusted proxies.
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, p := range proxies {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.Contains(p, "/") {
+			addr, err := netip.ParseAddr(p)
+			if err != nil {
+				return nil, errors.Wrap(ErrInvalidTrustedProxy, err)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidTrustedProxy, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// ClientIP returns the IP address of the client which sent the request. It's
+// the remote address of the request, unless the request comes from one of the
+// trusted proxies. Then, the X-Forwarded-For addresses are read from right to
+// left, and the client IP is the first one which isn't a trusted proxy, since
+// only the addresses appended by the trusted proxies can't be spoofed.
+func ClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		host = h
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !trusted(ip, trustedProxies) {
+		return host
+	}
+
+	var hops []string
+	for _, fwd := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(fwd, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// The malformed address isn't appended by the trusted proxy,
+			// so the last trusted hop is the client.
+			break
+		}
+		ip = hop
+		if !trusted(ip, trustedProxies) {
+			break
+		}
+	}
+
+	return ip.Unmap().String()
+}
+
+func trusted(ip netip.Addr, trustedProxies []netip.Prefix) bool {
+	ip = ip.Unmap()
+	for _, p := range trustedProxies {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
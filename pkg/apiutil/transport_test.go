@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"net/url"
 	"testing"
 
@@ -359,3 +360,141 @@ func TestLoggingErrorEncoder(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTrustedProxies(t *testing.T) {
+	cases := []struct {
+		desc     string
+		proxies  []string
+		prefixes []netip.Prefix
+		err      error
+	}{
+		{
+			desc:    "parse IP addresses and CIDR ranges",
+			proxies: []string{"10.0.0.1", "192.168.0.0/16", "fd00::1", " 172.16.1.1/12 "},
+			prefixes: []netip.Prefix{
+				netip.MustParsePrefix("10.0.0.1/32"),
+				netip.MustParsePrefix("192.168.0.0/16"),
+				netip.MustParsePrefix("fd00::1/128"),
+				netip.MustParsePrefix("172.16.0.0/12"),
+			},
+		},
+		{
+			desc:    "parse empty proxies",
+			proxies: []string{""},
+		},
+		{
+			desc:    "parse invalid IP address",
+			proxies: []string{"10.0.0"},
+			err:     apiutil.ErrInvalidTrustedProxy,
+		},
+		{
+			desc:    "parse invalid CIDR range",
+			proxies: []string{"10.0.0.0/33"},
+			err:     apiutil.ErrInvalidTrustedProxy,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			prefixes, err := apiutil.ParseTrustedProxies(c.proxies)
+			assert.True(t, errors.Contains(err, c.err), fmt.Sprintf("expected error %v got %v", c.err, err))
+			assert.Equal(t, c.prefixes, prefixes)
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("fd00::/8"),
+	}
+
+	cases := []struct {
+		desc       string
+		remoteAddr string
+		forwarded  []string
+		ip         string
+	}{
+		{
+			desc:       "remote address",
+			remoteAddr: "192.168.0.1:8080",
+			ip:         "192.168.0.1",
+		},
+		{
+			desc:       "remote address without port",
+			remoteAddr: "192.168.0.1",
+			ip:         "192.168.0.1",
+		},
+		{
+			desc:       "IPv6 remote address",
+			remoteAddr: "[::1]:8080",
+			ip:         "::1",
+		},
+		{
+			desc:       "request forwarded by trusted proxy",
+			remoteAddr: "10.0.0.1:8080",
+			forwarded:  []string{"192.168.0.1"},
+			ip:         "192.168.0.1",
+		},
+		{
+			desc:       "request forwarded by untrusted proxy",
+			remoteAddr: "192.168.0.2:8080",
+			forwarded:  []string{"192.168.0.1"},
+			ip:         "192.168.0.2",
+		},
+		{
+			desc:       "request forwarded by multiple trusted proxies",
+			remoteAddr: "10.0.0.1:8080",
+			forwarded:  []string{"192.168.0.1, 10.0.0.2"},
+			ip:         "192.168.0.1",
+		},
+		{
+			desc:       "request with spoofed forwarded address",
+			remoteAddr: "10.0.0.1:8080",
+			forwarded:  []string{"1.2.3.4, 192.168.0.1"},
+			ip:         "192.168.0.1",
+		},
+		{
+			desc:       "request with multiple forwarded headers",
+			remoteAddr: "10.0.0.1:8080",
+			forwarded:  []string{"1.2.3.4", "192.168.0.1, 10.0.0.2"},
+			ip:         "192.168.0.1",
+		},
+		{
+			desc:       "request with malformed forwarded address",
+			remoteAddr: "10.0.0.1:8080",
+			forwarded:  []string{"192.168.0.1, invalid, 10.0.0.2"},
+			ip:         "10.0.0.2",
+		},
+		{
+			desc:       "request forwarded only by trusted proxies",
+			remoteAddr: "10.0.0.1:8080",
+			forwarded:  []string{"10.0.0.3, 10.0.0.2"},
+			ip:         "10.0.0.3",
+		},
+		{
+			desc:       "IPv6 request forwarded by trusted proxy",
+			remoteAddr: "[fd00::1]:8080",
+			forwarded:  []string{"2001:db8::1"},
+			ip:         "2001:db8::1",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = c.remoteAddr
+			for _, fwd := range c.forwarded {
+				req.Header.Add("X-Forwarded-For", fwd)
+			}
+			assert.Equal(t, c.ip, apiutil.ClientIP(req, proxies))
+		})
+	}
+}
+
+func TestClientIPWithoutTrustedProxies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:8080"
+	req.Header.Set("X-Forwarded-For", "192.168.0.1")
+	assert.Equal(t, "10.0.0.1", apiutil.ClientIP(req, nil))
+}
@@ -4,11 +4,24 @@ import (
 	"context"
 )
 
+type sessionKeyType string
+
+// SessionKey is the context key of the authenticated session.
+const SessionKey = sessionKeyType("session")
+
 type Session struct {
 	DomainUserID string
 	UserID       string
 	DomainID     string
 	SuperAdmin   bool
+	// ServiceAccount is true if the session is authenticated with the
+	// token of a service account, in which case UserID is the service
+	// account ID.
+	ServiceAccount bool
+	// ImpersonatorID is the ID of the super admin impersonating the user,
+	// and ReadOnly is true if the impersonation is limited to the reads.
+	ImpersonatorID string
+	ReadOnly       bool
 }
 
 // Authn is mitras authentication library.
@@ -39,5 +39,12 @@ func (a authentication) Authenticate(ctx context.Context, token string) (authn.S
 	if err != nil {
 		return authn.Session{}, errors.Wrap(errors.ErrAuthentication, err)
 	}
-	return authn.Session{DomainUserID: res.GetId(), UserID: res.GetUserId(), DomainID: res.GetDomainId()}, nil
+	return authn.Session{
+		DomainUserID:   res.GetId(),
+		UserID:         res.GetUserId(),
+		DomainID:       res.GetDomainId(),
+		ServiceAccount: res.GetServiceAccount(),
+		ImpersonatorID: res.GetImpersonatorId(),
+		ReadOnly:       res.GetReadOnly(),
+	}, nil
 }
@@ -0,0 +1,272 @@
+package jwks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const (
+	issuerName = "mitras.auth"
+	tokenType  = "type"
+	userField  = "user"
+	domainKey  = "domain"
+
+	serviceAccountField = "service_account"
+	impersonatorField   = "impersonator"
+	readOnlyField       = "read_only"
+	sessionField        = "session"
+
+	// Token types which are verified locally. API keys are verified by the
+	// Auth service, since they can be revoked before they expire.
+	accessKey     = 0
+	refreshKey    = 1
+	recoveryKey   = 2
+	invitationKey = 4
+
+	refreshInterval    = 15 * time.Minute
+	minRefreshInterval = 10 * time.Second
+
+	// The revoked sessions are published by the Auth service next to the
+	// JWKS and reloaded every revokedInterval. If they can't be reloaded
+	// for longer than maxRevokedAge, the tokens of the sessions are
+	// verified by the fallback, so the tokens of a revoked session are
+	// accepted at most maxRevokedAge after the revocation.
+	revokedSessionsPath = "revoked-sessions.json"
+	revokedInterval     = 10 * time.Second
+	maxRevokedAge       = 30 * time.Second
+	revokedTimeout      = 5 * time.Second
+)
+
+var (
+	errInvalidTokenType = errors.New("invalid token type")
+	errUnknownKey       = errors.New("unknown signing key")
+	errSessionRevoked   = errors.New("session is revoked")
+	errRevokedSessions  = errors.New("failed to load revoked sessions")
+)
+
+type authentication struct {
+	url      string
+	cache    *jwk.Cache
+	fallback authn.Authentication
+
+	mu          sync.Mutex
+	refreshedAt time.Time
+
+	revokedURL string
+	client     *http.Client
+
+	revokedMu sync.RWMutex
+	revoked   map[string]struct{}
+	loadedAt  time.Time
+}
+
+var _ authn.Authentication = (*authentication)(nil)
+
+// NewAuthentication returns the authentication which verifies the tokens
+// signed with the asymmetric keys locally, using the public keys published
+// by the Auth service at the JWKS URL. The tokens of the revoked sessions are
+// rejected using the revoked sessions published next to the JWKS, which are
+// reloaded in the background until the context is canceled. The tokens which
+// can't be verified locally, such as the API keys and the tokens signed with
+// the shared secret, are verified by the fallback authentication.
+func NewAuthentication(ctx context.Context, jwksURL string, fallback authn.Authentication) (authn.Authentication, error) {
+	cache := jwk.NewCache(ctx)
+	if err := cache.Register(jwksURL, jwk.WithRefreshInterval(refreshInterval), jwk.WithMinRefreshInterval(minRefreshInterval)); err != nil {
+		return nil, err
+	}
+	if _, err := cache.Refresh(ctx, jwksURL); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(jwksURL)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &authentication{
+		url:         jwksURL,
+		cache:       cache,
+		fallback:    fallback,
+		refreshedAt: time.Now(),
+		revokedURL:  u.ResolveReference(&url.URL{Path: revokedSessionsPath}).String(),
+		client:      &http.Client{Timeout: revokedTimeout},
+	}
+	if err := a.loadRevoked(ctx); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		ticker := time.NewTicker(revokedInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// A failed reload is retried on the next tick, and the
+				// fallback is used once the revoked sessions are stale.
+				_ = a.loadRevoked(ctx)
+			}
+		}
+	}()
+
+	return a, nil
+}
+
+func (a *authentication) Authenticate(ctx context.Context, token string) (authn.Session, error) {
+	msg, err := jws.Parse([]byte(token))
+	if err != nil || len(msg.Signatures()) != 1 {
+		return a.fallback.Authenticate(ctx, token)
+	}
+	kid := msg.Signatures()[0].ProtectedHeaders().KeyID()
+	if kid == "" {
+		return a.fallback.Authenticate(ctx, token)
+	}
+
+	key, err := a.key(ctx, kid)
+	if errors.Contains(err, errUnknownKey) {
+		return a.fallback.Authenticate(ctx, token)
+	}
+	if err != nil {
+		return authn.Session{}, errors.Wrap(errors.ErrAuthentication, err)
+	}
+
+	tkn, err := jwt.Parse([]byte(token), jwt.WithKey(key.Algorithm(), key), jwt.WithValidate(true), jwt.WithIssuer(issuerName))
+	if err != nil {
+		return authn.Session{}, errors.Wrap(errors.ErrAuthentication, err)
+	}
+
+	tType, ok := tkn.Get(tokenType)
+	if !ok {
+		return authn.Session{}, errors.Wrap(errors.ErrAuthentication, errInvalidTokenType)
+	}
+	kt, err := strconv.ParseInt(fmt.Sprintf("%v", tType), 10, 64)
+	if err != nil {
+		return authn.Session{}, errors.Wrap(errors.ErrAuthentication, errInvalidTokenType)
+	}
+	switch kt {
+	case accessKey, refreshKey, recoveryKey, invitationKey:
+	default:
+		return a.fallback.Authenticate(ctx, token)
+	}
+	if session := claim(tkn, sessionField); session != "" {
+		revoked, ok := a.sessionRevoked(session)
+		if !ok {
+			return a.fallback.Authenticate(ctx, token)
+		}
+		if revoked {
+			return authn.Session{}, errors.Wrap(errors.ErrAuthentication, errSessionRevoked)
+		}
+	}
+
+	serviceAccount, _ := tkn.Get(serviceAccountField)
+	readOnly, _ := tkn.Get(readOnlyField)
+
+	return authn.Session{
+		DomainUserID:   tkn.Subject(),
+		UserID:         claim(tkn, userField),
+		DomainID:       claim(tkn, domainKey),
+		ServiceAccount: serviceAccount == true,
+		ImpersonatorID: claim(tkn, impersonatorField),
+		ReadOnly:       readOnly == true,
+	}, nil
+}
+
+// key returns the public key with the given ID. If the key isn't in the
+// cached key set, the key set is refreshed, at most once per minimal refresh
+// interval, to pick up the newly rotated keys.
+func (a *authentication) key(ctx context.Context, kid string) (jwk.Key, error) {
+	set, err := a.cache.Get(ctx, a.url)
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := set.LookupKeyID(kid); ok {
+		return key, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if time.Since(a.refreshedAt) < minRefreshInterval {
+		return nil, errUnknownKey
+	}
+	a.refreshedAt = time.Now()
+	if set, err = a.cache.Refresh(ctx, a.url); err != nil {
+		return nil, err
+	}
+	if key, ok := set.LookupKeyID(kid); ok {
+		return key, nil
+	}
+
+	return nil, errUnknownKey
+}
+
+// sessionRevoked reports whether the session with the given ID is revoked.
+// The second value is false if the revoked sessions are stale.
+func (a *authentication) sessionRevoked(id string) (bool, bool) {
+	a.revokedMu.RLock()
+	defer a.revokedMu.RUnlock()
+	if time.Since(a.loadedAt) > maxRevokedAge {
+		return false, false
+	}
+	_, ok := a.revoked[id]
+
+	return ok, true
+}
+
+func (a *authentication) loadRevoked(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.revokedURL, nil)
+	if err != nil {
+		return errors.Wrap(errRevokedSessions, err)
+	}
+	res, err := a.client.Do(req)
+	if err != nil {
+		return errors.Wrap(errRevokedSessions, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.Wrap(errRevokedSessions, errors.New(res.Status))
+	}
+
+	var body struct {
+		Sessions []struct {
+			ID string `json:"id"`
+		} `json:"sessions"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return errors.Wrap(errRevokedSessions, err)
+	}
+
+	revoked := make(map[string]struct{}, len(body.Sessions))
+	for _, s := range body.Sessions {
+		revoked[s.ID] = struct{}{}
+	}
+
+	a.revokedMu.Lock()
+	defer a.revokedMu.Unlock()
+	a.revoked = revoked
+	a.loadedAt = time.Now()
+
+	return nil
+}
+
+func claim(tkn jwt.Token, name string) string {
+	v, ok := tkn.Get(name)
+	if !ok {
+		return ""
+	}
+	s, _ := v.(string)
+
+	return s
+}
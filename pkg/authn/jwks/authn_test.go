@@ -0,0 +1,242 @@
+package jwks_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/auth"
+	authjwt "github.com/hantdev/mitras/auth/jwt"
+	"github.com/hantdev/mitras/auth/mocks"
+	"github.com/hantdev/mitras/internal/testsutil"
+	"github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/authn/jwks"
+	authnmocks "github.com/hantdev/mitras/pkg/authn/mocks"
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "secret"
+
+func newSigningKeyRepo() *mocks.SigningKeyRepository {
+	var mu sync.Mutex
+	var keys []auth.SigningKey
+
+	repo := new(mocks.SigningKeyRepository)
+	repo.On("Save", mock.Anything, mock.Anything).Return(func(_ context.Context, key auth.SigningKey) error {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, key)
+		return nil
+	})
+	repo.On("RetrieveAll", mock.Anything).Return(func(context.Context) ([]auth.SigningKey, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]auth.SigningKey{}, keys...), nil
+	})
+	repo.On("Extend", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	repo.On("RemoveExpired", mock.Anything).Return(nil)
+
+	return repo
+}
+
+func newTokenizer(t *testing.T) auth.Tokenizer {
+	tokenizer, err := authjwt.NewKeyTokenizer(authjwt.Config{Algorithm: "ES256", Rotation: time.Hour, GracePeriod: time.Hour, KEK: "key-encryption-key"}, newSigningKeyRepo(), []byte(secret))
+	require.Nil(t, err, fmt.Sprintf("new tokenizer unexpected error: %s", err))
+	return tokenizer
+}
+
+func issue(t *testing.T, tokenizer auth.Tokenizer, key auth.Key) string {
+	tkn, err := tokenizer.Issue(key)
+	require.Nil(t, err, fmt.Sprintf("issue token unexpected error: %s", err))
+	return tkn
+}
+
+// newServer returns the server which publishes the JWKS of the tokenizer
+// and the revoked sessions, like the Auth service.
+func newServer(tokenizer auth.Tokenizer, revoked ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/revoked-sessions.json" {
+			sessions := []map[string]string{}
+			for _, id := range revoked {
+				sessions = append(sessions, map[string]string{"id": id})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"sessions": sessions})
+			return
+		}
+		keys, err := tokenizer.RetrieveJWKS()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+}
+
+func TestAuthenticate(t *testing.T) {
+	tokenizer := newTokenizer(t)
+	revokedSession := testsutil.GenerateUUID(t)
+	ts := newServer(tokenizer, revokedSession)
+	defer ts.Close()
+
+	userID := testsutil.GenerateUUID(t)
+	subject := testsutil.GenerateUUID(t)
+	now := time.Now().UTC()
+	key := auth.Key{
+		ID:        testsutil.GenerateUUID(t),
+		Type:      auth.AccessKey,
+		Issuer:    "mitras.auth",
+		Subject:   subject,
+		User:      userID,
+		Session:   testsutil.GenerateUUID(t),
+		IssuedAt:  now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+	apiKey := key
+	apiKey.Type = auth.APIKey
+	serviceAccount := key
+	serviceAccount.ServiceAccount = true
+	impersonation := key
+	impersonation.Impersonator = "admin"
+	impersonation.ReadOnly = true
+	expired := key
+	expired.IssuedAt = now.Add(-2 * time.Hour)
+	expired.ExpiresAt = now.Add(-time.Hour)
+	revoked := key
+	revoked.Session = revokedSession
+
+	fallbackSession := authn.Session{DomainUserID: subject, UserID: userID}
+
+	cases := []struct {
+		desc     string
+		token    string
+		fallback bool
+		session  authn.Session
+		err      error
+	}{
+		{
+			desc:    "authenticate access token locally",
+			token:   issue(t, tokenizer, key),
+			session: authn.Session{DomainUserID: subject, UserID: userID},
+		},
+		{
+			desc:    "authenticate service account token locally",
+			token:   issue(t, tokenizer, serviceAccount),
+			session: authn.Session{DomainUserID: subject, UserID: userID, ServiceAccount: true},
+		},
+		{
+			desc:    "authenticate impersonation token locally",
+			token:   issue(t, tokenizer, impersonation),
+			session: authn.Session{DomainUserID: subject, UserID: userID, ImpersonatorID: "admin", ReadOnly: true},
+		},
+		{
+			desc:     "authenticate API key with fallback",
+			token:    issue(t, tokenizer, apiKey),
+			fallback: true,
+			session:  fallbackSession,
+		},
+		{
+			desc:  "authenticate token of revoked session",
+			token: issue(t, tokenizer, revoked),
+			err:   errors.ErrAuthentication,
+		},
+		{
+			desc:     "authenticate token signed with shared secret with fallback",
+			token:    issue(t, authjwt.New([]byte(secret)), key),
+			fallback: true,
+			session:  fallbackSession,
+		},
+		{
+			desc:     "authenticate token signed with unknown key with fallback",
+			token:    issue(t, newTokenizer(t), key),
+			fallback: true,
+			session:  fallbackSession,
+		},
+		{
+			desc:     "authenticate invalid token with fallback",
+			token:    "invalid",
+			fallback: true,
+			err:      errors.ErrAuthentication,
+		},
+		{
+			desc:  "authenticate expired token",
+			token: issue(t, tokenizer, expired),
+			err:   errors.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			fallback := new(authnmocks.Authentication)
+			fallback.On("Authenticate", mock.Anything, tc.token).Return(tc.session, tc.err)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			a, err := jwks.NewAuthentication(ctx, ts.URL, fallback)
+			require.Nil(t, err, fmt.Sprintf("new authentication unexpected error: %s", err))
+
+			session, err := a.Authenticate(context.Background(), tc.token)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("expected error %s, got %s", tc.err, err))
+			assert.Equal(t, tc.session, session)
+			if tc.fallback {
+				fallback.AssertCalled(t, "Authenticate", mock.Anything, tc.token)
+			} else {
+				fallback.AssertNotCalled(t, "Authenticate", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestNewAuthentication(t *testing.T) {
+	tokenizer := newTokenizer(t)
+	jwksServer := newServer(tokenizer)
+	defer jwksServer.Close()
+
+	cases := []struct {
+		desc   string
+		status int
+		path   string
+		err    bool
+	}{
+		{
+			desc: "new authentication",
+		},
+		{
+			desc:   "new authentication with unavailable JWKS",
+			status: http.StatusServiceUnavailable,
+			path:   "/jwks.json",
+			err:    true,
+		},
+		{
+			desc:   "new authentication with unavailable revoked sessions",
+			status: http.StatusServiceUnavailable,
+			path:   "/revoked-sessions.json",
+			err:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == tc.path {
+					w.WriteHeader(tc.status)
+					return
+				}
+				jwksServer.Config.Handler.ServeHTTP(w, r)
+			}))
+			defer ts.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			_, err := jwks.NewAuthentication(ctx, ts.URL+"/jwks.json", new(authnmocks.Authentication))
+			assert.Equal(t, tc.err, err != nil, fmt.Sprintf("%s: unexpected error %v", tc.desc, err))
+		})
+	}
+}
@@ -3,6 +3,8 @@ package events
 import (
 	"context"
 	"time"
+
+	"github.com/hantdev/mitras/pkg/authn"
 )
 
 const (
@@ -53,6 +55,35 @@ type Subscriber interface {
 	Close() error
 }
 
+// Possible actor types of the events.
+const (
+	UserActor           = "user"
+	ServiceAccountActor = "service_account"
+)
+
+// AddActor adds the user or the service account who caused the event, taken
+// from the authenticated session of the request, to the encoded event. The
+// events caused without the session, e.g. on login, are left unchanged. The
+// events caused during the impersonation are attributed to both the
+// impersonated user and the super admin impersonating the user.
+func AddActor(ctx context.Context, values map[string]interface{}) {
+	session, ok := ctx.Value(authn.SessionKey).(authn.Session)
+	if !ok || session.UserID == "" {
+		return
+	}
+	values["actor_id"] = session.UserID
+	values["actor_type"] = UserActor
+	if session.ServiceAccount {
+		values["actor_type"] = ServiceAccountActor
+	}
+	if session.DomainID != "" {
+		values["actor_domain"] = session.DomainID
+	}
+	if session.ImpersonatorID != "" {
+		values["impersonator_id"] = session.ImpersonatorID
+	}
+}
+
 // Read reads value from event map.
 // If value is not of type T, returns default value.
 func Read[T any](event map[string]interface{}, key string, def T) T {
@@ -0,0 +1,72 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddActor(t *testing.T) {
+	cases := []struct {
+		desc     string
+		ctx      context.Context
+		expected map[string]interface{}
+	}{
+		{
+			desc:     "add actor without session",
+			ctx:      context.Background(),
+			expected: map[string]interface{}{"operation": "user.update"},
+		},
+		{
+			desc: "add user actor",
+			ctx:  context.WithValue(context.Background(), authn.SessionKey, authn.Session{UserID: "user", DomainID: "domain"}),
+			expected: map[string]interface{}{
+				"operation":    "user.update",
+				"actor_id":     "user",
+				"actor_type":   events.UserActor,
+				"actor_domain": "domain",
+			},
+		},
+		{
+			desc: "add user actor without domain",
+			ctx:  context.WithValue(context.Background(), authn.SessionKey, authn.Session{UserID: "user"}),
+			expected: map[string]interface{}{
+				"operation":  "user.update",
+				"actor_id":   "user",
+				"actor_type": events.UserActor,
+			},
+		},
+		{
+			desc: "add service account actor",
+			ctx:  context.WithValue(context.Background(), authn.SessionKey, authn.Session{UserID: "sa", DomainID: "domain", ServiceAccount: true}),
+			expected: map[string]interface{}{
+				"operation":    "user.update",
+				"actor_id":     "sa",
+				"actor_type":   events.ServiceAccountActor,
+				"actor_domain": "domain",
+			},
+		},
+		{
+			desc: "add impersonated user actor",
+			ctx:  context.WithValue(context.Background(), authn.SessionKey, authn.Session{UserID: "user", DomainID: "domain", ImpersonatorID: "admin"}),
+			expected: map[string]interface{}{
+				"operation":       "user.update",
+				"actor_id":        "user",
+				"actor_type":      events.UserActor,
+				"actor_domain":    "domain",
+				"impersonator_id": "admin",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			values := map[string]interface{}{"operation": "user.update"}
+			events.AddActor(tc.ctx, values)
+			assert.Equal(t, tc.expected, values)
+		})
+	}
+}
@@ -42,6 +42,7 @@ func (es *pubEventStore) Publish(ctx context.Context, event events.Event) error
 		return err
 	}
 	values["occurred_at"] = time.Now().UnixNano()
+	events.AddActor(ctx, values)
 
 	data, err := json.Marshal(values)
 	if err != nil {
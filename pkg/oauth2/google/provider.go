@@ -72,8 +72,20 @@ func (cfg *config) IsEnabled() bool {
 	return cfg.config.ClientID != "" && cfg.config.ClientSecret != ""
 }
 
-func (cfg *config) Exchange(ctx context.Context, code string) (oauth2.Token, error) {
-	token, err := cfg.config.Exchange(ctx, code)
+func (cfg *config) AuthCodeURL(verifier string) string {
+	if verifier == "" {
+		return cfg.config.AuthCodeURL(cfg.state)
+	}
+
+	return cfg.config.AuthCodeURL(cfg.state, oauth2.S256ChallengeOption(verifier))
+}
+
+func (cfg *config) Exchange(ctx context.Context, code, verifier string) (oauth2.Token, error) {
+	opts := []oauth2.AuthCodeOption{}
+	if verifier != "" {
+		opts = append(opts, oauth2.VerifierOption(verifier))
+	}
+	token, err := cfg.config.Exchange(ctx, code, opts...)
 	if err != nil {
 		return oauth2.Token{}, err
 	}
@@ -81,20 +93,20 @@ func (cfg *config) Exchange(ctx context.Context, code string) (oauth2.Token, err
 	return *token, nil
 }
 
-func (cfg *config) UserInfo(accessToken string) (uclient.User, error) {
+func (cfg *config) UserInfo(accessToken string) (uclient.User, []uclient.DomainRole, error) {
 	resp, err := http.Get(userInfoURL + url.QueryEscape(accessToken))
 	if err != nil {
-		return uclient.User{}, err
+		return uclient.User{}, nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return uclient.User{}, svcerr.ErrAuthentication
+		return uclient.User{}, nil, svcerr.ErrAuthentication
 	}
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return uclient.User{}, err
+		return uclient.User{}, nil, err
 	}
 
 	var user struct {
@@ -103,14 +115,15 @@ func (cfg *config) UserInfo(accessToken string) (uclient.User, error) {
 		LastName  string `json:"last_name"`
 		Username  string `json:"username"`
 		Email     string `json:"email"`
+		Verified  bool   `json:"verified_email"`
 		Picture   string `json:"picture"`
 	}
 	if err := json.Unmarshal(data, &user); err != nil {
-		return uclient.User{}, err
+		return uclient.User{}, nil, err
 	}
 
 	if user.ID == "" || user.FirstName == "" || user.LastName == "" || user.Email == "" {
-		return uclient.User{}, svcerr.ErrAuthentication
+		return uclient.User{}, nil, svcerr.ErrAuthentication
 	}
 
 	client := uclient.User{
@@ -120,10 +133,16 @@ func (cfg *config) UserInfo(accessToken string) (uclient.User, error) {
 		Email:     user.Email,
 		Metadata: map[string]interface{}{
 			"oauth_provider":  providerName,
+			"oauth_subject":   user.ID,
 			"profile_picture": user.Picture,
 		},
 		Status: uclient.EnabledStatus,
 	}
+	// The users with the same email are linked only if Google verified
+	// the email.
+	if user.Verified {
+		client.VerifiedAt = time.Now()
+	}
 
-	return client, nil
+	return client, nil, nil
 }
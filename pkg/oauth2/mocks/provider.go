@@ -17,6 +17,24 @@ type Provider struct {
 	mock.Mock
 }
 
+// AuthCodeURL provides a mock function with given fields: verifier
+func (_m *Provider) AuthCodeURL(verifier string) string {
+	ret := _m.Called(verifier)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AuthCodeURL")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(verifier)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
 // ErrorURL provides a mock function with no fields
 func (_m *Provider) ErrorURL() string {
 	ret := _m.Called()
@@ -35,9 +53,9 @@ func (_m *Provider) ErrorURL() string {
 	return r0
 }
 
-// Exchange provides a mock function with given fields: ctx, code
-func (_m *Provider) Exchange(ctx context.Context, code string) (xoauth2.Token, error) {
-	ret := _m.Called(ctx, code)
+// Exchange provides a mock function with given fields: ctx, code, verifier
+func (_m *Provider) Exchange(ctx context.Context, code string, verifier string) (xoauth2.Token, error) {
+	ret := _m.Called(ctx, code, verifier)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Exchange")
@@ -45,17 +63,17 @@ func (_m *Provider) Exchange(ctx context.Context, code string) (xoauth2.Token, e
 
 	var r0 xoauth2.Token
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (xoauth2.Token, error)); ok {
-		return rf(ctx, code)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (xoauth2.Token, error)); ok {
+		return rf(ctx, code, verifier)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) xoauth2.Token); ok {
-		r0 = rf(ctx, code)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) xoauth2.Token); ok {
+		r0 = rf(ctx, code, verifier)
 	} else {
 		r0 = ret.Get(0).(xoauth2.Token)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, code)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, code, verifier)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -136,7 +154,7 @@ func (_m *Provider) State() string {
 }
 
 // UserInfo provides a mock function with given fields: accessToken
-func (_m *Provider) UserInfo(accessToken string) (users.User, error) {
+func (_m *Provider) UserInfo(accessToken string) (users.User, []users.DomainRole, error) {
 	ret := _m.Called(accessToken)
 
 	if len(ret) == 0 {
@@ -144,8 +162,9 @@ func (_m *Provider) UserInfo(accessToken string) (users.User, error) {
 	}
 
 	var r0 users.User
-	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (users.User, error)); ok {
+	var r1 []users.DomainRole
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string) (users.User, []users.DomainRole, error)); ok {
 		return rf(accessToken)
 	}
 	if rf, ok := ret.Get(0).(func(string) users.User); ok {
@@ -154,13 +173,21 @@ func (_m *Provider) UserInfo(accessToken string) (users.User, error) {
 		r0 = ret.Get(0).(users.User)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
+	if rf, ok := ret.Get(1).(func(string) []users.DomainRole); ok {
 		r1 = rf(accessToken)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]users.DomainRole)
+		}
 	}
 
-	return r0, r1
+	if rf, ok := ret.Get(2).(func(string) error); ok {
+		r2 = rf(accessToken)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // NewProvider creates a new instance of Provider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
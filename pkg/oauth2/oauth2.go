@@ -35,9 +35,15 @@ type Provider interface {
 	// IsEnabled checks if the OAuth2 provider is enabled.
 	IsEnabled() bool
 
-	// Exchange converts an authorization code into a token.
-	Exchange(ctx context.Context, code string) (oauth2.Token, error)
+	// AuthCodeURL returns the URL of the provider's consent page. The PKCE
+	// challenge is derived from the verifier, unless the verifier is empty.
+	AuthCodeURL(verifier string) string
 
-	// UserInfo retrieves the user's information using the access token.
-	UserInfo(accessToken string) (users.User, error)
+	// Exchange converts an authorization code into a token. The verifier is
+	// the PKCE code verifier of the authorization request, if any.
+	Exchange(ctx context.Context, code, verifier string) (oauth2.Token, error)
+
+	// UserInfo retrieves the user's information and the domain roles mapped
+	// from the user's groups using the access token.
+	UserInfo(accessToken string) (users.User, []users.DomainRole, error)
 }
@@ -0,0 +1,3 @@
+// Package oidc contains the domain concept definitions needed to support
+// mitras services for the generic OpenID Connect OAuth2 functionality.
+package oidc
@@ -0,0 +1,302 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	mgoauth2 "github.com/hantdev/mitras/pkg/oauth2"
+	uclient "github.com/hantdev/mitras/users"
+	"golang.org/x/oauth2"
+)
+
+const (
+	defTimeout   = 1 * time.Minute
+	discoveryURI = "/.well-known/openid-configuration"
+)
+
+var (
+	errDiscovery      = errors.New("failed to discover OpenID Connect provider")
+	errIssuerMismatch = errors.New("OpenID Connect issuer does not match the configured issuer")
+	errEndpoints      = errors.New("discovery document is missing the provider endpoints")
+	errMissingClaim   = errors.New("missing required claim")
+	errEmailVerified  = errors.New("email is not verified by the OpenID Connect provider")
+)
+
+// Config is the configuration of the OpenID Connect provider.
+type Config struct {
+	mgoauth2.Config
+
+	// IssuerURL is the provider's issuer, used to fetch its discovery document.
+	IssuerURL string   `env:"ISSUER_URL"             envDefault:""`
+	Scopes    []string `env:"SCOPES"                 envDefault:"openid,email,profile"`
+
+	// The claims are the names of the userinfo claims the user fields are
+	// mapped from. Nested claims are separated by a dot, e.g. "realm_access.roles".
+	EmailClaim     string `env:"EMAIL_CLAIM"            envDefault:"email"`
+	FirstNameClaim string `env:"FIRST_NAME_CLAIM"       envDefault:"given_name"`
+	LastNameClaim  string `env:"LAST_NAME_CLAIM"        envDefault:"family_name"`
+	PictureClaim   string `env:"PICTURE_CLAIM"          envDefault:"picture"`
+	GroupsClaim    string `env:"GROUPS_CLAIM"           envDefault:"groups"`
+
+	// RequireVerifiedEmail rejects the users whose email_verified claim is not true.
+	RequireVerifiedEmail bool `env:"REQUIRE_VERIFIED_EMAIL" envDefault:"true"`
+
+	// DomainRoles maps the provider groups to the domain roles. Each mapping
+	// has the "group:domain_id:role" format.
+	DomainRoles []string `env:"DOMAIN_ROLES"           envDefault:""`
+}
+
+// discovery is the subset of the provider metadata used by the provider.
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+var _ mgoauth2.Provider = (*provider)(nil)
+
+type provider struct {
+	name          string
+	config        *oauth2.Config
+	cfg           Config
+	userInfoURL   string
+	roles         map[string][]uclient.DomainRole
+	client        *http.Client
+	uiRedirectURL string
+	errorURL      string
+}
+
+// NewProvider returns a new OpenID Connect provider with the given name. The
+// provider endpoints are fetched from the issuer's discovery document.
+func NewProvider(ctx context.Context, name string, cfg Config, uiRedirectURL, errorURL string) (mgoauth2.Provider, error) {
+	roles, err := uclient.ParseDomainRoles(cfg.DomainRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: defTimeout}
+	d, err := discover(ctx, client, cfg.IssuerURL)
+	if err != nil {
+		return nil, errors.Wrap(errDiscovery, err)
+	}
+
+	return &provider{
+		name: name,
+		config: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  d.AuthorizationEndpoint,
+				TokenURL: d.TokenEndpoint,
+			},
+			RedirectURL: cfg.RedirectURL,
+			Scopes:      cfg.Scopes,
+		},
+		cfg:           cfg,
+		userInfoURL:   d.UserInfoEndpoint,
+		roles:         roles,
+		client:        client,
+		uiRedirectURL: uiRedirectURL,
+		errorURL:      errorURL,
+	}, nil
+}
+
+func (p *provider) Name() string {
+	return p.name
+}
+
+func (p *provider) State() string {
+	return p.cfg.State
+}
+
+func (p *provider) RedirectURL() string {
+	return p.uiRedirectURL
+}
+
+func (p *provider) ErrorURL() string {
+	return p.errorURL
+}
+
+func (p *provider) IsEnabled() bool {
+	return p.config.ClientID != "" && p.config.ClientSecret != ""
+}
+
+func (p *provider) AuthCodeURL(verifier string) string {
+	if verifier == "" {
+		return p.config.AuthCodeURL(p.cfg.State)
+	}
+
+	return p.config.AuthCodeURL(p.cfg.State, oauth2.S256ChallengeOption(verifier))
+}
+
+func (p *provider) Exchange(ctx context.Context, code, verifier string) (oauth2.Token, error) {
+	opts := []oauth2.AuthCodeOption{}
+	if verifier != "" {
+		opts = append(opts, oauth2.VerifierOption(verifier))
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	token, err := p.config.Exchange(ctx, code, opts...)
+	if err != nil {
+		return oauth2.Token{}, err
+	}
+
+	return *token, nil
+}
+
+func (p *provider) UserInfo(accessToken string) (uclient.User, []uclient.DomainRole, error) {
+	req, err := http.NewRequest(http.MethodGet, p.userInfoURL, nil)
+	if err != nil {
+		return uclient.User{}, nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return uclient.User{}, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return uclient.User{}, nil, svcerr.ErrAuthentication
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return uclient.User{}, nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return uclient.User{}, nil, err
+	}
+
+	sub := stringClaim(claims, "sub")
+	email := stringClaim(claims, p.cfg.EmailClaim)
+	if sub == "" || email == "" {
+		return uclient.User{}, nil, errors.Wrap(svcerr.ErrAuthentication, errMissingClaim)
+	}
+	verified := boolClaim(claims, "email_verified")
+	if p.cfg.RequireVerifiedEmail && !verified {
+		return uclient.User{}, nil, errors.Wrap(svcerr.ErrAuthentication, errEmailVerified)
+	}
+
+	user := uclient.User{
+		ID:        sub,
+		FirstName: stringClaim(claims, p.cfg.FirstNameClaim),
+		LastName:  stringClaim(claims, p.cfg.LastNameClaim),
+		Email:     email,
+		Metadata: map[string]interface{}{
+			"oauth_provider":  p.name,
+			"oauth_subject":   sub,
+			"profile_picture": stringClaim(claims, p.cfg.PictureClaim),
+		},
+		Status: uclient.EnabledStatus,
+	}
+	// The users with the same email are linked only if the provider
+	// verified the email.
+	if verified {
+		user.VerifiedAt = time.Now()
+	}
+
+	roles := []uclient.DomainRole{}
+	for _, group := range stringsClaim(claims, p.cfg.GroupsClaim) {
+		roles = append(roles, p.roles[group]...)
+	}
+
+	return user, roles, nil
+}
+
+// discover fetches the discovery document of the issuer and validates it.
+func discover(ctx context.Context, client *http.Client, issuer string) (discovery, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+discoveryURI, nil)
+	if err != nil {
+		return discovery{}, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return discovery{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return discovery{}, fmt.Errorf("unexpected discovery response status: %s", resp.Status)
+	}
+
+	var d discovery
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return discovery{}, err
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != issuer {
+		return discovery{}, errIssuerMismatch
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.UserInfoEndpoint == "" {
+		return discovery{}, errEndpoints
+	}
+
+	return d, nil
+}
+
+// claim returns the claim value, following the dot separated path of the
+// nested claims.
+func claim(claims map[string]interface{}, path string) interface{} {
+	if path == "" {
+		return nil
+	}
+	var val interface{} = claims
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := val.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		val = obj[key]
+	}
+
+	return val
+}
+
+func stringClaim(claims map[string]interface{}, path string) string {
+	val, _ := claim(claims, path).(string)
+	return val
+}
+
+// boolClaim returns the boolean claim value. Some providers encode the
+// booleans as strings.
+func boolClaim(claims map[string]interface{}, path string) bool {
+	switch val := claim(claims, path).(type) {
+	case bool:
+		return val
+	case string:
+		return val == "true"
+	default:
+		return false
+	}
+}
+
+// stringsClaim returns the claim value as a list of strings. A single string
+// value is a list of one element.
+func stringsClaim(claims map[string]interface{}, path string) []string {
+	switch val := claim(claims, path).(type) {
+	case string:
+		return []string{val}
+	case []interface{}:
+		vals := []string{}
+		for _, v := range val {
+			if s, ok := v.(string); ok {
+				vals = append(vals, s)
+			}
+		}
+		return vals
+	default:
+		return nil
+	}
+}
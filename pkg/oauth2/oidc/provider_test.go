@@ -0,0 +1,300 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	mgoauth2 "github.com/hantdev/mitras/pkg/oauth2"
+	"github.com/hantdev/mitras/pkg/oauth2/oidc"
+	"github.com/hantdev/mitras/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+const (
+	providerName = "keycloak"
+	clientID     = "mitras"
+	clientSecret = "secret"
+	state        = "state"
+	redirectURL  = "http://localhost/oauth/callback/keycloak"
+	code         = "code"
+	accessToken  = "access-token"
+	domainID     = "c0a8e1e0-8f1a-4bd4-9a8e-4b6f0c1c2d3e"
+)
+
+// server is the in-process mock OpenID Connect provider.
+type server struct {
+	*httptest.Server
+	issuer    string
+	challenge string
+	claims    map[string]interface{}
+}
+
+func newServer(t *testing.T) *server {
+	s := &server{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 s.issuer,
+			"authorization_endpoint": s.URL + "/auth",
+			"token_endpoint":         s.URL + "/token",
+			"userinfo_endpoint":      s.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if id != clientID || secret != clientSecret || r.FormValue("code") != code ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != s.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": accessToken, "token_type": "Bearer"})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+accessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(s.claims)
+	})
+	s.Server = httptest.NewServer(mux)
+	s.issuer = s.URL
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func newConfig(issuer string) oidc.Config {
+	return oidc.Config{
+		Config: mgoauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			State:        state,
+			RedirectURL:  redirectURL,
+		},
+		IssuerURL:            issuer,
+		Scopes:               []string{"openid", "email", "profile"},
+		EmailClaim:           "email",
+		FirstNameClaim:       "given_name",
+		LastNameClaim:        "family_name",
+		PictureClaim:         "picture",
+		GroupsClaim:          "groups",
+		RequireVerifiedEmail: true,
+		DomainRoles:          []string{"/admins:" + domainID + ":admin", "/admins:" + domainID + ":member"},
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	s := newServer(t)
+
+	other := newServer(t)
+	other.issuer = "http://localhost/realms/other"
+	mismatch := newConfig(other.URL)
+	invalidRoles := newConfig(s.URL)
+	invalidRoles.DomainRoles = []string{"admins:admin"}
+
+	cases := []struct {
+		desc string
+		cfg  oidc.Config
+		err  error
+	}{
+		{
+			desc: "create provider with valid config",
+			cfg:  newConfig(s.URL),
+			err:  nil,
+		},
+		{
+			desc: "create provider with issuer trailing slash",
+			cfg:  newConfig(s.URL + "/"),
+			err:  nil,
+		},
+		{
+			desc: "create provider with mismatched issuer",
+			cfg:  mismatch,
+			err:  errors.New("OpenID Connect issuer does not match the configured issuer"),
+		},
+		{
+			desc: "create provider with invalid domain role mapping",
+			cfg:  invalidRoles,
+			err:  errors.New("invalid domain role mapping, expected group:domain_id:role"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			p, err := oidc.NewProvider(context.Background(), providerName, tc.cfg, "ui", "error")
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if tc.err == nil {
+				assert.Equal(t, providerName, p.Name())
+				assert.True(t, p.IsEnabled())
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	s := newServer(t)
+	p, err := oidc.NewProvider(context.Background(), providerName, newConfig(s.URL), "ui", "error")
+	require.Nil(t, err, fmt.Sprintf("create provider unexpected error: %s", err))
+
+	verifier := oauth2.GenerateVerifier()
+	authURL, err := url.Parse(p.AuthCodeURL(verifier))
+	require.Nil(t, err, fmt.Sprintf("parse auth URL unexpected error: %s", err))
+	query := authURL.Query()
+	assert.Equal(t, s.URL+"/auth", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+	assert.Equal(t, clientID, query.Get("client_id"))
+	assert.Equal(t, state, query.Get("state"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	s.challenge = query.Get("code_challenge")
+
+	_, err = p.Exchange(context.Background(), code, oauth2.GenerateVerifier())
+	assert.NotNil(t, err, "exchange with invalid verifier expected error")
+
+	token, err := p.Exchange(context.Background(), code, verifier)
+	require.Nil(t, err, fmt.Sprintf("exchange unexpected error: %s", err))
+	assert.Equal(t, accessToken, token.AccessToken)
+}
+
+func TestUserInfo(t *testing.T) {
+	s := newServer(t)
+	cfg := newConfig(s.URL)
+	p, err := oidc.NewProvider(context.Background(), providerName, cfg, "ui", "error")
+	require.Nil(t, err, fmt.Sprintf("create provider unexpected error: %s", err))
+
+	nested := cfg
+	nested.EmailClaim = "profile.mail"
+	nested.GroupsClaim = "realm_access.roles"
+	nested.RequireVerifiedEmail = false
+	np, err := oidc.NewProvider(context.Background(), providerName, nested, "ui", "error")
+	require.Nil(t, err, fmt.Sprintf("create provider unexpected error: %s", err))
+
+	user := users.User{
+		ID:        "subject",
+		FirstName: "John",
+		LastName:  "Doe",
+		Email:     "john.doe@example.com",
+		Metadata: users.Metadata{
+			"oauth_provider":  providerName,
+			"oauth_subject":   "subject",
+			"profile_picture": "http://localhost/picture.png",
+		},
+		Status: users.EnabledStatus,
+	}
+	adminRoles := []users.DomainRole{{DomainID: domainID, Role: "admin"}, {DomainID: domainID, Role: "member"}}
+
+	cases := []struct {
+		desc     string
+		provider mgoauth2.Provider
+		token    string
+		claims   map[string]interface{}
+		user     users.User
+		roles    []users.DomainRole
+		verified bool
+		err      error
+	}{
+		{
+			desc:     "retrieve user info with mapped groups",
+			provider: p,
+			token:    accessToken,
+			claims: map[string]interface{}{
+				"sub":            "subject",
+				"email":          "john.doe@example.com",
+				"email_verified": true,
+				"given_name":     "John",
+				"family_name":    "Doe",
+				"picture":        "http://localhost/picture.png",
+				"groups":         []interface{}{"/admins", "/others"},
+			},
+			user:     user,
+			roles:    adminRoles,
+			verified: true,
+		},
+		{
+			desc:     "retrieve user info without mapped groups",
+			provider: p,
+			token:    accessToken,
+			claims: map[string]interface{}{
+				"sub":            "subject",
+				"email":          "john.doe@example.com",
+				"email_verified": "true",
+				"given_name":     "John",
+				"family_name":    "Doe",
+				"picture":        "http://localhost/picture.png",
+			},
+			user:     user,
+			roles:    []users.DomainRole{},
+			verified: true,
+		},
+		{
+			desc:     "retrieve user info with nested claims",
+			provider: np,
+			token:    accessToken,
+			claims: map[string]interface{}{
+				"sub":          "subject",
+				"profile":      map[string]interface{}{"mail": "john.doe@example.com"},
+				"given_name":   "John",
+				"family_name":  "Doe",
+				"picture":      "http://localhost/picture.png",
+				"realm_access": map[string]interface{}{"roles": "/admins"},
+			},
+			user:  user,
+			roles: adminRoles,
+		},
+		{
+			desc:     "retrieve user info with unverified email",
+			provider: p,
+			token:    accessToken,
+			claims: map[string]interface{}{
+				"sub":            "subject",
+				"email":          "john.doe@example.com",
+				"email_verified": false,
+			},
+			err: svcerr.ErrAuthentication,
+		},
+		{
+			desc:     "retrieve user info with missing email",
+			provider: p,
+			token:    accessToken,
+			claims: map[string]interface{}{
+				"sub":            "subject",
+				"email_verified": true,
+			},
+			err: svcerr.ErrAuthentication,
+		},
+		{
+			desc:     "retrieve user info with invalid token",
+			provider: p,
+			token:    "invalid",
+			err:      svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s.claims = tc.claims
+			user, roles, err := tc.provider.UserInfo(tc.token)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if tc.err == nil {
+				assert.Equal(t, tc.verified, !user.VerifiedAt.IsZero(), fmt.Sprintf("%s: expected verified email %t", tc.desc, tc.verified))
+				user.VerifiedAt = time.Time{}
+				assert.Equal(t, tc.user, user)
+				assert.Equal(t, tc.roles, roles)
+			}
+		})
+	}
+}
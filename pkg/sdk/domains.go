@@ -19,6 +19,7 @@ type Domain struct {
 	Metadata    Metadata  `json:"metadata,omitempty"`
 	Tags        []string  `json:"tags,omitempty"`
 	Alias       string    `json:"alias,omitempty"`
+	RequireMFA  bool      `json:"require_mfa,omitempty"`
 	Status      string    `json:"status,omitempty"`
 	Permission  string    `json:"permission,omitempty"`
 	CreatedBy   string    `json:"created_by,omitempty"`
@@ -136,6 +136,6 @@ func setupMinimalReader() *httptest.Server {
 	authn := new(authnmocks.Authentication)
 	clients := new(climocks.ClientsServiceClient)
 
-	mux := readersapi.MakeHandler(repo, authn, clients, channels, "test", "")
+	mux := readersapi.MakeHandler(repo, authn, clients, channels, 1000, "test", "")
 	return httptest.NewServer(mux)
 }
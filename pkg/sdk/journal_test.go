@@ -101,6 +101,7 @@ func TestRetrieveJournal(t *testing.T) {
 				Limit:      10,
 				EntityID:   validID,
 				EntityType: journal.ChannelEntity,
+				Domain:     domainID,
 				Direction:  "desc",
 			},
 			svcRes: journal.JournalsPage{
@@ -129,6 +130,7 @@ func TestRetrieveJournal(t *testing.T) {
 				Limit:      10,
 				EntityID:   validID,
 				EntityType: journal.GroupEntity,
+				Domain:     domainID,
 				Direction:  "desc",
 			},
 			svcRes: journal.JournalsPage{
@@ -157,6 +159,7 @@ func TestRetrieveJournal(t *testing.T) {
 				Limit:      10,
 				EntityID:   validID,
 				EntityType: journal.ClientEntity,
+				Domain:     domainID,
 				Direction:  "desc",
 			},
 			svcRes: journal.JournalsPage{
@@ -304,6 +307,7 @@ func TestRetrieveJournal(t *testing.T) {
 				Limit:      10,
 				EntityID:   validID,
 				EntityType: journal.GroupEntity,
+				Domain:     domainID,
 				Direction:  "desc",
 			},
 			svcRes: journal.JournalsPage{
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/hantdev/hermina"
 	proxy "github.com/hantdev/hermina/pkg/http"
@@ -23,6 +24,7 @@ import (
 	pubsub "github.com/hantdev/mitras/pkg/messaging/mocks"
 	sdk "github.com/hantdev/mitras/pkg/sdk"
 	"github.com/hantdev/mitras/pkg/transformers/senml"
+	"github.com/hantdev/mitras/pkg/uuid"
 	"github.com/hantdev/mitras/readers"
 	readersapi "github.com/hantdev/mitras/readers/api"
 	readersmocks "github.com/hantdev/mitras/readers/mocks"
@@ -40,9 +42,11 @@ func setupMessages() (*httptest.Server, *pubsub.PubSub) {
 	channelsGRPCClient = new(chmocks.ChannelsServiceClient)
 	pub := new(pubsub.PubSub)
 	authn := new(authnmocks.Authentication)
-	handler := adapter.NewHandler(pub, authn, clientsGRPCClient, channelsGRPCClient, smqlog.NewMock())
+	handler := adapter.NewHandler(pub, authn, clientsGRPCClient, channelsGRPCClient, 32<<20, smqlog.NewMock())
 
-	mux := api.MakeHandler(smqlog.NewMock(), "")
+	streams := adapter.NewService(pub, authn, clientsGRPCClient, channelsGRPCClient, uuid.NewMock(), 10, time.Minute)
+
+	mux := api.MakeHandler(streams, time.Minute, smqlog.NewMock(), "")
 	target := httptest.NewServer(mux)
 
 	config := hermina.Config{
@@ -63,7 +67,7 @@ func setupReaders() (*httptest.Server, *authnmocks.Authentication, *readersmocks
 	clientsGRPCClient = new(climocks.ClientsServiceClient)
 	channelsGRPCClient = new(chmocks.ChannelsServiceClient)
 
-	mux := readersapi.MakeHandler(repo, authn, clientsGRPCClient, channelsGRPCClient, "test", "")
+	mux := readersapi.MakeHandler(repo, authn, clientsGRPCClient, channelsGRPCClient, 1000, "test", "")
 	return httptest.NewServer(mux), authn, repo
 }
 
@@ -36,6 +36,7 @@ type User struct {
 	Status         string      `json:"status,omitempty"`
 	Role           string      `json:"role,omitempty"`
 	ProfilePicture string      `json:"profile_picture,omitempty"`
+	VerifiedAt     time.Time   `json:"verified_at,omitempty"`
 }
 
 func (sdk mgSDK) CreateUser(user User, token string) (User, errors.SDKError) {
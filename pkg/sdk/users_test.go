@@ -8,7 +8,6 @@ import (
 	"testing"
 
 	"github.com/go-chi/chi/v5"
-	authmocks "github.com/hantdev/mitras/auth/mocks"
 	internalapi "github.com/hantdev/mitras/internal/api"
 	grpcTokenV1 "github.com/hantdev/mitras/internal/grpc/token/v1"
 	smqlog "github.com/hantdev/mitras/logger"
@@ -39,8 +38,7 @@ func setupUsers() (*httptest.Server, *umocks.Service, *authnmocks.Authentication
 	provider := new(oauth2mocks.Provider)
 	provider.On("Name").Return("test")
 	authn := new(authnmocks.Authentication)
-	token := new(authmocks.TokenServiceClient)
-	api.MakeHandler(usvc, authn, token, true, mux, logger, "", passRegex, provider)
+	api.MakeHandler(usvc, authn, true, mux, logger, "", passRegex, nil, provider)
 
 	return httptest.NewServer(mux), usvc, authn
 }
@@ -253,12 +251,12 @@ func TestCreateUser(t *testing.T) {
 	}
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
-			svcCall := svc.On("Register", mock.Anything, smqauthn.Session{}, tc.svcReq, true).Return(tc.svcRes, tc.svcErr)
+			svcCall := svc.On("Register", mock.Anything, smqauthn.Session{}, tc.svcReq, true, "").Return(tc.svcRes, tc.svcErr)
 			resp, err := mgsdk.CreateUser(tc.createSdkUserReq, tc.token)
 			assert.Equal(t, tc.err, err)
 			assert.Equal(t, tc.response, resp)
 			if tc.err == nil {
-				ok := svcCall.Parent.AssertCalled(t, "Register", mock.Anything, authn.Session{}, tc.svcReq, true)
+				ok := svcCall.Parent.AssertCalled(t, "Register", mock.Anything, authn.Session{}, tc.svcReq, true, "")
 				assert.True(t, ok)
 			}
 			svcCall.Unset()
@@ -1400,12 +1398,12 @@ func TestResetPasswordRequest(t *testing.T) {
 	}
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
-			svcCall := svc.On("GenerateResetToken", mock.Anything, tc.email, defHost).Return(tc.svcErr)
+			svcCall := svc.On("GenerateResetToken", mock.Anything, tc.email, defHost, mock.Anything).Return(tc.svcErr)
 			svcCall1 := svc.On("SendPasswordReset", mock.Anything, mock.Anything, tc.email, user.Credentials.Username, tc.issueRes.AccessToken).Return(nil)
 			err := mgsdk.ResetPasswordRequest(tc.email)
 			assert.Equal(t, tc.err, err)
 			if tc.err == nil {
-				ok := svcCall.Parent.AssertCalled(t, "GenerateResetToken", mock.Anything, tc.email, defHost)
+				ok := svcCall.Parent.AssertCalled(t, "GenerateResetToken", mock.Anything, tc.email, defHost, mock.Anything)
 				assert.True(t, ok)
 			}
 			svcCall.Unset()
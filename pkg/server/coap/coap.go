@@ -2,39 +2,156 @@ package coap
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
+	"os"
 	"time"
 
 	"github.com/hantdev/mitras/pkg/server"
-	gocoap "github.com/plgd-dev/go-coap/v3"
+	piondtls "github.com/pion/dtls/v3"
+	"github.com/plgd-dev/go-coap/v3/dtls"
 	"github.com/plgd-dev/go-coap/v3/mux"
+	coapnet "github.com/plgd-dev/go-coap/v3/net"
+	"github.com/plgd-dev/go-coap/v3/net/blockwise"
+	"github.com/plgd-dev/go-coap/v3/options"
+	"github.com/plgd-dev/go-coap/v3/tcp"
+	"github.com/plgd-dev/go-coap/v3/udp"
 )
 
+const (
+	coapProtocol     = "coap"
+	coapsProtocol    = "coaps"
+	coapTCPProtocol  = "coap+tcp"
+	coapsTCPProtocol = "coaps+tcp"
+	coapWSProtocol   = "coap+ws"
+	coapsWSProtocol  = "coaps+ws"
+
+	defBlockSize                = 1024
+	defBlockwiseTransferTimeout = 3 * time.Second
+	defMaxMessageSize           = 64 * 1024
+)
+
+// CoAP transports served by the server.
+const (
+	// UDP transport serves CoAP over UDP, or over DTLS when configured.
+	UDP = "udp"
+	// TCP transport serves CoAP over TCP, or over TLS when configured, as
+	// defined in RFC 8323.
+	TCP = "tcp"
+	// WS transport serves CoAP over WebSockets, or over secure WebSockets
+	// when configured, as defined in RFC 8323.
+	WS = "ws"
+)
+
+var (
+	certCipherSuites = []piondtls.CipherSuiteID{
+		piondtls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		piondtls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		piondtls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		piondtls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		piondtls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+		piondtls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	}
+	pskCipherSuites = []piondtls.CipherSuiteID{
+		piondtls.TLS_PSK_WITH_AES_128_CCM,
+		piondtls.TLS_PSK_WITH_AES_128_CCM_8,
+		piondtls.TLS_PSK_WITH_AES_128_GCM_SHA256,
+	}
+)
+
+// PSKFunc returns the pre-shared key of the DTLS PSK identity.
+type PSKFunc func(identity []byte) ([]byte, error)
+
+// Option configures the CoAP server.
+type Option func(*coapServer)
+
+// WithPSK enables DTLS pre-shared key authentication using the given
+// callback to resolve the key of the PSK identity.
+func WithPSK(psk PSKFunc) Option {
+	return func(s *coapServer) {
+		s.psk = psk
+	}
+}
+
+// WithBlockwise sets the block size and the transfer timeout of block-wise
+// transfers, as defined in RFC 7959. The block size must be a power of two
+// between 16 and 1024 bytes.
+func WithBlockwise(blockSize int, transferTimeout time.Duration) Option {
+	return func(s *coapServer) {
+		s.blockSize = blockSize
+		s.transferTimeout = transferTimeout
+	}
+}
+
+// WithMaxMessageSize sets the maximum size in bytes of a received CoAP
+// message, including WebSocket messages of CoAP over WebSockets.
+func WithMaxMessageSize(size uint32) Option {
+	return func(s *coapServer) {
+		s.maxMessageSize = size
+	}
+}
+
+// WithTransport sets the transport of the server. UDP is used by default.
+func WithTransport(transport string) Option {
+	return func(s *coapServer) {
+		s.transport = transport
+	}
+}
+
 type coapServer struct {
 	server.BaseServer
-	handler mux.HandlerFunc
+	handler         mux.HandlerFunc
+	psk             PSKFunc
+	transport       string
+	blockSize       int
+	transferTimeout time.Duration
+	maxMessageSize  uint32
+	stop            func()
 }
 
 var _ server.Server = (*coapServer)(nil)
 
-func NewServer(ctx context.Context, cancel context.CancelFunc, name string, config server.Config, handler mux.HandlerFunc, logger *slog.Logger) server.Server {
+func NewServer(ctx context.Context, cancel context.CancelFunc, name string, config server.Config, handler mux.HandlerFunc, logger *slog.Logger, opts ...Option) server.Server {
 	baseServer := server.NewBaseServer(ctx, cancel, name, config, logger)
 
-	return &coapServer{
-		BaseServer: baseServer,
-		handler:    handler,
+	s := &coapServer{
+		BaseServer:      baseServer,
+		handler:         handler,
+		transport:       UDP,
+		blockSize:       defBlockSize,
+		transferTimeout: defBlockwiseTransferTimeout,
+		maxMessageSize:  defMaxMessageSize,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 func (s *coapServer) Start() error {
-	errCh := make(chan error)
-	s.Logger.Info(fmt.Sprintf("%s service started using http, exposed port %s", s.Name, s.Address))
-	s.Logger.Info(fmt.Sprintf("%s service %s server listening at %s without TLS", s.Name, s.Protocol, s.Address))
+	szx, err := blockSZX(s.blockSize)
+	if err != nil {
+		return err
+	}
+	bw := options.WithBlockwise(true, szx, s.transferTimeout)
 
-	go func() {
-		errCh <- gocoap.ListenAndServe("udp", s.Address, s.handler)
-	}()
+	errCh := make(chan error)
+	switch s.transport {
+	case TCP:
+		err = s.startTCP(errCh, bw)
+	case WS:
+		err = s.startWS(errCh, bw)
+	default:
+		err = s.startUDP(errCh, bw)
+	}
+	if err != nil {
+		return err
+	}
 
 	select {
 	case <-s.Ctx.Done():
@@ -47,11 +164,237 @@ func (s *coapServer) Start() error {
 func (s *coapServer) Stop() error {
 	defer s.Cancel()
 	c := make(chan bool)
-	defer close(c)
+	go func() {
+		defer close(c)
+		if s.stop != nil {
+			s.stop()
+		}
+	}()
 	select {
 	case <-c:
 	case <-time.After(server.StopWaitTime):
 	}
-	s.Logger.Info(fmt.Sprintf("%s service shutdown of http at %s", s.Name, s.Address))
+	s.Logger.Info(fmt.Sprintf("%s service shutdown of %s at %s", s.Name, s.Protocol, s.Address))
 	return nil
 }
+
+func (s *coapServer) startUDP(errCh chan error, bw options.BlockwiseOpt) error {
+	s.Protocol = coapProtocol
+	if s.Config.CertFile == "" && s.Config.KeyFile == "" && s.psk == nil {
+		listener, err := coapnet.NewListenUDP(UDP, s.Address)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", s.Address, err)
+		}
+		srv := udp.NewServer(options.WithMux(s.handler), bw, options.WithMaxMessageSize(s.maxMessageSize))
+		s.stop = func() {
+			srv.Stop()
+			_ = listener.Close()
+		}
+		s.Logger.Info(fmt.Sprintf("%s service %s server listening at %s without DTLS", s.Name, s.Protocol, s.Address))
+		go func() {
+			errCh <- srv.Serve(listener)
+		}()
+		return nil
+	}
+
+	s.Protocol = coapsProtocol
+	dtlsConfig, mode, err := s.dtlsConfig()
+	if err != nil {
+		return err
+	}
+	listener, err := coapnet.NewDTLSListener(UDP, s.Address, dtlsConfig)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.Address, err)
+	}
+	srv := dtls.NewServer(options.WithMux(s.handler), bw, options.WithMaxMessageSize(s.maxMessageSize))
+	s.stop = func() {
+		srv.Stop()
+		_ = listener.Close()
+	}
+	s.Logger.Info(fmt.Sprintf("%s service %s server listening at %s with DTLS %s", s.Name, s.Protocol, s.Address, mode))
+	go func() {
+		errCh <- srv.Serve(listener)
+	}()
+
+	return nil
+}
+
+// startTCP starts the CoAP over TCP server. Signaling messages, such as
+// CSM and Ping/Pong, are handled by the TCP server.
+func (s *coapServer) startTCP(errCh chan error, bw options.BlockwiseOpt) error {
+	var listener tcpListener
+	var err error
+	s.Protocol = coapTCPProtocol
+	switch {
+	case s.Config.CertFile != "" || s.Config.KeyFile != "":
+		s.Protocol = coapsTCPProtocol
+		tlsConfig, mode, err := s.tlsConfig()
+		if err != nil {
+			return err
+		}
+		if listener, err = coapnet.NewTLSListener(TCP, s.Address, tlsConfig); err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", s.Address, err)
+		}
+		s.Logger.Info(fmt.Sprintf("%s service %s server listening at %s with TLS %s", s.Name, s.Protocol, s.Address, mode))
+	default:
+		if listener, err = coapnet.NewTCPListener(TCP, s.Address); err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", s.Address, err)
+		}
+		s.Logger.Info(fmt.Sprintf("%s service %s server listening at %s without TLS", s.Name, s.Protocol, s.Address))
+	}
+
+	srv := tcp.NewServer(options.WithMux(s.handler), bw, options.WithMaxMessageSize(s.maxMessageSize))
+	s.stop = func() {
+		srv.Stop()
+		_ = listener.Close()
+	}
+	go func() {
+		errCh <- srv.Serve(listener)
+	}()
+
+	return nil
+}
+
+// startWS starts the CoAP over WebSockets server. WebSocket connections are
+// served by the CoAP over TCP server.
+func (s *coapServer) startWS(errCh chan error, bw options.BlockwiseOpt) error {
+	listener := newWSListener()
+	srv := tcp.NewServer(options.WithMux(s.handler), bw, options.WithMaxMessageSize(s.maxMessageSize))
+	hs := &http.Server{Addr: s.Address, Handler: newWSHandler(listener, int64(s.maxMessageSize), s.Logger)}
+	s.stop = func() {
+		ctx, cancel := context.WithTimeout(context.Background(), server.StopWaitTime)
+		defer cancel()
+		_ = hs.Shutdown(ctx)
+		srv.Stop()
+		_ = listener.Close()
+	}
+
+	s.Protocol = coapWSProtocol
+	switch {
+	case s.Config.CertFile != "" || s.Config.KeyFile != "":
+		s.Protocol = coapsWSProtocol
+		tlsConfig, mode, err := s.tlsConfig()
+		if err != nil {
+			return err
+		}
+		hs.TLSConfig = tlsConfig
+		s.Logger.Info(fmt.Sprintf("%s service %s server listening at %s%s with TLS %s", s.Name, s.Protocol, s.Address, WSPath, mode))
+		go func() {
+			errCh <- hs.ListenAndServeTLS("", "")
+		}()
+	default:
+		s.Logger.Info(fmt.Sprintf("%s service %s server listening at %s%s without TLS", s.Name, s.Protocol, s.Address, WSPath))
+		go func() {
+			errCh <- hs.ListenAndServe()
+		}()
+	}
+	go func() {
+		errCh <- srv.Serve(listener)
+	}()
+
+	return nil
+}
+
+// tcpListener is the listener of the CoAP over TCP server.
+type tcpListener interface {
+	AcceptWithContext(ctx context.Context) (net.Conn, error)
+	Close() error
+}
+
+// tlsConfig returns the TLS configuration of the server and the description
+// of its certificates. Client certificates are verified against the client
+// CA when configured.
+func (s *coapServer) tlsConfig() (*tls.Config, string, error) {
+	certificate, err := tls.LoadX509KeyPair(s.Config.CertFile, s.Config.KeyFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load CoAP server certificates: %w", err)
+	}
+	config := &tls.Config{
+		ClientAuth:   tls.NoClientCert,
+		Certificates: []tls.Certificate{certificate},
+	}
+	mode := fmt.Sprintf("cert %s and key %s", s.Config.CertFile, s.Config.KeyFile)
+
+	clientCA, err := loadCertFile(s.Config.ClientCAFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load client ca file: %w", err)
+	}
+	if len(clientCA) > 0 {
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(clientCA) {
+			return nil, "", fmt.Errorf("failed to append client ca to tls.Config")
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		mode = fmt.Sprintf("%s, client ca %s", mode, s.Config.ClientCAFile)
+	}
+
+	return config, mode, nil
+}
+
+// dtlsConfig returns the DTLS configuration of the server and the description
+// of enabled authentication modes. Client certificates are verified against
+// the client CA and are required unless PSK authentication is enabled too.
+func (s *coapServer) dtlsConfig() (*piondtls.Config, string, error) {
+	config := &piondtls.Config{
+		ExtendedMasterSecret: piondtls.RequireExtendedMasterSecret,
+	}
+	var mode string
+
+	if s.Config.CertFile != "" || s.Config.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(s.Config.CertFile, s.Config.KeyFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to load CoAP server certificates: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+		mode = fmt.Sprintf("cert %s and key %s", s.Config.CertFile, s.Config.KeyFile)
+
+		clientCA, err := loadCertFile(s.Config.ClientCAFile)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to load client ca file: %w", err)
+		}
+		if len(clientCA) > 0 {
+			config.ClientCAs = x509.NewCertPool()
+			if !config.ClientCAs.AppendCertsFromPEM(clientCA) {
+				return nil, "", fmt.Errorf("failed to append client ca to dtls.Config")
+			}
+			config.ClientAuth = piondtls.RequireAndVerifyClientCert
+			if s.psk != nil {
+				config.ClientAuth = piondtls.VerifyClientCertIfGiven
+			}
+			mode = fmt.Sprintf("%s, client ca %s", mode, s.Config.ClientCAFile)
+		}
+	}
+
+	if s.psk != nil {
+		config.PSK = piondtls.PSKCallback(s.psk)
+		// Default cipher suites do not include PSK cipher suites, so they
+		// are listed explicitly, after certificate cipher suites if any.
+		if len(config.Certificates) > 0 {
+			config.CipherSuites = append(config.CipherSuites, certCipherSuites...)
+		}
+		config.CipherSuites = append(config.CipherSuites, pskCipherSuites...)
+		if mode != "" {
+			mode += " and "
+		}
+		mode += "pre-shared keys"
+	}
+
+	return config, mode, nil
+}
+
+// blockSZX returns the block-wise transfer SZX of the block size.
+func blockSZX(size int) (blockwise.SZX, error) {
+	for szx := blockwise.SZX16; szx <= blockwise.SZX1024; szx++ {
+		if szx.Size() == int64(size) {
+			return szx, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid CoAP block size %d, must be a power of two between 16 and 1024", size)
+}
+
+func loadCertFile(certFile string) ([]byte, error) {
+	if certFile != "" {
+		return os.ReadFile(certFile)
+	}
+	return []byte{}, nil
+}
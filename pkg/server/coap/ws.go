@@ -0,0 +1,288 @@
+package coap
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// WSPath is the path of the CoAP over WebSockets endpoint.
+	WSPath = "/.well-known/coap"
+	// WSSubprotocol is the WebSocket subprotocol of CoAP.
+	WSSubprotocol = "coap"
+)
+
+// The Len field of the CoAP over TCP message header values indicating the
+// size of the Extended Length field, as defined in RFC 8323.
+const (
+	len13     = 13
+	len14     = 14
+	len15     = 15
+	len13Base = 13
+	len14Base = 269
+	len15Base = 65805
+	maxTKL    = 8
+)
+
+var (
+	errListenerClosed = errors.New("listener closed")
+	errMalformedFrame = errors.New("malformed CoAP over WebSockets message")
+)
+
+// wsListener provides WebSocket connections to the CoAP over TCP server.
+type wsListener struct {
+	conns  chan net.Conn
+	done   chan struct{}
+	closed sync.Once
+}
+
+func newWSListener() *wsListener {
+	return &wsListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+func (l *wsListener) AcceptWithContext(ctx context.Context) (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.done:
+		return nil, errListenerClosed
+	}
+}
+
+func (l *wsListener) Close() error {
+	l.closed.Do(func() {
+		close(l.done)
+	})
+	return nil
+}
+
+func (l *wsListener) accept(conn net.Conn) error {
+	select {
+	case l.conns <- conn:
+		return nil
+	case <-l.done:
+		return errListenerClosed
+	}
+}
+
+// newWSHandler returns the HTTP handler upgrading the requests using the
+// CoAP subprotocol to CoAP over WebSockets connections. Connections sending
+// WebSocket messages larger than the maximum message size are closed.
+func newWSHandler(listener *wsListener, maxMessageSize int64, logger *slog.Logger) http.Handler {
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{WSSubprotocol},
+		CheckOrigin:  func(r *http.Request) bool { return true },
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(WSPath, func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(websocket.Subprotocols(r), WSSubprotocol) {
+			http.Error(w, fmt.Sprintf("missing %s subprotocol", WSSubprotocol), http.StatusBadRequest)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Failed to upgrade connection to CoAP over WebSockets: %s", err))
+			return
+		}
+		conn.SetReadLimit(maxMessageSize)
+		if err := listener.accept(newWSConn(conn, r.TLS)); err != nil {
+			_ = conn.Close()
+		}
+	})
+
+	return mux
+}
+
+// wsConn adapts the CoAP over WebSockets connection to the CoAP over TCP
+// connection. CoAP over WebSockets messages don't carry the message length,
+// so the length is added to the received messages and removed from the sent
+// messages.
+type wsConn struct {
+	conn  *websocket.Conn
+	state *tls.ConnectionState
+	rbuf  bytes.Buffer
+	wmu   sync.Mutex
+	wbuf  bytes.Buffer
+}
+
+var _ net.Conn = (*wsConn)(nil)
+
+func newWSConn(conn *websocket.Conn, state *tls.ConnectionState) *wsConn {
+	return &wsConn{
+		conn:  conn,
+		state: state,
+	}
+}
+
+func (c *wsConn) Read(b []byte) (int, error) {
+	for c.rbuf.Len() == 0 {
+		typ, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return 0, err
+		}
+		if typ != websocket.BinaryMessage {
+			return 0, errMalformedFrame
+		}
+		if err := wsToTCP(&c.rbuf, data); err != nil {
+			return 0, err
+		}
+	}
+
+	return c.rbuf.Read(b)
+}
+
+func (c *wsConn) Write(b []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	c.wbuf.Write(b)
+	for {
+		msg, n, err := tcpToWS(c.wbuf.Bytes())
+		if err != nil {
+			c.wbuf.Reset()
+			return 0, err
+		}
+		if n == 0 {
+			return len(b), nil
+		}
+		if err := c.conn.WriteMessage(websocket.BinaryMessage, msg); err != nil {
+			return 0, err
+		}
+		c.wbuf.Next(n)
+	}
+}
+
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *wsConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *wsConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *wsConn) SetDeadline(t time.Time) error {
+	if err := c.conn.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.conn.SetWriteDeadline(t)
+}
+
+func (c *wsConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *wsConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// ConnectionState returns the TLS state of the secure WebSocket connection.
+func (c *wsConn) ConnectionState() tls.ConnectionState {
+	if c.state == nil {
+		return tls.ConnectionState{}
+	}
+	return *c.state
+}
+
+// wsToTCP writes the CoAP over WebSockets message as the CoAP over TCP
+// message, setting the Len and Extended Length fields of the header.
+func wsToTCP(buf *bytes.Buffer, msg []byte) error {
+	if len(msg) < 2 || msg[0]>>4 != 0 {
+		return errMalformedFrame
+	}
+	tkl := int(msg[0] & 0x0f)
+	if tkl > maxTKL || len(msg) < 2+tkl {
+		return errMalformedFrame
+	}
+
+	length := len(msg) - 2 - tkl
+	var ext []byte
+	var lenField byte
+	switch {
+	case length < len13Base:
+		lenField = byte(length)
+	case length < len14Base:
+		lenField = len13
+		ext = []byte{byte(length - len13Base)}
+	case length < len15Base:
+		lenField = len14
+		ext = binary.BigEndian.AppendUint16(nil, uint16(length-len14Base))
+	default:
+		lenField = len15
+		ext = binary.BigEndian.AppendUint32(nil, uint32(length-len15Base))
+	}
+
+	buf.WriteByte(lenField<<4 | byte(tkl))
+	buf.Write(ext)
+	buf.Write(msg[1:])
+
+	return nil
+}
+
+// tcpToWS returns the first CoAP over TCP message of the data as the CoAP
+// over WebSockets message and the number of consumed bytes. Zero bytes are
+// consumed if the data doesn't contain the whole message.
+func tcpToWS(data []byte) ([]byte, int, error) {
+	if len(data) == 0 {
+		return nil, 0, nil
+	}
+	lenField := data[0] >> 4
+	tkl := int(data[0] & 0x0f)
+	if tkl > maxTKL {
+		return nil, 0, errMalformedFrame
+	}
+
+	var extSize, length int
+	switch lenField {
+	case len13:
+		extSize = 1
+	case len14:
+		extSize = 2
+	case len15:
+		extSize = 4
+	}
+	if len(data) < 1+extSize {
+		return nil, 0, nil
+	}
+	switch lenField {
+	case len13:
+		length = int(data[1]) + len13Base
+	case len14:
+		length = int(binary.BigEndian.Uint16(data[1:3])) + len14Base
+	case len15:
+		length = int(binary.BigEndian.Uint32(data[1:5])) + len15Base
+	default:
+		length = int(lenField)
+	}
+
+	total := 1 + extSize + 1 + tkl + length
+	if len(data) < total {
+		return nil, 0, nil
+	}
+	msg := make([]byte, 0, total-extSize)
+	msg = append(msg, byte(tkl))
+	msg = append(msg, data[1+extSize:total]...)
+
+	return msg, total, nil
+}
@@ -0,0 +1,130 @@
+package coap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	smqlog "github.com/hantdev/mitras/logger"
+	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+	"github.com/plgd-dev/go-coap/v3/mux"
+	"github.com/plgd-dev/go-coap/v3/options"
+	"github.com/plgd-dev/go-coap/v3/tcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameConversion(t *testing.T) {
+	cases := []struct {
+		desc    string
+		payload int
+	}{
+		{desc: "message without payload", payload: 0},
+		{desc: "message with 1 byte extended length", payload: 100},
+		{desc: "message with 2 bytes extended length", payload: 1000},
+		{desc: "message with 4 bytes extended length", payload: 70000},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			msg := []byte{0x02, byte(codes.POST), 0xab, 0xcd}
+			if tc.payload > 0 {
+				msg = append(msg, 0xff)
+				msg = append(msg, bytes.Repeat([]byte{'a'}, tc.payload)...)
+			}
+
+			var buf bytes.Buffer
+			err := wsToTCP(&buf, msg)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+
+			// Incomplete CoAP over TCP message is not consumed.
+			_, n, err := tcpToWS(buf.Bytes()[:buf.Len()-1])
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, 0, n, fmt.Sprintf("%s: expected incomplete message not to be consumed", tc.desc))
+
+			res, n, err := tcpToWS(buf.Bytes())
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, buf.Len(), n, fmt.Sprintf("%s: got unexpected consumed length", tc.desc))
+			assert.Equal(t, msg, res, fmt.Sprintf("%s: got unexpected message", tc.desc))
+		})
+	}
+
+	err := wsToTCP(&bytes.Buffer{}, []byte{0x12, byte(codes.GET), 0x01, 0x02})
+	assert.Equal(t, errMalformedFrame, err, "expected error for message with length")
+}
+
+func TestWebSockets(t *testing.T) {
+	payloads := make(chan string, 1)
+	handler := func(w mux.ResponseWriter, r *mux.Message) {
+		data, err := io.ReadAll(r.Body())
+		require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+		payloads <- string(data)
+		err = w.SetResponse(codes.Created, message.TextPlain, bytes.NewReader([]byte("created")))
+		require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	}
+
+	listener := newWSListener()
+	srv := tcp.NewServer(options.WithMux(mux.HandlerFunc(handler)))
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	defer srv.Stop()
+	hs := httptest.NewServer(newWSHandler(listener, defMaxMessageSize, smqlog.NewMock()))
+	defer hs.Close()
+
+	url := "ws" + strings.TrimPrefix(hs.URL, "http") + WSPath
+	_, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NotNil(t, err, "expected error connecting without CoAP subprotocol")
+
+	dialer := websocket.Dialer{Subprotocols: []string{WSSubprotocol}}
+	conn, _, err := dialer.Dial(url, nil)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+
+	cc := tcp.Client(newWSConn(conn, nil))
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := cc.Post(ctx, "/channels/1/messages", message.TextPlain, bytes.NewReader([]byte("hello")))
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	assert.Equal(t, codes.Created, res.Code(), "got unexpected response code")
+	assert.Equal(t, "hello", <-payloads, "got unexpected payload")
+
+	err = cc.Ping(ctx)
+	assert.Nil(t, err, fmt.Sprintf("expected ping to succeed got %s", err))
+}
+
+func TestWebSocketsReadLimit(t *testing.T) {
+	listener := newWSListener()
+	srv := tcp.NewServer(options.WithMux(mux.HandlerFunc(func(w mux.ResponseWriter, r *mux.Message) {})))
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	defer srv.Stop()
+	hs := httptest.NewServer(newWSHandler(listener, 64, smqlog.NewMock()))
+	defer hs.Close()
+
+	dialer := websocket.Dialer{Subprotocols: []string{WSSubprotocol}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(hs.URL, "http")+WSPath, nil)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	defer conn.Close()
+
+	msg := append([]byte{0x00, byte(codes.POST), 0xff}, bytes.Repeat([]byte{'a'}, 64)...)
+	err = conn.WriteMessage(websocket.BinaryMessage, msg)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+
+	err = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	// Messages sent by the server, such as CSM, precede the close frame.
+	for err == nil {
+		_, _, err = conn.ReadMessage()
+	}
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseMessageTooBig), fmt.Sprintf("expected connection to be closed for message exceeding read limit got %v", err))
+}
@@ -0,0 +1,3 @@
+// Package totp implements the time-based one-time passwords, as specified
+// in RFC 6238, compatible with the common authenticator applications.
+package totp
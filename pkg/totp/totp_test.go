@@ -0,0 +1,138 @@
+package totp_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The secret and the codes of the RFC 6238 SHA1 test vectors.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	cases := []struct {
+		desc   string
+		secret string
+		time   time.Time
+		code   string
+		err    error
+	}{
+		{
+			desc:   "code at 59 seconds",
+			secret: rfcSecret,
+			time:   time.Unix(59, 0),
+			code:   "287082",
+		},
+		{
+			desc:   "code at 1111111109 seconds",
+			secret: rfcSecret,
+			time:   time.Unix(1111111109, 0),
+			code:   "081804",
+		},
+		{
+			desc:   "code at 2000000000 seconds",
+			secret: rfcSecret,
+			time:   time.Unix(2000000000, 0),
+			code:   "279037",
+		},
+		{
+			desc:   "code with lowercase secret",
+			secret: strings.ToLower(rfcSecret),
+			time:   time.Unix(59, 0),
+			code:   "287082",
+		},
+		{
+			desc:   "code with invalid secret",
+			secret: "invalid!",
+			time:   time.Unix(59, 0),
+			err:    totp.ErrInvalidSecret,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			code, err := totp.Code(tc.secret, tc.time)
+			assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected error %s got %s", tc.desc, tc.err, err))
+			assert.Equal(t, tc.code, code)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.Nil(t, err, fmt.Sprintf("generate secret unexpected error: %s", err))
+	now := time.Unix(1700000000, 0)
+	code, err := totp.Code(secret, now)
+	require.Nil(t, err, fmt.Sprintf("generate code unexpected error: %s", err))
+	step := now.Unix() / int64(totp.Period.Seconds())
+
+	cases := []struct {
+		desc   string
+		secret string
+		code   string
+		time   time.Time
+		step   int64
+		valid  bool
+	}{
+		{
+			desc:   "validate current code",
+			secret: secret,
+			code:   code,
+			time:   now,
+			step:   step,
+			valid:  true,
+		},
+		{
+			desc:   "validate code of the previous period",
+			secret: secret,
+			code:   code,
+			time:   now.Add(totp.Period),
+			step:   step,
+			valid:  true,
+		},
+		{
+			desc:   "validate code of the next period",
+			secret: secret,
+			code:   code,
+			time:   now.Add(-totp.Period),
+			step:   step,
+			valid:  true,
+		},
+		{
+			desc:   "validate outdated code",
+			secret: secret,
+			code:   code,
+			time:   now.Add(3 * totp.Period),
+		},
+		{
+			desc:   "validate invalid code",
+			secret: secret,
+			code:   "12345",
+			time:   now,
+		},
+		{
+			desc:   "validate code with invalid secret",
+			secret: "invalid!",
+			code:   code,
+			time:   now,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			step, valid := totp.Validate(tc.secret, tc.code, tc.time)
+			assert.Equal(t, tc.valid, valid, fmt.Sprintf("%s: expected %t got %t", tc.desc, tc.valid, valid))
+			assert.Equal(t, tc.step, step)
+		})
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("Mitras", "user@example.com", rfcSecret)
+	assert.Equal(t, "otpauth://totp/Mitras:user@example.com?algorithm=SHA1&digits=6&issuer=Mitras&period=30&secret="+rfcSecret, uri)
+}
@@ -0,0 +1,68 @@
+package readers
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/hantdev/mitras/pkg/transformers/senml"
+	"github.com/jmoiron/sqlx"
+)
+
+// ScanAggregates scans the aggregated time buckets. A bucket aggregated with a
+// single function is returned as a SenML message to keep the response format
+// of single aggregation requests.
+func ScanAggregates(rows *sqlx.Rows, aggs []string) ([]Message, error) {
+	msgs := []Message{}
+	for rows.Next() {
+		var (
+			t                                         float64
+			publisher, protocol, subtopic, name, unit sql.NullString
+		)
+		values := make([]sql.NullFloat64, len(aggs))
+		dest := []interface{}{&t, &publisher, &protocol, &subtopic, &name, &unit}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		if len(aggs) == 1 {
+			msg := senml.Message{
+				Time:      t,
+				Publisher: publisher.String,
+				Protocol:  protocol.String,
+				Subtopic:  subtopic.String,
+				Name:      name.String,
+				Unit:      unit.String,
+			}
+			if values[0].Valid {
+				v := values[0].Float64
+				msg.Value = &v
+			}
+			msgs = append(msgs, msg)
+			continue
+		}
+
+		agg := Aggregate{
+			Time:      t,
+			Publisher: publisher.String,
+			Protocol:  protocol.String,
+			Subtopic:  subtopic.String,
+			Name:      name.String,
+			Unit:      unit.String,
+			Values:    make(map[string]*float64, len(aggs)),
+		}
+		for i, fn := range aggs {
+			var v *float64
+			if values[i].Valid {
+				f := values[i].Float64
+				v = &f
+			}
+			agg.Values[strings.ToLower(fn)] = v
+		}
+		msgs = append(msgs, agg)
+	}
+
+	return msgs, nil
+}
@@ -13,14 +13,17 @@ import (
 	"github.com/hantdev/mitras/readers"
 )
 
-func listMessagesEndpoint(svc readers.MessageRepository, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient) endpoint.Endpoint {
+func listMessagesEndpoint(svc readers.MessageRepository, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient, maxBuckets uint64) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(listMessagesReq)
 		if err := req.validate(); err != nil {
 			return nil, errors.Wrap(apiutil.ErrValidation, err)
 		}
+		if err := req.validateBuckets(maxBuckets); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
 
-		if err := authnAuthz(ctx, req, authn, clients, channels); err != nil {
+		if err := authnAuthz(ctx, req.token, req.key, []string{req.chanID}, authn, clients, channels); err != nil {
 			return nil, errors.Wrap(svcerr.ErrAuthorization, err)
 		}
 
@@ -36,3 +39,27 @@ func listMessagesEndpoint(svc readers.MessageRepository, authn smqauthn.Authenti
 		}, nil
 	}
 }
+
+func listLatestEndpoint(svc readers.MessageRepository, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listLatestReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		if err := authnAuthz(ctx, req.token, req.key, req.chanIDs, authn, clients, channels); err != nil {
+			return nil, errors.Wrap(svcerr.ErrAuthorization, err)
+		}
+
+		page, err := svc.ReadLatest(req.chanIDs, req.pageMeta)
+		if err != nil {
+			return nil, err
+		}
+
+		return pageRes{
+			PageMetadata: page.PageMetadata,
+			Total:        page.Total,
+			Messages:     page.Messages,
+		}, nil
+	}
+}
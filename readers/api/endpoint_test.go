@@ -38,7 +38,9 @@ const (
 	mqttProt      = "mqtt"
 	httpProt      = "http"
 	msgName       = "temperature"
+	jsonFormat    = "some_json"
 	instanceID    = "5de9b29a-feb9-11ed-be56-0242ac120002"
+	maxBuckets    = 10000
 )
 
 var (
@@ -51,7 +53,7 @@ var (
 )
 
 func newServer(repo *mocks.MessageRepository, authn *authnmocks.Authentication, clients *climocks.ClientsServiceClient, channels *chmocks.ChannelsServiceClient) *httptest.Server {
-	mux := api.MakeHandler(repo, authn, clients, channels, svcName, instanceID)
+	mux := api.MakeHandler(repo, authn, clients, channels, maxBuckets, svcName, instanceID)
 	return httptest.NewServer(mux)
 }
 
@@ -159,6 +161,128 @@ func TestReadAll(t *testing.T) {
 				Messages:     messages[0:10],
 			},
 		},
+		{
+			desc:         "read page with multiple aggregations, interval, to and from as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?aggregation=MIN,MAX&interval=10h&from=%f&to=%f", ts.URL, chanID, messages[19].Time, messages[4].Time),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusOK,
+			res: pageRes{
+				PageMetadata: readers.PageMetadata{Limit: 10, Format: "messages", Aggregation: "MIN,MAX", Interval: "10h", From: messages[19].Time, To: messages[4].Time},
+				Total:        uint64(len(messages[5:20])),
+				Messages:     messages[5:15],
+			},
+		},
+		{
+			desc:         "read page with one invalid of multiple aggregations as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?aggregation=MIN,invalid&interval=10h&from=%f&to=%f", ts.URL, chanID, messages[19].Time, messages[4].Time),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
+		{
+			desc:         "read page with aggregation and previous value fill as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?aggregation=AVG&interval=10h&from=%f&to=%f&fill=%s", ts.URL, chanID, messages[19].Time, messages[4].Time, readers.FillPrevious),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusOK,
+			res: pageRes{
+				PageMetadata: readers.PageMetadata{Limit: 10, Format: "messages", Aggregation: "AVG", Interval: "10h", From: messages[19].Time, To: messages[4].Time, Fill: readers.FillPrevious},
+				Total:        uint64(len(messages[5:20])),
+				Messages:     messages[5:15],
+			},
+		},
+		{
+			desc:         "read page with payload filters and fields as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?format=%s&payload=temp:gt:30&payload=state.on:eq:true&fields=temp,state.on", ts.URL, chanID, jsonFormat),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusOK,
+			res: pageRes{
+				PageMetadata: readers.PageMetadata{
+					Limit:  10,
+					Format: jsonFormat,
+					PayloadFilters: []readers.PayloadFilter{
+						{Field: "temp", Comparator: readers.GreaterThanKey, Value: float64(30)},
+						{Field: "state.on", Comparator: readers.EqualKey, Value: true},
+					},
+					Fields: []string{"temp", "state.on"},
+				},
+			},
+		},
+		{
+			desc:         "read page with aggregation of payload field as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?format=%s&aggregation=AVG&interval=10h&from=%f&to=%f&field=temp", ts.URL, chanID, jsonFormat, messages[19].Time, messages[4].Time),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusOK,
+			res: pageRes{
+				PageMetadata: readers.PageMetadata{Limit: 10, Format: jsonFormat, Aggregation: "AVG", Interval: "10h", From: messages[19].Time, To: messages[4].Time, Field: "temp"},
+			},
+		},
+		{
+			desc:         "read page with aggregation of JSON messages without field as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?format=%s&aggregation=AVG&interval=10h&from=%f&to=%f", ts.URL, chanID, jsonFormat, messages[19].Time, messages[4].Time),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
+		{
+			desc:         "read page with payload filter of SenML messages as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?payload=temp:gt:30", ts.URL, chanID),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
+		{
+			desc:         "read page with malformed payload filter as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?format=%s&payload=temp:gt", ts.URL, chanID, jsonFormat),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
+		{
+			desc:         "read page with payload filter with invalid comparator as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?format=%s&payload=temp:invalid:30", ts.URL, chanID, jsonFormat),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
+		{
+			desc:         "read page with payload filter comparing boolean with lower than as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?format=%s&payload=state.on:lt:true", ts.URL, chanID, jsonFormat),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
+		{
+			desc:         "read page with invalid payload field as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?format=%s&fields=temp,state.", ts.URL, chanID, jsonFormat),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
+		{
+			desc:         "read page with fill and without aggregation as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?fill=%s", ts.URL, chanID, readers.FillNull),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
+		{
+			desc:         "read page with aggregation and invalid fill as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?aggregation=AVG&interval=10h&from=%f&to=%f&fill=invalid", ts.URL, chanID, messages[19].Time, messages[4].Time),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
+		{
+			desc:         "read page with aggregation and too many filled buckets as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?aggregation=AVG&interval=1s&from=1&to=%d&fill=%s", ts.URL, chanID, time.Now().UnixNano(), readers.FillNull),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
 		{
 			desc:         "read page with valid offset and limit as user",
 			url:          fmt.Sprintf("%s/channels/%s/messages?offset=0&limit=10", ts.URL, chanID),
@@ -539,6 +663,27 @@ func TestReadAll(t *testing.T) {
 			authResponse: true,
 			status:       http.StatusBadRequest,
 		},
+		{
+			desc:         "read page with zero interval and valid aggregation, to and from as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?aggregation=MAX&interval=0&from=%f&to=%f", ts.URL, chanID, messages[19].Time, messages[4].Time),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
+		{
+			desc:         "read page with negative interval and valid aggregation, to and from as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?aggregation=MAX&interval=-1h&from=%f&to=%f", ts.URL, chanID, messages[19].Time, messages[4].Time),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
+		{
+			desc:         "read page with sub-microsecond interval and valid aggregation, to and from as client",
+			url:          fmt.Sprintf("%s/channels/%s/messages?aggregation=MAX&interval=1ns&from=%f&to=%f", ts.URL, chanID, messages[19].Time, messages[4].Time),
+			key:          clientToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
 		{
 			desc:         "read page with aggregation, interval and to with missing from as client",
 			url:          fmt.Sprintf("%s/channels/%s/messages?aggregation=MAX&interval=10h&to=%f", ts.URL, chanID, messages[4].Time),
@@ -947,6 +1092,27 @@ func TestReadAll(t *testing.T) {
 			authResponse: true,
 			status:       http.StatusBadRequest,
 		},
+		{
+			desc:         "read page with zero interval and valid aggregation, to and from as user",
+			url:          fmt.Sprintf("%s/channels/%s/messages?aggregation=MAX&interval=0&from=%f&to=%f", ts.URL, chanID, messages[19].Time, messages[4].Time),
+			key:          userToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
+		{
+			desc:         "read page with negative interval and valid aggregation, to and from as user",
+			url:          fmt.Sprintf("%s/channels/%s/messages?aggregation=MAX&interval=-1h&from=%f&to=%f", ts.URL, chanID, messages[19].Time, messages[4].Time),
+			key:          userToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
+		{
+			desc:         "read page with sub-microsecond interval and valid aggregation, to and from as user",
+			url:          fmt.Sprintf("%s/channels/%s/messages?aggregation=MAX&interval=1ns&from=%f&to=%f", ts.URL, chanID, messages[19].Time, messages[4].Time),
+			key:          userToken,
+			authResponse: true,
+			status:       http.StatusBadRequest,
+		},
 		{
 			desc:         "read page with aggregation, interval and to with missing from as user",
 			url:          fmt.Sprintf("%s/channels/%s/messages?aggregation=MAX&interval=10h&to=%f", ts.URL, chanID, messages[4].Time),
@@ -1002,6 +1168,173 @@ func TestReadAll(t *testing.T) {
 	}
 }
 
+func TestReadLatest(t *testing.T) {
+	chanID := testsutil.GenerateUUID(t)
+	chanID2 := testsutil.GenerateUUID(t)
+	pubID := testsutil.GenerateUUID(t)
+
+	now := time.Now().Unix()
+
+	messages := []senml.Message{
+		{Channel: chanID, Publisher: pubID, Protocol: mqttProt, Name: msgName, Time: float64(now), Value: &v},
+		{Channel: chanID, Publisher: pubID, Protocol: mqttProt, Name: "name", Time: float64(now - 1), BoolValue: &vb},
+		{Channel: chanID2, Publisher: pubID, Protocol: httpProt, Name: msgName, Time: float64(now - 2), Value: &v},
+	}
+
+	repo := new(mocks.MessageRepository)
+	authn := new(authnmocks.Authentication)
+	clients := new(climocks.ClientsServiceClient)
+	channels := new(chmocks.ChannelsServiceClient)
+	ts := newServer(repo, authn, clients, channels)
+	defer ts.Close()
+
+	cases := []struct {
+		desc     string
+		url      string
+		token    string
+		key      string
+		chanIDs  []string
+		status   int
+		res      pageRes
+		authnErr error
+		authzRes bool
+	}{
+		{
+			desc:     "read latest of channel",
+			url:      fmt.Sprintf("%s/channels/%s/messages/latest", ts.URL, chanID),
+			token:    userToken,
+			chanIDs:  []string{chanID},
+			authzRes: true,
+			status:   http.StatusOK,
+			res: pageRes{
+				PageMetadata: readers.PageMetadata{Limit: 10, Format: "messages"},
+				Total:        2,
+				Messages:     messages[0:2],
+			},
+		},
+		{
+			desc:     "read latest of channel with name as client",
+			url:      fmt.Sprintf("%s/channels/%s/messages/latest?name=%s", ts.URL, chanID, msgName),
+			key:      clientToken,
+			chanIDs:  []string{chanID},
+			authzRes: true,
+			status:   http.StatusOK,
+			res: pageRes{
+				PageMetadata: readers.PageMetadata{Limit: 10, Format: "messages", Name: msgName},
+				Total:        1,
+				Messages:     messages[0:1],
+			},
+		},
+		{
+			desc:     "read latest of multiple channels",
+			url:      fmt.Sprintf("%s/messages/latest?channels=%s,%s", ts.URL, chanID, chanID2),
+			token:    userToken,
+			chanIDs:  []string{chanID, chanID2},
+			authzRes: true,
+			status:   http.StatusOK,
+			res: pageRes{
+				PageMetadata: readers.PageMetadata{Limit: 10, Format: "messages"},
+				Total:        3,
+				Messages:     messages,
+			},
+		},
+		{
+			desc:     "read latest without channels",
+			url:      fmt.Sprintf("%s/messages/latest", ts.URL),
+			token:    userToken,
+			authzRes: true,
+			status:   http.StatusBadRequest,
+		},
+		{
+			desc:     "read latest with empty channel",
+			url:      fmt.Sprintf("%s/messages/latest?channels=%s,", ts.URL, chanID),
+			token:    userToken,
+			authzRes: true,
+			status:   http.StatusBadRequest,
+		},
+		{
+			desc:     "read latest with invalid limit",
+			url:      fmt.Sprintf("%s/channels/%s/messages/latest?limit=0", ts.URL, chanID),
+			token:    userToken,
+			authzRes: true,
+			status:   http.StatusBadRequest,
+		},
+		{
+			desc:     "read latest without token",
+			url:      fmt.Sprintf("%s/channels/%s/messages/latest", ts.URL, chanID),
+			authzRes: true,
+			status:   http.StatusUnauthorized,
+		},
+		{
+			desc:     "read latest of channel with invalid token",
+			url:      fmt.Sprintf("%s/channels/%s/messages/latest", ts.URL, chanID),
+			token:    invalidToken,
+			chanIDs:  []string{chanID},
+			authnErr: svcerr.ErrAuthentication,
+			authzRes: true,
+			res:      pageRes{PageMetadata: readers.PageMetadata{Limit: 10, Format: "messages"}},
+			status:   http.StatusUnauthorized,
+		},
+		{
+			desc:     "read latest of multiple channels with invalid token",
+			url:      fmt.Sprintf("%s/messages/latest?channels=%s,%s", ts.URL, chanID, chanID2),
+			token:    invalidToken,
+			chanIDs:  []string{chanID, chanID2},
+			authnErr: svcerr.ErrAuthentication,
+			authzRes: true,
+			res:      pageRes{PageMetadata: readers.PageMetadata{Limit: 10, Format: "messages"}},
+			status:   http.StatusUnauthorized,
+		},
+		{
+			desc:     "read latest of channel with invalid client key",
+			url:      fmt.Sprintf("%s/channels/%s/messages/latest", ts.URL, chanID),
+			key:      invalid,
+			chanIDs:  []string{chanID},
+			authnErr: svcerr.ErrAuthentication,
+			authzRes: true,
+			res:      pageRes{PageMetadata: readers.PageMetadata{Limit: 10, Format: "messages"}},
+			status:   http.StatusUnauthorized,
+		},
+		{
+			desc:     "read latest of unauthorized channel",
+			url:      fmt.Sprintf("%s/messages/latest?channels=%s,%s", ts.URL, chanID, chanID2),
+			token:    userToken,
+			authzRes: false,
+			status:   http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		authnCall := authn.On("Authenticate", mock.Anything, tc.token).Return(validSession, tc.authnErr)
+		if tc.key != "" {
+			authnCall = clients.On("Authenticate", mock.Anything, &grpcClientsV1.AuthnReq{
+				ClientSecret: tc.key,
+			}).Return(&grpcClientsV1.AuthnRes{Id: testsutil.GenerateUUID(t), Authenticated: tc.authnErr == nil}, tc.authnErr)
+		}
+		authzCall := channels.On("Authorize", mock.Anything, mock.Anything).Return(&grpcChannelsV1.AuthzRes{Authorized: tc.authzRes}, nil)
+		repoCall := repo.On("ReadLatest", tc.chanIDs, tc.res.PageMetadata).Return(readers.MessagesPage{Total: tc.res.Total, Messages: fromSenml(tc.res.Messages)}, nil)
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  tc.token,
+			key:    tc.key,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+
+		var page pageRes
+		err = json.NewDecoder(res.Body).Decode(&page)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error while decoding response body: %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected %d got %d", tc.desc, tc.status, res.StatusCode))
+		assert.Equal(t, tc.res.Total, page.Total, fmt.Sprintf("%s: expected %d got %d", tc.desc, tc.res.Total, page.Total))
+		assert.ElementsMatch(t, tc.res.Messages, page.Messages, fmt.Sprintf("%s: got incorrect body from response", tc.desc))
+		authzCall.Unset()
+		authnCall.Unset()
+		repoCall.Unset()
+	}
+}
+
 type pageRes struct {
 	readers.PageMetadata
 	Total    uint64          `json:"total"`
@@ -51,3 +51,31 @@ func (lm *loggingMiddleware) ReadAll(chanID string, rpm readers.PageMetadata) (p
 
 	return lm.svc.ReadAll(chanID, rpm)
 }
+
+func (lm *loggingMiddleware) ReadLatest(chanIDs []string, rpm readers.PageMetadata) (page readers.MessagesPage, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Any("channel_ids", chanIDs),
+			slog.Group("page",
+				slog.Uint64("offset", rpm.Offset),
+				slog.Uint64("limit", rpm.Limit),
+				slog.Uint64("total", page.Total),
+			),
+		}
+		if rpm.Subtopic != "" {
+			args = append(args, slog.String("subtopic", rpm.Subtopic))
+		}
+		if rpm.Publisher != "" {
+			args = append(args, slog.String("publisher", rpm.Publisher))
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Read latest failed", args...)
+			return
+		}
+		lm.logger.Info("Read latest completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ReadLatest(chanIDs, rpm)
+}
@@ -34,3 +34,12 @@ func (mm *metricsMiddleware) ReadAll(chanID string, rpm readers.PageMetadata) (r
 
 	return mm.svc.ReadAll(chanID, rpm)
 }
+
+func (mm *metricsMiddleware) ReadLatest(chanIDs []string, rpm readers.PageMetadata) (readers.MessagesPage, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "read_latest").Add(1)
+		mm.latency.With("method", "read_latest").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.ReadLatest(chanIDs, rpm)
+}
@@ -2,14 +2,20 @@ package api
 
 import (
 	"slices"
-	"strings"
 	"time"
 
 	"github.com/hantdev/mitras/pkg/apiutil"
 	"github.com/hantdev/mitras/readers"
 )
 
-const maxLimitSize = 1000
+const (
+	maxLimitSize      = 1000
+	maxLatestChannels = 100
+
+	// Shortest aggregation interval, the precision of the stored timestamps
+	// used for the time buckets.
+	minInterval = time.Microsecond
+)
 
 var validAggregations = []string{"MAX", "MIN", "AVG", "SUM", "COUNT"}
 
@@ -51,14 +57,134 @@ func (req listMessagesReq) validate() error {
 			return apiutil.ErrMissingTo
 		}
 
-		if !slices.Contains(validAggregations, strings.ToUpper(req.pageMeta.Aggregation)) {
+		aggs := req.pageMeta.Aggregations()
+		if len(aggs) == 0 {
 			return apiutil.ErrInvalidAggregation
 		}
+		for _, agg := range aggs {
+			if !slices.Contains(validAggregations, agg) {
+				return apiutil.ErrInvalidAggregation
+			}
+		}
 
-		if _, err := time.ParseDuration(req.pageMeta.Interval); err != nil {
+		if interval, err := time.ParseDuration(req.pageMeta.Interval); err != nil || interval < minInterval {
 			return apiutil.ErrInvalidInterval
 		}
 	}
 
+	if err := validatePayload(req.pageMeta); err != nil {
+		return err
+	}
+
+	if req.pageMeta.Fill != "" {
+		if req.pageMeta.Aggregation == "" {
+			return apiutil.ErrMissingAggregation
+		}
+
+		if req.pageMeta.Fill != readers.FillNull && req.pageMeta.Fill != readers.FillPrevious {
+			return apiutil.ErrInvalidFill
+		}
+	}
+
+	return nil
+}
+
+// validateBuckets limits the number of the buckets generated by the gap
+// filling, since the buckets are generated for the whole time range
+// regardless of the stored messages. Zero max disables the limit.
+func (req listMessagesReq) validateBuckets(max uint64) error {
+	if req.pageMeta.Fill == "" || max == 0 {
+		return nil
+	}
+
+	interval, err := time.ParseDuration(req.pageMeta.Interval)
+	if err != nil || interval <= 0 {
+		return apiutil.ErrInvalidInterval
+	}
+	// From and To are Unix times in nanoseconds.
+	if buckets := (req.pageMeta.To - req.pageMeta.From) / float64(interval); buckets > float64(max) {
+		return apiutil.ErrTooManyBuckets
+	}
+
+	return nil
+}
+
+func validatePayload(pm readers.PageMetadata) error {
+	jsonFormat := pm.Format != "" && pm.Format != defFormat
+	if !jsonFormat {
+		if len(pm.PayloadFilters) > 0 || len(pm.Fields) > 0 || pm.Field != "" {
+			return apiutil.ErrPayloadFormat
+		}
+		return nil
+	}
+
+	for _, pf := range pm.PayloadFilters {
+		if !validField(pf.Field) {
+			return apiutil.ErrInvalidPayloadFilter
+		}
+
+		switch pf.Comparator {
+		case readers.EqualKey:
+		case readers.LowerThanKey, readers.LowerThanEqualKey, readers.GreaterThanKey, readers.GreaterThanEqualKey:
+			if _, ok := pf.Value.(bool); ok {
+				return apiutil.ErrInvalidComparator
+			}
+		default:
+			return apiutil.ErrInvalidComparator
+		}
+	}
+
+	for _, field := range pm.Fields {
+		if !validField(field) {
+			return apiutil.ErrInvalidPayloadField
+		}
+	}
+
+	if pm.Field != "" && !validField(pm.Field) {
+		return apiutil.ErrInvalidPayloadField
+	}
+
+	// Aggregations of JSON messages are applied to numeric payload field.
+	if pm.Aggregation != "" && pm.Field == "" {
+		return apiutil.ErrInvalidPayloadField
+	}
+
+	return nil
+}
+
+func validField(field string) bool {
+	return !slices.Contains(readers.FieldPath(field), "")
+}
+
+type listLatestReq struct {
+	chanIDs  []string
+	token    string
+	key      string
+	pageMeta readers.PageMetadata
+}
+
+func (req listLatestReq) validate() error {
+	if req.token == "" && req.key == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if len(req.chanIDs) == 0 {
+		return apiutil.ErrMissingID
+	}
+
+	if len(req.chanIDs) > maxLatestChannels {
+		return apiutil.ErrLimitSize
+	}
+
+	for _, chanID := range req.chanIDs {
+		if chanID == "" {
+			return apiutil.ErrMissingID
+		}
+	}
+
+	if req.pageMeta.Limit < 1 || req.pageMeta.Limit > maxLimitSize {
+		return apiutil.ErrLimitSize
+	}
+
 	return nil
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/hantdev/mitras"
 	"github.com/go-chi/chi/v5"
@@ -38,26 +40,46 @@ const (
 	toKey          = "to"
 	aggregationKey = "aggregation"
 	intervalKey    = "interval"
+	fillKey        = "fill"
+	channelsKey    = "channels"
+	payloadKey     = "payload"
+	fieldsKey      = "fields"
+	fieldKey       = "field"
 	defInterval    = "1s"
 	defLimit       = 10
 	defOffset      = 0
 	defFormat      = "messages"
 )
 
-// MakeHandler returns a HTTP handler for API endpoints.
-func MakeHandler(svc readers.MessageRepository, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient, svcName, instanceID string) http.Handler {
+// MakeHandler returns a HTTP handler for API endpoints. Gap filling is
+// limited to maxBuckets buckets per request.
+func MakeHandler(svc readers.MessageRepository, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient, maxBuckets uint64, svcName, instanceID string) http.Handler {
 	opts := []kithttp.ServerOption{
 		kithttp.ServerErrorEncoder(encodeError),
 	}
 
 	mux := chi.NewRouter()
 	mux.Get("/channels/{chanID}/messages", kithttp.NewServer(
-		listMessagesEndpoint(svc, authn, clients, channels),
+		listMessagesEndpoint(svc, authn, clients, channels, maxBuckets),
 		decodeList,
 		encodeResponse,
 		opts...,
 	).ServeHTTP)
 
+	mux.Get("/channels/{chanID}/messages/latest", kithttp.NewServer(
+		listLatestEndpoint(svc, authn, clients, channels),
+		decodeChannelLatest,
+		encodeResponse,
+		opts...,
+	).ServeHTTP)
+
+	mux.Get("/messages/latest", kithttp.NewServer(
+		listLatestEndpoint(svc, authn, clients, channels),
+		decodeLatest,
+		encodeResponse,
+		opts...,
+	).ServeHTTP)
+
 	mux.Get("/health", mitras.Health(svcName, instanceID))
 	mux.Handle("/metrics", promhttp.Handler())
 
@@ -148,6 +170,26 @@ func decodeList(_ context.Context, r *http.Request) (interface{}, error) {
 		}
 	}
 
+	fill, err := apiutil.ReadStringQuery(r, fillKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	payload, err := readPayloadFilters(r)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	fields, err := apiutil.ReadStringQuery(r, fieldsKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	field, err := apiutil.ReadStringQuery(r, fieldKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
 	req := listMessagesReq{
 		chanID: chi.URLParam(r, "chanID"),
 		token:  apiutil.ExtractBearerToken(r),
@@ -169,6 +211,113 @@ func decodeList(_ context.Context, r *http.Request) (interface{}, error) {
 			To:          to,
 			Aggregation: aggregation,
 			Interval:    interval,
+			Fill:        fill,
+			Field:       field,
+		},
+	}
+	if len(payload) > 0 {
+		req.pageMeta.PayloadFilters = payload
+	}
+	if fields != "" {
+		req.pageMeta.Fields = strings.Split(fields, ",")
+	}
+	return req, nil
+}
+
+// readPayloadFilters reads JSON payload filters formatted as
+// <field>:<comparator>:<value>, i.e. payload=temp:gt:30.
+func readPayloadFilters(r *http.Request) ([]readers.PayloadFilter, error) {
+	var filters []readers.PayloadFilter
+	for _, val := range r.URL.Query()[payloadKey] {
+		parts := strings.SplitN(val, ":", 3)
+		if len(parts) != 3 {
+			return nil, apiutil.ErrInvalidPayloadFilter
+		}
+
+		filter := readers.PayloadFilter{
+			Field:      parts[0],
+			Comparator: parts[1],
+			Value:      parts[2],
+		}
+		if v, err := strconv.ParseFloat(parts[2], 64); err == nil {
+			filter.Value = v
+		} else if v, err := strconv.ParseBool(parts[2]); err == nil {
+			filter.Value = v
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
+
+func decodeChannelLatest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeLatest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	latestReq := req.(listLatestReq)
+	latestReq.chanIDs = []string{chi.URLParam(r, "chanID")}
+
+	return latestReq, nil
+}
+
+func decodeLatest(_ context.Context, r *http.Request) (interface{}, error) {
+	offset, err := apiutil.ReadNumQuery[uint64](r, offsetKey, defOffset)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	limit, err := apiutil.ReadNumQuery[uint64](r, limitKey, defLimit)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	format, err := apiutil.ReadStringQuery(r, formatKey, defFormat)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	subtopic, err := apiutil.ReadStringQuery(r, subtopicKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	publisher, err := apiutil.ReadStringQuery(r, publisherKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	protocol, err := apiutil.ReadStringQuery(r, protocolKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	name, err := apiutil.ReadStringQuery(r, nameKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	chans, err := apiutil.ReadStringQuery(r, channelsKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+	var chanIDs []string
+	if chans != "" {
+		chanIDs = strings.Split(chans, ",")
+	}
+
+	req := listLatestReq{
+		chanIDs: chanIDs,
+		token:   apiutil.ExtractBearerToken(r),
+		key:     apiutil.ExtractClientSecret(r),
+		pageMeta: readers.PageMetadata{
+			Offset:    offset,
+			Limit:     limit,
+			Format:    format,
+			Subtopic:  subtopic,
+			Publisher: publisher,
+			Protocol:  protocol,
+			Name:      name,
 		},
 	}
 	return req, nil
@@ -208,6 +357,12 @@ func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 		errors.Contains(err, apiutil.ErrInvalidComparator),
 		errors.Contains(err, apiutil.ErrInvalidAggregation),
 		errors.Contains(err, apiutil.ErrInvalidInterval),
+		errors.Contains(err, apiutil.ErrMissingAggregation),
+		errors.Contains(err, apiutil.ErrInvalidFill),
+		errors.Contains(err, apiutil.ErrTooManyBuckets),
+		errors.Contains(err, apiutil.ErrInvalidPayloadFilter),
+		errors.Contains(err, apiutil.ErrInvalidPayloadField),
+		errors.Contains(err, apiutil.ErrPayloadFormat),
 		errors.Contains(err, apiutil.ErrMissingFrom),
 		errors.Contains(err, apiutil.ErrMissingTo),
 		errors.Contains(err, apiutil.ErrMissingDomainID):
@@ -233,29 +388,31 @@ func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 	}
 }
 
-func authnAuthz(ctx context.Context, req listMessagesReq, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient) error {
-	clientID, clientType, err := authenticate(ctx, req, authn, clients)
+func authnAuthz(ctx context.Context, token, key string, chanIDs []string, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient, channels grpcChannelsV1.ChannelsServiceClient) error {
+	clientID, clientType, err := authenticate(ctx, token, key, authn, clients)
 	if err != nil {
-		return nil
+		return errors.Wrap(svcerr.ErrAuthentication, err)
 	}
-	if err := authorize(ctx, clientID, clientType, req.chanID, channels); err != nil {
-		return err
+	for _, chanID := range chanIDs {
+		if err := authorize(ctx, clientID, clientType, chanID, channels); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func authenticate(ctx context.Context, req listMessagesReq, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient) (clientID string, clientType string, err error) {
+func authenticate(ctx context.Context, token, key string, authn smqauthn.Authentication, clients grpcClientsV1.ClientsServiceClient) (clientID string, clientType string, err error) {
 	switch {
-	case req.token != "":
-		session, err := authn.Authenticate(ctx, req.token)
+	case token != "":
+		session, err := authn.Authenticate(ctx, token)
 		if err != nil {
 			return "", "", err
 		}
 
 		return session.DomainUserID, policies.UserType, nil
-	case req.key != "":
+	case key != "":
 		res, err := clients.Authenticate(ctx, &grpcClientsV1.AuthnReq{
-			ClientSecret: req.key,
+			ClientSecret: key,
 		})
 		if err != nil {
 			return "", "", err
@@ -1,6 +1,9 @@
 package readers
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 const (
 	// EqualKey represents the equal comparison operator key.
@@ -15,6 +18,14 @@ const (
 	GreaterThanEqualKey = "ge"
 )
 
+const (
+	// FillNull fills aggregation buckets without messages with null values.
+	FillNull = "null"
+	// FillPrevious fills aggregation buckets without messages with the last
+	// observed aggregated values.
+	FillPrevious = "previous"
+)
+
 // ErrReadMessages indicates failure occurred while reading messages from database.
 var ErrReadMessages = errors.New("failed to read messages from database")
 
@@ -25,6 +36,12 @@ type MessageRepository interface {
 	// ReadAll skips given number of messages for given channel and returns next
 	// limited number of messages.
 	ReadAll(chanID string, pm PageMetadata) (MessagesPage, error)
+
+	// ReadLatest returns the most recent message of every series of the given
+	// channels. SenML series are identified by channel, publisher and name,
+	// while JSON series are identified by channel, publisher and top-level
+	// payload key.
+	ReadLatest(chanIDs []string, pm PageMetadata) (MessagesPage, error)
 }
 
 // Message represents any message format.
@@ -56,6 +73,50 @@ type PageMetadata struct {
 	Format      string  `json:"format,omitempty"`
 	Aggregation string  `json:"aggregation,omitempty"`
 	Interval    string  `json:"interval,omitempty"`
+	Fill        string  `json:"fill,omitempty"`
+	// PayloadFilters, Fields and Field apply to JSON messages only.
+	PayloadFilters []PayloadFilter `json:"payload_filters,omitempty"`
+	Fields         []string        `json:"fields,omitempty"`
+	Field          string          `json:"field,omitempty"`
+}
+
+// PayloadFilter filters JSON messages by the value of the payload field.
+// Field is a dot-separated path of the field in the payload. Value is a
+// number, boolean or string.
+type PayloadFilter struct {
+	Field      string      `json:"field"`
+	Comparator string      `json:"comparator,omitempty"`
+	Value      interface{} `json:"value"`
+}
+
+// FieldPath splits dot-separated JSON payload field into path elements.
+func FieldPath(field string) []string {
+	return strings.Split(field, ".")
+}
+
+// Aggregations returns the upper-cased aggregation functions requested as a
+// comma-separated list in Aggregation.
+func (pm PageMetadata) Aggregations() []string {
+	var aggs []string
+	for _, agg := range strings.Split(pm.Aggregation, ",") {
+		if agg = strings.ToUpper(strings.TrimSpace(agg)); agg != "" {
+			aggs = append(aggs, agg)
+		}
+	}
+
+	return aggs
+}
+
+// Aggregate represents a time bucket of a series aggregated with multiple
+// aggregation functions. Values are keyed by lower-cased function names.
+type Aggregate struct {
+	Time      float64             `json:"time"`
+	Publisher string              `json:"publisher,omitempty"`
+	Protocol  string              `json:"protocol,omitempty"`
+	Subtopic  string              `json:"subtopic,omitempty"`
+	Name      string              `json:"name,omitempty"`
+	Unit      string              `json:"unit,omitempty"`
+	Values    map[string]*float64 `json:"values"`
 }
 
 // ParseValueComparator convert comparison operator keys into mathematic anotation.
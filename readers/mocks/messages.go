@@ -40,6 +40,34 @@ func (_m *MessageRepository) ReadAll(chanID string, pm readers.PageMetadata) (re
 	return r0, r1
 }
 
+// ReadLatest provides a mock function with given fields: chanIDs, pm
+func (_m *MessageRepository) ReadLatest(chanIDs []string, pm readers.PageMetadata) (readers.MessagesPage, error) {
+	ret := _m.Called(chanIDs, pm)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReadLatest")
+	}
+
+	var r0 readers.MessagesPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]string, readers.PageMetadata) (readers.MessagesPage, error)); ok {
+		return rf(chanIDs, pm)
+	}
+	if rf, ok := ret.Get(0).(func([]string, readers.PageMetadata) readers.MessagesPage); ok {
+		r0 = rf(chanIDs, pm)
+	} else {
+		r0 = ret.Get(0).(readers.MessagesPage)
+	}
+
+	if rf, ok := ret.Get(1).(func([]string, readers.PageMetadata) error); ok {
+		r1 = rf(chanIDs, pm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewMessageRepository creates a new instance of MessageRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMessageRepository(t interface {
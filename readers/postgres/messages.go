@@ -3,6 +3,7 @@ package postgres
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/hantdev/mitras/pkg/errors"
 	"github.com/hantdev/mitras/pkg/transformers/senml"
@@ -12,6 +13,13 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+const (
+	// Divisor converting message time in nanoseconds to seconds.
+	timeDivisor = 1000000000
+	// Origin of the aggregation time buckets.
+	binOrigin = "TIMESTAMPTZ 'epoch'"
+)
+
 var _ readers.MessageRepository = (*postgresRepository)(nil)
 
 type postgresRepository struct {
@@ -33,14 +41,92 @@ func (tr postgresRepository) ReadAll(chanID string, rpm readers.PageMetadata) (r
 		order = "created"
 		format = rpm.Format
 	}
-	cond := fmtCondition(chanID, rpm)
+	params := map[string]interface{}{
+		"channel":      chanID,
+		"limit":        rpm.Limit,
+		"offset":       rpm.Offset,
+		"subtopic":     rpm.Subtopic,
+		"publisher":    rpm.Publisher,
+		"name":         rpm.Name,
+		"protocol":     rpm.Protocol,
+		"value":        rpm.Value,
+		"bool_value":   rpm.BoolValue,
+		"string_value": rpm.StringValue,
+		"data_value":   rpm.DataValue,
+		"from":         rpm.From,
+		"to":           rpm.To,
+	}
+
+	payloadParams(rpm, params)
 
-	q := fmt.Sprintf(`SELECT * FROM %s
+	if rpm.Aggregation != "" {
+		return tr.readAggregates(chanID, format, rpm, params)
+	}
+
+	cols := "*"
+	if format != defTable {
+		cols = fmt.Sprintf("id, channel, created, subtopic, publisher, protocol, %s", fmtPayload(rpm))
+	}
+	cond := fmtCondition(chanID, rpm)
+	q := fmt.Sprintf(`SELECT %s FROM %s
     WHERE %s ORDER BY %s DESC
-	LIMIT :limit OFFSET :offset;`, format, cond, order)
+	LIMIT :limit OFFSET :offset;`, cols, format, cond, order)
+
+	rows, err := tr.db.NamedQuery(q, params)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok {
+			if pgErr.Code == pgerrcode.UndefinedTable {
+				return readers.MessagesPage{}, nil
+			}
+		}
+		return readers.MessagesPage{}, errors.Wrap(readers.ErrReadMessages, err)
+	}
+	defer rows.Close()
 
+	page := readers.MessagesPage{
+		PageMetadata: rpm,
+		Messages:     []readers.Message{},
+	}
+	switch format {
+	case defTable:
+		for rows.Next() {
+			msg := senmlMessage{Message: senml.Message{}}
+			if err := rows.StructScan(&msg); err != nil {
+				return readers.MessagesPage{}, errors.Wrap(readers.ErrReadMessages, err)
+			}
+
+			page.Messages = append(page.Messages, msg.Message)
+		}
+	default:
+		for rows.Next() {
+			msg := jsonMessage{}
+			if err := rows.StructScan(&msg); err != nil {
+				return readers.MessagesPage{}, errors.Wrap(readers.ErrReadMessages, err)
+			}
+			m, err := msg.toMap()
+			if err != nil {
+				return readers.MessagesPage{}, errors.Wrap(readers.ErrReadMessages, err)
+			}
+			page.Messages = append(page.Messages, m)
+		}
+	}
+
+	total, err := tr.total(fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s;`, format, cond), params)
+	if err != nil {
+		return page, err
+	}
+	page.Total = total
+
+	return page, nil
+}
+
+func (tr postgresRepository) ReadLatest(chanIDs []string, rpm readers.PageMetadata) (readers.MessagesPage, error) {
+	format := defTable
+	if rpm.Format != "" && rpm.Format != defTable {
+		format = rpm.Format
+	}
 	params := map[string]interface{}{
-		"channel":      chanID,
+		"channels":     chanIDs,
 		"limit":        rpm.Limit,
 		"offset":       rpm.Offset,
 		"subtopic":     rpm.Subtopic,
@@ -54,6 +140,21 @@ func (tr postgresRepository) ReadAll(chanID string, rpm readers.PageMetadata) (r
 		"from":         rpm.From,
 		"to":           rpm.To,
 	}
+	payloadParams(rpm, params)
+	cond := fmtFilters(`channel = ANY(:channels)`, rpm)
+
+	latest := fmt.Sprintf(`SELECT DISTINCT ON (channel, publisher, name) * FROM %s
+		WHERE %s ORDER BY channel, publisher, name, time DESC`, format, cond)
+	order := "time"
+	if format != defTable {
+		// Every top-level key of the JSON payloads is a separate series.
+		latest = fmt.Sprintf(`SELECT DISTINCT ON (channel, publisher, field) id, channel, created, subtopic, publisher, protocol, jsonb_build_object(field, val) AS payload
+			FROM %s, jsonb_each(payload) AS kv(field, val)
+			WHERE %s ORDER BY channel, publisher, field, created DESC`, format, cond)
+		order = "created"
+	}
+
+	q := fmt.Sprintf(`SELECT * FROM (%s) AS latest ORDER BY %s DESC LIMIT :limit OFFSET :offset;`, latest, order)
 	rows, err := tr.db.NamedQuery(q, params)
 	if err != nil {
 		if pgErr, ok := err.(*pgconn.PgError); ok {
@@ -93,27 +194,120 @@ func (tr postgresRepository) ReadAll(chanID string, rpm readers.PageMetadata) (r
 		}
 	}
 
-	q = fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s;`, format, cond)
-	rows, err = tr.db.NamedQuery(q, params)
+	total, err := tr.total(fmt.Sprintf(`SELECT COUNT(*) FROM (%s) AS latest;`, latest), params)
 	if err != nil {
+		return page, err
+	}
+	page.Total = total
+
+	return page, nil
+}
+
+// readAggregates reads time buckets of the messages aggregated with the
+// requested aggregation functions. Buckets are aligned to the Unix epoch.
+// Empty buckets are filled if requested.
+func (tr postgresRepository) readAggregates(chanID, format string, rpm readers.PageMetadata, params map[string]interface{}) (readers.MessagesPage, error) {
+	aggs := rpm.Aggregations()
+
+	names := make([]string, len(aggs))
+	values := make([]string, len(aggs))
+	for i, agg := range aggs {
+		names[i] = strings.ToLower(agg)
+		values[i] = fmt.Sprintf("%s(value) AS %s", agg, names[i])
+	}
+
+	source, cond := format, fmtCondition(chanID, rpm)
+	if format != defTable {
+		// Numeric JSON payload field is aggregated as a SenML value series.
+		source = fmt.Sprintf(`(SELECT created AS time, publisher, protocol, subtopic, CAST(:field AS TEXT) AS name, CAST(NULL AS TEXT) AS unit, %s AS value
+			FROM %s WHERE %s AND %s IS NOT NULL) AS fields`, jsonNumber(":field_path"), format, cond, jsonNumber(":field_path"))
+		cond = "TRUE"
+	}
+
+	series := fmt.Sprintf(`SELECT date_bin('%s', to_timestamp(time / %d), %s) AS bucket,
+		(ARRAY_AGG(publisher ORDER BY time))[1] AS publisher, (ARRAY_AGG(protocol ORDER BY time))[1] AS protocol,
+		(ARRAY_AGG(subtopic ORDER BY time))[1] AS subtopic, (ARRAY_AGG(name ORDER BY time))[1] AS name,
+		(ARRAY_AGG(unit ORDER BY time))[1] AS unit, %s
+		FROM %s WHERE %s GROUP BY 1`, rpm.Interval, timeDivisor, binOrigin, strings.Join(values, ", "), source, cond)
+
+	q := fmt.Sprintf(`SELECT EXTRACT(epoch FROM bucket) * %d AS time, publisher, protocol, subtopic, name, unit, %s
+		FROM (%s) AS series ORDER BY time DESC LIMIT :limit OFFSET :offset;`, timeDivisor, strings.Join(names, ", "), series)
+	totalQuery := fmt.Sprintf(`SELECT COUNT(*) FROM (%s) AS series;`, series)
+
+	if rpm.Fill != "" {
+		buckets := fmt.Sprintf(`SELECT generate_series(date_bin('%s', to_timestamp(:from / %d), %s), to_timestamp(:to / %d) - INTERVAL '1 microsecond', INTERVAL '%s') AS bucket`,
+			rpm.Interval, timeDivisor, binOrigin, timeDivisor, rpm.Interval)
+
+		// Last observation is carried forward by grouping every empty bucket
+		// with the closest preceding non-empty bucket.
+		groups := make([]string, len(aggs))
+		filled := make([]string, len(aggs))
+		for i, name := range names {
+			groups[i] = fmt.Sprintf("series.%s, COUNT(series.%s) OVER (ORDER BY buckets.bucket) AS %s_group", name, name, name)
+			filled[i] = name
+			if rpm.Fill == readers.FillPrevious {
+				filled[i] = fmt.Sprintf("FIRST_VALUE(%s) OVER (PARTITION BY %s_group ORDER BY bucket) AS %s", name, name, name)
+			}
+		}
+
+		q = fmt.Sprintf(`SELECT EXTRACT(epoch FROM bucket) * %d AS time, publisher, protocol, subtopic, name, unit, %s
+			FROM (SELECT buckets.bucket, series.publisher, series.protocol, series.subtopic, series.name, series.unit, %s
+			FROM (%s) AS buckets LEFT JOIN (%s) AS series ON series.bucket = buckets.bucket) AS filled
+			ORDER BY time DESC LIMIT :limit OFFSET :offset;`, timeDivisor, strings.Join(filled, ", "), strings.Join(groups, ", "), buckets, series)
+		totalQuery = fmt.Sprintf(`SELECT COUNT(*) FROM (%s) AS buckets;`, buckets)
+	}
+
+	rows, err := tr.db.NamedQuery(q, params)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok {
+			if pgErr.Code == pgerrcode.UndefinedTable {
+				return readers.MessagesPage{}, nil
+			}
+		}
 		return readers.MessagesPage{}, errors.Wrap(readers.ErrReadMessages, err)
 	}
 	defer rows.Close()
 
+	msgs, err := readers.ScanAggregates(rows, aggs)
+	if err != nil {
+		return readers.MessagesPage{}, errors.Wrap(readers.ErrReadMessages, err)
+	}
+
+	total, err := tr.total(totalQuery, params)
+	if err != nil {
+		return readers.MessagesPage{}, err
+	}
+
+	return readers.MessagesPage{
+		PageMetadata: rpm,
+		Total:        total,
+		Messages:     msgs,
+	}, nil
+}
+
+func (tr postgresRepository) total(q string, params map[string]interface{}) (uint64, error) {
+	rows, err := tr.db.NamedQuery(q, params)
+	if err != nil {
+		return 0, errors.Wrap(readers.ErrReadMessages, err)
+	}
+	defer rows.Close()
+
 	total := uint64(0)
 	if rows.Next() {
 		if err := rows.Scan(&total); err != nil {
-			return page, err
+			return 0, err
 		}
 	}
-	page.Total = total
 
-	return page, nil
+	return total, nil
 }
 
 func fmtCondition(chanID string, rpm readers.PageMetadata) string {
-	condition := `channel = :channel`
+	return fmtFilters(`channel = :channel`, rpm)
+}
 
+// fmtFilters appends the filters of the page metadata to the condition.
+func fmtFilters(condition string, rpm readers.PageMetadata) string {
 	var query map[string]interface{}
 	meta, err := json.Marshal(rpm)
 	if err != nil {
@@ -154,14 +348,83 @@ func fmtCondition(chanID string, rpm readers.PageMetadata) string {
 			comparator := readers.ParseValueComparator(query)
 			condition = fmt.Sprintf(`%s AND data_value %s :data_value`, condition, comparator)
 		case "from":
-			condition = fmt.Sprintf(`%s AND time >= :from`, condition)
+			condition = fmt.Sprintf(`%s AND %s >= :from`, condition, timeColumn(rpm))
 		case "to":
-			condition = fmt.Sprintf(`%s AND time < :to`, condition)
+			condition = fmt.Sprintf(`%s AND %s < :to`, condition, timeColumn(rpm))
 		}
 	}
+	for i, pf := range rpm.PayloadFilters {
+		condition = fmt.Sprintf(`%s AND %s`, condition, fmtPayloadFilter(i, pf))
+	}
 	return condition
 }
 
+// timeColumn returns the column of the message time. JSON messages are
+// stored with the creation time.
+func timeColumn(rpm readers.PageMetadata) string {
+	if rpm.Format != "" && rpm.Format != defTable {
+		return "created"
+	}
+	return "time"
+}
+
+// fmtPayloadFilter formats the condition of the JSON payload filter. Field
+// paths and values are passed as query parameters.
+func fmtPayloadFilter(i int, pf readers.PayloadFilter) string {
+	path, value := fmt.Sprintf(":payload_path_%d", i), fmt.Sprintf(":payload_value_%d", i)
+	comparator := readers.ParseValueComparator(map[string]interface{}{"comparator": pf.Comparator})
+
+	switch pf.Value.(type) {
+	case float64:
+		return fmt.Sprintf(`%s %s %s`, jsonNumber(path), comparator, value)
+	case bool:
+		return fmt.Sprintf(`(jsonb_typeof(payload #> %s) = 'boolean' AND payload #>> %s %s %s)`, path, path, comparator, value)
+	default:
+		return fmt.Sprintf(`(jsonb_typeof(payload #> %s) = 'string' AND payload #>> %s %s %s)`, path, path, comparator, value)
+	}
+}
+
+// jsonNumber returns the value of the JSON payload field at the path, or
+// NULL if the field is not a number.
+func jsonNumber(path string) string {
+	return fmt.Sprintf(`(CASE WHEN jsonb_typeof(payload #> %s) = 'number' THEN CAST(payload #>> %s AS DOUBLE PRECISION) END)`, path, path)
+}
+
+// fmtPayload returns the payload column, projected to the requested JSON
+// payload fields if any.
+func fmtPayload(rpm readers.PageMetadata) string {
+	if len(rpm.Fields) == 0 {
+		return "payload"
+	}
+
+	fields := make([]string, len(rpm.Fields))
+	for i := range rpm.Fields {
+		fields[i] = fmt.Sprintf("CAST(:field_%d AS TEXT), payload #> :field_path_%d", i, i)
+	}
+	return fmt.Sprintf("jsonb_strip_nulls(jsonb_build_object(%s)) AS payload", strings.Join(fields, ", "))
+}
+
+// payloadParams adds the parameters of the JSON payload queries.
+func payloadParams(rpm readers.PageMetadata, params map[string]interface{}) {
+	for i, pf := range rpm.PayloadFilters {
+		params[fmt.Sprintf("payload_path_%d", i)] = readers.FieldPath(pf.Field)
+		switch v := pf.Value.(type) {
+		case float64:
+			params[fmt.Sprintf("payload_value_%d", i)] = v
+		default:
+			params[fmt.Sprintf("payload_value_%d", i)] = fmt.Sprint(v)
+		}
+	}
+	for i, field := range rpm.Fields {
+		params[fmt.Sprintf("field_%d", i)] = field
+		params[fmt.Sprintf("field_path_%d", i)] = readers.FieldPath(field)
+	}
+	if rpm.Field != "" {
+		params["field"] = rpm.Field
+		params["field_path"] = readers.FieldPath(rpm.Field)
+	}
+}
+
 type senmlMessage struct {
 	ID string `db:"id"`
 	senml.Message
@@ -515,6 +515,213 @@ func TestReadSenml(t *testing.T) {
 	}
 }
 
+func TestReadAggregates(t *testing.T) {
+	writer := pwriter.New(db)
+
+	chanID := testsutil.GenerateUUID(t)
+	pubID := testsutil.GenerateUUID(t)
+
+	// The buckets are base, base + 10s and base + 20s, where the middle
+	// bucket has no messages.
+	base := time.Now().Add(-24 * time.Hour).Truncate(time.Hour)
+	values := []float64{1, 3, 5}
+	offsets := []time.Duration{time.Second, 2 * time.Second, 21 * time.Second}
+	messages := []senml.Message{}
+	for i := range values {
+		messages = append(messages, senml.Message{
+			Channel:   chanID,
+			Publisher: pubID,
+			Protocol:  mqttProt,
+			Name:      msgName,
+			Time:      float64(base.Add(offsets[i]).UnixNano()),
+			Value:     &values[i],
+		})
+	}
+	err := writer.ConsumeBlocking(context.TODO(), messages)
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+
+	reader := preader.New(db)
+
+	bucket := func(offset time.Duration) float64 {
+		return float64(base.Add(offset).UnixNano())
+	}
+	value := func(v float64) *float64 {
+		return &v
+	}
+	pageMeta := readers.PageMetadata{
+		Limit:    limit,
+		Interval: "10 seconds",
+		From:     float64(base.UnixNano()),
+		To:       float64(base.Add(30 * time.Second).UnixNano()),
+	}
+
+	cases := []struct {
+		desc        string
+		aggregation string
+		fill        string
+		times       []float64
+		values      []map[string]*float64
+	}{
+		{
+			desc:        "read messages aggregated with multiple aggregations",
+			aggregation: "MIN,MAX,AVG",
+			times:       []float64{bucket(20 * time.Second), bucket(0)},
+			values: []map[string]*float64{
+				{"min": value(5), "max": value(5), "avg": value(5)},
+				{"min": value(1), "max": value(3), "avg": value(2)},
+			},
+		},
+		{
+			desc:        "read messages aggregated with null fill",
+			aggregation: "AVG",
+			fill:        readers.FillNull,
+			times:       []float64{bucket(20 * time.Second), bucket(10 * time.Second), bucket(0)},
+			values:      []map[string]*float64{{"avg": value(5)}, {"avg": nil}, {"avg": value(2)}},
+		},
+		{
+			desc:        "read messages aggregated with previous fill",
+			aggregation: "AVG",
+			fill:        readers.FillPrevious,
+			times:       []float64{bucket(20 * time.Second), bucket(10 * time.Second), bucket(0)},
+			values:      []map[string]*float64{{"avg": value(5)}, {"avg": value(2)}, {"avg": value(2)}},
+		},
+		{
+			desc:        "read messages aggregated with multiple aggregations and previous fill",
+			aggregation: "MIN,MAX",
+			fill:        readers.FillPrevious,
+			times:       []float64{bucket(20 * time.Second), bucket(10 * time.Second), bucket(0)},
+			values: []map[string]*float64{
+				{"min": value(5), "max": value(5)},
+				{"min": value(1), "max": value(3)},
+				{"min": value(1), "max": value(3)},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			pm := pageMeta
+			pm.Aggregation = tc.aggregation
+			pm.Fill = tc.fill
+			page, err := reader.ReadAll(chanID, pm)
+			require.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", tc.desc, err))
+			assert.Equal(t, uint64(len(tc.times)), page.Total, fmt.Sprintf("%s: expected total %d got %d", tc.desc, len(tc.times), page.Total))
+			require.Len(t, page.Messages, len(tc.times))
+			for i, msg := range page.Messages {
+				var (
+					t0   float64
+					vals map[string]*float64
+				)
+				switch m := msg.(type) {
+				case senml.Message:
+					t0, vals = m.Time, map[string]*float64{"avg": m.Value}
+				case readers.Aggregate:
+					t0, vals = m.Time, m.Values
+				}
+				assert.InDelta(t, tc.times[i], t0, float64(time.Millisecond), fmt.Sprintf("%s: unexpected bucket time", tc.desc))
+				assert.Equal(t, tc.values[i], vals, fmt.Sprintf("%s: unexpected bucket values", tc.desc))
+			}
+		})
+	}
+}
+
+func TestReadLatest(t *testing.T) {
+	writer := pwriter.New(db)
+
+	chanID := testsutil.GenerateUUID(t)
+	chanID2 := testsutil.GenerateUUID(t)
+	pubID := testsutil.GenerateUUID(t)
+	pubID2 := testsutil.GenerateUUID(t)
+	wrongID := testsutil.GenerateUUID(t)
+
+	now := float64(time.Now().Unix())
+	messages := []senml.Message{}
+	for i := 0; i < msgsNum; i++ {
+		// Every fourth message belongs to the same series.
+		msg := senml.Message{
+			Channel:   chanID,
+			Publisher: pubID,
+			Protocol:  mqttProt,
+			Name:      msgName,
+			Time:      now - float64(i),
+			Value:     &v,
+		}
+		switch i % 4 {
+		case 1:
+			msg.Name = "humidity"
+		case 2:
+			msg.Publisher = pubID2
+		case 3:
+			msg.Channel = chanID2
+		}
+		messages = append(messages, msg)
+	}
+
+	err := writer.ConsumeBlocking(context.TODO(), messages)
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+
+	reader := preader.New(db)
+
+	cases := []struct {
+		desc     string
+		chanIDs  []string
+		pageMeta readers.PageMetadata
+		page     readers.MessagesPage
+	}{
+		{
+			desc:     "read latest messages of channel",
+			chanIDs:  []string{chanID},
+			pageMeta: readers.PageMetadata{Limit: limit},
+			page: readers.MessagesPage{
+				Total:    3,
+				Messages: fromSenml(messages[0:3]),
+			},
+		},
+		{
+			desc:     "read latest messages of multiple channels",
+			chanIDs:  []string{chanID, chanID2},
+			pageMeta: readers.PageMetadata{Limit: limit},
+			page: readers.MessagesPage{
+				Total:    4,
+				Messages: fromSenml(messages[0:4]),
+			},
+		},
+		{
+			desc:     "read latest messages of channel with publisher",
+			chanIDs:  []string{chanID},
+			pageMeta: readers.PageMetadata{Limit: limit, Publisher: pubID2},
+			page: readers.MessagesPage{
+				Total:    1,
+				Messages: fromSenml(messages[2:3]),
+			},
+		},
+		{
+			desc:     "read latest messages of channel with offset",
+			chanIDs:  []string{chanID},
+			pageMeta: readers.PageMetadata{Offset: 1, Limit: limit},
+			page: readers.MessagesPage{
+				Total:    3,
+				Messages: fromSenml(messages[1:3]),
+			},
+		},
+		{
+			desc:     "read latest messages of non-existent channel",
+			chanIDs:  []string{wrongID},
+			pageMeta: readers.PageMetadata{Limit: limit},
+			page: readers.MessagesPage{
+				Messages: []readers.Message{},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		result, err := reader.ReadLatest(tc.chanIDs, tc.pageMeta)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s", tc.desc, err))
+		assert.ElementsMatch(t, tc.page.Messages, result.Messages, fmt.Sprintf("%s: got incorrect list of senml Messages from ReadLatest()", tc.desc))
+		assert.Equal(t, tc.page.Total, result.Total, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.page.Total, result.Total))
+	}
+}
+
 func TestReadJSON(t *testing.T) {
 	writer := pwriter.New(db)
 
@@ -585,6 +792,19 @@ func TestReadJSON(t *testing.T) {
 		httpMsgs = append(httpMsgs, msgs2[i])
 	}
 
+	projectedMsgs := []map[string]interface{}{}
+	for _, msg := range msgs1 {
+		m := map[string]interface{}{}
+		for k, v := range msg {
+			m[k] = v
+		}
+		m["payload"] = map[string]interface{}{
+			"field_1":         123.0,
+			"field_5.field_2": 42.0,
+		}
+		projectedMsgs = append(projectedMsgs, m)
+	}
+
 	reader := preader.New(db)
 
 	cases := map[string]struct {
@@ -640,6 +860,66 @@ func TestReadJSON(t *testing.T) {
 				Messages: fromJSON(httpMsgs),
 			},
 		},
+		"read message with numeric payload filter": {
+			chanID: id2,
+			pageMeta: readers.PageMetadata{
+				Format: messages2.Format,
+				Offset: 0,
+				Limit:  msgsNum,
+				PayloadFilters: []readers.PayloadFilter{
+					{Field: "field_pi", Comparator: readers.GreaterThanKey, Value: 3.0},
+				},
+			},
+			page: readers.MessagesPage{
+				Total:    msgsNum,
+				Messages: fromJSON(msgs2),
+			},
+		},
+		"read message with boolean payload filter": {
+			chanID: id2,
+			pageMeta: readers.PageMetadata{
+				Format: messages2.Format,
+				Offset: 0,
+				Limit:  msgsNum,
+				PayloadFilters: []readers.PayloadFilter{
+					{Field: "false_value", Comparator: readers.EqualKey, Value: false},
+				},
+			},
+			page: readers.MessagesPage{
+				Total:    msgsNum,
+				Messages: fromJSON(msgs2),
+			},
+		},
+		"read message with non-matching string payload filter": {
+			chanID: id2,
+			pageMeta: readers.PageMetadata{
+				Format: messages2.Format,
+				Offset: 0,
+				Limit:  msgsNum,
+				PayloadFilters: []readers.PayloadFilter{
+					{Field: "field_1", Comparator: readers.EqualKey, Value: "value"},
+				},
+			},
+			page: readers.MessagesPage{
+				Messages: []readers.Message{},
+			},
+		},
+		"read message with nested payload filter and projection": {
+			chanID: id1,
+			pageMeta: readers.PageMetadata{
+				Format: messages1.Format,
+				Offset: 0,
+				Limit:  msgsNum,
+				PayloadFilters: []readers.PayloadFilter{
+					{Field: "field_5.field_2", Comparator: readers.EqualKey, Value: 42.0},
+				},
+				Fields: []string{"field_1", "field_5.field_2"},
+			},
+			page: readers.MessagesPage{
+				Total:    msgsNum,
+				Messages: fromJSON(projectedMsgs),
+			},
+		},
 	}
 
 	for desc, tc := range cases {
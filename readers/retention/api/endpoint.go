@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/hantdev/mitras/internal/api"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	"github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/mitras/readers/retention"
+)
+
+func createPolicyEndpoint(svc retention.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createPolicyReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		policy := retention.Policy{
+			ChannelID:          req.ChannelID,
+			RawDays:            req.RawDays,
+			DownsampleInterval: req.DownsampleInterval,
+			DownsampleDays:     req.DownsampleDays,
+		}
+		policy, err := svc.CreatePolicy(ctx, session, policy)
+		if err != nil {
+			return nil, err
+		}
+
+		return policyRes{Policy: policy, created: true}, nil
+	}
+}
+
+func viewPolicyEndpoint(svc retention.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(policyReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		policy, err := svc.ViewPolicy(ctx, session, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return policyRes{Policy: policy}, nil
+	}
+}
+
+func listPoliciesEndpoint(svc retention.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listPoliciesReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		page, err := svc.ListPolicies(ctx, session, req.page)
+		if err != nil {
+			return nil, err
+		}
+
+		return policiesPageRes{PoliciesPage: page}, nil
+	}
+}
+
+func updatePolicyEndpoint(svc retention.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(updatePolicyReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		policy := retention.Policy{
+			ID:                 req.id,
+			RawDays:            req.RawDays,
+			DownsampleInterval: req.DownsampleInterval,
+			DownsampleDays:     req.DownsampleDays,
+		}
+		policy, err := svc.UpdatePolicy(ctx, session, policy)
+		if err != nil {
+			return nil, err
+		}
+
+		return policyRes{Policy: policy}, nil
+	}
+}
+
+func removePolicyEndpoint(svc retention.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(policyReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthorization
+		}
+
+		if err := svc.RemovePolicy(ctx, session, req.id); err != nil {
+			return nil, err
+		}
+
+		return removePolicyRes{}, nil
+	}
+}
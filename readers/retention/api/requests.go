@@ -0,0 +1,86 @@
+package api
+
+import (
+	"time"
+
+	"github.com/hantdev/mitras/internal/api"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	"github.com/hantdev/mitras/readers/retention"
+)
+
+type createPolicyReq struct {
+	ChannelID          string `json:"channel_id,omitempty"`
+	RawDays            uint64 `json:"raw_days"`
+	DownsampleInterval string `json:"downsample_interval,omitempty"`
+	DownsampleDays     uint64 `json:"downsample_days,omitempty"`
+}
+
+func (req createPolicyReq) validate() error {
+	if req.ChannelID != "" {
+		if err := api.ValidateUUID(req.ChannelID); err != nil {
+			return err
+		}
+	}
+
+	return validateLimits(req.RawDays, req.DownsampleInterval, req.DownsampleDays)
+}
+
+type updatePolicyReq struct {
+	id                 string
+	RawDays            uint64 `json:"raw_days"`
+	DownsampleInterval string `json:"downsample_interval,omitempty"`
+	DownsampleDays     uint64 `json:"downsample_days,omitempty"`
+}
+
+func (req updatePolicyReq) validate() error {
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	return validateLimits(req.RawDays, req.DownsampleInterval, req.DownsampleDays)
+}
+
+type policyReq struct {
+	id string
+}
+
+func (req policyReq) validate() error {
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	return nil
+}
+
+type listPoliciesReq struct {
+	page retention.Page
+}
+
+func (req listPoliciesReq) validate() error {
+	if req.page.Limit > api.MaxLimitSize {
+		return apiutil.ErrLimitSize
+	}
+
+	return nil
+}
+
+func validateLimits(rawDays uint64, interval string, downsampleDays uint64) error {
+	if rawDays == 0 {
+		return apiutil.ErrMissingRetention
+	}
+
+	if interval == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		return apiutil.ErrInvalidInterval
+	}
+
+	if downsampleDays != 0 && downsampleDays <= rawDays {
+		return apiutil.ErrInvalidRetention
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hantdev/mitras"
+	"github.com/hantdev/mitras/readers/retention"
+)
+
+var (
+	_ mitras.Response = (*policyRes)(nil)
+	_ mitras.Response = (*policiesPageRes)(nil)
+	_ mitras.Response = (*removePolicyRes)(nil)
+)
+
+type policyRes struct {
+	retention.Policy `json:",inline"`
+	created          bool
+}
+
+func (res policyRes) Code() int {
+	if res.created {
+		return http.StatusCreated
+	}
+
+	return http.StatusOK
+}
+
+func (res policyRes) Headers() map[string]string {
+	if res.created {
+		return map[string]string{
+			"Location": fmt.Sprintf("/%s/retention/policies/%s", res.DomainID, res.ID),
+		}
+	}
+
+	return map[string]string{}
+}
+
+func (res policyRes) Empty() bool {
+	return false
+}
+
+type policiesPageRes struct {
+	retention.PoliciesPage `json:",inline"`
+}
+
+func (res policiesPageRes) Code() int {
+	return http.StatusOK
+}
+
+func (res policiesPageRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res policiesPageRes) Empty() bool {
+	return false
+}
+
+type removePolicyRes struct{}
+
+func (res removePolicyRes) Code() int {
+	return http.StatusNoContent
+}
+
+func (res removePolicyRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res removePolicyRes) Empty() bool {
+	return true
+}
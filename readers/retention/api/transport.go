@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/hantdev/mitras/internal/api"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/mitras/readers/retention"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const channelIDKey = "channel_id"
+
+// MakeHandler registers retention policy management routes on the given mux.
+func MakeHandler(svc retention.Service, authn smqauthn.Authentication, mux *chi.Mux, logger *slog.Logger) *chi.Mux {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorEncoder(apiutil.LoggingErrorEncoder(logger, api.EncodeError)),
+	}
+
+	mux.Route("/{domainID}/retention/policies", func(r chi.Router) {
+		r.Use(api.AuthenticateMiddleware(authn, true))
+
+		r.Post("/", otelhttp.NewHandler(kithttp.NewServer(
+			createPolicyEndpoint(svc),
+			decodeCreatePolicyReq,
+			api.EncodeResponse,
+			opts...,
+		), "create_retention_policy").ServeHTTP)
+
+		r.Get("/", otelhttp.NewHandler(kithttp.NewServer(
+			listPoliciesEndpoint(svc),
+			decodeListPoliciesReq,
+			api.EncodeResponse,
+			opts...,
+		), "list_retention_policies").ServeHTTP)
+
+		r.Get("/{policyID}", otelhttp.NewHandler(kithttp.NewServer(
+			viewPolicyEndpoint(svc),
+			decodePolicyReq,
+			api.EncodeResponse,
+			opts...,
+		), "view_retention_policy").ServeHTTP)
+
+		r.Patch("/{policyID}", otelhttp.NewHandler(kithttp.NewServer(
+			updatePolicyEndpoint(svc),
+			decodeUpdatePolicyReq,
+			api.EncodeResponse,
+			opts...,
+		), "update_retention_policy").ServeHTTP)
+
+		r.Delete("/{policyID}", otelhttp.NewHandler(kithttp.NewServer(
+			removePolicyEndpoint(svc),
+			decodePolicyReq,
+			api.EncodeResponse,
+			opts...,
+		), "remove_retention_policy").ServeHTTP)
+	})
+
+	return mux
+}
+
+func decodeCreatePolicyReq(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := createPolicyReq{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
+
+func decodeUpdatePolicyReq(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := updatePolicyReq{id: chi.URLParam(r, "policyID")}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
+
+func decodePolicyReq(_ context.Context, r *http.Request) (interface{}, error) {
+	return policyReq{id: chi.URLParam(r, "policyID")}, nil
+}
+
+func decodeListPoliciesReq(_ context.Context, r *http.Request) (interface{}, error) {
+	offset, err := apiutil.ReadNumQuery[uint64](r, api.OffsetKey, api.DefOffset)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	limit, err := apiutil.ReadNumQuery[uint64](r, api.LimitKey, api.DefLimit)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	chanID, err := apiutil.ReadStringQuery(r, channelIDKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	req := listPoliciesReq{
+		page: retention.Page{
+			Offset:    offset,
+			Limit:     limit,
+			ChannelID: chanID,
+		},
+	}
+
+	return req, nil
+}
@@ -0,0 +1,4 @@
+// Package retention contains the telemetry retention service.
+// It manages per-domain and per-channel retention policies and periodically
+// enforces them by downsampling and dropping expired raw messages.
+package retention
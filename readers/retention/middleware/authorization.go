@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	smqauthz "github.com/hantdev/mitras/pkg/authz"
+	"github.com/hantdev/mitras/pkg/policies"
+	"github.com/hantdev/mitras/readers/retention"
+)
+
+var _ retention.Service = (*authorizationMiddleware)(nil)
+
+type authorizationMiddleware struct {
+	svc   retention.Service
+	authz smqauthz.Authorization
+}
+
+// AuthorizationMiddleware adds authorization to the retention service.
+// Retention policies are managed by the domain administrators only.
+func AuthorizationMiddleware(svc retention.Service, authz smqauthz.Authorization) retention.Service {
+	return &authorizationMiddleware{
+		svc:   svc,
+		authz: authz,
+	}
+}
+
+func (am *authorizationMiddleware) CreatePolicy(ctx context.Context, session smqauthn.Session, policy retention.Policy) (retention.Policy, error) {
+	if err := am.authorize(ctx, session); err != nil {
+		return retention.Policy{}, err
+	}
+
+	return am.svc.CreatePolicy(ctx, session, policy)
+}
+
+func (am *authorizationMiddleware) ViewPolicy(ctx context.Context, session smqauthn.Session, id string) (retention.Policy, error) {
+	if err := am.authorize(ctx, session); err != nil {
+		return retention.Policy{}, err
+	}
+
+	return am.svc.ViewPolicy(ctx, session, id)
+}
+
+func (am *authorizationMiddleware) ListPolicies(ctx context.Context, session smqauthn.Session, page retention.Page) (retention.PoliciesPage, error) {
+	if err := am.authorize(ctx, session); err != nil {
+		return retention.PoliciesPage{}, err
+	}
+
+	return am.svc.ListPolicies(ctx, session, page)
+}
+
+func (am *authorizationMiddleware) UpdatePolicy(ctx context.Context, session smqauthn.Session, policy retention.Policy) (retention.Policy, error) {
+	if err := am.authorize(ctx, session); err != nil {
+		return retention.Policy{}, err
+	}
+
+	return am.svc.UpdatePolicy(ctx, session, policy)
+}
+
+func (am *authorizationMiddleware) RemovePolicy(ctx context.Context, session smqauthn.Session, id string) error {
+	if err := am.authorize(ctx, session); err != nil {
+		return err
+	}
+
+	return am.svc.RemovePolicy(ctx, session, id)
+}
+
+func (am *authorizationMiddleware) Enforce(ctx context.Context, now time.Time) error {
+	return am.svc.Enforce(ctx, now)
+}
+
+func (am *authorizationMiddleware) authorize(ctx context.Context, session smqauthn.Session) error {
+	return am.authz.Authorize(ctx, smqauthz.PolicyReq{
+		Domain:      session.DomainID,
+		SubjectType: policies.UserType,
+		SubjectKind: policies.UsersKind,
+		Subject:     session.DomainUserID,
+		Permission:  policies.AdminPermission,
+		ObjectType:  policies.DomainType,
+		Object:      session.DomainID,
+	})
+}
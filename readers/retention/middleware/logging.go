@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/readers/retention"
+)
+
+var _ retention.Service = (*loggingMiddleware)(nil)
+
+type loggingMiddleware struct {
+	logger *slog.Logger
+	svc    retention.Service
+}
+
+// LoggingMiddleware adds logging facilities to the retention service.
+func LoggingMiddleware(svc retention.Service, logger *slog.Logger) retention.Service {
+	return &loggingMiddleware{
+		logger: logger,
+		svc:    svc,
+	}
+}
+
+func (lm *loggingMiddleware) CreatePolicy(ctx context.Context, session smqauthn.Session, policy retention.Policy) (p retention.Policy, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("policy",
+				slog.String("id", p.ID),
+				slog.String("domain_id", session.DomainID),
+				slog.String("channel_id", policy.ChannelID),
+				slog.Uint64("raw_days", policy.RawDays),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Create retention policy failed", args...)
+			return
+		}
+		lm.logger.Info("Create retention policy completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.CreatePolicy(ctx, session, policy)
+}
+
+func (lm *loggingMiddleware) ViewPolicy(ctx context.Context, session smqauthn.Session, id string) (p retention.Policy, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("domain_id", session.DomainID),
+			slog.String("policy_id", id),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("View retention policy failed", args...)
+			return
+		}
+		lm.logger.Info("View retention policy completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ViewPolicy(ctx, session, id)
+}
+
+func (lm *loggingMiddleware) ListPolicies(ctx context.Context, session smqauthn.Session, page retention.Page) (pp retention.PoliciesPage, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("domain_id", session.DomainID),
+			slog.Group("page",
+				slog.Uint64("offset", page.Offset),
+				slog.Uint64("limit", page.Limit),
+				slog.Uint64("total", pp.Total),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("List retention policies failed", args...)
+			return
+		}
+		lm.logger.Info("List retention policies completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ListPolicies(ctx, session, page)
+}
+
+func (lm *loggingMiddleware) UpdatePolicy(ctx context.Context, session smqauthn.Session, policy retention.Policy) (p retention.Policy, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("policy",
+				slog.String("id", policy.ID),
+				slog.String("domain_id", session.DomainID),
+				slog.Uint64("raw_days", policy.RawDays),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Update retention policy failed", args...)
+			return
+		}
+		lm.logger.Info("Update retention policy completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.UpdatePolicy(ctx, session, policy)
+}
+
+func (lm *loggingMiddleware) RemovePolicy(ctx context.Context, session smqauthn.Session, id string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("domain_id", session.DomainID),
+			slog.String("policy_id", id),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Remove retention policy failed", args...)
+			return
+		}
+		lm.logger.Info("Remove retention policy completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.RemovePolicy(ctx, session, id)
+}
+
+func (lm *loggingMiddleware) Enforce(ctx context.Context, now time.Time) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Enforce retention policies failed", args...)
+			return
+		}
+		lm.logger.Info("Enforce retention policies completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Enforce(ctx, now)
+}
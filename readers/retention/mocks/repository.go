@@ -0,0 +1,209 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	retention "github.com/hantdev/mitras/readers/retention"
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// Repository is an autogenerated mock type for the Repository type
+type Repository struct {
+	mock.Mock
+}
+
+// Apply provides a mock function with given fields: ctx, chanID, policy, now
+func (_m *Repository) Apply(ctx context.Context, chanID string, policy retention.Policy, now time.Time) error {
+	ret := _m.Called(ctx, chanID, policy, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Apply")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, retention.Policy, time.Time) error); ok {
+		r0 = rf(ctx, chanID, policy, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Channels provides a mock function with given fields: ctx
+func (_m *Repository) Channels(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Channels")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]string, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Remove provides a mock function with given fields: ctx, domainID, id
+func (_m *Repository) Remove(ctx context.Context, domainID string, id string) error {
+	ret := _m.Called(ctx, domainID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Remove")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, domainID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Retrieve provides a mock function with given fields: ctx, domainID, id
+func (_m *Repository) Retrieve(ctx context.Context, domainID string, id string) (retention.Policy, error) {
+	ret := _m.Called(ctx, domainID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Retrieve")
+	}
+
+	var r0 retention.Policy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (retention.Policy, error)); ok {
+		return rf(ctx, domainID, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) retention.Policy); ok {
+		r0 = rf(ctx, domainID, id)
+	} else {
+		r0 = ret.Get(0).(retention.Policy)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, domainID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RetrieveAll provides a mock function with given fields: ctx, page
+func (_m *Repository) RetrieveAll(ctx context.Context, page retention.Page) (retention.PoliciesPage, error) {
+	ret := _m.Called(ctx, page)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveAll")
+	}
+
+	var r0 retention.PoliciesPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, retention.Page) (retention.PoliciesPage, error)); ok {
+		return rf(ctx, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, retention.Page) retention.PoliciesPage); ok {
+		r0 = rf(ctx, page)
+	} else {
+		r0 = ret.Get(0).(retention.PoliciesPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, retention.Page) error); ok {
+		r1 = rf(ctx, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, policy
+func (_m *Repository) Save(ctx context.Context, policy retention.Policy) (retention.Policy, error) {
+	ret := _m.Called(ctx, policy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 retention.Policy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, retention.Policy) (retention.Policy, error)); ok {
+		return rf(ctx, policy)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, retention.Policy) retention.Policy); ok {
+		r0 = rf(ctx, policy)
+	} else {
+		r0 = ret.Get(0).(retention.Policy)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, retention.Policy) error); ok {
+		r1 = rf(ctx, policy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, policy
+func (_m *Repository) Update(ctx context.Context, policy retention.Policy) (retention.Policy, error) {
+	ret := _m.Called(ctx, policy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 retention.Policy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, retention.Policy) (retention.Policy, error)); ok {
+		return rf(ctx, policy)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, retention.Policy) retention.Policy); ok {
+		r0 = rf(ctx, policy)
+	} else {
+		r0 = ret.Get(0).(retention.Policy)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, retention.Policy) error); ok {
+		r1 = rf(ctx, policy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Repository {
+	mock := &Repository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,182 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	authn "github.com/hantdev/mitras/pkg/authn"
+
+	mock "github.com/stretchr/testify/mock"
+
+	retention "github.com/hantdev/mitras/readers/retention"
+
+	time "time"
+)
+
+// Service is an autogenerated mock type for the Service type
+type Service struct {
+	mock.Mock
+}
+
+// CreatePolicy provides a mock function with given fields: ctx, session, policy
+func (_m *Service) CreatePolicy(ctx context.Context, session authn.Session, policy retention.Policy) (retention.Policy, error) {
+	ret := _m.Called(ctx, session, policy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePolicy")
+	}
+
+	var r0 retention.Policy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, retention.Policy) (retention.Policy, error)); ok {
+		return rf(ctx, session, policy)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, retention.Policy) retention.Policy); ok {
+		r0 = rf(ctx, session, policy)
+	} else {
+		r0 = ret.Get(0).(retention.Policy)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, retention.Policy) error); ok {
+		r1 = rf(ctx, session, policy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Enforce provides a mock function with given fields: ctx, now
+func (_m *Service) Enforce(ctx context.Context, now time.Time) error {
+	ret := _m.Called(ctx, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Enforce")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) error); ok {
+		r0 = rf(ctx, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListPolicies provides a mock function with given fields: ctx, session, page
+func (_m *Service) ListPolicies(ctx context.Context, session authn.Session, page retention.Page) (retention.PoliciesPage, error) {
+	ret := _m.Called(ctx, session, page)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListPolicies")
+	}
+
+	var r0 retention.PoliciesPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, retention.Page) (retention.PoliciesPage, error)); ok {
+		return rf(ctx, session, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, retention.Page) retention.PoliciesPage); ok {
+		r0 = rf(ctx, session, page)
+	} else {
+		r0 = ret.Get(0).(retention.PoliciesPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, retention.Page) error); ok {
+		r1 = rf(ctx, session, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemovePolicy provides a mock function with given fields: ctx, session, id
+func (_m *Service) RemovePolicy(ctx context.Context, session authn.Session, id string) error {
+	ret := _m.Called(ctx, session, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemovePolicy")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) error); ok {
+		r0 = rf(ctx, session, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePolicy provides a mock function with given fields: ctx, session, policy
+func (_m *Service) UpdatePolicy(ctx context.Context, session authn.Session, policy retention.Policy) (retention.Policy, error) {
+	ret := _m.Called(ctx, session, policy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePolicy")
+	}
+
+	var r0 retention.Policy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, retention.Policy) (retention.Policy, error)); ok {
+		return rf(ctx, session, policy)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, retention.Policy) retention.Policy); ok {
+		r0 = rf(ctx, session, policy)
+	} else {
+		r0 = ret.Get(0).(retention.Policy)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, retention.Policy) error); ok {
+		r1 = rf(ctx, session, policy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ViewPolicy provides a mock function with given fields: ctx, session, id
+func (_m *Service) ViewPolicy(ctx context.Context, session authn.Session, id string) (retention.Policy, error) {
+	ret := _m.Called(ctx, session, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ViewPolicy")
+	}
+
+	var r0 retention.Policy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) (retention.Policy, error)); ok {
+		return rf(ctx, session, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) retention.Policy); ok {
+		r0 = rf(ctx, session, id)
+	} else {
+		r0 = ret.Get(0).(retention.Policy)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, string) error); ok {
+		r1 = rf(ctx, session, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewService creates a new instance of Service. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Service {
+	mock := &Service{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,181 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+)
+
+var (
+	// ErrEnforce indicates failure occurred while enforcing retention policies.
+	ErrEnforce = errors.New("failed to enforce retention policy")
+
+	// ErrInvalidPolicy indicates retention policy with invalid limits.
+	ErrInvalidPolicy = errors.New("invalid retention policy")
+
+	errMissingRawDays     = errors.New("missing raw data retention period")
+	errInvalidInterval    = errors.New("downsample interval must be positive")
+	errInvalidDownsampled = errors.New("downsampled data must be retained longer than raw data")
+)
+
+// Policy describes how long telemetry of a domain or a channel is kept.
+//
+// Raw messages older than RawDays are dropped. If DownsampleInterval is set,
+// numeric values are rolled up into buckets of that interval holding
+// min, max, sum and count before the raw messages are dropped, and the
+// rollups are kept for DownsampleDays (forever if zero).
+// A policy without ChannelID applies to every channel of the domain which
+// doesn't have a policy of its own.
+type Policy struct {
+	ID                 string    `json:"id" db:"id"`
+	DomainID           string    `json:"domain_id" db:"domain_id"`
+	ChannelID          string    `json:"channel_id,omitempty" db:"channel_id"`
+	RawDays            uint64    `json:"raw_days" db:"raw_days"`
+	DownsampleInterval string    `json:"downsample_interval,omitempty" db:"downsample_interval"`
+	DownsampleDays     uint64    `json:"downsample_days,omitempty" db:"downsample_days"`
+	CreatedBy          string    `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// RawCutoff returns the time in nanoseconds before which raw messages expire.
+func (p Policy) RawCutoff(now time.Time) int64 {
+	return now.Add(-days(p.RawDays)).UnixNano()
+}
+
+// DownsampleCutoff returns the time in nanoseconds before which rollups
+// expire. Zero is returned if rollups never expire.
+func (p Policy) DownsampleCutoff(now time.Time) int64 {
+	if p.DownsampleDays == 0 {
+		return 0
+	}
+
+	return now.Add(-days(p.DownsampleDays)).UnixNano()
+}
+
+// Bucket returns the downsampling interval in nanoseconds. Zero is returned
+// if the policy doesn't downsample.
+func (p Policy) Bucket() (int64, error) {
+	if p.DownsampleInterval == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(p.DownsampleInterval)
+	if err != nil {
+		return 0, errors.Wrap(ErrInvalidPolicy, err)
+	}
+	if d <= 0 {
+		return 0, errors.Wrap(ErrInvalidPolicy, errInvalidInterval)
+	}
+
+	return d.Nanoseconds(), nil
+}
+
+// Validate returns an error if the retention limits of the policy are invalid.
+func (p Policy) Validate() error {
+	if p.RawDays == 0 {
+		return errors.Wrap(ErrInvalidPolicy, errMissingRawDays)
+	}
+
+	bucket, err := p.Bucket()
+	if err != nil {
+		return err
+	}
+
+	if bucket > 0 && p.DownsampleDays != 0 && p.DownsampleDays <= p.RawDays {
+		return errors.Wrap(ErrInvalidPolicy, errInvalidDownsampled)
+	}
+
+	return nil
+}
+
+func days(n uint64) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// PoliciesPage represents a page of retention policies.
+type PoliciesPage struct {
+	Total    uint64   `json:"total"`
+	Offset   uint64   `json:"offset"`
+	Limit    uint64   `json:"limit"`
+	Policies []Policy `json:"policies"`
+}
+
+func (page PoliciesPage) MarshalJSON() ([]byte, error) {
+	type Alias PoliciesPage
+	a := struct {
+		Alias
+	}{
+		Alias: Alias(page),
+	}
+
+	if a.Policies == nil {
+		a.Policies = make([]Policy, 0)
+	}
+
+	return json.Marshal(a)
+}
+
+// Page is used to filter retention policies.
+type Page struct {
+	Offset    uint64 `json:"offset" db:"offset"`
+	Limit     uint64 `json:"limit" db:"limit"`
+	DomainID  string `json:"domain_id,omitempty" db:"domain_id"`
+	ChannelID string `json:"channel_id,omitempty" db:"channel_id"`
+}
+
+// Service manages retention policies and enforces them.
+//
+//go:generate mockery --name Service --output=./mocks --filename service.go --quiet
+type Service interface {
+	// CreatePolicy creates a retention policy in the session domain.
+	CreatePolicy(ctx context.Context, session smqauthn.Session, policy Policy) (Policy, error)
+
+	// ViewPolicy retrieves the retention policy with the given ID.
+	ViewPolicy(ctx context.Context, session smqauthn.Session, id string) (Policy, error)
+
+	// ListPolicies retrieves the retention policies of the session domain.
+	ListPolicies(ctx context.Context, session smqauthn.Session, page Page) (PoliciesPage, error)
+
+	// UpdatePolicy updates retention limits of the policy.
+	UpdatePolicy(ctx context.Context, session smqauthn.Session, policy Policy) (Policy, error)
+
+	// RemovePolicy removes the retention policy with the given ID.
+	RemovePolicy(ctx context.Context, session smqauthn.Session, id string) error
+
+	// Enforce downsamples and drops expired telemetry of every stored
+	// channel which is covered by a retention policy.
+	Enforce(ctx context.Context, now time.Time) error
+}
+
+// Repository stores retention policies and applies them to the stored
+// telemetry.
+//
+//go:generate mockery --name Repository --output=./mocks --filename repository.go --quiet
+type Repository interface {
+	// Save persists the retention policy.
+	Save(ctx context.Context, policy Policy) (Policy, error)
+
+	// Retrieve retrieves the retention policy of the domain by its ID.
+	Retrieve(ctx context.Context, domainID, id string) (Policy, error)
+
+	// RetrieveAll retrieves retention policies filtered by the given page.
+	// Zero limit returns all the policies which match the page filters.
+	RetrieveAll(ctx context.Context, page Page) (PoliciesPage, error)
+
+	// Update updates retention limits of the policy.
+	Update(ctx context.Context, policy Policy) (Policy, error)
+
+	// Remove removes the retention policy of the domain by its ID.
+	Remove(ctx context.Context, domainID, id string) error
+
+	// Channels returns IDs of all the channels which have stored telemetry.
+	Channels(ctx context.Context) ([]string, error)
+
+	// Apply downsamples and drops telemetry of the channel which is expired
+	// according to the policy.
+	Apply(ctx context.Context, chanID string, policy Policy, now time.Time) error
+}
@@ -0,0 +1,164 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/hantdev/mitras"
+	grpcChannelsV1 "github.com/hantdev/mitras/internal/grpc/channels/v1"
+	grpcCommonV1 "github.com/hantdev/mitras/internal/grpc/common/v1"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+)
+
+type service struct {
+	idProvider mitras.IDProvider
+	repository Repository
+	channels   grpcChannelsV1.ChannelsServiceClient
+}
+
+// NewService returns a new retention service.
+func NewService(idp mitras.IDProvider, repository Repository, channels grpcChannelsV1.ChannelsServiceClient) Service {
+	return &service{
+		idProvider: idp,
+		repository: repository,
+		channels:   channels,
+	}
+}
+
+func (svc *service) CreatePolicy(ctx context.Context, session smqauthn.Session, policy Policy) (Policy, error) {
+	if err := policy.Validate(); err != nil {
+		return Policy{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+
+	if policy.ChannelID != "" {
+		domainID, err := svc.channelDomain(ctx, policy.ChannelID)
+		if err != nil {
+			return Policy{}, errors.Wrap(svcerr.ErrCreateEntity, err)
+		}
+		if domainID != session.DomainID {
+			return Policy{}, svcerr.ErrNotFound
+		}
+	}
+
+	id, err := svc.idProvider.ID()
+	if err != nil {
+		return Policy{}, err
+	}
+	policy.ID = id
+	policy.DomainID = session.DomainID
+	policy.CreatedBy = session.UserID
+	policy.CreatedAt = time.Now().UTC()
+
+	saved, err := svc.repository.Save(ctx, policy)
+	if err != nil {
+		return Policy{}, errors.Wrap(svcerr.ErrCreateEntity, err)
+	}
+
+	return saved, nil
+}
+
+func (svc *service) ViewPolicy(ctx context.Context, session smqauthn.Session, id string) (Policy, error) {
+	policy, err := svc.repository.Retrieve(ctx, session.DomainID, id)
+	if err != nil {
+		return Policy{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	return policy, nil
+}
+
+func (svc *service) ListPolicies(ctx context.Context, session smqauthn.Session, page Page) (PoliciesPage, error) {
+	page.DomainID = session.DomainID
+
+	policies, err := svc.repository.RetrieveAll(ctx, page)
+	if err != nil {
+		return PoliciesPage{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	return policies, nil
+}
+
+func (svc *service) UpdatePolicy(ctx context.Context, session smqauthn.Session, policy Policy) (Policy, error) {
+	if err := policy.Validate(); err != nil {
+		return Policy{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+
+	policy.DomainID = session.DomainID
+	policy.UpdatedAt = time.Now().UTC()
+
+	updated, err := svc.repository.Update(ctx, policy)
+	if err != nil {
+		return Policy{}, errors.Wrap(svcerr.ErrUpdateEntity, err)
+	}
+
+	return updated, nil
+}
+
+func (svc *service) RemovePolicy(ctx context.Context, session smqauthn.Session, id string) error {
+	if err := svc.repository.Remove(ctx, session.DomainID, id); err != nil {
+		return errors.Wrap(svcerr.ErrRemoveEntity, err)
+	}
+
+	return nil
+}
+
+func (svc *service) Enforce(ctx context.Context, now time.Time) error {
+	page, err := svc.repository.RetrieveAll(ctx, Page{})
+	if err != nil {
+		return errors.Wrap(ErrEnforce, err)
+	}
+	if len(page.Policies) == 0 {
+		return nil
+	}
+
+	channelPolicies := make(map[string]Policy)
+	domainPolicies := make(map[string]Policy)
+	for _, p := range page.Policies {
+		if p.ChannelID != "" {
+			channelPolicies[p.ChannelID] = p
+			continue
+		}
+		domainPolicies[p.DomainID] = p
+	}
+
+	chanIDs, err := svc.repository.Channels(ctx)
+	if err != nil {
+		return errors.Wrap(ErrEnforce, err)
+	}
+
+	// Enforcement of the remaining channels goes on if one of them fails,
+	// the last failure is reported.
+	var enforceErr error
+	for _, chanID := range chanIDs {
+		policy, ok := channelPolicies[chanID]
+		if !ok {
+			if len(domainPolicies) == 0 {
+				continue
+			}
+			domainID, err := svc.channelDomain(ctx, chanID)
+			if err != nil {
+				enforceErr = errors.Wrap(ErrEnforce, err)
+				continue
+			}
+			if policy, ok = domainPolicies[domainID]; !ok {
+				continue
+			}
+		}
+
+		if err := svc.repository.Apply(ctx, chanID, policy, now); err != nil {
+			enforceErr = errors.Wrap(ErrEnforce, err)
+		}
+	}
+
+	return enforceErr
+}
+
+func (svc *service) channelDomain(ctx context.Context, chanID string) (string, error) {
+	res, err := svc.channels.RetrieveEntity(ctx, &grpcCommonV1.RetrieveEntityReq{Id: chanID})
+	if err != nil {
+		return "", err
+	}
+
+	return res.GetEntity().GetDomainId(), nil
+}
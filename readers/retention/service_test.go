@@ -0,0 +1,259 @@
+package retention_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	chmocks "github.com/hantdev/mitras/channels/mocks"
+	grpcCommonV1 "github.com/hantdev/mitras/internal/grpc/common/v1"
+	"github.com/hantdev/mitras/internal/testsutil"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/mitras/pkg/uuid"
+	"github.com/hantdev/mitras/readers/retention"
+	"github.com/hantdev/mitras/readers/retention/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var idProvider = uuid.New()
+
+func newService() (retention.Service, *mocks.Repository, *chmocks.ChannelsServiceClient) {
+	repo := new(mocks.Repository)
+	channels := new(chmocks.ChannelsServiceClient)
+
+	return retention.NewService(idProvider, repo, channels), repo, channels
+}
+
+func TestCreatePolicy(t *testing.T) {
+	svc, repo, channels := newService()
+
+	session := smqauthn.Session{UserID: testsutil.GenerateUUID(t), DomainID: testsutil.GenerateUUID(t)}
+	chanID := testsutil.GenerateUUID(t)
+
+	cases := []struct {
+		desc     string
+		policy   retention.Policy
+		domainID string
+		chanErr  error
+		repoErr  error
+		err      error
+	}{
+		{
+			desc:   "create domain policy successfully",
+			policy: retention.Policy{RawDays: 30, DownsampleInterval: "1h", DownsampleDays: 365},
+		},
+		{
+			desc:     "create channel policy successfully",
+			policy:   retention.Policy{ChannelID: chanID, RawDays: 7},
+			domainID: session.DomainID,
+		},
+		{
+			desc:     "create policy for channel of another domain",
+			policy:   retention.Policy{ChannelID: chanID, RawDays: 7},
+			domainID: testsutil.GenerateUUID(t),
+			err:      svcerr.ErrNotFound,
+		},
+		{
+			desc:    "create policy for channel with failed channel retrieval",
+			policy:  retention.Policy{ChannelID: chanID, RawDays: 7},
+			chanErr: svcerr.ErrNotFound,
+			err:     svcerr.ErrCreateEntity,
+		},
+		{
+			desc:   "create policy without raw retention",
+			policy: retention.Policy{DownsampleInterval: "1h"},
+			err:    retention.ErrInvalidPolicy,
+		},
+		{
+			desc:   "create policy with invalid downsample interval",
+			policy: retention.Policy{RawDays: 7, DownsampleInterval: "hourly"},
+			err:    retention.ErrInvalidPolicy,
+		},
+		{
+			desc:   "create policy with zero downsample interval",
+			policy: retention.Policy{RawDays: 7, DownsampleInterval: "0s"},
+			err:    retention.ErrInvalidPolicy,
+		},
+		{
+			desc:   "create policy with rollups expiring before raw messages",
+			policy: retention.Policy{RawDays: 7, DownsampleInterval: "1h", DownsampleDays: 7},
+			err:    retention.ErrInvalidPolicy,
+		},
+		{
+			desc:    "create policy with repo error",
+			policy:  retention.Policy{RawDays: 7},
+			repoErr: repoerr.ErrConflict,
+			err:     svcerr.ErrCreateEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			chanCall := channels.On("RetrieveEntity", mock.Anything, &grpcCommonV1.RetrieveEntityReq{Id: tc.policy.ChannelID}).
+				Return(&grpcCommonV1.RetrieveEntityRes{Entity: &grpcCommonV1.EntityBasic{Id: tc.policy.ChannelID, DomainId: tc.domainID}}, tc.chanErr)
+			repoCall := repo.On("Save", context.Background(), mock.Anything).Return(func(_ context.Context, p retention.Policy) (retention.Policy, error) {
+				return p, tc.repoErr
+			})
+			policy, err := svc.CreatePolicy(context.Background(), session, tc.policy)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if tc.err == nil {
+				assert.NotEmpty(t, policy.ID, fmt.Sprintf("%s: expected non-empty policy ID", tc.desc))
+				assert.Equal(t, session.DomainID, policy.DomainID, fmt.Sprintf("%s: expected domain %s got %s\n", tc.desc, session.DomainID, policy.DomainID))
+				assert.Equal(t, session.UserID, policy.CreatedBy, fmt.Sprintf("%s: expected creator %s got %s\n", tc.desc, session.UserID, policy.CreatedBy))
+			}
+			chanCall.Unset()
+			repoCall.Unset()
+		})
+	}
+}
+
+func TestUpdatePolicy(t *testing.T) {
+	svc, repo, _ := newService()
+
+	session := smqauthn.Session{UserID: testsutil.GenerateUUID(t), DomainID: testsutil.GenerateUUID(t)}
+	id := testsutil.GenerateUUID(t)
+
+	cases := []struct {
+		desc    string
+		policy  retention.Policy
+		repoErr error
+		err     error
+	}{
+		{
+			desc:   "update policy successfully",
+			policy: retention.Policy{ID: id, RawDays: 30, DownsampleInterval: "1h", DownsampleDays: 365},
+		},
+		{
+			desc:   "update policy with invalid downsample interval",
+			policy: retention.Policy{ID: id, RawDays: 30, DownsampleInterval: "-1h"},
+			err:    retention.ErrInvalidPolicy,
+		},
+		{
+			desc:    "update non-existing policy",
+			policy:  retention.Policy{ID: id, RawDays: 30},
+			repoErr: repoerr.ErrNotFound,
+			err:     svcerr.ErrUpdateEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repoCall := repo.On("Update", context.Background(), mock.Anything).Return(func(_ context.Context, p retention.Policy) (retention.Policy, error) {
+				return p, tc.repoErr
+			})
+			policy, err := svc.UpdatePolicy(context.Background(), session, tc.policy)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if tc.err == nil {
+				assert.Equal(t, session.DomainID, policy.DomainID, fmt.Sprintf("%s: expected domain %s got %s\n", tc.desc, session.DomainID, policy.DomainID))
+			}
+			if errors.Contains(tc.err, retention.ErrInvalidPolicy) {
+				repo.AssertNotCalled(t, "Update", context.Background(), mock.Anything)
+			}
+			repoCall.Unset()
+			repo.Calls = nil
+		})
+	}
+}
+
+func TestEnforce(t *testing.T) {
+	svc, repo, channels := newService()
+
+	now := time.Now()
+	domainID := testsutil.GenerateUUID(t)
+	otherDomainID := testsutil.GenerateUUID(t)
+	chanID := testsutil.GenerateUUID(t)
+	domainChanID := testsutil.GenerateUUID(t)
+	otherChanID := testsutil.GenerateUUID(t)
+
+	chanPolicy := retention.Policy{ID: testsutil.GenerateUUID(t), DomainID: domainID, ChannelID: chanID, RawDays: 1}
+	domainPolicy := retention.Policy{ID: testsutil.GenerateUUID(t), DomainID: domainID, RawDays: 30, DownsampleInterval: "1h"}
+
+	cases := []struct {
+		desc     string
+		policies []retention.Policy
+		listErr  error
+		channels []string
+		chanErr  error
+		applyErr error
+		applied  map[string]retention.Policy
+		err      error
+	}{
+		{
+			desc:     "enforce without policies",
+			channels: []string{chanID},
+			applied:  map[string]retention.Policy{},
+		},
+		{
+			desc:     "enforce channel and domain policies",
+			policies: []retention.Policy{chanPolicy, domainPolicy},
+			channels: []string{chanID, domainChanID, otherChanID},
+			applied: map[string]retention.Policy{
+				chanID:       chanPolicy,
+				domainChanID: domainPolicy,
+			},
+		},
+		{
+			desc:    "enforce with failed policies retrieval",
+			listErr: repoerr.ErrViewEntity,
+			applied: map[string]retention.Policy{},
+			err:     retention.ErrEnforce,
+		},
+		{
+			desc:     "enforce with failed channel retrieval",
+			policies: []retention.Policy{chanPolicy, domainPolicy},
+			channels: []string{chanID, domainChanID},
+			chanErr:  svcerr.ErrNotFound,
+			applied: map[string]retention.Policy{
+				chanID: chanPolicy,
+			},
+			err: retention.ErrEnforce,
+		},
+		{
+			desc:     "enforce with failed apply",
+			policies: []retention.Policy{chanPolicy},
+			channels: []string{chanID},
+			applyErr: repoerr.ErrRemoveEntity,
+			applied: map[string]retention.Policy{
+				chanID: chanPolicy,
+			},
+			err: retention.ErrEnforce,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			listCall := repo.On("RetrieveAll", context.Background(), retention.Page{}).Return(retention.PoliciesPage{Policies: tc.policies}, tc.listErr)
+			channelsCall := repo.On("Channels", context.Background()).Return(tc.channels, nil)
+			chanCall1 := channels.On("RetrieveEntity", mock.Anything, &grpcCommonV1.RetrieveEntityReq{Id: domainChanID}).
+				Return(&grpcCommonV1.RetrieveEntityRes{Entity: &grpcCommonV1.EntityBasic{Id: domainChanID, DomainId: domainID}}, tc.chanErr)
+			chanCall2 := channels.On("RetrieveEntity", mock.Anything, &grpcCommonV1.RetrieveEntityReq{Id: otherChanID}).
+				Return(&grpcCommonV1.RetrieveEntityRes{Entity: &grpcCommonV1.EntityBasic{Id: otherChanID, DomainId: otherDomainID}}, tc.chanErr)
+			applyCall := repo.On("Apply", context.Background(), mock.Anything, mock.Anything, now).Return(tc.applyErr)
+
+			err := svc.Enforce(context.Background(), now)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			for chanID, policy := range tc.applied {
+				repo.AssertCalled(t, "Apply", context.Background(), chanID, policy, now)
+			}
+			applied := 0
+			for _, call := range repo.Calls {
+				if call.Method == "Apply" {
+					applied++
+				}
+			}
+			assert.Equal(t, len(tc.applied), applied, fmt.Sprintf("%s: expected %d applied policies got %d\n", tc.desc, len(tc.applied), applied))
+
+			listCall.Unset()
+			channelsCall.Unset()
+			chanCall1.Unset()
+			chanCall2.Unset()
+			applyCall.Unset()
+			repo.Calls = nil
+		})
+	}
+}
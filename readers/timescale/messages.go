@@ -3,6 +3,7 @@ package timescale
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/hantdev/mitras/pkg/errors"
 	"github.com/hantdev/mitras/pkg/transformers/senml"
@@ -12,6 +13,14 @@ import (
 	"github.com/jmoiron/sqlx" // required for DB access
 )
 
+const (
+	// Divisor converting message time in nanoseconds to seconds.
+	timeDivisor = 1000000000
+
+	// Columns of the SenML messages table.
+	senmlColumns = "time, channel, subtopic, publisher, protocol, name, unit, value, string_value, bool_value, data_value, sum, update_time"
+)
+
 var _ readers.MessageRepository = (*timescaleRepository)(nil)
 
 type timescaleRepository struct {
@@ -34,20 +43,96 @@ func (tr timescaleRepository) ReadAll(chanID string, rpm readers.PageMetadata) (
 		format = rpm.Format
 	}
 
-	q := fmt.Sprintf(`SELECT * FROM %s WHERE %s ORDER BY %s DESC LIMIT :limit OFFSET :offset;`, format, fmtCondition(rpm), order)
-	totalQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s;`, format, fmtCondition(rpm))
+	params := map[string]interface{}{
+		"channel":      chanID,
+		"limit":        rpm.Limit,
+		"offset":       rpm.Offset,
+		"subtopic":     rpm.Subtopic,
+		"publisher":    rpm.Publisher,
+		"name":         rpm.Name,
+		"protocol":     rpm.Protocol,
+		"value":        rpm.Value,
+		"bool_value":   rpm.BoolValue,
+		"string_value": rpm.StringValue,
+		"data_value":   rpm.DataValue,
+		"from":         rpm.From,
+		"to":           rpm.To,
+	}
 
-	// If aggregation is provided, add time_bucket and aggregation to the query
-	const timeDivisor = 1000000000
+	payloadParams(rpm, params)
 
 	if rpm.Aggregation != "" {
-		q = fmt.Sprintf(`SELECT EXTRACT(epoch FROM time_bucket('%s', to_timestamp(time/%d))) *%d AS time, %s(value) AS value, FIRST(publisher, time) AS publisher, FIRST(protocol, time) AS protocol, FIRST(subtopic, time) AS subtopic, FIRST(name,time) AS name, FIRST(unit, time) AS unit FROM %s WHERE %s GROUP BY 1 ORDER BY time DESC LIMIT :limit OFFSET :offset;`, rpm.Interval, timeDivisor, timeDivisor, rpm.Aggregation, format, fmtCondition(rpm))
+		return tr.readAggregates(format, rpm, params)
+	}
+
+	cols, source := "*", format
+	switch format {
+	case defTable:
+		// Rollups of the raw messages dropped by the retention policy are
+		// read as messages holding the mean value of the bucket.
+		source = fmt.Sprintf(`(SELECT %s FROM %s UNION ALL SELECT time, channel, subtopic, publisher, protocol, name, unit, sum_value / NULLIF(count_value, 0) AS value, CAST(NULL AS TEXT) AS string_value, CAST(NULL AS BOOL) AS bool_value, CAST(NULL AS BYTEA) AS data_value, CAST(NULL AS FLOAT) AS sum, CAST(NULL AS FLOAT) AS update_time FROM %s) AS %s`, senmlColumns, defTable, rollupTable, defTable)
+	default:
+		cols = fmt.Sprintf("channel, created, subtopic, publisher, protocol, %s", fmtPayload(rpm))
+	}
+	q := fmt.Sprintf(`SELECT %s FROM %s WHERE %s ORDER BY %s DESC LIMIT :limit OFFSET :offset;`, cols, source, fmtCondition(rpm), order)
+	totalQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s;`, source, fmtCondition(rpm))
+
+	rows, err := tr.db.NamedQuery(q, params)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok {
+			if pgErr.Code == pgerrcode.UndefinedTable {
+				return readers.MessagesPage{}, nil
+			}
+		}
+		return readers.MessagesPage{}, errors.Wrap(readers.ErrReadMessages, err)
+	}
+	defer rows.Close()
+
+	page := readers.MessagesPage{
+		PageMetadata: rpm,
+		Messages:     []readers.Message{},
+	}
+	switch format {
+	case defTable:
+		for rows.Next() {
+			msg := senmlMessage{Message: senml.Message{}}
+			if err := rows.StructScan(&msg); err != nil {
+				return readers.MessagesPage{}, errors.Wrap(readers.ErrReadMessages, err)
+			}
+
+			page.Messages = append(page.Messages, msg.Message)
+		}
+	default:
+		for rows.Next() {
+			msg := jsonMessage{}
+			if err := rows.StructScan(&msg); err != nil {
+				return readers.MessagesPage{}, errors.Wrap(readers.ErrReadMessages, err)
+			}
+			m, err := msg.toMap()
+			if err != nil {
+				return readers.MessagesPage{}, errors.Wrap(readers.ErrReadMessages, err)
+			}
+			page.Messages = append(page.Messages, m)
+		}
+	}
 
-		totalQuery = fmt.Sprintf(`SELECT COUNT(*) FROM (SELECT EXTRACT(epoch FROM time_bucket('%s', to_timestamp(time/%d))) AS time, %s(value) AS value FROM %s WHERE %s GROUP BY 1) AS subquery;`, rpm.Interval, timeDivisor, rpm.Aggregation, format, fmtCondition(rpm))
+	total, err := tr.total(totalQuery, params)
+	if err != nil {
+		return page, err
+	}
+	page.Total = total
+
+	return page, nil
+}
+
+func (tr timescaleRepository) ReadLatest(chanIDs []string, rpm readers.PageMetadata) (readers.MessagesPage, error) {
+	format := defTable
+	if rpm.Format != "" && rpm.Format != defTable {
+		format = rpm.Format
 	}
 
 	params := map[string]interface{}{
-		"channel":      chanID,
+		"channels":     chanIDs,
 		"limit":        rpm.Limit,
 		"offset":       rpm.Offset,
 		"subtopic":     rpm.Subtopic,
@@ -61,6 +146,19 @@ func (tr timescaleRepository) ReadAll(chanID string, rpm readers.PageMetadata) (
 		"from":         rpm.From,
 		"to":           rpm.To,
 	}
+	payloadParams(rpm, params)
+	cond := fmtFilters(`channel = ANY(:channels)`, rpm)
+
+	latest := fmt.Sprintf(`SELECT channel, publisher, name, MAX(time) AS time, last(subtopic, time) AS subtopic, last(protocol, time) AS protocol, last(unit, time) AS unit, last(value, time) AS value, last(string_value, time) AS string_value, last(bool_value, time) AS bool_value, last(data_value, time) AS data_value, last(sum, time) AS sum, last(update_time, time) AS update_time FROM %s WHERE %s GROUP BY channel, publisher, name`, format, cond)
+	order := "time"
+	if format != defTable {
+		// Every top-level key of the JSON payloads is a separate series.
+		latest = fmt.Sprintf(`SELECT channel, publisher, MAX(created) AS created, last(subtopic, created) AS subtopic, last(protocol, created) AS protocol, jsonb_build_object(field, last(val, created)) AS payload FROM %s, jsonb_each(payload) AS kv(field, val) WHERE %s GROUP BY channel, publisher, field`, format, cond)
+		order = "created"
+	}
+
+	q := fmt.Sprintf(`SELECT * FROM (%s) AS latest ORDER BY %s DESC LIMIT :limit OFFSET :offset;`, latest, order)
+	totalQuery := fmt.Sprintf(`SELECT COUNT(*) FROM (%s) AS latest;`, latest)
 
 	rows, err := tr.db.NamedQuery(q, params)
 	if err != nil {
@@ -101,26 +199,112 @@ func (tr timescaleRepository) ReadAll(chanID string, rpm readers.PageMetadata) (
 		}
 	}
 
-	rows, err = tr.db.NamedQuery(totalQuery, params)
+	total, err := tr.total(totalQuery, params)
+	if err != nil {
+		return page, err
+	}
+	page.Total = total
+
+	return page, nil
+}
+
+// readAggregates reads time buckets of the messages aggregated with the
+// requested aggregation functions. Empty buckets are filled if requested.
+func (tr timescaleRepository) readAggregates(format string, rpm readers.PageMetadata, params map[string]interface{}) (readers.MessagesPage, error) {
+	aggs := rpm.Aggregations()
+	source, cond := format, fmtCondition(rpm)
+	rollups := format == defTable && !hasValueFilter(rpm)
+	if rollups {
+		// Rollups of the raw messages dropped by the retention policy
+		// are aggregated together with the remaining raw messages.
+		source = fmt.Sprintf(`(SELECT time, publisher, protocol, subtopic, name, unit, value AS min_value, value AS max_value, value AS sum_value, 1 AS count_value FROM %s WHERE %s AND value IS NOT NULL UNION ALL SELECT time, publisher, protocol, subtopic, name, unit, min_value, max_value, sum_value, count_value FROM %s WHERE %s) AS series`, defTable, cond, rollupTable, cond)
+		cond = "TRUE"
+	}
+	if format != defTable {
+		// Numeric JSON payload field is aggregated as a SenML value series.
+		source = fmt.Sprintf(`(SELECT created AS time, publisher, protocol, subtopic, CAST(:field AS TEXT) AS name, CAST(NULL AS TEXT) AS unit, %s AS value FROM %s WHERE %s AND %s IS NOT NULL) AS series`, jsonNumber(":field_path"), format, cond, jsonNumber(":field_path"))
+		cond = "TRUE"
+	}
+
+	values := make([]string, len(aggs))
+	for i, agg := range aggs {
+		value := fmt.Sprintf("%s(value)", agg)
+		if rollups {
+			value = rollupAggregation(agg)
+		}
+		if rpm.Fill == readers.FillPrevious {
+			value = fmt.Sprintf("locf(%s)", value)
+		}
+		values[i] = fmt.Sprintf("%s AS %s", value, strings.ToLower(agg))
+	}
+
+	bucket := fmt.Sprintf("time_bucket('%s', to_timestamp(time/%d))", rpm.Interval, timeDivisor)
+	if rpm.Fill != "" {
+		bucket = fmt.Sprintf("time_bucket_gapfill('%s', to_timestamp(time/%d), to_timestamp(:from/%d), to_timestamp(:to/%d))", rpm.Interval, timeDivisor, timeDivisor, timeDivisor)
+	}
+
+	names := make([]string, len(aggs))
+	for i, agg := range aggs {
+		names[i] = strings.ToLower(agg)
+	}
+
+	// Gap filling requires time_bucket_gapfill to be the top-level group
+	// expression, so the buckets are converted to Unix time in the outer query.
+	buckets := fmt.Sprintf(`SELECT %s AS bucket, FIRST(publisher, time) AS publisher, FIRST(protocol, time) AS protocol, FIRST(subtopic, time) AS subtopic, FIRST(name, time) AS name, FIRST(unit, time) AS unit, %s FROM %s WHERE %s GROUP BY bucket`, bucket, strings.Join(values, ", "), source, cond)
+	q := fmt.Sprintf(`SELECT EXTRACT(epoch FROM bucket) * %d AS time, publisher, protocol, subtopic, name, unit, %s FROM (%s) AS buckets ORDER BY time DESC LIMIT :limit OFFSET :offset;`, timeDivisor, strings.Join(names, ", "), buckets)
+	totalQuery := fmt.Sprintf(`SELECT COUNT(*) FROM (%s) AS buckets;`, buckets)
+
+	rows, err := tr.db.NamedQuery(q, params)
 	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok {
+			if pgErr.Code == pgerrcode.UndefinedTable {
+				return readers.MessagesPage{}, nil
+			}
+		}
 		return readers.MessagesPage{}, errors.Wrap(readers.ErrReadMessages, err)
 	}
 	defer rows.Close()
 
+	msgs, err := readers.ScanAggregates(rows, aggs)
+	if err != nil {
+		return readers.MessagesPage{}, errors.Wrap(readers.ErrReadMessages, err)
+	}
+
+	total, err := tr.total(totalQuery, params)
+	if err != nil {
+		return readers.MessagesPage{}, err
+	}
+
+	return readers.MessagesPage{
+		PageMetadata: rpm,
+		Total:        total,
+		Messages:     msgs,
+	}, nil
+}
+
+func (tr timescaleRepository) total(q string, params map[string]interface{}) (uint64, error) {
+	rows, err := tr.db.NamedQuery(q, params)
+	if err != nil {
+		return 0, errors.Wrap(readers.ErrReadMessages, err)
+	}
+	defer rows.Close()
+
 	total := uint64(0)
 	if rows.Next() {
 		if err := rows.Scan(&total); err != nil {
-			return page, err
+			return 0, err
 		}
 	}
-	page.Total = total
 
-	return page, nil
+	return total, nil
 }
 
 func fmtCondition(rpm readers.PageMetadata) string {
-	condition := `channel = :channel`
+	return fmtFilters(`channel = :channel`, rpm)
+}
 
+// fmtFilters appends the filters of the page metadata to the condition.
+func fmtFilters(condition string, rpm readers.PageMetadata) string {
 	var query map[string]interface{}
 	meta, err := json.Marshal(rpm)
 	if err != nil {
@@ -161,14 +345,104 @@ func fmtCondition(rpm readers.PageMetadata) string {
 			comparator := readers.ParseValueComparator(query)
 			condition = fmt.Sprintf(`%s AND data_value %s :data_value`, condition, comparator)
 		case "from":
-			condition = fmt.Sprintf(`%s AND time >= :from`, condition)
+			condition = fmt.Sprintf(`%s AND %s >= :from`, condition, timeColumn(rpm))
 		case "to":
-			condition = fmt.Sprintf(`%s AND time < :to`, condition)
+			condition = fmt.Sprintf(`%s AND %s < :to`, condition, timeColumn(rpm))
 		}
 	}
+	for i, pf := range rpm.PayloadFilters {
+		condition = fmt.Sprintf(`%s AND %s`, condition, fmtPayloadFilter(i, pf))
+	}
 	return condition
 }
 
+// timeColumn returns the column of the message time. JSON messages are
+// stored with the creation time.
+func timeColumn(rpm readers.PageMetadata) string {
+	if rpm.Format != "" && rpm.Format != defTable {
+		return "created"
+	}
+	return "time"
+}
+
+// fmtPayloadFilter formats the condition of the JSON payload filter. Field
+// paths and values are passed as query parameters.
+func fmtPayloadFilter(i int, pf readers.PayloadFilter) string {
+	path, value := fmt.Sprintf(":payload_path_%d", i), fmt.Sprintf(":payload_value_%d", i)
+	comparator := readers.ParseValueComparator(map[string]interface{}{"comparator": pf.Comparator})
+
+	switch pf.Value.(type) {
+	case float64:
+		return fmt.Sprintf(`%s %s %s`, jsonNumber(path), comparator, value)
+	case bool:
+		return fmt.Sprintf(`(jsonb_typeof(payload #> %s) = 'boolean' AND payload #>> %s %s %s)`, path, path, comparator, value)
+	default:
+		return fmt.Sprintf(`(jsonb_typeof(payload #> %s) = 'string' AND payload #>> %s %s %s)`, path, path, comparator, value)
+	}
+}
+
+// jsonNumber returns the value of the JSON payload field at the path, or
+// NULL if the field is not a number.
+func jsonNumber(path string) string {
+	return fmt.Sprintf(`(CASE WHEN jsonb_typeof(payload #> %s) = 'number' THEN CAST(payload #>> %s AS DOUBLE PRECISION) END)`, path, path)
+}
+
+// fmtPayload returns the payload column, projected to the requested JSON
+// payload fields if any.
+func fmtPayload(rpm readers.PageMetadata) string {
+	if len(rpm.Fields) == 0 {
+		return "payload"
+	}
+
+	fields := make([]string, len(rpm.Fields))
+	for i := range rpm.Fields {
+		fields[i] = fmt.Sprintf("CAST(:field_%d AS TEXT), payload #> :field_path_%d", i, i)
+	}
+	return fmt.Sprintf("jsonb_strip_nulls(jsonb_build_object(%s)) AS payload", strings.Join(fields, ", "))
+}
+
+// payloadParams adds the parameters of the JSON payload queries.
+func payloadParams(rpm readers.PageMetadata, params map[string]interface{}) {
+	for i, pf := range rpm.PayloadFilters {
+		params[fmt.Sprintf("payload_path_%d", i)] = readers.FieldPath(pf.Field)
+		switch v := pf.Value.(type) {
+		case float64:
+			params[fmt.Sprintf("payload_value_%d", i)] = v
+		default:
+			params[fmt.Sprintf("payload_value_%d", i)] = fmt.Sprint(v)
+		}
+	}
+	for i, field := range rpm.Fields {
+		params[fmt.Sprintf("field_%d", i)] = field
+		params[fmt.Sprintf("field_path_%d", i)] = readers.FieldPath(field)
+	}
+	if rpm.Field != "" {
+		params["field"] = rpm.Field
+		params["field_path"] = readers.FieldPath(rpm.Field)
+	}
+}
+
+func hasValueFilter(rpm readers.PageMetadata) bool {
+	return rpm.Value != 0 || rpm.BoolValue || rpm.StringValue != "" || rpm.DataValue != ""
+}
+
+// rollupAggregation returns the aggregation over the series of raw values
+// and downsampled rollups.
+func rollupAggregation(aggregation string) string {
+	switch strings.ToUpper(aggregation) {
+	case "MIN":
+		return "MIN(min_value)"
+	case "MAX":
+		return "MAX(max_value)"
+	case "SUM":
+		return "SUM(sum_value)"
+	case "COUNT":
+		return "SUM(count_value)"
+	default:
+		return "SUM(sum_value) / NULLIF(SUM(count_value), 0)"
+	}
+}
+
 type senmlMessage struct {
 	ID string `db:"id"`
 	senml.Message
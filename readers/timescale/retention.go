@@ -0,0 +1,321 @@
+package timescale
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/hantdev/mitras/pkg/postgres"
+	"github.com/hantdev/mitras/readers/retention"
+	"github.com/jackc/pgx/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// Table for downsampled SenML messages.
+const rollupTable = "messages_rollup"
+
+var errTransRollback = errors.New("failed to rollback transaction")
+
+var _ retention.Repository = (*retentionRepository)(nil)
+
+type retentionRepository struct {
+	db *sqlx.DB
+}
+
+// NewRetentionRepository returns new TimescaleSQL retention policies repository.
+func NewRetentionRepository(db *sqlx.DB) retention.Repository {
+	return &retentionRepository{
+		db: db,
+	}
+}
+
+func (rr retentionRepository) Save(ctx context.Context, policy retention.Policy) (retention.Policy, error) {
+	q := `INSERT INTO retention_policies (id, domain_id, channel_id, raw_days, downsample_interval, downsample_days, created_by, created_at)
+		VALUES (:id, :domain_id, :channel_id, :raw_days, :downsample_interval, :downsample_days, :created_by, :created_at)
+		RETURNING id, domain_id, channel_id, raw_days, downsample_interval, downsample_days, created_by, created_at, updated_at;`
+
+	row, err := rr.db.NamedQueryContext(ctx, q, toDBPolicy(policy))
+	if err != nil {
+		return retention.Policy{}, postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+	defer row.Close()
+
+	return scanPolicy(row, repoerr.ErrCreateEntity)
+}
+
+func (rr retentionRepository) Retrieve(ctx context.Context, domainID, id string) (retention.Policy, error) {
+	q := `SELECT id, domain_id, channel_id, raw_days, downsample_interval, downsample_days, created_by, created_at, updated_at
+		FROM retention_policies WHERE domain_id = :domain_id AND id = :id;`
+
+	row, err := rr.db.NamedQueryContext(ctx, q, dbPolicy{ID: id, DomainID: domainID})
+	if err != nil {
+		return retention.Policy{}, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+	defer row.Close()
+
+	return scanPolicy(row, repoerr.ErrViewEntity)
+}
+
+func (rr retentionRepository) RetrieveAll(ctx context.Context, page retention.Page) (retention.PoliciesPage, error) {
+	var conditions []string
+	if page.DomainID != "" {
+		conditions = append(conditions, "domain_id = :domain_id")
+	}
+	if page.ChannelID != "" {
+		conditions = append(conditions, "channel_id = :channel_id")
+	}
+	var where string
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	var pagination string
+	if page.Limit > 0 {
+		pagination = "LIMIT :limit OFFSET :offset"
+	}
+
+	q := fmt.Sprintf(`SELECT id, domain_id, channel_id, raw_days, downsample_interval, downsample_days, created_by, created_at, updated_at
+		FROM retention_policies %s ORDER BY created_at %s;`, where, pagination)
+
+	rows, err := rr.db.NamedQueryContext(ctx, q, page)
+	if err != nil {
+		return retention.PoliciesPage{}, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	var items []retention.Policy
+	for rows.Next() {
+		var dbp dbPolicy
+		if err := rows.StructScan(&dbp); err != nil {
+			return retention.PoliciesPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		items = append(items, toPolicy(dbp))
+	}
+
+	cq := fmt.Sprintf(`SELECT COUNT(*) FROM retention_policies %s;`, where)
+	crows, err := rr.db.NamedQueryContext(ctx, cq, page)
+	if err != nil {
+		return retention.PoliciesPage{}, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+	defer crows.Close()
+
+	total := uint64(0)
+	if crows.Next() {
+		if err := crows.Scan(&total); err != nil {
+			return retention.PoliciesPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+	}
+
+	return retention.PoliciesPage{
+		Total:    total,
+		Offset:   page.Offset,
+		Limit:    page.Limit,
+		Policies: items,
+	}, nil
+}
+
+func (rr retentionRepository) Update(ctx context.Context, policy retention.Policy) (retention.Policy, error) {
+	q := `UPDATE retention_policies SET raw_days = :raw_days, downsample_interval = :downsample_interval,
+		downsample_days = :downsample_days, updated_at = :updated_at
+		WHERE domain_id = :domain_id AND id = :id
+		RETURNING id, domain_id, channel_id, raw_days, downsample_interval, downsample_days, created_by, created_at, updated_at;`
+
+	row, err := rr.db.NamedQueryContext(ctx, q, toDBPolicy(policy))
+	if err != nil {
+		return retention.Policy{}, postgres.HandleError(repoerr.ErrUpdateEntity, err)
+	}
+	defer row.Close()
+
+	return scanPolicy(row, repoerr.ErrUpdateEntity)
+}
+
+func (rr retentionRepository) Remove(ctx context.Context, domainID, id string) error {
+	q := `DELETE FROM retention_policies WHERE domain_id = $1 AND id = $2;`
+
+	res, err := rr.db.ExecContext(ctx, q, domainID, id)
+	if err != nil {
+		return postgres.HandleError(repoerr.ErrRemoveEntity, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
+func (rr retentionRepository) Channels(ctx context.Context) ([]string, error) {
+	tables, err := rr.jsonTables(ctx)
+	if err != nil {
+		return nil, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	sources := []string{
+		fmt.Sprintf(`SELECT DISTINCT channel::text AS channel FROM %s`, defTable),
+		fmt.Sprintf(`SELECT DISTINCT channel::text AS channel FROM %s`, rollupTable),
+	}
+	for _, t := range tables {
+		sources = append(sources, fmt.Sprintf(`SELECT DISTINCT channel::text AS channel FROM %s`, t))
+	}
+	q := strings.Join(sources, " UNION ") + ";"
+
+	var channels []string
+	if err := rr.db.SelectContext(ctx, &channels, q); err != nil {
+		return nil, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return channels, nil
+}
+
+func (rr retentionRepository) Apply(ctx context.Context, chanID string, policy retention.Policy, now time.Time) (err error) {
+	bucket, err := policy.Bucket()
+	if err != nil {
+		return err
+	}
+
+	tables, err := rr.jsonTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := rr.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if txErr := tx.Rollback(); txErr != nil {
+				err = errors.Wrap(err, errors.Wrap(errTransRollback, txErr))
+			}
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	rawCutoff := policy.RawCutoff(now)
+
+	// Raw values are rolled up in the same transaction in which they are
+	// removed, so the raw and the downsampled data never overlap. A bucket
+	// which straddles the cutoff is merged with its earlier part.
+	if bucket > 0 {
+		q := fmt.Sprintf(`INSERT INTO %s (time, channel, subtopic, publisher, protocol, name, unit, min_value, max_value, sum_value, count_value)
+			SELECT time - (time %% $3) AS bucket, channel, subtopic, publisher, MAX(protocol), name, MAX(unit),
+				MIN(value), MAX(value), SUM(value), COUNT(value)
+			FROM %s WHERE channel = $1 AND time < $2 AND value IS NOT NULL
+			GROUP BY bucket, channel, subtopic, publisher, name
+			ON CONFLICT (time, channel, publisher, subtopic, name) DO UPDATE SET
+				min_value = LEAST(%s.min_value, EXCLUDED.min_value),
+				max_value = GREATEST(%s.max_value, EXCLUDED.max_value),
+				sum_value = %s.sum_value + EXCLUDED.sum_value,
+				count_value = %s.count_value + EXCLUDED.count_value;`,
+			rollupTable, defTable, rollupTable, rollupTable, rollupTable, rollupTable)
+		if _, err = tx.ExecContext(ctx, q, chanID, rawCutoff, bucket); err != nil {
+			return err
+		}
+	}
+
+	q := fmt.Sprintf(`DELETE FROM %s WHERE channel = $1 AND time < $2;`, defTable)
+	if _, err = tx.ExecContext(ctx, q, chanID, rawCutoff); err != nil {
+		return err
+	}
+
+	for _, t := range tables {
+		q := fmt.Sprintf(`DELETE FROM %s WHERE channel = $1 AND created < $2;`, t)
+		if _, err = tx.ExecContext(ctx, q, chanID, rawCutoff); err != nil {
+			return err
+		}
+	}
+
+	if cutoff := policy.DownsampleCutoff(now); cutoff > 0 {
+		q := fmt.Sprintf(`DELETE FROM %s WHERE channel = $1 AND time < $2;`, rollupTable)
+		if _, err = tx.ExecContext(ctx, q, chanID, cutoff); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jsonTables returns sanitized names of the tables created for JSON messages.
+func (rr retentionRepository) jsonTables(ctx context.Context) ([]string, error) {
+	q := `SELECT table_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND column_name = 'payload';`
+
+	var names []string
+	if err := rr.db.SelectContext(ctx, &names, q); err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		names[i] = pgx.Identifier{name}.Sanitize()
+	}
+
+	return names, nil
+}
+
+type dbPolicy struct {
+	ID                 string       `db:"id"`
+	DomainID           string       `db:"domain_id"`
+	ChannelID          string       `db:"channel_id"`
+	RawDays            uint64       `db:"raw_days"`
+	DownsampleInterval string       `db:"downsample_interval"`
+	DownsampleDays     uint64       `db:"downsample_days"`
+	CreatedBy          string       `db:"created_by"`
+	CreatedAt          time.Time    `db:"created_at"`
+	UpdatedAt          sql.NullTime `db:"updated_at"`
+}
+
+func toDBPolicy(p retention.Policy) dbPolicy {
+	var updatedAt sql.NullTime
+	if !p.UpdatedAt.IsZero() {
+		updatedAt = sql.NullTime{Time: p.UpdatedAt, Valid: true}
+	}
+
+	return dbPolicy{
+		ID:                 p.ID,
+		DomainID:           p.DomainID,
+		ChannelID:          p.ChannelID,
+		RawDays:            p.RawDays,
+		DownsampleInterval: p.DownsampleInterval,
+		DownsampleDays:     p.DownsampleDays,
+		CreatedBy:          p.CreatedBy,
+		CreatedAt:          p.CreatedAt,
+		UpdatedAt:          updatedAt,
+	}
+}
+
+func toPolicy(dbp dbPolicy) retention.Policy {
+	var updatedAt time.Time
+	if dbp.UpdatedAt.Valid {
+		updatedAt = dbp.UpdatedAt.Time.UTC()
+	}
+
+	return retention.Policy{
+		ID:                 dbp.ID,
+		DomainID:           dbp.DomainID,
+		ChannelID:          dbp.ChannelID,
+		RawDays:            dbp.RawDays,
+		DownsampleInterval: dbp.DownsampleInterval,
+		DownsampleDays:     dbp.DownsampleDays,
+		CreatedBy:          dbp.CreatedBy,
+		CreatedAt:          dbp.CreatedAt.UTC(),
+		UpdatedAt:          updatedAt,
+	}
+}
+
+func scanPolicy(rows *sqlx.Rows, wrapper error) (retention.Policy, error) {
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return retention.Policy{}, postgres.HandleError(wrapper, err)
+		}
+		return retention.Policy{}, repoerr.ErrNotFound
+	}
+
+	var dbp dbPolicy
+	if err := rows.StructScan(&dbp); err != nil {
+		return retention.Policy{}, errors.Wrap(wrapper, err)
+	}
+
+	return toPolicy(dbp), nil
+}
@@ -0,0 +1,170 @@
+package timescale_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	twriter "github.com/hantdev/mitras/consumers/writers/timescale"
+	"github.com/hantdev/mitras/internal/testsutil"
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/mitras/pkg/transformers/json"
+	"github.com/hantdev/mitras/pkg/transformers/senml"
+	"github.com/hantdev/mitras/readers"
+	"github.com/hantdev/mitras/readers/retention"
+	treader "github.com/hantdev/mitras/readers/timescale"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const retentionFormat = "retention"
+
+type rollup struct {
+	Min   float64 `db:"min_value"`
+	Max   float64 `db:"max_value"`
+	Sum   float64 `db:"sum_value"`
+	Count int64   `db:"count_value"`
+}
+
+func TestRetentionChannels(t *testing.T) {
+	writer := twriter.New(db)
+	repo := treader.NewRetentionRepository(db)
+
+	senmlChanID := testsutil.GenerateUUID(t)
+	jsonChanID := testsutil.GenerateUUID(t)
+
+	err := writer.ConsumeBlocking(context.TODO(), []senml.Message{{Channel: senmlChanID, Publisher: senmlChanID, Time: float64(time.Now().UnixNano()), Value: &v}})
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	err = writer.ConsumeBlocking(context.TODO(), json.Messages{
+		Format: retentionFormat,
+		Data:   []json.Message{{Channel: jsonChanID, Publisher: jsonChanID, Created: time.Now().UnixNano(), Payload: map[string]interface{}{"value": v}}},
+	})
+	require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+
+	channels, err := repo.Channels(context.Background())
+	assert.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+	assert.Contains(t, channels, senmlChanID, "expected channel of SenML messages")
+	assert.Contains(t, channels, jsonChanID, "expected channel of JSON messages")
+}
+
+func TestRetentionApply(t *testing.T) {
+	writer := twriter.New(db)
+	reader := treader.New(db)
+	repo := treader.NewRetentionRepository(db)
+
+	now := time.Now()
+	// Expired raw values fall into a single hourly bucket.
+	base := now.Add(-10 * 24 * time.Hour).Truncate(time.Hour)
+	values := []float64{1, 2, 3}
+	recent := float64(5)
+
+	cases := []struct {
+		desc     string
+		policy   retention.Policy
+		raw      int
+		rollups  []rollup
+		messages []float64
+		err      error
+	}{
+		{
+			desc:     "apply policy without downsampling",
+			policy:   retention.Policy{RawDays: 7},
+			raw:      1,
+			messages: []float64{recent},
+		},
+		{
+			desc:     "apply policy with downsampling",
+			policy:   retention.Policy{RawDays: 7, DownsampleInterval: "1h"},
+			raw:      1,
+			rollups:  []rollup{{Min: 1, Max: 3, Sum: 6, Count: 3}},
+			messages: []float64{recent, 2},
+		},
+		{
+			desc:     "apply policy with expired rollups",
+			policy:   retention.Policy{RawDays: 7, DownsampleInterval: "1h", DownsampleDays: 9},
+			raw:      1,
+			messages: []float64{recent},
+		},
+		{
+			desc:     "apply policy with invalid downsample interval",
+			policy:   retention.Policy{RawDays: 7, DownsampleInterval: "0s"},
+			raw:      len(values) + 1,
+			messages: []float64{recent, 3, 2, 1},
+			err:      retention.ErrInvalidPolicy,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			chanID := testsutil.GenerateUUID(t)
+			pubID := testsutil.GenerateUUID(t)
+
+			messages := []senml.Message{}
+			for i := range values {
+				messages = append(messages, senml.Message{
+					Channel:   chanID,
+					Publisher: pubID,
+					Protocol:  mqttProt,
+					Name:      msgName,
+					Time:      float64(base.Add(time.Duration(i+1) * time.Second).UnixNano()),
+					Value:     &values[i],
+				})
+			}
+			messages = append(messages, senml.Message{
+				Channel:   chanID,
+				Publisher: pubID,
+				Protocol:  mqttProt,
+				Name:      msgName,
+				Time:      float64(now.Add(-time.Hour).UnixNano()),
+				Value:     &recent,
+			})
+			err := writer.ConsumeBlocking(context.TODO(), messages)
+			require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+			err = writer.ConsumeBlocking(context.TODO(), json.Messages{
+				Format: retentionFormat,
+				Data: []json.Message{
+					{Channel: chanID, Publisher: pubID, Created: base.UnixNano(), Payload: map[string]interface{}{"value": values[0]}},
+					{Channel: chanID, Publisher: pubID, Created: now.Add(-time.Hour).UnixNano(), Payload: map[string]interface{}{"value": recent}},
+				},
+			})
+			require.Nil(t, err, fmt.Sprintf("expected no error got %s\n", err))
+
+			err = repo.Apply(context.Background(), chanID, tc.policy, now)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+
+			var raw int
+			err = db.Get(&raw, `SELECT COUNT(*) FROM messages WHERE channel = $1;`, chanID)
+			require.Nil(t, err, fmt.Sprintf("%s: expected no error got %s\n", tc.desc, err))
+			assert.Equal(t, tc.raw, raw, fmt.Sprintf("%s: expected %d raw messages got %d\n", tc.desc, tc.raw, raw))
+
+			var jsonRaw int
+			err = db.Get(&jsonRaw, fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE channel = $1;`, retentionFormat), chanID)
+			require.Nil(t, err, fmt.Sprintf("%s: expected no error got %s\n", tc.desc, err))
+			expected := 1
+			if tc.err != nil {
+				expected = 2
+			}
+			assert.Equal(t, expected, jsonRaw, fmt.Sprintf("%s: expected %d JSON messages got %d\n", tc.desc, expected, jsonRaw))
+
+			rollups := []rollup{}
+			err = db.Select(&rollups, `SELECT min_value, max_value, sum_value, count_value FROM messages_rollup WHERE channel = $1;`, chanID)
+			require.Nil(t, err, fmt.Sprintf("%s: expected no error got %s\n", tc.desc, err))
+			if tc.rollups == nil {
+				tc.rollups = []rollup{}
+			}
+			assert.Equal(t, tc.rollups, rollups, fmt.Sprintf("%s: got unexpected rollups\n", tc.desc))
+
+			page, err := reader.ReadAll(chanID, readers.PageMetadata{Limit: limit})
+			require.Nil(t, err, fmt.Sprintf("%s: expected no error got %s\n", tc.desc, err))
+			read := []float64{}
+			for _, msg := range page.Messages {
+				m, ok := msg.(senml.Message)
+				require.True(t, ok, fmt.Sprintf("%s: expected SenML message", tc.desc))
+				require.NotNil(t, m.Value, fmt.Sprintf("%s: expected message value", tc.desc))
+				read = append(read, *m.Value)
+			}
+			assert.Equal(t, tc.messages, read, fmt.Sprintf("%s: got unexpected message values\n", tc.desc))
+		})
+	}
+}
@@ -8,11 +8,13 @@ import (
 	"os"
 	"testing"
 
+	twriter "github.com/hantdev/mitras/consumers/writers/timescale"
 	"github.com/hantdev/mitras/readers/timescale"
 	_ "github.com/jackc/pgx/v5/stdlib" // required for SQL access
 	"github.com/jmoiron/sqlx"
 	"github.com/ory/dockertest/v3"
 	"github.com/ory/dockertest/v3/docker"
+	migrate "github.com/rubenv/sql-migrate"
 )
 
 var db *sqlx.DB
@@ -69,6 +71,11 @@ func TestMain(m *testing.M) {
 		log.Fatalf("Could not setup test DB connection: %s", err)
 	}
 
+	// Retention tables are created by the writer migrations.
+	if _, err = migrate.Exec(db.DB, "postgres", twriter.Migration(), migrate.Up); err != nil {
+		log.Fatalf("Could not migrate test DB: %s", err)
+	}
+
 	code := m.Run()
 
 	// Defers will not be run when using os.Exit
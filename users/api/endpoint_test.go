@@ -9,9 +9,9 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
-	authmocks "github.com/hantdev/mitras/auth/mocks"
 	"github.com/hantdev/mitras/internal/api"
 	grpcTokenV1 "github.com/hantdev/mitras/internal/grpc/token/v1"
 	"github.com/hantdev/mitras/internal/testsutil"
@@ -27,6 +27,7 @@ import (
 	"github.com/hantdev/mitras/users/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/oauth2"
 )
 
 var (
@@ -89,8 +90,7 @@ func newUsersServer() (*httptest.Server, *mocks.Service, *authnmocks.Authenticat
 	provider := new(oauth2mocks.Provider)
 	provider.On("Name").Return("test")
 	authn := new(authnmocks.Authentication)
-	token := new(authmocks.TokenServiceClient)
-	httpapi.MakeHandler(svc, authn, token, true, mux, logger, "", passRegex, provider)
+	httpapi.MakeHandler(svc, authn, true, mux, logger, "", passRegex, nil, provider)
 
 	return httptest.NewServer(mux), svc, authn
 }
@@ -231,7 +231,7 @@ func TestRegister(t *testing.T) {
 				body:        strings.NewReader(data),
 			}
 
-			svcCall := svc.On("Register", mock.Anything, smqauthn.Session{}, tc.user, true).Return(tc.user, tc.err)
+			svcCall := svc.On("Register", mock.Anything, smqauthn.Session{}, tc.user, true, "").Return(tc.user, tc.err)
 			res, err := req.make()
 			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
 			var errRes respBody
@@ -1727,7 +1727,7 @@ func TestPasswordResetRequest(t *testing.T) {
 				referer:     tc.referer,
 				body:        strings.NewReader(tc.data),
 			}
-			svcCall := svc.On("GenerateResetToken", mock.Anything, mock.Anything, mock.Anything).Return(tc.generateErr)
+			svcCall := svc.On("GenerateResetToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tc.generateErr)
 			svcCall1 := svc.On("SendPasswordReset", mock.Anything, mock.Anything, mock.Anything, mock.Anything, validToken).Return(tc.err)
 			res, err := req.make()
 			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
@@ -2178,7 +2178,7 @@ func TestIssueToken(t *testing.T) {
 				body:        strings.NewReader(tc.data),
 			}
 
-			svcCall := svc.On("IssueToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&grpcTokenV1.Token{AccessToken: validToken}, tc.err)
+			svcCall := svc.On("IssueToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&grpcTokenV1.Token{AccessToken: validToken}, tc.err)
 			res, err := req.make()
 			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
 			if tc.err != nil {
@@ -2196,6 +2196,978 @@ func TestIssueToken(t *testing.T) {
 	}
 }
 
+func TestIssueTokenMFAChallenge(t *testing.T) {
+	us, svc, _ := newUsersServer()
+	defer us.Close()
+
+	cases := []struct {
+		desc     string
+		token    *grpcTokenV1.Token
+		status   int
+		response string
+	}{
+		{
+			desc:     "issue token for user with enabled MFA",
+			token:    &grpcTokenV1.Token{AccessToken: validToken, AccessType: users.MFAAccessType},
+			status:   http.StatusOK,
+			response: fmt.Sprintf(`{"mfa_token":"%s","enrollment_required":false}`, validToken),
+		},
+		{
+			desc:     "issue token for user who must enroll MFA",
+			token:    &grpcTokenV1.Token{AccessToken: validToken, AccessType: users.MFAEnrollAccessType},
+			status:   http.StatusOK,
+			response: fmt.Sprintf(`{"mfa_token":"%s","enrollment_required":true}`, validToken),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:        us.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/users/tokens/issue", us.URL),
+				contentType: contentType,
+				body:        strings.NewReader(fmt.Sprintf(`{"identity": "%s", "secret": "%s"}`, "valid", secret)),
+			}
+
+			svcCall := svc.On("IssueToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tc.token, nil)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			body, err := io.ReadAll(res.Body)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error while reading response body: %s", tc.desc, err))
+			assert.JSONEq(t, tc.response, string(body))
+			svcCall.Unset()
+		})
+	}
+}
+
+func TestIssueMFAToken(t *testing.T) {
+	us, svc, _ := newUsersServer()
+	defer us.Close()
+
+	cases := []struct {
+		desc        string
+		data        string
+		contentType string
+		status      int
+		svcErr      error
+		err         error
+	}{
+		{
+			desc:        "issue MFA token with valid MFA token and code",
+			data:        fmt.Sprintf(`{"mfa_token": "%s", "code": "123456"}`, validToken),
+			contentType: contentType,
+			status:      http.StatusCreated,
+			err:         nil,
+		},
+		{
+			desc:        "issue MFA token with empty MFA token",
+			data:        `{"mfa_token": "", "code": "123456"}`,
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+			err:         apiutil.ErrMissingMFAToken,
+		},
+		{
+			desc:        "issue MFA token with empty code",
+			data:        fmt.Sprintf(`{"mfa_token": "%s", "code": ""}`, validToken),
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+			err:         apiutil.ErrMissingMFACode,
+		},
+		{
+			desc:        "issue MFA token with invalid code",
+			data:        fmt.Sprintf(`{"mfa_token": "%s", "code": "000000"}`, validToken),
+			contentType: contentType,
+			status:      http.StatusUnauthorized,
+			svcErr:      svcerr.ErrAuthentication,
+			err:         svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "issue MFA token with malformed data",
+			data:        fmt.Sprintf(`{"mfa_token": %s}`, validToken),
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+			err:         apiutil.ErrValidation,
+		},
+		{
+			desc:        "issue MFA token with invalid content type",
+			data:        fmt.Sprintf(`{"mfa_token": "%s", "code": "123456"}`, validToken),
+			contentType: "application/xml",
+			status:      http.StatusUnsupportedMediaType,
+			err:         apiutil.ErrUnsupportedContentType,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:        us.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/users/tokens/mfa", us.URL),
+				contentType: tc.contentType,
+				body:        strings.NewReader(tc.data),
+			}
+
+			svcCall := svc.On("IssueMFAToken", mock.Anything, validToken, mock.Anything).Return(&grpcTokenV1.Token{AccessToken: validToken, RefreshToken: &validToken}, tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			if tc.err != nil {
+				var resBody respBody
+				err = json.NewDecoder(res.Body).Decode(&resBody)
+				assert.Nil(t, err, fmt.Sprintf("%s: unexpected error while decoding response body: %s", tc.desc, err))
+				if resBody.Err != "" || resBody.Message != "" {
+					err = errors.Wrap(errors.New(resBody.Err), errors.New(resBody.Message))
+				}
+				assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			}
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+		})
+	}
+}
+
+func TestIssueTokenPasswordChange(t *testing.T) {
+	us, svc, _ := newUsersServer()
+	defer us.Close()
+
+	req := testRequest{
+		user:        us.Client(),
+		method:      http.MethodPost,
+		url:         fmt.Sprintf("%s/users/tokens/issue", us.URL),
+		contentType: contentType,
+		body:        strings.NewReader(fmt.Sprintf(`{"identity": "%s", "secret": "%s"}`, "valid", secret)),
+	}
+
+	svcCall := svc.On("IssueToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&grpcTokenV1.Token{AccessToken: validToken, AccessType: users.PasswordChangeAccessType}, nil)
+	res, err := req.make()
+	assert.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	assert.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("expected status code %d got %d", http.StatusOK, res.StatusCode))
+	body, err := io.ReadAll(res.Body)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error while reading response body: %s", err))
+	assert.JSONEq(t, fmt.Sprintf(`{"password_change_token":"%s","password_expired":true}`, validToken), string(body))
+	svcCall.Unset()
+}
+
+func TestChangeExpiredSecret(t *testing.T) {
+	us, svc, _ := newUsersServer()
+	defer us.Close()
+
+	cases := []struct {
+		desc        string
+		data        string
+		contentType string
+		status      int
+		svcErr      error
+		err         error
+	}{
+		{
+			desc:        "change expired secret with valid token and secret",
+			data:        fmt.Sprintf(`{"password_change_token": "%s", "new_secret": "%s"}`, validToken, secret),
+			contentType: contentType,
+			status:      http.StatusCreated,
+			err:         nil,
+		},
+		{
+			desc:        "change expired secret with empty token",
+			data:        fmt.Sprintf(`{"password_change_token": "", "new_secret": "%s"}`, secret),
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+			err:         apiutil.ErrMissingPasswordChangeToken,
+		},
+		{
+			desc:        "change expired secret with empty secret",
+			data:        fmt.Sprintf(`{"password_change_token": "%s", "new_secret": ""}`, validToken),
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+			err:         apiutil.ErrMissingPass,
+		},
+		{
+			desc:        "change expired secret with invalid token",
+			data:        fmt.Sprintf(`{"password_change_token": "%s", "new_secret": "%s"}`, inValidToken, secret),
+			contentType: contentType,
+			status:      http.StatusUnauthorized,
+			svcErr:      svcerr.ErrAuthentication,
+			err:         svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "change expired secret with secret violating the policy",
+			data:        fmt.Sprintf(`{"password_change_token": "%s", "new_secret": "%s"}`, validToken, secret),
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+			svcErr:      apiutil.ErrPasswordFormat,
+			err:         apiutil.ErrPasswordFormat,
+		},
+		{
+			desc:        "change expired secret with malformed data",
+			data:        fmt.Sprintf(`{"password_change_token": %s}`, validToken),
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+			err:         apiutil.ErrValidation,
+		},
+		{
+			desc:        "change expired secret with invalid content type",
+			data:        fmt.Sprintf(`{"password_change_token": "%s", "new_secret": "%s"}`, validToken, secret),
+			contentType: "application/xml",
+			status:      http.StatusUnsupportedMediaType,
+			err:         apiutil.ErrUnsupportedContentType,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:        us.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/users/tokens/password", us.URL),
+				contentType: tc.contentType,
+				body:        strings.NewReader(tc.data),
+			}
+
+			svcCall := svc.On("ChangeExpiredSecret", mock.Anything, mock.Anything, secret, mock.Anything, mock.Anything).Return(&grpcTokenV1.Token{AccessToken: validToken, RefreshToken: &validToken}, tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			if tc.err != nil {
+				var resBody respBody
+				err = json.NewDecoder(res.Body).Decode(&resBody)
+				assert.Nil(t, err, fmt.Sprintf("%s: unexpected error while decoding response body: %s", tc.desc, err))
+				if resBody.Err != "" || resBody.Message != "" {
+					err = errors.Wrap(errors.New(resBody.Err), errors.New(resBody.Message))
+				}
+				assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			}
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+		})
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	us, svc, _ := newUsersServer()
+	defer us.Close()
+
+	pp := users.PasswordPolicy{
+		MinLength:    12,
+		RequireUpper: true,
+		RequireDigit: true,
+		Denylist:     map[string]struct{}{"password": {}},
+		History:      5,
+		MaxAge:       36 * time.Hour,
+	}
+	req := testRequest{
+		user:   us.Client(),
+		method: http.MethodGet,
+		url:    fmt.Sprintf("%s/password/policy", us.URL),
+	}
+
+	svcCall := svc.On("PasswordPolicy", mock.Anything).Return(pp)
+	res, err := req.make()
+	assert.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	assert.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("expected status code %d got %d", http.StatusOK, res.StatusCode))
+	body, err := io.ReadAll(res.Body)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error while reading response body: %s", err))
+	expected := `{"min_length":12,"require_uppercase":true,"require_lowercase":false,"require_digit":true,"require_symbol":false,"denylist":true,"history":5,"max_age_days":2}`
+	assert.JSONEq(t, expected, string(body))
+	svcCall.Unset()
+}
+
+func TestEnrollMFAWithChallenge(t *testing.T) {
+	us, svc, _ := newUsersServer()
+	defer us.Close()
+
+	cases := []struct {
+		desc   string
+		data   string
+		status int
+		svcErr error
+		err    error
+	}{
+		{
+			desc:   "enroll MFA with valid MFA token",
+			data:   fmt.Sprintf(`{"mfa_token": "%s"}`, validToken),
+			status: http.StatusCreated,
+			err:    nil,
+		},
+		{
+			desc:   "enroll MFA with empty MFA token",
+			data:   `{"mfa_token": ""}`,
+			status: http.StatusBadRequest,
+			err:    apiutil.ErrMissingMFAToken,
+		},
+		{
+			desc:   "enroll MFA with invalid MFA token",
+			data:   fmt.Sprintf(`{"mfa_token": "%s"}`, validToken),
+			status: http.StatusUnauthorized,
+			svcErr: svcerr.ErrAuthentication,
+			err:    svcerr.ErrAuthentication,
+		},
+		{
+			desc:   "enroll MFA with enabled MFA",
+			data:   fmt.Sprintf(`{"mfa_token": "%s"}`, validToken),
+			status: http.StatusConflict,
+			svcErr: svcerr.ErrConflict,
+			err:    svcerr.ErrConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:        us.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/users/tokens/mfa/enroll", us.URL),
+				contentType: contentType,
+				body:        strings.NewReader(tc.data),
+			}
+
+			svcCall := svc.On("EnrollMFAWithChallenge", mock.Anything, validToken).Return(users.MFAEnrollment{}, tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+		})
+	}
+}
+
+func TestEnrollMFA(t *testing.T) {
+	us, svc, authn := newUsersServer()
+	defer us.Close()
+
+	enrollment := users.MFAEnrollment{
+		Secret:        "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		URI:           "otpauth://totp/Mitras:user",
+		RecoveryCodes: []string{"abcde-fghij"},
+	}
+	cases := []struct {
+		desc     string
+		token    string
+		authnRes smqauthn.Session
+		authnErr error
+		status   int
+		svcErr   error
+	}{
+		{
+			desc:     "enroll MFA with valid token",
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusCreated,
+		},
+		{
+			desc:     "enroll MFA with invalid token",
+			token:    inValidToken,
+			authnErr: svcerr.ErrAuthentication,
+			status:   http.StatusUnauthorized,
+		},
+		{
+			desc:     "enroll MFA with enabled MFA",
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusConflict,
+			svcErr:   svcerr.ErrConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:   us.Client(),
+				method: http.MethodPost,
+				url:    fmt.Sprintf("%s/users/mfa/enroll", us.URL),
+				token:  tc.token,
+			}
+
+			authnCall := authn.On("Authenticate", mock.Anything, tc.token).Return(tc.authnRes, tc.authnErr)
+			svcCall := svc.On("EnrollMFA", mock.Anything, tc.authnRes).Return(enrollment, tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			if tc.status == http.StatusCreated {
+				var resBody users.MFAEnrollment
+				err = json.NewDecoder(res.Body).Decode(&resBody)
+				assert.Nil(t, err, fmt.Sprintf("%s: unexpected error while decoding response body: %s", tc.desc, err))
+				assert.Equal(t, enrollment, resBody)
+			}
+			svcCall.Unset()
+			authnCall.Unset()
+		})
+	}
+}
+
+func TestVerifyMFA(t *testing.T) {
+	us, svc, authn := newUsersServer()
+	defer us.Close()
+
+	cases := []struct {
+		desc     string
+		data     string
+		token    string
+		authnRes smqauthn.Session
+		authnErr error
+		status   int
+		svcErr   error
+	}{
+		{
+			desc:     "verify MFA with valid code",
+			data:     `{"code": "123456"}`,
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusNoContent,
+		},
+		{
+			desc:     "verify MFA with invalid token",
+			data:     `{"code": "123456"}`,
+			token:    inValidToken,
+			authnErr: svcerr.ErrAuthentication,
+			status:   http.StatusUnauthorized,
+		},
+		{
+			desc:     "verify MFA with empty code",
+			data:     `{"code": ""}`,
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusBadRequest,
+		},
+		{
+			desc:     "verify MFA with invalid code",
+			data:     `{"code": "000000"}`,
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusUnauthorized,
+			svcErr:   svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:        us.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/users/mfa/verify", us.URL),
+				contentType: contentType,
+				token:       tc.token,
+				body:        strings.NewReader(tc.data),
+			}
+
+			authnCall := authn.On("Authenticate", mock.Anything, tc.token).Return(tc.authnRes, tc.authnErr)
+			svcCall := svc.On("VerifyMFA", mock.Anything, tc.authnRes, mock.Anything).Return(tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+			authnCall.Unset()
+		})
+	}
+}
+
+func TestResetMFA(t *testing.T) {
+	us, svc, authn := newUsersServer()
+	defer us.Close()
+
+	cases := []struct {
+		desc     string
+		id       string
+		token    string
+		authnRes smqauthn.Session
+		authnErr error
+		status   int
+		svcErr   error
+	}{
+		{
+			desc:     "reset MFA as admin with valid token",
+			id:       user.ID,
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusNoContent,
+		},
+		{
+			desc:     "reset MFA with invalid token",
+			id:       user.ID,
+			token:    inValidToken,
+			authnErr: svcerr.ErrAuthentication,
+			status:   http.StatusUnauthorized,
+		},
+		{
+			desc:     "reset MFA as non admin",
+			id:       user.ID,
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusForbidden,
+			svcErr:   svcerr.ErrAuthorization,
+		},
+		{
+			desc:     "reset MFA of user without MFA",
+			id:       user.ID,
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusNotFound,
+			svcErr:   svcerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:   us.Client(),
+				method: http.MethodDelete,
+				url:    fmt.Sprintf("%s/users/%s/mfa", us.URL, tc.id),
+				token:  tc.token,
+			}
+
+			authnCall := authn.On("Authenticate", mock.Anything, tc.token).Return(tc.authnRes, tc.authnErr)
+			svcCall := svc.On("ResetMFA", mock.Anything, tc.authnRes, tc.id).Return(tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+			authnCall.Unset()
+		})
+	}
+}
+
+func TestUnlock(t *testing.T) {
+	us, svc, authn := newUsersServer()
+	defer us.Close()
+
+	cases := []struct {
+		desc     string
+		id       string
+		token    string
+		authnRes smqauthn.Session
+		authnErr error
+		status   int
+		svcErr   error
+	}{
+		{
+			desc:     "unlock user as admin with valid token",
+			id:       user.ID,
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusNoContent,
+		},
+		{
+			desc:     "unlock user with invalid token",
+			id:       user.ID,
+			token:    inValidToken,
+			authnErr: svcerr.ErrAuthentication,
+			status:   http.StatusUnauthorized,
+		},
+		{
+			desc:     "unlock user as non admin",
+			id:       user.ID,
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusForbidden,
+			svcErr:   svcerr.ErrAuthorization,
+		},
+		{
+			desc:     "unlock non-existing user",
+			id:       user.ID,
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusBadRequest,
+			svcErr:   svcerr.ErrViewEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:   us.Client(),
+				method: http.MethodPost,
+				url:    fmt.Sprintf("%s/users/%s/unlock", us.URL, tc.id),
+				token:  tc.token,
+			}
+
+			authnCall := authn.On("Authenticate", mock.Anything, tc.token).Return(tc.authnRes, tc.authnErr)
+			svcCall := svc.On("Unlock", mock.Anything, tc.authnRes, tc.id).Return(tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+			authnCall.Unset()
+		})
+	}
+}
+
+func TestImpersonate(t *testing.T) {
+	us, svc, authn := newUsersServer()
+	defer us.Close()
+
+	cases := []struct {
+		desc        string
+		id          string
+		token       string
+		data        string
+		contentType string
+		readOnly    bool
+		authnRes    smqauthn.Session
+		authnErr    error
+		svcRes      *grpcTokenV1.Token
+		svcErr      error
+		status      int
+		err         error
+	}{
+		{
+			desc:        "impersonate user as admin with valid token",
+			id:          user.ID,
+			token:       validToken,
+			data:        `{}`,
+			contentType: contentType,
+			authnRes:    smqauthn.Session{UserID: validID},
+			svcRes:      &grpcTokenV1.Token{AccessToken: validToken},
+			status:      http.StatusCreated,
+		},
+		{
+			desc:        "impersonate user read-only as admin",
+			id:          user.ID,
+			token:       validToken,
+			data:        `{"read_only": true}`,
+			contentType: contentType,
+			readOnly:    true,
+			authnRes:    smqauthn.Session{UserID: validID},
+			svcRes:      &grpcTokenV1.Token{AccessToken: validToken},
+			status:      http.StatusCreated,
+		},
+		{
+			desc:        "impersonate user with invalid token",
+			id:          user.ID,
+			token:       inValidToken,
+			data:        `{}`,
+			contentType: contentType,
+			authnErr:    svcerr.ErrAuthentication,
+			status:      http.StatusUnauthorized,
+			err:         svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "impersonate user with read-only impersonation token",
+			id:          user.ID,
+			token:       validToken,
+			data:        `{}`,
+			contentType: contentType,
+			authnRes:    smqauthn.Session{UserID: validID, ImpersonatorID: testsutil.GenerateUUID(t), ReadOnly: true},
+			status:      http.StatusForbidden,
+			err:         svcerr.ErrAuthorization,
+		},
+		{
+			desc:        "impersonate user as non admin",
+			id:          user.ID,
+			token:       validToken,
+			data:        `{}`,
+			contentType: contentType,
+			authnRes:    smqauthn.Session{UserID: validID},
+			svcRes:      &grpcTokenV1.Token{},
+			svcErr:      svcerr.ErrAuthorization,
+			status:      http.StatusForbidden,
+			err:         svcerr.ErrAuthorization,
+		},
+		{
+			desc:        "impersonate user with invalid content type",
+			id:          user.ID,
+			token:       validToken,
+			data:        `{}`,
+			contentType: "application/xml",
+			authnRes:    smqauthn.Session{UserID: validID},
+			status:      http.StatusUnsupportedMediaType,
+			err:         apiutil.ErrUnsupportedContentType,
+		},
+		{
+			desc:        "impersonate user with malformed request",
+			id:          user.ID,
+			token:       validToken,
+			data:        `{"read_only": "yes"}`,
+			contentType: contentType,
+			authnRes:    smqauthn.Session{UserID: validID},
+			status:      http.StatusBadRequest,
+			err:         apiutil.ErrValidation,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:        us.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/users/%s/impersonate", us.URL, tc.id),
+				contentType: tc.contentType,
+				token:       tc.token,
+				body:        strings.NewReader(tc.data),
+			}
+
+			authnCall := authn.On("Authenticate", mock.Anything, tc.token).Return(tc.authnRes, tc.authnErr)
+			svcCall := svc.On("Impersonate", mock.Anything, tc.authnRes, tc.id, tc.readOnly).Return(tc.svcRes, tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			var resBody respBody
+			err = json.NewDecoder(res.Body).Decode(&resBody)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error while decoding response body: %s", tc.desc, err))
+			if resBody.Err != "" || resBody.Message != "" {
+				err = errors.Wrap(errors.New(resBody.Err), errors.New(resBody.Message))
+			}
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected error %s got %s", tc.desc, tc.err, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			if tc.status == http.StatusCreated {
+				svcCall.Parent.AssertCalled(t, "Impersonate", mock.Anything, tc.authnRes, tc.id, tc.readOnly)
+			}
+			svcCall.Unset()
+			authnCall.Unset()
+		})
+	}
+}
+
+func TestRegisterWithInvitation(t *testing.T) {
+	us, svc, _ := newUsersServer()
+	defer us.Close()
+
+	cases := []struct {
+		desc       string
+		invitation string
+		status     int
+		svcErr     error
+	}{
+		{
+			desc:       "register user with valid invitation",
+			invitation: validToken,
+			status:     http.StatusCreated,
+		},
+		{
+			desc:       "register user with invalid invitation",
+			invitation: inValidToken,
+			status:     http.StatusForbidden,
+			svcErr:     svcerr.ErrAuthorization,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			data := toJSON(struct {
+				users.User
+				Invitation string `json:"invitation"`
+			}{user, tc.invitation})
+			req := testRequest{
+				user:        us.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/users/", us.URL),
+				contentType: contentType,
+				body:        strings.NewReader(data),
+			}
+
+			svcCall := svc.On("Register", mock.Anything, smqauthn.Session{}, user, true, tc.invitation).Return(user, tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	us, svc, _ := newUsersServer()
+	defer us.Close()
+
+	cases := []struct {
+		desc        string
+		data        string
+		contentType string
+		status      int
+		svcErr      error
+	}{
+		{
+			desc:        "verify email with valid token",
+			data:        fmt.Sprintf(`{"token": "%s"}`, validToken),
+			contentType: contentType,
+			status:      http.StatusNoContent,
+		},
+		{
+			desc:        "verify email with empty token",
+			data:        `{"token": ""}`,
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "verify email with invalid token",
+			data:        fmt.Sprintf(`{"token": "%s"}`, inValidToken),
+			contentType: contentType,
+			status:      http.StatusUnauthorized,
+			svcErr:      svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "verify already verified email",
+			data:        fmt.Sprintf(`{"token": "%s"}`, validToken),
+			contentType: contentType,
+			status:      http.StatusConflict,
+			svcErr:      svcerr.ErrConflict,
+		},
+		{
+			desc:        "verify email with invalid content type",
+			data:        fmt.Sprintf(`{"token": "%s"}`, validToken),
+			contentType: "application/xml",
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			desc:        "verify email with malformed request",
+			data:        `{"token": }`,
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:        us.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/users/verify-email", us.URL),
+				contentType: tc.contentType,
+				body:        strings.NewReader(tc.data),
+			}
+
+			svcCall := svc.On("VerifyEmail", mock.Anything, mock.Anything).Return(tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+		})
+	}
+}
+
+func TestSendVerification(t *testing.T) {
+	us, svc, _ := newUsersServer()
+	defer us.Close()
+
+	cases := []struct {
+		desc   string
+		data   string
+		status int
+		svcErr error
+	}{
+		{
+			desc:   "resend verification with valid email",
+			data:   fmt.Sprintf(`{"email": "%s"}`, user.Email),
+			status: http.StatusCreated,
+		},
+		{
+			desc:   "resend verification with empty email",
+			data:   `{"email": ""}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "resend verification with invalid email",
+			data:   `{"email": "invalid"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "resend verification to verified user",
+			data:   fmt.Sprintf(`{"email": "%s"}`, user.Email),
+			status: http.StatusCreated,
+			svcErr: svcerr.ErrConflict,
+		},
+		{
+			desc:   "resend verification to non-existing user",
+			data:   `{"email": "unknown@example.com"}`,
+			status: http.StatusCreated,
+			svcErr: svcerr.ErrViewEntity,
+		},
+		{
+			desc:   "resend verification with too many requests",
+			data:   fmt.Sprintf(`{"email": "%s"}`, user.Email),
+			status: http.StatusCreated,
+			svcErr: users.ErrLockedOut,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:        us.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/users/verify-email/resend", us.URL),
+				contentType: contentType,
+				body:        strings.NewReader(tc.data),
+			}
+
+			svcCall := svc.On("SendVerification", mock.Anything, mock.Anything, mock.Anything).Return(tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+		})
+	}
+}
+
+func TestInviteUser(t *testing.T) {
+	us, svc, authn := newUsersServer()
+	defer us.Close()
+
+	cases := []struct {
+		desc     string
+		data     string
+		token    string
+		authnRes smqauthn.Session
+		authnErr error
+		status   int
+		svcErr   error
+	}{
+		{
+			desc:     "invite user as admin",
+			data:     `{"email": "invited@example.com"}`,
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusCreated,
+		},
+		{
+			desc:     "invite user with invalid token",
+			data:     `{"email": "invited@example.com"}`,
+			token:    inValidToken,
+			authnErr: svcerr.ErrAuthentication,
+			status:   http.StatusUnauthorized,
+		},
+		{
+			desc:     "invite user with empty email",
+			data:     `{"email": ""}`,
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusBadRequest,
+		},
+		{
+			desc:     "invite user as non admin",
+			data:     `{"email": "invited@example.com"}`,
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusForbidden,
+			svcErr:   svcerr.ErrAuthorization,
+		},
+		{
+			desc:     "invite existing user",
+			data:     `{"email": "invited@example.com"}`,
+			token:    validToken,
+			authnRes: smqauthn.Session{UserID: validID},
+			status:   http.StatusConflict,
+			svcErr:   svcerr.ErrConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:        us.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/users/invite", us.URL),
+				contentType: contentType,
+				token:       tc.token,
+				body:        strings.NewReader(tc.data),
+			}
+
+			authnCall := authn.On("Authenticate", mock.Anything, tc.token).Return(tc.authnRes, tc.authnErr)
+			svcCall := svc.On("InviteUser", mock.Anything, tc.authnRes, "invited@example.com").Return(tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+			authnCall.Unset()
+		})
+	}
+}
+
 func TestRefreshToken(t *testing.T) {
 	us, svc, authn := newUsersServer()
 	defer us.Close()
@@ -3893,3 +4865,436 @@ type respBody struct {
 	Role    users.Role   `json:"role"`
 	Status  users.Status `json:"status"`
 }
+
+func TestOAuthAuthorize(t *testing.T) {
+	svc := new(mocks.Service)
+	mux := chi.NewRouter()
+	provider := new(oauth2mocks.Provider)
+	provider.On("Name").Return("test")
+	provider.On("IsEnabled").Return(true)
+	provider.On("AuthCodeURL", mock.Anything).Return("http://localhost/auth")
+	httpapi.MakeHandler(svc, new(authnmocks.Authentication), true, mux, smqlog.NewMock(), "", passRegex, nil, provider)
+	us := httptest.NewServer(mux)
+	defer us.Close()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(us.URL + "/oauth/authorize/test")
+	assert.Nil(t, err, fmt.Sprintf("authorize unexpected error %s", err))
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusFound, res.StatusCode)
+	assert.Equal(t, "http://localhost/auth", res.Header.Get("Location"))
+
+	var verifier string
+	for _, c := range res.Cookies() {
+		if c.Name == "oauth_verifier_test" {
+			verifier = c.Value
+			assert.True(t, c.HttpOnly)
+		}
+	}
+	assert.NotEmpty(t, verifier)
+	provider.AssertCalled(t, "AuthCodeURL", verifier)
+}
+
+func TestOAuthCallback(t *testing.T) {
+	svc := new(mocks.Service)
+	mux := chi.NewRouter()
+	provider := new(oauth2mocks.Provider)
+	provider.On("Name").Return("test")
+	provider.On("IsEnabled").Return(true)
+	provider.On("State").Return("state")
+	provider.On("RedirectURL").Return("http://localhost/redirect")
+	provider.On("ErrorURL").Return("http://localhost/error")
+	provider.On("Exchange", mock.Anything, "code", mock.Anything).Return(oauth2.Token{AccessToken: validToken}, nil)
+	httpapi.MakeHandler(svc, new(authnmocks.Authentication), true, mux, smqlog.NewMock(), "", passRegex, nil, provider)
+	us := httptest.NewServer(mux)
+	defer us.Close()
+
+	user := users.User{ID: validID, Email: "oauth@example.com", Role: users.UserRole}
+	refreshToken := validToken
+
+	cases := []struct {
+		desc         string
+		issueRes     *grpcTokenV1.Token
+		issueErr     error
+		location     string
+		cookies      map[string]string
+		emptyCookies []string
+	}{
+		{
+			desc:         "callback with user without MFA",
+			issueRes:     &grpcTokenV1.Token{AccessToken: validToken, RefreshToken: &refreshToken},
+			location:     "http://localhost/redirect",
+			cookies:      map[string]string{"access_token": validToken, "refresh_token": validToken},
+			emptyCookies: []string{"mfa_token"},
+		},
+		{
+			desc:         "callback with MFA enrolled user",
+			issueRes:     &grpcTokenV1.Token{AccessToken: "challenge", AccessType: users.MFAAccessType},
+			location:     "http://localhost/redirect?access_type=" + users.MFAAccessType,
+			cookies:      map[string]string{"mfa_token": "challenge"},
+			emptyCookies: []string{"access_token", "refresh_token"},
+		},
+		{
+			desc:         "callback with user required to enroll MFA",
+			issueRes:     &grpcTokenV1.Token{AccessToken: "challenge", AccessType: users.MFAEnrollAccessType},
+			location:     "http://localhost/redirect?access_type=" + users.MFAEnrollAccessType,
+			cookies:      map[string]string{"mfa_token": "challenge"},
+			emptyCookies: []string{"access_token", "refresh_token"},
+		},
+		{
+			desc:         "callback with locked out user",
+			issueRes:     &grpcTokenV1.Token{},
+			issueErr:     svcerr.ErrAuthentication,
+			location:     "http://localhost/error?error=" + svcerr.ErrAuthentication.Error(),
+			emptyCookies: []string{"access_token", "refresh_token", "mfa_token"},
+		},
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			infoCall := provider.On("UserInfo", validToken).Return(user, []users.DomainRole{}, nil)
+			svcCall := svc.On("OAuthCallback", mock.Anything, user, []users.DomainRole{}).Return(user, nil)
+			svcCall1 := svc.On("OAuthAddUserPolicy", mock.Anything, user).Return(nil)
+			svcCall2 := svc.On("OAuthIssueToken", mock.Anything, user, mock.Anything, mock.Anything).Return(tc.issueRes, tc.issueErr)
+			res, err := client.Get(us.URL + "/oauth/callback/test?state=state&code=code")
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			defer res.Body.Close()
+			assert.Equal(t, tc.location, res.Header.Get("Location"), fmt.Sprintf("%s: unexpected redirect location", tc.desc))
+
+			cookies := map[string]string{}
+			for _, c := range res.Cookies() {
+				cookies[c.Name] = c.Value
+				if _, ok := tc.cookies[c.Name]; ok {
+					assert.True(t, c.HttpOnly, fmt.Sprintf("%s: expected HTTP only cookie %s", tc.desc, c.Name))
+				}
+			}
+			for name, value := range tc.cookies {
+				assert.Equal(t, value, cookies[name], fmt.Sprintf("%s: unexpected cookie %s", tc.desc, name))
+			}
+			for _, name := range tc.emptyCookies {
+				assert.NotContains(t, cookies, name, fmt.Sprintf("%s: unexpected cookie %s", tc.desc, name))
+			}
+			infoCall.Unset()
+			svcCall.Unset()
+			svcCall1.Unset()
+			svcCall2.Unset()
+		})
+	}
+}
+
+func TestCreateServiceAccount(t *testing.T) {
+	us, svc, authn := newUsersServer()
+	defer us.Close()
+
+	sa := users.ServiceAccount{
+		ID:       testsutil.GenerateUUID(t),
+		DomainID: validID,
+		Name:     "ci",
+		Status:   users.EnabledStatus,
+	}
+
+	cases := []struct {
+		desc        string
+		data        string
+		token       string
+		contentType string
+		authnRes    smqauthn.Session
+		authnErr    error
+		svcErr      error
+		status      int
+		err         error
+	}{
+		{
+			desc:        "create service account successfully",
+			data:        `{"name": "ci"}`,
+			token:       validToken,
+			contentType: contentType,
+			authnRes:    smqauthn.Session{UserID: validID, DomainID: validID},
+			status:      http.StatusCreated,
+			err:         nil,
+		},
+		{
+			desc:        "create service account with invalid token",
+			data:        `{"name": "ci"}`,
+			token:       inValidToken,
+			contentType: contentType,
+			authnErr:    svcerr.ErrAuthentication,
+			status:      http.StatusUnauthorized,
+			err:         svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "create service account with service account of other domain",
+			data:        `{"name": "ci"}`,
+			token:       validToken,
+			contentType: contentType,
+			authnRes:    smqauthn.Session{UserID: validID, DomainID: domainID, ServiceAccount: true},
+			status:      http.StatusForbidden,
+			err:         svcerr.ErrDomainAuthorization,
+		},
+		{
+			desc:        "create service account with empty name",
+			data:        `{"name": ""}`,
+			token:       validToken,
+			contentType: contentType,
+			authnRes:    smqauthn.Session{UserID: validID, DomainID: validID},
+			status:      http.StatusBadRequest,
+			err:         apiutil.ErrMissingName,
+		},
+		{
+			desc:        "create service account with invalid public key",
+			data:        `{"name": "ci", "public_key": "invalid"}`,
+			token:       validToken,
+			contentType: contentType,
+			authnRes:    smqauthn.Session{UserID: validID, DomainID: validID},
+			svcErr:      svcerr.ErrMalformedEntity,
+			status:      http.StatusBadRequest,
+			err:         svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:        "create service account with malformed data",
+			data:        `{"name": ci}`,
+			token:       validToken,
+			contentType: contentType,
+			authnRes:    smqauthn.Session{UserID: validID, DomainID: validID},
+			status:      http.StatusBadRequest,
+			err:         apiutil.ErrValidation,
+		},
+		{
+			desc:        "create service account with invalid content type",
+			data:        `{"name": "ci"}`,
+			token:       validToken,
+			contentType: "application/xml",
+			authnRes:    smqauthn.Session{UserID: validID, DomainID: validID},
+			status:      http.StatusUnsupportedMediaType,
+			err:         apiutil.ErrUnsupportedContentType,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:        us.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/%s/service-accounts", us.URL, validID),
+				contentType: tc.contentType,
+				token:       tc.token,
+				body:        strings.NewReader(tc.data),
+			}
+
+			authnCall := authn.On("Authenticate", mock.Anything, tc.token).Return(tc.authnRes, tc.authnErr)
+			svcCall := svc.On("CreateServiceAccount", mock.Anything, mock.Anything, mock.Anything).Return(sa, secret, tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			var resBody struct {
+				ID           string `json:"id"`
+				ClientSecret string `json:"client_secret"`
+				Err          string `json:"error"`
+				Message      string `json:"message"`
+			}
+			err = json.NewDecoder(res.Body).Decode(&resBody)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error while decoding response body: %s", tc.desc, err))
+			if resBody.Err != "" || resBody.Message != "" {
+				err = errors.Wrap(errors.New(resBody.Err), errors.New(resBody.Message))
+			}
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected error %s got %s", tc.desc, tc.err, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			if tc.status == http.StatusCreated {
+				assert.Equal(t, sa.ID, resBody.ID)
+				assert.Equal(t, secret, resBody.ClientSecret, "the client secret must be returned on create")
+			}
+			svcCall.Unset()
+			authnCall.Unset()
+		})
+	}
+}
+
+func TestListServiceAccounts(t *testing.T) {
+	us, svc, authn := newUsersServer()
+	defer us.Close()
+
+	page := users.ServiceAccountsPage{
+		Page: users.Page{Total: 1, Limit: 10},
+		ServiceAccounts: []users.ServiceAccount{
+			{ID: testsutil.GenerateUUID(t), DomainID: validID, Name: "ci", Status: users.EnabledStatus},
+		},
+	}
+
+	cases := []struct {
+		desc     string
+		query    string
+		authnRes smqauthn.Session
+		svcErr   error
+		status   int
+		err      error
+	}{
+		{
+			desc:     "list service accounts successfully",
+			authnRes: smqauthn.Session{UserID: validID, DomainID: validID},
+			status:   http.StatusOK,
+			err:      nil,
+		},
+		{
+			desc:     "list service accounts with status",
+			query:    "status=disabled",
+			authnRes: smqauthn.Session{UserID: validID, DomainID: validID},
+			status:   http.StatusOK,
+			err:      nil,
+		},
+		{
+			desc:     "list service accounts with invalid status",
+			query:    "status=invalid",
+			authnRes: smqauthn.Session{UserID: validID, DomainID: validID},
+			status:   http.StatusBadRequest,
+			err:      apiutil.ErrValidation,
+		},
+		{
+			desc:     "list service accounts with invalid limit",
+			query:    "limit=1000",
+			authnRes: smqauthn.Session{UserID: validID, DomainID: validID},
+			status:   http.StatusBadRequest,
+			err:      apiutil.ErrValidation,
+		},
+		{
+			desc:     "list service accounts without domain admin",
+			authnRes: smqauthn.Session{UserID: validID, DomainID: validID},
+			svcErr:   svcerr.ErrAuthorization,
+			status:   http.StatusForbidden,
+			err:      svcerr.ErrAuthorization,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:   us.Client(),
+				method: http.MethodGet,
+				url:    fmt.Sprintf("%s/%s/service-accounts?", us.URL, validID) + tc.query,
+				token:  validToken,
+			}
+
+			authnCall := authn.On("Authenticate", mock.Anything, validToken).Return(tc.authnRes, nil)
+			svcCall := svc.On("ListServiceAccounts", mock.Anything, mock.Anything, mock.Anything).Return(page, tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			svcCall.Unset()
+			authnCall.Unset()
+		})
+	}
+}
+
+func TestIssueServiceAccountToken(t *testing.T) {
+	us, svc, _ := newUsersServer()
+	defer us.Close()
+
+	clientID := testsutil.GenerateUUID(t)
+
+	cases := []struct {
+		desc         string
+		data         string
+		contentType  string
+		clientID     string
+		clientSecret string
+		assertion    string
+		svcRes       *grpcTokenV1.Token
+		svcErr       error
+		status       int
+		err          error
+	}{
+		{
+			desc:         "issue token with client credentials",
+			data:         fmt.Sprintf(`{"grant_type": "client_credentials", "client_id": "%s", "client_secret": "%s"}`, clientID, secret),
+			contentType:  contentType,
+			clientID:     clientID,
+			clientSecret: secret,
+			svcRes:       &grpcTokenV1.Token{AccessToken: validToken, AccessType: "Bearer"},
+			status:       http.StatusCreated,
+			err:          nil,
+		},
+		{
+			desc:        "issue token with JWT assertion",
+			data:        fmt.Sprintf(`{"grant_type": "urn:ietf:params:oauth:grant-type:jwt-bearer", "assertion": "%s"}`, validToken),
+			contentType: contentType,
+			assertion:   validToken,
+			svcRes:      &grpcTokenV1.Token{AccessToken: validToken, AccessType: "Bearer"},
+			status:      http.StatusCreated,
+			err:         nil,
+		},
+		{
+			desc:         "issue token with invalid client credentials",
+			data:         fmt.Sprintf(`{"grant_type": "client_credentials", "client_id": "%s", "client_secret": "%s"}`, clientID, inValid),
+			contentType:  contentType,
+			clientID:     clientID,
+			clientSecret: inValid,
+			svcRes:       &grpcTokenV1.Token{},
+			svcErr:       svcerr.ErrAuthentication,
+			status:       http.StatusUnauthorized,
+			err:          svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "issue token with missing client secret",
+			data:        fmt.Sprintf(`{"grant_type": "client_credentials", "client_id": "%s"}`, clientID),
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+			err:         apiutil.ErrMissingSecret,
+		},
+		{
+			desc:        "issue token with missing assertion",
+			data:        `{"grant_type": "urn:ietf:params:oauth:grant-type:jwt-bearer"}`,
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+			err:         apiutil.ErrMissingAssertion,
+		},
+		{
+			desc:        "issue token with unsupported grant type",
+			data:        fmt.Sprintf(`{"grant_type": "password", "client_id": "%s", "client_secret": "%s"}`, clientID, secret),
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+			err:         apiutil.ErrUnsupportedGrantType,
+		},
+		{
+			desc:        "issue token with invalid content type",
+			data:        fmt.Sprintf(`{"grant_type": "client_credentials", "client_id": "%s", "client_secret": "%s"}`, clientID, secret),
+			contentType: "application/xml",
+			status:      http.StatusUnsupportedMediaType,
+			err:         apiutil.ErrUnsupportedContentType,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := testRequest{
+				user:        us.Client(),
+				method:      http.MethodPost,
+				url:         fmt.Sprintf("%s/service-accounts/token", us.URL),
+				contentType: tc.contentType,
+				body:        strings.NewReader(tc.data),
+			}
+
+			svcCall := svc.On("IssueServiceAccountToken", mock.Anything, tc.clientID, tc.clientSecret, tc.assertion, mock.Anything).Return(tc.svcRes, tc.svcErr)
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			var resBody struct {
+				AccessToken string `json:"access_token"`
+				Err         string `json:"error"`
+				Message     string `json:"message"`
+			}
+			err = json.NewDecoder(res.Body).Decode(&resBody)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error while decoding response body: %s", tc.desc, err))
+			if resBody.Err != "" || resBody.Message != "" {
+				err = errors.Wrap(errors.New(resBody.Err), errors.New(resBody.Message))
+			}
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected error %s got %s", tc.desc, tc.err, err))
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+			if tc.status == http.StatusCreated {
+				assert.Equal(t, validToken, resBody.AccessToken)
+			}
+			svcCall.Unset()
+		})
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/hantdev/mitras"
 	"github.com/hantdev/mitras/internal/api"
+	grpcTokenV1 "github.com/hantdev/mitras/internal/grpc/token/v1"
 	"github.com/hantdev/mitras/pkg/apiutil"
 	"github.com/hantdev/mitras/pkg/authn"
 	"github.com/hantdev/mitras/pkg/errors"
@@ -28,7 +30,7 @@ func registrationEndpoint(svc users.Service, selfRegister bool) endpoint.Endpoin
 			}
 		}
 
-		user, err := svc.Register(ctx, session, req.User, selfRegister)
+		user, err := svc.Register(ctx, session, req.User, selfRegister, req.Invitation)
 		if err != nil {
 			return nil, err
 		}
@@ -342,7 +344,7 @@ func passwordResetRequestEndpoint(svc users.Service) endpoint.Endpoint {
 			return nil, errors.Wrap(apiutil.ErrValidation, err)
 		}
 
-		if err := svc.GenerateResetToken(ctx, req.Email, req.Host); err != nil {
+		if err := svc.GenerateResetToken(ctx, req.Email, req.Host, req.ip); err != nil {
 			return nil, err
 		}
 
@@ -472,7 +474,65 @@ func issueTokenEndpoint(svc users.Service) endpoint.Endpoint {
 			return nil, errors.Wrap(apiutil.ErrValidation, err)
 		}
 
-		token, err := svc.IssueToken(ctx, req.Identity, req.Secret)
+		token, err := svc.IssueToken(ctx, req.Identity, req.Secret, req.device, req.ip)
+		if err != nil {
+			return nil, err
+		}
+
+		return loginRes(token), nil
+	}
+}
+
+// loginRes returns the response of the login steps, which is either the
+// access token or the challenge of the next step.
+func loginRes(token *grpcTokenV1.Token) mitras.Response {
+	switch token.GetAccessType() {
+	case users.MFAAccessType, users.MFAEnrollAccessType:
+		return mfaChallengeRes{
+			MFAToken:           token.GetAccessToken(),
+			EnrollmentRequired: token.GetAccessType() == users.MFAEnrollAccessType,
+		}
+	case users.PasswordChangeAccessType:
+		return passwordChangeChallengeRes{PasswordChangeToken: token.GetAccessToken(), PasswordExpired: true}
+	}
+
+	return tokenRes{
+		AccessToken:  token.GetAccessToken(),
+		RefreshToken: token.GetRefreshToken(),
+		AccessType:   token.GetAccessType(),
+	}
+}
+
+func changeExpiredSecretEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(changeExpiredSecretReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		token, err := svc.ChangeExpiredSecret(ctx, req.PasswordChangeToken, req.NewSecret, req.device, req.ip)
+		if err != nil {
+			return nil, err
+		}
+
+		return loginRes(token), nil
+	}
+}
+
+func passwordPolicyEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return newPasswordPolicyRes(svc.PasswordPolicy(ctx)), nil
+	}
+}
+
+func issueMFATokenEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(mfaTokenReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		token, err := svc.IssueMFAToken(ctx, req.MFAToken, req.Code)
 		if err != nil {
 			return nil, err
 		}
@@ -485,6 +545,173 @@ func issueTokenEndpoint(svc users.Service) endpoint.Endpoint {
 	}
 }
 
+func enrollMFAWithChallengeEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(mfaEnrollReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		enrollment, err := svc.EnrollMFAWithChallenge(ctx, req.MFAToken)
+		if err != nil {
+			return nil, err
+		}
+
+		return mfaEnrollmentRes{MFAEnrollment: enrollment}, nil
+	}
+}
+
+func enrollMFAEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		enrollment, err := svc.EnrollMFA(ctx, session)
+		if err != nil {
+			return nil, err
+		}
+
+		return mfaEnrollmentRes{MFAEnrollment: enrollment}, nil
+	}
+}
+
+func verifyMFAEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(verifyMFAReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		if err := svc.VerifyMFA(ctx, session, req.Code); err != nil {
+			return nil, err
+		}
+
+		return verifyMFARes{}, nil
+	}
+}
+
+func resetMFAEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(changeUserStatusReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		if err := svc.ResetMFA(ctx, session, req.id); err != nil {
+			return nil, err
+		}
+
+		return resetMFARes{}, nil
+	}
+}
+
+func unlockEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(changeUserStatusReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		if err := svc.Unlock(ctx, session, req.id); err != nil {
+			return nil, err
+		}
+
+		return unlockUserRes{}, nil
+	}
+}
+
+func impersonateEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(impersonateReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		token, err := svc.Impersonate(ctx, session, req.id, req.ReadOnly)
+		if err != nil {
+			return nil, err
+		}
+
+		return accessTokenRes{
+			AccessToken: token.GetAccessToken(),
+			AccessType:  token.GetAccessType(),
+		}, nil
+	}
+}
+
+func verifyEmailEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(verifyEmailReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		if err := svc.VerifyEmail(ctx, req.Token); err != nil {
+			return nil, err
+		}
+
+		return verifyEmailRes{}, nil
+	}
+}
+
+func sendVerificationEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(sendVerificationReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		// The response is the same whether the email is sent or not, so it
+		// doesn't reveal which emails are registered and unverified. The
+		// error is logged by the logging middleware.
+		_ = svc.SendVerification(ctx, req.Email, req.ip)
+
+		return sendVerificationRes{Msg: VerificationSent}, nil
+	}
+}
+
+func inviteUserEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(emailReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		if err := svc.InviteUser(ctx, session, req.Email); err != nil {
+			return nil, err
+		}
+
+		return inviteUserRes{Msg: InvitationSent}, nil
+	}
+}
+
 func refreshTokenEndpoint(svc users.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(tokenReq)
@@ -497,7 +724,7 @@ func refreshTokenEndpoint(svc users.Service) endpoint.Endpoint {
 			return nil, svcerr.ErrAuthentication
 		}
 
-		token, err := svc.RefreshToken(ctx, session, req.RefreshToken)
+		token, err := svc.RefreshToken(ctx, session, req.RefreshToken, req.ip)
 		if err != nil {
 			return nil, err
 		}
@@ -588,3 +815,220 @@ func buildUsersResponse(cp users.MembersPage) usersPageRes {
 
 	return res
 }
+
+func createServiceAccountEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createServiceAccountReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		sa := users.ServiceAccount{
+			Name:        req.Name,
+			Description: req.Description,
+			Metadata:    req.Metadata,
+			PublicKey:   req.PublicKey,
+			Status:      users.EnabledStatus,
+		}
+		sa, secret, err := svc.CreateServiceAccount(ctx, session, sa)
+		if err != nil {
+			return nil, err
+		}
+
+		return createServiceAccountRes{ServiceAccount: sa, ClientSecret: secret}, nil
+	}
+}
+
+func viewServiceAccountEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewServiceAccountReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		sa, err := svc.ViewServiceAccount(ctx, session, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return serviceAccountRes{ServiceAccount: sa}, nil
+	}
+}
+
+func listServiceAccountsEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listServiceAccountsReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		pm := users.Page{
+			Status: req.status,
+			Offset: req.offset,
+			Limit:  req.limit,
+		}
+		page, err := svc.ListServiceAccounts(ctx, session, pm)
+		if err != nil {
+			return nil, err
+		}
+
+		return serviceAccountsPageRes{
+			pageRes: pageRes{
+				Total:  page.Total,
+				Offset: page.Offset,
+				Limit:  page.Limit,
+			},
+			ServiceAccounts: page.ServiceAccounts,
+		}, nil
+	}
+}
+
+func updateServiceAccountEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(updateServiceAccountReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		sa := users.ServiceAccount{
+			ID:          req.id,
+			Name:        req.Name,
+			Description: req.Description,
+			Metadata:    req.Metadata,
+			PublicKey:   req.PublicKey,
+		}
+		sa, err := svc.UpdateServiceAccount(ctx, session, sa)
+		if err != nil {
+			return nil, err
+		}
+
+		return serviceAccountRes{ServiceAccount: sa}, nil
+	}
+}
+
+func rotateServiceAccountSecretEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewServiceAccountReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		secret, err := svc.RotateServiceAccountSecret(ctx, session, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return serviceAccountSecretRes{ClientID: req.id, ClientSecret: secret}, nil
+	}
+}
+
+func enableServiceAccountEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewServiceAccountReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		sa, err := svc.EnableServiceAccount(ctx, session, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return serviceAccountRes{ServiceAccount: sa}, nil
+	}
+}
+
+func disableServiceAccountEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewServiceAccountReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		sa, err := svc.DisableServiceAccount(ctx, session, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return serviceAccountRes{ServiceAccount: sa}, nil
+	}
+}
+
+func deleteServiceAccountEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewServiceAccountReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		session, ok := ctx.Value(api.SessionKey).(authn.Session)
+		if !ok {
+			return nil, svcerr.ErrAuthentication
+		}
+
+		if err := svc.DeleteServiceAccount(ctx, session, req.id); err != nil {
+			return nil, err
+		}
+
+		return deleteUserRes{deleted: true}, nil
+	}
+}
+
+func issueServiceAccountTokenEndpoint(svc users.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(serviceAccountTokenReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		// The assertion is used only by the JWT bearer grant.
+		var assertion string
+		if req.GrantType == jwtBearerGrant {
+			assertion = req.Assertion
+		}
+		token, err := svc.IssueServiceAccountToken(ctx, req.ClientID, req.ClientSecret, assertion, req.ip)
+		if err != nil {
+			return nil, err
+		}
+
+		return accessTokenRes{
+			AccessToken: token.GetAccessToken(),
+			AccessType:  token.GetAccessType(),
+		}, nil
+	}
+}
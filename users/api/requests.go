@@ -14,6 +14,7 @@ const maxLimitSize = 100
 
 type createUserReq struct {
 	users.User
+	Invitation string `json:"invitation,omitempty"`
 }
 
 func (req createUserReq) validate() error {
@@ -255,9 +256,24 @@ func (req changeUserStatusReq) validate() error {
 	return nil
 }
 
+type impersonateReq struct {
+	id       string
+	ReadOnly bool `json:"read_only,omitempty"`
+}
+
+func (req impersonateReq) validate() error {
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	return nil
+}
+
 type loginUserReq struct {
 	Identity string `json:"identity,omitempty"`
 	Secret   string `json:"secret,omitempty"`
+	device   string
+	ip       string
 }
 
 func (req loginUserReq) validate() error {
@@ -273,6 +289,7 @@ func (req loginUserReq) validate() error {
 
 type tokenReq struct {
 	RefreshToken string `json:"refresh_token,omitempty"`
+	ip           string
 }
 
 func (req tokenReq) validate() error {
@@ -283,9 +300,114 @@ func (req tokenReq) validate() error {
 	return nil
 }
 
+type mfaTokenReq struct {
+	MFAToken string `json:"mfa_token,omitempty"`
+	Code     string `json:"code,omitempty"`
+}
+
+func (req mfaTokenReq) validate() error {
+	if req.MFAToken == "" {
+		return apiutil.ErrMissingMFAToken
+	}
+	if req.Code == "" {
+		return apiutil.ErrMissingMFACode
+	}
+
+	return nil
+}
+
+type changeExpiredSecretReq struct {
+	PasswordChangeToken string `json:"password_change_token,omitempty"`
+	NewSecret           string `json:"new_secret,omitempty"`
+	device              string
+	ip                  string
+}
+
+func (req changeExpiredSecretReq) validate() error {
+	if req.PasswordChangeToken == "" {
+		return apiutil.ErrMissingPasswordChangeToken
+	}
+	if req.NewSecret == "" {
+		return apiutil.ErrMissingPass
+	}
+	if !passRegex.MatchString(req.NewSecret) {
+		return apiutil.ErrPasswordFormat
+	}
+
+	return nil
+}
+
+type mfaEnrollReq struct {
+	MFAToken string `json:"mfa_token,omitempty"`
+}
+
+func (req mfaEnrollReq) validate() error {
+	if req.MFAToken == "" {
+		return apiutil.ErrMissingMFAToken
+	}
+
+	return nil
+}
+
+type verifyMFAReq struct {
+	Code string `json:"code,omitempty"`
+}
+
+func (req verifyMFAReq) validate() error {
+	if req.Code == "" {
+		return apiutil.ErrMissingMFACode
+	}
+
+	return nil
+}
+
+type verifyEmailReq struct {
+	Token string `json:"token,omitempty"`
+}
+
+func (req verifyEmailReq) validate() error {
+	if req.Token == "" {
+		return apiutil.ErrMissingVerificationToken
+	}
+
+	return nil
+}
+
+type emailReq struct {
+	Email string `json:"email,omitempty"`
+}
+
+func (req emailReq) validate() error {
+	if req.Email == "" {
+		return apiutil.ErrMissingEmail
+	}
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return apiutil.ErrInvalidEmail
+	}
+
+	return nil
+}
+
+type sendVerificationReq struct {
+	Email string `json:"email,omitempty"`
+	ip    string
+}
+
+func (req sendVerificationReq) validate() error {
+	if req.Email == "" {
+		return apiutil.ErrMissingEmail
+	}
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return apiutil.ErrInvalidEmail
+	}
+
+	return nil
+}
+
 type passwResetReq struct {
 	Email string `json:"email"`
 	Host  string `json:"host"`
+	ip    string
 }
 
 func (req passwResetReq) validate() error {
@@ -408,3 +530,97 @@ func (req unassignGroupsReq) validate() error {
 
 	return nil
 }
+
+type createServiceAccountReq struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Metadata    users.Metadata `json:"metadata,omitempty"`
+	PublicKey   string         `json:"public_key,omitempty"`
+}
+
+func (req createServiceAccountReq) validate() error {
+	if req.Name == "" {
+		return apiutil.ErrMissingName
+	}
+	if len(req.Name) > api.MaxNameSize {
+		return apiutil.ErrNameSize
+	}
+
+	return nil
+}
+
+type viewServiceAccountReq struct {
+	id string
+}
+
+func (req viewServiceAccountReq) validate() error {
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	return nil
+}
+
+type listServiceAccountsReq struct {
+	status users.Status
+	offset uint64
+	limit  uint64
+}
+
+func (req listServiceAccountsReq) validate() error {
+	if req.limit > maxLimitSize || req.limit < 1 {
+		return apiutil.ErrLimitSize
+	}
+
+	return nil
+}
+
+type updateServiceAccountReq struct {
+	id          string
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Metadata    users.Metadata `json:"metadata,omitempty"`
+	PublicKey   string         `json:"public_key,omitempty"`
+}
+
+func (req updateServiceAccountReq) validate() error {
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+	if req.Name == "" {
+		return apiutil.ErrMissingName
+	}
+	if len(req.Name) > api.MaxNameSize {
+		return apiutil.ErrNameSize
+	}
+
+	return nil
+}
+
+type serviceAccountTokenReq struct {
+	GrantType    string `json:"grant_type"`
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	Assertion    string `json:"assertion,omitempty"`
+	ip           string
+}
+
+func (req serviceAccountTokenReq) validate() error {
+	switch req.GrantType {
+	case clientCredentialsGrant:
+		if req.ClientID == "" {
+			return apiutil.ErrMissingID
+		}
+		if req.ClientSecret == "" {
+			return apiutil.ErrMissingSecret
+		}
+	case jwtBearerGrant:
+		if req.Assertion == "" {
+			return apiutil.ErrMissingAssertion
+		}
+	default:
+		return apiutil.ErrUnsupportedGrantType
+	}
+
+	return nil
+}
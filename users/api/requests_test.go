@@ -536,6 +536,138 @@ func TestLoginUserReqValidate(t *testing.T) {
 	}
 }
 
+func TestMFATokenReqValidate(t *testing.T) {
+	cases := []struct {
+		desc string
+		req  mfaTokenReq
+		err  error
+	}{
+		{
+			desc: "valid request",
+			req: mfaTokenReq{
+				MFAToken: valid,
+				Code:     "123456",
+			},
+			err: nil,
+		},
+		{
+			desc: "empty MFA token",
+			req: mfaTokenReq{
+				Code: "123456",
+			},
+			err: apiutil.ErrMissingMFAToken,
+		},
+		{
+			desc: "empty code",
+			req: mfaTokenReq{
+				MFAToken: valid,
+			},
+			err: apiutil.ErrMissingMFACode,
+		},
+	}
+	for _, c := range cases {
+		err := c.req.validate()
+		assert.Equal(t, c.err, err, "%s: expected %s got %s\n", c.desc, c.err, err)
+	}
+}
+
+func TestMFAEnrollReqValidate(t *testing.T) {
+	cases := []struct {
+		desc string
+		req  mfaEnrollReq
+		err  error
+	}{
+		{
+			desc: "valid request",
+			req:  mfaEnrollReq{MFAToken: valid},
+			err:  nil,
+		},
+		{
+			desc: "empty MFA token",
+			req:  mfaEnrollReq{},
+			err:  apiutil.ErrMissingMFAToken,
+		},
+	}
+	for _, c := range cases {
+		err := c.req.validate()
+		assert.Equal(t, c.err, err, "%s: expected %s got %s\n", c.desc, c.err, err)
+	}
+}
+
+func TestVerifyMFAReqValidate(t *testing.T) {
+	cases := []struct {
+		desc string
+		req  verifyMFAReq
+		err  error
+	}{
+		{
+			desc: "valid request",
+			req:  verifyMFAReq{Code: "123456"},
+			err:  nil,
+		},
+		{
+			desc: "empty code",
+			req:  verifyMFAReq{},
+			err:  apiutil.ErrMissingMFACode,
+		},
+	}
+	for _, c := range cases {
+		err := c.req.validate()
+		assert.Equal(t, c.err, err, "%s: expected %s got %s\n", c.desc, c.err, err)
+	}
+}
+
+func TestVerifyEmailReqValidate(t *testing.T) {
+	cases := []struct {
+		desc string
+		req  verifyEmailReq
+		err  error
+	}{
+		{
+			desc: "valid request",
+			req:  verifyEmailReq{Token: valid},
+			err:  nil,
+		},
+		{
+			desc: "empty token",
+			req:  verifyEmailReq{},
+			err:  apiutil.ErrMissingVerificationToken,
+		},
+	}
+	for _, c := range cases {
+		err := c.req.validate()
+		assert.Equal(t, c.err, err, "%s: expected %s got %s\n", c.desc, c.err, err)
+	}
+}
+
+func TestEmailReqValidate(t *testing.T) {
+	cases := []struct {
+		desc string
+		req  emailReq
+		err  error
+	}{
+		{
+			desc: "valid request",
+			req:  emailReq{Email: "user@example.com"},
+			err:  nil,
+		},
+		{
+			desc: "empty email",
+			req:  emailReq{},
+			err:  apiutil.ErrMissingEmail,
+		},
+		{
+			desc: "invalid email",
+			req:  emailReq{Email: "invalid"},
+			err:  apiutil.ErrInvalidEmail,
+		},
+	}
+	for _, c := range cases {
+		err := c.req.validate()
+		assert.Equal(t, c.err, err, "%s: expected %s got %s\n", c.desc, c.err, err)
+	}
+}
+
 func TestTokenReqValidate(t *testing.T) {
 	cases := []struct {
 		desc string
@@ -3,6 +3,7 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/hantdev/mitras"
 	"github.com/hantdev/mitras/users"
@@ -11,6 +12,12 @@ import (
 // MailSent message response when link is sent.
 const MailSent = "Email with reset link is sent"
 
+// VerificationSent message response when verification link is sent.
+const VerificationSent = "Email with verification link is sent"
+
+// InvitationSent message response when registration invitation is sent.
+const InvitationSent = "Email with registration invitation is sent"
+
 var (
 	_ mitras.Response = (*tokenRes)(nil)
 	_ mitras.Response = (*viewUserRes)(nil)
@@ -25,6 +32,21 @@ var (
 	_ mitras.Response = (*updateUserRes)(nil)
 	_ mitras.Response = (*tokenRes)(nil)
 	_ mitras.Response = (*deleteUserRes)(nil)
+	_ mitras.Response = (*mfaChallengeRes)(nil)
+	_ mitras.Response = (*passwordChangeChallengeRes)(nil)
+	_ mitras.Response = (*passwordPolicyRes)(nil)
+	_ mitras.Response = (*mfaEnrollmentRes)(nil)
+	_ mitras.Response = (*verifyMFARes)(nil)
+	_ mitras.Response = (*resetMFARes)(nil)
+	_ mitras.Response = (*verifyEmailRes)(nil)
+	_ mitras.Response = (*sendVerificationRes)(nil)
+	_ mitras.Response = (*inviteUserRes)(nil)
+	_ mitras.Response = (*unlockUserRes)(nil)
+	_ mitras.Response = (*createServiceAccountRes)(nil)
+	_ mitras.Response = (*serviceAccountRes)(nil)
+	_ mitras.Response = (*serviceAccountsPageRes)(nil)
+	_ mitras.Response = (*serviceAccountSecretRes)(nil)
+	_ mitras.Response = (*accessTokenRes)(nil)
 )
 
 type pageRes struct {
@@ -60,6 +82,183 @@ func (res createUserRes) Empty() bool {
 	return false
 }
 
+type mfaChallengeRes struct {
+	MFAToken           string `json:"mfa_token"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+}
+
+func (res mfaChallengeRes) Code() int {
+	return http.StatusOK
+}
+
+func (res mfaChallengeRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res mfaChallengeRes) Empty() bool {
+	return false
+}
+
+type passwordChangeChallengeRes struct {
+	PasswordChangeToken string `json:"password_change_token"`
+	PasswordExpired     bool   `json:"password_expired"`
+}
+
+func (res passwordChangeChallengeRes) Code() int {
+	return http.StatusOK
+}
+
+func (res passwordChangeChallengeRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res passwordChangeChallengeRes) Empty() bool {
+	return false
+}
+
+type passwordPolicyRes struct {
+	MinLength     int    `json:"min_length"`
+	RequireUpper  bool   `json:"require_uppercase"`
+	RequireLower  bool   `json:"require_lowercase"`
+	RequireDigit  bool   `json:"require_digit"`
+	RequireSymbol bool   `json:"require_symbol"`
+	Denylist      bool   `json:"denylist"`
+	History       uint64 `json:"history"`
+	MaxAgeDays    uint64 `json:"max_age_days"`
+}
+
+// newPasswordPolicyRes rounds the max age up to the whole days, so the
+// passwords expiring within a day are not shown as never expiring.
+func newPasswordPolicyRes(pp users.PasswordPolicy) passwordPolicyRes {
+	const day = 24 * time.Hour
+	return passwordPolicyRes{
+		MinLength:     pp.MinLength,
+		RequireUpper:  pp.RequireUpper,
+		RequireLower:  pp.RequireLower,
+		RequireDigit:  pp.RequireDigit,
+		RequireSymbol: pp.RequireSymbol,
+		Denylist:      len(pp.Denylist) > 0,
+		History:       pp.History,
+		MaxAgeDays:    uint64((pp.MaxAge + day - 1) / day),
+	}
+}
+
+func (res passwordPolicyRes) Code() int {
+	return http.StatusOK
+}
+
+func (res passwordPolicyRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res passwordPolicyRes) Empty() bool {
+	return false
+}
+
+type mfaEnrollmentRes struct {
+	users.MFAEnrollment
+}
+
+func (res mfaEnrollmentRes) Code() int {
+	return http.StatusCreated
+}
+
+func (res mfaEnrollmentRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res mfaEnrollmentRes) Empty() bool {
+	return false
+}
+
+type verifyMFARes struct{}
+
+func (res verifyMFARes) Code() int {
+	return http.StatusNoContent
+}
+
+func (res verifyMFARes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res verifyMFARes) Empty() bool {
+	return true
+}
+
+type resetMFARes struct{}
+
+func (res resetMFARes) Code() int {
+	return http.StatusNoContent
+}
+
+func (res resetMFARes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res resetMFARes) Empty() bool {
+	return true
+}
+
+type unlockUserRes struct{}
+
+func (res unlockUserRes) Code() int {
+	return http.StatusNoContent
+}
+
+func (res unlockUserRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res unlockUserRes) Empty() bool {
+	return true
+}
+
+type verifyEmailRes struct{}
+
+func (res verifyEmailRes) Code() int {
+	return http.StatusNoContent
+}
+
+func (res verifyEmailRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res verifyEmailRes) Empty() bool {
+	return true
+}
+
+type sendVerificationRes struct {
+	Msg string `json:"msg"`
+}
+
+func (res sendVerificationRes) Code() int {
+	return http.StatusCreated
+}
+
+func (res sendVerificationRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res sendVerificationRes) Empty() bool {
+	return false
+}
+
+type inviteUserRes struct {
+	Msg string `json:"msg"`
+}
+
+func (res inviteUserRes) Code() int {
+	return http.StatusCreated
+}
+
+func (res inviteUserRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res inviteUserRes) Empty() bool {
+	return false
+}
+
 type tokenRes struct {
 	AccessToken  string `json:"access_token,omitempty"`
 	RefreshToken string `json:"refresh_token,omitempty"`
@@ -236,3 +435,91 @@ func (res deleteUserRes) Headers() map[string]string {
 func (res deleteUserRes) Empty() bool {
 	return true
 }
+
+type createServiceAccountRes struct {
+	users.ServiceAccount
+	// ClientSecret is returned only once, when the service account is
+	// created.
+	ClientSecret string `json:"client_secret"`
+}
+
+func (res createServiceAccountRes) Code() int {
+	return http.StatusCreated
+}
+
+func (res createServiceAccountRes) Headers() map[string]string {
+	return map[string]string{
+		"Location": fmt.Sprintf("/%s/service-accounts/%s", res.DomainID, res.ID),
+	}
+}
+
+func (res createServiceAccountRes) Empty() bool {
+	return false
+}
+
+type serviceAccountRes struct {
+	users.ServiceAccount
+}
+
+func (res serviceAccountRes) Code() int {
+	return http.StatusOK
+}
+
+func (res serviceAccountRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res serviceAccountRes) Empty() bool {
+	return false
+}
+
+type serviceAccountsPageRes struct {
+	pageRes
+	ServiceAccounts []users.ServiceAccount `json:"service_accounts"`
+}
+
+func (res serviceAccountsPageRes) Code() int {
+	return http.StatusOK
+}
+
+func (res serviceAccountsPageRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res serviceAccountsPageRes) Empty() bool {
+	return false
+}
+
+type serviceAccountSecretRes struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+func (res serviceAccountSecretRes) Code() int {
+	return http.StatusOK
+}
+
+func (res serviceAccountSecretRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res serviceAccountSecretRes) Empty() bool {
+	return false
+}
+
+type accessTokenRes struct {
+	AccessToken string `json:"access_token,omitempty"`
+	AccessType  string `json:"access_type,omitempty"`
+}
+
+func (res accessTokenRes) Code() int {
+	return http.StatusCreated
+}
+
+func (res accessTokenRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res accessTokenRes) Empty() bool {
+	return res.AccessToken == ""
+}
@@ -3,11 +3,11 @@ package api
 import (
 	"log/slog"
 	"net/http"
+	"net/netip"
 	"regexp"
 
 	"github.com/hantdev/mitras"
 	"github.com/go-chi/chi/v5"
-	grpcTokenV1 "github.com/hantdev/mitras/internal/grpc/token/v1"
 	smqauthn "github.com/hantdev/mitras/pkg/authn"
 	"github.com/hantdev/mitras/pkg/oauth2"
 	"github.com/hantdev/mitras/users"
@@ -15,8 +15,10 @@ import (
 )
 
 // MakeHandler returns a HTTP handler for Users and Groups API endpoints.
-func MakeHandler(cls users.Service, authn smqauthn.Authentication, tokensvc grpcTokenV1.TokenServiceClient, selfRegister bool, mux *chi.Mux, logger *slog.Logger, instanceID string, pr *regexp.Regexp, providers ...oauth2.Provider) http.Handler {
-	mux = usersHandler(cls, authn, tokensvc, selfRegister, mux, logger, pr, providers...)
+// The client IP is read from the X-Forwarded-For header only if the request
+// comes from one of the trusted proxies.
+func MakeHandler(cls users.Service, authn smqauthn.Authentication, selfRegister bool, mux *chi.Mux, logger *slog.Logger, instanceID string, pr *regexp.Regexp, proxies []netip.Prefix, providers ...oauth2.Provider) http.Handler {
+	mux = usersHandler(cls, authn, selfRegister, mux, logger, pr, proxies, providers...)
 
 	mux.Get("/health", mitras.Health("users", instanceID))
 	mux.Handle("/metrics", promhttp.Handler())
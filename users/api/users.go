@@ -5,14 +5,14 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"net/netip"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	kithttp "github.com/go-kit/kit/transport/http"
-	smqauth "github.com/hantdev/mitras/auth"
 	"github.com/hantdev/mitras/internal/api"
-	grpcTokenV1 "github.com/hantdev/mitras/internal/grpc/token/v1"
 	"github.com/hantdev/mitras/pkg/apiutil"
 	smqauthn "github.com/hantdev/mitras/pkg/authn"
 	"github.com/hantdev/mitras/pkg/errors"
@@ -20,13 +20,30 @@ import (
 	"github.com/hantdev/mitras/pkg/policies"
 	"github.com/hantdev/mitras/users"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	xoauth2 "golang.org/x/oauth2"
 )
 
-var passRegex = regexp.MustCompile("^.{8,}$")
+const (
+	oauthVerifierCookie   = "oauth_verifier_"
+	oauthVerifierDuration = 10 * time.Minute
+	// mfaTokenCookie holds the MFA challenge of the OAuth login.
+	mfaTokenCookie = "mfa_token"
+
+	// OAuth 2.0 grant types (RFC 6749 and RFC 7523) of the service account
+	// token request.
+	clientCredentialsGrant = "client_credentials"
+	jwtBearerGrant         = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+)
+
+var (
+	passRegex      = regexp.MustCompile("^.{8,}$")
+	trustedProxies []netip.Prefix
+)
 
 // usersHandler returns a HTTP handler for API endpoints.
-func usersHandler(svc users.Service, authn smqauthn.Authentication, tokenClient grpcTokenV1.TokenServiceClient, selfRegister bool, r *chi.Mux, logger *slog.Logger, pr *regexp.Regexp, providers ...oauth2.Provider) *chi.Mux {
+func usersHandler(svc users.Service, authn smqauthn.Authentication, selfRegister bool, r *chi.Mux, logger *slog.Logger, pr *regexp.Regexp, proxies []netip.Prefix, providers ...oauth2.Provider) *chi.Mux {
 	passRegex = pr
+	trustedProxies = proxies
 
 	opts := []kithttp.ServerOption{
 		kithttp.ServerErrorEncoder(apiutil.LoggingErrorEncoder(logger, api.EncodeError)),
@@ -151,6 +168,48 @@ func usersHandler(svc users.Service, authn smqauthn.Authentication, tokenClient
 				opts...,
 			), "delete_user").ServeHTTP)
 
+			r.Post("/mfa/enroll", otelhttp.NewHandler(kithttp.NewServer(
+				enrollMFAEndpoint(svc),
+				decodeViewProfile,
+				api.EncodeResponse,
+				opts...,
+			), "enroll_mfa").ServeHTTP)
+
+			r.Post("/mfa/verify", otelhttp.NewHandler(kithttp.NewServer(
+				verifyMFAEndpoint(svc),
+				decodeVerifyMFA,
+				api.EncodeResponse,
+				opts...,
+			), "verify_mfa").ServeHTTP)
+
+			r.Delete("/{id}/mfa", otelhttp.NewHandler(kithttp.NewServer(
+				resetMFAEndpoint(svc),
+				decodeChangeUserStatus,
+				api.EncodeResponse,
+				opts...,
+			), "reset_mfa").ServeHTTP)
+
+			r.Post("/{id}/unlock", otelhttp.NewHandler(kithttp.NewServer(
+				unlockEndpoint(svc),
+				decodeChangeUserStatus,
+				api.EncodeResponse,
+				opts...,
+			), "unlock_user").ServeHTTP)
+
+			r.Post("/{id}/impersonate", otelhttp.NewHandler(kithttp.NewServer(
+				impersonateEndpoint(svc),
+				decodeImpersonate,
+				api.EncodeResponse,
+				opts...,
+			), "impersonate_user").ServeHTTP)
+
+			r.Post("/invite", otelhttp.NewHandler(kithttp.NewServer(
+				inviteUserEndpoint(svc),
+				decodeEmail,
+				api.EncodeResponse,
+				opts...,
+			), "invite_user").ServeHTTP)
+
 			r.Post("/tokens/refresh", otelhttp.NewHandler(kithttp.NewServer(
 				refreshTokenEndpoint(svc),
 				decodeRefreshToken,
@@ -210,6 +269,64 @@ func usersHandler(svc users.Service, authn smqauthn.Authentication, tokenClient
 			api.EncodeResponse,
 			opts...,
 		), "list_users_by_domain_id").ServeHTTP)
+
+		r.Route("/{domainID}/service-accounts", func(r chi.Router) {
+			r.Post("/", otelhttp.NewHandler(kithttp.NewServer(
+				createServiceAccountEndpoint(svc),
+				decodeCreateServiceAccount,
+				api.EncodeResponse,
+				opts...,
+			), "create_service_account").ServeHTTP)
+
+			r.Get("/", otelhttp.NewHandler(kithttp.NewServer(
+				listServiceAccountsEndpoint(svc),
+				decodeListServiceAccounts,
+				api.EncodeResponse,
+				opts...,
+			), "list_service_accounts").ServeHTTP)
+
+			r.Get("/{id}", otelhttp.NewHandler(kithttp.NewServer(
+				viewServiceAccountEndpoint(svc),
+				decodeViewServiceAccount,
+				api.EncodeResponse,
+				opts...,
+			), "view_service_account").ServeHTTP)
+
+			r.Patch("/{id}", otelhttp.NewHandler(kithttp.NewServer(
+				updateServiceAccountEndpoint(svc),
+				decodeUpdateServiceAccount,
+				api.EncodeResponse,
+				opts...,
+			), "update_service_account").ServeHTTP)
+
+			r.Post("/{id}/secret", otelhttp.NewHandler(kithttp.NewServer(
+				rotateServiceAccountSecretEndpoint(svc),
+				decodeViewServiceAccount,
+				api.EncodeResponse,
+				opts...,
+			), "rotate_service_account_secret").ServeHTTP)
+
+			r.Post("/{id}/enable", otelhttp.NewHandler(kithttp.NewServer(
+				enableServiceAccountEndpoint(svc),
+				decodeViewServiceAccount,
+				api.EncodeResponse,
+				opts...,
+			), "enable_service_account").ServeHTTP)
+
+			r.Post("/{id}/disable", otelhttp.NewHandler(kithttp.NewServer(
+				disableServiceAccountEndpoint(svc),
+				decodeViewServiceAccount,
+				api.EncodeResponse,
+				opts...,
+			), "disable_service_account").ServeHTTP)
+
+			r.Delete("/{id}", otelhttp.NewHandler(kithttp.NewServer(
+				deleteServiceAccountEndpoint(svc),
+				decodeViewServiceAccount,
+				api.EncodeResponse,
+				opts...,
+			), "delete_service_account").ServeHTTP)
+		})
 	})
 
 	r.Post("/users/tokens/issue", otelhttp.NewHandler(kithttp.NewServer(
@@ -219,6 +336,48 @@ func usersHandler(svc users.Service, authn smqauthn.Authentication, tokenClient
 		opts...,
 	), "issue_token").ServeHTTP)
 
+	r.Post("/service-accounts/token", otelhttp.NewHandler(kithttp.NewServer(
+		issueServiceAccountTokenEndpoint(svc),
+		decodeServiceAccountToken,
+		api.EncodeResponse,
+		opts...,
+	), "issue_service_account_token").ServeHTTP)
+
+	r.Post("/users/tokens/mfa", otelhttp.NewHandler(kithttp.NewServer(
+		issueMFATokenEndpoint(svc),
+		decodeMFAToken,
+		api.EncodeResponse,
+		opts...,
+	), "issue_mfa_token").ServeHTTP)
+
+	r.Post("/users/tokens/password", otelhttp.NewHandler(kithttp.NewServer(
+		changeExpiredSecretEndpoint(svc),
+		decodeChangeExpiredSecret,
+		api.EncodeResponse,
+		opts...,
+	), "change_expired_secret").ServeHTTP)
+
+	r.Post("/users/tokens/mfa/enroll", otelhttp.NewHandler(kithttp.NewServer(
+		enrollMFAWithChallengeEndpoint(svc),
+		decodeMFAEnroll,
+		api.EncodeResponse,
+		opts...,
+	), "enroll_mfa_with_challenge").ServeHTTP)
+
+	r.Post("/users/verify-email", otelhttp.NewHandler(kithttp.NewServer(
+		verifyEmailEndpoint(svc),
+		decodeVerifyEmail,
+		api.EncodeResponse,
+		opts...,
+	), "verify_email").ServeHTTP)
+
+	r.Post("/users/verify-email/resend", otelhttp.NewHandler(kithttp.NewServer(
+		sendVerificationEndpoint(svc),
+		decodeSendVerification,
+		api.EncodeResponse,
+		opts...,
+	), "send_verification").ServeHTTP)
+
 	r.Post("/password/reset-request", otelhttp.NewHandler(kithttp.NewServer(
 		passwordResetRequestEndpoint(svc),
 		decodePasswordResetRequest,
@@ -226,8 +385,16 @@ func usersHandler(svc users.Service, authn smqauthn.Authentication, tokenClient
 		opts...,
 	), "password_reset_req").ServeHTTP)
 
+	r.Get("/password/policy", otelhttp.NewHandler(kithttp.NewServer(
+		passwordPolicyEndpoint(svc),
+		kithttp.NopRequestDecoder,
+		api.EncodeResponse,
+		opts...,
+	), "view_password_policy").ServeHTTP)
+
 	for _, provider := range providers {
-		r.HandleFunc("/oauth/callback/"+provider.Name(), oauth2CallbackHandler(provider, svc, tokenClient))
+		r.Get("/oauth/authorize/"+provider.Name(), oauth2AuthorizeHandler(provider))
+		r.HandleFunc("/oauth/callback/"+provider.Name(), oauth2CallbackHandler(provider, svc))
 	}
 
 	return r
@@ -466,7 +633,9 @@ func decodePasswordResetRequest(_ context.Context, r *http.Request) (interface{}
 		return nil, apiutil.ErrUnsupportedContentType
 	}
 
-	var req passwResetReq
+	req := passwResetReq{
+		ip: apiutil.ClientIP(r, trustedProxies),
+	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
 	}
@@ -504,12 +673,126 @@ func decodeUpdateUserRole(_ context.Context, r *http.Request) (interface{}, erro
 	return req, err
 }
 
+func decodeImpersonate(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := impersonateReq{
+		id: chi.URLParam(r, "id"),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
+
 func decodeCredentials(_ context.Context, r *http.Request) (interface{}, error) {
 	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
 		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
 	}
 
-	req := loginUserReq{}
+	req := loginUserReq{
+		device: r.UserAgent(),
+		ip:     apiutil.ClientIP(r, trustedProxies),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
+
+func decodeMFAToken(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	var req mfaTokenReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
+
+func decodeChangeExpiredSecret(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := changeExpiredSecretReq{
+		device: r.UserAgent(),
+		ip:     apiutil.ClientIP(r, trustedProxies),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
+
+func decodeMFAEnroll(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	var req mfaEnrollReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
+
+func decodeVerifyMFA(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	var req verifyMFAReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
+
+func decodeVerifyEmail(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	var req verifyEmailReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
+
+func decodeEmail(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	var req emailReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
+
+func decodeSendVerification(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := sendVerificationReq{
+		ip: apiutil.ClientIP(r, trustedProxies),
+	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
 	}
@@ -521,7 +804,10 @@ func decodeRefreshToken(_ context.Context, r *http.Request) (interface{}, error)
 	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
 		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
 	}
-	req := tokenReq{RefreshToken: apiutil.ExtractBearerToken(r)}
+	req := tokenReq{
+		RefreshToken: apiutil.ExtractBearerToken(r),
+		ip:           apiutil.ClientIP(r, trustedProxies),
+	}
 
 	return req, nil
 }
@@ -547,6 +833,84 @@ func decodeChangeUserStatus(_ context.Context, r *http.Request) (interface{}, er
 	return req, nil
 }
 
+func decodeCreateServiceAccount(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	var req createServiceAccountReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
+
+func decodeViewServiceAccount(_ context.Context, r *http.Request) (interface{}, error) {
+	req := viewServiceAccountReq{
+		id: chi.URLParam(r, "id"),
+	}
+
+	return req, nil
+}
+
+func decodeListServiceAccounts(_ context.Context, r *http.Request) (interface{}, error) {
+	s, err := apiutil.ReadStringQuery(r, api.StatusKey, api.DefUserStatus)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+	st, err := users.ToStatus(s)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+	o, err := apiutil.ReadNumQuery[uint64](r, api.OffsetKey, api.DefOffset)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+	l, err := apiutil.ReadNumQuery[uint64](r, api.LimitKey, api.DefLimit)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	req := listServiceAccountsReq{
+		status: st,
+		offset: o,
+		limit:  l,
+	}
+
+	return req, nil
+}
+
+func decodeUpdateServiceAccount(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := updateServiceAccountReq{
+		id: chi.URLParam(r, "id"),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
+
+func decodeServiceAccountToken(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), api.ContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := serviceAccountTokenReq{
+		ip: apiutil.ClientIP(r, trustedProxies),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
+	}
+
+	return req, nil
+}
+
 func decodeListMembersByGroup(_ context.Context, r *http.Request) (interface{}, error) {
 	page, err := queryPageParams(r, api.DefPermission)
 	if err != nil {
@@ -664,8 +1028,33 @@ func queryPageParams(r *http.Request, defPermission string) (users.Page, error)
 	}, nil
 }
 
+// oauth2AuthorizeHandler is a http.HandlerFunc that redirects to the OAuth2
+// provider's consent page. The PKCE code verifier is kept in the cookie until
+// the callback.
+func oauth2AuthorizeHandler(oauth oauth2.Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !oauth.IsEnabled() {
+			http.Redirect(w, r, oauth.ErrorURL()+"?error=oauth%20provider%20is%20disabled", http.StatusSeeOther)
+			return
+		}
+
+		verifier := xoauth2.GenerateVerifier()
+		http.SetCookie(w, &http.Cookie{
+			Name:     oauthVerifierCookie + oauth.Name(),
+			Value:    verifier,
+			Path:     "/",
+			MaxAge:   int(oauthVerifierDuration.Seconds()),
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		http.Redirect(w, r, oauth.AuthCodeURL(verifier), http.StatusFound)
+	}
+}
+
 // oauth2CallbackHandler is a http.HandlerFunc that handles OAuth2 callbacks.
-func oauth2CallbackHandler(oauth oauth2.Provider, svc users.Service, tokenClient grpcTokenV1.TokenServiceClient) http.HandlerFunc {
+func oauth2CallbackHandler(oauth oauth2.Provider, svc users.Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if !oauth.IsEnabled() {
 			http.Redirect(w, r, oauth.ErrorURL()+"?error=oauth%20provider%20is%20disabled", http.StatusSeeOther)
@@ -677,20 +1066,32 @@ func oauth2CallbackHandler(oauth oauth2.Provider, svc users.Service, tokenClient
 			return
 		}
 
+		var verifier string
+		if cookie, err := r.Cookie(oauthVerifierCookie + oauth.Name()); err == nil {
+			verifier = cookie.Value
+			http.SetCookie(w, &http.Cookie{
+				Name:     oauthVerifierCookie + oauth.Name(),
+				Path:     "/",
+				MaxAge:   -1,
+				HttpOnly: true,
+				Secure:   true,
+			})
+		}
+
 		if code := r.FormValue("code"); code != "" {
-			token, err := oauth.Exchange(r.Context(), code)
+			token, err := oauth.Exchange(r.Context(), code, verifier)
 			if err != nil {
 				http.Redirect(w, r, oauth.ErrorURL()+"?error="+err.Error(), http.StatusSeeOther)
 				return
 			}
 
-			user, err := oauth.UserInfo(token.AccessToken)
+			user, roles, err := oauth.UserInfo(token.AccessToken)
 			if err != nil {
 				http.Redirect(w, r, oauth.ErrorURL()+"?error="+err.Error(), http.StatusSeeOther)
 				return
 			}
 
-			user, err = svc.OAuthCallback(r.Context(), user)
+			user, err = svc.OAuthCallback(r.Context(), user, roles)
 			if err != nil {
 				http.Redirect(w, r, oauth.ErrorURL()+"?error="+err.Error(), http.StatusSeeOther)
 				return
@@ -700,15 +1101,27 @@ func oauth2CallbackHandler(oauth oauth2.Provider, svc users.Service, tokenClient
 				return
 			}
 
-			jwt, err := tokenClient.Issue(r.Context(), &grpcTokenV1.IssueReq{
-				UserId: user.ID,
-				Type:   uint32(smqauth.AccessKey),
-			})
+			jwt, err := svc.OAuthIssueToken(r.Context(), user, r.UserAgent(), apiutil.ClientIP(r, trustedProxies))
 			if err != nil {
 				http.Redirect(w, r, oauth.ErrorURL()+"?error="+err.Error(), http.StatusSeeOther)
 				return
 			}
 
+			// The MFA challenge is completed by the UI with the same
+			// endpoints as the password login.
+			switch jwt.GetAccessType() {
+			case users.MFAAccessType, users.MFAEnrollAccessType:
+				http.SetCookie(w, &http.Cookie{
+					Name:     mfaTokenCookie,
+					Value:    jwt.GetAccessToken(),
+					Path:     "/",
+					HttpOnly: true,
+					Secure:   true,
+				})
+				http.Redirect(w, r, oauth.RedirectURL()+"?access_type="+jwt.GetAccessType(), http.StatusFound)
+				return
+			}
+
 			http.SetCookie(w, &http.Cookie{
 				Name:     "access_token",
 				Value:    jwt.GetAccessToken(),
@@ -0,0 +1,115 @@
+package users
+
+import (
+	"context"
+	"strings"
+
+	grpcDomainsV1 "github.com/hantdev/mitras/internal/grpc/domains/v1"
+	"github.com/hantdev/mitras/pkg/errors"
+)
+
+var (
+	// ErrDirectoryUserNotFound indicates that the identity is not in the directory.
+	ErrDirectoryUserNotFound = errors.New("user not found in the directory")
+
+	errDomainRoleMapping = errors.New("invalid domain role mapping, expected group:domain_id:role")
+	errDirectoryUser     = errors.New("failed to provision directory user")
+	errLocalUser         = errors.New("user with the email is not bound to the directory")
+	errRemoveDomainRole  = errors.New("failed to remove user domain role")
+)
+
+// DirectoryUser represents the user entry of the external directory, such
+// as LDAP or Active Directory.
+type DirectoryUser struct {
+	DN        string
+	Email     string
+	FirstName string
+	LastName  string
+	// Roles are the domain roles mapped from the user's directory groups.
+	Roles []DomainRole
+}
+
+// Directory specifies the external directory the users authenticate against.
+// The directory users are provisioned on their first login and their domain
+// roles are kept in sync with their directory groups.
+//
+//go:generate mockery --name Directory --output=./mocks --filename directory.go --quiet
+type Directory interface {
+	// Handles returns true if the identity is authenticated by the directory.
+	Handles(identity string) bool
+
+	// Authenticate binds to the directory with the identity and the secret,
+	// and returns the directory user.
+	Authenticate(ctx context.Context, identity, secret string) (DirectoryUser, error)
+
+	// RetrieveAll retrieves the directory users with the DNs of the given
+	// users without authenticating them. The users are searched by their
+	// emails in batches, and by their DNs if the emails changed. The
+	// returned users are keyed by the DNs, and the users removed from the
+	// directory are missing.
+	RetrieveAll(ctx context.Context, users []DirectoryUser) (map[string]DirectoryUser, error)
+
+	// Roles returns all the domain roles mapped from the directory groups.
+	// These roles are managed by the directory.
+	Roles() []DomainRole
+}
+
+// ParseDomainRoles parses the "group:domain_id:role" mappings of the identity
+// provider groups to the domain roles. The group may contain colons, so the
+// domain ID and the role are the last two fields.
+func ParseDomainRoles(mappings []string) (map[string][]DomainRole, error) {
+	roles := make(map[string][]DomainRole)
+	for _, m := range mappings {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			continue
+		}
+		rest, role, ok := cutLast(m)
+		if !ok {
+			return nil, errors.Wrap(errDomainRoleMapping, errors.New(m))
+		}
+		group, domainID, ok := cutLast(rest)
+		if !ok || group == "" || domainID == "" || role == "" {
+			return nil, errors.Wrap(errDomainRoleMapping, errors.New(m))
+		}
+		roles[group] = append(roles[group], DomainRole{DomainID: domainID, Role: role})
+	}
+
+	return roles, nil
+}
+
+func cutLast(s string) (string, string, bool) {
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return s, "", false
+	}
+
+	return s[:i], s[i+1:], true
+}
+
+// syncDirectoryRoles adds the user to the mapped domain roles and removes
+// the user from the rest of the roles managed by the directory.
+func syncDirectoryRoles(ctx context.Context, domains grpcDomainsV1.DomainsServiceClient, userID string, managed, mapped []DomainRole) error {
+	granted := make(map[DomainRole]bool)
+	for _, role := range mapped {
+		granted[role] = true
+	}
+
+	for _, role := range mapped {
+		req := &grpcDomainsV1.AddUserRoleReq{DomainId: role.DomainID, RoleName: role.Role, UserId: userID}
+		if _, err := domains.AddUserRole(ctx, req); err != nil {
+			return errors.Wrap(errDomainRole, err)
+		}
+	}
+	for _, role := range managed {
+		if granted[role] {
+			continue
+		}
+		req := &grpcDomainsV1.RemoveUserRoleReq{DomainId: role.DomainID, RoleName: role.Role, UserId: userID}
+		if _, err := domains.RemoveUserRole(ctx, req); err != nil {
+			return errors.Wrap(errRemoveDomainRole, err)
+		}
+	}
+
+	return nil
+}
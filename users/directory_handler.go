@@ -0,0 +1,99 @@
+// The DirectorySyncHandler is a cron job that runs periodically to sync the domain roles of the users bound to the directory
+// with their directory groups, so the roles follow the group changes of the users who don't log in.
+// The users removed from the directory are removed from all the domain roles managed by the directory.
+
+package users
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	grpcDomainsV1 "github.com/hantdev/mitras/internal/grpc/domains/v1"
+)
+
+type directoryHandler struct {
+	users         Repository
+	directory     Directory
+	domains       grpcDomainsV1.DomainsServiceClient
+	checkInterval time.Duration
+	logger        *slog.Logger
+}
+
+func NewDirectorySyncHandler(ctx context.Context, users Repository, directory Directory, domainsClient grpcDomainsV1.DomainsServiceClient, syncInterval time.Duration, logger *slog.Logger) {
+	handler := &directoryHandler{
+		users:         users,
+		directory:     directory,
+		domains:       domainsClient,
+		checkInterval: syncInterval,
+		logger:        logger,
+	}
+
+	go func() {
+		ticker := time.NewTicker(handler.checkInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				handler.handle(ctx)
+			}
+		}
+	}()
+}
+
+func (h *directoryHandler) handle(ctx context.Context) {
+	managed := h.directory.Roles()
+	if len(managed) == 0 {
+		return
+	}
+	pm := Page{Limit: defLimit, Offset: 0, Status: EnabledStatus, Role: AllRole, Directory: true}
+
+	for {
+		dbUsers, err := h.users.RetrieveAll(ctx, pm)
+		if err != nil {
+			h.logger.Error("failed to retrieve users", slog.Any("error", err))
+			break
+		}
+
+		h.sync(ctx, managed, dbUsers.Users)
+
+		pm.Offset += pm.Limit
+		if pm.Offset >= dbUsers.Total {
+			break
+		}
+	}
+}
+
+// sync syncs the domain roles of the page of the directory users, which are
+// retrieved from the directory at once.
+func (h *directoryHandler) sync(ctx context.Context, managed []DomainRole, users []User) {
+	ids := make(map[string]string)
+	bound := []DirectoryUser{}
+	for _, u := range users {
+		dn := metadataString(u.Metadata, directoryDNKey)
+		if dn == "" || !h.directory.Handles(u.Email) {
+			continue
+		}
+		ids[dn] = u.ID
+		bound = append(bound, DirectoryUser{DN: dn, Email: u.Email})
+	}
+	if len(bound) == 0 {
+		return
+	}
+
+	dusers, err := h.directory.RetrieveAll(ctx, bound)
+	if err != nil {
+		h.logger.Error("failed to retrieve directory users", slog.Any("error", err))
+		return
+	}
+	for _, du := range bound {
+		// The users removed from the directory are removed from all the
+		// managed roles.
+		if err := syncDirectoryRoles(ctx, h.domains, ids[du.DN], managed, dusers[du.DN].Roles); err != nil {
+			h.logger.Error("failed to sync directory user roles", slog.String("id", ids[du.DN]), slog.Any("error", err))
+		}
+	}
+}
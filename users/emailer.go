@@ -6,4 +6,13 @@ package users
 type Emailer interface {
 	// SendPasswordReset sends an email to the user with a link to reset the password.
 	SendPasswordReset(To []string, host, user, token string) error
+
+	// SendVerification sends an email to the user with a link to verify the email.
+	SendVerification(To []string, user, token string) error
+
+	// SendInvitation sends an email with a link to register using the invitation.
+	SendInvitation(To []string, user, token string) error
+
+	// SendImpersonation notifies the user that the super admin impersonates the user.
+	SendImpersonation(To []string, user, impersonator string, readOnly bool) error
 }
@@ -3,6 +3,7 @@ package emailer
 import (
 	"fmt"
 
+	smqauth "github.com/hantdev/mitras/auth"
 	"github.com/hantdev/mitras/internal/email"
 	"github.com/hantdev/mitras/users"
 )
@@ -10,17 +11,38 @@ import (
 var _ users.Emailer = (*emailer)(nil)
 
 type emailer struct {
-	resetURL string
-	agent    *email.Agent
+	resetURL        string
+	verificationURL string
+	invitationURL   string
+	agent           *email.Agent
 }
 
 // New creates new emailer utility.
-func New(url string, c *email.Config) (users.Emailer, error) {
+func New(resetURL, verificationURL, invitationURL string, c *email.Config) (users.Emailer, error) {
 	e, err := email.New(c)
-	return &emailer{resetURL: url, agent: e}, err
+	return &emailer{resetURL: resetURL, verificationURL: verificationURL, invitationURL: invitationURL, agent: e}, err
 }
 
 func (e *emailer) SendPasswordReset(to []string, host, user, token string) error {
 	url := fmt.Sprintf("%s%s?token=%s", host, e.resetURL, token)
 	return e.agent.Send(to, "", "Password Reset Request", "", user, url, "")
 }
+
+func (e *emailer) SendVerification(to []string, user, token string) error {
+	url := fmt.Sprintf("%s?token=%s", e.verificationURL, token)
+	return e.agent.Send(to, "", "Email Verification", "", user, url, "")
+}
+
+func (e *emailer) SendImpersonation(to []string, user, impersonator string, readOnly bool) error {
+	access := "full"
+	if readOnly {
+		access = "read-only"
+	}
+	content := fmt.Sprintf("The administrator %s was granted %s access to your account for %s.", impersonator, access, smqauth.ImpersonationDuration)
+	return e.agent.Send(to, "", "Account Impersonation", "", user, content, "")
+}
+
+func (e *emailer) SendInvitation(to []string, user, token string) error {
+	url := fmt.Sprintf("%s?invitation=%s", e.invitationURL, token)
+	return e.agent.Send(to, "", "Registration Invitation", "", user, url, "")
+}
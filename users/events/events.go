@@ -28,6 +28,36 @@ const (
 	deleteUser               = userPrefix + "delete"
 	userUpdateUsername       = userPrefix + "update_username"
 	userUpdateProfilePicture = userPrefix + "update_profile_picture"
+	issueMFAToken            = userPrefix + "issue_mfa_token"
+	changeExpiredSecret      = userPrefix + "change_expired_secret"
+	enrollMFA                = userPrefix + "enroll_mfa"
+	verifyMFA                = userPrefix + "verify_mfa"
+	resetMFA                 = userPrefix + "reset_mfa"
+	verifyEmail              = userPrefix + "verify_email"
+	sendVerification         = userPrefix + "send_verification"
+	inviteUser               = userPrefix + "invite"
+	userLockout              = userPrefix + "lockout"
+	userUnlock               = userPrefix + "unlock"
+	userImpersonate          = userPrefix + "impersonate"
+)
+
+const (
+	serviceAccountPrefix       = "service_account."
+	serviceAccountCreate       = serviceAccountPrefix + "create"
+	serviceAccountView         = serviceAccountPrefix + "view"
+	serviceAccountList         = serviceAccountPrefix + "list"
+	serviceAccountUpdate       = serviceAccountPrefix + "update"
+	serviceAccountRotateSecret = serviceAccountPrefix + "rotate_secret"
+	serviceAccountEnable       = serviceAccountPrefix + "enable"
+	serviceAccountDisable      = serviceAccountPrefix + "disable"
+	serviceAccountDelete       = serviceAccountPrefix + "delete"
+	serviceAccountIssueToken   = serviceAccountPrefix + "issue_token"
+)
+
+const (
+	loginFlow         = "login"
+	passwordResetFlow = "password_reset"
+	verificationFlow  = "verification"
 )
 
 var (
@@ -50,6 +80,21 @@ var (
 	_ events.Event = (*oauthCallbackEvent)(nil)
 	_ events.Event = (*deleteUserEvent)(nil)
 	_ events.Event = (*addUserPolicyEvent)(nil)
+	_ events.Event = (*issueMFATokenEvent)(nil)
+	_ events.Event = (*changeExpiredSecretEvent)(nil)
+	_ events.Event = (*enrollMFAEvent)(nil)
+	_ events.Event = (*verifyMFAEvent)(nil)
+	_ events.Event = (*resetMFAEvent)(nil)
+	_ events.Event = (*verifyEmailEvent)(nil)
+	_ events.Event = (*sendVerificationEvent)(nil)
+	_ events.Event = (*inviteUserEvent)(nil)
+	_ events.Event = (*lockoutEvent)(nil)
+	_ events.Event = (*unlockUserEvent)(nil)
+	_ events.Event = (*impersonateUserEvent)(nil)
+	_ events.Event = (*serviceAccountEvent)(nil)
+	_ events.Event = (*listServiceAccountsEvent)(nil)
+	_ events.Event = (*serviceAccountIDEvent)(nil)
+	_ events.Event = (*issueServiceAccountTokenEvent)(nil)
 )
 
 type createUserEvent struct {
@@ -82,6 +127,9 @@ func (uce createUserEvent) Encode() (map[string]interface{}, error) {
 	if uce.Email != "" {
 		val["email"] = uce.Email
 	}
+	if !uce.VerifiedAt.IsZero() {
+		val["verified_at"] = uce.VerifiedAt
+	}
 
 	return val, nil
 }
@@ -457,6 +505,124 @@ func (rte refreshTokenEvent) Encode() (map[string]interface{}, error) {
 	}, nil
 }
 
+type issueMFATokenEvent struct{}
+
+func (imte issueMFATokenEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": issueMFAToken,
+	}, nil
+}
+
+type changeExpiredSecretEvent struct{}
+
+func (cese changeExpiredSecretEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": changeExpiredSecret,
+	}, nil
+}
+
+type enrollMFAEvent struct{}
+
+func (eme enrollMFAEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": enrollMFA,
+	}, nil
+}
+
+type verifyMFAEvent struct{}
+
+func (vme verifyMFAEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": verifyMFA,
+	}, nil
+}
+
+type resetMFAEvent struct {
+	id string
+}
+
+func (rme resetMFAEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": resetMFA,
+		"id":        rme.id,
+	}, nil
+}
+
+type verifyEmailEvent struct{}
+
+func (vee verifyEmailEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": verifyEmail,
+	}, nil
+}
+
+type sendVerificationEvent struct {
+	email string
+}
+
+func (sve sendVerificationEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": sendVerification,
+		"email":     sve.email,
+	}, nil
+}
+
+type inviteUserEvent struct {
+	email string
+}
+
+func (iue inviteUserEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": inviteUser,
+		"email":     iue.email,
+	}, nil
+}
+
+type lockoutEvent struct {
+	identity string
+	ip       string
+	flow     string
+}
+
+func (le lockoutEvent) Encode() (map[string]interface{}, error) {
+	val := map[string]interface{}{
+		"operation": userLockout,
+		"identity":  le.identity,
+		"flow":      le.flow,
+	}
+	if le.ip != "" {
+		val["ip"] = le.ip
+	}
+
+	return val, nil
+}
+
+type unlockUserEvent struct {
+	id string
+}
+
+type impersonateUserEvent struct {
+	id             string
+	impersonatorID string
+	readOnly       bool
+}
+
+func (iue impersonateUserEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation":       userImpersonate,
+		"id":              iue.id,
+		"impersonator_id": iue.impersonatorID,
+		"read_only":       iue.readOnly,
+	}, nil
+}
+
+func (uue unlockUserEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": userUnlock,
+		"id":        uue.id,
+	}, nil
+}
+
 type resetSecretEvent struct{}
 
 func (rse resetSecretEvent) Encode() (map[string]interface{}, error) {
@@ -514,3 +680,95 @@ func (acpe addUserPolicyEvent) Encode() (map[string]interface{}, error) {
 		"role":      acpe.role,
 	}, nil
 }
+
+type serviceAccountEvent struct {
+	operation string
+	users.ServiceAccount
+}
+
+func (sae serviceAccountEvent) Encode() (map[string]interface{}, error) {
+	val := map[string]interface{}{
+		"operation": sae.operation,
+		"id":        sae.ID,
+		"domain":    sae.DomainID,
+		"name":      sae.Name,
+		"status":    sae.Status.String(),
+	}
+
+	if sae.Description != "" {
+		val["description"] = sae.Description
+	}
+	if sae.Metadata != nil {
+		val["metadata"] = sae.Metadata
+	}
+	if sae.PublicKey != "" {
+		val["public_key"] = sae.PublicKey
+	}
+	if !sae.CreatedAt.IsZero() {
+		val["created_at"] = sae.CreatedAt
+	}
+	if sae.CreatedBy != "" {
+		val["created_by"] = sae.CreatedBy
+	}
+	if !sae.UpdatedAt.IsZero() {
+		val["updated_at"] = sae.UpdatedAt
+	}
+	if sae.UpdatedBy != "" {
+		val["updated_by"] = sae.UpdatedBy
+	}
+
+	return val, nil
+}
+
+type listServiceAccountsEvent struct {
+	domainID string
+	users.Page
+}
+
+func (lsae listServiceAccountsEvent) Encode() (map[string]interface{}, error) {
+	val := map[string]interface{}{
+		"operation": serviceAccountList,
+		"domain":    lsae.domainID,
+		"total":     lsae.Total,
+		"offset":    lsae.Offset,
+		"limit":     lsae.Limit,
+	}
+
+	if lsae.Status.String() != "" {
+		val["status"] = lsae.Status.String()
+	}
+
+	return val, nil
+}
+
+type serviceAccountIDEvent struct {
+	operation string
+	id        string
+	domainID  string
+}
+
+func (saie serviceAccountIDEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": saie.operation,
+		"id":        saie.id,
+		"domain":    saie.domainID,
+	}, nil
+}
+
+type issueServiceAccountTokenEvent struct {
+	clientID  string
+	assertion bool
+}
+
+func (isate issueServiceAccountTokenEvent) Encode() (map[string]interface{}, error) {
+	val := map[string]interface{}{
+		"operation": serviceAccountIssueToken,
+		"assertion": isate.assertion,
+	}
+	// The client ID is the assertion subject if the assertion is used.
+	if isate.clientID != "" {
+		val["id"] = isate.clientID
+	}
+
+	return val, nil
+}
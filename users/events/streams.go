@@ -5,6 +5,7 @@ import (
 
 	grpcTokenV1 "github.com/hantdev/mitras/internal/grpc/token/v1"
 	"github.com/hantdev/mitras/pkg/authn"
+	"github.com/hantdev/mitras/pkg/errors"
 	"github.com/hantdev/mitras/pkg/events"
 	"github.com/hantdev/mitras/pkg/events/store"
 	"github.com/hantdev/mitras/users"
@@ -33,8 +34,8 @@ func NewEventStoreMiddleware(ctx context.Context, svc users.Service, url string)
 	}, nil
 }
 
-func (es *eventStore) Register(ctx context.Context, session authn.Session, user users.User, selfRegister bool) (users.User, error) {
-	user, err := es.svc.Register(ctx, session, user, selfRegister)
+func (es *eventStore) Register(ctx context.Context, session authn.Session, user users.User, selfRegister bool, invitation string) (users.User, error) {
+	user, err := es.svc.Register(ctx, session, user, selfRegister, invitation)
 	if err != nil {
 		return user, err
 	}
@@ -273,10 +274,10 @@ func (es *eventStore) Identify(ctx context.Context, session authn.Session) (stri
 	return userID, nil
 }
 
-func (es *eventStore) GenerateResetToken(ctx context.Context, email, host string) error {
-	err := es.svc.GenerateResetToken(ctx, email, host)
+func (es *eventStore) GenerateResetToken(ctx context.Context, email, host, ip string) error {
+	err := es.svc.GenerateResetToken(ctx, email, host, ip)
 	if err != nil {
-		return err
+		return es.lockout(ctx, err, lockoutEvent{identity: email, ip: ip, flow: passwordResetFlow})
 	}
 
 	event := generateResetTokenEvent{
@@ -287,10 +288,10 @@ func (es *eventStore) GenerateResetToken(ctx context.Context, email, host string
 	return es.Publish(ctx, event)
 }
 
-func (es *eventStore) IssueToken(ctx context.Context, username, secret string) (*grpcTokenV1.Token, error) {
-	token, err := es.svc.IssueToken(ctx, username, secret)
+func (es *eventStore) IssueToken(ctx context.Context, username, secret, device, ip string) (*grpcTokenV1.Token, error) {
+	token, err := es.svc.IssueToken(ctx, username, secret, device, ip)
 	if err != nil {
-		return token, err
+		return token, es.lockout(ctx, err, lockoutEvent{identity: username, ip: ip, flow: loginFlow})
 	}
 
 	event := issueTokenEvent{
@@ -304,8 +305,8 @@ func (es *eventStore) IssueToken(ctx context.Context, username, secret string) (
 	return token, nil
 }
 
-func (es *eventStore) RefreshToken(ctx context.Context, session authn.Session, refreshToken string) (*grpcTokenV1.Token, error) {
-	token, err := es.svc.RefreshToken(ctx, session, refreshToken)
+func (es *eventStore) RefreshToken(ctx context.Context, session authn.Session, refreshToken, ip string) (*grpcTokenV1.Token, error) {
+	token, err := es.svc.RefreshToken(ctx, session, refreshToken, ip)
 	if err != nil {
 		return token, err
 	}
@@ -319,6 +320,253 @@ func (es *eventStore) RefreshToken(ctx context.Context, session authn.Session, r
 	return token, nil
 }
 
+func (es *eventStore) IssueMFAToken(ctx context.Context, mfaToken, code string) (*grpcTokenV1.Token, error) {
+	token, err := es.svc.IssueMFAToken(ctx, mfaToken, code)
+	if err != nil {
+		return token, err
+	}
+
+	if err := es.Publish(ctx, issueMFATokenEvent{}); err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+func (es *eventStore) ChangeExpiredSecret(ctx context.Context, changeToken, newSecret, device, ip string) (*grpcTokenV1.Token, error) {
+	token, err := es.svc.ChangeExpiredSecret(ctx, changeToken, newSecret, device, ip)
+	if err != nil {
+		return token, err
+	}
+
+	if err := es.Publish(ctx, changeExpiredSecretEvent{}); err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+func (es *eventStore) PasswordPolicy(ctx context.Context) users.PasswordPolicy {
+	return es.svc.PasswordPolicy(ctx)
+}
+
+func (es *eventStore) EnrollMFA(ctx context.Context, session authn.Session) (users.MFAEnrollment, error) {
+	enrollment, err := es.svc.EnrollMFA(ctx, session)
+	if err != nil {
+		return enrollment, err
+	}
+
+	if err := es.Publish(ctx, enrollMFAEvent{}); err != nil {
+		return enrollment, err
+	}
+
+	return enrollment, nil
+}
+
+func (es *eventStore) EnrollMFAWithChallenge(ctx context.Context, mfaToken string) (users.MFAEnrollment, error) {
+	enrollment, err := es.svc.EnrollMFAWithChallenge(ctx, mfaToken)
+	if err != nil {
+		return enrollment, err
+	}
+
+	if err := es.Publish(ctx, enrollMFAEvent{}); err != nil {
+		return enrollment, err
+	}
+
+	return enrollment, nil
+}
+
+func (es *eventStore) VerifyMFA(ctx context.Context, session authn.Session, code string) error {
+	if err := es.svc.VerifyMFA(ctx, session, code); err != nil {
+		return err
+	}
+
+	return es.Publish(ctx, verifyMFAEvent{})
+}
+
+func (es *eventStore) ResetMFA(ctx context.Context, session authn.Session, id string) error {
+	if err := es.svc.ResetMFA(ctx, session, id); err != nil {
+		return err
+	}
+
+	return es.Publish(ctx, resetMFAEvent{id: id})
+}
+
+func (es *eventStore) Unlock(ctx context.Context, session authn.Session, id string) error {
+	if err := es.svc.Unlock(ctx, session, id); err != nil {
+		return err
+	}
+
+	return es.Publish(ctx, unlockUserEvent{id: id})
+}
+
+func (es *eventStore) Impersonate(ctx context.Context, session authn.Session, id string, readOnly bool) (*grpcTokenV1.Token, error) {
+	token, err := es.svc.Impersonate(ctx, session, id, readOnly)
+	if err != nil {
+		return token, err
+	}
+
+	event := impersonateUserEvent{
+		id:             id,
+		impersonatorID: session.UserID,
+		readOnly:       readOnly,
+	}
+	if err := es.Publish(ctx, event); err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+func (es *eventStore) CreateServiceAccount(ctx context.Context, session authn.Session, sa users.ServiceAccount) (users.ServiceAccount, string, error) {
+	sa, secret, err := es.svc.CreateServiceAccount(ctx, session, sa)
+	if err != nil {
+		return sa, secret, err
+	}
+
+	if err := es.Publish(ctx, serviceAccountEvent{serviceAccountCreate, sa}); err != nil {
+		return sa, secret, err
+	}
+
+	return sa, secret, nil
+}
+
+func (es *eventStore) ViewServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	sa, err := es.svc.ViewServiceAccount(ctx, session, id)
+	if err != nil {
+		return sa, err
+	}
+
+	if err := es.Publish(ctx, serviceAccountEvent{serviceAccountView, sa}); err != nil {
+		return sa, err
+	}
+
+	return sa, nil
+}
+
+func (es *eventStore) ListServiceAccounts(ctx context.Context, session authn.Session, pm users.Page) (users.ServiceAccountsPage, error) {
+	sp, err := es.svc.ListServiceAccounts(ctx, session, pm)
+	if err != nil {
+		return sp, err
+	}
+
+	if err := es.Publish(ctx, listServiceAccountsEvent{session.DomainID, sp.Page}); err != nil {
+		return sp, err
+	}
+
+	return sp, nil
+}
+
+func (es *eventStore) UpdateServiceAccount(ctx context.Context, session authn.Session, sa users.ServiceAccount) (users.ServiceAccount, error) {
+	sa, err := es.svc.UpdateServiceAccount(ctx, session, sa)
+	if err != nil {
+		return sa, err
+	}
+
+	if err := es.Publish(ctx, serviceAccountEvent{serviceAccountUpdate, sa}); err != nil {
+		return sa, err
+	}
+
+	return sa, nil
+}
+
+func (es *eventStore) RotateServiceAccountSecret(ctx context.Context, session authn.Session, id string) (string, error) {
+	secret, err := es.svc.RotateServiceAccountSecret(ctx, session, id)
+	if err != nil {
+		return secret, err
+	}
+
+	if err := es.Publish(ctx, serviceAccountIDEvent{serviceAccountRotateSecret, id, session.DomainID}); err != nil {
+		return secret, err
+	}
+
+	return secret, nil
+}
+
+func (es *eventStore) EnableServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	sa, err := es.svc.EnableServiceAccount(ctx, session, id)
+	if err != nil {
+		return sa, err
+	}
+
+	if err := es.Publish(ctx, serviceAccountEvent{serviceAccountEnable, sa}); err != nil {
+		return sa, err
+	}
+
+	return sa, nil
+}
+
+func (es *eventStore) DisableServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	sa, err := es.svc.DisableServiceAccount(ctx, session, id)
+	if err != nil {
+		return sa, err
+	}
+
+	if err := es.Publish(ctx, serviceAccountEvent{serviceAccountDisable, sa}); err != nil {
+		return sa, err
+	}
+
+	return sa, nil
+}
+
+func (es *eventStore) DeleteServiceAccount(ctx context.Context, session authn.Session, id string) error {
+	if err := es.svc.DeleteServiceAccount(ctx, session, id); err != nil {
+		return err
+	}
+
+	return es.Publish(ctx, serviceAccountIDEvent{serviceAccountDelete, id, session.DomainID})
+}
+
+func (es *eventStore) IssueServiceAccountToken(ctx context.Context, clientID, clientSecret, assertion, ip string) (*grpcTokenV1.Token, error) {
+	token, err := es.svc.IssueServiceAccountToken(ctx, clientID, clientSecret, assertion, ip)
+	if err != nil {
+		return token, err
+	}
+
+	if err := es.Publish(ctx, issueServiceAccountTokenEvent{clientID: clientID, assertion: assertion != ""}); err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+// lockout publishes the lockout event if the failed attempt locked out the
+// identity or the IP, and returns the error of the attempt.
+func (es *eventStore) lockout(ctx context.Context, err error, event lockoutEvent) error {
+	if !errors.Contains(err, users.ErrLockedOut) {
+		return err
+	}
+	if perr := es.Publish(ctx, event); perr != nil {
+		return errors.Wrap(err, perr)
+	}
+
+	return err
+}
+
+func (es *eventStore) VerifyEmail(ctx context.Context, token string) error {
+	if err := es.svc.VerifyEmail(ctx, token); err != nil {
+		return err
+	}
+
+	return es.Publish(ctx, verifyEmailEvent{})
+}
+
+func (es *eventStore) SendVerification(ctx context.Context, email, ip string) error {
+	if err := es.svc.SendVerification(ctx, email, ip); err != nil {
+		return es.lockout(ctx, err, lockoutEvent{identity: email, ip: ip, flow: verificationFlow})
+	}
+
+	return es.Publish(ctx, sendVerificationEvent{email: email})
+}
+
+func (es *eventStore) InviteUser(ctx context.Context, session authn.Session, email string) error {
+	if err := es.svc.InviteUser(ctx, session, email); err != nil {
+		return err
+	}
+
+	return es.Publish(ctx, inviteUserEvent{email: email})
+}
+
 func (es *eventStore) ResetSecret(ctx context.Context, session authn.Session, secret string) error {
 	if err := es.svc.ResetSecret(ctx, session, secret); err != nil {
 		return err
@@ -343,8 +591,8 @@ func (es *eventStore) SendPasswordReset(ctx context.Context, host, email, user,
 	return es.Publish(ctx, event)
 }
 
-func (es *eventStore) OAuthCallback(ctx context.Context, user users.User) (users.User, error) {
-	token, err := es.svc.OAuthCallback(ctx, user)
+func (es *eventStore) OAuthCallback(ctx context.Context, user users.User, roles []users.DomainRole) (users.User, error) {
+	token, err := es.svc.OAuthCallback(ctx, user, roles)
 	if err != nil {
 		return token, err
 	}
@@ -384,3 +632,7 @@ func (es *eventStore) OAuthAddUserPolicy(ctx context.Context, user users.User) e
 
 	return es.Publish(ctx, event)
 }
+
+func (es *eventStore) OAuthIssueToken(ctx context.Context, user users.User, device, ip string) (*grpcTokenV1.Token, error) {
+	return es.svc.OAuthIssueToken(ctx, user, device, ip)
+}
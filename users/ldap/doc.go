@@ -0,0 +1,3 @@
+// Package ldap contains the domain concept definitions needed to support
+// mitras users authentication against the LDAP or Active Directory server.
+package ldap
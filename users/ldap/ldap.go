@@ -0,0 +1,394 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/mitras/users"
+)
+
+const (
+	identityPlaceholder = "{identity}"
+	dnPlaceholder       = "{dn}"
+	anyIdentityDomain   = "*"
+	// batchSize is the number of the users retrieved with a single search.
+	batchSize = 100
+)
+
+var (
+	errURL            = errors.New("invalid LDAP server URL")
+	errCACert         = errors.New("failed to load LDAP server CA certificate")
+	errStartTLS       = errors.New("StartTLS can't be used with the ldaps scheme")
+	errConnect        = errors.New("failed to connect to LDAP server")
+	errServiceBind    = errors.New("failed to bind with the LDAP service account")
+	errSearch         = errors.New("failed to search LDAP directory")
+	errAmbiguousUser  = errors.New("LDAP user filter matches more than one entry")
+	errMissingEmail   = errors.New("LDAP user entry is missing the email attribute")
+	errEmptySecret    = errors.New("empty secret")
+	errInvalidBinding = errors.New("invalid LDAP user credentials")
+)
+
+// Config is the configuration of the LDAP directory.
+type Config struct {
+	// URL is the LDAP server URL, with the ldap or the ldaps scheme,
+	// e.g. "ldaps://ldap.example.com:636".
+	URL string `env:"URL"                  envDefault:""`
+	// StartTLS upgrades the ldap scheme connection to TLS.
+	StartTLS           bool          `env:"START_TLS"            envDefault:"false"`
+	CACert             string        `env:"CA_CERT"              envDefault:""`
+	InsecureSkipVerify bool          `env:"INSECURE_SKIP_VERIFY" envDefault:"false"`
+	Timeout            time.Duration `env:"TIMEOUT"              envDefault:"10s"`
+
+	// BindDN and BindPassword are the credentials of the service account
+	// that searches the directory. The directory is searched anonymously
+	// if BindDN is empty.
+	BindDN       string `env:"BIND_DN"              envDefault:""`
+	BindPassword string `env:"BIND_PASSWORD"        envDefault:""`
+
+	// UserFilter finds the user entry under BaseDN. The {identity}
+	// placeholder is replaced with the escaped login identity.
+	BaseDN             string `env:"BASE_DN"              envDefault:""`
+	UserFilter         string `env:"USER_FILTER"          envDefault:"(&(objectClass=person)(|(uid={identity})(mail={identity})))"`
+	EmailAttribute     string `env:"EMAIL_ATTRIBUTE"      envDefault:"mail"`
+	FirstNameAttribute string `env:"FIRST_NAME_ATTRIBUTE" envDefault:"givenName"`
+	LastNameAttribute  string `env:"LAST_NAME_ATTRIBUTE"  envDefault:"sn"`
+
+	// GroupAttribute is the user entry attribute listing the DNs of the
+	// user's groups, e.g. "memberOf" on Active Directory.
+	GroupAttribute string `env:"GROUP_ATTRIBUTE"      envDefault:"memberOf"`
+	// GroupFilter finds the user's groups under GroupBaseDN, for the servers
+	// without the group attribute. The {dn} placeholder is replaced with the
+	// escaped user DN. The groups are not searched if it's empty.
+	GroupBaseDN string `env:"GROUP_BASE_DN"        envDefault:""`
+	GroupFilter string `env:"GROUP_FILTER"         envDefault:""`
+
+	// IdentityDomains are the email domains of the identities authenticated
+	// by the directory, or "*" for all the identities. None of the
+	// identities is authenticated by the directory if it's empty.
+	IdentityDomains []string `env:"IDENTITY_DOMAINS"     envDefault:""`
+
+	// DomainRoles maps the group DNs to the domain roles. Each mapping has
+	// the "group_dn:domain_id:role" format.
+	DomainRoles []string `env:"DOMAIN_ROLES"         envDefault:"" envSeparator:";"`
+}
+
+var _ users.Directory = (*directory)(nil)
+
+type directory struct {
+	cfg   Config
+	tls   *tls.Config
+	roles map[string][]users.DomainRole
+}
+
+// NewDirectory returns the LDAP directory. The group DNs of the domain role
+// mappings are matched case-insensitively.
+func NewDirectory(cfg Config) (users.Directory, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "" {
+		return nil, errURL
+	}
+	if cfg.StartTLS && u.Scheme == "ldaps" {
+		return nil, errStartTLS
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if cfg.CACert != "" {
+		pem, err := os.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, errors.Wrap(errCACert, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errCACert
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	mappings, err := users.ParseDomainRoles(cfg.DomainRoles)
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[string][]users.DomainRole)
+	for group, r := range mappings {
+		group = strings.ToLower(group)
+		roles[group] = append(roles[group], r...)
+	}
+
+	return &directory{
+		cfg:   cfg,
+		tls:   tlsConfig,
+		roles: roles,
+	}, nil
+}
+
+func (d *directory) Handles(identity string) bool {
+	domain := ""
+	if i := strings.LastIndex(identity, "@"); i >= 0 {
+		domain = strings.ToLower(identity[i+1:])
+	}
+	for _, id := range d.cfg.IdentityDomains {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == anyIdentityDomain || (domain != "" && id == domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d *directory) Authenticate(_ context.Context, identity, secret string) (users.DirectoryUser, error) {
+	// Most servers treat the bind with the empty password as the anonymous
+	// bind, which always succeeds.
+	if secret == "" {
+		return users.DirectoryUser{}, errors.Wrap(svcerr.ErrLogin, errEmptySecret)
+	}
+
+	conn, err := d.connect()
+	if err != nil {
+		return users.DirectoryUser{}, err
+	}
+	defer conn.Close()
+
+	entry, err := d.searchUser(conn, identity)
+	if err != nil {
+		return users.DirectoryUser{}, err
+	}
+	if err := conn.Bind(entry.DN, secret); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return users.DirectoryUser{}, errors.Wrap(svcerr.ErrLogin, errInvalidBinding)
+		}
+		return users.DirectoryUser{}, errors.Wrap(errConnect, err)
+	}
+	// The user may not be allowed to search the groups.
+	if err := d.bind(conn); err != nil {
+		return users.DirectoryUser{}, err
+	}
+
+	return d.user(conn, entry)
+}
+
+func (d *directory) RetrieveAll(_ context.Context, dusers []users.DirectoryUser) (map[string]users.DirectoryUser, error) {
+	conn, err := d.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entries := make(map[string]*ldap.Entry)
+	for i := 0; i < len(dusers); i += batchSize {
+		batch, err := d.searchByEmails(conn, dusers[i:min(i+batchSize, len(dusers))])
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range batch {
+			entries[strings.ToLower(e.DN)] = e
+		}
+	}
+
+	ret := make(map[string]users.DirectoryUser, len(dusers))
+	for _, du := range dusers {
+		entry, ok := entries[strings.ToLower(du.DN)]
+		if !ok {
+			// The user's email may have changed in the directory.
+			entry, err = d.searchDN(conn, du.DN)
+			switch {
+			case errors.Contains(err, users.ErrDirectoryUserNotFound):
+				continue
+			case err != nil:
+				return nil, err
+			}
+		}
+		user, err := d.user(conn, entry)
+		if err != nil {
+			return nil, err
+		}
+		ret[du.DN] = user
+	}
+
+	return ret, nil
+}
+
+func (d *directory) Roles() []users.DomainRole {
+	seen := make(map[users.DomainRole]bool)
+	roles := []users.DomainRole{}
+	for _, rs := range d.roles {
+		for _, r := range rs {
+			if !seen[r] {
+				seen[r] = true
+				roles = append(roles, r)
+			}
+		}
+	}
+
+	return roles
+}
+
+// connect dials the server, upgrades the connection to TLS if StartTLS is
+// enabled, and binds with the service account.
+func (d *directory) connect() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(d.cfg.URL, ldap.DialWithTLSConfig(d.tls), ldap.DialWithDialer(&net.Dialer{Timeout: d.cfg.Timeout}))
+	if err != nil {
+		return nil, errors.Wrap(errConnect, err)
+	}
+	conn.SetTimeout(d.cfg.Timeout)
+
+	if d.cfg.StartTLS {
+		if err := conn.StartTLS(d.tls); err != nil {
+			conn.Close()
+			return nil, errors.Wrap(errConnect, err)
+		}
+	}
+	if err := d.bind(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (d *directory) bind(conn *ldap.Conn) error {
+	if d.cfg.BindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(d.cfg.BindDN, d.cfg.BindPassword); err != nil {
+		return errors.Wrap(errServiceBind, err)
+	}
+
+	return nil
+}
+
+// searchUser returns the only user entry matching the identity.
+func (d *directory) searchUser(conn *ldap.Conn, identity string) (*ldap.Entry, error) {
+	filter := strings.ReplaceAll(d.cfg.UserFilter, identityPlaceholder, ldap.EscapeFilter(identity))
+	req := ldap.NewSearchRequest(d.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, d.timeLimit(), false, filter, d.attributes(), nil)
+
+	res, err := conn.Search(req)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			return nil, users.ErrDirectoryUserNotFound
+		}
+		return nil, errors.Wrap(errSearch, err)
+	}
+	switch len(res.Entries) {
+	case 0:
+		return nil, users.ErrDirectoryUserNotFound
+	case 1:
+		return res.Entries[0], nil
+	default:
+		return nil, errAmbiguousUser
+	}
+}
+
+// searchByEmails returns the user entries with any of the users' emails.
+func (d *directory) searchByEmails(conn *ldap.Conn, dusers []users.DirectoryUser) ([]*ldap.Entry, error) {
+	var filter strings.Builder
+	filter.WriteString("(|")
+	for _, du := range dusers {
+		if du.Email != "" {
+			filter.WriteString("(" + d.cfg.EmailAttribute + "=" + ldap.EscapeFilter(du.Email) + ")")
+		}
+	}
+	filter.WriteString(")")
+	if filter.Len() == len("(|)") {
+		return nil, nil
+	}
+	req := ldap.NewSearchRequest(d.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, d.timeLimit(), false, filter.String(), d.attributes(), nil)
+
+	res, err := conn.Search(req)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(errSearch, err)
+	}
+
+	return res.Entries, nil
+}
+
+// searchDN returns the user entry with the DN.
+func (d *directory) searchDN(conn *ldap.Conn, dn string) (*ldap.Entry, error) {
+	req := ldap.NewSearchRequest(dn, ldap.ScopeBaseObject, ldap.NeverDerefAliases, 0, d.timeLimit(), false, "(objectClass=*)", d.attributes(), nil)
+
+	res, err := conn.Search(req)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			return nil, users.ErrDirectoryUserNotFound
+		}
+		return nil, errors.Wrap(errSearch, err)
+	}
+	if len(res.Entries) == 0 {
+		return nil, users.ErrDirectoryUserNotFound
+	}
+
+	return res.Entries[0], nil
+}
+
+func (d *directory) attributes() []string {
+	attrs := []string{d.cfg.EmailAttribute, d.cfg.FirstNameAttribute, d.cfg.LastNameAttribute}
+	if d.cfg.GroupAttribute != "" {
+		attrs = append(attrs, d.cfg.GroupAttribute)
+	}
+
+	return attrs
+}
+
+// user returns the directory user of the entry with the domain roles mapped
+// from the user's groups.
+func (d *directory) user(conn *ldap.Conn, entry *ldap.Entry) (users.DirectoryUser, error) {
+	email := entry.GetAttributeValue(d.cfg.EmailAttribute)
+	if email == "" {
+		return users.DirectoryUser{}, errMissingEmail
+	}
+
+	groups := []string{}
+	if d.cfg.GroupAttribute != "" {
+		groups = append(groups, entry.GetAttributeValues(d.cfg.GroupAttribute)...)
+	}
+	if d.cfg.GroupFilter != "" {
+		filter := strings.ReplaceAll(d.cfg.GroupFilter, dnPlaceholder, ldap.EscapeFilter(entry.DN))
+		req := ldap.NewSearchRequest(d.cfg.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, d.timeLimit(), false, filter, []string{"dn"}, nil)
+		res, err := conn.Search(req)
+		if err != nil {
+			return users.DirectoryUser{}, errors.Wrap(errSearch, err)
+		}
+		for _, g := range res.Entries {
+			groups = append(groups, g.DN)
+		}
+	}
+
+	seen := make(map[users.DomainRole]bool)
+	roles := []users.DomainRole{}
+	for _, g := range groups {
+		for _, r := range d.roles[strings.ToLower(g)] {
+			if !seen[r] {
+				seen[r] = true
+				roles = append(roles, r)
+			}
+		}
+	}
+
+	return users.DirectoryUser{
+		DN:        entry.DN,
+		Email:     email,
+		FirstName: entry.GetAttributeValue(d.cfg.FirstNameAttribute),
+		LastName:  entry.GetAttributeValue(d.cfg.LastNameAttribute),
+		Roles:     roles,
+	}, nil
+}
+
+func (d *directory) timeLimit() int {
+	return int(d.cfg.Timeout.Seconds())
+}
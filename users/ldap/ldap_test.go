@@ -0,0 +1,529 @@
+package ldap_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/mitras/users"
+	"github.com/hantdev/mitras/users/ldap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	adminDN       = "cn=admin,dc=example,dc=com"
+	adminPassword = "admin-secret"
+	baseDN        = "ou=people,dc=example,dc=com"
+	groupBaseDN   = "ou=groups,dc=example,dc=com"
+	userDN        = "uid=jdoe,ou=people,dc=example,dc=com"
+	userPassword  = "jdoe-secret"
+	adminsDN      = "cn=admins,ou=groups,dc=example,dc=com"
+	startTLSOID   = "1.3.6.1.4.1.1466.20037"
+	domainID      = "c0a8e1e0-8f1a-4bd4-9a8e-4b6f0c1c2d3e"
+
+	resultSuccess                = 0
+	resultConfidentialityRequire = 13
+	resultInvalidCredentials     = 49
+)
+
+type entry struct {
+	dn    string
+	attrs map[string][]string
+}
+
+var entries = []entry{
+	{
+		dn: userDN,
+		attrs: map[string][]string{
+			"uid":       {"jdoe"},
+			"mail":      {"jdoe@example.com"},
+			"givenName": {"John"},
+			"sn":        {"Doe"},
+			"memberOf":  {"CN=Admins,OU=Groups,DC=example,DC=com"},
+		},
+	},
+	{
+		dn:    adminsDN,
+		attrs: map[string][]string{"cn": {"admins"}, "member": {userDN}},
+	},
+}
+
+// server is the in-process stub LDAP server. It supports the simple bind,
+// the equality match and the base object search and the StartTLS extended
+// operation.
+type server struct {
+	addr       string
+	tls        *tls.Config
+	requireTLS bool
+	searches   atomic.Int64
+}
+
+func newServer(t *testing.T, tlsConfig *tls.Config, ldaps, requireTLS bool) *server {
+	var ln net.Listener
+	var err error
+	switch ldaps {
+	case true:
+		ln, err = tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	default:
+		ln, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	require.Nil(t, err, fmt.Sprintf("listen unexpected error: %s", err))
+	t.Cleanup(func() { ln.Close() })
+
+	s := &server{addr: ln.Addr().String(), tls: tlsConfig, requireTLS: requireTLS}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, ldaps)
+		}
+	}()
+
+	return s
+}
+
+func (s *server) serve(conn net.Conn, secure bool) {
+	defer func() { conn.Close() }()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		id := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case 0:
+			code := int64(resultSuccess)
+			dn, _ := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			switch {
+			case s.requireTLS && !secure:
+				code = resultConfidentialityRequire
+			case dn == adminDN && password != adminPassword,
+				dn == userDN && password != userPassword,
+				dn != "" && dn != adminDN && dn != userDN:
+				code = resultInvalidCredentials
+			}
+			write(conn, result(id, 1, code))
+		case 2:
+			return
+		case 3:
+			s.searches.Add(1)
+			base, _ := op.Children[0].Value.(string)
+			scope, _ := op.Children[1].Value.(int64)
+			for _, e := range entries {
+				switch scope {
+				case 0:
+					if e.dn == base && matches(e, op.Children[6]) {
+						write(conn, searchEntry(id, e))
+					}
+				default:
+					if strings.HasSuffix(e.dn, base) && matches(e, op.Children[6]) {
+						write(conn, searchEntry(id, e))
+					}
+				}
+			}
+			write(conn, result(id, 5, resultSuccess))
+		case 23:
+			name := op.Children[0].Data.String()
+			if name != startTLSOID || secure {
+				write(conn, result(id, 24, 2))
+				continue
+			}
+			write(conn, result(id, 24, resultSuccess))
+			conn = tls.Server(conn, s.tls)
+			secure = true
+		}
+	}
+}
+
+// matches returns true if any of the filter's equality matches, other than
+// the object class, matches the entry. The object class presence matches
+// any entry.
+func matches(e entry, filter *ber.Packet) bool {
+	if filter.Tag == 7 {
+		return filter.Data.String() == "objectClass"
+	}
+	if filter.Tag == 3 && len(filter.Children) == 2 {
+		attr, _ := filter.Children[0].Value.(string)
+		val, _ := filter.Children[1].Value.(string)
+		if attr == "objectClass" {
+			return false
+		}
+		for _, v := range e.attrs[attr] {
+			if strings.EqualFold(v, val) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, c := range filter.Children {
+		if matches(e, c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func message(id int64, op *ber.Packet) *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+	packet.AppendChild(op)
+
+	return packet
+}
+
+func result(id int64, tag ber.Tag, code int64) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+
+	return message(id, op)
+}
+
+func searchEntry(id int64, e entry) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, 4, nil, "")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, ""))
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for name, vals := range e.attrs {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, v := range vals {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, ""))
+		}
+		attr.AppendChild(set)
+		attrs.AppendChild(attr)
+	}
+	op.AppendChild(attrs)
+
+	return message(id, op)
+}
+
+func write(conn net.Conn, packet *ber.Packet) {
+	_, _ = conn.Write(packet.Bytes())
+}
+
+// newCert returns the self-signed server certificate and the path of its
+// PEM encoded file.
+func newCert(t *testing.T) (tls.Certificate, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err, fmt.Sprintf("generate key unexpected error: %s", err))
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ldap"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.Nil(t, err, fmt.Sprintf("create certificate unexpected error: %s", err))
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	require.Nil(t, err, fmt.Sprintf("write certificate unexpected error: %s", err))
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, path
+}
+
+func newConfig(url string) ldap.Config {
+	return ldap.Config{
+		URL:                url,
+		Timeout:            5 * time.Second,
+		BindDN:             adminDN,
+		BindPassword:       adminPassword,
+		BaseDN:             baseDN,
+		UserFilter:         "(&(objectClass=person)(|(uid={identity})(mail={identity})))",
+		EmailAttribute:     "mail",
+		FirstNameAttribute: "givenName",
+		LastNameAttribute:  "sn",
+		GroupAttribute:     "memberOf",
+		DomainRoles:        []string{adminsDN + ":" + domainID + ":admin"},
+	}
+}
+
+func TestNewDirectory(t *testing.T) {
+	invalidCA := newConfig("ldap://127.0.0.1:389")
+	invalidCA.CACert = filepath.Join(t.TempDir(), "missing.pem")
+	startTLS := newConfig("ldaps://127.0.0.1:636")
+	startTLS.StartTLS = true
+	invalidRoles := newConfig("ldap://127.0.0.1:389")
+	invalidRoles.DomainRoles = []string{"admin"}
+
+	cases := []struct {
+		desc string
+		cfg  ldap.Config
+		err  error
+	}{
+		{
+			desc: "create directory with valid config",
+			cfg:  newConfig("ldap://127.0.0.1:389"),
+			err:  nil,
+		},
+		{
+			desc: "create directory with invalid URL scheme",
+			cfg:  newConfig("http://127.0.0.1:389"),
+			err:  errors.New("invalid LDAP server URL"),
+		},
+		{
+			desc: "create directory with StartTLS and ldaps scheme",
+			cfg:  startTLS,
+			err:  errors.New("StartTLS can't be used with the ldaps scheme"),
+		},
+		{
+			desc: "create directory with missing CA certificate",
+			cfg:  invalidCA,
+			err:  errors.New("failed to load LDAP server CA certificate"),
+		},
+		{
+			desc: "create directory with invalid domain role mapping",
+			cfg:  invalidRoles,
+			err:  errors.New("invalid domain role mapping, expected group:domain_id:role"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := ldap.NewDirectory(tc.cfg)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		})
+	}
+}
+
+func TestHandles(t *testing.T) {
+	none, err := ldap.NewDirectory(newConfig("ldap://127.0.0.1:389"))
+	require.Nil(t, err, fmt.Sprintf("create directory unexpected error: %s", err))
+	cfg := newConfig("ldap://127.0.0.1:389")
+	cfg.IdentityDomains = []string{"*"}
+	global, err := ldap.NewDirectory(cfg)
+	require.Nil(t, err, fmt.Sprintf("create directory unexpected error: %s", err))
+	cfg = newConfig("ldap://127.0.0.1:389")
+	cfg.IdentityDomains = []string{"example.com"}
+	scoped, err := ldap.NewDirectory(cfg)
+	require.Nil(t, err, fmt.Sprintf("create directory unexpected error: %s", err))
+
+	assert.False(t, none.Handles("jdoe"))
+	assert.False(t, none.Handles("jdoe@example.com"))
+	assert.True(t, global.Handles("jdoe"))
+	assert.True(t, global.Handles("admin@other.com"))
+	assert.True(t, scoped.Handles("jdoe@Example.com"))
+	assert.False(t, scoped.Handles("admin@other.com"))
+	assert.False(t, scoped.Handles("jdoe"))
+}
+
+func TestAuthenticate(t *testing.T) {
+	cert, caPath := newCert(t)
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	plain := newServer(t, tlsConfig, false, false)
+	startTLS := newServer(t, tlsConfig, false, true)
+	ldaps := newServer(t, tlsConfig, true, false)
+
+	plainCfg := newConfig("ldap://" + plain.addr)
+	startTLSCfg := newConfig("ldap://" + startTLS.addr)
+	startTLSCfg.StartTLS = true
+	startTLSCfg.CACert = caPath
+	withoutTLSCfg := newConfig("ldap://" + startTLS.addr)
+	ldapsCfg := newConfig("ldaps://" + ldaps.addr)
+	ldapsCfg.CACert = caPath
+	untrustedCfg := newConfig("ldaps://" + ldaps.addr)
+	groupsCfg := newConfig("ldap://" + plain.addr)
+	groupsCfg.GroupAttribute = ""
+	groupsCfg.GroupBaseDN = groupBaseDN
+	groupsCfg.GroupFilter = "(&(objectClass=groupOfNames)(member={dn}))"
+	invalidBindCfg := newConfig("ldap://" + plain.addr)
+	invalidBindCfg.BindPassword = "invalid"
+
+	user := users.DirectoryUser{
+		DN:        userDN,
+		Email:     "jdoe@example.com",
+		FirstName: "John",
+		LastName:  "Doe",
+		Roles:     []users.DomainRole{{DomainID: domainID, Role: "admin"}},
+	}
+
+	cases := []struct {
+		desc     string
+		cfg      ldap.Config
+		identity string
+		secret   string
+		user     users.DirectoryUser
+		err      error
+	}{
+		{
+			desc:     "authenticate with username",
+			cfg:      plainCfg,
+			identity: "jdoe",
+			secret:   userPassword,
+			user:     user,
+		},
+		{
+			desc:     "authenticate with email",
+			cfg:      plainCfg,
+			identity: "jdoe@example.com",
+			secret:   userPassword,
+			user:     user,
+		},
+		{
+			desc:     "authenticate with StartTLS",
+			cfg:      startTLSCfg,
+			identity: "jdoe",
+			secret:   userPassword,
+			user:     user,
+		},
+		{
+			desc:     "authenticate with ldaps",
+			cfg:      ldapsCfg,
+			identity: "jdoe",
+			secret:   userPassword,
+			user:     user,
+		},
+		{
+			desc:     "authenticate with groups search",
+			cfg:      groupsCfg,
+			identity: "jdoe",
+			secret:   userPassword,
+			user:     user,
+		},
+		{
+			desc:     "authenticate with invalid password",
+			cfg:      plainCfg,
+			identity: "jdoe",
+			secret:   "invalid",
+			err:      svcerr.ErrLogin,
+		},
+		{
+			desc:     "authenticate with empty password",
+			cfg:      plainCfg,
+			identity: "jdoe",
+			secret:   "",
+			err:      svcerr.ErrLogin,
+		},
+		{
+			desc:     "authenticate unknown user",
+			cfg:      plainCfg,
+			identity: "unknown",
+			secret:   userPassword,
+			err:      users.ErrDirectoryUserNotFound,
+		},
+		{
+			desc:     "authenticate with invalid service account",
+			cfg:      invalidBindCfg,
+			identity: "jdoe",
+			secret:   userPassword,
+			err:      errors.New("failed to bind with the LDAP service account"),
+		},
+		{
+			desc:     "authenticate without required TLS",
+			cfg:      withoutTLSCfg,
+			identity: "jdoe",
+			secret:   userPassword,
+			err:      errors.New("failed to bind with the LDAP service account"),
+		},
+		{
+			desc:     "authenticate with untrusted server certificate",
+			cfg:      untrustedCfg,
+			identity: "jdoe",
+			secret:   userPassword,
+			err:      errors.New("failed to connect to LDAP server"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dir, err := ldap.NewDirectory(tc.cfg)
+			require.Nil(t, err, fmt.Sprintf("create directory unexpected error: %s", err))
+			user, err := dir.Authenticate(context.Background(), tc.identity, tc.secret)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			assert.Equal(t, tc.user, user)
+		})
+	}
+}
+
+func TestRetrieveAll(t *testing.T) {
+	cert, _ := newCert(t)
+	s := newServer(t, &tls.Config{Certificates: []tls.Certificate{cert}}, false, false)
+	dir, err := ldap.NewDirectory(newConfig("ldap://" + s.addr))
+	require.Nil(t, err, fmt.Sprintf("create directory unexpected error: %s", err))
+
+	user := users.DirectoryUser{
+		DN:        userDN,
+		Email:     "jdoe@example.com",
+		FirstName: "John",
+		LastName:  "Doe",
+		Roles:     []users.DomainRole{{DomainID: domainID, Role: "admin"}},
+	}
+	removedDN := "uid=removed,ou=people,dc=example,dc=com"
+
+	cases := []struct {
+		desc     string
+		users    []users.DirectoryUser
+		res      map[string]users.DirectoryUser
+		searches int64
+		err      error
+	}{
+		{
+			desc:     "retrieve directory users by emails",
+			users:    []users.DirectoryUser{{DN: userDN, Email: "jdoe@example.com"}},
+			res:      map[string]users.DirectoryUser{userDN: user},
+			searches: 1,
+		},
+		{
+			desc:     "retrieve directory user with changed email",
+			users:    []users.DirectoryUser{{DN: userDN, Email: "john@example.com"}},
+			res:      map[string]users.DirectoryUser{userDN: user},
+			searches: 2,
+		},
+		{
+			desc:     "retrieve directory users with removed user",
+			users:    []users.DirectoryUser{{DN: userDN, Email: "jdoe@example.com"}, {DN: removedDN, Email: "removed@example.com"}},
+			res:      map[string]users.DirectoryUser{userDN: user},
+			searches: 2,
+		},
+		{
+			desc:     "retrieve directory users without users",
+			users:    []users.DirectoryUser{},
+			res:      map[string]users.DirectoryUser{},
+			searches: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s.searches.Store(0)
+			res, err := dir.RetrieveAll(context.Background(), tc.users)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			assert.Equal(t, tc.res, res)
+			assert.Equal(t, tc.searches, s.searches.Load(), fmt.Sprintf("%s: unexpected number of searches", tc.desc))
+		})
+	}
+	assert.Equal(t, user.Roles, dir.Roles())
+}
@@ -0,0 +1,109 @@
+package users
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hantdev/mitras/pkg/errors"
+)
+
+const (
+	loginIdentityPrefix = "login:identity:"
+	loginIPPrefix       = "login:ip:"
+	resetEmailPrefix    = "reset:email:"
+	resetIPPrefix       = "reset:ip:"
+	verifyEmailPrefix   = "verify:email:"
+	verifyIPPrefix      = "verify:ip:"
+)
+
+// ErrLockedOut indicates that the failed attempt locked out the identity or
+// the client IP.
+var ErrLockedOut = errors.New("too many failed attempts, temporarily locked out")
+
+// LoginAttempts represents the failed attempts counted for the key, which is
+// the identity or the client IP of the login, the password reset or the
+// verification email request.
+type LoginAttempts struct {
+	Key         string
+	Failures    uint64
+	LastFailure time.Time
+}
+
+// LockoutConfig contains the brute-force protection settings.
+type LockoutConfig struct {
+	// MaxAttempts is the number of the failed attempts of the identity
+	// after which the identity is locked out.
+	MaxAttempts uint64
+	// MaxIPAttempts is the number of the failed attempts from the client
+	// IP after which the IP is locked out.
+	MaxIPAttempts uint64
+	// Duration is the lockout duration and the period in which the failed
+	// attempts are counted. The protection is disabled if it's zero.
+	Duration time.Duration
+	// Delay is the delay after the first failed attempt, doubled on every
+	// next failed attempt up to MaxDelay. The delay is constant if MaxDelay
+	// is lower than Delay.
+	Delay    time.Duration
+	MaxDelay time.Duration
+}
+
+// LockoutRepository specifies the failed attempts persistence API.
+//
+//go:generate mockery --name LockoutRepository --output=./mocks --filename lockout.go --quiet
+type LockoutRepository interface {
+	// Retrieve retrieves the attempts of the key failed after the given
+	// time.
+	Retrieve(ctx context.Context, key string, since time.Time) (LoginAttempts, error)
+
+	// Fail counts the failed attempt of the key and returns the attempts.
+	// The attempts failed before the given time are not counted.
+	Fail(ctx context.Context, key string, at, since time.Time) (LoginAttempts, error)
+
+	// Remove removes the attempts of the keys.
+	Remove(ctx context.Context, keys ...string) error
+}
+
+// attemptsLimit is the failed attempts key and its lockout threshold.
+type attemptsLimit struct {
+	key         string
+	maxAttempts uint64
+}
+
+func (lc LockoutConfig) enabled() bool {
+	return lc.Duration > 0
+}
+
+// limits returns the failed attempts limits of the identity and the IP.
+// The IP is empty when the client IP is unknown.
+func (lc LockoutConfig) limits(identityPrefix, identity, ipPrefix, ip string) []attemptsLimit {
+	limits := []attemptsLimit{{key: identityPrefix + strings.ToLower(identity), maxAttempts: lc.MaxAttempts}}
+	if ip != "" {
+		limits = append(limits, attemptsLimit{key: ipPrefix + ip, maxAttempts: lc.MaxIPAttempts})
+	}
+
+	return limits
+}
+
+// locked returns true if the attempts reached the lockout threshold.
+func (lc LockoutConfig) locked(a LoginAttempts, maxAttempts uint64) bool {
+	return maxAttempts > 0 && a.Failures >= maxAttempts
+}
+
+// retryAt returns the time of the next allowed attempt.
+func (lc LockoutConfig) retryAt(a LoginAttempts, maxAttempts uint64) time.Time {
+	if a.Failures == 0 {
+		return time.Time{}
+	}
+	if lc.locked(a, maxAttempts) {
+		return a.LastFailure.Add(lc.Duration)
+	}
+
+	delay := lc.Delay
+	for i := uint64(1); i < a.Failures && delay > 0 && delay < lc.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, max(lc.Delay, lc.MaxDelay))
+
+	return a.LastFailure.Add(delay)
+}
@@ -0,0 +1,88 @@
+package users
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// MFAAccessType is the access type of the token issued on login when the
+	// user has to provide the second authentication factor. The access token
+	// is the MFA challenge, which is exchanged together with the code for
+	// the access and refresh tokens.
+	MFAAccessType = "mfa"
+
+	// MFAEnrollAccessType is the access type of the token issued on login when
+	// the user's domains require MFA, but the user hasn't enrolled it yet.
+	// The MFA challenge is used to enroll and then to log in.
+	MFAEnrollAccessType = "mfa_enroll"
+)
+
+// MFA represents the user's TOTP second authentication factor.
+type MFA struct {
+	UserID string
+	// Secret is the base32 encoded TOTP secret.
+	Secret  string
+	Enabled bool
+	// RecoveryCodes are the salted hashes of the unused recovery codes.
+	RecoveryCodes []string
+	// LastUsedStep is the time step of the last accepted code, used to
+	// prevent replay of the code within its validity window.
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+// MFAEnrollment contains the data the user needs to set up the
+// authenticator application. The recovery codes are shown only once.
+type MFAEnrollment struct {
+	Secret        string   `json:"secret"`
+	URI           string   `json:"uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAChallenge represents the pending second step of the login.
+type MFAChallenge struct {
+	// ID is the hash of the MFA challenge token.
+	ID        string
+	UserID    string
+	Device    string
+	IP        string
+	Attempts  uint64
+	ExpiresAt time.Time
+}
+
+// MFARepository specifies the MFA persistence API.
+//
+//go:generate mockery --name MFARepository --output=./mocks --filename mfa.go --quiet
+type MFARepository interface {
+	// Save persists the pending MFA enrollment, replacing the previous
+	// pending one. The enabled MFA is never replaced.
+	Save(ctx context.Context, mfa MFA) error
+
+	// Retrieve retrieves the MFA of the user.
+	Retrieve(ctx context.Context, userID string) (MFA, error)
+
+	// Enable enables the pending MFA enrollment of the user.
+	Enable(ctx context.Context, userID string, step int64) error
+
+	// UseStep stores the time step of the accepted code. It returns not
+	// found error if the step is already used.
+	UseStep(ctx context.Context, userID string, step int64) error
+
+	// UseRecoveryCode removes the hash of the used recovery code. It returns
+	// not found error if the user has no such recovery code.
+	UseRecoveryCode(ctx context.Context, userID, hash string) error
+
+	// Remove removes the MFA of the user.
+	Remove(ctx context.Context, userID string) error
+
+	// SaveChallenge persists the MFA challenge and removes the expired ones.
+	SaveChallenge(ctx context.Context, challenge MFAChallenge) error
+
+	// RetrieveChallenge retrieves the unexpired MFA challenge and
+	// increments its attempts counter.
+	RetrieveChallenge(ctx context.Context, id string) (MFAChallenge, error)
+
+	// RemoveChallenge removes the MFA challenge.
+	RemoveChallenge(ctx context.Context, id string) error
+}
@@ -30,14 +30,14 @@ func AuthorizationMiddleware(svc users.Service, authz smqauthz.Authorization, se
 	}
 }
 
-func (am *authorizationMiddleware) Register(ctx context.Context, session authn.Session, user users.User, selfRegister bool) (users.User, error) {
+func (am *authorizationMiddleware) Register(ctx context.Context, session authn.Session, user users.User, selfRegister bool, invitation string) (users.User, error) {
 	if selfRegister {
 		if err := am.checkSuperAdmin(ctx, session.UserID); err == nil {
 			session.SuperAdmin = true
 		}
 	}
 
-	return am.svc.Register(ctx, session, user, selfRegister)
+	return am.svc.Register(ctx, session, user, selfRegister, invitation)
 }
 
 func (am *authorizationMiddleware) View(ctx context.Context, session authn.Session, id string) (users.User, error) {
@@ -127,8 +127,8 @@ func (am *authorizationMiddleware) UpdateProfilePicture(ctx context.Context, ses
 	return am.svc.UpdateProfilePicture(ctx, session, user)
 }
 
-func (am *authorizationMiddleware) GenerateResetToken(ctx context.Context, email, host string) error {
-	return am.svc.GenerateResetToken(ctx, email, host)
+func (am *authorizationMiddleware) GenerateResetToken(ctx context.Context, email, host, ip string) error {
+	return am.svc.GenerateResetToken(ctx, email, host, ip)
 }
 
 func (am *authorizationMiddleware) UpdateSecret(ctx context.Context, session authn.Session, oldSecret, newSecret string) (users.User, error) {
@@ -183,16 +183,148 @@ func (am *authorizationMiddleware) Identify(ctx context.Context, session authn.S
 	return am.svc.Identify(ctx, session)
 }
 
-func (am *authorizationMiddleware) IssueToken(ctx context.Context, username, secret string) (*grpcTokenV1.Token, error) {
-	return am.svc.IssueToken(ctx, username, secret)
+func (am *authorizationMiddleware) IssueToken(ctx context.Context, username, secret, device, ip string) (*grpcTokenV1.Token, error) {
+	return am.svc.IssueToken(ctx, username, secret, device, ip)
 }
 
-func (am *authorizationMiddleware) RefreshToken(ctx context.Context, session authn.Session, refreshToken string) (*grpcTokenV1.Token, error) {
-	return am.svc.RefreshToken(ctx, session, refreshToken)
+func (am *authorizationMiddleware) RefreshToken(ctx context.Context, session authn.Session, refreshToken, ip string) (*grpcTokenV1.Token, error) {
+	return am.svc.RefreshToken(ctx, session, refreshToken, ip)
 }
 
-func (am *authorizationMiddleware) OAuthCallback(ctx context.Context, user users.User) (users.User, error) {
-	return am.svc.OAuthCallback(ctx, user)
+func (am *authorizationMiddleware) IssueMFAToken(ctx context.Context, mfaToken, code string) (*grpcTokenV1.Token, error) {
+	return am.svc.IssueMFAToken(ctx, mfaToken, code)
+}
+
+func (am *authorizationMiddleware) ChangeExpiredSecret(ctx context.Context, changeToken, newSecret, device, ip string) (*grpcTokenV1.Token, error) {
+	return am.svc.ChangeExpiredSecret(ctx, changeToken, newSecret, device, ip)
+}
+
+func (am *authorizationMiddleware) PasswordPolicy(ctx context.Context) users.PasswordPolicy {
+	return am.svc.PasswordPolicy(ctx)
+}
+
+func (am *authorizationMiddleware) EnrollMFA(ctx context.Context, session authn.Session) (users.MFAEnrollment, error) {
+	return am.svc.EnrollMFA(ctx, session)
+}
+
+func (am *authorizationMiddleware) EnrollMFAWithChallenge(ctx context.Context, mfaToken string) (users.MFAEnrollment, error) {
+	return am.svc.EnrollMFAWithChallenge(ctx, mfaToken)
+}
+
+func (am *authorizationMiddleware) VerifyMFA(ctx context.Context, session authn.Session, code string) error {
+	return am.svc.VerifyMFA(ctx, session, code)
+}
+
+func (am *authorizationMiddleware) ResetMFA(ctx context.Context, session authn.Session, id string) error {
+	if err := am.checkSuperAdmin(ctx, session.UserID); err == nil {
+		session.SuperAdmin = true
+	}
+
+	return am.svc.ResetMFA(ctx, session, id)
+}
+
+func (am *authorizationMiddleware) Unlock(ctx context.Context, session authn.Session, id string) error {
+	if err := am.checkSuperAdmin(ctx, session.UserID); err == nil {
+		session.SuperAdmin = true
+	}
+
+	return am.svc.Unlock(ctx, session, id)
+}
+
+func (am *authorizationMiddleware) Impersonate(ctx context.Context, session authn.Session, id string, readOnly bool) (*grpcTokenV1.Token, error) {
+	if err := am.checkSuperAdmin(ctx, session.UserID); err == nil {
+		session.SuperAdmin = true
+	}
+
+	return am.svc.Impersonate(ctx, session, id, readOnly)
+}
+
+func (am *authorizationMiddleware) VerifyEmail(ctx context.Context, token string) error {
+	return am.svc.VerifyEmail(ctx, token)
+}
+
+func (am *authorizationMiddleware) SendVerification(ctx context.Context, email, ip string) error {
+	return am.svc.SendVerification(ctx, email, ip)
+}
+
+func (am *authorizationMiddleware) InviteUser(ctx context.Context, session authn.Session, email string) error {
+	if err := am.checkSuperAdmin(ctx, session.UserID); err == nil {
+		session.SuperAdmin = true
+	}
+
+	return am.svc.InviteUser(ctx, session, email)
+}
+
+func (am *authorizationMiddleware) CreateServiceAccount(ctx context.Context, session authn.Session, sa users.ServiceAccount) (users.ServiceAccount, string, error) {
+	if err := am.authorizeServiceAccounts(ctx, session); err != nil {
+		return users.ServiceAccount{}, "", err
+	}
+
+	return am.svc.CreateServiceAccount(ctx, session, sa)
+}
+
+func (am *authorizationMiddleware) ViewServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	if err := am.authorizeServiceAccounts(ctx, session); err != nil {
+		return users.ServiceAccount{}, err
+	}
+
+	return am.svc.ViewServiceAccount(ctx, session, id)
+}
+
+func (am *authorizationMiddleware) ListServiceAccounts(ctx context.Context, session authn.Session, pm users.Page) (users.ServiceAccountsPage, error) {
+	if err := am.authorizeServiceAccounts(ctx, session); err != nil {
+		return users.ServiceAccountsPage{}, err
+	}
+
+	return am.svc.ListServiceAccounts(ctx, session, pm)
+}
+
+func (am *authorizationMiddleware) UpdateServiceAccount(ctx context.Context, session authn.Session, sa users.ServiceAccount) (users.ServiceAccount, error) {
+	if err := am.authorizeServiceAccounts(ctx, session); err != nil {
+		return users.ServiceAccount{}, err
+	}
+
+	return am.svc.UpdateServiceAccount(ctx, session, sa)
+}
+
+func (am *authorizationMiddleware) RotateServiceAccountSecret(ctx context.Context, session authn.Session, id string) (string, error) {
+	if err := am.authorizeServiceAccounts(ctx, session); err != nil {
+		return "", err
+	}
+
+	return am.svc.RotateServiceAccountSecret(ctx, session, id)
+}
+
+func (am *authorizationMiddleware) EnableServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	if err := am.authorizeServiceAccounts(ctx, session); err != nil {
+		return users.ServiceAccount{}, err
+	}
+
+	return am.svc.EnableServiceAccount(ctx, session, id)
+}
+
+func (am *authorizationMiddleware) DisableServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	if err := am.authorizeServiceAccounts(ctx, session); err != nil {
+		return users.ServiceAccount{}, err
+	}
+
+	return am.svc.DisableServiceAccount(ctx, session, id)
+}
+
+func (am *authorizationMiddleware) DeleteServiceAccount(ctx context.Context, session authn.Session, id string) error {
+	if err := am.authorizeServiceAccounts(ctx, session); err != nil {
+		return err
+	}
+
+	return am.svc.DeleteServiceAccount(ctx, session, id)
+}
+
+func (am *authorizationMiddleware) IssueServiceAccountToken(ctx context.Context, clientID, clientSecret, assertion, ip string) (*grpcTokenV1.Token, error) {
+	return am.svc.IssueServiceAccountToken(ctx, clientID, clientSecret, assertion, ip)
+}
+
+func (am *authorizationMiddleware) OAuthCallback(ctx context.Context, user users.User, roles []users.DomainRole) (users.User, error) {
+	return am.svc.OAuthCallback(ctx, user, roles)
 }
 
 func (am *authorizationMiddleware) OAuthAddUserPolicy(ctx context.Context, user users.User) error {
@@ -202,6 +334,10 @@ func (am *authorizationMiddleware) OAuthAddUserPolicy(ctx context.Context, user
 	return am.svc.OAuthAddUserPolicy(ctx, user)
 }
 
+func (am *authorizationMiddleware) OAuthIssueToken(ctx context.Context, user users.User, device, ip string) (*grpcTokenV1.Token, error) {
+	return am.svc.OAuthIssueToken(ctx, user, device, ip)
+}
+
 func (am *authorizationMiddleware) checkSuperAdmin(ctx context.Context, adminID string) error {
 	if err := am.authz.Authorize(ctx, authz.PolicyReq{
 		SubjectType: policies.UserType,
@@ -215,6 +351,16 @@ func (am *authorizationMiddleware) checkSuperAdmin(ctx context.Context, adminID
 	return nil
 }
 
+// authorizeServiceAccounts checks that the user is the admin of the domain
+// owning the service accounts.
+func (am *authorizationMiddleware) authorizeServiceAccounts(ctx context.Context, session authn.Session) error {
+	if session.DomainUserID == "" {
+		return svcerr.ErrDomainAuthorization
+	}
+
+	return am.authorize(ctx, session.DomainID, policies.UserType, policies.UsersKind, session.DomainUserID, policies.AdminPermission, policies.DomainType, session.DomainID)
+}
+
 func (am *authorizationMiddleware) authorize(ctx context.Context, domain, subjType, subjKind, subj, perm, objType, obj string) error {
 	req := authz.PolicyReq{
 		Domain:      domain,
@@ -24,7 +24,7 @@ func LoggingMiddleware(svc users.Service, logger *slog.Logger) users.Service {
 
 // Register logs the user request. It logs the user id and the time it took to complete the request.
 // If the request fails, it logs the error.
-func (lm *loggingMiddleware) Register(ctx context.Context, session authn.Session, user users.User, selfRegister bool) (u users.User, err error) {
+func (lm *loggingMiddleware) Register(ctx context.Context, session authn.Session, user users.User, selfRegister bool, invitation string) (u users.User, err error) {
 	defer func(begin time.Time) {
 		args := []any{
 			slog.String("duration", time.Since(begin).String()),
@@ -42,12 +42,12 @@ func (lm *loggingMiddleware) Register(ctx context.Context, session authn.Session
 		args = append(args, slog.String("user_id", u.ID))
 		lm.logger.Info("Register user completed successfully", args...)
 	}(time.Now())
-	return lm.svc.Register(ctx, session, user, selfRegister)
+	return lm.svc.Register(ctx, session, user, selfRegister, invitation)
 }
 
 // IssueToken logs the issue_token request. It logs the username type and the time it took to complete the request.
 // If the request fails, it logs the error.
-func (lm *loggingMiddleware) IssueToken(ctx context.Context, username, secret string) (t *grpcTokenV1.Token, err error) {
+func (lm *loggingMiddleware) IssueToken(ctx context.Context, username, secret, device, ip string) (t *grpcTokenV1.Token, err error) {
 	defer func(begin time.Time) {
 		args := []any{
 			slog.String("duration", time.Since(begin).String()),
@@ -62,12 +62,12 @@ func (lm *loggingMiddleware) IssueToken(ctx context.Context, username, secret st
 		}
 		lm.logger.Info("Issue token completed successfully", args...)
 	}(time.Now())
-	return lm.svc.IssueToken(ctx, username, secret)
+	return lm.svc.IssueToken(ctx, username, secret, device, ip)
 }
 
 // RefreshToken logs the refresh_token request. It logs the refreshtoken, token type and the time it took to complete the request.
 // If the request fails, it logs the error.
-func (lm *loggingMiddleware) RefreshToken(ctx context.Context, session authn.Session, refreshToken string) (t *grpcTokenV1.Token, err error) {
+func (lm *loggingMiddleware) RefreshToken(ctx context.Context, session authn.Session, refreshToken, ip string) (t *grpcTokenV1.Token, err error) {
 	defer func(begin time.Time) {
 		args := []any{
 			slog.String("duration", time.Since(begin).String()),
@@ -82,7 +82,400 @@ func (lm *loggingMiddleware) RefreshToken(ctx context.Context, session authn.Ses
 		}
 		lm.logger.Info("Refresh token completed successfully", args...)
 	}(time.Now())
-	return lm.svc.RefreshToken(ctx, session, refreshToken)
+	return lm.svc.RefreshToken(ctx, session, refreshToken, ip)
+}
+
+// IssueMFAToken logs the issue_mfa_token request. It logs the token type and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) IssueMFAToken(ctx context.Context, mfaToken, code string) (t *grpcTokenV1.Token, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+		}
+		if t.AccessType != "" {
+			args = append(args, slog.String("access_type", t.AccessType))
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Issue MFA token failed", args...)
+			return
+		}
+		lm.logger.Info("Issue MFA token completed successfully", args...)
+	}(time.Now())
+	return lm.svc.IssueMFAToken(ctx, mfaToken, code)
+}
+
+// ChangeExpiredSecret logs the change_expired_secret request. It logs the token type and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) ChangeExpiredSecret(ctx context.Context, changeToken, newSecret, device, ip string) (t *grpcTokenV1.Token, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+		}
+		if t.AccessType != "" {
+			args = append(args, slog.String("access_type", t.AccessType))
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Change expired secret failed", args...)
+			return
+		}
+		lm.logger.Info("Change expired secret completed successfully", args...)
+	}(time.Now())
+	return lm.svc.ChangeExpiredSecret(ctx, changeToken, newSecret, device, ip)
+}
+
+// PasswordPolicy logs the view_password_policy request. It logs the time it took to complete the request.
+func (lm *loggingMiddleware) PasswordPolicy(ctx context.Context) users.PasswordPolicy {
+	defer func(begin time.Time) {
+		lm.logger.Info("View password policy completed successfully", slog.String("duration", time.Since(begin).String()))
+	}(time.Now())
+	return lm.svc.PasswordPolicy(ctx)
+}
+
+// EnrollMFA logs the enroll_mfa request. It logs the user id and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) EnrollMFA(ctx context.Context, session authn.Session) (e users.MFAEnrollment, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("user_id", session.UserID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Enroll MFA failed", args...)
+			return
+		}
+		lm.logger.Info("Enroll MFA completed successfully", args...)
+	}(time.Now())
+	return lm.svc.EnrollMFA(ctx, session)
+}
+
+// EnrollMFAWithChallenge logs the enroll_mfa_with_challenge request. It logs the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) EnrollMFAWithChallenge(ctx context.Context, mfaToken string) (e users.MFAEnrollment, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Enroll MFA with challenge failed", args...)
+			return
+		}
+		lm.logger.Info("Enroll MFA with challenge completed successfully", args...)
+	}(time.Now())
+	return lm.svc.EnrollMFAWithChallenge(ctx, mfaToken)
+}
+
+// VerifyMFA logs the verify_mfa request. It logs the user id and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) VerifyMFA(ctx context.Context, session authn.Session, code string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("user_id", session.UserID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Verify MFA failed", args...)
+			return
+		}
+		lm.logger.Info("Verify MFA completed successfully", args...)
+	}(time.Now())
+	return lm.svc.VerifyMFA(ctx, session, code)
+}
+
+// ResetMFA logs the reset_mfa request. It logs the user id and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) ResetMFA(ctx context.Context, session authn.Session, id string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("user_id", id),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Reset MFA failed", args...)
+			return
+		}
+		lm.logger.Info("Reset MFA completed successfully", args...)
+	}(time.Now())
+	return lm.svc.ResetMFA(ctx, session, id)
+}
+
+// Unlock logs the unlock_user request. It logs the user id and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) Unlock(ctx context.Context, session authn.Session, id string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("user_id", id),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Unlock user failed", args...)
+			return
+		}
+		lm.logger.Info("Unlock user completed successfully", args...)
+	}(time.Now())
+	return lm.svc.Unlock(ctx, session, id)
+}
+
+// Impersonate logs the impersonate request. It logs the user id, the super admin id, the access mode and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) Impersonate(ctx context.Context, session authn.Session, id string, readOnly bool) (t *grpcTokenV1.Token, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("user_id", id),
+			slog.String("impersonator_id", session.UserID),
+			slog.Bool("read_only", readOnly),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Impersonate user failed", args...)
+			return
+		}
+		lm.logger.Info("Impersonate user completed successfully", args...)
+	}(time.Now())
+	return lm.svc.Impersonate(ctx, session, id, readOnly)
+}
+
+// CreateServiceAccount logs the create_service_account request. It logs the service account id and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) CreateServiceAccount(ctx context.Context, session authn.Session, sa users.ServiceAccount) (s users.ServiceAccount, secret string, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("service_account",
+				slog.String("id", s.ID),
+				slog.String("name", s.Name),
+				slog.String("domain_id", session.DomainID),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Create service account failed", args...)
+			return
+		}
+		lm.logger.Info("Create service account completed successfully", args...)
+	}(time.Now())
+	return lm.svc.CreateServiceAccount(ctx, session, sa)
+}
+
+// ViewServiceAccount logs the view_service_account request. It logs the service account id and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) ViewServiceAccount(ctx context.Context, session authn.Session, id string) (s users.ServiceAccount, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("service_account",
+				slog.String("id", id),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("View service account failed", args...)
+			return
+		}
+		lm.logger.Info("View service account completed successfully", args...)
+	}(time.Now())
+	return lm.svc.ViewServiceAccount(ctx, session, id)
+}
+
+// ListServiceAccounts logs the list_service_accounts request. It logs the page metadata and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) ListServiceAccounts(ctx context.Context, session authn.Session, pm users.Page) (sp users.ServiceAccountsPage, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("domain_id", session.DomainID),
+			slog.Group("page",
+				slog.Uint64("limit", pm.Limit),
+				slog.Uint64("offset", pm.Offset),
+				slog.Uint64("total", sp.Total),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("List service accounts failed", args...)
+			return
+		}
+		lm.logger.Info("List service accounts completed successfully", args...)
+	}(time.Now())
+	return lm.svc.ListServiceAccounts(ctx, session, pm)
+}
+
+// UpdateServiceAccount logs the update_service_account request. It logs the service account id and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) UpdateServiceAccount(ctx context.Context, session authn.Session, sa users.ServiceAccount) (s users.ServiceAccount, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("service_account",
+				slog.String("id", sa.ID),
+				slog.String("name", s.Name),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Update service account failed", args...)
+			return
+		}
+		lm.logger.Info("Update service account completed successfully", args...)
+	}(time.Now())
+	return lm.svc.UpdateServiceAccount(ctx, session, sa)
+}
+
+// RotateServiceAccountSecret logs the rotate_service_account_secret request. It logs the service account id and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) RotateServiceAccountSecret(ctx context.Context, session authn.Session, id string) (secret string, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("service_account",
+				slog.String("id", id),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Rotate service account secret failed", args...)
+			return
+		}
+		lm.logger.Info("Rotate service account secret completed successfully", args...)
+	}(time.Now())
+	return lm.svc.RotateServiceAccountSecret(ctx, session, id)
+}
+
+// EnableServiceAccount logs the enable_service_account request. It logs the service account id and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) EnableServiceAccount(ctx context.Context, session authn.Session, id string) (s users.ServiceAccount, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("service_account",
+				slog.String("id", id),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Enable service account failed", args...)
+			return
+		}
+		lm.logger.Info("Enable service account completed successfully", args...)
+	}(time.Now())
+	return lm.svc.EnableServiceAccount(ctx, session, id)
+}
+
+// DisableServiceAccount logs the disable_service_account request. It logs the service account id and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) DisableServiceAccount(ctx context.Context, session authn.Session, id string) (s users.ServiceAccount, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("service_account",
+				slog.String("id", id),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Disable service account failed", args...)
+			return
+		}
+		lm.logger.Info("Disable service account completed successfully", args...)
+	}(time.Now())
+	return lm.svc.DisableServiceAccount(ctx, session, id)
+}
+
+// DeleteServiceAccount logs the delete_service_account request. It logs the service account id and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) DeleteServiceAccount(ctx context.Context, session authn.Session, id string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("service_account",
+				slog.String("id", id),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Delete service account failed", args...)
+			return
+		}
+		lm.logger.Info("Delete service account completed successfully", args...)
+	}(time.Now())
+	return lm.svc.DeleteServiceAccount(ctx, session, id)
+}
+
+// IssueServiceAccountToken logs the issue_service_account_token request. It logs the client id and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) IssueServiceAccountToken(ctx context.Context, clientID, clientSecret, assertion, ip string) (t *grpcTokenV1.Token, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("client_id", clientID),
+			slog.Bool("assertion", assertion != ""),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Issue service account token failed", args...)
+			return
+		}
+		lm.logger.Info("Issue service account token completed successfully", args...)
+	}(time.Now())
+	return lm.svc.IssueServiceAccountToken(ctx, clientID, clientSecret, assertion, ip)
+}
+
+// VerifyEmail logs the verify_email request. It logs the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) VerifyEmail(ctx context.Context, token string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Verify email failed", args...)
+			return
+		}
+		lm.logger.Info("Verify email completed successfully", args...)
+	}(time.Now())
+	return lm.svc.VerifyEmail(ctx, token)
+}
+
+// SendVerification logs the send_verification request. It logs the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) SendVerification(ctx context.Context, email, ip string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Send verification failed", args...)
+			return
+		}
+		lm.logger.Info("Send verification completed successfully", args...)
+	}(time.Now())
+	return lm.svc.SendVerification(ctx, email, ip)
+}
+
+// InviteUser logs the invite_user request. It logs the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) InviteUser(ctx context.Context, session authn.Session, email string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Invite user failed", args...)
+			return
+		}
+		lm.logger.Info("Invite user completed successfully", args...)
+	}(time.Now())
+	return lm.svc.InviteUser(ctx, session, email)
 }
 
 // View logs the view_user request. It logs the user id and the time it took to complete the request.
@@ -299,7 +692,7 @@ func (lm *loggingMiddleware) UpdateProfilePicture(ctx context.Context, session a
 
 // GenerateResetToken logs the generate_reset_token request. It logs the time it took to complete the request.
 // If the request fails, it logs the error.
-func (lm *loggingMiddleware) GenerateResetToken(ctx context.Context, email, host string) (err error) {
+func (lm *loggingMiddleware) GenerateResetToken(ctx context.Context, email, host, ip string) (err error) {
 	defer func(begin time.Time) {
 		args := []any{
 			slog.String("duration", time.Since(begin).String()),
@@ -312,7 +705,7 @@ func (lm *loggingMiddleware) GenerateResetToken(ctx context.Context, email, host
 		}
 		lm.logger.Info("Generate reset token completed successfully", args...)
 	}(time.Now())
-	return lm.svc.GenerateResetToken(ctx, email, host)
+	return lm.svc.GenerateResetToken(ctx, email, host, ip)
 }
 
 // ResetSecret logs the reset_secret request. It logs the time it took to complete the request.
@@ -454,11 +847,12 @@ func (lm *loggingMiddleware) Identify(ctx context.Context, session authn.Session
 	return lm.svc.Identify(ctx, session)
 }
 
-func (lm *loggingMiddleware) OAuthCallback(ctx context.Context, user users.User) (c users.User, err error) {
+func (lm *loggingMiddleware) OAuthCallback(ctx context.Context, user users.User, roles []users.DomainRole) (c users.User, err error) {
 	defer func(begin time.Time) {
 		args := []any{
 			slog.String("duration", time.Since(begin).String()),
 			slog.String("user_id", user.ID),
+			slog.Int("domain_roles", len(roles)),
 		}
 		if err != nil {
 			args = append(args, slog.Any("error", err))
@@ -467,7 +861,7 @@ func (lm *loggingMiddleware) OAuthCallback(ctx context.Context, user users.User)
 		}
 		lm.logger.Info("OAuth callback completed successfully", args...)
 	}(time.Now())
-	return lm.svc.OAuthCallback(ctx, user)
+	return lm.svc.OAuthCallback(ctx, user, roles)
 }
 
 // Delete logs the delete_user request. It logs the user id and token and the time it took to complete the request.
@@ -503,3 +897,24 @@ func (lm *loggingMiddleware) OAuthAddUserPolicy(ctx context.Context, user users.
 	}(time.Now())
 	return lm.svc.OAuthAddUserPolicy(ctx, user)
 }
+
+// OAuthIssueToken logs the oauth_issue_token request. It logs the user id, the access type and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) OAuthIssueToken(ctx context.Context, user users.User, device, ip string) (t *grpcTokenV1.Token, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("user_id", user.ID),
+		}
+		if t.GetAccessType() != "" {
+			args = append(args, slog.String("access_type", t.GetAccessType()))
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("OAuth issue token failed", args...)
+			return
+		}
+		lm.logger.Info("OAuth issue token completed successfully", args...)
+	}(time.Now())
+	return lm.svc.OAuthIssueToken(ctx, user, device, ip)
+}
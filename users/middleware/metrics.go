@@ -28,30 +28,219 @@ func MetricsMiddleware(svc users.Service, counter metrics.Counter, latency metri
 }
 
 // Register instruments Register method with metrics.
-func (ms *metricsMiddleware) Register(ctx context.Context, session authn.Session, user users.User, selfRegister bool) (users.User, error) {
+func (ms *metricsMiddleware) Register(ctx context.Context, session authn.Session, user users.User, selfRegister bool, invitation string) (users.User, error) {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "register_user").Add(1)
 		ms.latency.With("method", "register_user").Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	return ms.svc.Register(ctx, session, user, selfRegister)
+	return ms.svc.Register(ctx, session, user, selfRegister, invitation)
 }
 
 // IssueToken instruments IssueToken method with metrics.
-func (ms *metricsMiddleware) IssueToken(ctx context.Context, username, secret string) (*grpcTokenV1.Token, error) {
+func (ms *metricsMiddleware) IssueToken(ctx context.Context, username, secret, device, ip string) (*grpcTokenV1.Token, error) {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "issue_token").Add(1)
 		ms.latency.With("method", "issue_token").Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	return ms.svc.IssueToken(ctx, username, secret)
+	return ms.svc.IssueToken(ctx, username, secret, device, ip)
 }
 
 // RefreshToken instruments RefreshToken method with metrics.
-func (ms *metricsMiddleware) RefreshToken(ctx context.Context, session authn.Session, refreshToken string) (token *grpcTokenV1.Token, err error) {
+func (ms *metricsMiddleware) RefreshToken(ctx context.Context, session authn.Session, refreshToken, ip string) (token *grpcTokenV1.Token, err error) {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "refresh_token").Add(1)
 		ms.latency.With("method", "refresh_token").Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	return ms.svc.RefreshToken(ctx, session, refreshToken)
+	return ms.svc.RefreshToken(ctx, session, refreshToken, ip)
+}
+
+// IssueMFAToken instruments IssueMFAToken method with metrics.
+func (ms *metricsMiddleware) IssueMFAToken(ctx context.Context, mfaToken, code string) (*grpcTokenV1.Token, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "issue_mfa_token").Add(1)
+		ms.latency.With("method", "issue_mfa_token").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.IssueMFAToken(ctx, mfaToken, code)
+}
+
+// ChangeExpiredSecret instruments ChangeExpiredSecret method with metrics.
+func (ms *metricsMiddleware) ChangeExpiredSecret(ctx context.Context, changeToken, newSecret, device, ip string) (*grpcTokenV1.Token, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "change_expired_secret").Add(1)
+		ms.latency.With("method", "change_expired_secret").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.ChangeExpiredSecret(ctx, changeToken, newSecret, device, ip)
+}
+
+// PasswordPolicy instruments PasswordPolicy method with metrics.
+func (ms *metricsMiddleware) PasswordPolicy(ctx context.Context) users.PasswordPolicy {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "view_password_policy").Add(1)
+		ms.latency.With("method", "view_password_policy").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.PasswordPolicy(ctx)
+}
+
+// EnrollMFA instruments EnrollMFA method with metrics.
+func (ms *metricsMiddleware) EnrollMFA(ctx context.Context, session authn.Session) (users.MFAEnrollment, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "enroll_mfa").Add(1)
+		ms.latency.With("method", "enroll_mfa").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.EnrollMFA(ctx, session)
+}
+
+// EnrollMFAWithChallenge instruments EnrollMFAWithChallenge method with metrics.
+func (ms *metricsMiddleware) EnrollMFAWithChallenge(ctx context.Context, mfaToken string) (users.MFAEnrollment, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "enroll_mfa_with_challenge").Add(1)
+		ms.latency.With("method", "enroll_mfa_with_challenge").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.EnrollMFAWithChallenge(ctx, mfaToken)
+}
+
+// VerifyMFA instruments VerifyMFA method with metrics.
+func (ms *metricsMiddleware) VerifyMFA(ctx context.Context, session authn.Session, code string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "verify_mfa").Add(1)
+		ms.latency.With("method", "verify_mfa").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.VerifyMFA(ctx, session, code)
+}
+
+// ResetMFA instruments ResetMFA method with metrics.
+func (ms *metricsMiddleware) ResetMFA(ctx context.Context, session authn.Session, id string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "reset_mfa").Add(1)
+		ms.latency.With("method", "reset_mfa").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.ResetMFA(ctx, session, id)
+}
+
+// Unlock instruments Unlock method with metrics.
+func (ms *metricsMiddleware) Unlock(ctx context.Context, session authn.Session, id string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "unlock_user").Add(1)
+		ms.latency.With("method", "unlock_user").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.Unlock(ctx, session, id)
+}
+
+// Impersonate instruments Impersonate method with metrics.
+func (ms *metricsMiddleware) Impersonate(ctx context.Context, session authn.Session, id string, readOnly bool) (*grpcTokenV1.Token, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "impersonate_user").Add(1)
+		ms.latency.With("method", "impersonate_user").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.Impersonate(ctx, session, id, readOnly)
+}
+
+// CreateServiceAccount instruments CreateServiceAccount method with metrics.
+func (ms *metricsMiddleware) CreateServiceAccount(ctx context.Context, session authn.Session, sa users.ServiceAccount) (users.ServiceAccount, string, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "create_service_account").Add(1)
+		ms.latency.With("method", "create_service_account").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.CreateServiceAccount(ctx, session, sa)
+}
+
+// ViewServiceAccount instruments ViewServiceAccount method with metrics.
+func (ms *metricsMiddleware) ViewServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "view_service_account").Add(1)
+		ms.latency.With("method", "view_service_account").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.ViewServiceAccount(ctx, session, id)
+}
+
+// ListServiceAccounts instruments ListServiceAccounts method with metrics.
+func (ms *metricsMiddleware) ListServiceAccounts(ctx context.Context, session authn.Session, pm users.Page) (users.ServiceAccountsPage, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "list_service_accounts").Add(1)
+		ms.latency.With("method", "list_service_accounts").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.ListServiceAccounts(ctx, session, pm)
+}
+
+// UpdateServiceAccount instruments UpdateServiceAccount method with metrics.
+func (ms *metricsMiddleware) UpdateServiceAccount(ctx context.Context, session authn.Session, sa users.ServiceAccount) (users.ServiceAccount, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "update_service_account").Add(1)
+		ms.latency.With("method", "update_service_account").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.UpdateServiceAccount(ctx, session, sa)
+}
+
+// RotateServiceAccountSecret instruments RotateServiceAccountSecret method with metrics.
+func (ms *metricsMiddleware) RotateServiceAccountSecret(ctx context.Context, session authn.Session, id string) (string, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "rotate_service_account_secret").Add(1)
+		ms.latency.With("method", "rotate_service_account_secret").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.RotateServiceAccountSecret(ctx, session, id)
+}
+
+// EnableServiceAccount instruments EnableServiceAccount method with metrics.
+func (ms *metricsMiddleware) EnableServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "enable_service_account").Add(1)
+		ms.latency.With("method", "enable_service_account").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.EnableServiceAccount(ctx, session, id)
+}
+
+// DisableServiceAccount instruments DisableServiceAccount method with metrics.
+func (ms *metricsMiddleware) DisableServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "disable_service_account").Add(1)
+		ms.latency.With("method", "disable_service_account").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.DisableServiceAccount(ctx, session, id)
+}
+
+// DeleteServiceAccount instruments DeleteServiceAccount method with metrics.
+func (ms *metricsMiddleware) DeleteServiceAccount(ctx context.Context, session authn.Session, id string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "delete_service_account").Add(1)
+		ms.latency.With("method", "delete_service_account").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.DeleteServiceAccount(ctx, session, id)
+}
+
+// IssueServiceAccountToken instruments IssueServiceAccountToken method with metrics.
+func (ms *metricsMiddleware) IssueServiceAccountToken(ctx context.Context, clientID, clientSecret, assertion, ip string) (*grpcTokenV1.Token, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "issue_service_account_token").Add(1)
+		ms.latency.With("method", "issue_service_account_token").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.IssueServiceAccountToken(ctx, clientID, clientSecret, assertion, ip)
+}
+
+// VerifyEmail instruments VerifyEmail method with metrics.
+func (ms *metricsMiddleware) VerifyEmail(ctx context.Context, token string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "verify_email").Add(1)
+		ms.latency.With("method", "verify_email").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.VerifyEmail(ctx, token)
+}
+
+// SendVerification instruments SendVerification method with metrics.
+func (ms *metricsMiddleware) SendVerification(ctx context.Context, email, ip string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "send_verification").Add(1)
+		ms.latency.With("method", "send_verification").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.SendVerification(ctx, email, ip)
+}
+
+// InviteUser instruments InviteUser method with metrics.
+func (ms *metricsMiddleware) InviteUser(ctx context.Context, session authn.Session, email string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "invite_user").Add(1)
+		ms.latency.With("method", "invite_user").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.InviteUser(ctx, session, email)
 }
 
 // View instruments View method with metrics.
@@ -145,12 +334,12 @@ func (ms *metricsMiddleware) UpdateProfilePicture(ctx context.Context, session a
 }
 
 // GenerateResetToken instruments GenerateResetToken method with metrics.
-func (ms *metricsMiddleware) GenerateResetToken(ctx context.Context, email, host string) error {
+func (ms *metricsMiddleware) GenerateResetToken(ctx context.Context, email, host, ip string) error {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "generate_reset_token").Add(1)
 		ms.latency.With("method", "generate_reset_token").Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	return ms.svc.GenerateResetToken(ctx, email, host)
+	return ms.svc.GenerateResetToken(ctx, email, host, ip)
 }
 
 // ResetSecret instruments ResetSecret method with metrics.
@@ -217,12 +406,12 @@ func (ms *metricsMiddleware) Identify(ctx context.Context, session authn.Session
 }
 
 // OAuthCallback instruments OAuthCallback method with metrics.
-func (ms *metricsMiddleware) OAuthCallback(ctx context.Context, user users.User) (users.User, error) {
+func (ms *metricsMiddleware) OAuthCallback(ctx context.Context, user users.User, roles []users.DomainRole) (users.User, error) {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "oauth_callback").Add(1)
 		ms.latency.With("method", "oauth_callback").Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	return ms.svc.OAuthCallback(ctx, user)
+	return ms.svc.OAuthCallback(ctx, user, roles)
 }
 
 // Delete instruments Delete method with metrics.
@@ -242,3 +431,12 @@ func (ms *metricsMiddleware) OAuthAddUserPolicy(ctx context.Context, user users.
 	}(time.Now())
 	return ms.svc.OAuthAddUserPolicy(ctx, user)
 }
+
+// OAuthIssueToken instruments OAuthIssueToken method with metrics.
+func (ms *metricsMiddleware) OAuthIssueToken(ctx context.Context, user users.User, device, ip string) (*grpcTokenV1.Token, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "oauth_issue_token").Add(1)
+		ms.latency.With("method", "oauth_issue_token").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return ms.svc.OAuthIssueToken(ctx, user, device, ip)
+}
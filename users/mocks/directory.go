@@ -0,0 +1,125 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	users "github.com/hantdev/mitras/users"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Directory is an autogenerated mock type for the Directory type
+type Directory struct {
+	mock.Mock
+}
+
+// Authenticate provides a mock function with given fields: ctx, identity, secret
+func (_m *Directory) Authenticate(ctx context.Context, identity string, secret string) (users.DirectoryUser, error) {
+	ret := _m.Called(ctx, identity, secret)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Authenticate")
+	}
+
+	var r0 users.DirectoryUser
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (users.DirectoryUser, error)); ok {
+		return rf(ctx, identity, secret)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) users.DirectoryUser); ok {
+		r0 = rf(ctx, identity, secret)
+	} else {
+		r0 = ret.Get(0).(users.DirectoryUser)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, identity, secret)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Handles provides a mock function with given fields: identity
+func (_m *Directory) Handles(identity string) bool {
+	ret := _m.Called(identity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Handles")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(identity)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// RetrieveAll provides a mock function with given fields: ctx, _a1
+func (_m *Directory) RetrieveAll(ctx context.Context, _a1 []users.DirectoryUser) (map[string]users.DirectoryUser, error) {
+	ret := _m.Called(ctx, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveAll")
+	}
+
+	var r0 map[string]users.DirectoryUser
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []users.DirectoryUser) (map[string]users.DirectoryUser, error)); ok {
+		return rf(ctx, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []users.DirectoryUser) map[string]users.DirectoryUser); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]users.DirectoryUser)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []users.DirectoryUser) error); ok {
+		r1 = rf(ctx, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Roles provides a mock function with no fields
+func (_m *Directory) Roles() []users.DomainRole {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Roles")
+	}
+
+	var r0 []users.DomainRole
+	if rf, ok := ret.Get(0).(func() []users.DomainRole); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]users.DomainRole)
+		}
+	}
+
+	return r0
+}
+
+// NewDirectory creates a new instance of Directory. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewDirectory(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Directory {
+	mock := &Directory{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
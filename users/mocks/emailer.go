@@ -9,6 +9,42 @@ type Emailer struct {
 	mock.Mock
 }
 
+// SendImpersonation provides a mock function with given fields: To, user, impersonator, readOnly
+func (_m *Emailer) SendImpersonation(To []string, user string, impersonator string, readOnly bool) error {
+	ret := _m.Called(To, user, impersonator, readOnly)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendImpersonation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]string, string, string, bool) error); ok {
+		r0 = rf(To, user, impersonator, readOnly)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SendInvitation provides a mock function with given fields: To, user, token
+func (_m *Emailer) SendInvitation(To []string, user string, token string) error {
+	ret := _m.Called(To, user, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendInvitation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]string, string, string) error); ok {
+		r0 = rf(To, user, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SendPasswordReset provides a mock function with given fields: To, host, user, token
 func (_m *Emailer) SendPasswordReset(To []string, host string, user string, token string) error {
 	ret := _m.Called(To, host, user, token)
@@ -27,6 +63,24 @@ func (_m *Emailer) SendPasswordReset(To []string, host string, user string, toke
 	return r0
 }
 
+// SendVerification provides a mock function with given fields: To, user, token
+func (_m *Emailer) SendVerification(To []string, user string, token string) error {
+	ret := _m.Called(To, user, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendVerification")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]string, string, string) error); ok {
+		r0 = rf(To, user, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewEmailer creates a new instance of Emailer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewEmailer(t interface {
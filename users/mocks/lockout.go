@@ -0,0 +1,112 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+
+	users "github.com/hantdev/mitras/users"
+)
+
+// LockoutRepository is an autogenerated mock type for the LockoutRepository type
+type LockoutRepository struct {
+	mock.Mock
+}
+
+// Fail provides a mock function with given fields: ctx, key, at, since
+func (_m *LockoutRepository) Fail(ctx context.Context, key string, at time.Time, since time.Time) (users.LoginAttempts, error) {
+	ret := _m.Called(ctx, key, at, since)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Fail")
+	}
+
+	var r0 users.LoginAttempts
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) (users.LoginAttempts, error)); ok {
+		return rf(ctx, key, at, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) users.LoginAttempts); ok {
+		r0 = rf(ctx, key, at, since)
+	} else {
+		r0 = ret.Get(0).(users.LoginAttempts)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, key, at, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Remove provides a mock function with given fields: ctx, keys
+func (_m *LockoutRepository) Remove(ctx context.Context, keys ...string) error {
+	_va := make([]interface{}, len(keys))
+	for _i := range keys {
+		_va[_i] = keys[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Remove")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) error); ok {
+		r0 = rf(ctx, keys...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Retrieve provides a mock function with given fields: ctx, key, since
+func (_m *LockoutRepository) Retrieve(ctx context.Context, key string, since time.Time) (users.LoginAttempts, error) {
+	ret := _m.Called(ctx, key, since)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Retrieve")
+	}
+
+	var r0 users.LoginAttempts
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) (users.LoginAttempts, error)); ok {
+		return rf(ctx, key, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) users.LoginAttempts); ok {
+		r0 = rf(ctx, key, since)
+	} else {
+		r0 = ret.Get(0).(users.LoginAttempts)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(ctx, key, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewLockoutRepository creates a new instance of LockoutRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewLockoutRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *LockoutRepository {
+	mock := &LockoutRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
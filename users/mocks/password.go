@@ -0,0 +1,77 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	users "github.com/hantdev/mitras/users"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// PasswordRepository is an autogenerated mock type for the PasswordRepository type
+type PasswordRepository struct {
+	mock.Mock
+}
+
+// Retrieve provides a mock function with given fields: ctx, userID, limit
+func (_m *PasswordRepository) Retrieve(ctx context.Context, userID string, limit uint64) ([]users.PasswordHistory, error) {
+	ret := _m.Called(ctx, userID, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Retrieve")
+	}
+
+	var r0 []users.PasswordHistory
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint64) ([]users.PasswordHistory, error)); ok {
+		return rf(ctx, userID, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint64) []users.PasswordHistory); ok {
+		r0 = rf(ctx, userID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]users.PasswordHistory)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint64) error); ok {
+		r1 = rf(ctx, userID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, ph, keep
+func (_m *PasswordRepository) Save(ctx context.Context, ph users.PasswordHistory, keep uint64) error {
+	ret := _m.Called(ctx, ph, keep)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, users.PasswordHistory, uint64) error); ok {
+		r0 = rf(ctx, ph, keep)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewPasswordRepository creates a new instance of PasswordRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPasswordRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PasswordRepository {
+	mock := &PasswordRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
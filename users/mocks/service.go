@@ -19,6 +19,71 @@ type Service struct {
 	mock.Mock
 }
 
+// ChangeExpiredSecret provides a mock function with given fields: ctx, changeToken, newSecret, device, ip
+func (_m *Service) ChangeExpiredSecret(ctx context.Context, changeToken string, newSecret string, device string, ip string) (*v1.Token, error) {
+	ret := _m.Called(ctx, changeToken, newSecret, device, ip)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangeExpiredSecret")
+	}
+
+	var r0 *v1.Token
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) (*v1.Token, error)); ok {
+		return rf(ctx, changeToken, newSecret, device, ip)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) *v1.Token); ok {
+		r0 = rf(ctx, changeToken, newSecret, device, ip)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.Token)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
+		r1 = rf(ctx, changeToken, newSecret, device, ip)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateServiceAccount provides a mock function with given fields: ctx, session, sa
+func (_m *Service) CreateServiceAccount(ctx context.Context, session authn.Session, sa users.ServiceAccount) (users.ServiceAccount, string, error) {
+	ret := _m.Called(ctx, session, sa)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateServiceAccount")
+	}
+
+	var r0 users.ServiceAccount
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, users.ServiceAccount) (users.ServiceAccount, string, error)); ok {
+		return rf(ctx, session, sa)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, users.ServiceAccount) users.ServiceAccount); ok {
+		r0 = rf(ctx, session, sa)
+	} else {
+		r0 = ret.Get(0).(users.ServiceAccount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, users.ServiceAccount) string); ok {
+		r1 = rf(ctx, session, sa)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, authn.Session, users.ServiceAccount) error); ok {
+		r2 = rf(ctx, session, sa)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // Delete provides a mock function with given fields: ctx, session, id
 func (_m *Service) Delete(ctx context.Context, session authn.Session, id string) error {
 	ret := _m.Called(ctx, session, id)
@@ -37,6 +102,24 @@ func (_m *Service) Delete(ctx context.Context, session authn.Session, id string)
 	return r0
 }
 
+// DeleteServiceAccount provides a mock function with given fields: ctx, session, id
+func (_m *Service) DeleteServiceAccount(ctx context.Context, session authn.Session, id string) error {
+	ret := _m.Called(ctx, session, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteServiceAccount")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) error); ok {
+		r0 = rf(ctx, session, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Disable provides a mock function with given fields: ctx, session, id
 func (_m *Service) Disable(ctx context.Context, session authn.Session, id string) (users.User, error) {
 	ret := _m.Called(ctx, session, id)
@@ -65,6 +148,34 @@ func (_m *Service) Disable(ctx context.Context, session authn.Session, id string
 	return r0, r1
 }
 
+// DisableServiceAccount provides a mock function with given fields: ctx, session, id
+func (_m *Service) DisableServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	ret := _m.Called(ctx, session, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DisableServiceAccount")
+	}
+
+	var r0 users.ServiceAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) (users.ServiceAccount, error)); ok {
+		return rf(ctx, session, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) users.ServiceAccount); ok {
+		r0 = rf(ctx, session, id)
+	} else {
+		r0 = ret.Get(0).(users.ServiceAccount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, string) error); ok {
+		r1 = rf(ctx, session, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Enable provides a mock function with given fields: ctx, session, id
 func (_m *Service) Enable(ctx context.Context, session authn.Session, id string) (users.User, error) {
 	ret := _m.Called(ctx, session, id)
@@ -93,17 +204,101 @@ func (_m *Service) Enable(ctx context.Context, session authn.Session, id string)
 	return r0, r1
 }
 
-// GenerateResetToken provides a mock function with given fields: ctx, email, host
-func (_m *Service) GenerateResetToken(ctx context.Context, email string, host string) error {
-	ret := _m.Called(ctx, email, host)
+// EnableServiceAccount provides a mock function with given fields: ctx, session, id
+func (_m *Service) EnableServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	ret := _m.Called(ctx, session, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnableServiceAccount")
+	}
+
+	var r0 users.ServiceAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) (users.ServiceAccount, error)); ok {
+		return rf(ctx, session, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) users.ServiceAccount); ok {
+		r0 = rf(ctx, session, id)
+	} else {
+		r0 = ret.Get(0).(users.ServiceAccount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, string) error); ok {
+		r1 = rf(ctx, session, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EnrollMFA provides a mock function with given fields: ctx, session
+func (_m *Service) EnrollMFA(ctx context.Context, session authn.Session) (users.MFAEnrollment, error) {
+	ret := _m.Called(ctx, session)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnrollMFA")
+	}
+
+	var r0 users.MFAEnrollment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session) (users.MFAEnrollment, error)); ok {
+		return rf(ctx, session)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session) users.MFAEnrollment); ok {
+		r0 = rf(ctx, session)
+	} else {
+		r0 = ret.Get(0).(users.MFAEnrollment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session) error); ok {
+		r1 = rf(ctx, session)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EnrollMFAWithChallenge provides a mock function with given fields: ctx, mfaToken
+func (_m *Service) EnrollMFAWithChallenge(ctx context.Context, mfaToken string) (users.MFAEnrollment, error) {
+	ret := _m.Called(ctx, mfaToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnrollMFAWithChallenge")
+	}
+
+	var r0 users.MFAEnrollment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (users.MFAEnrollment, error)); ok {
+		return rf(ctx, mfaToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) users.MFAEnrollment); ok {
+		r0 = rf(ctx, mfaToken)
+	} else {
+		r0 = ret.Get(0).(users.MFAEnrollment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, mfaToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateResetToken provides a mock function with given fields: ctx, email, host, ip
+func (_m *Service) GenerateResetToken(ctx context.Context, email string, host string, ip string) error {
+	ret := _m.Called(ctx, email, host, ip)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GenerateResetToken")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = rf(ctx, email, host)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, email, host, ip)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -139,21 +334,69 @@ func (_m *Service) Identify(ctx context.Context, session authn.Session) (string,
 	return r0, r1
 }
 
-// IssueToken provides a mock function with given fields: ctx, identity, secret
-func (_m *Service) IssueToken(ctx context.Context, identity string, secret string) (*v1.Token, error) {
-	ret := _m.Called(ctx, identity, secret)
+// Impersonate provides a mock function with given fields: ctx, session, id, readOnly
+func (_m *Service) Impersonate(ctx context.Context, session authn.Session, id string, readOnly bool) (*v1.Token, error) {
+	ret := _m.Called(ctx, session, id, readOnly)
 
 	if len(ret) == 0 {
-		panic("no return value specified for IssueToken")
+		panic("no return value specified for Impersonate")
+	}
+
+	var r0 *v1.Token
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string, bool) (*v1.Token, error)); ok {
+		return rf(ctx, session, id, readOnly)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string, bool) *v1.Token); ok {
+		r0 = rf(ctx, session, id, readOnly)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.Token)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, string, bool) error); ok {
+		r1 = rf(ctx, session, id, readOnly)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InviteUser provides a mock function with given fields: ctx, session, email
+func (_m *Service) InviteUser(ctx context.Context, session authn.Session, email string) error {
+	ret := _m.Called(ctx, session, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InviteUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) error); ok {
+		r0 = rf(ctx, session, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// IssueMFAToken provides a mock function with given fields: ctx, mfaToken, code
+func (_m *Service) IssueMFAToken(ctx context.Context, mfaToken string, code string) (*v1.Token, error) {
+	ret := _m.Called(ctx, mfaToken, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IssueMFAToken")
 	}
 
 	var r0 *v1.Token
 	var r1 error
 	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*v1.Token, error)); ok {
-		return rf(ctx, identity, secret)
+		return rf(ctx, mfaToken, code)
 	}
 	if rf, ok := ret.Get(0).(func(context.Context, string, string) *v1.Token); ok {
-		r0 = rf(ctx, identity, secret)
+		r0 = rf(ctx, mfaToken, code)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*v1.Token)
@@ -161,7 +404,67 @@ func (_m *Service) IssueToken(ctx context.Context, identity string, secret strin
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, identity, secret)
+		r1 = rf(ctx, mfaToken, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IssueServiceAccountToken provides a mock function with given fields: ctx, clientID, clientSecret, assertion, ip
+func (_m *Service) IssueServiceAccountToken(ctx context.Context, clientID string, clientSecret string, assertion string, ip string) (*v1.Token, error) {
+	ret := _m.Called(ctx, clientID, clientSecret, assertion, ip)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IssueServiceAccountToken")
+	}
+
+	var r0 *v1.Token
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) (*v1.Token, error)); ok {
+		return rf(ctx, clientID, clientSecret, assertion, ip)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) *v1.Token); ok {
+		r0 = rf(ctx, clientID, clientSecret, assertion, ip)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.Token)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
+		r1 = rf(ctx, clientID, clientSecret, assertion, ip)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IssueToken provides a mock function with given fields: ctx, identity, secret, device, ip
+func (_m *Service) IssueToken(ctx context.Context, identity string, secret string, device string, ip string) (*v1.Token, error) {
+	ret := _m.Called(ctx, identity, secret, device, ip)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IssueToken")
+	}
+
+	var r0 *v1.Token
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) (*v1.Token, error)); ok {
+		return rf(ctx, identity, secret, device, ip)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) *v1.Token); ok {
+		r0 = rf(ctx, identity, secret, device, ip)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.Token)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
+		r1 = rf(ctx, identity, secret, device, ip)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -197,6 +500,34 @@ func (_m *Service) ListMembers(ctx context.Context, session authn.Session, objec
 	return r0, r1
 }
 
+// ListServiceAccounts provides a mock function with given fields: ctx, session, pm
+func (_m *Service) ListServiceAccounts(ctx context.Context, session authn.Session, pm users.Page) (users.ServiceAccountsPage, error) {
+	ret := _m.Called(ctx, session, pm)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListServiceAccounts")
+	}
+
+	var r0 users.ServiceAccountsPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, users.Page) (users.ServiceAccountsPage, error)); ok {
+		return rf(ctx, session, pm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, users.Page) users.ServiceAccountsPage); ok {
+		r0 = rf(ctx, session, pm)
+	} else {
+		r0 = ret.Get(0).(users.ServiceAccountsPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, users.Page) error); ok {
+		r1 = rf(ctx, session, pm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListUsers provides a mock function with given fields: ctx, session, pm
 func (_m *Service) ListUsers(ctx context.Context, session authn.Session, pm users.Page) (users.UsersPage, error) {
 	ret := _m.Called(ctx, session, pm)
@@ -243,9 +574,9 @@ func (_m *Service) OAuthAddUserPolicy(ctx context.Context, user users.User) erro
 	return r0
 }
 
-// OAuthCallback provides a mock function with given fields: ctx, user
-func (_m *Service) OAuthCallback(ctx context.Context, user users.User) (users.User, error) {
-	ret := _m.Called(ctx, user)
+// OAuthCallback provides a mock function with given fields: ctx, user, roles
+func (_m *Service) OAuthCallback(ctx context.Context, user users.User, roles []users.DomainRole) (users.User, error) {
+	ret := _m.Called(ctx, user, roles)
 
 	if len(ret) == 0 {
 		panic("no return value specified for OAuthCallback")
@@ -253,17 +584,17 @@ func (_m *Service) OAuthCallback(ctx context.Context, user users.User) (users.Us
 
 	var r0 users.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, users.User) (users.User, error)); ok {
-		return rf(ctx, user)
+	if rf, ok := ret.Get(0).(func(context.Context, users.User, []users.DomainRole) (users.User, error)); ok {
+		return rf(ctx, user, roles)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, users.User) users.User); ok {
-		r0 = rf(ctx, user)
+	if rf, ok := ret.Get(0).(func(context.Context, users.User, []users.DomainRole) users.User); ok {
+		r0 = rf(ctx, user, roles)
 	} else {
 		r0 = ret.Get(0).(users.User)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, users.User) error); ok {
-		r1 = rf(ctx, user)
+	if rf, ok := ret.Get(1).(func(context.Context, users.User, []users.DomainRole) error); ok {
+		r1 = rf(ctx, user, roles)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -271,9 +602,57 @@ func (_m *Service) OAuthCallback(ctx context.Context, user users.User) (users.Us
 	return r0, r1
 }
 
-// RefreshToken provides a mock function with given fields: ctx, session, refreshToken
-func (_m *Service) RefreshToken(ctx context.Context, session authn.Session, refreshToken string) (*v1.Token, error) {
-	ret := _m.Called(ctx, session, refreshToken)
+// OAuthIssueToken provides a mock function with given fields: ctx, user, device, ip
+func (_m *Service) OAuthIssueToken(ctx context.Context, user users.User, device string, ip string) (*v1.Token, error) {
+	ret := _m.Called(ctx, user, device, ip)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OAuthIssueToken")
+	}
+
+	var r0 *v1.Token
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, users.User, string, string) (*v1.Token, error)); ok {
+		return rf(ctx, user, device, ip)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, users.User, string, string) *v1.Token); ok {
+		r0 = rf(ctx, user, device, ip)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.Token)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, users.User, string, string) error); ok {
+		r1 = rf(ctx, user, device, ip)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PasswordPolicy provides a mock function with given fields: ctx
+func (_m *Service) PasswordPolicy(ctx context.Context) users.PasswordPolicy {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PasswordPolicy")
+	}
+
+	var r0 users.PasswordPolicy
+	if rf, ok := ret.Get(0).(func(context.Context) users.PasswordPolicy); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(users.PasswordPolicy)
+	}
+
+	return r0
+}
+
+// RefreshToken provides a mock function with given fields: ctx, session, refreshToken, ip
+func (_m *Service) RefreshToken(ctx context.Context, session authn.Session, refreshToken string, ip string) (*v1.Token, error) {
+	ret := _m.Called(ctx, session, refreshToken, ip)
 
 	if len(ret) == 0 {
 		panic("no return value specified for RefreshToken")
@@ -281,19 +660,19 @@ func (_m *Service) RefreshToken(ctx context.Context, session authn.Session, refr
 
 	var r0 *v1.Token
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) (*v1.Token, error)); ok {
-		return rf(ctx, session, refreshToken)
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string, string) (*v1.Token, error)); ok {
+		return rf(ctx, session, refreshToken, ip)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) *v1.Token); ok {
-		r0 = rf(ctx, session, refreshToken)
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string, string) *v1.Token); ok {
+		r0 = rf(ctx, session, refreshToken, ip)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*v1.Token)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, string) error); ok {
-		r1 = rf(ctx, session, refreshToken)
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, string, string) error); ok {
+		r1 = rf(ctx, session, refreshToken, ip)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -301,9 +680,9 @@ func (_m *Service) RefreshToken(ctx context.Context, session authn.Session, refr
 	return r0, r1
 }
 
-// Register provides a mock function with given fields: ctx, session, user, selfRegister
-func (_m *Service) Register(ctx context.Context, session authn.Session, user users.User, selfRegister bool) (users.User, error) {
-	ret := _m.Called(ctx, session, user, selfRegister)
+// Register provides a mock function with given fields: ctx, session, user, selfRegister, invitation
+func (_m *Service) Register(ctx context.Context, session authn.Session, user users.User, selfRegister bool, invitation string) (users.User, error) {
+	ret := _m.Called(ctx, session, user, selfRegister, invitation)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Register")
@@ -311,17 +690,17 @@ func (_m *Service) Register(ctx context.Context, session authn.Session, user use
 
 	var r0 users.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, users.User, bool) (users.User, error)); ok {
-		return rf(ctx, session, user, selfRegister)
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, users.User, bool, string) (users.User, error)); ok {
+		return rf(ctx, session, user, selfRegister, invitation)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, users.User, bool) users.User); ok {
-		r0 = rf(ctx, session, user, selfRegister)
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, users.User, bool, string) users.User); ok {
+		r0 = rf(ctx, session, user, selfRegister, invitation)
 	} else {
 		r0 = ret.Get(0).(users.User)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, users.User, bool) error); ok {
-		r1 = rf(ctx, session, user, selfRegister)
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, users.User, bool, string) error); ok {
+		r1 = rf(ctx, session, user, selfRegister, invitation)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -329,6 +708,24 @@ func (_m *Service) Register(ctx context.Context, session authn.Session, user use
 	return r0, r1
 }
 
+// ResetMFA provides a mock function with given fields: ctx, session, id
+func (_m *Service) ResetMFA(ctx context.Context, session authn.Session, id string) error {
+	ret := _m.Called(ctx, session, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetMFA")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) error); ok {
+		r0 = rf(ctx, session, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ResetSecret provides a mock function with given fields: ctx, session, secret
 func (_m *Service) ResetSecret(ctx context.Context, session authn.Session, secret string) error {
 	ret := _m.Called(ctx, session, secret)
@@ -347,6 +744,34 @@ func (_m *Service) ResetSecret(ctx context.Context, session authn.Session, secre
 	return r0
 }
 
+// RotateServiceAccountSecret provides a mock function with given fields: ctx, session, id
+func (_m *Service) RotateServiceAccountSecret(ctx context.Context, session authn.Session, id string) (string, error) {
+	ret := _m.Called(ctx, session, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RotateServiceAccountSecret")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) (string, error)); ok {
+		return rf(ctx, session, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) string); ok {
+		r0 = rf(ctx, session, id)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, string) error); ok {
+		r1 = rf(ctx, session, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SearchUsers provides a mock function with given fields: ctx, pm
 func (_m *Service) SearchUsers(ctx context.Context, pm users.Page) (users.UsersPage, error) {
 	ret := _m.Called(ctx, pm)
@@ -393,6 +818,42 @@ func (_m *Service) SendPasswordReset(ctx context.Context, host string, email str
 	return r0
 }
 
+// SendVerification provides a mock function with given fields: ctx, email, ip
+func (_m *Service) SendVerification(ctx context.Context, email string, ip string) error {
+	ret := _m.Called(ctx, email, ip)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendVerification")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, email, ip)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Unlock provides a mock function with given fields: ctx, session, id
+func (_m *Service) Unlock(ctx context.Context, session authn.Session, id string) error {
+	ret := _m.Called(ctx, session, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Unlock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) error); ok {
+		r0 = rf(ctx, session, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: ctx, session, user
 func (_m *Service) Update(ctx context.Context, session authn.Session, user users.User) (users.User, error) {
 	ret := _m.Called(ctx, session, user)
@@ -533,6 +994,34 @@ func (_m *Service) UpdateSecret(ctx context.Context, session authn.Session, oldS
 	return r0, r1
 }
 
+// UpdateServiceAccount provides a mock function with given fields: ctx, session, sa
+func (_m *Service) UpdateServiceAccount(ctx context.Context, session authn.Session, sa users.ServiceAccount) (users.ServiceAccount, error) {
+	ret := _m.Called(ctx, session, sa)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateServiceAccount")
+	}
+
+	var r0 users.ServiceAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, users.ServiceAccount) (users.ServiceAccount, error)); ok {
+		return rf(ctx, session, sa)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, users.ServiceAccount) users.ServiceAccount); ok {
+		r0 = rf(ctx, session, sa)
+	} else {
+		r0 = ret.Get(0).(users.ServiceAccount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, users.ServiceAccount) error); ok {
+		r1 = rf(ctx, session, sa)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateTags provides a mock function with given fields: ctx, session, user
 func (_m *Service) UpdateTags(ctx context.Context, session authn.Session, user users.User) (users.User, error) {
 	ret := _m.Called(ctx, session, user)
@@ -589,6 +1078,42 @@ func (_m *Service) UpdateUsername(ctx context.Context, session authn.Session, id
 	return r0, r1
 }
 
+// VerifyEmail provides a mock function with given fields: ctx, token
+func (_m *Service) VerifyEmail(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyEmail")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyMFA provides a mock function with given fields: ctx, session, code
+func (_m *Service) VerifyMFA(ctx context.Context, session authn.Session, code string) error {
+	ret := _m.Called(ctx, session, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyMFA")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) error); ok {
+		r0 = rf(ctx, session, code)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // View provides a mock function with given fields: ctx, session, id
 func (_m *Service) View(ctx context.Context, session authn.Session, id string) (users.User, error) {
 	ret := _m.Called(ctx, session, id)
@@ -645,6 +1170,34 @@ func (_m *Service) ViewProfile(ctx context.Context, session authn.Session) (user
 	return r0, r1
 }
 
+// ViewServiceAccount provides a mock function with given fields: ctx, session, id
+func (_m *Service) ViewServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	ret := _m.Called(ctx, session, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ViewServiceAccount")
+	}
+
+	var r0 users.ServiceAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) (users.ServiceAccount, error)); ok {
+		return rf(ctx, session, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authn.Session, string) users.ServiceAccount); ok {
+		r0 = rf(ctx, session, id)
+	} else {
+		r0 = ret.Get(0).(users.ServiceAccount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authn.Session, string) error); ok {
+		r1 = rf(ctx, session, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewService creates a new instance of Service. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewService(t interface {
@@ -0,0 +1,215 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	users "github.com/hantdev/mitras/users"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ServiceAccountRepository is an autogenerated mock type for the ServiceAccountRepository type
+type ServiceAccountRepository struct {
+	mock.Mock
+}
+
+// ChangeStatus provides a mock function with given fields: ctx, sa
+func (_m *ServiceAccountRepository) ChangeStatus(ctx context.Context, sa users.ServiceAccount) (users.ServiceAccount, error) {
+	ret := _m.Called(ctx, sa)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangeStatus")
+	}
+
+	var r0 users.ServiceAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, users.ServiceAccount) (users.ServiceAccount, error)); ok {
+		return rf(ctx, sa)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, users.ServiceAccount) users.ServiceAccount); ok {
+		r0 = rf(ctx, sa)
+	} else {
+		r0 = ret.Get(0).(users.ServiceAccount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, users.ServiceAccount) error); ok {
+		r1 = rf(ctx, sa)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *ServiceAccountRepository) Delete(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RetrieveAll provides a mock function with given fields: ctx, domainID, pm
+func (_m *ServiceAccountRepository) RetrieveAll(ctx context.Context, domainID string, pm users.Page) (users.ServiceAccountsPage, error) {
+	ret := _m.Called(ctx, domainID, pm)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveAll")
+	}
+
+	var r0 users.ServiceAccountsPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, users.Page) (users.ServiceAccountsPage, error)); ok {
+		return rf(ctx, domainID, pm)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, users.Page) users.ServiceAccountsPage); ok {
+		r0 = rf(ctx, domainID, pm)
+	} else {
+		r0 = ret.Get(0).(users.ServiceAccountsPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, users.Page) error); ok {
+		r1 = rf(ctx, domainID, pm)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RetrieveByID provides a mock function with given fields: ctx, id
+func (_m *ServiceAccountRepository) RetrieveByID(ctx context.Context, id string) (users.ServiceAccount, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveByID")
+	}
+
+	var r0 users.ServiceAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (users.ServiceAccount, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) users.ServiceAccount); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(users.ServiceAccount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, sa
+func (_m *ServiceAccountRepository) Save(ctx context.Context, sa users.ServiceAccount) (users.ServiceAccount, error) {
+	ret := _m.Called(ctx, sa)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Save")
+	}
+
+	var r0 users.ServiceAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, users.ServiceAccount) (users.ServiceAccount, error)); ok {
+		return rf(ctx, sa)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, users.ServiceAccount) users.ServiceAccount); ok {
+		r0 = rf(ctx, sa)
+	} else {
+		r0 = ret.Get(0).(users.ServiceAccount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, users.ServiceAccount) error); ok {
+		r1 = rf(ctx, sa)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, sa
+func (_m *ServiceAccountRepository) Update(ctx context.Context, sa users.ServiceAccount) (users.ServiceAccount, error) {
+	ret := _m.Called(ctx, sa)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Update")
+	}
+
+	var r0 users.ServiceAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, users.ServiceAccount) (users.ServiceAccount, error)); ok {
+		return rf(ctx, sa)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, users.ServiceAccount) users.ServiceAccount); ok {
+		r0 = rf(ctx, sa)
+	} else {
+		r0 = ret.Get(0).(users.ServiceAccount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, users.ServiceAccount) error); ok {
+		r1 = rf(ctx, sa)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateSecret provides a mock function with given fields: ctx, sa
+func (_m *ServiceAccountRepository) UpdateSecret(ctx context.Context, sa users.ServiceAccount) (users.ServiceAccount, error) {
+	ret := _m.Called(ctx, sa)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateSecret")
+	}
+
+	var r0 users.ServiceAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, users.ServiceAccount) (users.ServiceAccount, error)); ok {
+		return rf(ctx, sa)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, users.ServiceAccount) users.ServiceAccount); ok {
+		r0 = rf(ctx, sa)
+	} else {
+		r0 = ret.Get(0).(users.ServiceAccount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, users.ServiceAccount) error); ok {
+		r1 = rf(ctx, sa)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewServiceAccountRepository creates a new instance of ServiceAccountRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewServiceAccountRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ServiceAccountRepository {
+	mock := &ServiceAccountRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,145 @@
+package users
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hantdev/mitras/pkg/errors"
+)
+
+// PasswordChangeAccessType is the access type of the token issued on login
+// when the password expired. The token is exchanged for the access token
+// together with the new password.
+const PasswordChangeAccessType = "password_change"
+
+const (
+	passwordChangePurpose  = "password_change"
+	passwordChangeDuration = 10 * time.Minute
+)
+
+var (
+	errPasswordLength    = errors.New("password is too short")
+	errPasswordUpper     = errors.New("password must contain an uppercase letter")
+	errPasswordLower     = errors.New("password must contain a lowercase letter")
+	errPasswordDigit     = errors.New("password must contain a digit")
+	errPasswordSymbol    = errors.New("password must contain a symbol")
+	errPasswordDenied    = errors.New("password is too common or known to be breached")
+	errPasswordReused    = errors.New("password was used recently")
+	errInvalidPassChange = errors.New("invalid or expired password change token")
+	errPasswordHistory   = errors.New("failed to check password history")
+)
+
+// PasswordPolicy contains the rules the user passwords must satisfy.
+type PasswordPolicy struct {
+	// MinLength is the minimum number of the password characters.
+	MinLength int
+	// The password must contain at least one character of each of the
+	// required character classes.
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// Denylist contains the common and the breached passwords, lowercased.
+	Denylist map[string]struct{}
+	// History is the number of the last passwords that can't be reused.
+	History uint64
+	// MaxAge is the password validity, after which the user has to change
+	// the password on login. The passwords never expire if it's zero.
+	MaxAge time.Duration
+}
+
+// Validate returns the first rule the password doesn't satisfy. The reuse
+// of the previous passwords is checked by the service.
+func (pp PasswordPolicy) Validate(password string) error {
+	if utf8.RuneCountInString(password) < pp.MinLength {
+		return errPasswordLength
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r), unicode.IsSymbol(r), unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	switch {
+	case pp.RequireUpper && !upper:
+		return errPasswordUpper
+	case pp.RequireLower && !lower:
+		return errPasswordLower
+	case pp.RequireDigit && !digit:
+		return errPasswordDigit
+	case pp.RequireSymbol && !symbol:
+		return errPasswordSymbol
+	}
+
+	if _, ok := pp.Denylist[strings.ToLower(password)]; ok {
+		return errPasswordDenied
+	}
+
+	return nil
+}
+
+// LoadDenylist reads the denied passwords from the file with one password
+// per line. The empty lines and the lines starting with "#" are skipped.
+func LoadDenylist(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	denylist := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		denylist[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return denylist, nil
+}
+
+// PasswordHistory represents the hash of the password the user had.
+type PasswordHistory struct {
+	UserID    string
+	Secret    string
+	CreatedAt time.Time
+}
+
+// PasswordRepository specifies the password history persistence API.
+//
+//go:generate mockery --name PasswordRepository --output=./mocks --filename password.go --quiet
+type PasswordRepository interface {
+	// Save saves the password and removes the user's passwords older than
+	// the last keep passwords.
+	Save(ctx context.Context, ph PasswordHistory, keep uint64) error
+
+	// Retrieve retrieves the user's last passwords, the newest first.
+	Retrieve(ctx context.Context, userID string, limit uint64) ([]PasswordHistory, error)
+}
+
+// secretFingerprint binds the password change token to the current password
+// hash, so the token can't be used once the password is changed.
+func secretFingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:8])
+}
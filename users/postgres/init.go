@@ -83,6 +83,93 @@ func Migration() *migrate.MemoryMigrationSource {
 					`ALTER TABLE users ALTER COLUMN last_name SET DEFAULT ''`,
 				},
 			},
+			{
+				Id: "clients_06",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS users_mfa (
+						user_id         VARCHAR(36) PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+						secret          TEXT NOT NULL,
+						enabled         BOOLEAN NOT NULL DEFAULT FALSE,
+						recovery_codes  TEXT[] NOT NULL DEFAULT '{}',
+						last_used_step  BIGINT NOT NULL DEFAULT 0,
+						created_at      TIMESTAMP NOT NULL
+					)`,
+					`CREATE TABLE IF NOT EXISTS mfa_challenges (
+						id          VARCHAR(64) PRIMARY KEY,
+						user_id     VARCHAR(36) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+						device      TEXT NOT NULL DEFAULT '',
+						ip          VARCHAR(45) NOT NULL DEFAULT '',
+						attempts    BIGINT NOT NULL DEFAULT 0,
+						expires_at  TIMESTAMP NOT NULL
+					)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS mfa_challenges`,
+					`DROP TABLE IF EXISTS users_mfa`,
+				},
+			},
+			{
+				Id: "clients_07",
+				Up: []string{
+					`ALTER TABLE users ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP`,
+					// Users registered before the email verification are verified.
+					`UPDATE users SET verified_at = created_at WHERE verified_at IS NULL`,
+				},
+				Down: []string{
+					`ALTER TABLE users DROP COLUMN IF EXISTS verified_at`,
+				},
+			},
+			{
+				Id: "clients_08",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS login_attempts (
+						key           VARCHAR(512) PRIMARY KEY,
+						failures      BIGINT NOT NULL DEFAULT 0,
+						last_failure  TIMESTAMP NOT NULL
+					)`,
+					`CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failure ON login_attempts (last_failure)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS login_attempts`,
+				},
+			},
+			{
+				Id: "clients_09",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS password_history (
+						user_id     VARCHAR(36) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+						secret      TEXT NOT NULL,
+						created_at  TIMESTAMP NOT NULL
+					)`,
+					`CREATE INDEX IF NOT EXISTS idx_password_history_user_id ON password_history (user_id, created_at DESC)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS password_history`,
+				},
+			},
+			{
+				Id: "clients_10",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS service_accounts (
+						id          VARCHAR(36) PRIMARY KEY,
+						domain_id   VARCHAR(36) NOT NULL,
+						name        VARCHAR(254) NOT NULL,
+						description VARCHAR(1024),
+						metadata    JSONB,
+						status      SMALLINT NOT NULL DEFAULT 0 CHECK (status >= 0),
+						secret      TEXT NOT NULL,
+						public_key  TEXT,
+						created_at  TIMESTAMP NOT NULL,
+						created_by  VARCHAR(254),
+						updated_at  TIMESTAMP,
+						updated_by  VARCHAR(254),
+						UNIQUE (domain_id, name)
+					)`,
+				},
+				Down: []string{
+					`DROP TABLE IF EXISTS service_accounts`,
+				},
+			},
 		},
 	}
 }
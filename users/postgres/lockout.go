@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/hantdev/mitras/pkg/postgres"
+	"github.com/hantdev/mitras/users"
+	"github.com/jackc/pgtype"
+)
+
+var _ users.LockoutRepository = (*lockoutRepo)(nil)
+
+type lockoutRepo struct {
+	db postgres.Database
+}
+
+// NewLockoutRepository instantiates a PostgreSQL implementation of the failed
+// attempts repository.
+func NewLockoutRepository(db postgres.Database) users.LockoutRepository {
+	return &lockoutRepo{
+		db: db,
+	}
+}
+
+func (repo *lockoutRepo) Retrieve(ctx context.Context, key string, since time.Time) (users.LoginAttempts, error) {
+	q := `SELECT key, failures, last_failure FROM login_attempts WHERE key = $1 AND last_failure > $2`
+
+	var dba dbLoginAttempts
+	if err := repo.db.QueryRowxContext(ctx, q, key, since).StructScan(&dba); err != nil {
+		if err == sql.ErrNoRows {
+			return users.LoginAttempts{}, repoerr.ErrNotFound
+		}
+		return users.LoginAttempts{}, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+
+	return users.LoginAttempts(dba), nil
+}
+
+func (repo *lockoutRepo) Fail(ctx context.Context, key string, at, since time.Time) (users.LoginAttempts, error) {
+	if _, err := repo.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE last_failure <= $1`, since); err != nil {
+		return users.LoginAttempts{}, postgres.HandleError(repoerr.ErrUpdateEntity, err)
+	}
+
+	q := `INSERT INTO login_attempts (key, failures, last_failure) VALUES ($1, 1, $2)
+	      ON CONFLICT (key) DO UPDATE SET failures = login_attempts.failures + 1, last_failure = EXCLUDED.last_failure
+	      RETURNING key, failures, last_failure`
+
+	var dba dbLoginAttempts
+	if err := repo.db.QueryRowxContext(ctx, q, key, at).StructScan(&dba); err != nil {
+		return users.LoginAttempts{}, postgres.HandleError(repoerr.ErrUpdateEntity, err)
+	}
+
+	return users.LoginAttempts(dba), nil
+}
+
+func (repo *lockoutRepo) Remove(ctx context.Context, keys ...string) error {
+	var ks pgtype.TextArray
+	if err := ks.Set(keys); err != nil {
+		return postgres.HandleError(repoerr.ErrRemoveEntity, err)
+	}
+	if _, err := repo.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE key = ANY($1)`, ks); err != nil {
+		return postgres.HandleError(repoerr.ErrRemoveEntity, err)
+	}
+
+	return nil
+}
+
+type dbLoginAttempts struct {
+	Key         string    `db:"key"`
+	Failures    uint64    `db:"failures"`
+	LastFailure time.Time `db:"last_failure"`
+}
@@ -0,0 +1,52 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/hantdev/mitras/users"
+	cpostgres "github.com/hantdev/mitras/users/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginAttempts(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := db.Exec("DELETE FROM login_attempts")
+		require.Nil(t, err, fmt.Sprintf("clean login attempts unexpected error: %s", err))
+	})
+
+	repo := cpostgres.NewLockoutRepository(database)
+	ctx := context.Background()
+	key := "login:identity:username"
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	since := now.Add(-time.Minute)
+
+	_, err := repo.Retrieve(ctx, key, since)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+
+	// The attempt failed before the counted period is dropped.
+	_, err = repo.Fail(ctx, key, since.Add(-time.Second), since.Add(-time.Minute))
+	assert.Nil(t, err, fmt.Sprintf("fail attempt unexpected error: %s", err))
+
+	for i := uint64(1); i <= 2; i++ {
+		attempts, err := repo.Fail(ctx, key, now, since)
+		assert.Nil(t, err, fmt.Sprintf("fail attempt unexpected error: %s", err))
+		assert.Equal(t, users.LoginAttempts{Key: key, Failures: i, LastFailure: now}, attempts)
+	}
+
+	attempts, err := repo.Retrieve(ctx, key, since)
+	assert.Nil(t, err, fmt.Sprintf("retrieve attempts unexpected error: %s", err))
+	assert.Equal(t, uint64(2), attempts.Failures)
+	_, err = repo.Retrieve(ctx, key, now)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+
+	err = repo.Remove(ctx, key, "login:ip:192.168.0.1")
+	assert.Nil(t, err, fmt.Sprintf("remove attempts unexpected error: %s", err))
+	_, err = repo.Retrieve(ctx, key, since)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+}
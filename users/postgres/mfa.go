@@ -0,0 +1,259 @@
+package postgres
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"time"
+
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/hantdev/mitras/pkg/postgres"
+	"github.com/hantdev/mitras/users"
+	"github.com/jackc/pgtype"
+)
+
+var errDecryptSecret = errors.New("failed to decrypt MFA secret")
+
+var _ users.MFARepository = (*mfaRepo)(nil)
+
+type mfaRepo struct {
+	db  postgres.Database
+	key [sha256.Size]byte
+}
+
+// NewMFARepository instantiates a PostgreSQL implementation of MFA
+// repository. The TOTP secrets are stored encrypted with AES-GCM, using the
+// AES-256 key derived from the given key.
+func NewMFARepository(db postgres.Database, key []byte) users.MFARepository {
+	return &mfaRepo{
+		db:  db,
+		key: sha256.Sum256(key),
+	}
+}
+
+func (repo *mfaRepo) Save(ctx context.Context, mfa users.MFA) error {
+	q := `INSERT INTO users_mfa (user_id, secret, enabled, recovery_codes, last_used_step, created_at)
+	      VALUES (:user_id, :secret, FALSE, :recovery_codes, 0, :created_at)
+	      ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, recovery_codes = EXCLUDED.recovery_codes,
+	      last_used_step = 0, created_at = EXCLUDED.created_at
+	      WHERE users_mfa.enabled = FALSE`
+
+	dbm, err := toDBMFA(mfa)
+	if err != nil {
+		return postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+	if dbm.Secret, err = repo.encrypt(mfa.UserID, mfa.Secret); err != nil {
+		return errors.Wrap(repoerr.ErrCreateEntity, err)
+	}
+	result, err := repo.db.NamedExecContext(ctx, q, dbm)
+	if err != nil {
+		return postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return repoerr.ErrConflict
+	}
+
+	return nil
+}
+
+func (repo *mfaRepo) Retrieve(ctx context.Context, userID string) (users.MFA, error) {
+	q := `SELECT user_id, secret, enabled, recovery_codes, last_used_step, created_at FROM users_mfa WHERE user_id = $1`
+
+	var dbm dbMFA
+	if err := repo.db.QueryRowxContext(ctx, q, userID).StructScan(&dbm); err != nil {
+		if err == sql.ErrNoRows {
+			return users.MFA{}, repoerr.ErrNotFound
+		}
+		return users.MFA{}, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+	secret, err := repo.decrypt(dbm.UserID, dbm.Secret)
+	if err != nil {
+		return users.MFA{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+	dbm.Secret = secret
+
+	return toMFA(dbm), nil
+}
+
+func (repo *mfaRepo) Enable(ctx context.Context, userID string, step int64) error {
+	q := `UPDATE users_mfa SET enabled = TRUE, last_used_step = $2 WHERE user_id = $1 AND enabled = FALSE`
+
+	return repo.update(ctx, q, userID, step)
+}
+
+func (repo *mfaRepo) UseStep(ctx context.Context, userID string, step int64) error {
+	q := `UPDATE users_mfa SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+
+	return repo.update(ctx, q, userID, step)
+}
+
+func (repo *mfaRepo) UseRecoveryCode(ctx context.Context, userID, hash string) error {
+	q := `UPDATE users_mfa SET recovery_codes = array_remove(recovery_codes, $2)
+	      WHERE user_id = $1 AND enabled AND $2 = ANY(recovery_codes)`
+
+	return repo.update(ctx, q, userID, hash)
+}
+
+func (repo *mfaRepo) Remove(ctx context.Context, userID string) error {
+	q := `DELETE FROM users_mfa WHERE user_id = $1`
+
+	result, err := repo.db.ExecContext(ctx, q, userID)
+	if err != nil {
+		return postgres.HandleError(repoerr.ErrRemoveEntity, err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo *mfaRepo) SaveChallenge(ctx context.Context, challenge users.MFAChallenge) error {
+	if _, err := repo.db.ExecContext(ctx, `DELETE FROM mfa_challenges WHERE expires_at <= $1`, time.Now().UTC()); err != nil {
+		return postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+
+	q := `INSERT INTO mfa_challenges (id, user_id, device, ip, attempts, expires_at)
+	      VALUES (:id, :user_id, :device, :ip, 0, :expires_at)`
+	if _, err := repo.db.NamedExecContext(ctx, q, dbMFAChallenge(challenge)); err != nil {
+		return postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+func (repo *mfaRepo) RetrieveChallenge(ctx context.Context, id string) (users.MFAChallenge, error) {
+	q := `UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = $1 AND expires_at > $2
+	      RETURNING id, user_id, device, ip, attempts, expires_at`
+
+	var dbc dbMFAChallenge
+	if err := repo.db.QueryRowxContext(ctx, q, id, time.Now().UTC()).StructScan(&dbc); err != nil {
+		if err == sql.ErrNoRows {
+			return users.MFAChallenge{}, repoerr.ErrNotFound
+		}
+		return users.MFAChallenge{}, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+
+	return users.MFAChallenge(dbc), nil
+}
+
+func (repo *mfaRepo) RemoveChallenge(ctx context.Context, id string) error {
+	if _, err := repo.db.ExecContext(ctx, `DELETE FROM mfa_challenges WHERE id = $1`, id); err != nil {
+		return postgres.HandleError(repoerr.ErrRemoveEntity, err)
+	}
+
+	return nil
+}
+
+// encrypt returns the base64 encoded nonce and the encrypted secret. The
+// user ID is authenticated with the secret, so the secret can't be moved to
+// another user.
+func (repo *mfaRepo) encrypt(userID, secret string) (string, error) {
+	aead, err := repo.aead()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(secret)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(secret), []byte(userID))), nil
+}
+
+func (repo *mfaRepo) decrypt(userID, secret string) (string, error) {
+	aead, err := repo.aead()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", errDecryptSecret
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(userID))
+	if err != nil {
+		return "", errors.Wrap(errDecryptSecret, err)
+	}
+
+	return string(plain), nil
+}
+
+func (repo *mfaRepo) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(repo.key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (repo *mfaRepo) update(ctx context.Context, q string, args ...interface{}) error {
+	result, err := repo.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return postgres.HandleError(repoerr.ErrUpdateEntity, err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
+type dbMFA struct {
+	UserID        string           `db:"user_id"`
+	Secret        string           `db:"secret"`
+	Enabled       bool             `db:"enabled"`
+	RecoveryCodes pgtype.TextArray `db:"recovery_codes"`
+	LastUsedStep  int64            `db:"last_used_step"`
+	CreatedAt     time.Time        `db:"created_at"`
+}
+
+type dbMFAChallenge struct {
+	ID        string    `db:"id"`
+	UserID    string    `db:"user_id"`
+	Device    string    `db:"device"`
+	IP        string    `db:"ip"`
+	Attempts  uint64    `db:"attempts"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+func toDBMFA(mfa users.MFA) (dbMFA, error) {
+	codes := mfa.RecoveryCodes
+	if codes == nil {
+		codes = []string{}
+	}
+	var rc pgtype.TextArray
+	if err := rc.Set(codes); err != nil {
+		return dbMFA{}, err
+	}
+
+	return dbMFA{
+		UserID:        mfa.UserID,
+		Secret:        mfa.Secret,
+		Enabled:       mfa.Enabled,
+		RecoveryCodes: rc,
+		LastUsedStep:  mfa.LastUsedStep,
+		CreatedAt:     mfa.CreatedAt,
+	}, nil
+}
+
+func toMFA(dbm dbMFA) users.MFA {
+	codes := []string{}
+	for _, e := range dbm.RecoveryCodes.Elements {
+		codes = append(codes, e.String)
+	}
+
+	return users.MFA{
+		UserID:        dbm.UserID,
+		Secret:        dbm.Secret,
+		Enabled:       dbm.Enabled,
+		RecoveryCodes: codes,
+		LastUsedStep:  dbm.LastUsedStep,
+		CreatedAt:     dbm.CreatedAt,
+	}
+}
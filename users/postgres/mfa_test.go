@@ -0,0 +1,138 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/internal/testsutil"
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/hantdev/mitras/users"
+	"github.com/hantdev/mitras/users/hasher"
+	cpostgres "github.com/hantdev/mitras/users/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mfaKey = "mfa-key"
+
+func TestMFA(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := db.Exec("DELETE FROM users")
+		require.Nil(t, err, fmt.Sprintf("clean users unexpected error: %s", err))
+	})
+
+	repo := cpostgres.NewMFARepository(database, []byte(mfaKey))
+	user := generateUser(t, users.EnabledStatus, cpostgres.NewRepository(database))
+	ctx := context.Background()
+
+	_, err := repo.Retrieve(ctx, user.ID)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+
+	first, err := hasher.New().Hash("abcdefghij")
+	require.Nil(t, err, fmt.Sprintf("hash recovery code unexpected error: %s", err))
+	second, err := hasher.New().Hash("klmnopqrst")
+	require.Nil(t, err, fmt.Sprintf("hash recovery code unexpected error: %s", err))
+	mfa := users.MFA{
+		UserID:        user.ID,
+		Secret:        "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		RecoveryCodes: []string{first, second},
+		CreatedAt:     time.Now().UTC().Truncate(time.Microsecond),
+	}
+	err = repo.Save(ctx, users.MFA{UserID: user.ID, Secret: "pending", CreatedAt: mfa.CreatedAt})
+	assert.Nil(t, err, fmt.Sprintf("save MFA unexpected error: %s", err))
+
+	// The pending enrollment is replaced.
+	err = repo.Save(ctx, mfa)
+	assert.Nil(t, err, fmt.Sprintf("save MFA unexpected error: %s", err))
+	saved, err := repo.Retrieve(ctx, user.ID)
+	assert.Nil(t, err, fmt.Sprintf("retrieve MFA unexpected error: %s", err))
+	assert.Equal(t, mfa, saved)
+
+	// The secret is stored encrypted, and can't be read using another key.
+	var stored string
+	err = db.QueryRow("SELECT secret FROM users_mfa WHERE user_id = $1", user.ID).Scan(&stored)
+	require.Nil(t, err, fmt.Sprintf("select MFA secret unexpected error: %s", err))
+	assert.NotContains(t, stored, mfa.Secret)
+	_, err = cpostgres.NewMFARepository(database, []byte("other")).Retrieve(ctx, user.ID)
+	assert.True(t, errors.Contains(err, repoerr.ErrViewEntity), fmt.Sprintf("expected error %s, got %s", repoerr.ErrViewEntity, err))
+
+	err = repo.UseRecoveryCode(ctx, user.ID, first)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+
+	err = repo.Enable(ctx, user.ID, 10)
+	assert.Nil(t, err, fmt.Sprintf("enable MFA unexpected error: %s", err))
+	err = repo.Enable(ctx, user.ID, 11)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+
+	// The enabled MFA is never replaced.
+	err = repo.Save(ctx, mfa)
+	assert.True(t, errors.Contains(err, repoerr.ErrConflict), fmt.Sprintf("expected error %s, got %s", repoerr.ErrConflict, err))
+
+	err = repo.UseStep(ctx, user.ID, 10)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+	err = repo.UseStep(ctx, user.ID, 11)
+	assert.Nil(t, err, fmt.Sprintf("use step unexpected error: %s", err))
+
+	err = repo.UseRecoveryCode(ctx, user.ID, first)
+	assert.Nil(t, err, fmt.Sprintf("use recovery code unexpected error: %s", err))
+	err = repo.UseRecoveryCode(ctx, user.ID, first)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+
+	saved, err = repo.Retrieve(ctx, user.ID)
+	assert.Nil(t, err, fmt.Sprintf("retrieve MFA unexpected error: %s", err))
+	assert.True(t, saved.Enabled)
+	assert.Equal(t, int64(11), saved.LastUsedStep)
+	assert.Equal(t, []string{second}, saved.RecoveryCodes)
+
+	err = repo.Remove(ctx, user.ID)
+	assert.Nil(t, err, fmt.Sprintf("remove MFA unexpected error: %s", err))
+	err = repo.Remove(ctx, user.ID)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+}
+
+func TestMFAChallenges(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := db.Exec("DELETE FROM users")
+		require.Nil(t, err, fmt.Sprintf("clean users unexpected error: %s", err))
+	})
+
+	repo := cpostgres.NewMFARepository(database, []byte(mfaKey))
+	user := generateUser(t, users.EnabledStatus, cpostgres.NewRepository(database))
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	challenge := users.MFAChallenge{
+		ID:        testsutil.GenerateUUID(t),
+		UserID:    user.ID,
+		Device:    "Mozilla/5.0",
+		IP:        "192.168.0.1",
+		ExpiresAt: now.Add(time.Minute),
+	}
+	expired := users.MFAChallenge{
+		ID:        testsutil.GenerateUUID(t),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(-time.Minute),
+	}
+	for _, c := range []users.MFAChallenge{expired, challenge} {
+		err := repo.SaveChallenge(ctx, c)
+		assert.Nil(t, err, fmt.Sprintf("save challenge unexpected error: %s", err))
+	}
+
+	_, err := repo.RetrieveChallenge(ctx, expired.ID)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+
+	for i := uint64(1); i <= 2; i++ {
+		saved, err := repo.RetrieveChallenge(ctx, challenge.ID)
+		assert.Nil(t, err, fmt.Sprintf("retrieve challenge unexpected error: %s", err))
+		challenge.Attempts = i
+		assert.Equal(t, challenge, saved)
+	}
+
+	err = repo.RemoveChallenge(ctx, challenge.ID)
+	assert.Nil(t, err, fmt.Sprintf("remove challenge unexpected error: %s", err))
+	_, err = repo.RetrieveChallenge(ctx, challenge.ID)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("expected error %s, got %s", repoerr.ErrNotFound, err))
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/hantdev/mitras/pkg/postgres"
+	"github.com/hantdev/mitras/users"
+)
+
+var _ users.PasswordRepository = (*passwordRepo)(nil)
+
+type passwordRepo struct {
+	db postgres.Database
+}
+
+// NewPasswordRepository instantiates a PostgreSQL implementation of the
+// password history repository.
+func NewPasswordRepository(db postgres.Database) users.PasswordRepository {
+	return &passwordRepo{
+		db: db,
+	}
+}
+
+func (repo *passwordRepo) Save(ctx context.Context, ph users.PasswordHistory, keep uint64) error {
+	q := `INSERT INTO password_history (user_id, secret, created_at) VALUES (:user_id, :secret, :created_at)`
+	if _, err := repo.db.NamedExecContext(ctx, q, dbPasswordHistory(ph)); err != nil {
+		return postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+
+	q = `DELETE FROM password_history WHERE user_id = $1 AND ctid NOT IN (
+	       SELECT ctid FROM password_history WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2
+	     )`
+	if _, err := repo.db.ExecContext(ctx, q, ph.UserID, keep); err != nil {
+		return postgres.HandleError(repoerr.ErrRemoveEntity, err)
+	}
+
+	return nil
+}
+
+func (repo *passwordRepo) Retrieve(ctx context.Context, userID string, limit uint64) ([]users.PasswordHistory, error) {
+	q := `SELECT user_id, secret, created_at FROM password_history WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+
+	rows, err := repo.db.QueryxContext(ctx, q, userID, limit)
+	if err != nil {
+		return nil, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	history := []users.PasswordHistory{}
+	for rows.Next() {
+		var dbph dbPasswordHistory
+		if err := rows.StructScan(&dbph); err != nil {
+			return nil, postgres.HandleError(repoerr.ErrViewEntity, err)
+		}
+		history = append(history, users.PasswordHistory(dbph))
+	}
+
+	return history, nil
+}
+
+type dbPasswordHistory struct {
+	UserID    string    `db:"user_id"`
+	Secret    string    `db:"secret"`
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -0,0 +1,49 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/users"
+	cpostgres "github.com/hantdev/mitras/users/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordHistory(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := db.Exec("DELETE FROM users")
+		require.Nil(t, err, fmt.Sprintf("clean users unexpected error: %s", err))
+	})
+
+	repo := cpostgres.NewPasswordRepository(database)
+	user := generateUser(t, users.EnabledStatus, cpostgres.NewRepository(database))
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	history, err := repo.Retrieve(ctx, user.ID, 3)
+	assert.Nil(t, err, fmt.Sprintf("retrieve password history unexpected error: %s", err))
+	assert.Empty(t, history)
+
+	saved := []users.PasswordHistory{}
+	for i := 0; i < 4; i++ {
+		ph := users.PasswordHistory{UserID: user.ID, Secret: fmt.Sprintf("hash-%d", i), CreatedAt: now.Add(time.Duration(i) * time.Minute)}
+		err := repo.Save(ctx, ph, 3)
+		assert.Nil(t, err, fmt.Sprintf("save password unexpected error: %s", err))
+		saved = append([]users.PasswordHistory{ph}, saved...)
+	}
+
+	// Only the last 3 passwords are kept.
+	history, err = repo.Retrieve(ctx, user.ID, 5)
+	assert.Nil(t, err, fmt.Sprintf("retrieve password history unexpected error: %s", err))
+	assert.Equal(t, saved[:3], history)
+
+	history, err = repo.Retrieve(ctx, user.ID, 1)
+	assert.Nil(t, err, fmt.Sprintf("retrieve password history unexpected error: %s", err))
+	assert.Equal(t, saved[:1], history)
+
+	err = repo.Save(ctx, users.PasswordHistory{UserID: "unknown", Secret: "hash", CreatedAt: now}, 3)
+	assert.NotNil(t, err, "save password of unknown user expected error")
+}
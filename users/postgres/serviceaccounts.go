@@ -0,0 +1,231 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/hantdev/mitras/pkg/postgres"
+	"github.com/hantdev/mitras/users"
+)
+
+const serviceAccountColumns = `id, domain_id, name, description, metadata, status, secret, public_key,
+	created_at, created_by, updated_at, updated_by`
+
+var _ users.ServiceAccountRepository = (*serviceAccountRepo)(nil)
+
+type serviceAccountRepo struct {
+	db postgres.Database
+}
+
+// NewServiceAccountRepository instantiates a PostgreSQL implementation of
+// the service account repository.
+func NewServiceAccountRepository(db postgres.Database) users.ServiceAccountRepository {
+	return &serviceAccountRepo{
+		db: db,
+	}
+}
+
+func (repo *serviceAccountRepo) Save(ctx context.Context, sa users.ServiceAccount) (users.ServiceAccount, error) {
+	q := `INSERT INTO service_accounts (id, domain_id, name, description, metadata, status, secret, public_key, created_at, created_by)
+	      VALUES (:id, :domain_id, :name, :description, :metadata, :status, :secret, :public_key, :created_at, :created_by)
+	      RETURNING ` + serviceAccountColumns
+
+	return repo.query(ctx, q, sa, repoerr.ErrCreateEntity)
+}
+
+func (repo *serviceAccountRepo) RetrieveByID(ctx context.Context, id string) (users.ServiceAccount, error) {
+	q := `SELECT ` + serviceAccountColumns + ` FROM service_accounts WHERE id = :id`
+
+	return repo.query(ctx, q, users.ServiceAccount{ID: id}, repoerr.ErrViewEntity)
+}
+
+func (repo *serviceAccountRepo) RetrieveAll(ctx context.Context, domainID string, pm users.Page) (users.ServiceAccountsPage, error) {
+	query := `WHERE domain_id = :domain_id`
+	if pm.Status != users.AllStatus {
+		query += ` AND status = :status`
+	}
+	page := dbServiceAccountsPage{
+		DomainID: domainID,
+		Status:   pm.Status,
+		Limit:    pm.Limit,
+		Offset:   pm.Offset,
+	}
+
+	q := `SELECT ` + serviceAccountColumns + ` FROM service_accounts ` + query + ` ORDER BY created_at LIMIT :limit OFFSET :offset`
+	rows, err := repo.db.NamedQueryContext(ctx, q, page)
+	if err != nil {
+		return users.ServiceAccountsPage{}, postgres.HandleError(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	items := []users.ServiceAccount{}
+	for rows.Next() {
+		var dbsa dbServiceAccount
+		if err := rows.StructScan(&dbsa); err != nil {
+			return users.ServiceAccountsPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		sa, err := toServiceAccount(dbsa)
+		if err != nil {
+			return users.ServiceAccountsPage{}, err
+		}
+		items = append(items, sa)
+	}
+
+	total, err := postgres.Total(ctx, repo.db, `SELECT COUNT(*) FROM service_accounts `+query, page)
+	if err != nil {
+		return users.ServiceAccountsPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return users.ServiceAccountsPage{
+		Page: users.Page{
+			Total:  total,
+			Offset: pm.Offset,
+			Limit:  pm.Limit,
+		},
+		ServiceAccounts: items,
+	}, nil
+}
+
+func (repo *serviceAccountRepo) Update(ctx context.Context, sa users.ServiceAccount) (users.ServiceAccount, error) {
+	q := `UPDATE service_accounts SET name = :name, description = :description, metadata = :metadata, public_key = :public_key,
+	      updated_at = :updated_at, updated_by = :updated_by
+	      WHERE id = :id
+	      RETURNING ` + serviceAccountColumns
+
+	return repo.query(ctx, q, sa, repoerr.ErrUpdateEntity)
+}
+
+func (repo *serviceAccountRepo) UpdateSecret(ctx context.Context, sa users.ServiceAccount) (users.ServiceAccount, error) {
+	q := `UPDATE service_accounts SET secret = :secret, updated_at = :updated_at, updated_by = :updated_by
+	      WHERE id = :id
+	      RETURNING ` + serviceAccountColumns
+
+	return repo.query(ctx, q, sa, repoerr.ErrUpdateEntity)
+}
+
+func (repo *serviceAccountRepo) ChangeStatus(ctx context.Context, sa users.ServiceAccount) (users.ServiceAccount, error) {
+	q := `UPDATE service_accounts SET status = :status, updated_at = :updated_at, updated_by = :updated_by
+	      WHERE id = :id
+	      RETURNING ` + serviceAccountColumns
+
+	return repo.query(ctx, q, sa, repoerr.ErrUpdateEntity)
+}
+
+func (repo *serviceAccountRepo) Delete(ctx context.Context, id string) error {
+	result, err := repo.db.ExecContext(ctx, `DELETE FROM service_accounts WHERE id = $1`, id)
+	if err != nil {
+		return postgres.HandleError(repoerr.ErrRemoveEntity, err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
+// query runs the query returning the single service account.
+func (repo *serviceAccountRepo) query(ctx context.Context, q string, sa users.ServiceAccount, errOp error) (users.ServiceAccount, error) {
+	dbsa, err := toDBServiceAccount(sa)
+	if err != nil {
+		return users.ServiceAccount{}, errors.Wrap(errOp, err)
+	}
+	rows, err := repo.db.NamedQueryContext(ctx, q, dbsa)
+	if err != nil {
+		return users.ServiceAccount{}, postgres.HandleError(errOp, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return users.ServiceAccount{}, repoerr.ErrNotFound
+	}
+	dbsa = dbServiceAccount{}
+	if err := rows.StructScan(&dbsa); err != nil {
+		return users.ServiceAccount{}, postgres.HandleError(errOp, err)
+	}
+
+	return toServiceAccount(dbsa)
+}
+
+type dbServiceAccount struct {
+	ID          string         `db:"id"`
+	DomainID    string         `db:"domain_id"`
+	Name        string         `db:"name"`
+	Description sql.NullString `db:"description"`
+	Metadata    []byte         `db:"metadata"`
+	Status      users.Status   `db:"status"`
+	Secret      string         `db:"secret"`
+	PublicKey   sql.NullString `db:"public_key"`
+	CreatedAt   time.Time      `db:"created_at"`
+	CreatedBy   sql.NullString `db:"created_by"`
+	UpdatedAt   sql.NullTime   `db:"updated_at"`
+	UpdatedBy   sql.NullString `db:"updated_by"`
+}
+
+type dbServiceAccountsPage struct {
+	DomainID string       `db:"domain_id"`
+	Status   users.Status `db:"status"`
+	Limit    uint64       `db:"limit"`
+	Offset   uint64       `db:"offset"`
+}
+
+func toDBServiceAccount(sa users.ServiceAccount) (dbServiceAccount, error) {
+	data := []byte("{}")
+	if len(sa.Metadata) > 0 {
+		b, err := json.Marshal(sa.Metadata)
+		if err != nil {
+			return dbServiceAccount{}, errors.Wrap(repoerr.ErrMalformedEntity, err)
+		}
+		data = b
+	}
+	var updatedAt sql.NullTime
+	if !sa.UpdatedAt.IsZero() {
+		updatedAt = sql.NullTime{Time: sa.UpdatedAt, Valid: true}
+	}
+
+	return dbServiceAccount{
+		ID:          sa.ID,
+		DomainID:    sa.DomainID,
+		Name:        sa.Name,
+		Description: stringToNullString(sa.Description),
+		Metadata:    data,
+		Status:      sa.Status,
+		Secret:      sa.Secret,
+		PublicKey:   stringToNullString(sa.PublicKey),
+		CreatedAt:   sa.CreatedAt,
+		CreatedBy:   stringToNullString(sa.CreatedBy),
+		UpdatedAt:   updatedAt,
+		UpdatedBy:   stringToNullString(sa.UpdatedBy),
+	}, nil
+}
+
+func toServiceAccount(dbsa dbServiceAccount) (users.ServiceAccount, error) {
+	var metadata users.Metadata
+	if dbsa.Metadata != nil {
+		if err := json.Unmarshal(dbsa.Metadata, &metadata); err != nil {
+			return users.ServiceAccount{}, errors.Wrap(repoerr.ErrMalformedEntity, err)
+		}
+	}
+	var updatedAt time.Time
+	if dbsa.UpdatedAt.Valid {
+		updatedAt = dbsa.UpdatedAt.Time
+	}
+
+	return users.ServiceAccount{
+		ID:          dbsa.ID,
+		DomainID:    dbsa.DomainID,
+		Name:        dbsa.Name,
+		Description: nullStringString(dbsa.Description),
+		Metadata:    metadata,
+		Status:      dbsa.Status,
+		Secret:      dbsa.Secret,
+		PublicKey:   nullStringString(dbsa.PublicKey),
+		CreatedAt:   dbsa.CreatedAt,
+		CreatedBy:   nullStringString(dbsa.CreatedBy),
+		UpdatedAt:   updatedAt,
+		UpdatedBy:   nullStringString(dbsa.UpdatedBy),
+	}, nil
+}
@@ -0,0 +1,93 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hantdev/mitras/internal/testsutil"
+	"github.com/hantdev/mitras/pkg/errors"
+	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
+	"github.com/hantdev/mitras/users"
+	cpostgres "github.com/hantdev/mitras/users/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAccounts(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := db.Exec("DELETE FROM service_accounts")
+		require.Nil(t, err, fmt.Sprintf("clean service accounts unexpected error: %s", err))
+	})
+
+	repo := cpostgres.NewServiceAccountRepository(database)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	domainID := testsutil.GenerateUUID(t)
+
+	sa := users.ServiceAccount{
+		ID:          testsutil.GenerateUUID(t),
+		DomainID:    domainID,
+		Name:        "ci",
+		Description: "CI pipeline",
+		Metadata:    users.Metadata{"team": "platform"},
+		Status:      users.EnabledStatus,
+		Secret:      "hash",
+		CreatedAt:   now,
+		CreatedBy:   testsutil.GenerateUUID(t),
+	}
+	saved, err := repo.Save(ctx, sa)
+	assert.Nil(t, err, fmt.Sprintf("save service account unexpected error: %s", err))
+	assert.Equal(t, sa, saved)
+
+	dup := sa
+	dup.ID = testsutil.GenerateUUID(t)
+	_, err = repo.Save(ctx, dup)
+	assert.True(t, errors.Contains(err, repoerr.ErrConflict), fmt.Sprintf("save service account with duplicate name: expected %s got %s", repoerr.ErrConflict, err))
+
+	other := sa
+	other.ID = testsutil.GenerateUUID(t)
+	other.DomainID = testsutil.GenerateUUID(t)
+	_, err = repo.Save(ctx, other)
+	assert.Nil(t, err, fmt.Sprintf("save service account of other domain unexpected error: %s", err))
+
+	retrieved, err := repo.RetrieveByID(ctx, sa.ID)
+	assert.Nil(t, err, fmt.Sprintf("retrieve service account unexpected error: %s", err))
+	assert.Equal(t, sa, retrieved)
+
+	_, err = repo.RetrieveByID(ctx, testsutil.GenerateUUID(t))
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("retrieve unknown service account: expected %s got %s", repoerr.ErrNotFound, err))
+
+	page, err := repo.RetrieveAll(ctx, domainID, users.Page{Limit: 10, Status: users.AllStatus})
+	assert.Nil(t, err, fmt.Sprintf("retrieve service accounts unexpected error: %s", err))
+	assert.Equal(t, uint64(1), page.Total)
+	assert.Equal(t, []users.ServiceAccount{sa}, page.ServiceAccounts)
+
+	sa.Name = "deploy"
+	sa.PublicKey = "public key"
+	sa.UpdatedAt = now.Add(time.Minute)
+	sa.UpdatedBy = sa.CreatedBy
+	updated, err := repo.Update(ctx, sa)
+	assert.Nil(t, err, fmt.Sprintf("update service account unexpected error: %s", err))
+	assert.Equal(t, sa, updated)
+
+	sa.Secret = "new hash"
+	updated, err = repo.UpdateSecret(ctx, sa)
+	assert.Nil(t, err, fmt.Sprintf("update service account secret unexpected error: %s", err))
+	assert.Equal(t, sa, updated)
+
+	sa.Status = users.DisabledStatus
+	updated, err = repo.ChangeStatus(ctx, sa)
+	assert.Nil(t, err, fmt.Sprintf("change service account status unexpected error: %s", err))
+	assert.Equal(t, sa, updated)
+
+	page, err = repo.RetrieveAll(ctx, domainID, users.Page{Limit: 10, Status: users.EnabledStatus})
+	assert.Nil(t, err, fmt.Sprintf("retrieve enabled service accounts unexpected error: %s", err))
+	assert.Equal(t, uint64(0), page.Total)
+
+	err = repo.Delete(ctx, sa.ID)
+	assert.Nil(t, err, fmt.Sprintf("delete service account unexpected error: %s", err))
+	err = repo.Delete(ctx, sa.ID)
+	assert.True(t, errors.Contains(err, repoerr.ErrNotFound), fmt.Sprintf("delete deleted service account: expected %s got %s", repoerr.ErrNotFound, err))
+}
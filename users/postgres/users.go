@@ -28,9 +28,9 @@ func NewRepository(db postgres.Database) users.Repository {
 }
 
 func (repo *userRepo) Save(ctx context.Context, c users.User) (users.User, error) {
-	q := `INSERT INTO users (id, tags, email, secret, metadata, created_at, status, role, first_name, last_name, username, profile_picture)
-        VALUES (:id, :tags, :email, :secret, :metadata, :created_at, :status, :role, :first_name, :last_name, :username, :profile_picture)
-        RETURNING id, tags, email, metadata, created_at, status, role, first_name, last_name, username, profile_picture`
+	q := `INSERT INTO users (id, tags, email, secret, metadata, created_at, status, role, first_name, last_name, username, profile_picture, verified_at)
+        VALUES (:id, :tags, :email, :secret, :metadata, :created_at, :status, :role, :first_name, :last_name, :username, :profile_picture, :verified_at)
+        RETURNING id, tags, email, metadata, created_at, status, role, first_name, last_name, username, profile_picture, verified_at`
 
 	dbu, err := toDBUser(c)
 	if err != nil {
@@ -78,7 +78,7 @@ func (repo *userRepo) CheckSuperAdmin(ctx context.Context, adminID string) error
 }
 
 func (repo *userRepo) RetrieveByID(ctx context.Context, id string) (users.User, error) {
-	q := `SELECT id, tags, email, secret, metadata, created_at, updated_at, updated_by, status, role, first_name, last_name, username, profile_picture
+	q := `SELECT id, tags, email, secret, metadata, created_at, updated_at, updated_by, status, role, first_name, last_name, username, profile_picture, verified_at
         FROM users WHERE id = :id`
 
 	dbu := DBUser{
@@ -115,7 +115,7 @@ func (repo *userRepo) RetrieveAll(ctx context.Context, pm users.Page) (users.Use
 	}
 
 	q := fmt.Sprintf(`SELECT u.id, u.tags, u.email, u.metadata, u.status, u.role, u.first_name, u.last_name, u.username,
-    u.created_at, u.updated_at, u.profile_picture, COALESCE(u.updated_by, '') AS updated_by
+    u.created_at, u.updated_at, u.profile_picture, COALESCE(u.updated_by, '') AS updated_by, u.verified_at
     FROM users u %s ORDER BY u.created_at LIMIT :limit OFFSET :offset;`, query)
 
 	dbPage, err := ToDBUsersPage(pm)
@@ -275,6 +275,14 @@ func (repo *userRepo) ChangeStatus(ctx context.Context, user users.User) (users.
 	return repo.update(ctx, user, q)
 }
 
+func (repo *userRepo) VerifyEmail(ctx context.Context, user users.User) (users.User, error) {
+	q := `UPDATE users SET verified_at = :verified_at
+        WHERE id = :id AND email = :email AND verified_at IS NULL
+        RETURNING id, tags, email, metadata, status, created_at, updated_at, updated_by, first_name, last_name, username, verified_at`
+
+	return repo.update(ctx, user, q)
+}
+
 func (repo *userRepo) Delete(ctx context.Context, id string) error {
 	q := "DELETE FROM users AS u  WHERE u.id = $1 ;"
 
@@ -404,7 +412,7 @@ func (repo *userRepo) RetrieveAllByIDs(ctx context.Context, pm users.Page) (user
 }
 
 func (repo *userRepo) RetrieveByEmail(ctx context.Context, email string) (users.User, error) {
-	q := `SELECT id, tags, email, secret, metadata, created_at, updated_at, updated_by, status, role, first_name, last_name, username
+	q := `SELECT id, tags, email, secret, metadata, created_at, updated_at, updated_by, status, role, first_name, last_name, username, verified_at
         FROM users WHERE email = :email AND status = :status`
 
 	dbu := DBUser{
@@ -431,7 +439,7 @@ func (repo *userRepo) RetrieveByEmail(ctx context.Context, email string) (users.
 }
 
 func (repo *userRepo) RetrieveByUsername(ctx context.Context, username string) (users.User, error) {
-	q := `SELECT id, tags, email, secret, metadata, created_at, updated_at, updated_by, status, role, first_name, last_name, username
+	q := `SELECT id, tags, email, secret, metadata, created_at, updated_at, updated_by, status, role, first_name, last_name, username, verified_at
 		FROM users WHERE username = :username AND status = :status`
 
 	dbu := DBUser{
@@ -474,6 +482,7 @@ type DBUser struct {
 	LastName       sql.NullString   `db:"last_name, omitempty"`
 	ProfilePicture sql.NullString   `db:"profile_picture, omitempty"`
 	Email          string           `db:"email,omitempty"`
+	VerifiedAt     sql.NullTime     `db:"verified_at,omitempty"`
 }
 
 func toDBUser(u users.User) (DBUser, error) {
@@ -497,6 +506,10 @@ func toDBUser(u users.User) (DBUser, error) {
 	if u.UpdatedAt != (time.Time{}) {
 		updatedAt = sql.NullTime{Time: u.UpdatedAt, Valid: true}
 	}
+	var verifiedAt sql.NullTime
+	if !u.VerifiedAt.IsZero() {
+		verifiedAt = sql.NullTime{Time: u.VerifiedAt, Valid: true}
+	}
 
 	return DBUser{
 		ID:             u.ID,
@@ -513,6 +526,7 @@ func toDBUser(u users.User) (DBUser, error) {
 		Username:       stringToNullString(u.Credentials.Username),
 		ProfilePicture: stringToNullString(u.ProfilePicture),
 		Email:          u.Email,
+		VerifiedAt:     verifiedAt,
 	}, nil
 }
 
@@ -535,6 +549,10 @@ func ToUser(dbu DBUser) (users.User, error) {
 	if dbu.UpdatedAt.Valid {
 		updatedAt = dbu.UpdatedAt.Time
 	}
+	var verifiedAt time.Time
+	if dbu.VerifiedAt.Valid {
+		verifiedAt = dbu.VerifiedAt.Time
+	}
 
 	user := users.User{
 		ID:        dbu.ID,
@@ -552,6 +570,7 @@ func ToUser(dbu DBUser) (users.User, error) {
 		Status:         dbu.Status,
 		Tags:           tags,
 		ProfilePicture: nullStringString(dbu.ProfilePicture),
+		VerifiedAt:     verifiedAt,
 	}
 	if dbu.Role != nil {
 		user.Role = *dbu.Role
@@ -629,6 +648,9 @@ func PageQuery(pm users.Page) (string, error) {
 	if mq != "" {
 		query = append(query, mq)
 	}
+	if pm.Directory {
+		query = append(query, "u.metadata->>'directory_dn' IS NOT NULL")
+	}
 
 	if len(pm.IDs) != 0 {
 		query = append(query, fmt.Sprintf("id IN ('%s')", strings.Join(pm.IDs, "','")))
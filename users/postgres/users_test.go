@@ -1430,6 +1430,56 @@ func TestUpdateSecret(t *testing.T) {
 	}
 }
 
+func TestVerifyEmail(t *testing.T) {
+	t.Cleanup(func() {
+		_, err := db.Exec("DELETE FROM users")
+		require.Nil(t, err, fmt.Sprintf("clean users unexpected error: %s", err))
+	})
+	repo := cpostgres.NewRepository(database)
+
+	user1 := generateUser(t, users.EnabledStatus, repo)
+	user2 := generateUser(t, users.EnabledStatus, repo)
+
+	cases := []struct {
+		desc string
+		user users.User
+		err  error
+	}{
+		{
+			desc: "for unverified user",
+			user: users.User{ID: user1.ID, Email: user1.Email},
+			err:  nil,
+		},
+		{
+			desc: "for verified user",
+			user: users.User{ID: user1.ID, Email: user1.Email},
+			err:  repoerr.ErrNotFound,
+		},
+		{
+			desc: "for changed email",
+			user: users.User{ID: user2.ID, Email: user1.Email},
+			err:  repoerr.ErrNotFound,
+		},
+		{
+			desc: "for invalid user",
+			user: users.User{ID: testsutil.GenerateUUID(t), Email: user1.Email},
+			err:  repoerr.ErrNotFound,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			c.user.VerifiedAt = time.Now().UTC().Truncate(time.Millisecond)
+			_, err := repo.VerifyEmail(context.Background(), c.user)
+			assert.True(t, errors.Contains(err, c.err), fmt.Sprintf("expected %s to contain %s\n", err, c.err))
+			if err == nil {
+				rc, err := repo.RetrieveByID(context.Background(), c.user.ID)
+				require.Nil(t, err, fmt.Sprintf("retrieve user by id during email verification unexpected error: %s", err))
+				assert.Equal(t, c.user.VerifiedAt, rc.VerifiedAt)
+			}
+		})
+	}
+}
+
 func TestChangeStatus(t *testing.T) {
 	t.Cleanup(func() {
 		_, err := db.Exec("DELETE FROM users")
@@ -2,11 +2,17 @@ package users
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
 	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/hantdev/mitras"
 	smqauth "github.com/hantdev/mitras/auth"
+	grpcDomainsV1 "github.com/hantdev/mitras/internal/grpc/domains/v1"
 	grpcTokenV1 "github.com/hantdev/mitras/internal/grpc/token/v1"
 	"github.com/hantdev/mitras/pkg/apiutil"
 	"github.com/hantdev/mitras/pkg/authn"
@@ -14,6 +20,7 @@ import (
 	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
 	svcerr "github.com/hantdev/mitras/pkg/errors/service"
 	"github.com/hantdev/mitras/pkg/policies"
+	"github.com/hantdev/mitras/pkg/totp"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -22,102 +29,906 @@ var (
 	errFailedPermissionsList = errors.New("failed to list permissions")
 	errRecoveryToken         = errors.New("failed to generate password recovery token")
 	errLoginDisableUser      = errors.New("failed to login in disabled user")
+	errRevokeSessions        = errors.New("failed to revoke user sessions")
+	errMFAEnabled            = errors.New("multi-factor authentication is already enabled")
+	errMFANotEnrolled        = errors.New("multi-factor authentication is not enrolled")
+	errInvalidMFACode        = errors.New("invalid multi-factor authentication code")
+	errInvalidMFAToken       = errors.New("invalid or expired multi-factor authentication token")
+	errMFAAttempts           = errors.New("too many multi-factor authentication attempts")
+	errEmailNotVerified      = errors.New("email is not verified")
+	errEmailVerified         = errors.New("email is already verified")
+	errInvalidVerification   = errors.New("invalid or expired email verification token")
+	errSendVerification      = errors.New("failed to send verification email")
+	errInvalidInvitation     = errors.New("invalid or expired registration invitation")
+	errInvitationRequired    = errors.New("registration requires an invitation")
+	errEmailDomain           = errors.New("email domain is not allowed to register")
+	errSendInvitation        = errors.New("failed to send registration invitation")
+	errUserExists            = errors.New("user with the email already exists")
+	errTooManyAttempts       = errors.New("too many failed attempts, try again later")
+	errLockout               = errors.New("failed to check failed attempts")
+	errDomainRole            = errors.New("failed to add user domain role")
+	errImpersonateAdmin      = errors.New("super admins can't be impersonated")
+	errNestedImpersonation   = errors.New("impersonated user can't impersonate")
+	errSendImpersonation     = errors.New("failed to notify impersonated user")
+	errOAuthLink             = errors.New("user with the email is not linked to the OAuth identity")
+)
+
+// The metadata keys which bind the user to the external identity. They're
+// managed by the service, so the users can't change them.
+const (
+	directoryDNKey   = "directory_dn"
+	oauthProviderKey = "oauth_provider"
+	oauthSubjectKey  = "oauth_subject"
+)
+
+var identityKeys = []string{directoryDNKey, oauthProviderKey, oauthSubjectKey}
+
+const (
+	mfaIssuer            = "Mitras"
+	mfaChallengeDuration = 5 * time.Minute
+	mfaMaxAttempts       = 5
+	recoveryCodesCount   = 10
+	recoveryCodeLen      = 10
 )
 
 type service struct {
-	token      grpcTokenV1.TokenServiceClient
-	users      Repository
-	idProvider mitras.IDProvider
-	policies   policies.Service
-	hasher     Hasher
-	email      Emailer
+	token           grpcTokenV1.TokenServiceClient
+	users           Repository
+	mfa             MFARepository
+	lockout         LockoutRepository
+	passwords       PasswordRepository
+	serviceAccounts ServiceAccountRepository
+	domains         grpcDomainsV1.DomainsServiceClient
+	idProvider      mitras.IDProvider
+	directory       Directory
+	policies        policies.Service
+	hasher          Hasher
+	email           Emailer
+	registration    RegistrationConfig
+	lockoutCfg      LockoutConfig
+	passwordCfg     PasswordPolicy
+}
+
+// NewService returns a new Users service implementation. The directory is
+// optional, the users authenticate only with their local passwords if it's nil.
+func NewService(token grpcTokenV1.TokenServiceClient, urepo Repository, mfaRepo MFARepository, lockoutRepo LockoutRepository, passwordRepo PasswordRepository, serviceAccountRepo ServiceAccountRepository, domainsClient grpcDomainsV1.DomainsServiceClient, policyService policies.Service, emailer Emailer, hasher Hasher, idp mitras.IDProvider, directory Directory, rc RegistrationConfig, lc LockoutConfig, pp PasswordPolicy) Service {
+	return service{
+		token:           token,
+		users:           urepo,
+		mfa:             mfaRepo,
+		lockout:         lockoutRepo,
+		passwords:       passwordRepo,
+		serviceAccounts: serviceAccountRepo,
+		domains:         domainsClient,
+		policies:        policyService,
+		hasher:          hasher,
+		email:           emailer,
+		idProvider:      idp,
+		directory:       directory,
+		registration:    rc,
+		lockoutCfg:      lc,
+		passwordCfg:     pp,
+	}
+}
+
+func (svc service) Register(ctx context.Context, session authn.Session, u User, selfRegister bool, invitation string) (User, error) {
+	if !selfRegister {
+		if err := svc.checkSuperAdmin(ctx, session); err != nil {
+			return User{}, err
+		}
+	}
+
+	verified := true
+	if selfRegister && !session.SuperAdmin {
+		invited, err := svc.checkRegistration(u.Email, invitation)
+		if err != nil {
+			return User{}, err
+		}
+		verified = invited || !svc.registration.VerifyEmail
+	}
+
+	return svc.register(ctx, u, verified)
+}
+
+func (svc service) register(ctx context.Context, u User, verified bool) (uc User, err error) {
+	userID, err := svc.idProvider.ID()
+	if err != nil {
+		return User{}, err
+	}
+
+	if u.Credentials.Secret != "" {
+		if err := svc.passwordCfg.Validate(u.Credentials.Secret); err != nil {
+			return User{}, errors.Wrap(apiutil.ErrPasswordFormat, err)
+		}
+		hash, err := svc.hasher.Hash(u.Credentials.Secret)
+		if err != nil {
+			return User{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+		}
+		u.Credentials.Secret = hash
+	}
+
+	if u.Status != DisabledStatus && u.Status != EnabledStatus {
+		return User{}, errors.Wrap(svcerr.ErrMalformedEntity, svcerr.ErrInvalidStatus)
+	}
+	if u.Role != UserRole && u.Role != AdminRole {
+		return User{}, errors.Wrap(svcerr.ErrMalformedEntity, svcerr.ErrInvalidRole)
+	}
+	u.ID = userID
+	u.CreatedAt = time.Now()
+	u.VerifiedAt = time.Time{}
+	if verified {
+		u.VerifiedAt = u.CreatedAt
+	}
+
+	if err := svc.addUserPolicy(ctx, u.ID, u.Role); err != nil {
+		return User{}, err
+	}
+	defer func() {
+		if err != nil {
+			if errRollback := svc.addUserPolicyRollback(ctx, u.ID, u.Role); errRollback != nil {
+				err = errors.Wrap(errors.Wrap(apiutil.ErrRollbackTx, errRollback), err)
+			}
+		}
+	}()
+	user, err := svc.users.Save(ctx, u)
+	if err != nil {
+		return User{}, errors.Wrap(svcerr.ErrCreateEntity, err)
+	}
+	if u.Credentials.Secret != "" {
+		if err = svc.savePassword(ctx, user.ID, u.Credentials.Secret, u.CreatedAt); err != nil {
+			if errDelete := svc.users.Delete(ctx, user.ID); errDelete != nil {
+				err = errors.Wrap(errors.Wrap(apiutil.ErrRollbackTx, errDelete), err)
+			}
+			return User{}, err
+		}
+	}
+	if !verified {
+		// The user can't log in without the verification email, so the
+		// registration fails as a whole.
+		if err = svc.sendVerification(user); err != nil {
+			if errDelete := svc.users.Delete(ctx, user.ID); errDelete != nil {
+				err = errors.Wrap(errors.Wrap(apiutil.ErrRollbackTx, errDelete), err)
+			}
+			return User{}, err
+		}
+	}
+	return user, nil
+}
+
+// checkRegistration enforces the self-registration policy and returns
+// whether the email is verified by the invitation.
+func (svc service) checkRegistration(email, invitation string) (bool, error) {
+	if invitation != "" {
+		_, invited, err := parseToken(svc.registration.Key, invitationPurpose, invitation)
+		if err != nil || !strings.EqualFold(invited, email) {
+			return false, errors.Wrap(svcerr.ErrAuthorization, errInvalidInvitation)
+		}
+		return true, nil
+	}
+	if svc.registration.InviteOnly {
+		return false, errors.Wrap(svcerr.ErrAuthorization, errInvitationRequired)
+	}
+	if !svc.registration.allowsEmail(email) {
+		return false, errors.Wrap(svcerr.ErrAuthorization, errEmailDomain)
+	}
+
+	return false, nil
+}
+
+func (svc service) VerifyEmail(ctx context.Context, token string) error {
+	userID, email, err := parseToken(svc.registration.Key, verificationPurpose, token)
+	if err != nil {
+		return errors.Wrap(svcerr.ErrAuthentication, errInvalidVerification)
+	}
+	user, err := svc.users.RetrieveByID(ctx, userID)
+	if err != nil {
+		return errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+	if user.Email != email {
+		return errors.Wrap(svcerr.ErrAuthentication, errInvalidVerification)
+	}
+	if !user.VerifiedAt.IsZero() {
+		return errors.Wrap(svcerr.ErrConflict, errEmailVerified)
+	}
+
+	user = User{
+		ID:         user.ID,
+		Email:      user.Email,
+		VerifiedAt: time.Now(),
+	}
+	if _, err := svc.users.VerifyEmail(ctx, user); err != nil {
+		return errors.Wrap(svcerr.ErrUpdateEntity, err)
+	}
+
+	return nil
+}
+
+func (svc service) SendVerification(ctx context.Context, email, ip string) error {
+	// Every request counts as the failed attempt, since the user doesn't
+	// authenticate and the request sends the email.
+	limits := svc.lockoutCfg.limits(verifyEmailPrefix, email, verifyIPPrefix, ip)
+	if err := svc.checkAttempts(ctx, limits); err != nil {
+		return err
+	}
+	if err := svc.failAttempt(ctx, limits); err != nil {
+		return err
+	}
+
+	user, err := svc.users.RetrieveByEmail(ctx, email)
+	if err != nil {
+		return errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+	if !user.VerifiedAt.IsZero() {
+		return errors.Wrap(svcerr.ErrConflict, errEmailVerified)
+	}
+
+	return svc.sendVerification(user)
+}
+
+func (svc service) sendVerification(user User) error {
+	token := signToken(svc.registration.Key, verificationPurpose, user.ID, user.Email, time.Now().Add(svc.registration.VerificationDuration))
+	if err := svc.email.SendVerification([]string{user.Email}, user.Credentials.Username, token); err != nil {
+		return errors.Wrap(errSendVerification, err)
+	}
+
+	return nil
+}
+
+func (svc service) InviteUser(ctx context.Context, session authn.Session, email string) error {
+	if err := svc.checkSuperAdmin(ctx, session); err != nil {
+		return err
+	}
+	if _, err := svc.users.RetrieveByEmail(ctx, email); err == nil {
+		return errors.Wrap(svcerr.ErrConflict, errUserExists)
+	}
+
+	token := signToken(svc.registration.Key, invitationPurpose, session.UserID, email, time.Now().Add(svc.registration.InvitationDuration))
+	if err := svc.email.SendInvitation([]string{email}, email, token); err != nil {
+		return errors.Wrap(errSendInvitation, err)
+	}
+
+	return nil
+}
+
+func (svc service) IssueToken(ctx context.Context, identity, secret, device, ip string) (*grpcTokenV1.Token, error) {
+	dbUser, err := svc.retrieveByIdentity(ctx, identity)
+	if err != nil && !errors.Contains(err, repoerr.ErrNotFound) {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, err)
+	}
+	// The attempts of the existing user are counted by the user ID, so the
+	// username and the email of the user share the same limit.
+	lockoutIdentity := identity
+	if dbUser.ID != "" {
+		lockoutIdentity = dbUser.ID
+	}
+	limits := svc.lockoutCfg.limits(loginIdentityPrefix, lockoutIdentity, loginIPPrefix, ip)
+	if err := svc.checkAttempts(ctx, limits); err != nil {
+		return &grpcTokenV1.Token{}, err
+	}
+
+	dbUser, local, err := svc.login(ctx, identity, secret, dbUser, limits)
+	if err != nil {
+		return &grpcTokenV1.Token{}, err
+	}
+	// Only the identity attempts are reset, so the successful logins
+	// don't hide the credential stuffing from the same IP.
+	if svc.lockoutCfg.enabled() {
+		if err := svc.lockout.Remove(ctx, limits[0].key); err != nil {
+			return &grpcTokenV1.Token{}, errors.Wrap(errLockout, err)
+		}
+	}
+	if svc.registration.VerifyEmail && dbUser.VerifiedAt.IsZero() {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, errEmailNotVerified)
+	}
+	// The directory users' passwords expire in the directory.
+	if local {
+		expired, err := svc.passwordExpired(ctx, dbUser)
+		if err != nil {
+			return &grpcTokenV1.Token{}, errors.Wrap(errIssueToken, err)
+		}
+		if expired {
+			token := signToken(svc.registration.Key, passwordChangePurpose, dbUser.ID, secretFingerprint(dbUser.Credentials.Secret), time.Now().Add(passwordChangeDuration))
+			return &grpcTokenV1.Token{AccessToken: token, AccessType: PasswordChangeAccessType}, nil
+		}
+	}
+
+	return svc.issueToken(ctx, dbUser.ID, device, ip)
+}
+
+// issueToken issues the access token, or the MFA challenge if the user has
+// to complete the second factor.
+func (svc service) issueToken(ctx context.Context, userID, device, ip string) (*grpcTokenV1.Token, error) {
+	accessType, err := svc.mfaAccessType(ctx, userID)
+	if err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(errIssueToken, err)
+	}
+	if accessType != "" {
+		challenge, err := svc.issueMFAChallenge(ctx, userID, device, ip)
+		if err != nil {
+			return &grpcTokenV1.Token{}, errors.Wrap(errIssueToken, err)
+		}
+
+		return &grpcTokenV1.Token{AccessToken: challenge, AccessType: accessType}, nil
+	}
+
+	token, err := svc.token.Issue(ctx, &grpcTokenV1.IssueReq{UserId: userID, Type: uint32(smqauth.AccessKey), Device: device, Ip: ip})
+	if err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(errIssueToken, err)
+	}
+
+	return token, nil
+}
+
+func (svc service) ChangeExpiredSecret(ctx context.Context, changeToken, newSecret, device, ip string) (*grpcTokenV1.Token, error) {
+	userID, fingerprint, err := parseToken(svc.registration.Key, passwordChangePurpose, changeToken)
+	if err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, errInvalidPassChange)
+	}
+	dbUser, err := svc.users.RetrieveByID(ctx, userID)
+	if err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, err)
+	}
+	if dbUser.Status != EnabledStatus || secretFingerprint(dbUser.Credentials.Secret) != fingerprint {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, errInvalidPassChange)
+	}
+
+	if err := svc.checkPassword(ctx, dbUser, newSecret); err != nil {
+		return &grpcTokenV1.Token{}, err
+	}
+	hash, err := svc.hasher.Hash(newSecret)
+	if err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+	dbUser.Credentials.Secret = hash
+	dbUser.UpdatedAt = time.Now()
+	dbUser.UpdatedBy = dbUser.ID
+	if _, err := svc.users.UpdateSecret(ctx, dbUser); err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrUpdateEntity, err)
+	}
+	if err := svc.savePassword(ctx, dbUser.ID, hash, dbUser.UpdatedAt); err != nil {
+		return &grpcTokenV1.Token{}, err
+	}
+	if err := svc.revokeSessions(ctx, dbUser.ID); err != nil {
+		return &grpcTokenV1.Token{}, err
+	}
+
+	return svc.issueToken(ctx, dbUser.ID, device, ip)
+}
+
+func (svc service) PasswordPolicy(_ context.Context) PasswordPolicy {
+	return svc.passwordCfg
+}
+
+// checkPassword validates the new password against the policy and the
+// user's current and last passwords.
+func (svc service) checkPassword(ctx context.Context, user User, secret string) error {
+	if err := svc.passwordCfg.Validate(secret); err != nil {
+		return errors.Wrap(apiutil.ErrPasswordFormat, err)
+	}
+	if svc.passwordCfg.History == 0 {
+		return nil
+	}
+
+	history, err := svc.passwords.Retrieve(ctx, user.ID, svc.passwordCfg.History)
+	if err != nil {
+		return errors.Wrap(errPasswordHistory, err)
+	}
+	hashes := []string{user.Credentials.Secret}
+	for _, ph := range history {
+		hashes = append(hashes, ph.Secret)
+	}
+	for _, hash := range hashes {
+		if hash != "" && svc.hasher.Compare(secret, hash) == nil {
+			return errors.Wrap(apiutil.ErrPasswordFormat, errPasswordReused)
+		}
+	}
+
+	return nil
+}
+
+// savePassword saves the password hash to the user's password history,
+// which is kept even without the reuse rule to track the password age.
+func (svc service) savePassword(ctx context.Context, userID, hash string, at time.Time) error {
+	keep := max(svc.passwordCfg.History, 1)
+	if err := svc.passwords.Save(ctx, PasswordHistory{UserID: userID, Secret: hash, CreatedAt: at}, keep); err != nil {
+		return errors.Wrap(errPasswordHistory, err)
+	}
+
+	return nil
+}
+
+// passwordExpired returns true if the user's password is older than the
+// policy max age. The passwords set before the history was kept are as old
+// as the user.
+func (svc service) passwordExpired(ctx context.Context, user User) (bool, error) {
+	if svc.passwordCfg.MaxAge == 0 {
+		return false, nil
+	}
+	history, err := svc.passwords.Retrieve(ctx, user.ID, 1)
+	if err != nil {
+		return false, errors.Wrap(errPasswordHistory, err)
+	}
+	changedAt := user.CreatedAt
+	if len(history) > 0 {
+		changedAt = history[0].CreatedAt
+	}
+
+	return time.Since(changedAt) > svc.passwordCfg.MaxAge, nil
+}
+
+// login authenticates the identity with the secret and returns the user,
+// and whether the user is authenticated with the local password.
+// The identities handled by the directory are authenticated against it,
+// unless they are not in the directory, e.g. the local administrator.
+// The dbUser is the local user with the identity, or the empty user if
+// there's no such user.
+func (svc service) login(ctx context.Context, identity, secret string, dbUser User, limits []attemptsLimit) (User, bool, error) {
+	if svc.directory != nil && svc.directory.Handles(identity) {
+		user, err := svc.directoryLogin(ctx, identity, secret)
+		switch {
+		case err == nil:
+			return user, false, nil
+		case errors.Contains(err, svcerr.ErrLogin):
+			if err := svc.failAttempt(ctx, limits); err != nil {
+				return User{}, false, err
+			}
+			return User{}, false, err
+		case !errors.Contains(err, ErrDirectoryUserNotFound):
+			return User{}, false, errors.Wrap(svcerr.ErrAuthentication, err)
+		}
+	}
+
+	if dbUser.ID == "" {
+		if err := svc.failAttempt(ctx, limits); err != nil {
+			return User{}, false, err
+		}
+		return User{}, false, errors.Wrap(svcerr.ErrAuthentication, repoerr.ErrNotFound)
+	}
+
+	if err := svc.hasher.Compare(secret, dbUser.Credentials.Secret); err != nil {
+		if err := svc.failAttempt(ctx, limits); err != nil {
+			return User{}, false, err
+		}
+		return User{}, false, errors.Wrap(svcerr.ErrLogin, err)
+	}
+
+	return dbUser, true, nil
+}
+
+// retrieveByIdentity retrieves the user by the email or the username.
+func (svc service) retrieveByIdentity(ctx context.Context, identity string) (User, error) {
+	if _, err := mail.ParseAddress(identity); err != nil {
+		return svc.users.RetrieveByUsername(ctx, identity)
+	}
+
+	return svc.users.RetrieveByEmail(ctx, identity)
+}
+
+// directoryLogin authenticates the identity against the directory. The user
+// is provisioned on the first login, and the user's domain roles are synced
+// with the directory groups on every login.
+func (svc service) directoryLogin(ctx context.Context, identity, secret string) (User, error) {
+	duser, err := svc.directory.Authenticate(ctx, identity, secret)
+	if err != nil {
+		return User{}, err
+	}
+
+	user, err := svc.directoryUser(ctx, duser)
+	if err != nil {
+		return User{}, err
+	}
+	if err := syncDirectoryRoles(ctx, svc.domains, user.ID, svc.directory.Roles(), duser.Roles); err != nil {
+		return User{}, err
+	}
+
+	return user, nil
+}
+
+// directoryUser returns the user bound to the directory user, and provisions
+// it on the first login. The user is matched only by the stored DN, so the
+// directory can't be used to log in as the local user with the same email.
+func (svc service) directoryUser(ctx context.Context, duser DirectoryUser) (User, error) {
+	page, err := svc.users.RetrieveAll(ctx, Page{
+		Limit:    1,
+		Metadata: Metadata{directoryDNKey: duser.DN},
+		Status:   EnabledStatus,
+		Role:     AllRole,
+	})
+	if err != nil {
+		return User{}, err
+	}
+	if len(page.Users) > 0 {
+		return page.Users[0], nil
+	}
+
+	_, err = svc.users.RetrieveByEmail(ctx, duser.Email)
+	switch {
+	case err == nil:
+		return User{}, errLocalUser
+	case !errors.Contains(err, repoerr.ErrNotFound):
+		return User{}, err
+	}
+
+	// The directory users have no local password, and the directory is
+	// trusted to verify their emails.
+	user, err := svc.register(ctx, User{
+		FirstName: duser.FirstName,
+		LastName:  duser.LastName,
+		Email:     duser.Email,
+		Metadata:  Metadata{directoryDNKey: duser.DN},
+		Status:    EnabledStatus,
+		Role:      UserRole,
+	}, true)
+	if err != nil {
+		return User{}, errors.Wrap(errDirectoryUser, err)
+	}
+
+	return user, nil
+}
+
+func (svc service) IssueMFAToken(ctx context.Context, mfaToken, code string) (*grpcTokenV1.Token, error) {
+	challenge, err := svc.retrieveMFAChallenge(ctx, mfaToken)
+	if err != nil {
+		return &grpcTokenV1.Token{}, err
+	}
+
+	mfa, err := svc.mfa.Retrieve(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Contains(err, repoerr.ErrNotFound) {
+			return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, errMFANotEnrolled)
+		}
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, err)
+	}
+	if err := svc.verifyMFACode(ctx, mfa, code); err != nil {
+		return &grpcTokenV1.Token{}, err
+	}
+	if err := svc.mfa.RemoveChallenge(ctx, challenge.ID); err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(errIssueToken, err)
+	}
+
+	token, err := svc.token.Issue(ctx, &grpcTokenV1.IssueReq{UserId: challenge.UserID, Type: uint32(smqauth.AccessKey), Device: challenge.Device, Ip: challenge.IP})
+	if err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(errIssueToken, err)
+	}
+
+	return token, nil
+}
+
+func (svc service) EnrollMFA(ctx context.Context, session authn.Session) (MFAEnrollment, error) {
+	return svc.enrollMFA(ctx, session.UserID)
+}
+
+func (svc service) EnrollMFAWithChallenge(ctx context.Context, mfaToken string) (MFAEnrollment, error) {
+	challenge, err := svc.retrieveMFAChallenge(ctx, mfaToken)
+	if err != nil {
+		return MFAEnrollment{}, err
+	}
+
+	return svc.enrollMFA(ctx, challenge.UserID)
+}
+
+func (svc service) VerifyMFA(ctx context.Context, session authn.Session, code string) error {
+	mfa, err := svc.mfa.Retrieve(ctx, session.UserID)
+	if err != nil {
+		if errors.Contains(err, repoerr.ErrNotFound) {
+			return errors.Wrap(svcerr.ErrNotFound, errMFANotEnrolled)
+		}
+		return errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+	if mfa.Enabled {
+		return errors.Wrap(svcerr.ErrConflict, errMFAEnabled)
+	}
+
+	return svc.verifyMFACode(ctx, mfa, code)
+}
+
+func (svc service) ResetMFA(ctx context.Context, session authn.Session, id string) error {
+	if err := svc.checkSuperAdmin(ctx, session); err != nil {
+		return err
+	}
+	if err := svc.mfa.Remove(ctx, id); err != nil {
+		if errors.Contains(err, repoerr.ErrNotFound) {
+			return errors.Wrap(svcerr.ErrNotFound, errMFANotEnrolled)
+		}
+		return errors.Wrap(svcerr.ErrRemoveEntity, err)
+	}
+
+	return nil
+}
+
+func (svc service) Unlock(ctx context.Context, session authn.Session, id string) error {
+	if err := svc.checkSuperAdmin(ctx, session); err != nil {
+		return err
+	}
+	user, err := svc.users.RetrieveByID(ctx, id)
+	if err != nil {
+		return errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+	keys := []string{
+		loginIdentityPrefix + strings.ToLower(user.ID),
+		resetEmailPrefix + strings.ToLower(user.Email),
+		verifyEmailPrefix + strings.ToLower(user.Email),
+	}
+	if err := svc.lockout.Remove(ctx, keys...); err != nil {
+		return errors.Wrap(svcerr.ErrRemoveEntity, err)
+	}
+
+	return nil
+}
+
+func (svc service) Impersonate(ctx context.Context, session authn.Session, id string, readOnly bool) (*grpcTokenV1.Token, error) {
+	if err := svc.checkSuperAdmin(ctx, session); err != nil {
+		return &grpcTokenV1.Token{}, err
+	}
+	if session.ImpersonatorID != "" {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthorization, errNestedImpersonation)
+	}
+	if session.UserID == id {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthorization, errImpersonateAdmin)
+	}
+	user, err := svc.users.RetrieveByID(ctx, id)
+	if err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+	if user.Role == AdminRole {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthorization, errImpersonateAdmin)
+	}
+	if user.Status != EnabledStatus {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, errLoginDisableUser)
+	}
+	admin, err := svc.users.RetrieveByID(ctx, session.UserID)
+	if err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	// The user is notified before the token is issued, so there's no
+	// impersonation the user isn't aware of.
+	if err := svc.email.SendImpersonation([]string{user.Email}, user.Credentials.Username, admin.Credentials.Username, readOnly); err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(errSendImpersonation, err)
+	}
+
+	req := &grpcTokenV1.IssueReq{
+		UserId:         user.ID,
+		Type:           uint32(smqauth.AccessKey),
+		ImpersonatorId: session.UserID,
+		ReadOnly:       readOnly,
+	}
+	token, err := svc.token.Issue(ctx, req)
+	if err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(errIssueToken, err)
+	}
+
+	return token, nil
+}
+
+// checkAttempts returns an error if any of the limits is locked out or the
+// next attempt is delayed.
+func (svc service) checkAttempts(ctx context.Context, limits []attemptsLimit) error {
+	if !svc.lockoutCfg.enabled() {
+		return nil
+	}
+	now := time.Now().UTC()
+	for _, l := range limits {
+		attempts, err := svc.lockout.Retrieve(ctx, l.key, now.Add(-svc.lockoutCfg.Duration))
+		switch {
+		case errors.Contains(err, repoerr.ErrNotFound):
+			continue
+		case err != nil:
+			return errors.Wrap(errLockout, err)
+		}
+		if now.Before(svc.lockoutCfg.retryAt(attempts, l.maxAttempts)) {
+			return errors.Wrap(svcerr.ErrAuthentication, errTooManyAttempts)
+		}
+	}
+
+	return nil
 }
 
-// NewService returns a new Users service implementation.
-func NewService(token grpcTokenV1.TokenServiceClient, urepo Repository, policyService policies.Service, emailer Emailer, hasher Hasher, idp mitras.IDProvider) Service {
-	return service{
-		token:      token,
-		users:      urepo,
-		policies:   policyService,
-		hasher:     hasher,
-		email:      emailer,
-		idProvider: idp,
+// failAttempt counts the failed attempt for all the limits. It returns
+// ErrLockedOut if any of them reached the lockout threshold.
+func (svc service) failAttempt(ctx context.Context, limits []attemptsLimit) error {
+	if !svc.lockoutCfg.enabled() {
+		return nil
+	}
+	now := time.Now().UTC()
+	locked := false
+	for _, l := range limits {
+		attempts, err := svc.lockout.Fail(ctx, l.key, now, now.Add(-svc.lockoutCfg.Duration))
+		if err != nil {
+			return errors.Wrap(errLockout, err)
+		}
+		locked = locked || svc.lockoutCfg.locked(attempts, l.maxAttempts)
+	}
+	if locked {
+		return errors.Wrap(svcerr.ErrAuthentication, ErrLockedOut)
 	}
+
+	return nil
 }
 
-func (svc service) Register(ctx context.Context, session authn.Session, u User, selfRegister bool) (uc User, err error) {
-	if !selfRegister {
-		if err := svc.checkSuperAdmin(ctx, session); err != nil {
-			return User{}, err
-		}
+// mfaAccessType returns the access type of the MFA challenge issued on
+// login, or an empty string if the user doesn't need the second factor.
+func (svc service) mfaAccessType(ctx context.Context, userID string) (string, error) {
+	mfa, err := svc.mfa.Retrieve(ctx, userID)
+	switch {
+	case err == nil && mfa.Enabled:
+		return MFAAccessType, nil
+	case err != nil && !errors.Contains(err, repoerr.ErrNotFound):
+		return "", err
 	}
 
-	userID, err := svc.idProvider.ID()
+	res, err := svc.domains.RequiresMFA(ctx, &grpcDomainsV1.RequiresMFAReq{UserId: userID})
 	if err != nil {
-		return User{}, err
+		return "", err
+	}
+	if res.GetRequired() {
+		return MFAEnrollAccessType, nil
 	}
 
-	if u.Credentials.Secret != "" {
-		hash, err := svc.hasher.Hash(u.Credentials.Secret)
-		if err != nil {
-			return User{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
-		}
-		u.Credentials.Secret = hash
+	return "", nil
+}
+
+func (svc service) issueMFAChallenge(ctx context.Context, userID, device, ip string) (string, error) {
+	token, err := randomString(32)
+	if err != nil {
+		return "", err
+	}
+	challenge := MFAChallenge{
+		ID:        hashMFASecret(token),
+		UserID:    userID,
+		Device:    device,
+		IP:        ip,
+		ExpiresAt: time.Now().UTC().Add(mfaChallengeDuration),
+	}
+	if err := svc.mfa.SaveChallenge(ctx, challenge); err != nil {
+		return "", err
 	}
 
-	if u.Status != DisabledStatus && u.Status != EnabledStatus {
-		return User{}, errors.Wrap(svcerr.ErrMalformedEntity, svcerr.ErrInvalidStatus)
+	return token, nil
+}
+
+// retrieveMFAChallenge retrieves the MFA challenge and counts the attempt
+// to use it. The challenge is removed once the attempts are exhausted.
+func (svc service) retrieveMFAChallenge(ctx context.Context, mfaToken string) (MFAChallenge, error) {
+	challenge, err := svc.mfa.RetrieveChallenge(ctx, hashMFASecret(mfaToken))
+	if err != nil {
+		if errors.Contains(err, repoerr.ErrNotFound) {
+			return MFAChallenge{}, errors.Wrap(svcerr.ErrAuthentication, errInvalidMFAToken)
+		}
+		return MFAChallenge{}, errors.Wrap(svcerr.ErrAuthentication, err)
 	}
-	if u.Role != UserRole && u.Role != AdminRole {
-		return User{}, errors.Wrap(svcerr.ErrMalformedEntity, svcerr.ErrInvalidRole)
+	if challenge.Attempts > mfaMaxAttempts {
+		if err := svc.mfa.RemoveChallenge(ctx, challenge.ID); err != nil {
+			return MFAChallenge{}, errors.Wrap(svcerr.ErrAuthentication, err)
+		}
+		return MFAChallenge{}, errors.Wrap(svcerr.ErrAuthentication, errMFAAttempts)
 	}
-	u.ID = userID
-	u.CreatedAt = time.Now()
 
-	if err := svc.addUserPolicy(ctx, u.ID, u.Role); err != nil {
-		return User{}, err
+	return challenge, nil
+}
+
+func (svc service) enrollMFA(ctx context.Context, userID string) (MFAEnrollment, error) {
+	user, err := svc.users.RetrieveByID(ctx, userID)
+	if err != nil {
+		return MFAEnrollment{}, errors.Wrap(svcerr.ErrViewEntity, err)
 	}
-	defer func() {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return MFAEnrollment{}, err
+	}
+
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+	for i := range codes {
+		code, err := randomString(8)
 		if err != nil {
-			if errRollback := svc.addUserPolicyRollback(ctx, u.ID, u.Role); errRollback != nil {
-				err = errors.Wrap(errors.Wrap(apiutil.ErrRollbackTx, errRollback), err)
-			}
+			return MFAEnrollment{}, err
+		}
+		codes[i] = code[:5] + "-" + code[5:recoveryCodeLen]
+		if hashes[i], err = svc.hasher.Hash(normalizeRecoveryCode(codes[i])); err != nil {
+			return MFAEnrollment{}, err
 		}
-	}()
-	user, err := svc.users.Save(ctx, u)
-	if err != nil {
-		return User{}, errors.Wrap(svcerr.ErrCreateEntity, err)
 	}
-	return user, nil
-}
 
-func (svc service) IssueToken(ctx context.Context, identity, secret string) (*grpcTokenV1.Token, error) {
-	var dbUser User
-	var err error
+	mfa := MFA{
+		UserID:        user.ID,
+		Secret:        secret,
+		RecoveryCodes: hashes,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := svc.mfa.Save(ctx, mfa); err != nil {
+		if errors.Contains(err, repoerr.ErrConflict) {
+			return MFAEnrollment{}, errors.Wrap(svcerr.ErrConflict, errMFAEnabled)
+		}
+		return MFAEnrollment{}, errors.Wrap(svcerr.ErrCreateEntity, err)
+	}
 
-	if _, parseErr := mail.ParseAddress(identity); parseErr != nil {
-		dbUser, err = svc.users.RetrieveByUsername(ctx, identity)
-	} else {
-		dbUser, err = svc.users.RetrieveByEmail(ctx, identity)
+	account := user.Email
+	if account == "" {
+		account = user.Credentials.Username
 	}
 
-	if err != nil {
-		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, err)
+	return MFAEnrollment{
+		Secret:        secret,
+		URI:           totp.URI(mfaIssuer, account, secret),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// verifyMFACode accepts the TOTP code or, once MFA is enabled, one of the
+// recovery codes. The valid TOTP code enables the pending enrollment.
+func (svc service) verifyMFACode(ctx context.Context, mfa MFA, code string) error {
+	if step, ok := totp.Validate(mfa.Secret, code, time.Now()); ok {
+		var err error
+		switch mfa.Enabled {
+		case true:
+			err = svc.mfa.UseStep(ctx, mfa.UserID, step)
+		default:
+			err = svc.mfa.Enable(ctx, mfa.UserID, step)
+		}
+		switch {
+		case err == nil:
+			return nil
+		case errors.Contains(err, repoerr.ErrNotFound):
+			return errors.Wrap(svcerr.ErrAuthentication, errInvalidMFACode)
+		default:
+			return errors.Wrap(svcerr.ErrUpdateEntity, err)
+		}
+	}
+	if !mfa.Enabled {
+		return errors.Wrap(svcerr.ErrAuthentication, errInvalidMFACode)
 	}
 
-	if err := svc.hasher.Compare(secret, dbUser.Credentials.Secret); err != nil {
-		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrLogin, err)
+	hash, ok := svc.recoveryCodeHash(mfa, code)
+	if !ok {
+		return errors.Wrap(svcerr.ErrAuthentication, errInvalidMFACode)
+	}
+	if err := svc.mfa.UseRecoveryCode(ctx, mfa.UserID, hash); err != nil {
+		if errors.Contains(err, repoerr.ErrNotFound) {
+			return errors.Wrap(svcerr.ErrAuthentication, errInvalidMFACode)
+		}
+		return errors.Wrap(svcerr.ErrUpdateEntity, err)
 	}
 
-	token, err := svc.token.Issue(ctx, &grpcTokenV1.IssueReq{UserId: dbUser.ID, Type: uint32(smqauth.AccessKey)})
-	if err != nil {
-		return &grpcTokenV1.Token{}, errors.Wrap(errIssueToken, err)
+	return nil
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
 
-	return token, nil
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)), nil
 }
 
-func (svc service) RefreshToken(ctx context.Context, session authn.Session, refreshToken string) (*grpcTokenV1.Token, error) {
+// recoveryCodeHash returns the salted hash of the user's unused recovery code
+// which matches the given code.
+func (svc service) recoveryCodeHash(mfa MFA, code string) (string, bool) {
+	code = normalizeRecoveryCode(code)
+	if len(code) != recoveryCodeLen {
+		return "", false
+	}
+	for _, hash := range mfa.RecoveryCodes {
+		if svc.hasher.Compare(code, hash) == nil {
+			return hash, true
+		}
+	}
+
+	return "", false
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+func hashMFASecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func (svc service) RefreshToken(ctx context.Context, session authn.Session, refreshToken, ip string) (*grpcTokenV1.Token, error) {
 	dbUser, err := svc.users.RetrieveByID(ctx, session.UserID)
 	if err != nil {
 		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, err)
@@ -126,7 +937,7 @@ func (svc service) RefreshToken(ctx context.Context, session authn.Session, refr
 		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, errLoginDisableUser)
 	}
 
-	return svc.token.Refresh(ctx, &grpcTokenV1.RefreshReq{RefreshToken: refreshToken})
+	return svc.token.Refresh(ctx, &grpcTokenV1.RefreshReq{RefreshToken: refreshToken, Ip: ip})
 }
 
 func (svc service) View(ctx context.Context, session authn.Session, id string) (User, error) {
@@ -209,6 +1020,13 @@ func (svc service) Update(ctx context.Context, session authn.Session, usr User)
 		UpdatedAt: time.Now(),
 		UpdatedBy: session.UserID,
 	}
+	if usr.Metadata != nil {
+		stored, err := svc.users.RetrieveByID(ctx, usr.ID)
+		if err != nil {
+			return User{}, errors.Wrap(svcerr.ErrViewEntity, err)
+		}
+		user.Metadata = withIdentityKeys(usr.Metadata, stored.Metadata)
+	}
 
 	user, err := svc.users.Update(ctx, user)
 	if err != nil {
@@ -283,7 +1101,17 @@ func (svc service) UpdateEmail(ctx context.Context, session authn.Session, userI
 	return user, nil
 }
 
-func (svc service) GenerateResetToken(ctx context.Context, email, host string) error {
+func (svc service) GenerateResetToken(ctx context.Context, email, host, ip string) error {
+	// Every request counts as the failed attempt, since the user doesn't
+	// authenticate and the request sends the email.
+	limits := svc.lockoutCfg.limits(resetEmailPrefix, email, resetIPPrefix, ip)
+	if err := svc.checkAttempts(ctx, limits); err != nil {
+		return err
+	}
+	if err := svc.failAttempt(ctx, limits); err != nil {
+		return err
+	}
+
 	user, err := svc.users.RetrieveByEmail(ctx, email)
 	if err != nil {
 		return errors.Wrap(svcerr.ErrViewEntity, err)
@@ -306,6 +1134,9 @@ func (svc service) ResetSecret(ctx context.Context, session authn.Session, secre
 		return errors.Wrap(svcerr.ErrViewEntity, err)
 	}
 
+	if err := svc.checkPassword(ctx, u, secret); err != nil {
+		return err
+	}
 	secret, err = svc.hasher.Hash(secret)
 	if err != nil {
 		return errors.Wrap(svcerr.ErrMalformedEntity, err)
@@ -322,7 +1153,10 @@ func (svc service) ResetSecret(ctx context.Context, session authn.Session, secre
 	if _, err := svc.users.UpdateSecret(ctx, u); err != nil {
 		return errors.Wrap(svcerr.ErrAuthorization, err)
 	}
-	return nil
+	if err := svc.savePassword(ctx, u.ID, secret, u.UpdatedAt); err != nil {
+		return err
+	}
+	return svc.revokeSessions(ctx, u.ID)
 }
 
 func (svc service) UpdateSecret(ctx context.Context, session authn.Session, oldSecret, newSecret string) (User, error) {
@@ -330,7 +1164,10 @@ func (svc service) UpdateSecret(ctx context.Context, session authn.Session, oldS
 	if err != nil {
 		return User{}, errors.Wrap(svcerr.ErrViewEntity, err)
 	}
-	if _, err := svc.IssueToken(ctx, dbUser.Credentials.Username, oldSecret); err != nil {
+	if _, err := svc.IssueToken(ctx, dbUser.Credentials.Username, oldSecret, "", ""); err != nil {
+		return User{}, err
+	}
+	if err := svc.checkPassword(ctx, dbUser, newSecret); err != nil {
 		return User{}, err
 	}
 	newSecret, err = svc.hasher.Hash(newSecret)
@@ -345,6 +1182,13 @@ func (svc service) UpdateSecret(ctx context.Context, session authn.Session, oldS
 	if err != nil {
 		return User{}, errors.Wrap(svcerr.ErrUpdateEntity, err)
 	}
+	if err := svc.savePassword(ctx, dbUser.ID, newSecret, dbUser.UpdatedAt); err != nil {
+		return User{}, err
+	}
+	// The session created to verify the old secret is revoked as well.
+	if err := svc.revokeSessions(ctx, dbUser.ID); err != nil {
+		return User{}, err
+	}
 
 	return dbUser, nil
 }
@@ -426,10 +1270,23 @@ func (svc service) Disable(ctx context.Context, session authn.Session, id string
 	if err != nil {
 		return User{}, errors.Wrap(svcerr.ErrDisableUser, err)
 	}
+	if err := svc.revokeSessions(ctx, user.ID); err != nil {
+		return User{}, errors.Wrap(svcerr.ErrDisableUser, err)
+	}
 
 	return user, nil
 }
 
+// revokeSessions revokes all the sessions of the user, so the tokens issued
+// before the user was disabled or changed the secret can't be used anymore.
+func (svc service) revokeSessions(ctx context.Context, userID string) error {
+	if _, err := svc.token.RevokeSessions(ctx, &grpcTokenV1.RevokeSessionsReq{UserId: userID}); err != nil {
+		return errors.Wrap(errRevokeSessions, err)
+	}
+
+	return nil
+}
+
 func (svc service) changeUserStatus(ctx context.Context, session authn.Session, user User) (User, error) {
 	if session.UserID != user.ID {
 		if err := svc.checkSuperAdmin(ctx, session); err != nil {
@@ -576,18 +1433,246 @@ func (svc *service) checkSuperAdmin(ctx context.Context, session authn.Session)
 	return nil
 }
 
-func (svc service) OAuthCallback(ctx context.Context, user User) (User, error) {
-	ruser, err := svc.users.RetrieveByEmail(ctx, user.Email)
+func (svc service) CreateServiceAccount(ctx context.Context, session authn.Session, sa ServiceAccount) (ServiceAccount, string, error) {
+	if sa.Status != DisabledStatus && sa.Status != EnabledStatus {
+		return ServiceAccount{}, "", errors.Wrap(svcerr.ErrMalformedEntity, svcerr.ErrInvalidStatus)
+	}
+	if sa.PublicKey != "" {
+		if _, err := parsePublicKey(sa.PublicKey); err != nil {
+			return ServiceAccount{}, "", errors.Wrap(svcerr.ErrMalformedEntity, err)
+		}
+	}
+	id, err := svc.idProvider.ID()
 	if err != nil {
-		switch errors.Contains(err, repoerr.ErrNotFound) {
-		case true:
-			ruser, err = svc.Register(ctx, authn.Session{}, user, true)
-			if err != nil {
-				return User{}, err
-			}
-		default:
+		return ServiceAccount{}, "", err
+	}
+	secret, err := randomString(serviceAccountSecret)
+	if err != nil {
+		return ServiceAccount{}, "", errors.Wrap(svcerr.ErrCreateEntity, err)
+	}
+	hash, err := svc.hasher.Hash(secret)
+	if err != nil {
+		return ServiceAccount{}, "", errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+
+	sa.ID = id
+	sa.DomainID = session.DomainID
+	sa.Secret = hash
+	sa.CreatedAt = time.Now()
+	sa.CreatedBy = session.UserID
+	sa, err = svc.serviceAccounts.Save(ctx, sa)
+	if err != nil {
+		return ServiceAccount{}, "", errors.Wrap(svcerr.ErrCreateEntity, err)
+	}
+
+	return sa, secret, nil
+}
+
+func (svc service) ViewServiceAccount(ctx context.Context, session authn.Session, id string) (ServiceAccount, error) {
+	return svc.retrieveServiceAccount(ctx, session, id)
+}
+
+func (svc service) ListServiceAccounts(ctx context.Context, session authn.Session, pm Page) (ServiceAccountsPage, error) {
+	page, err := svc.serviceAccounts.RetrieveAll(ctx, session.DomainID, pm)
+	if err != nil {
+		return ServiceAccountsPage{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	return page, nil
+}
+
+func (svc service) UpdateServiceAccount(ctx context.Context, session authn.Session, sa ServiceAccount) (ServiceAccount, error) {
+	if _, err := svc.retrieveServiceAccount(ctx, session, sa.ID); err != nil {
+		return ServiceAccount{}, err
+	}
+	if sa.PublicKey != "" {
+		if _, err := parsePublicKey(sa.PublicKey); err != nil {
+			return ServiceAccount{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+		}
+	}
+	sa.UpdatedAt = time.Now()
+	sa.UpdatedBy = session.UserID
+
+	sa, err := svc.serviceAccounts.Update(ctx, sa)
+	if err != nil {
+		return ServiceAccount{}, errors.Wrap(svcerr.ErrUpdateEntity, err)
+	}
+
+	return sa, nil
+}
+
+func (svc service) RotateServiceAccountSecret(ctx context.Context, session authn.Session, id string) (string, error) {
+	sa, err := svc.retrieveServiceAccount(ctx, session, id)
+	if err != nil {
+		return "", err
+	}
+	secret, err := randomString(serviceAccountSecret)
+	if err != nil {
+		return "", errors.Wrap(svcerr.ErrUpdateEntity, err)
+	}
+	if sa.Secret, err = svc.hasher.Hash(secret); err != nil {
+		return "", errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+	sa.UpdatedAt = time.Now()
+	sa.UpdatedBy = session.UserID
+	if _, err := svc.serviceAccounts.UpdateSecret(ctx, sa); err != nil {
+		return "", errors.Wrap(svcerr.ErrUpdateEntity, err)
+	}
+	// The tokens issued with the old secret can't be used anymore.
+	if err := svc.revokeSessions(ctx, sa.ID); err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+func (svc service) EnableServiceAccount(ctx context.Context, session authn.Session, id string) (ServiceAccount, error) {
+	sa, err := svc.changeServiceAccountStatus(ctx, session, id, EnabledStatus)
+	if err != nil {
+		return ServiceAccount{}, errors.Wrap(svcerr.ErrEnableUser, err)
+	}
+
+	return sa, nil
+}
+
+func (svc service) DisableServiceAccount(ctx context.Context, session authn.Session, id string) (ServiceAccount, error) {
+	sa, err := svc.changeServiceAccountStatus(ctx, session, id, DisabledStatus)
+	if err != nil {
+		return ServiceAccount{}, errors.Wrap(svcerr.ErrDisableUser, err)
+	}
+	if err := svc.revokeSessions(ctx, sa.ID); err != nil {
+		return ServiceAccount{}, errors.Wrap(svcerr.ErrDisableUser, err)
+	}
+
+	return sa, nil
+}
+
+func (svc service) changeServiceAccountStatus(ctx context.Context, session authn.Session, id string, status Status) (ServiceAccount, error) {
+	sa, err := svc.retrieveServiceAccount(ctx, session, id)
+	if err != nil {
+		return ServiceAccount{}, err
+	}
+	if sa.Status == status {
+		return ServiceAccount{}, errors.ErrStatusAlreadyAssigned
+	}
+	sa.Status = status
+	sa.UpdatedAt = time.Now()
+	sa.UpdatedBy = session.UserID
+
+	sa, err = svc.serviceAccounts.ChangeStatus(ctx, sa)
+	if err != nil {
+		return ServiceAccount{}, errors.Wrap(svcerr.ErrUpdateEntity, err)
+	}
+
+	return sa, nil
+}
+
+func (svc service) DeleteServiceAccount(ctx context.Context, session authn.Session, id string) error {
+	sa, err := svc.retrieveServiceAccount(ctx, session, id)
+	if err != nil {
+		return err
+	}
+	// The service account is removed from the domain, group and channel
+	// roles by removing its role memberships.
+	req := policies.Policy{
+		Subject:     policies.EncodeDomainUserID(sa.DomainID, sa.ID),
+		SubjectType: policies.UserType,
+	}
+	if err := svc.policies.DeletePolicyFilter(ctx, req); err != nil {
+		return errors.Wrap(errServiceAccountRole, err)
+	}
+	if err := svc.serviceAccounts.Delete(ctx, sa.ID); err != nil {
+		return errors.Wrap(svcerr.ErrRemoveEntity, err)
+	}
+
+	return svc.revokeSessions(ctx, sa.ID)
+}
+
+func (svc service) IssueServiceAccountToken(ctx context.Context, clientID, clientSecret, assertion, ip string) (*grpcTokenV1.Token, error) {
+	if assertion != "" {
+		sub, err := assertionSubject(assertion)
+		if err != nil {
+			return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, err)
+		}
+		clientID = sub
+	}
+	sa, err := svc.serviceAccounts.RetrieveByID(ctx, clientID)
+	if err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, errInvalidCredentials)
+	}
+	switch assertion {
+	case "":
+		if err := svc.hasher.Compare(clientSecret, sa.Secret); err != nil {
+			return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, errInvalidCredentials)
+		}
+	default:
+		if err := verifyAssertion(sa, assertion); err != nil {
+			return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, err)
+		}
+	}
+	if sa.Status != EnabledStatus {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, errLoginDisableUser)
+	}
+
+	req := &grpcTokenV1.IssueReq{
+		UserId:         sa.ID,
+		Type:           uint32(smqauth.AccessKey),
+		Ip:             ip,
+		ServiceAccount: true,
+		DomainId:       sa.DomainID,
+	}
+	token, err := svc.token.Issue(ctx, req)
+	if err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(errIssueToken, err)
+	}
+
+	// The service account authenticates again instead of refreshing the
+	// token, so the refresh token isn't returned.
+	return &grpcTokenV1.Token{AccessToken: token.GetAccessToken(), AccessType: token.GetAccessType()}, nil
+}
+
+// retrieveServiceAccount retrieves the service account of the session
+// domain. The service accounts of the other domains are not found.
+func (svc service) retrieveServiceAccount(ctx context.Context, session authn.Session, id string) (ServiceAccount, error) {
+	sa, err := svc.serviceAccounts.RetrieveByID(ctx, id)
+	if err != nil {
+		return ServiceAccount{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+	if sa.DomainID != session.DomainID {
+		return ServiceAccount{}, errors.Wrap(svcerr.ErrViewEntity, repoerr.ErrNotFound)
+	}
+
+	return sa, nil
+}
+
+func (svc service) OAuthCallback(ctx context.Context, user User, roles []DomainRole) (User, error) {
+	ruser, err := svc.users.RetrieveByEmail(ctx, user.Email)
+	switch {
+	case err == nil:
+		if err := svc.linkOAuth(ctx, ruser, user); err != nil {
+			return User{}, err
+		}
+	case errors.Contains(err, repoerr.ErrNotFound):
+		// The provider verifies the email, but the user can't be
+		// invited through it.
+		if svc.registration.InviteOnly {
+			return User{}, errors.Wrap(svcerr.ErrAuthorization, errInvitationRequired)
+		}
+		if !svc.registration.allowsEmail(user.Email) {
+			return User{}, errors.Wrap(svcerr.ErrAuthorization, errEmailDomain)
+		}
+		ruser, err = svc.register(ctx, user, true)
+		if err != nil {
 			return User{}, err
 		}
+	default:
+		return User{}, err
+	}
+
+	// The roles granted in mitras are never removed, so none of the roles
+	// is managed by the provider.
+	if err := syncDirectoryRoles(ctx, svc.domains, ruser.ID, nil, roles); err != nil {
+		return User{}, err
 	}
 
 	return User{
@@ -596,10 +1681,93 @@ func (svc service) OAuthCallback(ctx context.Context, user User) (User, error) {
 	}, nil
 }
 
+// linkOAuth checks that the OAuth identity can sign in as the existing user
+// with the same email. The identity is linked to the user who is already
+// bound to it, or if the provider verified the email and the user isn't the
+// super admin. The linked user is bound to the identity.
+func (svc service) linkOAuth(ctx context.Context, ruser, user User) error {
+	provider, subject := metadataString(user.Metadata, oauthProviderKey), metadataString(user.Metadata, oauthSubjectKey)
+	if provider == "" || subject == "" {
+		return errors.Wrap(svcerr.ErrAuthentication, errOAuthLink)
+	}
+	// The users registered with the provider before the subject was stored
+	// are bound to any subject of the provider.
+	boundSubject := metadataString(ruser.Metadata, oauthSubjectKey)
+	bound := metadataString(ruser.Metadata, oauthProviderKey) == provider && (boundSubject == subject || boundSubject == "")
+	switch {
+	case bound && boundSubject == subject:
+		return nil
+	case !bound && (user.VerifiedAt.IsZero() || ruser.Role == AdminRole):
+		return errors.Wrap(svcerr.ErrAuthentication, errOAuthLink)
+	}
+
+	metadata := Metadata{}
+	for k, v := range ruser.Metadata {
+		metadata[k] = v
+	}
+	metadata[oauthProviderKey] = provider
+	metadata[oauthSubjectKey] = subject
+	if _, err := svc.users.Update(ctx, User{
+		ID:        ruser.ID,
+		Metadata:  metadata,
+		Role:      AllRole,
+		UpdatedAt: time.Now(),
+		UpdatedBy: ruser.ID,
+	}); err != nil {
+		return errors.Wrap(svcerr.ErrUpdateEntity, err)
+	}
+
+	return nil
+}
+
+func metadataString(metadata Metadata, key string) string {
+	v, _ := metadata[key].(string)
+
+	return v
+}
+
+// withIdentityKeys returns the metadata with the identity bindings of the
+// stored metadata.
+func withIdentityKeys(metadata, stored Metadata) Metadata {
+	md := Metadata{}
+	for k, v := range metadata {
+		md[k] = v
+	}
+	for _, k := range identityKeys {
+		delete(md, k)
+		if v, ok := stored[k]; ok {
+			md[k] = v
+		}
+	}
+
+	return md
+}
+
 func (svc service) OAuthAddUserPolicy(ctx context.Context, user User) error {
 	return svc.addUserPolicy(ctx, user.ID, user.Role)
 }
 
+func (svc service) OAuthIssueToken(ctx context.Context, user User, device, ip string) (*grpcTokenV1.Token, error) {
+	dbUser, err := svc.users.RetrieveByID(ctx, user.ID)
+	if err != nil {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, err)
+	}
+	if dbUser.Status != EnabledStatus {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, errLoginDisableUser)
+	}
+	// The provider verifies the credentials, so the attempts are only
+	// checked against the lockout of the password logins.
+	limits := svc.lockoutCfg.limits(loginIdentityPrefix, dbUser.ID, loginIPPrefix, ip)
+	if err := svc.checkAttempts(ctx, limits); err != nil {
+		return &grpcTokenV1.Token{}, err
+	}
+	if svc.registration.VerifyEmail && dbUser.VerifiedAt.IsZero() {
+		return &grpcTokenV1.Token{}, errors.Wrap(svcerr.ErrAuthentication, errEmailNotVerified)
+	}
+
+	return svc.issueToken(ctx, dbUser.ID, device, ip)
+}
+
 func (svc service) Identify(ctx context.Context, session authn.Session) (string, error) {
 	return session.UserID, nil
 }
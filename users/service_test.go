@@ -2,24 +2,38 @@ package users_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	smqauth "github.com/hantdev/mitras/auth"
 	authmocks "github.com/hantdev/mitras/auth/mocks"
+	domainsmocks "github.com/hantdev/mitras/domains/mocks"
+	grpcDomainsV1 "github.com/hantdev/mitras/internal/grpc/domains/v1"
 	grpcTokenV1 "github.com/hantdev/mitras/internal/grpc/token/v1"
 	"github.com/hantdev/mitras/internal/testsutil"
+	"github.com/hantdev/mitras/pkg/apiutil"
 	"github.com/hantdev/mitras/pkg/authn"
 	"github.com/hantdev/mitras/pkg/errors"
 	repoerr "github.com/hantdev/mitras/pkg/errors/repository"
 	svcerr "github.com/hantdev/mitras/pkg/errors/service"
 	policysvc "github.com/hantdev/mitras/pkg/policies"
 	policymocks "github.com/hantdev/mitras/pkg/policies/mocks"
+	"github.com/hantdev/mitras/pkg/totp"
 	"github.com/hantdev/mitras/pkg/uuid"
 	"github.com/hantdev/mitras/users"
 	"github.com/hantdev/mitras/users/hasher"
 	"github.com/hantdev/mitras/users/mocks"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -59,7 +73,7 @@ func newService() (users.Service, *authmocks.TokenServiceClient, *mocks.Reposito
 	policies := new(policymocks.Service)
 	e := new(mocks.Emailer)
 	tokenClient := new(authmocks.TokenServiceClient)
-	return users.NewService(tokenClient, cRepo, policies, e, phasher, idProvider), tokenClient, cRepo, policies, e
+	return users.NewService(tokenClient, cRepo, newMFARepo(), new(mocks.LockoutRepository), newPasswordRepo(), new(mocks.ServiceAccountRepository), newDomainsClient(), policies, e, phasher, idProvider, nil, users.RegistrationConfig{}, users.LockoutConfig{}, users.PasswordPolicy{}), tokenClient, cRepo, policies, e
 }
 
 func newServiceMinimal() (users.Service, *mocks.Repository) {
@@ -67,7 +81,41 @@ func newServiceMinimal() (users.Service, *mocks.Repository) {
 	policies := new(policymocks.Service)
 	e := new(mocks.Emailer)
 	tokenUser := new(authmocks.TokenServiceClient)
-	return users.NewService(tokenUser, cRepo, policies, e, phasher, idProvider), cRepo
+	return users.NewService(tokenUser, cRepo, newMFARepo(), new(mocks.LockoutRepository), newPasswordRepo(), new(mocks.ServiceAccountRepository), newDomainsClient(), policies, e, phasher, idProvider, nil, users.RegistrationConfig{}, users.LockoutConfig{}, users.PasswordPolicy{}), cRepo
+}
+
+// newMFAService returns the service with the MFA mocks without the default
+// expectations of the users who haven't enrolled MFA.
+func newMFAService() (users.Service, *authmocks.TokenServiceClient, *mocks.Repository, *mocks.MFARepository, *domainsmocks.DomainsServiceClient) {
+	cRepo := new(mocks.Repository)
+	mfaRepo := new(mocks.MFARepository)
+	domainsClient := new(domainsmocks.DomainsServiceClient)
+	tokenClient := new(authmocks.TokenServiceClient)
+	svc := users.NewService(tokenClient, cRepo, mfaRepo, new(mocks.LockoutRepository), newPasswordRepo(), new(mocks.ServiceAccountRepository), domainsClient, new(policymocks.Service), new(mocks.Emailer), phasher, idProvider, nil, users.RegistrationConfig{}, users.LockoutConfig{}, users.PasswordPolicy{})
+
+	return svc, tokenClient, cRepo, mfaRepo, domainsClient
+}
+
+func newMFARepo() *mocks.MFARepository {
+	mfaRepo := new(mocks.MFARepository)
+	mfaRepo.On("Retrieve", mock.Anything, mock.Anything).Return(users.MFA{}, repoerr.ErrNotFound).Maybe()
+
+	return mfaRepo
+}
+
+func newPasswordRepo() *mocks.PasswordRepository {
+	passwordRepo := new(mocks.PasswordRepository)
+	passwordRepo.On("Save", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	passwordRepo.On("Retrieve", mock.Anything, mock.Anything, mock.Anything).Return([]users.PasswordHistory{}, nil).Maybe()
+
+	return passwordRepo
+}
+
+func newDomainsClient() *domainsmocks.DomainsServiceClient {
+	domainsClient := new(domainsmocks.DomainsServiceClient)
+	domainsClient.On("RequiresMFA", mock.Anything, mock.Anything).Return(&grpcDomainsV1.RequiresMFARes{}, nil).Maybe()
+
+	return domainsClient
 }
 
 func TestRegister(t *testing.T) {
@@ -221,7 +269,7 @@ func TestRegister(t *testing.T) {
 		policyCall := policies.On("AddPolicies", context.Background(), mock.Anything).Return(tc.addPoliciesResponseErr)
 		policyCall1 := policies.On("DeletePolicies", context.Background(), mock.Anything).Return(tc.deletePoliciesResponseErr)
 		repoCall := cRepo.On("Save", context.Background(), mock.Anything).Return(tc.user, tc.saveErr)
-		expected, err := svc.Register(context.Background(), authn.Session{}, tc.user, true)
+		expected, err := svc.Register(context.Background(), authn.Session{}, tc.user, true, "")
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 		if err == nil {
 			tc.user.ID = expected.ID
@@ -269,7 +317,7 @@ func TestRegister(t *testing.T) {
 		policyCall := policies.On("AddPolicies", context.Background(), mock.Anything).Return(tc.addPoliciesResponseErr)
 		policyCall1 := policies.On("DeletePolicies", context.Background(), mock.Anything).Return(tc.deletePoliciesResponseErr)
 		repoCall1 := cRepo.On("Save", context.Background(), mock.Anything).Return(tc.user, tc.saveErr)
-		expected, err := svc.Register(context.Background(), authn.Session{UserID: validID}, tc.user, false)
+		expected, err := svc.Register(context.Background(), authn.Session{UserID: validID}, tc.user, false, "")
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 		if err == nil {
 			tc.user.ID = expected.ID
@@ -506,6 +554,10 @@ func TestUpdateUser(t *testing.T) {
 	user2 := user
 	user1.FirstName = "Updated user"
 	user2.Metadata = users.Metadata{"role": "test"}
+	user3 := user
+	user3.Metadata = users.Metadata{"role": "test", "oauth_provider": "google", "oauth_subject": "other", "directory_dn": "uid=admin,dc=example,dc=com"}
+	stored := user
+	stored.Metadata = users.Metadata{"oauth_provider": "google", "oauth_subject": "subject"}
 	adminID := testsutil.GenerateUUID(t)
 
 	cases := []struct {
@@ -514,6 +566,9 @@ func TestUpdateUser(t *testing.T) {
 		session            authn.Session
 		updateResponse     users.User
 		token              string
+		retrieveResponse   users.User
+		retrieveErr        error
+		metadata           users.Metadata
 		updateErr          error
 		checkSuperAdminErr error
 		err                error
@@ -532,8 +587,27 @@ func TestUpdateUser(t *testing.T) {
 			session:        authn.Session{UserID: user2.ID},
 			updateResponse: user2,
 			token:          validToken,
+			metadata:       user2.Metadata,
 			err:            nil,
 		},
+		{
+			desc:             "update metadata with identity bindings as normal user",
+			user:             user3,
+			session:          authn.Session{UserID: user3.ID},
+			updateResponse:   user2,
+			token:            validToken,
+			retrieveResponse: stored,
+			metadata:         users.Metadata{"role": "test", "oauth_provider": "google", "oauth_subject": "subject"},
+			err:              nil,
+		},
+		{
+			desc:        "update metadata with failed to retrieve user",
+			user:        user2,
+			session:     authn.Session{UserID: user2.ID},
+			token:       validToken,
+			retrieveErr: repoerr.ErrNotFound,
+			err:         svcerr.ErrViewEntity,
+		},
 		{
 			desc:           "update user name as normal user with repo error on update",
 			user:           user1,
@@ -557,6 +631,7 @@ func TestUpdateUser(t *testing.T) {
 			session:        authn.Session{UserID: adminID, SuperAdmin: true},
 			updateResponse: user2,
 			token:          validToken,
+			metadata:       user2.Metadata,
 			err:            nil,
 		},
 		{
@@ -581,15 +656,20 @@ func TestUpdateUser(t *testing.T) {
 	for _, tc := range cases {
 		repoCall := cRepo.On("CheckSuperAdmin", context.Background(), mock.Anything).Return(tc.checkSuperAdminErr)
 		repoCall1 := cRepo.On("Update", context.Background(), mock.Anything).Return(tc.updateResponse, tc.err)
+		repoCall2 := cRepo.On("RetrieveByID", context.Background(), tc.user.ID).Return(tc.retrieveResponse, tc.retrieveErr)
 		updatedUser, err := svc.Update(context.Background(), tc.session, tc.user)
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 		assert.Equal(t, tc.updateResponse, updatedUser, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.updateResponse, updatedUser))
 		if tc.err == nil {
-			ok := repoCall1.Parent.AssertCalled(t, "Update", context.Background(), mock.Anything)
+			ok := repoCall1.Parent.AssertCalled(t, "Update", context.Background(), mock.MatchedBy(func(u users.User) bool {
+				return tc.metadata == nil || assert.ObjectsAreEqual(tc.metadata, u.Metadata)
+			}))
 			assert.True(t, ok, fmt.Sprintf("Update was not called on %s", tc.desc))
 		}
 		repoCall.Unset()
 		repoCall1.Unset()
+		repoCall2.Unset()
+		cRepo.Calls = nil
 	}
 }
 
@@ -782,6 +862,7 @@ func TestUpdateSecret(t *testing.T) {
 		retrieveByEmailErr      error
 		updateSecretErr         error
 		issueErr                error
+		revokeErr               error
 		err                     error
 	}{
 		{
@@ -844,6 +925,18 @@ func TestUpdateSecret(t *testing.T) {
 			updateSecretErr:         repoerr.ErrMalformedEntity,
 			err:                     svcerr.ErrUpdateEntity,
 		},
+		{
+			desc:                    "update user secret with failed to revoke sessions",
+			oldSecret:               user.Credentials.Secret,
+			newSecret:               newSecret,
+			session:                 authn.Session{UserID: user.ID},
+			retrieveByIDResponse:    user,
+			retrieveByEmailResponse: rUser,
+			updateSecretResponse:    responseUser,
+			issueResponse:           &grpcTokenV1.Token{AccessToken: validToken},
+			revokeErr:               svcerr.ErrRemoveEntity,
+			err:                     svcerr.ErrRemoveEntity,
+		},
 	}
 
 	for _, tc := range cases {
@@ -851,6 +944,7 @@ func TestUpdateSecret(t *testing.T) {
 		repoCall1 := cRepo.On("RetrieveByUsername", context.Background(), user.Credentials.Username).Return(tc.retrieveByEmailResponse, tc.retrieveByEmailErr)
 		repoCall2 := cRepo.On("UpdateSecret", context.Background(), mock.Anything).Return(tc.updateSecretResponse, tc.updateSecretErr)
 		authCall := authUser.On("Issue", context.Background(), mock.Anything).Return(tc.issueResponse, tc.issueErr)
+		authCall1 := authUser.On("RevokeSessions", context.Background(), &grpcTokenV1.RevokeSessionsReq{UserId: responseUser.ID}).Return(&grpcTokenV1.RevokeSessionsRes{}, tc.revokeErr)
 		updatedUser, err := svc.UpdateSecret(context.Background(), tc.session, tc.oldSecret, tc.newSecret)
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 		assert.Equal(t, tc.response, updatedUser, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.response, updatedUser))
@@ -861,11 +955,14 @@ func TestUpdateSecret(t *testing.T) {
 			assert.True(t, ok, fmt.Sprintf("RetrieveByUsername was not called on %s", tc.desc))
 			ok = repoCall2.Parent.AssertCalled(t, "UpdateSecret", context.Background(), mock.Anything)
 			assert.True(t, ok, fmt.Sprintf("UpdateSecret was not called on %s", tc.desc))
+			ok = authCall1.Parent.AssertCalled(t, "RevokeSessions", context.Background(), &grpcTokenV1.RevokeSessionsReq{UserId: responseUser.ID})
+			assert.True(t, ok, fmt.Sprintf("RevokeSessions was not called on %s", tc.desc))
 		}
 		repoCall.Unset()
 		repoCall1.Unset()
 		repoCall2.Unset()
 		authCall.Unset()
+		authCall1.Unset()
 	}
 }
 
@@ -1170,7 +1267,7 @@ func TestEnableUser(t *testing.T) {
 }
 
 func TestDisableUser(t *testing.T) {
-	svc, cRepo := newServiceMinimal()
+	svc, authUser, cRepo, _, _ := newService()
 
 	enabledUser1 := users.User{ID: testsutil.GenerateUUID(t), Credentials: users.Credentials{Username: "user1@example.com", Secret: "password"}, Status: users.EnabledStatus}
 	disabledUser1 := users.User{ID: testsutil.GenerateUUID(t), Credentials: users.Credentials{Username: "user3@example.com", Secret: "password"}, Status: users.DisabledStatus}
@@ -1187,6 +1284,7 @@ func TestDisableUser(t *testing.T) {
 		retrieveByIDErr      error
 		changeStatusErr      error
 		checkSuperAdminErr   error
+		revokeErr            error
 		err                  error
 	}{
 		{
@@ -1228,12 +1326,22 @@ func TestDisableUser(t *testing.T) {
 			changeStatusErr:      repoerr.ErrMalformedEntity,
 			err:                  svcerr.ErrUpdateEntity,
 		},
+		{
+			desc:                 "disable enabled user with failed to revoke sessions",
+			id:                   enabledUser1.ID,
+			user:                 enabledUser1,
+			retrieveByIDResponse: enabledUser1,
+			changeStatusResponse: disenabledUser1,
+			revokeErr:            svcerr.ErrRemoveEntity,
+			err:                  svcerr.ErrRemoveEntity,
+		},
 	}
 
 	for _, tc := range cases {
 		repoCall := cRepo.On("CheckSuperAdmin", context.Background(), mock.Anything).Return(tc.checkSuperAdminErr)
 		repoCall1 := cRepo.On("RetrieveByID", context.Background(), tc.id).Return(tc.retrieveByIDResponse, tc.retrieveByIDErr)
 		repoCall2 := cRepo.On("ChangeStatus", context.Background(), mock.Anything).Return(tc.changeStatusResponse, tc.changeStatusErr)
+		authCall := authUser.On("RevokeSessions", context.Background(), &grpcTokenV1.RevokeSessionsReq{UserId: tc.id}).Return(&grpcTokenV1.RevokeSessionsRes{}, tc.revokeErr)
 
 		_, err := svc.Disable(context.Background(), authn.Session{}, tc.id)
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
@@ -1242,10 +1350,13 @@ func TestDisableUser(t *testing.T) {
 			assert.True(t, ok, fmt.Sprintf("RetrieveByID was not called on %s", tc.desc))
 			ok = repoCall2.Parent.AssertCalled(t, "ChangeStatus", context.Background(), mock.Anything)
 			assert.True(t, ok, fmt.Sprintf("ChangeStatus was not called on %s", tc.desc))
+			ok = authCall.Parent.AssertCalled(t, "RevokeSessions", context.Background(), &grpcTokenV1.RevokeSessionsReq{UserId: tc.id})
+			assert.True(t, ok, fmt.Sprintf("RevokeSessions was not called on %s", tc.desc))
 		}
 		repoCall.Unset()
 		repoCall1.Unset()
 		repoCall2.Unset()
+		authCall.Unset()
 	}
 }
 
@@ -1685,7 +1796,7 @@ func TestIssueToken(t *testing.T) {
 	for _, tc := range cases {
 		repoCall := cRepo.On("RetrieveByUsername", context.Background(), tc.user.Credentials.Username).Return(tc.retrieveByUsernameResponse, tc.retrieveByUsernameErr)
 		authCall := auth.On("Issue", context.Background(), &grpcTokenV1.IssueReq{UserId: tc.user.ID, Type: uint32(smqauth.AccessKey)}).Return(tc.issueResponse, tc.issueErr)
-		token, err := svc.IssueToken(context.Background(), tc.user.Credentials.Username, tc.user.Credentials.Secret)
+		token, err := svc.IssueToken(context.Background(), tc.user.Credentials.Username, tc.user.Credentials.Secret, "", "")
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 		if err == nil {
 			assert.NotEmpty(t, token.GetAccessToken(), fmt.Sprintf("%s: expected %s not to be empty\n", tc.desc, token.GetAccessToken()))
@@ -1762,7 +1873,7 @@ func TestRefreshToken(t *testing.T) {
 	for _, tc := range cases {
 		authCall := authsvc.On("Refresh", context.Background(), &grpcTokenV1.RefreshReq{RefreshToken: validToken}).Return(tc.refreshResp, tc.refresErr)
 		repoCall := crepo.On("RetrieveByID", context.Background(), tc.session.UserID).Return(tc.repoResp, tc.repoErr)
-		token, err := svc.RefreshToken(context.Background(), tc.session, validToken)
+		token, err := svc.RefreshToken(context.Background(), tc.session, validToken, "")
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 		if err == nil {
 			assert.NotEmpty(t, token.GetAccessToken(), fmt.Sprintf("%s: expected %s not to be empty\n", tc.desc, token.GetAccessToken()))
@@ -1825,7 +1936,7 @@ func TestGenerateResetToken(t *testing.T) {
 			repoCall := cRepo.On("RetrieveByEmail", context.Background(), tc.email).Return(tc.retrieveByEmailResponse, tc.retrieveByEmailErr)
 			authCall := auth.On("Issue", context.Background(), mock.Anything).Return(tc.issueResponse, tc.issueErr)
 			svcCall := e.On("SendPasswordReset", []string{tc.email}, tc.host, user.Credentials.Username, validToken).Return(tc.err)
-			err := svc.GenerateResetToken(context.Background(), tc.email, tc.host)
+			err := svc.GenerateResetToken(context.Background(), tc.email, tc.host, "")
 			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 			repoCall.Parent.AssertCalled(t, "RetrieveByEmail", context.Background(), tc.email)
 			repoCall.Unset()
@@ -1836,7 +1947,7 @@ func TestGenerateResetToken(t *testing.T) {
 }
 
 func TestResetSecret(t *testing.T) {
-	svc, cRepo := newServiceMinimal()
+	svc, authUser, cRepo, _, _ := newService()
 
 	user := users.User{
 		ID:    "userID",
@@ -1854,6 +1965,7 @@ func TestResetSecret(t *testing.T) {
 		updateSecretResponse users.User
 		retrieveByIDErr      error
 		updateSecretErr      error
+		revokeErr            error
 		err                  error
 	}{
 		{
@@ -1904,18 +2016,30 @@ func TestResetSecret(t *testing.T) {
 			retrieveByIDResponse: user,
 			err:                  errHashPassword,
 		},
+		{
+			desc:                 "reset secret with failed to revoke sessions",
+			newSecret:            "newStrongSecret",
+			session:              authn.Session{UserID: validID, SuperAdmin: true},
+			retrieveByIDResponse: user,
+			updateSecretResponse: user,
+			revokeErr:            svcerr.ErrRemoveEntity,
+			err:                  svcerr.ErrRemoveEntity,
+		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
 			repoCall := cRepo.On("RetrieveByID", context.Background(), mock.Anything).Return(tc.retrieveByIDResponse, tc.retrieveByIDErr)
 			repoCall1 := cRepo.On("UpdateSecret", context.Background(), mock.Anything).Return(tc.updateSecretResponse, tc.updateSecretErr)
+			authCall := authUser.On("RevokeSessions", context.Background(), &grpcTokenV1.RevokeSessionsReq{UserId: tc.retrieveByIDResponse.ID}).Return(&grpcTokenV1.RevokeSessionsRes{}, tc.revokeErr)
 			err := svc.ResetSecret(context.Background(), tc.session, tc.newSecret)
 			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 			if tc.err == nil {
 				repoCall1.Parent.AssertCalled(t, "UpdateSecret", context.Background(), mock.Anything)
 				repoCall.Parent.AssertCalled(t, "RetrieveByID", context.Background(), validID)
+				authCall.Parent.AssertCalled(t, "RevokeSessions", context.Background(), &grpcTokenV1.RevokeSessionsReq{UserId: tc.retrieveByIDResponse.ID})
 			}
+			authCall.Unset()
 			repoCall1.Unset()
 			repoCall.Unset()
 		})
@@ -1971,6 +2095,19 @@ func TestViewProfile(t *testing.T) {
 func TestOAuthCallback(t *testing.T) {
 	svc, _, cRepo, policies, _ := newService()
 
+	identity := users.Metadata{"oauth_provider": "google", "oauth_subject": "subject"}
+	oauthUser := users.User{
+		Email:      "test@example.com",
+		Metadata:   users.Metadata{"oauth_provider": "google", "oauth_subject": "subject", "profile_picture": "http://localhost/picture.png"},
+		VerifiedAt: time.Now(),
+	}
+	unverified := oauthUser
+	unverified.VerifiedAt = time.Time{}
+	withoutSubject := oauthUser
+	withoutSubject.Metadata = users.Metadata{"oauth_provider": "google"}
+	bound := users.Metadata{"oauth_provider": "google", "oauth_subject": "subject", "role": "test"}
+	userID := testsutil.GenerateUUID(t)
+
 	cases := []struct {
 		desc                    string
 		user                    users.User
@@ -1978,71 +2115,2798 @@ func TestOAuthCallback(t *testing.T) {
 		retrieveByEmailErr      error
 		saveResponse            users.User
 		addPoliciesErr          error
+		updateErr               error
+		linked                  users.Metadata
 		err                     error
 	}{
 		{
-			desc: "oauth signin callback with already existing user",
-			user: users.User{
-				Email: "test@example.com",
+			desc: "oauth signin callback with user bound to the identity",
+			user: oauthUser,
+			retrieveByEmailResponse: users.User{
+				ID:       userID,
+				Metadata: bound,
+				Role:     users.UserRole,
 			},
+			err: nil,
+		},
+		{
+			desc: "oauth signin callback with super admin bound to the identity",
+			user: unverified,
 			retrieveByEmailResponse: users.User{
-				ID:   testsutil.GenerateUUID(t),
-				Role: users.UserRole,
+				ID:       userID,
+				Metadata: bound,
+				Role:     users.AdminRole,
 			},
 			err: nil,
 		},
 		{
-			desc: "oauth signup callback with user not found",
-			user: users.User{
-				Email: "test@example.com",
+			desc: "oauth signin callback with user registered with the provider before the subject was stored",
+			user: unverified,
+			retrieveByEmailResponse: users.User{
+				ID:       userID,
+				Metadata: users.Metadata{"oauth_provider": "google"},
+				Role:     users.UserRole,
 			},
-			retrieveByEmailErr: repoerr.ErrNotFound,
-			saveResponse: users.User{
-				ID:   testsutil.GenerateUUID(t),
+			linked: identity,
+			err:    nil,
+		},
+		{
+			desc: "oauth signin callback with local user and verified email",
+			user: oauthUser,
+			retrieveByEmailResponse: users.User{
+				ID:       userID,
+				Metadata: users.Metadata{"role": "test"},
+				Role:     users.UserRole,
+			},
+			linked: bound,
+			err:    nil,
+		},
+		{
+			desc: "oauth signin callback with user bound to another subject and verified email",
+			user: oauthUser,
+			retrieveByEmailResponse: users.User{
+				ID:       userID,
+				Metadata: users.Metadata{"oauth_provider": "google", "oauth_subject": "other"},
+				Role:     users.UserRole,
+			},
+			linked: identity,
+			err:    nil,
+		},
+		{
+			desc: "oauth signin callback with local user and unverified email",
+			user: unverified,
+			retrieveByEmailResponse: users.User{
+				ID:   userID,
 				Role: users.UserRole,
 			},
-			err: nil,
+			err: svcerr.ErrAuthentication,
 		},
 		{
-			desc: "oauth signup callback with malformed entity",
-			user: users.User{
-				Email: "test@example.com",
+			desc: "oauth signin callback with user bound to another subject and unverified email",
+			user: unverified,
+			retrieveByEmailResponse: users.User{
+				ID:       userID,
+				Metadata: users.Metadata{"oauth_provider": "google", "oauth_subject": "other"},
+				Role:     users.UserRole,
 			},
-			retrieveByEmailErr: repoerr.ErrMalformedEntity,
-			err:                repoerr.ErrMalformedEntity,
+			err: svcerr.ErrAuthentication,
 		},
 		{
-			desc: "oauth signup callback with failed to register user",
-			user: users.User{
-				Email: "test@example.com",
+			desc: "oauth signin callback with super admin email and verified email",
+			user: oauthUser,
+			retrieveByEmailResponse: users.User{
+				ID:   userID,
+				Role: users.AdminRole,
 			},
-			addPoliciesErr:     svcerr.ErrAuthorization,
-			retrieveByEmailErr: repoerr.ErrNotFound,
-			err:                svcerr.ErrAuthorization,
+			err: svcerr.ErrAuthentication,
 		},
 		{
-			desc: "oauth signin callback with user not in the platform",
-			user: users.User{
-				Email: "test@example.com",
+			desc: "oauth signin callback with super admin bound to another provider and verified email",
+			user: oauthUser,
+			retrieveByEmailResponse: users.User{
+				ID:       userID,
+				Metadata: users.Metadata{"oauth_provider": "keycloak", "oauth_subject": "subject"},
+				Role:     users.AdminRole,
+			},
+			err: svcerr.ErrAuthentication,
+		},
+		{
+			desc: "oauth signin callback with identity without subject",
+			user: withoutSubject,
+			retrieveByEmailResponse: users.User{
+				ID:       userID,
+				Metadata: users.Metadata{"oauth_provider": "google"},
+				Role:     users.UserRole,
 			},
+			err: svcerr.ErrAuthentication,
+		},
+		{
+			desc: "oauth signin callback with failed to link user",
+			user: oauthUser,
 			retrieveByEmailResponse: users.User{
+				ID:   userID,
+				Role: users.UserRole,
+			},
+			updateErr: repoerr.ErrUpdateEntity,
+			linked:    identity,
+			err:       svcerr.ErrUpdateEntity,
+		},
+		{
+			desc:               "oauth signup callback with user not found",
+			user:               oauthUser,
+			retrieveByEmailErr: repoerr.ErrNotFound,
+			saveResponse: users.User{
 				ID:   testsutil.GenerateUUID(t),
 				Role: users.UserRole,
 			},
 			err: nil,
 		},
+		{
+			desc:               "oauth signup callback with malformed entity",
+			user:               oauthUser,
+			retrieveByEmailErr: repoerr.ErrMalformedEntity,
+			err:                repoerr.ErrMalformedEntity,
+		},
+		{
+			desc:               "oauth signup callback with failed to register user",
+			user:               oauthUser,
+			addPoliciesErr:     svcerr.ErrAuthorization,
+			retrieveByEmailErr: repoerr.ErrNotFound,
+			err:                svcerr.ErrAuthorization,
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.desc, func(t *testing.T) {
 			repoCall := cRepo.On("RetrieveByEmail", context.Background(), tc.user.Email).Return(tc.retrieveByEmailResponse, tc.retrieveByEmailErr)
 			repoCall1 := cRepo.On("Save", context.Background(), mock.Anything).Return(tc.saveResponse, nil)
+			repoCall2 := cRepo.On("Update", context.Background(), mock.Anything).Return(users.User{}, tc.updateErr)
 			policyCall := policies.On("AddPolicies", context.Background(), mock.Anything).Return(tc.addPoliciesErr)
-			_, err := svc.OAuthCallback(context.Background(), tc.user)
+			_, err := svc.OAuthCallback(context.Background(), tc.user, nil)
 			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 			repoCall.Parent.AssertCalled(t, "RetrieveByEmail", context.Background(), tc.user.Email)
+			switch tc.linked {
+			case nil:
+				cRepo.AssertNotCalled(t, "Update", context.Background(), mock.Anything)
+			default:
+				cRepo.AssertCalled(t, "Update", context.Background(), mock.MatchedBy(func(u users.User) bool {
+					return u.ID == userID && assert.ObjectsAreEqual(tc.linked, u.Metadata)
+				}))
+			}
 			repoCall.Unset()
 			repoCall1.Unset()
+			repoCall2.Unset()
 			policyCall.Unset()
+			cRepo.Calls = nil
+		})
+	}
+}
+
+func TestOAuthCallbackDomainRoles(t *testing.T) {
+	identity := users.Metadata{"oauth_provider": "google", "oauth_subject": "subject"}
+	ruser := users.User{ID: testsutil.GenerateUUID(t), Metadata: identity, Role: users.UserRole}
+	roles := []users.DomainRole{
+		{DomainID: testsutil.GenerateUUID(t), Role: "admin"},
+		{DomainID: testsutil.GenerateUUID(t), Role: "member"},
+	}
+
+	cases := []struct {
+		desc  string
+		roles []users.DomainRole
+		err   error
+		res   error
+	}{
+		{
+			desc:  "oauth callback with domain roles",
+			roles: roles,
+			err:   nil,
+		},
+		{
+			desc:  "oauth callback without domain roles",
+			roles: nil,
+			err:   nil,
+		},
+		{
+			desc:  "oauth callback with failed to add domain role",
+			roles: roles,
+			res:   svcerr.ErrNotFound,
+			err:   svcerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, _, cRepo, _, domainsClient := newMFAService()
+			cRepo.On("RetrieveByEmail", context.Background(), "test@example.com").Return(ruser, nil)
+			domainsClient.On("AddUserRole", context.Background(), mock.Anything).Return(&grpcDomainsV1.AddUserRoleRes{Added: tc.res == nil}, tc.res)
+			user, err := svc.OAuthCallback(context.Background(), users.User{Email: "test@example.com", Metadata: identity}, tc.roles)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if tc.err != nil {
+				domainsClient.AssertNumberOfCalls(t, "AddUserRole", 1)
+				return
+			}
+			assert.Equal(t, ruser.ID, user.ID)
+			domainsClient.AssertNumberOfCalls(t, "AddUserRole", len(tc.roles))
+			for _, role := range tc.roles {
+				domainsClient.AssertCalled(t, "AddUserRole", context.Background(), &grpcDomainsV1.AddUserRoleReq{DomainId: role.DomainID, RoleName: role.Role, UserId: ruser.ID})
+			}
+		})
+	}
+}
+
+func TestIssueTokenMFA(t *testing.T) {
+	rUser := user
+	rUser.Credentials.Secret, _ = phasher.Hash(user.Credentials.Secret)
+
+	cases := []struct {
+		desc        string
+		mfa         users.MFA
+		mfaErr      error
+		requiresRes *grpcDomainsV1.RequiresMFARes
+		requiresErr error
+		saveErr     error
+		accessType  string
+		err         error
+	}{
+		{
+			desc:       "issue token for user with enabled MFA",
+			mfa:        users.MFA{UserID: user.ID, Enabled: true},
+			accessType: users.MFAAccessType,
+			err:        nil,
+		},
+		{
+			desc:        "issue token for user who must enroll MFA",
+			mfa:         users.MFA{UserID: user.ID},
+			requiresRes: &grpcDomainsV1.RequiresMFARes{Required: true},
+			accessType:  users.MFAEnrollAccessType,
+			err:         nil,
+		},
+		{
+			desc:        "issue token for user without MFA",
+			mfaErr:      repoerr.ErrNotFound,
+			requiresRes: &grpcDomainsV1.RequiresMFARes{},
+			accessType:  "3",
+			err:         nil,
+		},
+		{
+			desc:   "issue token with failed to retrieve MFA",
+			mfaErr: repoerr.ErrViewEntity,
+			err:    repoerr.ErrViewEntity,
+		},
+		{
+			desc:        "issue token with failed to check domains MFA requirement",
+			mfaErr:      repoerr.ErrNotFound,
+			requiresRes: &grpcDomainsV1.RequiresMFARes{},
+			requiresErr: svcerr.ErrViewEntity,
+			err:         svcerr.ErrViewEntity,
+		},
+		{
+			desc:       "issue token with failed to save MFA challenge",
+			mfa:        users.MFA{UserID: user.ID, Enabled: true},
+			saveErr:    repoerr.ErrCreateEntity,
+			accessType: users.MFAAccessType,
+			err:        repoerr.ErrCreateEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, auth, cRepo, mfaRepo, domainsClient := newMFAService()
+			cRepo.On("RetrieveByUsername", context.Background(), user.Credentials.Username).Return(rUser, nil)
+			mfaRepo.On("Retrieve", context.Background(), user.ID).Return(tc.mfa, tc.mfaErr)
+			mfaRepo.On("SaveChallenge", context.Background(), mock.Anything).Return(tc.saveErr)
+			domainsClient.On("RequiresMFA", context.Background(), &grpcDomainsV1.RequiresMFAReq{UserId: user.ID}).Return(tc.requiresRes, tc.requiresErr)
+			auth.On("Issue", context.Background(), &grpcTokenV1.IssueReq{UserId: user.ID, Type: uint32(smqauth.AccessKey)}).Return(&grpcTokenV1.Token{AccessToken: validToken, RefreshToken: &validToken, AccessType: "3"}, nil)
+			token, err := svc.IssueToken(context.Background(), user.Credentials.Username, user.Credentials.Secret, "", "")
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if err == nil {
+				assert.Equal(t, tc.accessType, token.GetAccessType())
+				assert.NotEmpty(t, token.GetAccessToken())
+			}
+			if tc.accessType == users.MFAAccessType || tc.accessType == users.MFAEnrollAccessType {
+				auth.AssertNotCalled(t, "Issue", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestOAuthIssueToken(t *testing.T) {
+	disabled := user
+	disabled.Status = users.DisabledStatus
+
+	cases := []struct {
+		desc        string
+		user        users.User
+		retrieveErr error
+		mfa         users.MFA
+		mfaErr      error
+		requiresRes *grpcDomainsV1.RequiresMFARes
+		accessType  string
+		err         error
+	}{
+		{
+			desc:       "issue token for OAuth user with enabled MFA",
+			user:       user,
+			mfa:        users.MFA{UserID: user.ID, Enabled: true},
+			accessType: users.MFAAccessType,
+			err:        nil,
+		},
+		{
+			desc:        "issue token for OAuth user who must enroll MFA",
+			user:        user,
+			mfa:         users.MFA{UserID: user.ID},
+			requiresRes: &grpcDomainsV1.RequiresMFARes{Required: true},
+			accessType:  users.MFAEnrollAccessType,
+			err:         nil,
+		},
+		{
+			desc:        "issue token for OAuth user without MFA",
+			user:        user,
+			mfaErr:      repoerr.ErrNotFound,
+			requiresRes: &grpcDomainsV1.RequiresMFARes{},
+			accessType:  "3",
+			err:         nil,
+		},
+		{
+			desc: "issue token for disabled OAuth user",
+			user: disabled,
+			err:  svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "issue token with failed to retrieve OAuth user",
+			user:        user,
+			retrieveErr: repoerr.ErrNotFound,
+			err:         svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, auth, cRepo, mfaRepo, domainsClient := newMFAService()
+			cRepo.On("RetrieveByID", context.Background(), user.ID).Return(tc.user, tc.retrieveErr)
+			mfaRepo.On("Retrieve", context.Background(), user.ID).Return(tc.mfa, tc.mfaErr)
+			mfaRepo.On("SaveChallenge", context.Background(), mock.Anything).Return(nil)
+			domainsClient.On("RequiresMFA", context.Background(), &grpcDomainsV1.RequiresMFAReq{UserId: user.ID}).Return(tc.requiresRes, nil)
+			auth.On("Issue", context.Background(), &grpcTokenV1.IssueReq{UserId: user.ID, Type: uint32(smqauth.AccessKey)}).Return(&grpcTokenV1.Token{AccessToken: validToken, RefreshToken: &validToken, AccessType: "3"}, nil)
+			token, err := svc.OAuthIssueToken(context.Background(), users.User{ID: user.ID}, "", "")
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if err == nil {
+				assert.Equal(t, tc.accessType, token.GetAccessType())
+				assert.NotEmpty(t, token.GetAccessToken())
+			}
+			if tc.accessType != "3" {
+				auth.AssertNotCalled(t, "Issue", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestIssueMFAToken(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	assert.Nil(t, err, fmt.Sprintf("generate secret unexpected error: %s", err))
+	code, err := totp.Code(secret, time.Now())
+	assert.Nil(t, err, fmt.Sprintf("generate code unexpected error: %s", err))
+
+	challenge := users.MFAChallenge{ID: validID, UserID: user.ID, Device: "Mozilla/5.0", IP: "192.168.0.1", Attempts: 1}
+	issueReq := &grpcTokenV1.IssueReq{UserId: user.ID, Type: uint32(smqauth.AccessKey), Device: challenge.Device, Ip: challenge.IP}
+	token := &grpcTokenV1.Token{AccessToken: validToken, RefreshToken: &validToken, AccessType: "3"}
+	recoveryHash, err := phasher.Hash("abcdefghij")
+	assert.Nil(t, err, fmt.Sprintf("hash recovery code unexpected error: %s", err))
+
+	cases := []struct {
+		desc         string
+		code         string
+		challenge    users.MFAChallenge
+		challengeErr error
+		mfa          users.MFA
+		mfaErr       error
+		useStepErr   error
+		enableErr    error
+		recoveryErr  error
+		removeErr    error
+		issueErr     error
+		err          error
+	}{
+		{
+			desc:      "issue MFA token with valid code",
+			code:      code,
+			challenge: challenge,
+			mfa:       users.MFA{UserID: user.ID, Secret: secret, Enabled: true},
+			err:       nil,
+		},
+		{
+			desc:      "issue MFA token with valid code of pending enrollment",
+			code:      code,
+			challenge: challenge,
+			mfa:       users.MFA{UserID: user.ID, Secret: secret},
+			err:       nil,
+		},
+		{
+			desc:      "issue MFA token with recovery code",
+			code:      "ABCDE-FGHIJ",
+			challenge: challenge,
+			mfa:       users.MFA{UserID: user.ID, Secret: secret, Enabled: true, RecoveryCodes: []string{recoveryHash}},
+			err:       nil,
+		},
+		{
+			desc:      "issue MFA token with unknown recovery code",
+			code:      "KLMNO-PQRST",
+			challenge: challenge,
+			mfa:       users.MFA{UserID: user.ID, Secret: secret, Enabled: true, RecoveryCodes: []string{recoveryHash}},
+			err:       svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "issue MFA token with used recovery code",
+			code:        "abcde-fghij",
+			challenge:   challenge,
+			mfa:         users.MFA{UserID: user.ID, Secret: secret, Enabled: true, RecoveryCodes: []string{recoveryHash}},
+			recoveryErr: repoerr.ErrNotFound,
+			err:         svcerr.ErrAuthentication,
+		},
+		{
+			desc:         "issue MFA token with invalid MFA token",
+			code:         code,
+			challengeErr: repoerr.ErrNotFound,
+			err:          svcerr.ErrAuthentication,
+		},
+		{
+			desc:      "issue MFA token with exhausted attempts",
+			code:      code,
+			challenge: users.MFAChallenge{ID: validID, UserID: user.ID, Attempts: 6},
+			err:       svcerr.ErrAuthentication,
+		},
+		{
+			desc:      "issue MFA token for user without MFA",
+			code:      code,
+			challenge: challenge,
+			mfaErr:    repoerr.ErrNotFound,
+			err:       svcerr.ErrAuthentication,
+		},
+		{
+			desc:       "issue MFA token with used code",
+			code:       code,
+			challenge:  challenge,
+			mfa:        users.MFA{UserID: user.ID, Secret: secret, Enabled: true},
+			useStepErr: repoerr.ErrNotFound,
+			err:        svcerr.ErrAuthentication,
+		},
+		{
+			desc:      "issue MFA token with invalid code",
+			code:      "000000",
+			challenge: challenge,
+			mfa:       users.MFA{UserID: user.ID, Secret: secret, Enabled: true, RecoveryCodes: []string{recoveryHash}},
+			err:       svcerr.ErrAuthentication,
+		},
+		{
+			desc:      "issue MFA token with recovery code of pending enrollment",
+			code:      "ABCDE-FGHIJ",
+			challenge: challenge,
+			mfa:       users.MFA{UserID: user.ID, Secret: secret},
+			err:       svcerr.ErrAuthentication,
+		},
+		{
+			desc:      "issue MFA token with failed to remove challenge",
+			code:      code,
+			challenge: challenge,
+			mfa:       users.MFA{UserID: user.ID, Secret: secret, Enabled: true},
+			removeErr: repoerr.ErrRemoveEntity,
+			err:       repoerr.ErrRemoveEntity,
+		},
+		{
+			desc:      "issue MFA token with failed to issue token",
+			code:      code,
+			challenge: challenge,
+			mfa:       users.MFA{UserID: user.ID, Secret: secret, Enabled: true},
+			issueErr:  svcerr.ErrAuthentication,
+			err:       svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, auth, _, mfaRepo, _ := newMFAService()
+			mfaRepo.On("RetrieveChallenge", context.Background(), mock.Anything).Return(tc.challenge, tc.challengeErr)
+			mfaRepo.On("RemoveChallenge", context.Background(), tc.challenge.ID).Return(tc.removeErr)
+			mfaRepo.On("Retrieve", context.Background(), tc.challenge.UserID).Return(tc.mfa, tc.mfaErr)
+			mfaRepo.On("UseStep", context.Background(), user.ID, mock.Anything).Return(tc.useStepErr)
+			mfaRepo.On("Enable", context.Background(), user.ID, mock.Anything).Return(tc.enableErr)
+			mfaRepo.On("UseRecoveryCode", context.Background(), user.ID, recoveryHash).Return(tc.recoveryErr)
+			auth.On("Issue", context.Background(), issueReq).Return(token, tc.issueErr)
+			res, err := svc.IssueMFAToken(context.Background(), validToken, tc.code)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if err == nil {
+				assert.Equal(t, token.GetAccessToken(), res.GetAccessToken())
+			}
+			if tc.recoveryErr != nil || (err == nil && len(tc.code) != 6) {
+				mfaRepo.AssertCalled(t, "UseRecoveryCode", context.Background(), user.ID, recoveryHash)
+			}
+		})
+	}
+}
+
+func TestEnrollMFA(t *testing.T) {
+	cases := []struct {
+		desc        string
+		session     authn.Session
+		retrieveErr error
+		saveErr     error
+		err         error
+	}{
+		{
+			desc:    "enroll MFA successfully",
+			session: authn.Session{UserID: user.ID},
+			err:     nil,
+		},
+		{
+			desc:        "enroll MFA with failed to retrieve user",
+			session:     authn.Session{UserID: user.ID},
+			retrieveErr: repoerr.ErrNotFound,
+			err:         svcerr.ErrViewEntity,
+		},
+		{
+			desc:    "enroll MFA with enabled MFA",
+			session: authn.Session{UserID: user.ID},
+			saveErr: repoerr.ErrConflict,
+			err:     svcerr.ErrConflict,
+		},
+		{
+			desc:    "enroll MFA with failed to save MFA",
+			session: authn.Session{UserID: user.ID},
+			saveErr: repoerr.ErrCreateEntity,
+			err:     svcerr.ErrCreateEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, _, cRepo, mfaRepo, _ := newMFAService()
+			cRepo.On("RetrieveByID", context.Background(), tc.session.UserID).Return(user, tc.retrieveErr)
+			mfaRepo.On("Save", context.Background(), mock.Anything).Return(tc.saveErr)
+			enrollment, err := svc.EnrollMFA(context.Background(), tc.session)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if err != nil {
+				return
+			}
+			assert.Contains(t, enrollment.URI, enrollment.Secret)
+			assert.Len(t, enrollment.RecoveryCodes, 10)
+			saved := mfaRepo.Calls[0].Arguments.Get(1).(users.MFA)
+			assert.Equal(t, enrollment.Secret, saved.Secret)
+			assert.False(t, saved.Enabled)
+			assert.Len(t, saved.RecoveryCodes, 10)
+			assert.NotContains(t, saved.RecoveryCodes, enrollment.RecoveryCodes[0])
+			// The codes are stored as the salted hashes of the normalized codes.
+			code := strings.ReplaceAll(enrollment.RecoveryCodes[0], "-", "")
+			assert.Nil(t, phasher.Compare(code, saved.RecoveryCodes[0]), fmt.Sprintf("%s: expected the hash of the recovery code", tc.desc))
+			other, err := phasher.Hash(code)
+			assert.Nil(t, err, fmt.Sprintf("hash recovery code unexpected error: %s", err))
+			assert.NotEqual(t, other, saved.RecoveryCodes[0], fmt.Sprintf("%s: expected the salted hash of the recovery code", tc.desc))
+		})
+	}
+}
+
+func TestEnrollMFAWithChallenge(t *testing.T) {
+	cases := []struct {
+		desc         string
+		challenge    users.MFAChallenge
+		challengeErr error
+		saveErr      error
+		err          error
+	}{
+		{
+			desc:      "enroll MFA with valid MFA token",
+			challenge: users.MFAChallenge{ID: validID, UserID: user.ID, Attempts: 1},
+			err:       nil,
+		},
+		{
+			desc:         "enroll MFA with invalid MFA token",
+			challengeErr: repoerr.ErrNotFound,
+			err:          svcerr.ErrAuthentication,
+		},
+		{
+			desc:      "enroll MFA with enabled MFA",
+			challenge: users.MFAChallenge{ID: validID, UserID: user.ID, Attempts: 1},
+			saveErr:   repoerr.ErrConflict,
+			err:       svcerr.ErrConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, _, cRepo, mfaRepo, _ := newMFAService()
+			mfaRepo.On("RetrieveChallenge", context.Background(), mock.Anything).Return(tc.challenge, tc.challengeErr)
+			cRepo.On("RetrieveByID", context.Background(), user.ID).Return(user, nil)
+			mfaRepo.On("Save", context.Background(), mock.Anything).Return(tc.saveErr)
+			_, err := svc.EnrollMFAWithChallenge(context.Background(), validToken)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		})
+	}
+}
+
+func TestVerifyMFA(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	assert.Nil(t, err, fmt.Sprintf("generate secret unexpected error: %s", err))
+	code, err := totp.Code(secret, time.Now())
+	assert.Nil(t, err, fmt.Sprintf("generate code unexpected error: %s", err))
+
+	session := authn.Session{UserID: user.ID}
+	cases := []struct {
+		desc      string
+		code      string
+		mfa       users.MFA
+		mfaErr    error
+		enableErr error
+		err       error
+	}{
+		{
+			desc: "verify MFA with valid code",
+			code: code,
+			mfa:  users.MFA{UserID: user.ID, Secret: secret},
+			err:  nil,
+		},
+		{
+			desc: "verify MFA with invalid code",
+			code: "000000",
+			mfa:  users.MFA{UserID: user.ID, Secret: secret},
+			err:  svcerr.ErrAuthentication,
+		},
+		{
+			desc:   "verify MFA without enrollment",
+			code:   code,
+			mfaErr: repoerr.ErrNotFound,
+			err:    svcerr.ErrNotFound,
+		},
+		{
+			desc: "verify MFA with enabled MFA",
+			code: code,
+			mfa:  users.MFA{UserID: user.ID, Secret: secret, Enabled: true},
+			err:  svcerr.ErrConflict,
+		},
+		{
+			desc:      "verify MFA with failed to enable MFA",
+			code:      code,
+			mfa:       users.MFA{UserID: user.ID, Secret: secret},
+			enableErr: repoerr.ErrUpdateEntity,
+			err:       svcerr.ErrUpdateEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, _, _, mfaRepo, _ := newMFAService()
+			mfaRepo.On("Retrieve", context.Background(), user.ID).Return(tc.mfa, tc.mfaErr)
+			mfaRepo.On("Enable", context.Background(), user.ID, mock.Anything).Return(tc.enableErr)
+			err := svc.VerifyMFA(context.Background(), session, tc.code)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		})
+	}
+}
+
+func TestResetMFA(t *testing.T) {
+	cases := []struct {
+		desc          string
+		session       authn.Session
+		id            string
+		superAdminErr error
+		removeErr     error
+		err           error
+	}{
+		{
+			desc:    "reset MFA as super admin",
+			session: authn.Session{UserID: validID, SuperAdmin: true},
+			id:      user.ID,
+			err:     nil,
+		},
+		{
+			desc:          "reset MFA as non super admin",
+			session:       authn.Session{UserID: validID},
+			id:            user.ID,
+			superAdminErr: repoerr.ErrNotFound,
+			err:           svcerr.ErrAuthorization,
+		},
+		{
+			desc:      "reset MFA of user without MFA",
+			session:   authn.Session{UserID: validID, SuperAdmin: true},
+			id:        user.ID,
+			removeErr: repoerr.ErrNotFound,
+			err:       svcerr.ErrNotFound,
+		},
+		{
+			desc:      "reset MFA with failed to remove MFA",
+			session:   authn.Session{UserID: validID, SuperAdmin: true},
+			id:        user.ID,
+			removeErr: repoerr.ErrRemoveEntity,
+			err:       svcerr.ErrRemoveEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, _, cRepo, mfaRepo, _ := newMFAService()
+			cRepo.On("CheckSuperAdmin", context.Background(), tc.session.UserID).Return(tc.superAdminErr)
+			mfaRepo.On("Remove", context.Background(), tc.id).Return(tc.removeErr)
+			err := svc.ResetMFA(context.Background(), tc.session, tc.id)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		})
+	}
+}
+
+var registration = users.RegistrationConfig{
+	VerifyEmail:          true,
+	Key:                  []byte("secret"),
+	VerificationDuration: time.Hour,
+	InvitationDuration:   time.Hour,
+}
+
+func newRegistrationService(rc users.RegistrationConfig) (users.Service, *authmocks.TokenServiceClient, *mocks.Repository, *policymocks.Service, *mocks.Emailer) {
+	cRepo := new(mocks.Repository)
+	policies := new(policymocks.Service)
+	e := new(mocks.Emailer)
+	tokenClient := new(authmocks.TokenServiceClient)
+	svc := users.NewService(tokenClient, cRepo, newMFARepo(), new(mocks.LockoutRepository), newPasswordRepo(), new(mocks.ServiceAccountRepository), newDomainsClient(), policies, e, phasher, idProvider, nil, rc, users.LockoutConfig{}, users.PasswordPolicy{})
+
+	return svc, tokenClient, cRepo, policies, e
+}
+
+// invite returns the registration invitation for the email sent by the
+// service with the registration config.
+func invite(t *testing.T, rc users.RegistrationConfig, email string) string {
+	svc, _, cRepo, _, e := newRegistrationService(rc)
+	cRepo.On("RetrieveByEmail", context.Background(), email).Return(users.User{}, repoerr.ErrNotFound)
+
+	var invitation string
+	e.On("SendInvitation", []string{email}, email, mock.Anything).Run(func(args mock.Arguments) {
+		invitation = args.String(2)
+	}).Return(nil)
+	err := svc.InviteUser(context.Background(), authn.Session{UserID: validID, SuperAdmin: true}, email)
+	assert.Nil(t, err, fmt.Sprintf("invite user unexpected error: %s", err))
+
+	return invitation
+}
+
+// verificationToken returns the email verification token sent by the
+// service with the registration config.
+func verificationToken(t *testing.T, rc users.RegistrationConfig, u users.User) string {
+	svc, _, cRepo, _, e := newRegistrationService(rc)
+	cRepo.On("RetrieveByEmail", context.Background(), u.Email).Return(u, nil)
+
+	var token string
+	e.On("SendVerification", []string{u.Email}, u.Credentials.Username, mock.Anything).Run(func(args mock.Arguments) {
+		token = args.String(2)
+	}).Return(nil)
+	err := svc.SendVerification(context.Background(), u.Email, "")
+	assert.Nil(t, err, fmt.Sprintf("send verification unexpected error: %s", err))
+
+	return token
+}
+
+func TestRegisterSelfRegistration(t *testing.T) {
+	domainsOnly := registration
+	domainsOnly.EmailDomains = []string{"example.com"}
+	inviteOnly := registration
+	inviteOnly.InviteOnly = true
+	expiredInvitation := registration
+	expiredInvitation.InvitationDuration = -time.Minute
+	otherKey := registration
+	otherKey.Key = []byte("other")
+	withoutVerification := registration
+	withoutVerification.VerifyEmail = false
+
+	newUser := users.User{
+		FirstName:   "firstname",
+		LastName:    "lastname",
+		Credentials: users.Credentials{Username: "newuser", Secret: secret},
+		Email:       "newuser@example.com",
+	}
+	otherDomainUser := newUser
+	otherDomainUser.Email = "newuser@other.com"
+	sendErr := errors.New("failed to send email")
+
+	cases := []struct {
+		desc       string
+		rc         users.RegistrationConfig
+		user       users.User
+		invitation string
+		sendErr    error
+		verified   bool
+		err        error
+	}{
+		{
+			desc:     "self-register without email verification",
+			rc:       withoutVerification,
+			user:     newUser,
+			verified: true,
+		},
+		{
+			desc: "self-register with email verification",
+			rc:   registration,
+			user: newUser,
+		},
+		{
+			desc:    "self-register with failed to send verification email",
+			rc:      registration,
+			user:    newUser,
+			sendErr: sendErr,
+			err:     sendErr,
+		},
+		{
+			desc: "self-register with allowed email domain",
+			rc:   domainsOnly,
+			user: newUser,
+		},
+		{
+			desc: "self-register with not allowed email domain",
+			rc:   domainsOnly,
+			user: otherDomainUser,
+			err:  svcerr.ErrAuthorization,
+		},
+		{
+			desc:       "self-register with invitation for not allowed email domain",
+			rc:         domainsOnly,
+			user:       otherDomainUser,
+			invitation: invite(t, domainsOnly, otherDomainUser.Email),
+			verified:   true,
+		},
+		{
+			desc: "self-register without invitation when invite-only",
+			rc:   inviteOnly,
+			user: newUser,
+			err:  svcerr.ErrAuthorization,
+		},
+		{
+			desc:       "self-register with invitation when invite-only",
+			rc:         inviteOnly,
+			user:       newUser,
+			invitation: invite(t, inviteOnly, newUser.Email),
+			verified:   true,
+		},
+		{
+			desc:       "self-register with invitation for another email",
+			rc:         inviteOnly,
+			user:       newUser,
+			invitation: invite(t, inviteOnly, otherDomainUser.Email),
+			err:        svcerr.ErrAuthorization,
+		},
+		{
+			desc:       "self-register with expired invitation",
+			rc:         expiredInvitation,
+			user:       newUser,
+			invitation: invite(t, expiredInvitation, newUser.Email),
+			err:        svcerr.ErrAuthorization,
+		},
+		{
+			desc:       "self-register with invitation signed with another key",
+			rc:         inviteOnly,
+			user:       newUser,
+			invitation: invite(t, otherKey, newUser.Email),
+			err:        svcerr.ErrAuthorization,
+		},
+		{
+			desc:       "self-register with invalid invitation",
+			rc:         inviteOnly,
+			user:       newUser,
+			invitation: "invalid",
+			err:        svcerr.ErrAuthorization,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, _, cRepo, policies, e := newRegistrationService(tc.rc)
+			policies.On("AddPolicies", context.Background(), mock.Anything).Return(nil)
+			policies.On("DeletePolicies", context.Background(), mock.Anything).Return(nil)
+			cRepo.On("Save", context.Background(), mock.Anything).Return(func(_ context.Context, u users.User) (users.User, error) {
+				return u, nil
+			})
+			cRepo.On("Delete", context.Background(), mock.Anything).Return(nil)
+			e.On("SendVerification", []string{tc.user.Email}, tc.user.Credentials.Username, mock.Anything).Return(tc.sendErr)
+
+			registered, err := svc.Register(context.Background(), authn.Session{}, tc.user, true, tc.invitation)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			switch {
+			case tc.sendErr != nil:
+				cRepo.AssertCalled(t, "Delete", context.Background(), mock.Anything)
+			case tc.err != nil:
+				cRepo.AssertNotCalled(t, "Save", context.Background(), mock.Anything)
+			case tc.verified:
+				assert.False(t, registered.VerifiedAt.IsZero(), fmt.Sprintf("%s: expected verified user", tc.desc))
+				e.AssertNotCalled(t, "SendVerification", mock.Anything, mock.Anything, mock.Anything)
+			default:
+				assert.True(t, registered.VerifiedAt.IsZero(), fmt.Sprintf("%s: expected unverified user", tc.desc))
+				e.AssertCalled(t, "SendVerification", []string{tc.user.Email}, tc.user.Credentials.Username, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestIssueTokenUnverified(t *testing.T) {
+	hash, err := phasher.Hash(secret)
+	assert.Nil(t, err, fmt.Sprintf("hash secret unexpected error: %s", err))
+	unverified := user
+	unverified.Credentials.Secret = hash
+	verified := unverified
+	verified.VerifiedAt = time.Now()
+	withoutVerification := registration
+	withoutVerification.VerifyEmail = false
+
+	cases := []struct {
+		desc string
+		rc   users.RegistrationConfig
+		user users.User
+		err  error
+	}{
+		{
+			desc: "issue token for verified user",
+			rc:   registration,
+			user: verified,
+		},
+		{
+			desc: "issue token for unverified user",
+			rc:   registration,
+			user: unverified,
+			err:  svcerr.ErrAuthentication,
+		},
+		{
+			desc: "issue token for unverified user without email verification",
+			rc:   withoutVerification,
+			user: unverified,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, auth, cRepo, _, _ := newRegistrationService(tc.rc)
+			cRepo.On("RetrieveByUsername", context.Background(), tc.user.Credentials.Username).Return(tc.user, nil)
+			cRepo.On("RetrieveByID", context.Background(), tc.user.ID).Return(tc.user, nil)
+			auth.On("Issue", context.Background(), mock.Anything).Return(&grpcTokenV1.Token{AccessToken: validToken, RefreshToken: &validToken}, nil)
+
+			_, err := svc.IssueToken(context.Background(), tc.user.Credentials.Username, secret, "", "")
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			_, err = svc.OAuthIssueToken(context.Background(), tc.user, "", "")
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s with OAuth got %s\n", tc.desc, tc.err, err))
+			if tc.err != nil {
+				auth.AssertNotCalled(t, "Issue", context.Background(), mock.Anything)
+			}
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	expired := registration
+	expired.VerificationDuration = -time.Minute
+	otherKey := registration
+	otherKey.Key = []byte("other")
+
+	verified := user
+	verified.VerifiedAt = time.Now()
+	changed := user
+	changed.Email = "changed@example.com"
+	token := verificationToken(t, registration, user)
+
+	cases := []struct {
+		desc             string
+		token            string
+		retrieveResponse users.User
+		retrieveErr      error
+		verifyErr        error
+		err              error
+	}{
+		{
+			desc:             "verify email successfully",
+			token:            token,
+			retrieveResponse: user,
+		},
+		{
+			desc:  "verify email with invalid token",
+			token: "invalid",
+			err:   svcerr.ErrAuthentication,
+		},
+		{
+			desc:  "verify email with expired token",
+			token: verificationToken(t, expired, user),
+			err:   svcerr.ErrAuthentication,
+		},
+		{
+			desc:  "verify email with token signed with another key",
+			token: verificationToken(t, otherKey, user),
+			err:   svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "verify email of non-existing user",
+			token:       token,
+			retrieveErr: repoerr.ErrNotFound,
+			err:         svcerr.ErrViewEntity,
+		},
+		{
+			desc:             "verify changed email",
+			token:            token,
+			retrieveResponse: changed,
+			err:              svcerr.ErrAuthentication,
+		},
+		{
+			desc:             "verify already verified email",
+			token:            token,
+			retrieveResponse: verified,
+			err:              svcerr.ErrConflict,
+		},
+		{
+			desc:             "verify email with failed to update user",
+			token:            token,
+			retrieveResponse: user,
+			verifyErr:        repoerr.ErrNotFound,
+			err:              svcerr.ErrUpdateEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, _, cRepo, _, _ := newRegistrationService(registration)
+			cRepo.On("RetrieveByID", context.Background(), user.ID).Return(tc.retrieveResponse, tc.retrieveErr)
+			cRepo.On("VerifyEmail", context.Background(), mock.Anything).Return(tc.retrieveResponse, tc.verifyErr)
+
+			err := svc.VerifyEmail(context.Background(), tc.token)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if tc.err == nil {
+				cRepo.AssertCalled(t, "VerifyEmail", context.Background(), mock.MatchedBy(func(u users.User) bool {
+					return u.ID == user.ID && u.Email == user.Email && !u.VerifiedAt.IsZero()
+				}))
+			}
+		})
+	}
+}
+
+func TestSendVerification(t *testing.T) {
+	verified := user
+	verified.VerifiedAt = time.Now()
+	sendErr := errors.New("failed to send email")
+
+	cases := []struct {
+		desc             string
+		retrieveResponse users.User
+		retrieveErr      error
+		sendErr          error
+		err              error
+	}{
+		{
+			desc:             "send verification successfully",
+			retrieveResponse: user,
+		},
+		{
+			desc:        "send verification to non-existing user",
+			retrieveErr: repoerr.ErrNotFound,
+			err:         svcerr.ErrViewEntity,
+		},
+		{
+			desc:             "send verification to verified user",
+			retrieveResponse: verified,
+			err:              svcerr.ErrConflict,
+		},
+		{
+			desc:             "send verification with failed to send email",
+			retrieveResponse: user,
+			sendErr:          sendErr,
+			err:              sendErr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, _, cRepo, _, e := newRegistrationService(registration)
+			cRepo.On("RetrieveByEmail", context.Background(), user.Email).Return(tc.retrieveResponse, tc.retrieveErr)
+			e.On("SendVerification", []string{user.Email}, user.Credentials.Username, mock.Anything).Return(tc.sendErr)
+
+			err := svc.SendVerification(context.Background(), user.Email, "")
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		})
+	}
+}
+
+func TestInviteUser(t *testing.T) {
+	email := "invited@example.com"
+	sendErr := errors.New("failed to send email")
+
+	cases := []struct {
+		desc               string
+		session            authn.Session
+		checkSuperAdminErr error
+		retrieveErr        error
+		sendErr            error
+		err                error
+	}{
+		{
+			desc:        "invite user successfully",
+			session:     authn.Session{UserID: validID, SuperAdmin: true},
+			retrieveErr: repoerr.ErrNotFound,
+		},
+		{
+			desc:               "invite user as non-admin",
+			session:            authn.Session{UserID: validID},
+			checkSuperAdminErr: repoerr.ErrNotFound,
+			err:                svcerr.ErrAuthorization,
+		},
+		{
+			desc:    "invite existing user",
+			session: authn.Session{UserID: validID, SuperAdmin: true},
+			err:     svcerr.ErrConflict,
+		},
+		{
+			desc:        "invite user with failed to send email",
+			session:     authn.Session{UserID: validID, SuperAdmin: true},
+			retrieveErr: repoerr.ErrNotFound,
+			sendErr:     sendErr,
+			err:         sendErr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, _, cRepo, _, e := newRegistrationService(registration)
+			cRepo.On("CheckSuperAdmin", context.Background(), validID).Return(tc.checkSuperAdminErr)
+			cRepo.On("RetrieveByEmail", context.Background(), email).Return(users.User{}, tc.retrieveErr)
+			e.On("SendInvitation", []string{email}, email, mock.Anything).Return(tc.sendErr)
+
+			err := svc.InviteUser(context.Background(), tc.session, email)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		})
+	}
+}
+
+func TestOAuthCallbackRegistrationPolicy(t *testing.T) {
+	domainsOnly := registration
+	domainsOnly.EmailDomains = []string{"example.com"}
+	inviteOnly := registration
+	inviteOnly.InviteOnly = true
+
+	cases := []struct {
+		desc  string
+		rc    users.RegistrationConfig
+		email string
+		err   error
+	}{
+		{
+			desc:  "oauth signup verifies the email",
+			rc:    registration,
+			email: "test@example.com",
+		},
+		{
+			desc:  "oauth signup with allowed email domain",
+			rc:    domainsOnly,
+			email: "test@example.com",
+		},
+		{
+			desc:  "oauth signup with not allowed email domain",
+			rc:    domainsOnly,
+			email: "test@other.com",
+			err:   svcerr.ErrAuthorization,
+		},
+		{
+			desc:  "oauth signup when invite-only",
+			rc:    inviteOnly,
+			email: "test@example.com",
+			err:   svcerr.ErrAuthorization,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, _, cRepo, policies, e := newRegistrationService(tc.rc)
+			cRepo.On("RetrieveByEmail", context.Background(), tc.email).Return(users.User{}, repoerr.ErrNotFound)
+			cRepo.On("Save", context.Background(), mock.Anything).Return(func(_ context.Context, u users.User) (users.User, error) {
+				return u, nil
+			})
+			policies.On("AddPolicies", context.Background(), mock.Anything).Return(nil)
+
+			_, err := svc.OAuthCallback(context.Background(), users.User{Email: tc.email}, nil)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if tc.err == nil {
+				cRepo.AssertCalled(t, "Save", context.Background(), mock.MatchedBy(func(u users.User) bool {
+					return !u.VerifiedAt.IsZero()
+				}))
+				e.AssertNotCalled(t, "SendVerification", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+var lockout = users.LockoutConfig{
+	MaxAttempts:   3,
+	MaxIPAttempts: 10,
+	Duration:      time.Minute,
+	Delay:         time.Second,
+	MaxDelay:      4 * time.Second,
+}
+
+func newLockoutService() (users.Service, *authmocks.TokenServiceClient, *mocks.Repository, *mocks.LockoutRepository, *mocks.Emailer) {
+	cRepo := new(mocks.Repository)
+	lockoutRepo := new(mocks.LockoutRepository)
+	e := new(mocks.Emailer)
+	tokenClient := new(authmocks.TokenServiceClient)
+	svc := users.NewService(tokenClient, cRepo, newMFARepo(), lockoutRepo, newPasswordRepo(), new(mocks.ServiceAccountRepository), newDomainsClient(), new(policymocks.Service), e, phasher, idProvider, nil, users.RegistrationConfig{}, lockout, users.PasswordPolicy{})
+
+	return svc, tokenClient, cRepo, lockoutRepo, e
+}
+
+// retrieveAttempts returns the response of the attempts repository, which
+// doesn't find the key without the failed attempts.
+func retrieveAttempts(attempts users.LoginAttempts, err error) (users.LoginAttempts, error) {
+	if attempts.Failures == 0 && err == nil {
+		return users.LoginAttempts{}, repoerr.ErrNotFound
+	}
+
+	return attempts, err
+}
+
+func TestIssueTokenLockout(t *testing.T) {
+	hash, err := phasher.Hash(secret)
+	assert.Nil(t, err, fmt.Sprintf("hash secret unexpected error: %s", err))
+	rUser := user
+	rUser.Credentials.Secret = hash
+	ip := "192.168.0.1"
+	identityKey := "login:identity:" + user.ID
+	unknownKey := "login:identity:" + user.Credentials.Username
+	ipKey := "login:ip:" + ip
+	now := time.Now().UTC()
+
+	cases := []struct {
+		desc             string
+		identity         string
+		secret           string
+		identityAttempts users.LoginAttempts
+		ipAttempts       users.LoginAttempts
+		attemptsErr      error
+		retrieveErr      error
+		failIdentity     users.LoginAttempts
+		failIP           users.LoginAttempts
+		failErr          error
+		failed           bool
+		err              error
+	}{
+		{
+			desc:   "issue token without failed attempts",
+			secret: secret,
+			err:    nil,
+		},
+		{
+			desc:         "issue token with email and wrong secret",
+			identity:     strings.ToUpper(user.Email),
+			secret:       "wrongsecret",
+			failIdentity: users.LoginAttempts{Key: identityKey, Failures: 1, LastFailure: now},
+			failIP:       users.LoginAttempts{Key: ipKey, Failures: 1, LastFailure: now},
+			failed:       true,
+			err:          svcerr.ErrLogin,
+		},
+		{
+			desc:             "issue token with email and locked out username",
+			identity:         user.Email,
+			secret:           secret,
+			identityAttempts: users.LoginAttempts{Key: identityKey, Failures: 3, LastFailure: now.Add(-30 * time.Second)},
+			err:              svcerr.ErrAuthentication,
+		},
+		{
+			desc:             "issue token after the delay",
+			secret:           secret,
+			identityAttempts: users.LoginAttempts{Key: identityKey, Failures: 2, LastFailure: now.Add(-3 * time.Second)},
+			err:              nil,
+		},
+		{
+			desc:       "issue token after the max delay",
+			secret:     secret,
+			ipAttempts: users.LoginAttempts{Key: ipKey, Failures: 9, LastFailure: now.Add(-5 * time.Second)},
+			err:        nil,
+		},
+		{
+			desc:         "issue token with wrong secret",
+			secret:       "wrongsecret",
+			failIdentity: users.LoginAttempts{Key: identityKey, Failures: 1, LastFailure: now},
+			failIP:       users.LoginAttempts{Key: ipKey, Failures: 1, LastFailure: now},
+			failed:       true,
+			err:          svcerr.ErrLogin,
+		},
+		{
+			desc:         "issue token with wrong secret locking out the identity",
+			secret:       "wrongsecret",
+			failIdentity: users.LoginAttempts{Key: identityKey, Failures: 3, LastFailure: now},
+			failIP:       users.LoginAttempts{Key: ipKey, Failures: 3, LastFailure: now},
+			failed:       true,
+			err:          users.ErrLockedOut,
+		},
+		{
+			desc:         "issue token with wrong secret locking out the IP",
+			secret:       "wrongsecret",
+			failIdentity: users.LoginAttempts{Key: identityKey, Failures: 1, LastFailure: now},
+			failIP:       users.LoginAttempts{Key: ipKey, Failures: 10, LastFailure: now},
+			failed:       true,
+			err:          users.ErrLockedOut,
+		},
+		{
+			desc:         "issue token for non-existing user",
+			secret:       secret,
+			retrieveErr:  repoerr.ErrNotFound,
+			failIdentity: users.LoginAttempts{Key: unknownKey, Failures: 1, LastFailure: now},
+			failIP:       users.LoginAttempts{Key: ipKey, Failures: 1, LastFailure: now},
+			failed:       true,
+			err:          svcerr.ErrAuthentication,
+		},
+		{
+			desc:             "issue token within the delay",
+			secret:           secret,
+			identityAttempts: users.LoginAttempts{Key: identityKey, Failures: 2, LastFailure: now},
+			err:              svcerr.ErrAuthentication,
+		},
+		{
+			desc:             "issue token with locked out identity",
+			secret:           secret,
+			identityAttempts: users.LoginAttempts{Key: identityKey, Failures: 3, LastFailure: now.Add(-30 * time.Second)},
+			err:              svcerr.ErrAuthentication,
+		},
+		{
+			desc:       "issue token with locked out IP",
+			secret:     secret,
+			ipAttempts: users.LoginAttempts{Key: ipKey, Failures: 10, LastFailure: now},
+			err:        svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "issue token with failed to retrieve user",
+			secret:      secret,
+			retrieveErr: repoerr.ErrViewEntity,
+			err:         svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "issue token with failed to retrieve attempts",
+			secret:      secret,
+			attemptsErr: repoerr.ErrViewEntity,
+			err:         repoerr.ErrViewEntity,
+		},
+		{
+			desc:    "issue token with wrong secret and failed to count the attempt",
+			secret:  "wrongsecret",
+			failErr: repoerr.ErrUpdateEntity,
+			failed:  true,
+			err:     repoerr.ErrUpdateEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if tc.identity == "" {
+				tc.identity = user.Credentials.Username
+			}
+			key, retrieveRes := identityKey, rUser
+			if tc.retrieveErr != nil {
+				key, retrieveRes = unknownKey, users.User{}
+			}
+			svc, auth, cRepo, lockoutRepo, _ := newLockoutService()
+			lockoutRepo.On("Retrieve", context.Background(), key, mock.Anything).Return(retrieveAttempts(tc.identityAttempts, tc.attemptsErr))
+			lockoutRepo.On("Retrieve", context.Background(), ipKey, mock.Anything).Return(retrieveAttempts(tc.ipAttempts, nil))
+			lockoutRepo.On("Fail", context.Background(), key, mock.Anything, mock.Anything).Return(tc.failIdentity, tc.failErr)
+			lockoutRepo.On("Fail", context.Background(), ipKey, mock.Anything, mock.Anything).Return(tc.failIP, tc.failErr)
+			lockoutRepo.On("Remove", context.Background(), key).Return(nil)
+			cRepo.On("RetrieveByUsername", context.Background(), user.Credentials.Username).Return(retrieveRes, tc.retrieveErr)
+			cRepo.On("RetrieveByEmail", context.Background(), tc.identity).Return(retrieveRes, tc.retrieveErr)
+			auth.On("Issue", context.Background(), mock.Anything).Return(&grpcTokenV1.Token{AccessToken: validToken, RefreshToken: &validToken}, nil)
+
+			_, err := svc.IssueToken(context.Background(), tc.identity, tc.secret, "", ip)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			switch {
+			case err == nil:
+				lockoutRepo.AssertCalled(t, "Remove", context.Background(), key)
+			case tc.failed:
+				lockoutRepo.AssertCalled(t, "Fail", context.Background(), key, mock.Anything, mock.Anything)
+				lockoutRepo.AssertNotCalled(t, "Remove", context.Background(), key)
+			default:
+				lockoutRepo.AssertNotCalled(t, "Fail", context.Background(), key, mock.Anything, mock.Anything)
+			}
+			if err != nil {
+				auth.AssertNotCalled(t, "Issue", context.Background(), mock.Anything)
+			}
+		})
+	}
+}
+
+func TestOAuthIssueTokenLockout(t *testing.T) {
+	ip := "192.168.0.1"
+	identityKey := "login:identity:" + user.ID
+	ipKey := "login:ip:" + ip
+	now := time.Now().UTC()
+
+	cases := []struct {
+		desc             string
+		identityAttempts users.LoginAttempts
+		ipAttempts       users.LoginAttempts
+		err              error
+	}{
+		{
+			desc: "issue token for OAuth user without failed attempts",
+			err:  nil,
+		},
+		{
+			desc:             "issue token for locked out OAuth user",
+			identityAttempts: users.LoginAttempts{Key: identityKey, Failures: 3, LastFailure: now.Add(-30 * time.Second)},
+			err:              svcerr.ErrAuthentication,
+		},
+		{
+			desc:       "issue token for OAuth user from locked out IP",
+			ipAttempts: users.LoginAttempts{Key: ipKey, Failures: 10, LastFailure: now},
+			err:        svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, auth, cRepo, lockoutRepo, _ := newLockoutService()
+			lockoutRepo.On("Retrieve", context.Background(), identityKey, mock.Anything).Return(retrieveAttempts(tc.identityAttempts, nil))
+			lockoutRepo.On("Retrieve", context.Background(), ipKey, mock.Anything).Return(retrieveAttempts(tc.ipAttempts, nil))
+			cRepo.On("RetrieveByID", context.Background(), user.ID).Return(user, nil)
+			auth.On("Issue", context.Background(), mock.Anything).Return(&grpcTokenV1.Token{AccessToken: validToken, RefreshToken: &validToken}, nil)
+
+			_, err := svc.OAuthIssueToken(context.Background(), users.User{ID: user.ID}, "", ip)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if err != nil {
+				auth.AssertNotCalled(t, "Issue", context.Background(), mock.Anything)
+			}
+			lockoutRepo.AssertNotCalled(t, "Fail", context.Background(), mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestGenerateResetTokenLockout(t *testing.T) {
+	ip := "192.168.0.1"
+	emailKey := "reset:email:" + user.Email
+	ipKey := "reset:ip:" + ip
+	now := time.Now().UTC()
+
+	cases := []struct {
+		desc          string
+		ip            string
+		emailAttempts users.LoginAttempts
+		failEmail     users.LoginAttempts
+		failIP        users.LoginAttempts
+		sent          bool
+		err           error
+	}{
+		{
+			desc:      "generate reset token without previous requests",
+			ip:        ip,
+			failEmail: users.LoginAttempts{Key: emailKey, Failures: 1, LastFailure: now},
+			failIP:    users.LoginAttempts{Key: ipKey, Failures: 1, LastFailure: now},
+			sent:      true,
+			err:       nil,
+		},
+		{
+			desc:      "generate reset token without the client IP",
+			failEmail: users.LoginAttempts{Key: emailKey, Failures: 1, LastFailure: now},
+			sent:      true,
+			err:       nil,
+		},
+		{
+			desc:          "generate reset token locking out the email",
+			ip:            ip,
+			emailAttempts: users.LoginAttempts{Key: emailKey, Failures: 2, LastFailure: now.Add(-3 * time.Second)},
+			failEmail:     users.LoginAttempts{Key: emailKey, Failures: 3, LastFailure: now},
+			failIP:        users.LoginAttempts{Key: ipKey, Failures: 3, LastFailure: now},
+			err:           users.ErrLockedOut,
+		},
+		{
+			desc:          "generate reset token within the delay",
+			ip:            ip,
+			emailAttempts: users.LoginAttempts{Key: emailKey, Failures: 1, LastFailure: now},
+			err:           svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, auth, cRepo, lockoutRepo, e := newLockoutService()
+			lockoutRepo.On("Retrieve", context.Background(), emailKey, mock.Anything).Return(retrieveAttempts(tc.emailAttempts, nil))
+			lockoutRepo.On("Retrieve", context.Background(), ipKey, mock.Anything).Return(retrieveAttempts(users.LoginAttempts{}, nil))
+			lockoutRepo.On("Fail", context.Background(), emailKey, mock.Anything, mock.Anything).Return(tc.failEmail, nil)
+			lockoutRepo.On("Fail", context.Background(), ipKey, mock.Anything, mock.Anything).Return(tc.failIP, nil)
+			cRepo.On("RetrieveByEmail", context.Background(), user.Email).Return(user, nil)
+			auth.On("Issue", context.Background(), mock.Anything).Return(&grpcTokenV1.Token{AccessToken: validToken}, nil)
+			e.On("SendPasswordReset", []string{user.Email}, "examplehost", user.Credentials.Username, validToken).Return(nil)
+
+			err := svc.GenerateResetToken(context.Background(), user.Email, "examplehost", tc.ip)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if tc.sent {
+				e.AssertCalled(t, "SendPasswordReset", []string{user.Email}, "examplehost", user.Credentials.Username, validToken)
+			} else {
+				e.AssertNotCalled(t, "SendPasswordReset", []string{user.Email}, "examplehost", user.Credentials.Username, validToken)
+			}
+			if tc.ip == "" {
+				lockoutRepo.AssertNotCalled(t, "Fail", context.Background(), ipKey, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestSendVerificationLockout(t *testing.T) {
+	ip := "192.168.0.1"
+	emailKey := "verify:email:" + user.Email
+	ipKey := "verify:ip:" + ip
+	now := time.Now().UTC()
+
+	cases := []struct {
+		desc          string
+		ip            string
+		emailAttempts users.LoginAttempts
+		ipAttempts    users.LoginAttempts
+		failEmail     users.LoginAttempts
+		failIP        users.LoginAttempts
+		sent          bool
+		err           error
+	}{
+		{
+			desc:      "send verification without previous requests",
+			ip:        ip,
+			failEmail: users.LoginAttempts{Key: emailKey, Failures: 1, LastFailure: now},
+			failIP:    users.LoginAttempts{Key: ipKey, Failures: 1, LastFailure: now},
+			sent:      true,
+			err:       nil,
+		},
+		{
+			desc:      "send verification without the client IP",
+			failEmail: users.LoginAttempts{Key: emailKey, Failures: 1, LastFailure: now},
+			sent:      true,
+			err:       nil,
+		},
+		{
+			desc:          "send verification locking out the email",
+			ip:            ip,
+			emailAttempts: users.LoginAttempts{Key: emailKey, Failures: 2, LastFailure: now.Add(-3 * time.Second)},
+			failEmail:     users.LoginAttempts{Key: emailKey, Failures: 3, LastFailure: now},
+			failIP:        users.LoginAttempts{Key: ipKey, Failures: 3, LastFailure: now},
+			err:           users.ErrLockedOut,
+		},
+		{
+			desc:          "send verification within the delay",
+			ip:            ip,
+			emailAttempts: users.LoginAttempts{Key: emailKey, Failures: 1, LastFailure: now},
+			err:           svcerr.ErrAuthentication,
+		},
+		{
+			desc:       "send verification from the locked out IP",
+			ip:         ip,
+			ipAttempts: users.LoginAttempts{Key: ipKey, Failures: 10, LastFailure: now},
+			err:        svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, _, cRepo, lockoutRepo, e := newLockoutService()
+			lockoutRepo.On("Retrieve", context.Background(), emailKey, mock.Anything).Return(retrieveAttempts(tc.emailAttempts, nil))
+			lockoutRepo.On("Retrieve", context.Background(), ipKey, mock.Anything).Return(retrieveAttempts(tc.ipAttempts, nil))
+			lockoutRepo.On("Fail", context.Background(), emailKey, mock.Anything, mock.Anything).Return(tc.failEmail, nil)
+			lockoutRepo.On("Fail", context.Background(), ipKey, mock.Anything, mock.Anything).Return(tc.failIP, nil)
+			cRepo.On("RetrieveByEmail", context.Background(), user.Email).Return(user, nil)
+			e.On("SendVerification", []string{user.Email}, user.Credentials.Username, mock.Anything).Return(nil)
+
+			err := svc.SendVerification(context.Background(), user.Email, tc.ip)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if tc.sent {
+				e.AssertCalled(t, "SendVerification", []string{user.Email}, user.Credentials.Username, mock.Anything)
+			} else {
+				e.AssertNotCalled(t, "SendVerification", []string{user.Email}, user.Credentials.Username, mock.Anything)
+			}
+			if tc.ip == "" {
+				lockoutRepo.AssertNotCalled(t, "Fail", context.Background(), ipKey, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestUnlock(t *testing.T) {
+	keys := []string{
+		"login:identity:" + user.ID,
+		"reset:email:" + user.Email,
+		"verify:email:" + user.Email,
+	}
+
+	cases := []struct {
+		desc          string
+		session       authn.Session
+		id            string
+		superAdminErr error
+		retrieveErr   error
+		removeErr     error
+		err           error
+	}{
+		{
+			desc:    "unlock user as super admin",
+			session: authn.Session{UserID: validID, SuperAdmin: true},
+			id:      user.ID,
+			err:     nil,
+		},
+		{
+			desc:          "unlock user as non super admin",
+			session:       authn.Session{UserID: validID},
+			id:            user.ID,
+			superAdminErr: repoerr.ErrNotFound,
+			err:           svcerr.ErrAuthorization,
+		},
+		{
+			desc:        "unlock non-existing user",
+			session:     authn.Session{UserID: validID, SuperAdmin: true},
+			id:          wrongID,
+			retrieveErr: repoerr.ErrNotFound,
+			err:         svcerr.ErrViewEntity,
+		},
+		{
+			desc:      "unlock user with failed to remove attempts",
+			session:   authn.Session{UserID: validID, SuperAdmin: true},
+			id:        user.ID,
+			removeErr: repoerr.ErrRemoveEntity,
+			err:       svcerr.ErrRemoveEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, _, cRepo, lockoutRepo, _ := newLockoutService()
+			cRepo.On("CheckSuperAdmin", context.Background(), tc.session.UserID).Return(tc.superAdminErr)
+			cRepo.On("RetrieveByID", context.Background(), tc.id).Return(user, tc.retrieveErr)
+			lockoutRepo.On("Remove", context.Background(), keys[0], keys[1], keys[2]).Return(tc.removeErr)
+
+			err := svc.Unlock(context.Background(), tc.session, tc.id)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if tc.err == nil {
+				lockoutRepo.AssertCalled(t, "Remove", context.Background(), keys[0], keys[1], keys[2])
+			}
+		})
+	}
+}
+
+func TestImpersonate(t *testing.T) {
+	target := user
+	target.Role = users.UserRole
+	target.Status = users.EnabledStatus
+	admin := users.User{ID: validID, Role: users.AdminRole, Credentials: users.Credentials{Username: "admin"}}
+	adminTarget := target
+	adminTarget.Role = users.AdminRole
+	disabledTarget := target
+	disabledTarget.Status = users.DisabledStatus
+
+	cases := []struct {
+		desc          string
+		session       authn.Session
+		id            string
+		readOnly      bool
+		superAdminErr error
+		retrieveResp  users.User
+		retrieveErr   error
+		emailErr      error
+		issueResp     *grpcTokenV1.Token
+		issueErr      error
+		err           error
+	}{
+		{
+			desc:         "impersonate user as super admin",
+			session:      authn.Session{UserID: validID, SuperAdmin: true},
+			id:           target.ID,
+			retrieveResp: target,
+			issueResp:    &grpcTokenV1.Token{AccessToken: validToken},
+			err:          nil,
+		},
+		{
+			desc:         "impersonate user read-only as super admin",
+			session:      authn.Session{UserID: validID, SuperAdmin: true},
+			id:           target.ID,
+			readOnly:     true,
+			retrieveResp: target,
+			issueResp:    &grpcTokenV1.Token{AccessToken: validToken},
+			err:          nil,
+		},
+		{
+			desc:          "impersonate user as non super admin",
+			session:       authn.Session{UserID: validID},
+			id:            target.ID,
+			superAdminErr: repoerr.ErrNotFound,
+			err:           svcerr.ErrAuthorization,
+		},
+		{
+			desc:    "impersonate user by impersonated session",
+			session: authn.Session{UserID: validID, SuperAdmin: true, ImpersonatorID: testsutil.GenerateUUID(t)},
+			id:      target.ID,
+			err:     svcerr.ErrAuthorization,
+		},
+		{
+			desc:    "impersonate self",
+			session: authn.Session{UserID: validID, SuperAdmin: true},
+			id:      validID,
+			err:     svcerr.ErrAuthorization,
+		},
+		{
+			desc:        "impersonate non-existing user",
+			session:     authn.Session{UserID: validID, SuperAdmin: true},
+			id:          wrongID,
+			retrieveErr: repoerr.ErrNotFound,
+			err:         svcerr.ErrViewEntity,
+		},
+		{
+			desc:         "impersonate super admin",
+			session:      authn.Session{UserID: validID, SuperAdmin: true},
+			id:           adminTarget.ID,
+			retrieveResp: adminTarget,
+			err:          svcerr.ErrAuthorization,
+		},
+		{
+			desc:         "impersonate disabled user",
+			session:      authn.Session{UserID: validID, SuperAdmin: true},
+			id:           disabledTarget.ID,
+			retrieveResp: disabledTarget,
+			err:          svcerr.ErrAuthentication,
+		},
+		{
+			desc:         "impersonate user with failed to send notification",
+			session:      authn.Session{UserID: validID, SuperAdmin: true},
+			id:           target.ID,
+			retrieveResp: target,
+			emailErr:     svcerr.ErrMalformedEntity,
+			err:          svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:         "impersonate user with failed to issue token",
+			session:      authn.Session{UserID: validID, SuperAdmin: true},
+			id:           target.ID,
+			retrieveResp: target,
+			issueResp:    &grpcTokenV1.Token{},
+			issueErr:     svcerr.ErrAuthentication,
+			err:          svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, tokenClient, cRepo, _, e := newService()
+			cRepo.On("CheckSuperAdmin", context.Background(), tc.session.UserID).Return(tc.superAdminErr)
+			cRepo.On("RetrieveByID", context.Background(), tc.id).Return(tc.retrieveResp, tc.retrieveErr)
+			cRepo.On("RetrieveByID", context.Background(), validID).Return(admin, nil)
+			e.On("SendImpersonation", []string{tc.retrieveResp.Email}, tc.retrieveResp.Credentials.Username, admin.Credentials.Username, tc.readOnly).Return(tc.emailErr)
+			issueReq := &grpcTokenV1.IssueReq{UserId: tc.id, Type: uint32(smqauth.AccessKey), ImpersonatorId: validID, ReadOnly: tc.readOnly}
+			tokenClient.On("Issue", context.Background(), issueReq).Return(tc.issueResp, tc.issueErr)
+
+			token, err := svc.Impersonate(context.Background(), tc.session, tc.id, tc.readOnly)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if tc.err == nil {
+				assert.Equal(t, tc.issueResp.AccessToken, token.AccessToken)
+				e.AssertCalled(t, "SendImpersonation", []string{tc.retrieveResp.Email}, tc.retrieveResp.Credentials.Username, admin.Credentials.Username, tc.readOnly)
+			}
+			if tc.emailErr != nil {
+				tokenClient.AssertNotCalled(t, "Issue", context.Background(), issueReq)
+			}
+		})
+	}
+}
+
+func newDirectoryService(directory users.Directory) (users.Service, *authmocks.TokenServiceClient, *mocks.Repository, *policymocks.Service, *domainsmocks.DomainsServiceClient) {
+	cRepo := new(mocks.Repository)
+	policies := new(policymocks.Service)
+	domainsClient := newDomainsClient()
+	tokenClient := new(authmocks.TokenServiceClient)
+	svc := users.NewService(tokenClient, cRepo, newMFARepo(), new(mocks.LockoutRepository), newPasswordRepo(), new(mocks.ServiceAccountRepository), domainsClient, policies, new(mocks.Emailer), phasher, idProvider, directory, users.RegistrationConfig{}, users.LockoutConfig{}, users.PasswordPolicy{})
+
+	return svc, tokenClient, cRepo, policies, domainsClient
+}
+
+func TestIssueTokenDirectory(t *testing.T) {
+	hash, err := phasher.Hash(secret)
+	assert.Nil(t, err, fmt.Sprintf("hash secret unexpected error: %s", err))
+	localUser := user
+	localUser.Credentials.Secret = hash
+	ruser := users.User{ID: testsutil.GenerateUUID(t), Email: "jdoe@example.com", Status: users.EnabledStatus, Metadata: users.Metadata{"directory_dn": "uid=jdoe,ou=people,dc=example,dc=com"}}
+	luser := users.User{ID: testsutil.GenerateUUID(t), Email: "jdoe@example.com", Status: users.EnabledStatus}
+	adminRole := users.DomainRole{DomainID: testsutil.GenerateUUID(t), Role: "admin"}
+	memberRole := users.DomainRole{DomainID: testsutil.GenerateUUID(t), Role: "member"}
+	duser := users.DirectoryUser{
+		DN:        "uid=jdoe,ou=people,dc=example,dc=com",
+		Email:     "jdoe@example.com",
+		FirstName: "John",
+		LastName:  "Doe",
+		Roles:     []users.DomainRole{adminRole},
+	}
+
+	cases := []struct {
+		desc          string
+		identity      string
+		secret        string
+		handles       bool
+		authUser      users.DirectoryUser
+		authErr       error
+		boundUsers    []users.User
+		retrieveUser  users.User
+		retrieveErr   error
+		saveErr       error
+		addRoleErr    error
+		removeRoleErr error
+		provisioned   bool
+		synced        bool
+		local         bool
+		err           error
+	}{
+		{
+			desc:       "issue token for existing directory user",
+			identity:   "jdoe",
+			secret:     secret,
+			handles:    true,
+			authUser:   duser,
+			boundUsers: []users.User{ruser},
+			synced:     true,
+		},
+		{
+			desc:        "issue token for new directory user",
+			identity:    "jdoe",
+			secret:      secret,
+			handles:     true,
+			authUser:    duser,
+			retrieveErr: repoerr.ErrNotFound,
+			provisioned: true,
+			synced:      true,
+		},
+		{
+			desc:        "issue token for new directory user with failed provisioning",
+			identity:    "jdoe",
+			secret:      secret,
+			handles:     true,
+			authUser:    duser,
+			retrieveErr: repoerr.ErrNotFound,
+			saveErr:     repoerr.ErrConflict,
+			provisioned: true,
+			err:         svcerr.ErrAuthentication,
+		},
+		{
+			desc:         "issue token for directory user with the email of a local user",
+			identity:     "jdoe",
+			secret:       secret,
+			handles:      true,
+			authUser:     duser,
+			retrieveUser: luser,
+			err:          svcerr.ErrAuthentication,
+		},
+		{
+			desc:     "issue token with invalid directory credentials",
+			identity: "jdoe",
+			secret:   "wrongsecret",
+			handles:  true,
+			authErr:  errors.Wrap(svcerr.ErrLogin, errors.New("invalid LDAP user credentials")),
+			err:      svcerr.ErrLogin,
+		},
+		{
+			desc:     "issue token with unavailable directory",
+			identity: "jdoe",
+			secret:   secret,
+			handles:  true,
+			authErr:  errors.New("failed to connect to LDAP server"),
+			err:      svcerr.ErrAuthentication,
+		},
+		{
+			desc:       "issue token with failed to add domain role",
+			identity:   "jdoe",
+			secret:     secret,
+			handles:    true,
+			authUser:   duser,
+			boundUsers: []users.User{ruser},
+			addRoleErr: svcerr.ErrNotFound,
+			err:        svcerr.ErrNotFound,
+		},
+		{
+			desc:          "issue token with failed to remove domain role",
+			identity:      "jdoe",
+			secret:        secret,
+			handles:       true,
+			authUser:      duser,
+			boundUsers:    []users.User{ruser},
+			removeRoleErr: svcerr.ErrNotFound,
+			err:           svcerr.ErrNotFound,
+		},
+		{
+			desc:     "issue token for local user not in the directory",
+			identity: user.Credentials.Username,
+			secret:   secret,
+			handles:  true,
+			authErr:  users.ErrDirectoryUserNotFound,
+			local:    true,
+		},
+		{
+			desc:     "issue token for identity not handled by the directory",
+			identity: user.Credentials.Username,
+			secret:   secret,
+			handles:  false,
+			local:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			directory := new(mocks.Directory)
+			svc, auth, cRepo, policies, domainsClient := newDirectoryService(directory)
+			directory.On("Handles", tc.identity).Return(tc.handles)
+			directory.On("Authenticate", context.Background(), tc.identity, tc.secret).Return(tc.authUser, tc.authErr)
+			directory.On("Roles").Return([]users.DomainRole{adminRole, memberRole})
+			cRepo.On("RetrieveAll", context.Background(), users.Page{Limit: 1, Metadata: users.Metadata{"directory_dn": duser.DN}, Status: users.EnabledStatus, Role: users.AllRole}).Return(users.UsersPage{Users: tc.boundUsers}, nil)
+			cRepo.On("RetrieveByEmail", context.Background(), duser.Email).Return(tc.retrieveUser, tc.retrieveErr)
+			cRepo.On("RetrieveByUsername", context.Background(), user.Credentials.Username).Return(localUser, nil)
+			cRepo.On("RetrieveByUsername", context.Background(), "jdoe").Return(users.User{}, repoerr.ErrNotFound)
+			cRepo.On("Save", context.Background(), mock.Anything).Return(ruser, tc.saveErr)
+			policies.On("AddPolicies", context.Background(), mock.Anything).Return(nil)
+			policies.On("DeletePolicies", context.Background(), mock.Anything).Return(nil)
+			domainsClient.On("AddUserRole", context.Background(), mock.Anything).Return(&grpcDomainsV1.AddUserRoleRes{Added: tc.addRoleErr == nil}, tc.addRoleErr)
+			domainsClient.On("RemoveUserRole", context.Background(), mock.Anything).Return(&grpcDomainsV1.RemoveUserRoleRes{Removed: tc.removeRoleErr == nil}, tc.removeRoleErr)
+			auth.On("Issue", context.Background(), mock.Anything).Return(&grpcTokenV1.Token{AccessToken: validToken}, nil)
+
+			token, err := svc.IssueToken(context.Background(), tc.identity, tc.secret, "", "")
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if !tc.handles {
+				directory.AssertNotCalled(t, "Authenticate", mock.Anything, mock.Anything, mock.Anything)
+			}
+			if tc.local {
+				cRepo.AssertCalled(t, "RetrieveByUsername", context.Background(), user.Credentials.Username)
+				auth.AssertCalled(t, "Issue", context.Background(), &grpcTokenV1.IssueReq{UserId: localUser.ID, Type: uint32(smqauth.AccessKey)})
+				return
+			}
+			cRepo.AssertNotCalled(t, "RetrieveByUsername", mock.Anything, user.Credentials.Username)
+			if tc.provisioned {
+				cRepo.AssertCalled(t, "Save", context.Background(), mock.MatchedBy(func(u users.User) bool {
+					return u.Email == duser.Email && u.FirstName == duser.FirstName && u.Credentials.Secret == "" && u.Credentials.Username == "" && !u.VerifiedAt.IsZero() && u.Metadata["directory_dn"] == duser.DN
+				}))
+			}
+			if len(tc.boundUsers) > 0 {
+				cRepo.AssertNotCalled(t, "RetrieveByEmail", mock.Anything, duser.Email)
+			}
+			if !tc.provisioned {
+				cRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+			}
+			if tc.synced {
+				domainsClient.AssertCalled(t, "AddUserRole", context.Background(), &grpcDomainsV1.AddUserRoleReq{DomainId: adminRole.DomainID, RoleName: adminRole.Role, UserId: ruser.ID})
+				domainsClient.AssertCalled(t, "RemoveUserRole", context.Background(), &grpcDomainsV1.RemoveUserRoleReq{DomainId: memberRole.DomainID, RoleName: memberRole.Role, UserId: ruser.ID})
+				domainsClient.AssertNumberOfCalls(t, "RemoveUserRole", 1)
+			}
+			if tc.err == nil {
+				assert.Equal(t, validToken, token.GetAccessToken())
+				auth.AssertCalled(t, "Issue", context.Background(), &grpcTokenV1.IssueReq{UserId: ruser.ID, Type: uint32(smqauth.AccessKey)})
+				return
+			}
+			auth.AssertNotCalled(t, "Issue", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+var passwordPolicy = users.PasswordPolicy{
+	MinLength:     10,
+	RequireUpper:  true,
+	RequireLower:  true,
+	RequireDigit:  true,
+	RequireSymbol: true,
+	Denylist:      map[string]struct{}{"password123!a": {}},
+	History:       2,
+	MaxAge:        24 * time.Hour,
+}
+
+// newPasswordService returns the service with the password history mock
+// without the default expectations.
+func newPasswordService(pp users.PasswordPolicy) (users.Service, *authmocks.TokenServiceClient, *mocks.Repository, *mocks.PasswordRepository, *policymocks.Service) {
+	cRepo := new(mocks.Repository)
+	passwordRepo := new(mocks.PasswordRepository)
+	policies := new(policymocks.Service)
+	tokenClient := new(authmocks.TokenServiceClient)
+	svc := users.NewService(tokenClient, cRepo, newMFARepo(), new(mocks.LockoutRepository), passwordRepo, new(mocks.ServiceAccountRepository), newDomainsClient(), policies, new(mocks.Emailer), phasher, idProvider, nil, users.RegistrationConfig{Key: []byte("secret")}, users.LockoutConfig{}, pp)
+
+	return svc, tokenClient, cRepo, passwordRepo, policies
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	cases := []struct {
+		desc     string
+		password string
+		err      error
+	}{
+		{
+			desc:     "validate valid password",
+			password: "Str0ng-Secret",
+			err:      nil,
+		},
+		{
+			desc:     "validate too short password",
+			password: "Sh0rt-pw",
+			err:      errors.New("password is too short"),
+		},
+		{
+			desc:     "validate password without uppercase letter",
+			password: "str0ng-secret",
+			err:      errors.New("password must contain an uppercase letter"),
+		},
+		{
+			desc:     "validate password without lowercase letter",
+			password: "STR0NG-SECRET",
+			err:      errors.New("password must contain a lowercase letter"),
+		},
+		{
+			desc:     "validate password without digit",
+			password: "Strong-Secret",
+			err:      errors.New("password must contain a digit"),
+		},
+		{
+			desc:     "validate password without symbol",
+			password: "Str0ngSecret",
+			err:      errors.New("password must contain a symbol"),
+		},
+		{
+			desc:     "validate denied password",
+			password: "Password123!A",
+			err:      errors.New("password is too common or known to be breached"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := passwordPolicy.Validate(tc.password)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		})
+	}
+}
+
+func TestLoadDenylist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "denylist.txt")
+	err := os.WriteFile(path, []byte("# common passwords\n\n123456\n  Password1 \nqwerty\n"), 0o600)
+	assert.Nil(t, err, fmt.Sprintf("write denylist unexpected error: %s", err))
+
+	denylist, err := users.LoadDenylist(path)
+	assert.Nil(t, err, fmt.Sprintf("load denylist unexpected error: %s", err))
+	assert.Equal(t, map[string]struct{}{"123456": {}, "password1": {}, "qwerty": {}}, denylist)
+
+	_, err = users.LoadDenylist(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.NotNil(t, err, "load missing denylist expected error")
+}
+
+func TestRegisterPasswordPolicy(t *testing.T) {
+	cases := []struct {
+		desc    string
+		secret  string
+		saveErr error
+		err     error
+	}{
+		{
+			desc:   "register user with valid password",
+			secret: "Str0ng-Secret",
+			err:    nil,
+		},
+		{
+			desc:   "register user with password violating the policy",
+			secret: "strongsecret",
+			err:    apiutil.ErrPasswordFormat,
+		},
+		{
+			desc:   "register user with denied password",
+			secret: "Password123!A",
+			err:    apiutil.ErrPasswordFormat,
+		},
+		{
+			desc:    "register user with failed to save password history",
+			secret:  "Str0ng-Secret",
+			saveErr: repoerr.ErrCreateEntity,
+			err:     repoerr.ErrCreateEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, _, cRepo, passwordRepo, policies := newPasswordService(passwordPolicy)
+			u := user
+			u.Credentials.Secret = tc.secret
+			cRepo.On("CheckSuperAdmin", context.Background(), validID).Return(nil)
+			cRepo.On("Save", context.Background(), mock.Anything).Return(u, nil)
+			cRepo.On("Delete", context.Background(), u.ID).Return(nil)
+			policies.On("AddPolicies", context.Background(), mock.Anything).Return(nil)
+			policies.On("DeletePolicies", context.Background(), mock.Anything).Return(nil)
+			passwordRepo.On("Save", context.Background(), mock.Anything, passwordPolicy.History).Return(tc.saveErr)
+			_, err := svc.Register(context.Background(), authn.Session{UserID: validID, SuperAdmin: true}, u, false, "")
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			switch {
+			case errors.Contains(err, apiutil.ErrPasswordFormat):
+				cRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+			case tc.saveErr != nil:
+				cRepo.AssertCalled(t, "Delete", context.Background(), u.ID)
+			}
+		})
+	}
+}
+
+func TestResetSecretPasswordPolicy(t *testing.T) {
+	oldHash, err := phasher.Hash("Old-Secret1")
+	assert.Nil(t, err, fmt.Sprintf("hash secret unexpected error: %s", err))
+	currentHash, err := phasher.Hash("Current-Secret1")
+	assert.Nil(t, err, fmt.Sprintf("hash secret unexpected error: %s", err))
+	rUser := users.User{ID: validID, Email: "test@example.com", Credentials: users.Credentials{Secret: currentHash}}
+	history := []users.PasswordHistory{{UserID: validID, Secret: currentHash}, {UserID: validID, Secret: oldHash}}
+
+	cases := []struct {
+		desc        string
+		secret      string
+		history     []users.PasswordHistory
+		retrieveErr error
+		saveErr     error
+		err         error
+	}{
+		{
+			desc:    "reset secret with new password",
+			secret:  "New-Secret12",
+			history: history,
+			err:     nil,
+		},
+		{
+			desc:   "reset secret with password violating the policy",
+			secret: "newsecret12",
+			err:    apiutil.ErrPasswordFormat,
+		},
+		{
+			desc:    "reset secret with current password",
+			secret:  "Current-Secret1",
+			history: history,
+			err:     apiutil.ErrPasswordFormat,
+		},
+		{
+			desc:    "reset secret with previous password",
+			secret:  "Old-Secret1",
+			history: history,
+			err:     apiutil.ErrPasswordFormat,
+		},
+		{
+			desc:        "reset secret with failed to retrieve password history",
+			secret:      "New-Secret12",
+			retrieveErr: repoerr.ErrViewEntity,
+			err:         repoerr.ErrViewEntity,
+		},
+		{
+			desc:    "reset secret with failed to save password history",
+			secret:  "New-Secret12",
+			history: history,
+			saveErr: repoerr.ErrCreateEntity,
+			err:     repoerr.ErrCreateEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, auth, cRepo, passwordRepo, _ := newPasswordService(passwordPolicy)
+			cRepo.On("RetrieveByID", context.Background(), validID).Return(rUser, nil)
+			cRepo.On("UpdateSecret", context.Background(), mock.Anything).Return(rUser, nil)
+			passwordRepo.On("Retrieve", context.Background(), validID, passwordPolicy.History).Return(tc.history, tc.retrieveErr)
+			passwordRepo.On("Save", context.Background(), mock.Anything, passwordPolicy.History).Return(tc.saveErr)
+			auth.On("RevokeSessions", context.Background(), &grpcTokenV1.RevokeSessionsReq{UserId: validID}).Return(&grpcTokenV1.RevokeSessionsRes{}, nil)
+			err := svc.ResetSecret(context.Background(), authn.Session{UserID: validID}, tc.secret)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if errors.Contains(err, apiutil.ErrPasswordFormat) {
+				cRepo.AssertNotCalled(t, "UpdateSecret", mock.Anything, mock.Anything)
+			}
+			if tc.err == nil {
+				passwordRepo.AssertCalled(t, "Save", context.Background(), mock.Anything, passwordPolicy.History)
+			}
+		})
+	}
+}
+
+func TestUpdateSecretPasswordPolicy(t *testing.T) {
+	currentHash, err := phasher.Hash("Current-Secret1")
+	assert.Nil(t, err, fmt.Sprintf("hash secret unexpected error: %s", err))
+	rUser := user
+	rUser.ID = validID
+	rUser.Credentials.Secret = currentHash
+	rUser.CreatedAt = time.Now()
+
+	cases := []struct {
+		desc   string
+		secret string
+		err    error
+	}{
+		{
+			desc:   "update secret with new password",
+			secret: "New-Secret12",
+			err:    nil,
+		},
+		{
+			desc:   "update secret with password violating the policy",
+			secret: "NewSecret12",
+			err:    apiutil.ErrPasswordFormat,
+		},
+		{
+			desc:   "update secret with current password",
+			secret: "Current-Secret1",
+			err:    apiutil.ErrPasswordFormat,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, auth, cRepo, passwordRepo, _ := newPasswordService(passwordPolicy)
+			cRepo.On("RetrieveByID", context.Background(), validID).Return(rUser, nil)
+			cRepo.On("RetrieveByUsername", context.Background(), rUser.Credentials.Username).Return(rUser, nil)
+			cRepo.On("UpdateSecret", context.Background(), mock.Anything).Return(rUser, nil)
+			passwordRepo.On("Retrieve", context.Background(), validID, uint64(1)).Return([]users.PasswordHistory{}, nil)
+			passwordRepo.On("Retrieve", context.Background(), validID, passwordPolicy.History).Return([]users.PasswordHistory{}, nil)
+			passwordRepo.On("Save", context.Background(), mock.Anything, passwordPolicy.History).Return(nil)
+			auth.On("Issue", context.Background(), mock.Anything).Return(&grpcTokenV1.Token{AccessToken: validToken}, nil)
+			auth.On("RevokeSessions", context.Background(), &grpcTokenV1.RevokeSessionsReq{UserId: validID}).Return(&grpcTokenV1.RevokeSessionsRes{}, nil)
+			_, err := svc.UpdateSecret(context.Background(), authn.Session{UserID: validID}, "Current-Secret1", tc.secret)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if tc.err != nil {
+				cRepo.AssertNotCalled(t, "UpdateSecret", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestIssueTokenPasswordExpired(t *testing.T) {
+	hash, err := phasher.Hash(secret)
+	assert.Nil(t, err, fmt.Sprintf("hash secret unexpected error: %s", err))
+	rUser := user
+	rUser.Credentials.Secret = hash
+	rUser.CreatedAt = time.Now().Add(-48 * time.Hour)
+
+	cases := []struct {
+		desc        string
+		maxAge      time.Duration
+		history     []users.PasswordHistory
+		retrieveErr error
+		accessType  string
+		err         error
+	}{
+		{
+			desc:       "issue token without password max age",
+			accessType: "3",
+			err:        nil,
+		},
+		{
+			desc:       "issue token with recently changed password",
+			maxAge:     24 * time.Hour,
+			history:    []users.PasswordHistory{{UserID: user.ID, Secret: hash, CreatedAt: time.Now().Add(-time.Hour)}},
+			accessType: "3",
+			err:        nil,
+		},
+		{
+			desc:       "issue token with expired password",
+			maxAge:     24 * time.Hour,
+			history:    []users.PasswordHistory{{UserID: user.ID, Secret: hash, CreatedAt: time.Now().Add(-25 * time.Hour)}},
+			accessType: users.PasswordChangeAccessType,
+			err:        nil,
+		},
+		{
+			desc:       "issue token with expired password without history",
+			maxAge:     24 * time.Hour,
+			history:    []users.PasswordHistory{},
+			accessType: users.PasswordChangeAccessType,
+			err:        nil,
+		},
+		{
+			desc:        "issue token with failed to retrieve password history",
+			maxAge:      24 * time.Hour,
+			retrieveErr: repoerr.ErrViewEntity,
+			err:         repoerr.ErrViewEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svc, auth, cRepo, passwordRepo, _ := newPasswordService(users.PasswordPolicy{MaxAge: tc.maxAge})
+			cRepo.On("RetrieveByUsername", context.Background(), user.Credentials.Username).Return(rUser, nil)
+			passwordRepo.On("Retrieve", context.Background(), user.ID, uint64(1)).Return(tc.history, tc.retrieveErr)
+			auth.On("Issue", context.Background(), &grpcTokenV1.IssueReq{UserId: user.ID, Type: uint32(smqauth.AccessKey)}).Return(&grpcTokenV1.Token{AccessToken: validToken, AccessType: "3"}, nil)
+			token, err := svc.IssueToken(context.Background(), user.Credentials.Username, secret, "", "")
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if err == nil {
+				assert.Equal(t, tc.accessType, token.GetAccessType())
+				assert.NotEmpty(t, token.GetAccessToken())
+			}
+			if tc.accessType == users.PasswordChangeAccessType {
+				auth.AssertNotCalled(t, "Issue", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestChangeExpiredSecret(t *testing.T) {
+	hash, err := phasher.Hash(secret)
+	assert.Nil(t, err, fmt.Sprintf("hash secret unexpected error: %s", err))
+	rUser := user
+	rUser.Credentials.Secret = hash
+	rUser.CreatedAt = time.Now().Add(-48 * time.Hour)
+	pp := users.PasswordPolicy{MinLength: 8, History: 1, MaxAge: 24 * time.Hour}
+
+	svc, auth, cRepo, passwordRepo, _ := newPasswordService(pp)
+	cRepo.On("RetrieveByUsername", context.Background(), user.Credentials.Username).Return(rUser, nil)
+	passwordRepo.On("Retrieve", context.Background(), user.ID, uint64(1)).Return([]users.PasswordHistory{}, nil)
+	challenge, err := svc.IssueToken(context.Background(), user.Credentials.Username, secret, "", "")
+	assert.Nil(t, err, fmt.Sprintf("issue token unexpected error: %s", err))
+	assert.Equal(t, users.PasswordChangeAccessType, challenge.GetAccessType())
+
+	changedUser := rUser
+	changedUser.Credentials.Secret, err = phasher.Hash("changedsecret")
+	assert.Nil(t, err, fmt.Sprintf("hash secret unexpected error: %s", err))
+	disabledUser := rUser
+	disabledUser.Status = users.DisabledStatus
+
+	cases := []struct {
+		desc         string
+		token        string
+		secret       string
+		retrieveUser users.User
+		retrieveErr  error
+		updateErr    error
+		revokeErr    error
+		err          error
+	}{
+		{
+			desc:         "change expired secret successfully",
+			token:        challenge.GetAccessToken(),
+			secret:       "newstrongsecret",
+			retrieveUser: rUser,
+			err:          nil,
+		},
+		{
+			desc:   "change expired secret with invalid token",
+			token:  "invalid",
+			secret: "newstrongsecret",
+			err:    svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "change expired secret of non-existing user",
+			token:       challenge.GetAccessToken(),
+			secret:      "newstrongsecret",
+			retrieveErr: repoerr.ErrNotFound,
+			err:         svcerr.ErrAuthentication,
+		},
+		{
+			desc:         "change expired secret of disabled user",
+			token:        challenge.GetAccessToken(),
+			secret:       "newstrongsecret",
+			retrieveUser: disabledUser,
+			err:          svcerr.ErrAuthentication,
+		},
+		{
+			desc:         "change expired secret with token of changed password",
+			token:        challenge.GetAccessToken(),
+			secret:       "newstrongsecret",
+			retrieveUser: changedUser,
+			err:          svcerr.ErrAuthentication,
+		},
+		{
+			desc:         "change expired secret with current password",
+			token:        challenge.GetAccessToken(),
+			secret:       secret,
+			retrieveUser: rUser,
+			err:          apiutil.ErrPasswordFormat,
+		},
+		{
+			desc:         "change expired secret with too short password",
+			token:        challenge.GetAccessToken(),
+			secret:       "short",
+			retrieveUser: rUser,
+			err:          apiutil.ErrPasswordFormat,
+		},
+		{
+			desc:         "change expired secret with failed to update secret",
+			token:        challenge.GetAccessToken(),
+			secret:       "newstrongsecret",
+			retrieveUser: rUser,
+			updateErr:    repoerr.ErrMalformedEntity,
+			err:          svcerr.ErrUpdateEntity,
+		},
+		{
+			desc:         "change expired secret with failed to revoke sessions",
+			token:        challenge.GetAccessToken(),
+			secret:       "newstrongsecret",
+			retrieveUser: rUser,
+			revokeErr:    svcerr.ErrRemoveEntity,
+			err:          svcerr.ErrRemoveEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repoCall := cRepo.On("RetrieveByID", context.Background(), user.ID).Return(tc.retrieveUser, tc.retrieveErr)
+			repoCall1 := cRepo.On("UpdateSecret", context.Background(), mock.Anything).Return(tc.retrieveUser, tc.updateErr)
+			passwordCall := passwordRepo.On("Retrieve", context.Background(), user.ID, pp.History).Return([]users.PasswordHistory{}, nil)
+			passwordCall1 := passwordRepo.On("Save", context.Background(), mock.Anything, pp.History).Return(nil)
+			authCall := auth.On("RevokeSessions", context.Background(), &grpcTokenV1.RevokeSessionsReq{UserId: user.ID}).Return(&grpcTokenV1.RevokeSessionsRes{}, tc.revokeErr)
+			authCall1 := auth.On("Issue", context.Background(), &grpcTokenV1.IssueReq{UserId: user.ID, Type: uint32(smqauth.AccessKey)}).Return(&grpcTokenV1.Token{AccessToken: validToken, AccessType: "3"}, nil)
+			token, err := svc.ChangeExpiredSecret(context.Background(), tc.token, tc.secret, "", "")
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if tc.err == nil {
+				assert.Equal(t, validToken, token.GetAccessToken())
+				passwordCall1.Parent.AssertCalled(t, "Save", context.Background(), mock.Anything, pp.History)
+			}
+			repoCall.Unset()
+			repoCall1.Unset()
+			passwordCall.Unset()
+			passwordCall1.Unset()
+			authCall.Unset()
+			authCall1.Unset()
+		})
+	}
+}
+
+// newServiceAccountService returns the service with the service account
+// repository mock.
+func newServiceAccountService() (users.Service, *authmocks.TokenServiceClient, *mocks.ServiceAccountRepository, *policymocks.Service) {
+	saRepo := new(mocks.ServiceAccountRepository)
+	policies := new(policymocks.Service)
+	tokenClient := new(authmocks.TokenServiceClient)
+	svc := users.NewService(tokenClient, new(mocks.Repository), newMFARepo(), new(mocks.LockoutRepository), newPasswordRepo(), saRepo, newDomainsClient(), policies, new(mocks.Emailer), phasher, idProvider, nil, users.RegistrationConfig{}, users.LockoutConfig{}, users.PasswordPolicy{})
+
+	return svc, tokenClient, saRepo, policies
+}
+
+// newServiceAccountKey returns the ECDSA key signing the assertions and its
+// PEM encoded public key.
+func newServiceAccountKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err, fmt.Sprintf("generate key unexpected error: %s", err))
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.Nil(t, err, fmt.Sprintf("marshal public key unexpected error: %s", err))
+
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func newAssertion(t *testing.T, key *ecdsa.PrivateKey, issuer, audience string, exp time.Time) string {
+	tkn, err := jwt.NewBuilder().
+		Issuer(issuer).
+		Subject(issuer).
+		Audience([]string{audience}).
+		IssuedAt(time.Now()).
+		Expiration(exp).
+		Build()
+	assert.Nil(t, err, fmt.Sprintf("build assertion unexpected error: %s", err))
+	signed, err := jwt.Sign(tkn, jwt.WithKey(jwa.ES256, key))
+	assert.Nil(t, err, fmt.Sprintf("sign assertion unexpected error: %s", err))
+
+	return string(signed)
+}
+
+func TestCreateServiceAccount(t *testing.T) {
+	svc, _, saRepo, _ := newServiceAccountService()
+
+	session := authn.Session{UserID: validID, DomainID: testsutil.GenerateUUID(t), DomainUserID: validID}
+	_, publicKey := newServiceAccountKey(t)
+
+	cases := []struct {
+		desc    string
+		sa      users.ServiceAccount
+		saveErr error
+		err     error
+	}{
+		{
+			desc: "create service account successfully",
+			sa:   users.ServiceAccount{Name: "ci", Status: users.EnabledStatus},
+			err:  nil,
+		},
+		{
+			desc: "create service account with public key",
+			sa:   users.ServiceAccount{Name: "ci", Status: users.EnabledStatus, PublicKey: publicKey},
+			err:  nil,
+		},
+		{
+			desc: "create service account with invalid public key",
+			sa:   users.ServiceAccount{Name: "ci", Status: users.EnabledStatus, PublicKey: "invalid"},
+			err:  svcerr.ErrMalformedEntity,
+		},
+		{
+			desc: "create service account with invalid status",
+			sa:   users.ServiceAccount{Name: "ci", Status: users.AllStatus},
+			err:  svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:    "create service account with failed to save",
+			sa:      users.ServiceAccount{Name: "ci", Status: users.EnabledStatus},
+			saveErr: repoerr.ErrConflict,
+			err:     svcerr.ErrCreateEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repoCall := saRepo.On("Save", context.Background(), mock.Anything).Return(func(_ context.Context, sa users.ServiceAccount) (users.ServiceAccount, error) {
+				return sa, tc.saveErr
+			})
+			sa, secret, err := svc.CreateServiceAccount(context.Background(), session, tc.sa)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if err == nil {
+				assert.NotEmpty(t, sa.ID)
+				assert.Equal(t, session.DomainID, sa.DomainID)
+				assert.Equal(t, session.UserID, sa.CreatedBy)
+				assert.Nil(t, phasher.Compare(secret, sa.Secret), "the secret must be stored hashed")
+			}
+			repoCall.Unset()
+		})
+	}
+}
+
+func TestViewServiceAccount(t *testing.T) {
+	svc, _, saRepo, _ := newServiceAccountService()
+
+	session := authn.Session{UserID: validID, DomainID: testsutil.GenerateUUID(t), DomainUserID: validID}
+	sa := users.ServiceAccount{ID: testsutil.GenerateUUID(t), DomainID: session.DomainID, Name: "ci", Status: users.EnabledStatus}
+	otherDomain := sa
+	otherDomain.DomainID = testsutil.GenerateUUID(t)
+
+	cases := []struct {
+		desc        string
+		retrieveRes users.ServiceAccount
+		retrieveErr error
+		err         error
+	}{
+		{
+			desc:        "view service account successfully",
+			retrieveRes: sa,
+			err:         nil,
+		},
+		{
+			desc:        "view service account of other domain",
+			retrieveRes: otherDomain,
+			err:         svcerr.ErrViewEntity,
+		},
+		{
+			desc:        "view non-existing service account",
+			retrieveErr: repoerr.ErrNotFound,
+			err:         svcerr.ErrViewEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repoCall := saRepo.On("RetrieveByID", context.Background(), sa.ID).Return(tc.retrieveRes, tc.retrieveErr)
+			res, err := svc.ViewServiceAccount(context.Background(), session, sa.ID)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if err == nil {
+				assert.Equal(t, sa, res)
+			}
+			repoCall.Unset()
+		})
+	}
+}
+
+func TestRotateServiceAccountSecret(t *testing.T) {
+	svc, tokenClient, saRepo, _ := newServiceAccountService()
+
+	session := authn.Session{UserID: validID, DomainID: testsutil.GenerateUUID(t), DomainUserID: validID}
+	sa := users.ServiceAccount{ID: testsutil.GenerateUUID(t), DomainID: session.DomainID, Name: "ci", Status: users.EnabledStatus, Secret: "hash"}
+
+	cases := []struct {
+		desc      string
+		updateErr error
+		revokeErr error
+		err       error
+	}{
+		{
+			desc: "rotate service account secret successfully",
+			err:  nil,
+		},
+		{
+			desc:      "rotate service account secret with failed to update",
+			updateErr: repoerr.ErrNotFound,
+			err:       svcerr.ErrUpdateEntity,
+		},
+		{
+			desc:      "rotate service account secret with failed to revoke sessions",
+			revokeErr: svcerr.ErrAuthorization,
+			err:       svcerr.ErrAuthorization,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repoCall := saRepo.On("RetrieveByID", context.Background(), sa.ID).Return(sa, nil)
+			repoCall1 := saRepo.On("UpdateSecret", context.Background(), mock.Anything).Return(sa, tc.updateErr)
+			tokenCall := tokenClient.On("RevokeSessions", context.Background(), &grpcTokenV1.RevokeSessionsReq{UserId: sa.ID}).Return(&grpcTokenV1.RevokeSessionsRes{}, tc.revokeErr)
+			secret, err := svc.RotateServiceAccountSecret(context.Background(), session, sa.ID)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if err == nil {
+				assert.NotEmpty(t, secret)
+				ok := repoCall1.Parent.AssertCalled(t, "UpdateSecret", context.Background(), mock.MatchedBy(func(updated users.ServiceAccount) bool {
+					return phasher.Compare(secret, updated.Secret) == nil
+				}))
+				assert.True(t, ok, "UpdateSecret was not called with the new secret hash")
+			}
+			repoCall.Unset()
+			repoCall1.Unset()
+			tokenCall.Unset()
+		})
+	}
+}
+
+func TestDisableServiceAccount(t *testing.T) {
+	svc, tokenClient, saRepo, _ := newServiceAccountService()
+
+	session := authn.Session{UserID: validID, DomainID: testsutil.GenerateUUID(t), DomainUserID: validID}
+	enabled := users.ServiceAccount{ID: testsutil.GenerateUUID(t), DomainID: session.DomainID, Name: "ci", Status: users.EnabledStatus}
+	disabled := enabled
+	disabled.Status = users.DisabledStatus
+
+	cases := []struct {
+		desc        string
+		retrieveRes users.ServiceAccount
+		err         error
+	}{
+		{
+			desc:        "disable enabled service account",
+			retrieveRes: enabled,
+			err:         nil,
+		},
+		{
+			desc:        "disable disabled service account",
+			retrieveRes: disabled,
+			err:         errors.ErrStatusAlreadyAssigned,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repoCall := saRepo.On("RetrieveByID", context.Background(), enabled.ID).Return(tc.retrieveRes, nil)
+			repoCall1 := saRepo.On("ChangeStatus", context.Background(), mock.Anything).Return(disabled, nil)
+			tokenCall := tokenClient.On("RevokeSessions", context.Background(), &grpcTokenV1.RevokeSessionsReq{UserId: enabled.ID}).Return(&grpcTokenV1.RevokeSessionsRes{}, nil)
+			res, err := svc.DisableServiceAccount(context.Background(), session, enabled.ID)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if err == nil {
+				assert.Equal(t, disabled, res)
+				tokenClient.AssertCalled(t, "RevokeSessions", context.Background(), &grpcTokenV1.RevokeSessionsReq{UserId: enabled.ID})
+			}
+			repoCall.Unset()
+			repoCall1.Unset()
+			tokenCall.Unset()
+		})
+	}
+}
+
+func TestDeleteServiceAccount(t *testing.T) {
+	svc, tokenClient, saRepo, policies := newServiceAccountService()
+
+	session := authn.Session{UserID: validID, DomainID: testsutil.GenerateUUID(t), DomainUserID: validID}
+	sa := users.ServiceAccount{ID: testsutil.GenerateUUID(t), DomainID: session.DomainID, Name: "ci", Status: users.EnabledStatus}
+	filter := policysvc.Policy{
+		Subject:     policysvc.EncodeDomainUserID(sa.DomainID, sa.ID),
+		SubjectType: policysvc.UserType,
+	}
+
+	cases := []struct {
+		desc      string
+		policyErr error
+		deleteErr error
+		err       error
+	}{
+		{
+			desc: "delete service account successfully",
+			err:  nil,
+		},
+		{
+			desc:      "delete service account with failed to remove roles",
+			policyErr: svcerr.ErrAuthorization,
+			err:       svcerr.ErrAuthorization,
+		},
+		{
+			desc:      "delete service account with failed to delete",
+			deleteErr: repoerr.ErrNotFound,
+			err:       svcerr.ErrRemoveEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repoCall := saRepo.On("RetrieveByID", context.Background(), sa.ID).Return(sa, nil)
+			policyCall := policies.On("DeletePolicyFilter", context.Background(), filter).Return(tc.policyErr)
+			repoCall1 := saRepo.On("Delete", context.Background(), sa.ID).Return(tc.deleteErr)
+			tokenCall := tokenClient.On("RevokeSessions", context.Background(), &grpcTokenV1.RevokeSessionsReq{UserId: sa.ID}).Return(&grpcTokenV1.RevokeSessionsRes{}, nil)
+			err := svc.DeleteServiceAccount(context.Background(), session, sa.ID)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			repoCall.Unset()
+			policyCall.Unset()
+			repoCall1.Unset()
+			tokenCall.Unset()
+		})
+	}
+}
+
+func TestIssueServiceAccountToken(t *testing.T) {
+	svc, tokenClient, saRepo, _ := newServiceAccountService()
+
+	key, publicKey := newServiceAccountKey(t)
+	otherKey, _ := newServiceAccountKey(t)
+	clientSecret := "client-secret"
+	hash, err := phasher.Hash(clientSecret)
+	assert.Nil(t, err, fmt.Sprintf("hash secret unexpected error: %s", err))
+
+	sa := users.ServiceAccount{
+		ID:        testsutil.GenerateUUID(t),
+		DomainID:  testsutil.GenerateUUID(t),
+		Name:      "ci",
+		Status:    users.EnabledStatus,
+		Secret:    hash,
+		PublicKey: publicKey,
+	}
+	disabled := sa
+	disabled.Status = users.DisabledStatus
+	exp := time.Now().Add(5 * time.Minute)
+
+	cases := []struct {
+		desc         string
+		clientID     string
+		clientSecret string
+		assertion    string
+		retrieveRes  users.ServiceAccount
+		retrieveErr  error
+		err          error
+	}{
+		{
+			desc:         "issue token with client credentials",
+			clientID:     sa.ID,
+			clientSecret: clientSecret,
+			retrieveRes:  sa,
+			err:          nil,
+		},
+		{
+			desc:         "issue token with invalid client secret",
+			clientID:     sa.ID,
+			clientSecret: "invalid",
+			retrieveRes:  sa,
+			err:          svcerr.ErrAuthentication,
+		},
+		{
+			desc:         "issue token for non-existing service account",
+			clientID:     sa.ID,
+			clientSecret: clientSecret,
+			retrieveErr:  repoerr.ErrNotFound,
+			err:          svcerr.ErrAuthentication,
+		},
+		{
+			desc:         "issue token for disabled service account",
+			clientID:     sa.ID,
+			clientSecret: clientSecret,
+			retrieveRes:  disabled,
+			err:          svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "issue token with JWT assertion",
+			assertion:   newAssertion(t, key, sa.ID, users.ServiceAccountAudience, exp),
+			retrieveRes: sa,
+			err:         nil,
+		},
+		{
+			desc:        "issue token with assertion signed by other key",
+			assertion:   newAssertion(t, otherKey, sa.ID, users.ServiceAccountAudience, exp),
+			retrieveRes: sa,
+			err:         svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "issue token with assertion for other audience",
+			assertion:   newAssertion(t, key, sa.ID, "other", exp),
+			retrieveRes: sa,
+			err:         svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "issue token with expired assertion",
+			assertion:   newAssertion(t, key, sa.ID, users.ServiceAccountAudience, time.Now().Add(-time.Minute)),
+			retrieveRes: sa,
+			err:         svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "issue token with long-lived assertion",
+			assertion:   newAssertion(t, key, sa.ID, users.ServiceAccountAudience, time.Now().Add(time.Hour)),
+			retrieveRes: sa,
+			err:         svcerr.ErrAuthentication,
+		},
+		{
+			desc:      "issue token with malformed assertion",
+			assertion: "invalid",
+			err:       svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repoCall := saRepo.On("RetrieveByID", context.Background(), sa.ID).Return(tc.retrieveRes, tc.retrieveErr)
+			tokenCall := tokenClient.On("Issue", context.Background(), &grpcTokenV1.IssueReq{UserId: sa.ID, Type: uint32(smqauth.AccessKey), ServiceAccount: true, DomainId: sa.DomainID}).Return(&grpcTokenV1.Token{AccessToken: validToken, RefreshToken: &validToken, AccessType: "Bearer"}, nil)
+			token, err := svc.IssueServiceAccountToken(context.Background(), tc.clientID, tc.clientSecret, tc.assertion, "")
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			if err == nil {
+				assert.Equal(t, validToken, token.GetAccessToken())
+				assert.Empty(t, token.GetRefreshToken(), "the service account token must not be refreshable")
+			}
+			repoCall.Unset()
+			tokenCall.Unset()
 		})
 	}
 }
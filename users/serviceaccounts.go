@@ -0,0 +1,144 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const (
+	// ServiceAccountAudience is the audience of the signed JWT assertions
+	// the service accounts exchange for the access token.
+	ServiceAccountAudience = "mitras.users"
+
+	// assertionMaxLifetime limits the replay of the leaked assertion.
+	assertionMaxLifetime = 10 * time.Minute
+	serviceAccountSecret = 32
+)
+
+var (
+	errInvalidPublicKey   = errors.New("invalid service account public key")
+	errInvalidAssertion   = errors.New("invalid service account assertion")
+	errInvalidCredentials = errors.New("invalid service account credentials")
+	errServiceAccountRole = errors.New("failed to remove service account roles")
+)
+
+// ServiceAccount represents the non-human identity owned by the domain, such
+// as the integration or the automation. The service account is a member of
+// the domain, group and channel roles like the users, but it doesn't log in
+// and it isn't listed nor counted with the users.
+type ServiceAccount struct {
+	ID          string   `json:"id"`
+	DomainID    string   `json:"domain_id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Metadata    Metadata `json:"metadata,omitempty"`
+	Status      Status   `json:"status"`
+	// Secret is the hash of the client secret.
+	Secret string `json:"-"`
+	// PublicKey is the PEM encoded public key which verifies the signed
+	// JWT assertions of the service account.
+	PublicKey string    `json:"public_key,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+}
+
+// ServiceAccountsPage contains the page metadata and the service accounts
+// of the page.
+type ServiceAccountsPage struct {
+	Page
+	ServiceAccounts []ServiceAccount
+}
+
+// ServiceAccountRepository specifies the service account persistence API.
+//
+//go:generate mockery --name ServiceAccountRepository --output=./mocks --filename serviceaccounts.go --quiet
+type ServiceAccountRepository interface {
+	// Save persists the service account.
+	Save(ctx context.Context, sa ServiceAccount) (ServiceAccount, error)
+
+	// RetrieveByID retrieves the service account by its unique ID.
+	RetrieveByID(ctx context.Context, id string) (ServiceAccount, error)
+
+	// RetrieveAll retrieves the service accounts of the domain.
+	RetrieveAll(ctx context.Context, domainID string, pm Page) (ServiceAccountsPage, error)
+
+	// Update updates the service account name, description, metadata and
+	// public key.
+	Update(ctx context.Context, sa ServiceAccount) (ServiceAccount, error)
+
+	// UpdateSecret updates the service account secret.
+	UpdateSecret(ctx context.Context, sa ServiceAccount) (ServiceAccount, error)
+
+	// ChangeStatus changes the service account status to enabled or disabled.
+	ChangeStatus(ctx context.Context, sa ServiceAccount) (ServiceAccount, error)
+
+	// Delete deletes the service account with the given ID.
+	Delete(ctx context.Context, id string) error
+}
+
+// parsePublicKey parses the PEM encoded RSA, ECDSA or Ed25519 public key.
+func parsePublicKey(pem string) (jwk.Key, error) {
+	key, err := jwk.ParseKey([]byte(pem), jwk.WithPEM(true))
+	if err != nil {
+		return nil, errors.Wrap(errInvalidPublicKey, err)
+	}
+	switch key.(type) {
+	case jwk.RSAPublicKey, jwk.ECDSAPublicKey, jwk.OKPPublicKey:
+		return key, nil
+	default:
+		return nil, errInvalidPublicKey
+	}
+}
+
+// assertionSubject returns the unverified subject of the assertion, which
+// is the ID of the service account that signed it.
+func assertionSubject(assertion string) (string, error) {
+	tkn, err := jwt.ParseInsecure([]byte(assertion))
+	if err != nil || tkn.Subject() == "" {
+		return "", errInvalidAssertion
+	}
+
+	return tkn.Subject(), nil
+}
+
+// verifyAssertion verifies the JWT assertion (RFC 7523) signed by the
+// service account. The assertion is issued and signed by the service
+// account for the Users service, and it must expire within the max lifetime.
+func verifyAssertion(sa ServiceAccount, assertion string) error {
+	if sa.PublicKey == "" {
+		return errInvalidAssertion
+	}
+	key, err := parsePublicKey(sa.PublicKey)
+	if err != nil {
+		return err
+	}
+	set := jwk.NewSet()
+	if err := set.AddKey(key); err != nil {
+		return errors.Wrap(errInvalidAssertion, err)
+	}
+
+	tkn, err := jwt.Parse(
+		[]byte(assertion),
+		jwt.WithKeySet(set, jws.WithInferAlgorithmFromKey(true), jws.WithRequireKid(false)),
+		jwt.WithValidate(true),
+		jwt.WithIssuer(sa.ID),
+		jwt.WithSubject(sa.ID),
+		jwt.WithAudience(ServiceAccountAudience),
+		jwt.WithRequiredClaim(jwt.ExpirationKey),
+	)
+	if err != nil {
+		return errors.Wrap(errInvalidAssertion, err)
+	}
+	if time.Until(tkn.Expiration()) > assertionMaxLifetime {
+		return errors.Wrap(errInvalidAssertion, errors.New("assertion lifetime is too long"))
+	}
+
+	return nil
+}
@@ -23,27 +23,198 @@ func New(svc users.Service, tracer trace.Tracer) users.Service {
 }
 
 // Register traces the "Register" operation of the wrapped users.Service.
-func (tm *tracingMiddleware) Register(ctx context.Context, session authn.Session, user users.User, selfRegister bool) (users.User, error) {
+func (tm *tracingMiddleware) Register(ctx context.Context, session authn.Session, user users.User, selfRegister bool, invitation string) (users.User, error) {
 	ctx, span := tm.tracer.Start(ctx, "svc_register_user", trace.WithAttributes(attribute.String("email", user.Email)))
 	defer span.End()
 
-	return tm.svc.Register(ctx, session, user, selfRegister)
+	return tm.svc.Register(ctx, session, user, selfRegister, invitation)
 }
 
 // IssueToken traces the "IssueToken" operation of the wrapped users.Service.
-func (tm *tracingMiddleware) IssueToken(ctx context.Context, username, secret string) (*grpcTokenV1.Token, error) {
+func (tm *tracingMiddleware) IssueToken(ctx context.Context, username, secret, device, ip string) (*grpcTokenV1.Token, error) {
 	ctx, span := tm.tracer.Start(ctx, "svc_issue_token", trace.WithAttributes(attribute.String("username", username)))
 	defer span.End()
 
-	return tm.svc.IssueToken(ctx, username, secret)
+	return tm.svc.IssueToken(ctx, username, secret, device, ip)
 }
 
 // RefreshToken traces the "RefreshToken" operation of the wrapped users.Service.
-func (tm *tracingMiddleware) RefreshToken(ctx context.Context, session authn.Session, refreshToken string) (*grpcTokenV1.Token, error) {
+func (tm *tracingMiddleware) RefreshToken(ctx context.Context, session authn.Session, refreshToken, ip string) (*grpcTokenV1.Token, error) {
 	ctx, span := tm.tracer.Start(ctx, "svc_refresh_token", trace.WithAttributes(attribute.String("refresh_token", refreshToken)))
 	defer span.End()
 
-	return tm.svc.RefreshToken(ctx, session, refreshToken)
+	return tm.svc.RefreshToken(ctx, session, refreshToken, ip)
+}
+
+// IssueMFAToken traces the "IssueMFAToken" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) IssueMFAToken(ctx context.Context, mfaToken, code string) (*grpcTokenV1.Token, error) {
+	ctx, span := tm.tracer.Start(ctx, "svc_issue_mfa_token")
+	defer span.End()
+
+	return tm.svc.IssueMFAToken(ctx, mfaToken, code)
+}
+
+// ChangeExpiredSecret traces the "ChangeExpiredSecret" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) ChangeExpiredSecret(ctx context.Context, changeToken, newSecret, device, ip string) (*grpcTokenV1.Token, error) {
+	ctx, span := tm.tracer.Start(ctx, "svc_change_expired_secret")
+	defer span.End()
+
+	return tm.svc.ChangeExpiredSecret(ctx, changeToken, newSecret, device, ip)
+}
+
+// PasswordPolicy traces the "PasswordPolicy" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) PasswordPolicy(ctx context.Context) users.PasswordPolicy {
+	ctx, span := tm.tracer.Start(ctx, "svc_view_password_policy")
+	defer span.End()
+
+	return tm.svc.PasswordPolicy(ctx)
+}
+
+// EnrollMFA traces the "EnrollMFA" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) EnrollMFA(ctx context.Context, session authn.Session) (users.MFAEnrollment, error) {
+	ctx, span := tm.tracer.Start(ctx, "svc_enroll_mfa")
+	defer span.End()
+
+	return tm.svc.EnrollMFA(ctx, session)
+}
+
+// EnrollMFAWithChallenge traces the "EnrollMFAWithChallenge" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) EnrollMFAWithChallenge(ctx context.Context, mfaToken string) (users.MFAEnrollment, error) {
+	ctx, span := tm.tracer.Start(ctx, "svc_enroll_mfa_with_challenge")
+	defer span.End()
+
+	return tm.svc.EnrollMFAWithChallenge(ctx, mfaToken)
+}
+
+// VerifyMFA traces the "VerifyMFA" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) VerifyMFA(ctx context.Context, session authn.Session, code string) error {
+	ctx, span := tm.tracer.Start(ctx, "svc_verify_mfa")
+	defer span.End()
+
+	return tm.svc.VerifyMFA(ctx, session, code)
+}
+
+// ResetMFA traces the "ResetMFA" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) ResetMFA(ctx context.Context, session authn.Session, id string) error {
+	ctx, span := tm.tracer.Start(ctx, "svc_reset_mfa", trace.WithAttributes(attribute.String("id", id)))
+	defer span.End()
+
+	return tm.svc.ResetMFA(ctx, session, id)
+}
+
+// Unlock traces the "Unlock" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) Unlock(ctx context.Context, session authn.Session, id string) error {
+	ctx, span := tm.tracer.Start(ctx, "svc_unlock_user", trace.WithAttributes(attribute.String("id", id)))
+	defer span.End()
+
+	return tm.svc.Unlock(ctx, session, id)
+}
+
+// Impersonate traces the "Impersonate" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) Impersonate(ctx context.Context, session authn.Session, id string, readOnly bool) (*grpcTokenV1.Token, error) {
+	ctx, span := tm.tracer.Start(ctx, "svc_impersonate_user", trace.WithAttributes(
+		attribute.String("id", id),
+		attribute.Bool("read_only", readOnly),
+	))
+	defer span.End()
+
+	return tm.svc.Impersonate(ctx, session, id, readOnly)
+}
+
+// CreateServiceAccount traces the "CreateServiceAccount" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) CreateServiceAccount(ctx context.Context, session authn.Session, sa users.ServiceAccount) (users.ServiceAccount, string, error) {
+	ctx, span := tm.tracer.Start(ctx, "svc_create_service_account", trace.WithAttributes(attribute.String("name", sa.Name)))
+	defer span.End()
+
+	return tm.svc.CreateServiceAccount(ctx, session, sa)
+}
+
+// ViewServiceAccount traces the "ViewServiceAccount" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) ViewServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	ctx, span := tm.tracer.Start(ctx, "svc_view_service_account", trace.WithAttributes(attribute.String("id", id)))
+	defer span.End()
+
+	return tm.svc.ViewServiceAccount(ctx, session, id)
+}
+
+// ListServiceAccounts traces the "ListServiceAccounts" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) ListServiceAccounts(ctx context.Context, session authn.Session, pm users.Page) (users.ServiceAccountsPage, error) {
+	ctx, span := tm.tracer.Start(ctx, "svc_list_service_accounts", trace.WithAttributes(attribute.Int64("offset", int64(pm.Offset)), attribute.Int64("limit", int64(pm.Limit))))
+	defer span.End()
+
+	return tm.svc.ListServiceAccounts(ctx, session, pm)
+}
+
+// UpdateServiceAccount traces the "UpdateServiceAccount" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) UpdateServiceAccount(ctx context.Context, session authn.Session, sa users.ServiceAccount) (users.ServiceAccount, error) {
+	ctx, span := tm.tracer.Start(ctx, "svc_update_service_account", trace.WithAttributes(attribute.String("id", sa.ID)))
+	defer span.End()
+
+	return tm.svc.UpdateServiceAccount(ctx, session, sa)
+}
+
+// RotateServiceAccountSecret traces the "RotateServiceAccountSecret" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) RotateServiceAccountSecret(ctx context.Context, session authn.Session, id string) (string, error) {
+	ctx, span := tm.tracer.Start(ctx, "svc_rotate_service_account_secret", trace.WithAttributes(attribute.String("id", id)))
+	defer span.End()
+
+	return tm.svc.RotateServiceAccountSecret(ctx, session, id)
+}
+
+// EnableServiceAccount traces the "EnableServiceAccount" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) EnableServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	ctx, span := tm.tracer.Start(ctx, "svc_enable_service_account", trace.WithAttributes(attribute.String("id", id)))
+	defer span.End()
+
+	return tm.svc.EnableServiceAccount(ctx, session, id)
+}
+
+// DisableServiceAccount traces the "DisableServiceAccount" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) DisableServiceAccount(ctx context.Context, session authn.Session, id string) (users.ServiceAccount, error) {
+	ctx, span := tm.tracer.Start(ctx, "svc_disable_service_account", trace.WithAttributes(attribute.String("id", id)))
+	defer span.End()
+
+	return tm.svc.DisableServiceAccount(ctx, session, id)
+}
+
+// DeleteServiceAccount traces the "DeleteServiceAccount" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) DeleteServiceAccount(ctx context.Context, session authn.Session, id string) error {
+	ctx, span := tm.tracer.Start(ctx, "svc_delete_service_account", trace.WithAttributes(attribute.String("id", id)))
+	defer span.End()
+
+	return tm.svc.DeleteServiceAccount(ctx, session, id)
+}
+
+// IssueServiceAccountToken traces the "IssueServiceAccountToken" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) IssueServiceAccountToken(ctx context.Context, clientID, clientSecret, assertion, ip string) (*grpcTokenV1.Token, error) {
+	ctx, span := tm.tracer.Start(ctx, "svc_issue_service_account_token", trace.WithAttributes(attribute.String("client_id", clientID)))
+	defer span.End()
+
+	return tm.svc.IssueServiceAccountToken(ctx, clientID, clientSecret, assertion, ip)
+}
+
+// VerifyEmail traces the "VerifyEmail" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) VerifyEmail(ctx context.Context, token string) error {
+	ctx, span := tm.tracer.Start(ctx, "svc_verify_email")
+	defer span.End()
+
+	return tm.svc.VerifyEmail(ctx, token)
+}
+
+// SendVerification traces the "SendVerification" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) SendVerification(ctx context.Context, email, ip string) error {
+	ctx, span := tm.tracer.Start(ctx, "svc_send_verification", trace.WithAttributes(attribute.String("email", email)))
+	defer span.End()
+
+	return tm.svc.SendVerification(ctx, email, ip)
+}
+
+// InviteUser traces the "InviteUser" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) InviteUser(ctx context.Context, session authn.Session, email string) error {
+	ctx, span := tm.tracer.Start(ctx, "svc_invite_user", trace.WithAttributes(attribute.String("email", email)))
+	defer span.End()
+
+	return tm.svc.InviteUser(ctx, session, email)
 }
 
 // View traces the "View" operation of the wrapped users.Service.
@@ -143,14 +314,15 @@ func (tm *tracingMiddleware) UpdateProfilePicture(ctx context.Context, session a
 }
 
 // GenerateResetToken traces the "GenerateResetToken" operation of the wrapped users.Service.
-func (tm *tracingMiddleware) GenerateResetToken(ctx context.Context, email, host string) error {
+func (tm *tracingMiddleware) GenerateResetToken(ctx context.Context, email, host, ip string) error {
 	ctx, span := tm.tracer.Start(ctx, "svc_generate_reset_token", trace.WithAttributes(
 		attribute.String("email", email),
 		attribute.String("host", host),
+		attribute.String("ip", ip),
 	))
 	defer span.End()
 
-	return tm.svc.GenerateResetToken(ctx, email, host)
+	return tm.svc.GenerateResetToken(ctx, email, host, ip)
 }
 
 // ResetSecret traces the "ResetSecret" operation of the wrapped users.Service.
@@ -224,13 +396,13 @@ func (tm *tracingMiddleware) Identify(ctx context.Context, session authn.Session
 }
 
 // OAuthCallback traces the "OAuthCallback" operation of the wrapped users.Service.
-func (tm *tracingMiddleware) OAuthCallback(ctx context.Context, user users.User) (users.User, error) {
+func (tm *tracingMiddleware) OAuthCallback(ctx context.Context, user users.User, roles []users.DomainRole) (users.User, error) {
 	ctx, span := tm.tracer.Start(ctx, "svc_oauth_callback", trace.WithAttributes(
 		attribute.String("user_id", user.ID),
 	))
 	defer span.End()
 
-	return tm.svc.OAuthCallback(ctx, user)
+	return tm.svc.OAuthCallback(ctx, user, roles)
 }
 
 // Delete traces the "Delete" operation of the wrapped users.Service.
@@ -250,3 +422,11 @@ func (tm *tracingMiddleware) OAuthAddUserPolicy(ctx context.Context, user users.
 
 	return tm.svc.OAuthAddUserPolicy(ctx, user)
 }
+
+// OAuthIssueToken traces the "OAuthIssueToken" operation of the wrapped users.Service.
+func (tm *tracingMiddleware) OAuthIssueToken(ctx context.Context, user users.User, device, ip string) (*grpcTokenV1.Token, error) {
+	ctx, span := tm.tracer.Start(ctx, "svc_oauth_issue_token", trace.WithAttributes(attribute.String("user_id", user.ID)))
+	defer span.End()
+
+	return tm.svc.OAuthIssueToken(ctx, user, device, ip)
+}
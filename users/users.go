@@ -26,6 +26,14 @@ type User struct {
 	CreatedAt      time.Time   `json:"created_at,omitempty"`
 	UpdatedAt      time.Time   `json:"updated_at,omitempty"`
 	UpdatedBy      string      `json:"updated_by,omitempty"`
+	VerifiedAt     time.Time   `json:"verified_at,omitempty"`
+}
+
+// DomainRole represents the domain role the user is granted on OAuth login,
+// e.g. mapped from the identity provider groups.
+type DomainRole struct {
+	DomainID string
+	Role     string
 }
 
 type Credentials struct {
@@ -93,6 +101,9 @@ type Repository interface {
 	// Save persists the user account. A non-nil error is returned to indicate
 	// operation failure.
 	Save(ctx context.Context, user User) (User, error)
+
+	// VerifyEmail marks the user's email as verified if it's unchanged.
+	VerifyEmail(ctx context.Context, user User) (User, error)
 }
 
 // Validate returns an error if user representation is invalid.
@@ -128,6 +139,7 @@ type Page struct {
 	FirstName  string   `json:"first_name,omitempty"`
 	LastName   string   `json:"last_name,omitempty"`
 	Email      string   `json:"email,omitempty"`
+	Directory  bool     `json:"-"` // only the users bound to the directory
 }
 
 // Service specifies an API that must be fullfiled by the domain service
@@ -136,8 +148,22 @@ type Page struct {
 //go:generate mockery --name Service --output=./mocks --filename service.go --quiet
 type Service interface {
 	// Register creates new user. In case of the failed registration, a
-	// non-nil error value is returned.
-	Register(ctx context.Context, session authn.Session, user User, selfRegister bool) (User, error)
+	// non-nil error value is returned. Self-registration is subject to the
+	// registration policy, and the invitation is required if it's
+	// invite-only.
+	Register(ctx context.Context, session authn.Session, user User, selfRegister bool, invitation string) (User, error)
+
+	// VerifyEmail verifies the user's email using the token sent on
+	// registration.
+	VerifyEmail(ctx context.Context, token string) error
+
+	// SendVerification sends a new email verification token to the
+	// unverified user with the given email. The requests are limited per
+	// email and client IP.
+	SendVerification(ctx context.Context, email, ip string) error
+
+	// InviteUser sends the self-registration invitation to the email.
+	InviteUser(ctx context.Context, session authn.Session, email string) error
 
 	// View retrieves user info for a given user ID and an authorized token.
 	View(ctx context.Context, session authn.Session, id string) (User, error)
@@ -171,7 +197,8 @@ type Service interface {
 
 	// GenerateResetToken email where mail will be sent.
 	// host is used for generating reset link.
-	GenerateResetToken(ctx context.Context, email, host string) error
+	// The requests are throttled per email and per client IP.
+	GenerateResetToken(ctx context.Context, email, host, ip string) error
 
 	// UpdateSecret updates the user's secret.
 	UpdateSecret(ctx context.Context, session authn.Session, oldSecret, newSecret string) (User, error)
@@ -199,17 +226,97 @@ type Service interface {
 	Identify(ctx context.Context, session authn.Session) (string, error)
 
 	// IssueToken issues a new access and refresh token when provided with either a username or email.
-	IssueToken(ctx context.Context, identity, secret string) (*grpcTokenV1.Token, error)
+	// The device and IP of the client are stored with the session created on login.
+	// The failed attempts are throttled per identity and per client IP.
+	IssueToken(ctx context.Context, identity, secret, device, ip string) (*grpcTokenV1.Token, error)
 
 	// RefreshToken refreshes expired access tokens.
 	// After an access token expires, the refresh token is used to get
 	// a new pair of access and refresh tokens.
-	RefreshToken(ctx context.Context, session authn.Session, refreshToken string) (*grpcTokenV1.Token, error)
+	RefreshToken(ctx context.Context, session authn.Session, refreshToken, ip string) (*grpcTokenV1.Token, error)
+
+	// IssueMFAToken exchanges the MFA challenge issued on login and the TOTP
+	// or recovery code for a new access and refresh token.
+	IssueMFAToken(ctx context.Context, mfaToken, code string) (*grpcTokenV1.Token, error)
+
+	// ChangeExpiredSecret exchanges the password change token issued on login
+	// with the expired password and the new password for a new access and
+	// refresh token.
+	ChangeExpiredSecret(ctx context.Context, changeToken, newSecret, device, ip string) (*grpcTokenV1.Token, error)
+
+	// PasswordPolicy returns the password policy, so the UIs can show the
+	// password requirements.
+	PasswordPolicy(ctx context.Context) PasswordPolicy
+
+	// EnrollMFA starts the TOTP enrollment of the user. The enrollment is
+	// pending until it's verified with a valid code.
+	EnrollMFA(ctx context.Context, session authn.Session) (MFAEnrollment, error)
+
+	// EnrollMFAWithChallenge starts the TOTP enrollment of the user who
+	// must enroll MFA to log in, using the MFA challenge issued on login.
+	EnrollMFAWithChallenge(ctx context.Context, mfaToken string) (MFAEnrollment, error)
+
+	// VerifyMFA enables the pending TOTP enrollment of the user.
+	VerifyMFA(ctx context.Context, session authn.Session, code string) error
+
+	// ResetMFA removes MFA of the user with the given ID, so the user can
+	// enroll it again.
+	ResetMFA(ctx context.Context, session authn.Session, id string) error
+
+	// Unlock removes the failed login and password reset attempts of the
+	// user with the given ID, lifting the lockout.
+	Unlock(ctx context.Context, session authn.Session, id string) error
+
+	// Impersonate issues the time-limited access token of the user with the
+	// given ID to the super admin, carrying both the user and the super
+	// admin. The read-only token can't modify the entities. The user is
+	// notified by email.
+	Impersonate(ctx context.Context, session authn.Session, id string, readOnly bool) (*grpcTokenV1.Token, error)
+
+	// CreateServiceAccount creates the service account owned by the session
+	// domain. The client secret is returned only once.
+	CreateServiceAccount(ctx context.Context, session authn.Session, sa ServiceAccount) (ServiceAccount, string, error)
+
+	// ViewServiceAccount retrieves the service account of the session domain.
+	ViewServiceAccount(ctx context.Context, session authn.Session, id string) (ServiceAccount, error)
+
+	// ListServiceAccounts retrieves the service accounts of the session domain.
+	ListServiceAccounts(ctx context.Context, session authn.Session, pm Page) (ServiceAccountsPage, error)
+
+	// UpdateServiceAccount updates the service account name, description,
+	// metadata and public key.
+	UpdateServiceAccount(ctx context.Context, session authn.Session, sa ServiceAccount) (ServiceAccount, error)
+
+	// RotateServiceAccountSecret replaces the client secret of the service
+	// account and revokes its tokens. The new secret is returned only once.
+	RotateServiceAccountSecret(ctx context.Context, session authn.Session, id string) (string, error)
+
+	// EnableServiceAccount enables the service account.
+	EnableServiceAccount(ctx context.Context, session authn.Session, id string) (ServiceAccount, error)
+
+	// DisableServiceAccount disables the service account and revokes its
+	// tokens.
+	DisableServiceAccount(ctx context.Context, session authn.Session, id string) (ServiceAccount, error)
+
+	// DeleteServiceAccount removes the service account from its roles and
+	// deletes it at once, since there's no personal data to keep.
+	DeleteServiceAccount(ctx context.Context, session authn.Session, id string) error
+
+	// IssueServiceAccountToken issues the access token of the service
+	// account authenticated with the client ID and secret, or with the JWT
+	// assertion signed by the service account.
+	IssueServiceAccountToken(ctx context.Context, clientID, clientSecret, assertion, ip string) (*grpcTokenV1.Token, error)
 
 	// OAuthCallback handles the callback from any supported OAuth provider.
 	// It processes the OAuth tokens and either signs in or signs up the user based on the provided state.
-	OAuthCallback(ctx context.Context, user User) (User, error)
+	// The user is added to the given domain roles, if not already a member.
+	OAuthCallback(ctx context.Context, user User, roles []DomainRole) (User, error)
 
 	// OAuthAddUserPolicy adds a policy to the user for an OAuth request.
 	OAuthAddUserPolicy(ctx context.Context, user User) error
+
+	// OAuthIssueToken issues the access token of the user signed in with the
+	// OAuth provider, or the MFA challenge if the user has to complete the
+	// second factor.
+	OAuthIssueToken(ctx context.Context, user User, device, ip string) (*grpcTokenV1.Token, error)
 }
@@ -0,0 +1,100 @@
+package users
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hantdev/mitras/pkg/errors"
+)
+
+const (
+	verificationPurpose = "verification"
+	invitationPurpose   = "invitation"
+	tokenSeparator      = "\n"
+)
+
+var errInvalidSignedToken = errors.New("invalid or expired signed token")
+
+// RegistrationConfig contains the self-registration policy and the email
+// verification settings. Self-registration itself is enabled on the API.
+type RegistrationConfig struct {
+	// EmailDomains is the allowlist of the self-registered users email
+	// domains. Any domain is allowed if the list is empty.
+	EmailDomains []string
+	// InviteOnly allows self-registration only with an invitation.
+	InviteOnly bool
+	// VerifyEmail requires self-registered users to verify the email
+	// before logging in.
+	VerifyEmail bool
+	// Key signs the verification, invitation and password change tokens.
+	Key []byte
+	// VerificationDuration is the verification token validity.
+	VerificationDuration time.Duration
+	// InvitationDuration is the invitation token validity.
+	InvitationDuration time.Duration
+}
+
+// allowsEmail returns true if the email domain is in the allowlist.
+func (rc RegistrationConfig) allowsEmail(email string) bool {
+	if len(rc.EmailDomains) == 0 {
+		return true
+	}
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+	for _, d := range rc.EmailDomains {
+		if strings.ToLower(strings.TrimSpace(d)) == domain {
+			return true
+		}
+	}
+
+	return false
+}
+
+// signToken returns the token for the purpose, binding the subject and
+// the email until the expiration time. The token format is the base64
+// encoded payload and its HMAC-SHA256 signature separated by a dot.
+func signToken(key []byte, purpose, subject, email string, expiresAt time.Time) string {
+	payload := strings.Join([]string{purpose, subject, email, strconv.FormatInt(expiresAt.Unix(), 10)}, tokenSeparator)
+	enc := base64.RawURLEncoding
+
+	return enc.EncodeToString([]byte(payload)) + "." + enc.EncodeToString(tokenSignature(key, payload))
+}
+
+// parseToken validates the token signature, purpose and expiration, and
+// returns the subject and the email it is bound to.
+func parseToken(key []byte, purpose, token string) (string, string, error) {
+	enc := base64.RawURLEncoding
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || len(key) == 0 {
+		return "", "", errInvalidSignedToken
+	}
+	p, err := enc.DecodeString(payload)
+	if err != nil {
+		return "", "", errInvalidSignedToken
+	}
+	sig, err := enc.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, tokenSignature(key, string(p))) {
+		return "", "", errInvalidSignedToken
+	}
+
+	parts := strings.Split(string(p), tokenSeparator)
+	if len(parts) != 4 || parts[0] != purpose {
+		return "", "", errInvalidSignedToken
+	}
+	exp, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil || !time.Now().Before(time.Unix(exp, 0)) {
+		return "", "", errInvalidSignedToken
+	}
+
+	return parts[1], parts[2], nil
+}
+
+func tokenSignature(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)
+}
@@ -3,6 +3,7 @@ package ws
 import (
 	"context"
 	"fmt"
+	"time"
 
 	grpcChannelsV1 "github.com/hantdev/mitras/internal/grpc/channels/v1"
 	grpcClientsV1 "github.com/hantdev/mitras/internal/grpc/clients/v1"
@@ -35,6 +36,16 @@ type Service interface {
 	// and the channelID for subscription. Subtopic is optional.
 	// If the subscription is successful, nil is returned otherwise error is returned.
 	Subscribe(ctx context.Context, clientKey, chanID, subtopic string, client *Client) error
+
+	// Unsubscribe unsubscribes the client from the channel and subtopic.
+	Unsubscribe(ctx context.Context, chanID, subtopic string, client *Client) error
+
+	// Publish publishes the message to the channel using the clientKey for
+	// authorization.
+	Publish(ctx context.Context, clientKey string, msg *messaging.Message) error
+
+	// Authenticate authenticates the clientKey and returns the client ID.
+	Authenticate(ctx context.Context, clientKey string) (string, error)
 }
 
 var _ Service = (*adapterService)(nil)
@@ -64,16 +75,15 @@ func (svc *adapterService) Subscribe(ctx context.Context, clientKey, chanID, sub
 		return svcerr.ErrAuthorization
 	}
 
-	c.id = clientID
-
-	subject := fmt.Sprintf("%s.%s", chansPrefix, chanID)
-	if subtopic != "" {
-		subject = fmt.Sprintf("%s.%s", subject, subtopic)
+	// The client ID is set once, since it's read by the client handling
+	// messages of the previous subscriptions of the multiplexed connection.
+	if c.id == "" {
+		c.id = clientID
 	}
 
 	subCfg := messaging.SubscriberConfig{
-		ID:      clientID,
-		Topic:   subject,
+		ID:      c.subscriberID(),
+		Topic:   subject(chanID, subtopic),
 		Handler: c,
 	}
 	if err := svc.pubsub.Subscribe(ctx, subCfg); err != nil {
@@ -83,6 +93,64 @@ func (svc *adapterService) Subscribe(ctx context.Context, clientKey, chanID, sub
 	return nil
 }
 
+func (svc *adapterService) Unsubscribe(ctx context.Context, chanID, subtopic string, c *Client) error {
+	if chanID == "" {
+		return ErrEmptyTopic
+	}
+
+	if err := svc.pubsub.Unsubscribe(ctx, c.subscriberID(), subject(chanID, subtopic)); err != nil {
+		return errors.Wrap(errFailedUnsubscribe, err)
+	}
+
+	return nil
+}
+
+func (svc *adapterService) Publish(ctx context.Context, clientKey string, msg *messaging.Message) error {
+	if msg.GetChannel() == "" || clientKey == "" {
+		return svcerr.ErrAuthentication
+	}
+
+	clientID, err := svc.authorize(ctx, clientKey, msg.GetChannel(), connections.Publish)
+	if err != nil {
+		return svcerr.ErrAuthorization
+	}
+
+	msg.Protocol = protocol
+	msg.Publisher = clientID
+	msg.Created = time.Now().UnixNano()
+	if err := svc.pubsub.Publish(ctx, msg.GetChannel(), msg); err != nil {
+		return errors.Wrap(errFailedMessagePublish, err)
+	}
+
+	return nil
+}
+
+func (svc *adapterService) Authenticate(ctx context.Context, clientKey string) (string, error) {
+	if clientKey == "" {
+		return "", svcerr.ErrAuthentication
+	}
+
+	authnRes, err := svc.clients.Authenticate(ctx, &grpcClientsV1.AuthnReq{
+		ClientSecret: clientKey,
+	})
+	if err != nil {
+		return "", errors.Wrap(svcerr.ErrAuthentication, err)
+	}
+	if !authnRes.GetAuthenticated() {
+		return "", svcerr.ErrAuthentication
+	}
+
+	return authnRes.GetId(), nil
+}
+
+func subject(chanID, subtopic string) string {
+	subject := fmt.Sprintf("%s.%s", chansPrefix, chanID)
+	if subtopic != "" {
+		subject = fmt.Sprintf("%s.%s", subject, subtopic)
+	}
+	return subject
+}
+
 // authorize checks if the clientKey is authorized to access the channel
 // and returns the clientID if it is.
 func (svc *adapterService) authorize(ctx context.Context, clientKey, chanID string, msgType connections.ConnType) (string, error) {
@@ -11,6 +11,7 @@ import (
 	grpcClientsV1 "github.com/hantdev/mitras/internal/grpc/clients/v1"
 	"github.com/hantdev/mitras/internal/testsutil"
 	"github.com/hantdev/mitras/pkg/connections"
+	"github.com/hantdev/mitras/pkg/errors"
 	svcerr "github.com/hantdev/mitras/pkg/errors/service"
 	"github.com/hantdev/mitras/pkg/messaging"
 	"github.com/hantdev/mitras/pkg/messaging/mocks"
@@ -174,3 +175,180 @@ func TestSubscribe(t *testing.T) {
 		channelsCall.Unset()
 	}
 }
+
+func TestUnsubscribe(t *testing.T) {
+	svc, pubsub, _, _ := newService()
+
+	c := ws.NewClient(nil)
+
+	cases := []struct {
+		desc     string
+		chanID   string
+		subtopic string
+		unsubErr error
+		err      error
+	}{
+		{
+			desc:     "unsubscribe from channel with subtopic",
+			chanID:   chanID,
+			subtopic: subTopic,
+			err:      nil,
+		},
+		{
+			desc:   "unsubscribe from channel without subtopic",
+			chanID: chanID,
+			err:    nil,
+		},
+		{
+			desc:     "unsubscribe from empty channel",
+			chanID:   "",
+			subtopic: subTopic,
+			err:      ws.ErrEmptyTopic,
+		},
+		{
+			desc:     "unsubscribe from channel with unsubscribe set to fail",
+			chanID:   chanID,
+			subtopic: subTopic,
+			unsubErr: errors.New("failed to unsubscribe"),
+			err:      errors.New("failed to unsubscribe from a channel"),
+		},
+	}
+
+	for _, tc := range cases {
+		topic := "channels." + tc.chanID
+		if tc.subtopic != "" {
+			topic += "." + tc.subtopic
+		}
+		repocall := pubsub.On("Unsubscribe", mock.Anything, "", topic).Return(tc.unsubErr)
+		err := svc.Unsubscribe(context.Background(), tc.chanID, tc.subtopic, c)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		repocall.Unset()
+	}
+}
+
+func TestPublish(t *testing.T) {
+	svc, pubsub, clients, channels := newService()
+
+	cases := []struct {
+		desc      string
+		clientKey string
+		msg       *messaging.Message
+		authNRes  *grpcClientsV1.AuthnRes
+		authNErr  error
+		authZRes  *grpcChannelsV1.AuthzRes
+		authZErr  error
+		pubErr    error
+		err       error
+	}{
+		{
+			desc:      "publish message with valid clientKey and channel",
+			clientKey: clientKey,
+			msg:       &messaging.Message{Channel: chanID, Subtopic: subTopic, Payload: msg.Payload},
+			authNRes:  &grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true},
+			authZRes:  &grpcChannelsV1.AuthzRes{Authorized: true},
+			err:       nil,
+		},
+		{
+			desc:      "publish message with empty clientKey",
+			clientKey: "",
+			msg:       &messaging.Message{Channel: chanID, Payload: msg.Payload},
+			err:       svcerr.ErrAuthentication,
+		},
+		{
+			desc:      "publish message to empty channel",
+			clientKey: clientKey,
+			msg:       &messaging.Message{Payload: msg.Payload},
+			err:       svcerr.ErrAuthentication,
+		},
+		{
+			desc:      "publish message with invalid clientKey",
+			clientKey: invalidKey,
+			msg:       &messaging.Message{Channel: chanID, Payload: msg.Payload},
+			authNRes:  &grpcClientsV1.AuthnRes{Authenticated: false},
+			authNErr:  svcerr.ErrAuthentication,
+			err:       svcerr.ErrAuthorization,
+		},
+		{
+			desc:      "publish message with failed authorization",
+			clientKey: clientKey,
+			msg:       &messaging.Message{Channel: chanID, Payload: msg.Payload},
+			authNRes:  &grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true},
+			authZRes:  &grpcChannelsV1.AuthzRes{Authorized: false},
+			err:       svcerr.ErrAuthorization,
+		},
+		{
+			desc:      "publish message with publish set to fail",
+			clientKey: clientKey,
+			msg:       &messaging.Message{Channel: chanID, Payload: msg.Payload},
+			authNRes:  &grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true},
+			authZRes:  &grpcChannelsV1.AuthzRes{Authorized: true},
+			pubErr:    errors.New("failed to publish"),
+			err:       errors.New("failed to publish message"),
+		},
+	}
+
+	for _, tc := range cases {
+		clientsCall := clients.On("Authenticate", mock.Anything, &grpcClientsV1.AuthnReq{ClientSecret: tc.clientKey}).Return(tc.authNRes, tc.authNErr)
+		channelsCall := channels.On("Authorize", mock.Anything, &grpcChannelsV1.AuthzReq{
+			ClientType: policies.ClientType,
+			ClientId:   tc.authNRes.GetId(),
+			Type:       uint32(connections.Publish),
+			ChannelId:  tc.msg.GetChannel(),
+		}).Return(tc.authZRes, tc.authZErr)
+		repocall := pubsub.On("Publish", mock.Anything, tc.msg.GetChannel(), tc.msg).Return(tc.pubErr)
+		err := svc.Publish(context.Background(), tc.clientKey, tc.msg)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, clientID, tc.msg.GetPublisher(), fmt.Sprintf("%s: got unexpected publisher", tc.desc))
+		}
+		repocall.Unset()
+		clientsCall.Unset()
+		channelsCall.Unset()
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	svc, _, clients, _ := newService()
+
+	cases := []struct {
+		desc      string
+		clientKey string
+		authNRes  *grpcClientsV1.AuthnRes
+		authNErr  error
+		id        string
+		err       error
+	}{
+		{
+			desc:      "authenticate with valid clientKey",
+			clientKey: clientKey,
+			authNRes:  &grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true},
+			id:        clientID,
+			err:       nil,
+		},
+		{
+			desc:      "authenticate with empty clientKey",
+			clientKey: "",
+			err:       svcerr.ErrAuthentication,
+		},
+		{
+			desc:      "authenticate with invalid clientKey",
+			clientKey: invalidKey,
+			authNRes:  &grpcClientsV1.AuthnRes{Authenticated: false},
+			err:       svcerr.ErrAuthentication,
+		},
+		{
+			desc:      "authenticate with failed authentication",
+			clientKey: invalidKey,
+			authNErr:  svcerr.ErrAuthentication,
+			err:       svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		clientsCall := clients.On("Authenticate", mock.Anything, &grpcClientsV1.AuthnReq{ClientSecret: tc.clientKey}).Return(tc.authNRes, tc.authNErr)
+		id, err := svc.Authenticate(context.Background(), tc.clientKey)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.id, id, fmt.Sprintf("%s: expected id %s got %s\n", tc.desc, tc.id, id))
+		clientsCall.Unset()
+	}
+}
@@ -19,6 +19,7 @@ import (
 	smqlog "github.com/hantdev/mitras/logger"
 	smqauthn "github.com/hantdev/mitras/pkg/authn"
 	authnMocks "github.com/hantdev/mitras/pkg/authn/mocks"
+	"github.com/hantdev/mitras/pkg/messaging"
 	"github.com/hantdev/mitras/pkg/messaging/mocks"
 	"github.com/hantdev/mitras/ws"
 	"github.com/hantdev/mitras/ws/api"
@@ -214,3 +215,163 @@ func TestHandshake(t *testing.T) {
 		})
 	}
 }
+
+func TestMultiplexed(t *testing.T) {
+	clients := new(climocks.ClientsServiceClient)
+	channels := new(chmocks.ChannelsServiceClient)
+	svc, pubsub := newService(clients, channels)
+	target := newHTTPServer(svc)
+	defer target.Close()
+
+	handlers := make(chan messaging.MessageHandler, 2)
+	pubsub.On("Subscribe", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		handlers <- args.Get(1).(messaging.SubscriberConfig).Handler
+	}).Return(nil)
+	pubsub.On("Unsubscribe", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	pubsub.On("Publish", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	clients.On("Authenticate", mock.Anything, &grpcClientsV1.AuthnReq{ClientSecret: clientKey}).Return(&grpcClientsV1.AuthnRes{Id: id, Authenticated: true}, nil)
+	clients.On("Authenticate", mock.Anything, mock.Anything).Return(&grpcClientsV1.AuthnRes{Authenticated: false}, nil)
+	channels.On("Authorize", mock.Anything, mock.Anything).Return(&grpcChannelsV1.AuthzRes{Authorized: true}, nil)
+
+	u, err := url.Parse(target.URL)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	u.Scheme = protocol
+	u.Path = ws.MultiplexPath
+
+	handshakeCases := []struct {
+		desc         string
+		subprotocols []string
+		clientKey    string
+		status       int
+	}{
+		{
+			desc:         "connect with multiplexed subprotocol",
+			subprotocols: []string{ws.Subprotocol},
+			clientKey:    clientKey,
+			status:       http.StatusSwitchingProtocols,
+		},
+		{
+			desc:      "connect without multiplexed subprotocol",
+			clientKey: clientKey,
+			status:    http.StatusBadRequest,
+		},
+		{
+			desc:         "connect with empty clientKey",
+			subprotocols: []string{ws.Subprotocol},
+			status:       http.StatusForbidden,
+		},
+		{
+			desc:         "connect with invalid clientKey",
+			subprotocols: []string{ws.Subprotocol},
+			clientKey:    "invalid",
+			status:       http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range handshakeCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dialer := websocket.Dialer{Subprotocols: tc.subprotocols}
+			header := http.Header{}
+			if tc.clientKey != "" {
+				header.Add("Authorization", tc.clientKey)
+			}
+			conn, res, err := dialer.Dial(u.String(), header)
+			assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code '%d' got '%d'\n", tc.desc, tc.status, res.StatusCode))
+			if tc.status == http.StatusSwitchingProtocols {
+				assert.Nil(t, err, fmt.Sprintf("%s: got unexpected error %s\n", tc.desc, err))
+				assert.Equal(t, ws.Subprotocol, conn.Subprotocol(), fmt.Sprintf("%s: got unexpected subprotocol", tc.desc))
+				conn.Close()
+			}
+		})
+	}
+
+	dialer := websocket.Dialer{Subprotocols: []string{ws.Subprotocol}}
+	conn, _, err := dialer.Dial(u.String()+"?authorization="+clientKey, nil)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	defer conn.Close()
+
+	frameCases := []struct {
+		desc  string
+		frame ws.Frame
+		res   ws.Frame
+	}{
+		{
+			desc:  "subscribe to channel",
+			frame: ws.Frame{Type: ws.FrameSubscribe, ID: "1", Channel: chanID},
+			res:   ws.Frame{Type: ws.FrameAck, ID: "1"},
+		},
+		{
+			desc:  "subscribe to channel subtopic",
+			frame: ws.Frame{Type: ws.FrameSubscribe, ID: "2", Channel: chanID, Subtopic: "subtopic/nested"},
+			res:   ws.Frame{Type: ws.FrameAck, ID: "2"},
+		},
+		{
+			desc:  "subscribe again to channel",
+			frame: ws.Frame{Type: ws.FrameSubscribe, ID: "3", Channel: chanID},
+			res:   ws.Frame{Type: ws.FrameError, ID: "3", Error: "already subscribed to channel"},
+		},
+		{
+			desc:  "subscribe to empty channel",
+			frame: ws.Frame{Type: ws.FrameSubscribe, ID: "4"},
+			res:   ws.Frame{Type: ws.FrameError, ID: "4", Error: ws.ErrEmptyTopic.Error()},
+		},
+		{
+			desc:  "subscribe to channel with malformed subtopic",
+			frame: ws.Frame{Type: ws.FrameSubscribe, ID: "5", Channel: chanID, Subtopic: "sub/a*b/topic"},
+			res:   ws.Frame{Type: ws.FrameError, ID: "5", Error: "malformed subtopic"},
+		},
+		{
+			desc:  "publish message",
+			frame: ws.Frame{Type: ws.FramePublish, ID: "6", Channel: chanID, Payload: msg},
+			res:   ws.Frame{Type: ws.FrameAck, ID: "6"},
+		},
+		{
+			desc:  "publish empty message",
+			frame: ws.Frame{Type: ws.FramePublish, ID: "7", Channel: chanID},
+			res:   ws.Frame{Type: ws.FrameError, ID: "7", Error: "empty payload"},
+		},
+		{
+			desc:  "unsubscribe from channel subtopic",
+			frame: ws.Frame{Type: ws.FrameUnsubscribe, ID: "8", Channel: chanID, Subtopic: "subtopic.nested"},
+			res:   ws.Frame{Type: ws.FrameAck, ID: "8"},
+		},
+		{
+			desc:  "unsubscribe from channel that is not subscribed",
+			frame: ws.Frame{Type: ws.FrameUnsubscribe, ID: "9", Channel: chanID, Subtopic: "other"},
+			res:   ws.Frame{Type: ws.FrameError, ID: "9", Error: "not subscribed to channel"},
+		},
+		{
+			desc:  "send frame of unknown type",
+			frame: ws.Frame{Type: "unknown", ID: "10", Channel: chanID},
+			res:   ws.Frame{Type: ws.FrameError, ID: "10", Error: "unknown frame type"},
+		},
+	}
+
+	for _, tc := range frameCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := conn.WriteJSON(tc.frame)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			var res ws.Frame
+			err = conn.ReadJSON(&res)
+			require.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, tc.res, res, fmt.Sprintf("%s: got unexpected response frame", tc.desc))
+		})
+	}
+
+	err = conn.WriteMessage(websocket.TextMessage, []byte("invalid"))
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	var res ws.Frame
+	err = conn.ReadJSON(&res)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	assert.Equal(t, ws.Frame{Type: ws.FrameError, Error: "malformed frame"}, res, "got unexpected response to malformed frame")
+
+	// Messages of the subscribed channels are delivered using message frames.
+	handler := <-handlers
+	err = handler.Handle(&messaging.Message{Channel: chanID, Subtopic: "subtopic", Publisher: "other", Created: 1, Payload: msg})
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	var frame ws.Frame
+	err = conn.ReadJSON(&frame)
+	require.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+	expected := ws.Frame{Type: ws.FrameMessage, Channel: chanID, Subtopic: "subtopic", Publisher: "other", Created: 1, Payload: msg}
+	assert.Equal(t, expected, frame, "got unexpected message frame")
+}
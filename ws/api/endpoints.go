@@ -6,9 +6,12 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/hantdev/mitras"
 	"github.com/hantdev/mitras/pkg/errors"
 	"github.com/hantdev/mitras/ws"
 )
@@ -39,17 +42,61 @@ func handshake(ctx context.Context, svc ws.Service) http.HandlerFunc {
 	}
 }
 
-func decodeRequest(r *http.Request) (connReq, error) {
+// multiplexHandshake upgrades the authenticated connection using the
+// multiplexed websocket subprotocol and serves its frames.
+func multiplexHandshake(ctx context.Context, svc ws.Service, idProvider mitras.IDProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(websocket.Subprotocols(r), ws.Subprotocol) {
+			encodeError(w, errMissingSubprotocol)
+			return
+		}
+		authKey, err := decodeAuthKey(r)
+		if err != nil {
+			encodeError(w, err)
+			return
+		}
+		if _, err := svc.Authenticate(ctx, authKey); err != nil {
+			encodeError(w, errUnauthorizedAccess)
+			return
+		}
+		sessionID, err := idProvider.ID()
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Failed to generate websocket session ID: %s", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		conn, err := multiplexUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Failed to upgrade connection to websocket: %s", err.Error()))
+			return
+		}
+
+		logger.Debug(fmt.Sprintf("Successfully upgraded communication to multiplexed WS with session %s", sessionID))
+		s := newSession(svc, authKey, conn, ws.NewMultiplexedClient(conn, sessionID))
+		s.serve(ctx)
+	}
+}
+
+func decodeAuthKey(r *http.Request) (string, error) {
 	authKey := r.Header.Get("Authorization")
 	if authKey == "" {
 		authKeys := r.URL.Query()["authorization"]
 		if len(authKeys) == 0 {
 			logger.Debug("Missing authorization key.")
-			return connReq{}, errUnauthorizedAccess
+			return "", errUnauthorizedAccess
 		}
 		authKey = authKeys[0]
 	}
 
+	return authKey, nil
+}
+
+func decodeRequest(r *http.Request) (connReq, error) {
+	authKey, err := decodeAuthKey(r)
+	if err != nil {
+		return connReq{}, err
+	}
+
 	chanID := chi.URLParam(r, "chanID")
 
 	req := connReq{
@@ -112,7 +159,7 @@ func encodeError(w http.ResponseWriter, err error) {
 		statusCode = http.StatusBadRequest
 	case errUnauthorizedAccess:
 		statusCode = http.StatusForbidden
-	case errMalformedSubtopic, errors.ErrMalformedEntity:
+	case errMalformedSubtopic, errors.ErrMalformedEntity, errMissingSubprotocol:
 		statusCode = http.StatusBadRequest
 	default:
 		statusCode = http.StatusNotFound
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/hantdev/mitras/pkg/messaging"
 	"github.com/hantdev/mitras/ws"
 )
 
@@ -41,3 +42,66 @@ func (lm *loggingMiddleware) Subscribe(ctx context.Context, clientKey, chanID, s
 
 	return lm.svc.Subscribe(ctx, clientKey, chanID, subtopic, c)
 }
+
+// Unsubscribe logs the unsubscribe request. It logs the channel and subtopic(if present) and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) Unsubscribe(ctx context.Context, chanID, subtopic string, c *ws.Client) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("channel_id", chanID),
+		}
+		if subtopic != "" {
+			args = append(args, "subtopic", subtopic)
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Unsubscribe failed", args...)
+			return
+		}
+		lm.logger.Info("Unsubscribe completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Unsubscribe(ctx, chanID, subtopic, c)
+}
+
+// Publish logs the publish request. It logs the channel and subtopic(if present) and the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) Publish(ctx context.Context, clientKey string, msg *messaging.Message) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("channel_id", msg.GetChannel()),
+		}
+		if msg.GetSubtopic() != "" {
+			args = append(args, "subtopic", msg.GetSubtopic())
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Publish failed", args...)
+			return
+		}
+		lm.logger.Info("Publish completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Publish(ctx, clientKey, msg)
+}
+
+// Authenticate logs the authenticate request. It logs the time it took to complete the request.
+// If the request fails, it logs the error.
+func (lm *loggingMiddleware) Authenticate(ctx context.Context, clientKey string) (id string, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Authenticate failed", args...)
+			return
+		}
+		args = append(args, slog.String("client_id", id))
+		lm.logger.Info("Authenticate completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.Authenticate(ctx, clientKey)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/go-kit/kit/metrics"
+	"github.com/hantdev/mitras/pkg/messaging"
 	"github.com/hantdev/mitras/ws"
 )
 
@@ -36,3 +37,33 @@ func (mm *metricsMiddleware) Subscribe(ctx context.Context, clientKey, chanID, s
 
 	return mm.svc.Subscribe(ctx, clientKey, chanID, subtopic, c)
 }
+
+// Unsubscribe instruments Unsubscribe method with metrics.
+func (mm *metricsMiddleware) Unsubscribe(ctx context.Context, chanID, subtopic string, c *ws.Client) error {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "unsubscribe").Add(1)
+		mm.latency.With("method", "unsubscribe").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.Unsubscribe(ctx, chanID, subtopic, c)
+}
+
+// Publish instruments Publish method with metrics.
+func (mm *metricsMiddleware) Publish(ctx context.Context, clientKey string, msg *messaging.Message) error {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "publish").Add(1)
+		mm.latency.With("method", "publish").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.Publish(ctx, clientKey, msg)
+}
+
+// Authenticate instruments Authenticate method with metrics.
+func (mm *metricsMiddleware) Authenticate(ctx context.Context, clientKey string) (string, error) {
+	defer func(begin time.Time) {
+		mm.counter.With("method", "authenticate").Add(1)
+		mm.latency.With("method", "authenticate").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return mm.svc.Authenticate(ctx, clientKey)
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hantdev/mitras/pkg/errors"
+	"github.com/hantdev/mitras/pkg/messaging"
+	"github.com/hantdev/mitras/ws"
+)
+
+const (
+	// pongWait is the time allowed to read the next pong from the client.
+	pongWait = 60 * time.Second
+	// pingPeriod is the period of the keepalive pings, shorter than pongWait.
+	pingPeriod = pongWait * 9 / 10
+	// writeWait is the time allowed to write the ping to the client.
+	writeWait = 10 * time.Second
+	// maxFrameSize is the maximum size of the frame read from the client.
+	maxFrameSize = 1 << 20
+)
+
+var (
+	errMalformedFrame    = errors.New("malformed frame")
+	errUnknownFrameType  = errors.New("unknown frame type")
+	errEmptyPayload      = errors.New("empty payload")
+	errNotSubscribed     = errors.New("not subscribed to channel")
+	errAlreadySubscribed = errors.New("already subscribed to channel")
+)
+
+type subscription struct {
+	chanID   string
+	subtopic string
+}
+
+// session serves the frames of the multiplexed websocket connection. Frames
+// are handled in order, and the connection is unsubscribed from all the
+// channels when it's closed.
+type session struct {
+	svc           ws.Service
+	clientKey     string
+	conn          *websocket.Conn
+	client        *ws.Client
+	subscriptions map[subscription]struct{}
+}
+
+func newSession(svc ws.Service, clientKey string, conn *websocket.Conn, client *ws.Client) *session {
+	return &session{
+		svc:           svc,
+		clientKey:     clientKey,
+		conn:          conn,
+		client:        client,
+		subscriptions: make(map[subscription]struct{}),
+	}
+}
+
+func (s *session) serve(ctx context.Context) {
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		s.close(ctx)
+	}()
+
+	s.conn.SetReadLimit(maxFrameSize)
+	_ = s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	go s.keepalive(done)
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.Debug(fmt.Sprintf("Multiplexed websocket connection closed: %s", err))
+			}
+			return
+		}
+
+		var f ws.Frame
+		if err := json.Unmarshal(data, &f); err != nil {
+			err = s.reply(f, errMalformedFrame)
+		} else {
+			err = s.reply(f, s.handle(ctx, f))
+		}
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Failed to write multiplexed websocket frame: %s", err))
+			return
+		}
+	}
+}
+
+func (s *session) handle(ctx context.Context, f ws.Frame) error {
+	switch f.Type {
+	case ws.FrameSubscribe, ws.FrameUnsubscribe, ws.FramePublish:
+	default:
+		return errUnknownFrameType
+	}
+	if f.Channel == "" {
+		return ws.ErrEmptyTopic
+	}
+	subtopic, err := parseSubTopic(f.Subtopic)
+	if err != nil {
+		return err
+	}
+	sub := subscription{chanID: f.Channel, subtopic: subtopic}
+
+	switch f.Type {
+	case ws.FrameSubscribe:
+		if _, ok := s.subscriptions[sub]; ok {
+			return errAlreadySubscribed
+		}
+		if err := s.svc.Subscribe(ctx, s.clientKey, f.Channel, subtopic, s.client); err != nil {
+			return err
+		}
+		s.subscriptions[sub] = struct{}{}
+	case ws.FrameUnsubscribe:
+		if _, ok := s.subscriptions[sub]; !ok {
+			return errNotSubscribed
+		}
+		if err := s.svc.Unsubscribe(ctx, f.Channel, subtopic, s.client); err != nil {
+			return err
+		}
+		delete(s.subscriptions, sub)
+	case ws.FramePublish:
+		if len(f.Payload) == 0 {
+			return errEmptyPayload
+		}
+		msg := &messaging.Message{
+			Channel:  f.Channel,
+			Subtopic: subtopic,
+			Payload:  f.Payload,
+		}
+		if err := s.svc.Publish(ctx, s.clientKey, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reply acknowledges the frame or reports its error.
+func (s *session) reply(f ws.Frame, err error) error {
+	res := ws.Frame{
+		Type: ws.FrameAck,
+		ID:   f.ID,
+	}
+	if err != nil {
+		res.Type = ws.FrameError
+		res.Error = err.Error()
+	}
+
+	return s.client.WriteFrame(res)
+}
+
+func (s *session) keepalive(done chan struct{}) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := s.client.Ping(writeWait); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *session) close(ctx context.Context) {
+	for sub := range s.subscriptions {
+		if err := s.svc.Unsubscribe(ctx, sub.chanID, sub.subtopic, s.client); err != nil {
+			logger.Warn(fmt.Sprintf("Failed to unsubscribe multiplexed websocket connection from channel %s: %s", sub.chanID, err))
+		}
+	}
+	if err := s.client.Cancel(); err != nil {
+		logger.Debug(fmt.Sprintf("Failed to close multiplexed websocket connection: %s", err))
+	}
+}
@@ -6,9 +6,10 @@ import (
 	"log/slog"
 	"net/http"
 
-	"github.com/hantdev/mitras"
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
+	"github.com/hantdev/mitras"
+	"github.com/hantdev/mitras/pkg/uuid"
 	"github.com/hantdev/mitras/ws"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -21,6 +22,7 @@ const (
 var (
 	errUnauthorizedAccess = errors.New("missing or invalid credentials provided")
 	errMalformedSubtopic  = errors.New("malformed subtopic")
+	errMissingSubprotocol = errors.New("missing multiplexed websocket subprotocol")
 )
 
 var (
@@ -29,6 +31,12 @@ var (
 		WriteBufferSize: readwriteBufferSize,
 		CheckOrigin:     func(r *http.Request) bool { return true },
 	}
+	multiplexUpgrader = websocket.Upgrader{
+		ReadBufferSize:  readwriteBufferSize,
+		WriteBufferSize: readwriteBufferSize,
+		Subprotocols:    []string{ws.Subprotocol},
+		CheckOrigin:     func(r *http.Request) bool { return true },
+	}
 	logger *slog.Logger
 )
 
@@ -39,6 +47,7 @@ func MakeHandler(ctx context.Context, svc ws.Service, l *slog.Logger, instanceID
 	mux := chi.NewRouter()
 	mux.Get("/channels/{chanID}/messages", handshake(ctx, svc))
 	mux.Get("/channels/{chanID}/messages/*", handshake(ctx, svc))
+	mux.Get(ws.MultiplexPath, multiplexHandshake(ctx, svc, uuid.New()))
 
 	mux.Get("/health", mitras.Health(service, instanceID))
 	mux.Handle("/metrics", promhttp.Handler())
@@ -1,14 +1,20 @@
 package ws
 
 import (
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/gorilla/websocket"
 	"github.com/hantdev/mitras/pkg/messaging"
 )
 
 // Client handles messaging and websocket connection.
 type Client struct {
-	conn *websocket.Conn
-	id   string
+	conn    *websocket.Conn
+	id      string
+	session string
+	mu      sync.Mutex
 }
 
 // NewClient returns a new websocket client.
@@ -19,6 +25,17 @@ func NewClient(c *websocket.Conn) *Client {
 	}
 }
 
+// NewMultiplexedClient returns a new websocket client of the multiplexed
+// connection identified by the session ID. Messages are delivered to the
+// client using message frames.
+func NewMultiplexedClient(c *websocket.Conn, session string) *Client {
+	return &Client{
+		conn:    c,
+		id:      "",
+		session: session,
+	}
+}
+
 // Cancel handles the websocket connection after unsubscribing.
 func (c *Client) Cancel() error {
 	if c.conn == nil {
@@ -34,5 +51,40 @@ func (c *Client) Handle(msg *messaging.Message) error {
 		return nil
 	}
 
+	if c.session != "" {
+		return c.WriteFrame(Frame{
+			Type:      FrameMessage,
+			Channel:   msg.GetChannel(),
+			Subtopic:  msg.GetSubtopic(),
+			Publisher: msg.GetPublisher(),
+			Created:   msg.GetCreated(),
+			Payload:   msg.GetPayload(),
+		})
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.conn.WriteMessage(websocket.TextMessage, msg.GetPayload())
 }
+
+// WriteFrame writes the frame of the multiplexed protocol to the connection.
+func (c *Client) WriteFrame(f Frame) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteJSON(f)
+}
+
+// Ping sends the keepalive ping to the connection.
+func (c *Client) Ping(timeout time.Duration) error {
+	return c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(timeout))
+}
+
+// subscriberID returns the ID of the client subscriptions. Subscriptions of
+// the multiplexed connections are distinguished by the session ID, so the
+// same client can use multiple connections.
+func (c *Client) subscriberID() string {
+	if c.session == "" {
+		return c.id
+	}
+	return fmt.Sprintf("%s-%s", c.id, c.session)
+}
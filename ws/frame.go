@@ -0,0 +1,39 @@
+package ws
+
+const (
+	// MultiplexPath is the path of the multiplexed WebSocket endpoint.
+	MultiplexPath = "/ws"
+	// Subprotocol is the WebSocket subprotocol of the multiplexed connections.
+	Subprotocol = "mitras.ws.v1"
+)
+
+// Frame types of the multiplexed WebSocket protocol.
+const (
+	// FrameSubscribe subscribes the connection to the channel and subtopic.
+	FrameSubscribe = "subscribe"
+	// FrameUnsubscribe unsubscribes the connection from the channel and subtopic.
+	FrameUnsubscribe = "unsubscribe"
+	// FramePublish publishes the payload to the channel and subtopic.
+	FramePublish = "publish"
+	// FrameAck acknowledges the frame with the same ID.
+	FrameAck = "ack"
+	// FrameError reports the failure of the frame with the same ID.
+	FrameError = "error"
+	// FrameMessage delivers the message received on the subscribed channel.
+	FrameMessage = "message"
+)
+
+// Frame is the JSON frame of the multiplexed WebSocket protocol. Clients send
+// subscribe, unsubscribe and publish frames, which are answered by the ack or
+// the error frame with the same ID. Messages of the subscribed channels are
+// delivered using message frames. Payload is encoded as base64 string.
+type Frame struct {
+	Type      string `json:"type"`
+	ID        string `json:"id,omitempty"`
+	Channel   string `json:"channel,omitempty"`
+	Subtopic  string `json:"subtopic,omitempty"`
+	Publisher string `json:"publisher,omitempty"`
+	Created   int64  `json:"created,omitempty"`
+	Payload   []byte `json:"payload,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
@@ -45,6 +45,7 @@ var (
 	errFailedPublish            = errors.New("failed to publish")
 	errFailedParseSubtopic      = errors.New("failed to parse subtopic")
 	errFailedPublishToMsgBroker = errors.New("failed to publish to mitras message broker")
+	errReadOnly                 = errors.New("read-only impersonation can't publish")
 )
 
 var channelRegExp = regexp.MustCompile(`^\/?channels\/([\w\-]+)\/messages(\/[^?]*)?(\?.*)?$`)
@@ -81,6 +82,9 @@ func (h *handler) AuthPublish(ctx context.Context, topic *string, payload *[]byt
 	if topic == nil {
 		return errMissingTopicPub
 	}
+	if isMultiplexed(*topic) {
+		return nil
+	}
 	s, ok := session.FromContext(ctx)
 	if !ok {
 		return errClientNotInitialized
@@ -117,6 +121,9 @@ func (h *handler) AuthSubscribe(ctx context.Context, topics *[]string) error {
 	}
 
 	for _, topic := range *topics {
+		if isMultiplexed(topic) {
+			continue
+		}
 		if err := h.authAccess(ctx, token, topic, connections.Subscribe); err != nil {
 			return err
 		}
@@ -136,6 +143,9 @@ func (h *handler) Publish(ctx context.Context, topic *string, payload *[]byte) e
 	if !ok {
 		return errors.Wrap(errFailedPublish, errClientNotInitialized)
 	}
+	if isMultiplexed(*topic) {
+		return nil
+	}
 	h.logger.Info(fmt.Sprintf(LogInfoPublished, s.ID, *topic))
 
 	if len(*payload) == 0 {
@@ -157,7 +167,7 @@ func (h *handler) Publish(ctx context.Context, topic *string, payload *[]byte) e
 		return errors.Wrap(errFailedParseSubtopic, err)
 	}
 
-	var clientID, clientType string
+	var clientID, clientType, impersonator string
 	switch {
 	case strings.HasPrefix(string(s.Password), "Client"):
 		clientKey := extractClientSecret(string(s.Password))
@@ -176,8 +186,12 @@ func (h *handler) Publish(ctx context.Context, topic *string, payload *[]byte) e
 		if err != nil {
 			return err
 		}
+		if authnSession.ReadOnly {
+			return errors.Wrap(svcerr.ErrAuthorization, errReadOnly)
+		}
 		clientType = policies.UserType
 		clientID = authnSession.DomainUserID
+		impersonator = authnSession.ImpersonatorID
 	}
 
 	ar := &grpcChannelsV1.AuthzReq{
@@ -195,11 +209,12 @@ func (h *handler) Publish(ctx context.Context, topic *string, payload *[]byte) e
 	}
 
 	msg := messaging.Message{
-		Protocol: protocol,
-		Channel:  chanID,
-		Subtopic: subtopic,
-		Payload:  *payload,
-		Created:  time.Now().UnixNano(),
+		Protocol:     protocol,
+		Channel:      chanID,
+		Subtopic:     subtopic,
+		Payload:      *payload,
+		Created:      time.Now().UnixNano(),
+		Impersonator: impersonator,
 	}
 
 	if clientType == policies.ClientType {
@@ -258,6 +273,9 @@ func (h *handler) authAccess(ctx context.Context, token, topic string, msgType c
 		if err != nil {
 			return err
 		}
+		if authnSession.ReadOnly && msgType == connections.Publish {
+			return errors.Wrap(svcerr.ErrAuthorization, errReadOnly)
+		}
 		clientType = policies.UserType
 		clientID = authnSession.DomainUserID
 	}
@@ -321,6 +339,14 @@ func parseSubtopic(subtopic string) (string, error) {
 	return subtopic, nil
 }
 
+// isMultiplexed returns true if the topic is the path of the multiplexed
+// connection. Frames of the multiplexed connections are authorized and
+// published by the adapter, since they carry the channels in the frames.
+func isMultiplexed(topic string) bool {
+	path, _, _ := strings.Cut(topic, "?")
+	return path == MultiplexPath
+}
+
 // extractClientSecret returns value of the client secret. If there is no client key - an empty value is returned.
 func extractClientSecret(topic string) string {
 	if !strings.HasPrefix(topic, apiutil.ClientPrefix) {
@@ -0,0 +1,201 @@
+package ws_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hantdev/hermina/pkg/session"
+	chmocks "github.com/hantdev/mitras/channels/mocks"
+	climocks "github.com/hantdev/mitras/clients/mocks"
+	grpcChannelsV1 "github.com/hantdev/mitras/internal/grpc/channels/v1"
+	grpcClientsV1 "github.com/hantdev/mitras/internal/grpc/clients/v1"
+	"github.com/hantdev/mitras/internal/testsutil"
+	smqlog "github.com/hantdev/mitras/logger"
+	"github.com/hantdev/mitras/pkg/apiutil"
+	smqauthn "github.com/hantdev/mitras/pkg/authn"
+	authnmocks "github.com/hantdev/mitras/pkg/authn/mocks"
+	"github.com/hantdev/mitras/pkg/errors"
+	svcerr "github.com/hantdev/mitras/pkg/errors/service"
+	"github.com/hantdev/mitras/pkg/messaging"
+	"github.com/hantdev/mitras/pkg/messaging/mocks"
+	"github.com/hantdev/mitras/ws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const validToken = "token"
+
+var (
+	userID         = testsutil.GenerateUUID(&testing.T{})
+	impersonatorID = testsutil.GenerateUUID(&testing.T{})
+	channelTopic   = fmt.Sprintf("channels/%s/messages", chanID)
+	tokenSession   = session.Session{Password: []byte(apiutil.BearerPrefix + validToken)}
+	clientSession  = session.Session{Password: []byte(apiutil.ClientPrefix + clientKey)}
+	errReadOnly    = errors.New("read-only impersonation can't publish")
+)
+
+func newHandler() (session.Handler, *mocks.PubSub, *authnmocks.Authentication, *climocks.ClientsServiceClient, *chmocks.ChannelsServiceClient) {
+	pubsub := new(mocks.PubSub)
+	authn := new(authnmocks.Authentication)
+	clients := new(climocks.ClientsServiceClient)
+	channels := new(chmocks.ChannelsServiceClient)
+
+	return ws.NewHandler(pubsub, smqlog.NewMock(), authn, clients, channels), pubsub, authn, clients, channels
+}
+
+func TestHandlerAuthPublish(t *testing.T) {
+	handler, _, authn, _, channels := newHandler()
+
+	cases := []struct {
+		desc     string
+		session  session.Session
+		authnRes smqauthn.Session
+		authnErr error
+		authZRes *grpcChannelsV1.AuthzRes
+		err      error
+	}{
+		{
+			desc:     "authorize publish with token",
+			session:  tokenSession,
+			authnRes: smqauthn.Session{DomainUserID: userID, UserID: userID},
+			authZRes: &grpcChannelsV1.AuthzRes{Authorized: true},
+			err:      nil,
+		},
+		{
+			desc:     "authorize publish with impersonation token",
+			session:  tokenSession,
+			authnRes: smqauthn.Session{DomainUserID: userID, UserID: userID, ImpersonatorID: impersonatorID},
+			authZRes: &grpcChannelsV1.AuthzRes{Authorized: true},
+			err:      nil,
+		},
+		{
+			desc:     "authorize publish with read-only impersonation token",
+			session:  tokenSession,
+			authnRes: smqauthn.Session{DomainUserID: userID, UserID: userID, ImpersonatorID: impersonatorID, ReadOnly: true},
+			authZRes: &grpcChannelsV1.AuthzRes{Authorized: true},
+			err:      errReadOnly,
+		},
+		{
+			desc:     "authorize publish with invalid token",
+			session:  tokenSession,
+			authnErr: svcerr.ErrAuthentication,
+			err:      svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := session.NewContext(context.Background(), &tc.session)
+			authnCall := authn.On("Authenticate", ctx, validToken).Return(tc.authnRes, tc.authnErr)
+			channelsCall := channels.On("Authorize", ctx, mock.Anything).Return(tc.authZRes, nil)
+			topic := channelTopic
+			err := handler.AuthPublish(ctx, &topic, &msg.Payload)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			authnCall.Unset()
+			channelsCall.Unset()
+		})
+	}
+}
+
+func TestHandlerAuthSubscribe(t *testing.T) {
+	handler, _, authn, _, channels := newHandler()
+
+	cases := []struct {
+		desc     string
+		authnRes smqauthn.Session
+		err      error
+	}{
+		{
+			desc:     "authorize subscribe with token",
+			authnRes: smqauthn.Session{DomainUserID: userID, UserID: userID},
+			err:      nil,
+		},
+		{
+			desc:     "authorize subscribe with read-only impersonation token",
+			authnRes: smqauthn.Session{DomainUserID: userID, UserID: userID, ImpersonatorID: impersonatorID, ReadOnly: true},
+			err:      nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := session.NewContext(context.Background(), &tokenSession)
+			authnCall := authn.On("Authenticate", ctx, validToken).Return(tc.authnRes, nil)
+			channelsCall := channels.On("Authorize", ctx, mock.Anything).Return(&grpcChannelsV1.AuthzRes{Authorized: true}, nil)
+			topics := []string{channelTopic}
+			err := handler.AuthSubscribe(ctx, &topics)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			authnCall.Unset()
+			channelsCall.Unset()
+		})
+	}
+}
+
+func TestHandlerPublish(t *testing.T) {
+	handler, pubsub, authn, clients, channels := newHandler()
+
+	cases := []struct {
+		desc         string
+		session      session.Session
+		authNRes     *grpcClientsV1.AuthnRes
+		authnRes     smqauthn.Session
+		publisher    string
+		impersonator string
+		err          error
+	}{
+		{
+			desc:      "publish with client key",
+			session:   clientSession,
+			authNRes:  &grpcClientsV1.AuthnRes{Id: clientID, Authenticated: true},
+			publisher: clientID,
+			err:       nil,
+		},
+		{
+			desc:     "publish with token",
+			session:  tokenSession,
+			authnRes: smqauthn.Session{DomainUserID: userID, UserID: userID},
+			err:      nil,
+		},
+		{
+			desc:         "publish with impersonation token",
+			session:      tokenSession,
+			authnRes:     smqauthn.Session{DomainUserID: userID, UserID: userID, ImpersonatorID: impersonatorID},
+			impersonator: impersonatorID,
+			err:          nil,
+		},
+		{
+			desc:     "publish with read-only impersonation token",
+			session:  tokenSession,
+			authnRes: smqauthn.Session{DomainUserID: userID, UserID: userID, ImpersonatorID: impersonatorID, ReadOnly: true},
+			err:      errReadOnly,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := session.NewContext(context.Background(), &tc.session)
+			clientsCall := clients.On("Authenticate", ctx, mock.Anything).Return(tc.authNRes, nil)
+			authnCall := authn.On("Authenticate", ctx, validToken).Return(tc.authnRes, nil)
+			channelsCall := channels.On("Authorize", ctx, mock.Anything).Return(&grpcChannelsV1.AuthzRes{Authorized: true}, nil)
+			var published *messaging.Message
+			pubsubCall := pubsub.On("Publish", ctx, chanID, mock.Anything).Run(func(args mock.Arguments) {
+				published = args.Get(2).(*messaging.Message)
+			}).Return(nil)
+			topic := channelTopic
+			err := handler.Publish(ctx, &topic, &msg.Payload)
+			assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+			switch tc.err {
+			case nil:
+				assert.Equal(t, tc.publisher, published.GetPublisher(), fmt.Sprintf("%s: got unexpected publisher", tc.desc))
+				assert.Equal(t, tc.impersonator, published.GetImpersonator(), fmt.Sprintf("%s: got unexpected impersonator", tc.desc))
+			default:
+				assert.Nil(t, published, fmt.Sprintf("%s: expected no published message", tc.desc))
+			}
+			clientsCall.Unset()
+			authnCall.Unset()
+			channelsCall.Unset()
+			pubsubCall.Unset()
+		})
+	}
+}
@@ -3,16 +3,19 @@ package tracing
 import (
 	"context"
 
+	"github.com/hantdev/mitras/pkg/messaging"
 	"github.com/hantdev/mitras/ws"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
 var _ ws.Service = (*tracingMiddleware)(nil)
 
 const (
-	publishOP     = "publish_op"
-	subscribeOP   = "subscribe_op"
-	unsubscribeOP = "unsubscribe_op"
+	publishOP      = "publish_op"
+	subscribeOP    = "subscribe_op"
+	unsubscribeOP  = "unsubscribe_op"
+	authenticateOP = "authenticate_op"
 )
 
 type tracingMiddleware struct {
@@ -35,3 +38,33 @@ func (tm *tracingMiddleware) Subscribe(ctx context.Context, clientKey, chanID, s
 
 	return tm.svc.Subscribe(ctx, clientKey, chanID, subtopic, client)
 }
+
+// Unsubscribe traces the "Unsubscribe" operation of the wrapped ws.Service.
+func (tm *tracingMiddleware) Unsubscribe(ctx context.Context, chanID, subtopic string, client *ws.Client) error {
+	ctx, span := tm.tracer.Start(ctx, unsubscribeOP, trace.WithAttributes(
+		attribute.String("channel_id", chanID),
+		attribute.String("subtopic", subtopic),
+	))
+	defer span.End()
+
+	return tm.svc.Unsubscribe(ctx, chanID, subtopic, client)
+}
+
+// Publish traces the "Publish" operation of the wrapped ws.Service.
+func (tm *tracingMiddleware) Publish(ctx context.Context, clientKey string, msg *messaging.Message) error {
+	ctx, span := tm.tracer.Start(ctx, publishOP, trace.WithAttributes(
+		attribute.String("channel_id", msg.GetChannel()),
+		attribute.String("subtopic", msg.GetSubtopic()),
+	))
+	defer span.End()
+
+	return tm.svc.Publish(ctx, clientKey, msg)
+}
+
+// Authenticate traces the "Authenticate" operation of the wrapped ws.Service.
+func (tm *tracingMiddleware) Authenticate(ctx context.Context, clientKey string) (string, error) {
+	ctx, span := tm.tracer.Start(ctx, authenticateOP)
+	defer span.End()
+
+	return tm.svc.Authenticate(ctx, clientKey)
+}